@@ -0,0 +1,284 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// AddBundle adds a rendered bundle to its package in a file-based catalog
+// directory, by rewriting the files that contain the package, its channels,
+// and the bundle.
+//
+// A new bundle is added to Channels, which are created if they do not exist,
+// with the replaces, skips and skipRange of its CSV, and it is written to
+// the file that defines its package.
+//
+// With OverwriteLatest, a bundle of the same name that is already in the
+// package is replaced in its file, like the --overwrite-latest of a SQLite
+// catalog, provided that it is the head of every channel that it is in. Its
+// entry in each of those channels is rebuilt from the replaces, skips and
+// skipRange of its new CSV, and it is also added to those of Channels that
+// it is not in yet. A bundle without a CSV keeps its entries. The previous
+// content of the bundle is returned, and is recorded in AuditDir, if set.
+//
+// The package is validated with the added bundle before any file is
+// rewritten, so that a bundle that breaks the upgrade graph of a channel,
+// e.g. by not replacing its head, is not added.
+//
+// With DryRun, the bundle is validated, and the impact of adding it on the
+// upgrade graphs of the package is returned, but no file is written.
+type AddBundle struct {
+	CatalogDir string
+	// Bundle is the rendered bundle to add, e.g. by Render.
+	Bundle          declcfg.Bundle
+	Channels        []string
+	OverwriteLatest bool
+	// AuditDir is the directory in which the previous content of an
+	// overwritten bundle is written, as a file-based catalog file named
+	// after the bundle and the time it was overwritten. It should not be
+	// within CatalogDir.
+	AuditDir string
+	DryRun   bool
+}
+
+type AddBundleResult struct {
+	Package string `json:"package"`
+	Bundle  string `json:"bundle"`
+	// File is the file of the catalog directory that the bundle was
+	// written to.
+	File     string   `json:"file"`
+	Channels []string `json:"channels,omitempty"`
+	// Previous is the content of the bundle that was overwritten, if any,
+	// and AuditFile the file that it was recorded in.
+	Previous  *declcfg.Bundle `json:"previous,omitempty"`
+	AuditFile string          `json:"auditFile,omitempty"`
+	// Impact is the impact of adding the bundle on the upgrade graphs of
+	// the package.
+	Impact declcfg.GraphImpact `json:"impact"`
+}
+
+func (a AddBundle) Run(ctx context.Context) (*AddBundleResult, error) {
+	b := a.Bundle
+	if b.Name == "" || b.Package == "" {
+		return nil, fmt.Errorf("bundle name and package must be set")
+	}
+	fsys := os.DirFS(a.CatalogDir)
+
+	var (
+		mu    sync.Mutex
+		metas []*declcfg.Meta
+		// packageFile is the file that defines the package, and
+		// bundleFiles and channelFiles the files that define its bundles
+		// and channels, by name.
+		packageFile  string
+		bundleFiles  = map[string]string{}
+		channelFiles = map[string]string{}
+	)
+	if err := declcfg.WalkMetasFS(ctx, fsys, func(path string, meta *declcfg.Meta, err error) error {
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case meta.Schema == declcfg.SchemaPackage && meta.Name == b.Package:
+			packageFile = path
+		case meta.Package != b.Package:
+			return nil
+		case meta.Schema == declcfg.SchemaBundle:
+			bundleFiles[meta.Name] = path
+		case meta.Schema == declcfg.SchemaChannel:
+			channelFiles[meta.Name] = path
+		}
+		metas = append(metas, meta)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if packageFile == "" {
+		return nil, fmt.Errorf("package %q not found", b.Package)
+	}
+	cfg, err := declcfg.LoadSlice(metas)
+	if err != nil {
+		return nil, err
+	}
+
+	before := declcfg.SnapshotGraphs(*cfg)
+	res := &AddBundleResult{Package: b.Package, Bundle: b.Name}
+	// entries are the new entries of the channels that change, and
+	// newChannels the channels that are created.
+	entries := map[string][]declcfg.ChannelEntry{}
+	var newChannels []declcfg.Channel
+	entry, err := bundleChannelEntry(b)
+	if err != nil {
+		return nil, err
+	}
+	if file, ok := bundleFiles[b.Name]; ok {
+		if !a.OverwriteLatest {
+			return nil, fmt.Errorf("bundle %q already exists in package %q", b.Name, b.Package)
+		}
+		if err := checkLatest(cfg.Channels, b.Name); err != nil {
+			return nil, err
+		}
+		for i := range cfg.Bundles {
+			if cfg.Bundles[i].Name == b.Name {
+				previous := cfg.Bundles[i]
+				res.Previous = &previous
+				cfg.Bundles[i] = b
+			}
+		}
+		res.File = file
+		// Like the --overwrite-latest of a SQLite catalog, re-derive the
+		// entries of the bundle from its new CSV, so that the upgrade graph
+		// is validated with them.
+		if b.CsvJSON != "" {
+			for _, ch := range cfg.Channels {
+				for i, e := range ch.Entries {
+					if e.Name == b.Name {
+						entries[ch.Name] = slices.Clone(ch.Entries)
+						entries[ch.Name][i] = entry
+					}
+				}
+			}
+		}
+	} else {
+		if len(a.Channels) == 0 {
+			return nil, fmt.Errorf("channels must be set to add new bundle %q", b.Name)
+		}
+		cfg.Bundles = append(cfg.Bundles, b)
+		res.File = packageFile
+	}
+	for _, name := range sets.List(sets.New[string](a.Channels...)) {
+		if _, ok := channelFiles[name]; !ok {
+			newChannels = append(newChannels, declcfg.Channel{Schema: declcfg.SchemaChannel, Package: b.Package, Name: name, Entries: []declcfg.ChannelEntry{entry}})
+			continue
+		}
+		for _, ch := range cfg.Channels {
+			if ch.Name == name && !slices.ContainsFunc(ch.Entries, func(e declcfg.ChannelEntry) bool { return e.Name == b.Name }) {
+				entries[name] = append(slices.Clone(ch.Entries), entry)
+			}
+		}
+	}
+	for i := range cfg.Channels {
+		if e, ok := entries[cfg.Channels[i].Name]; ok {
+			cfg.Channels[i].Entries = e
+		}
+	}
+	cfg.Channels = append(cfg.Channels, newChannels...)
+	if len(a.Channels) > 0 {
+		res.Channels = sets.List(sets.New[string](a.Channels...))
+	}
+
+	if _, err := declcfg.ConvertToModel(*cfg); err != nil {
+		return nil, fmt.Errorf("invalid package %q with bundle %q: %v", b.Package, b.Name, err)
+	}
+	res.Impact = declcfg.CompareGraphs(before, declcfg.SnapshotGraphs(*cfg))
+	if a.DryRun {
+		return res, nil
+	}
+
+	// Record the previous content of the bundle before it is overwritten.
+	if res.Previous != nil && a.AuditDir != "" {
+		if res.AuditFile, err = writeAuditFile(a.AuditDir, *res.Previous); err != nil {
+			return nil, fmt.Errorf("record previous content of bundle %q: %v", b.Name, err)
+		}
+	}
+
+	files := sets.New[string](res.File)
+	for name := range entries {
+		files.Insert(channelFiles[name])
+	}
+	if len(newChannels) > 0 {
+		files.Insert(packageFile)
+	}
+	if err := rewriteCatalogFiles(a.CatalogDir, fsys, sets.List(files), func(f string, fileCfg *declcfg.DeclarativeConfig) {
+		for i, fb := range fileCfg.Bundles {
+			if fb.Package == b.Package && fb.Name == b.Name {
+				fileCfg.Bundles[i] = b
+			}
+		}
+		for i, ch := range fileCfg.Channels {
+			if e, ok := entries[ch.Name]; ok && ch.Package == b.Package {
+				fileCfg.Channels[i].Entries = e
+			}
+		}
+		if f == packageFile {
+			fileCfg.Channels = append(fileCfg.Channels, newChannels...)
+		}
+		if f == res.File && res.Previous == nil {
+			fileCfg.Bundles = append(fileCfg.Bundles, b)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("add bundle: %v", err)
+	}
+	return res, nil
+}
+
+// checkLatest returns an error if the bundle named name is not the head of
+// every channel that it is in.
+func checkLatest(channels []declcfg.Channel, name string) error {
+	var in []declcfg.Channel
+	for _, ch := range channels {
+		for _, e := range ch.Entries {
+			if e.Name == name {
+				in = append(in, ch)
+				break
+			}
+		}
+	}
+	for _, head := range declcfg.ChannelHeads(in) {
+		if len(head.Heads) != 1 || head.Heads[0] != name {
+			return fmt.Errorf("bundle %q is not the head of channel %q: only the latest bundle of a channel can be overwritten", name, head.Channel)
+		}
+	}
+	return nil
+}
+
+// bundleChannelEntry returns the channel entry of b, with the replaces,
+// skips and skipRange of its CSV, if it has one.
+func bundleChannelEntry(b declcfg.Bundle) (declcfg.ChannelEntry, error) {
+	entry := declcfg.ChannelEntry{Name: b.Name}
+	if b.CsvJSON == "" {
+		return entry, nil
+	}
+	var csv registry.ClusterServiceVersion
+	if err := json.Unmarshal([]byte(b.CsvJSON), &csv); err != nil {
+		return entry, fmt.Errorf("parse CSV of bundle %q: %v", b.Name, err)
+	}
+	var err error
+	if entry.Replaces, err = csv.GetReplaces(); err != nil {
+		return entry, fmt.Errorf("get replaces of bundle %q: %v", b.Name, err)
+	}
+	if entry.Skips, err = csv.GetSkips(); err != nil {
+		return entry, fmt.Errorf("get skips of bundle %q: %v", b.Name, err)
+	}
+	entry.SkipRange = csv.GetSkipRange()
+	return entry, nil
+}
+
+func writeAuditFile(dir string, previous declcfg.Bundle) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("%s-%s.json", previous.Name, time.Now().UTC().Format("20060102T150405.000000000Z")))
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	if err := declcfg.WriteJSON(declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{previous}}, f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return filename, f.Close()
+}
@@ -0,0 +1,242 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestAddBundle(t *testing.T) {
+	const addImpact = `package "foo", channel "fast" (new channel):
+  + entry foo.v0.3.0 (replaces foo.v0.2.0; skips foo.v0.1.1; skipRange "<0.3.0")
+  head: (none) -> foo.v0.3.0
+  + edge foo.v0.1.1 -> foo.v0.3.0 (skips)
+  + edge foo.v0.2.0 -> foo.v0.3.0 (replaces)
+package "foo", channel "stable":
+  + entry foo.v0.3.0 (replaces foo.v0.2.0; skips foo.v0.1.1; skipRange "<0.3.0")
+  head: foo.v0.2.0 -> foo.v0.3.0
+  + edge foo.v0.1.0 -> foo.v0.3.0 (skipRange)
+  + edge foo.v0.1.1 -> foo.v0.3.0 (skips)
+  + edge foo.v0.2.0 -> foo.v0.3.0 (replaces)
+  + edge foo.v0.2.0 -> foo.v0.3.0 (skipRange)
+`
+	const catalog = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.1.0
+- name: foo.v0.2.0
+  replaces: foo.v0.1.0
+`
+	const bundles = `{"schema": "olm.bundle", "package": "foo", "name": "foo.v0.1.0", "image": "foo:v0.1.0", "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "0.1.0"}}]}
+{"schema": "olm.bundle", "package": "foo", "name": "foo.v0.2.0", "image": "foo:v0.2.0", "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "0.2.0"}}]}
+`
+	bundle := func(version, image, replaces string) declcfg.Bundle {
+		b := declcfg.Bundle{
+			Schema:     declcfg.SchemaBundle,
+			Package:    "foo",
+			Name:       "foo.v" + version,
+			Image:      image,
+			Properties: []property.Property{property.MustBuildPackage("foo", version)},
+		}
+		if replaces != "" {
+			b.CsvJSON = `{"apiVersion": "operators.coreos.com/v1alpha1", "kind": "ClusterServiceVersion", "metadata": {"name": "foo.v` + version +
+				`", "annotations": {"olm.skipRange": "<` + version + `"}}, "spec": {"replaces": "` + replaces + `", "skips": ["foo.v0.1.1"]}}`
+		}
+		return b
+	}
+
+	type spec struct {
+		name           string
+		add            AddBundle
+		expectErr      string
+		expectResult   *AddBundleResult
+		expectImpact   string
+		expectChannels map[string][]declcfg.ChannelEntry
+		expectImages   map[string]string
+	}
+	withoutReplaces := bundle("0.2.0", "foo:v0.2.0-rebuilt", "")
+	withoutReplaces.CsvJSON = `{"apiVersion": "operators.coreos.com/v1alpha1", "kind": "ClusterServiceVersion", "metadata": {"name": "foo.v0.2.0"}, "spec": {}}`
+	specs := []spec{
+		{
+			name: "AddNewBundle",
+			add:  AddBundle{Bundle: bundle("0.3.0", "foo:v0.3.0", "foo.v0.2.0"), Channels: []string{"stable", "fast"}},
+			expectResult: &AddBundleResult{
+				Package:  "foo",
+				Bundle:   "foo.v0.3.0",
+				File:     "catalog.yaml",
+				Channels: []string{"fast", "stable"},
+			},
+			expectImpact: addImpact,
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v0.1.0"},
+					{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+					{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.1"}, SkipRange: "<0.3.0"},
+				},
+				"fast": {
+					{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.1"}, SkipRange: "<0.3.0"},
+				},
+			},
+			expectImages: map[string]string{"foo.v0.1.0": "foo:v0.1.0", "foo.v0.2.0": "foo:v0.2.0", "foo.v0.3.0": "foo:v0.3.0"},
+		},
+		{
+			name: "DryRun",
+			add:  AddBundle{Bundle: bundle("0.3.0", "foo:v0.3.0", "foo.v0.2.0"), Channels: []string{"stable", "fast"}, DryRun: true},
+			expectResult: &AddBundleResult{
+				Package:  "foo",
+				Bundle:   "foo.v0.3.0",
+				File:     "catalog.yaml",
+				Channels: []string{"fast", "stable"},
+			},
+			expectImpact: addImpact,
+			// The catalog is not changed.
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v0.1.0"},
+					{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				},
+			},
+			expectImages: map[string]string{"foo.v0.1.0": "foo:v0.1.0", "foo.v0.2.0": "foo:v0.2.0"},
+		},
+		{
+			name:      "NewBundleWithoutChannels",
+			add:       AddBundle{Bundle: bundle("0.3.0", "foo:v0.3.0", "foo.v0.2.0")},
+			expectErr: `channels must be set to add new bundle "foo.v0.3.0"`,
+		},
+		{
+			name:      "NewBundleBreaksUpgradeGraph",
+			add:       AddBundle{Bundle: bundle("0.3.0", "foo:v0.3.0", "foo.v0.1.0"), Channels: []string{"stable"}},
+			expectErr: "multiple channel heads found in graph: foo.v0.2.0, foo.v0.3.0",
+		},
+		{
+			name:      "ExistingBundle",
+			add:       AddBundle{Bundle: bundle("0.2.0", "foo:v0.2.0-rebuilt", "")},
+			expectErr: `bundle "foo.v0.2.0" already exists in package "foo"`,
+		},
+		{
+			name:      "OverwriteNotLatest",
+			add:       AddBundle{Bundle: bundle("0.1.0", "foo:v0.1.0-rebuilt", ""), OverwriteLatest: true},
+			expectErr: `bundle "foo.v0.1.0" is not the head of channel "stable"`,
+		},
+		{
+			name:      "UnknownPackage",
+			add:       AddBundle{Bundle: declcfg.Bundle{Package: "bar", Name: "bar.v0.1.0"}, Channels: []string{"stable"}},
+			expectErr: `package "bar" not found`,
+		},
+		{
+			name: "OverwriteLatest",
+			add:  AddBundle{Bundle: bundle("0.2.0", "foo:v0.2.0-rebuilt", ""), OverwriteLatest: true},
+			expectResult: &AddBundleResult{
+				Package: "foo",
+				Bundle:  "foo.v0.2.0",
+				File:    "bundles.json",
+			},
+			expectImpact: "no changes to upgrade graphs\n",
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v0.1.0"},
+					{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				},
+			},
+			expectImages: map[string]string{"foo.v0.1.0": "foo:v0.1.0", "foo.v0.2.0": "foo:v0.2.0-rebuilt"},
+		},
+		{
+			name: "OverwriteLatestWithNewCSV",
+			add:  AddBundle{Bundle: bundle("0.2.0", "foo:v0.2.0-rebuilt", "foo.v0.1.0"), OverwriteLatest: true, Channels: []string{"fast"}},
+			expectResult: &AddBundleResult{
+				Package:  "foo",
+				Bundle:   "foo.v0.2.0",
+				File:     "bundles.json",
+				Channels: []string{"fast"},
+			},
+			expectImpact: `package "foo", channel "fast" (new channel):
+  + entry foo.v0.2.0 (replaces foo.v0.1.0; skips foo.v0.1.1; skipRange "<0.2.0")
+  head: (none) -> foo.v0.2.0
+  + edge foo.v0.1.0 -> foo.v0.2.0 (replaces)
+  + edge foo.v0.1.1 -> foo.v0.2.0 (skips)
+package "foo", channel "stable":
+  ~ entry foo.v0.2.0 (replaces foo.v0.1.0; skips foo.v0.1.1; skipRange "<0.2.0"), was foo.v0.2.0 (replaces foo.v0.1.0)
+  + edge foo.v0.1.0 -> foo.v0.2.0 (skipRange)
+  + edge foo.v0.1.1 -> foo.v0.2.0 (skips)
+`,
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v0.1.0"},
+					{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1"}, SkipRange: "<0.2.0"},
+				},
+				"fast": {
+					{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1"}, SkipRange: "<0.2.0"},
+				},
+			},
+			expectImages: map[string]string{"foo.v0.1.0": "foo:v0.1.0", "foo.v0.2.0": "foo:v0.2.0-rebuilt"},
+		},
+		{
+			name:      "OverwriteLatestBreaksUpgradeGraph",
+			add:       AddBundle{Bundle: withoutReplaces, OverwriteLatest: true},
+			expectErr: "multiple channel heads found in graph: foo.v0.1.0, foo.v0.2.0",
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			dir := t.TempDir()
+			catalogDir := filepath.Join(dir, "catalog")
+			require.NoError(t, os.Mkdir(catalogDir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(catalogDir, "catalog.yaml"), []byte(catalog), 0600))
+			require.NoError(t, os.WriteFile(filepath.Join(catalogDir, "bundles.json"), []byte(bundles), 0600))
+
+			s.add.CatalogDir = catalogDir
+			s.add.AuditDir = filepath.Join(dir, "audit")
+			res, err := s.add.Run(context.Background())
+			if s.expectErr != "" {
+				require.ErrorContains(t, err, s.expectErr)
+				// The catalog is not changed.
+				actual, err := os.ReadFile(filepath.Join(catalogDir, "catalog.yaml"))
+				require.NoError(t, err)
+				require.Equal(t, catalog, string(actual))
+				require.NoDirExists(t, s.add.AuditDir)
+				return
+			}
+			require.NoError(t, err)
+
+			if res.Previous != nil {
+				require.Equal(t, "foo:v0.2.0", res.Previous.Image)
+				audit, err := declcfg.LoadFS(context.Background(), os.DirFS(s.add.AuditDir))
+				require.NoError(t, err)
+				require.Len(t, audit.Bundles, 1)
+				require.Equal(t, *res.Previous, audit.Bundles[0])
+				require.Equal(t, s.add.AuditDir, filepath.Dir(res.AuditFile))
+				res.Previous, res.AuditFile = nil, ""
+			}
+			require.Equal(t, s.expectImpact, res.Impact.String())
+			res.Impact = declcfg.GraphImpact{}
+			require.Equal(t, s.expectResult, res)
+
+			cfg, err := declcfg.LoadFS(context.Background(), os.DirFS(catalogDir))
+			require.NoError(t, err)
+			channels := map[string][]declcfg.ChannelEntry{}
+			for _, ch := range cfg.Channels {
+				channels[ch.Name] = ch.Entries
+			}
+			require.Equal(t, s.expectChannels, channels)
+			images := map[string]string{}
+			for _, b := range cfg.Bundles {
+				images[b.Name] = b.Image
+			}
+			require.Equal(t, s.expectImages, images)
+			_, err = declcfg.ConvertToModel(*cfg)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// rewriteCatalogFiles applies edit to the objects of each of the catalog
+// files at paths, relative to catalogDir, and rewrites the files in their
+// original format. YAML files are edited in place with declcfg.EditableYAML,
+// so that the comments and formatting of the objects that are not edited are
+// kept. Files whose objects edit does not change are not written, and files
+// with no remaining objects are deleted.
+//
+// The edited files are staged next to the files they replace before any file
+// is replaced, so that a failure to edit or write a file leaves the catalog
+// unchanged. If replacing a file fails, the files that were already replaced
+// are restored.
+func rewriteCatalogFiles(catalogDir string, fsys fs.FS, paths []string, edit func(path string, cfg *declcfg.DeclarativeConfig)) error {
+	var staged []*stagedCatalogFile
+	defer func() {
+		for _, s := range staged {
+			s.discard()
+		}
+	}()
+	for _, path := range paths {
+		s, err := stageCatalogFile(catalogDir, fsys, path, func(cfg *declcfg.DeclarativeConfig) { edit(path, cfg) })
+		if err != nil {
+			return fmt.Errorf("edit %q: %v", path, err)
+		}
+		if s != nil {
+			staged = append(staged, s)
+		}
+	}
+
+	for i, s := range staged {
+		if err := s.commit(); err != nil {
+			err = fmt.Errorf("write %q: %v", s.path, err)
+			for _, done := range staged[:i] {
+				if rErr := done.restore(); rErr != nil {
+					err = errors.Join(err, fmt.Errorf("restore %q: %v", done.path, rErr))
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// stagedCatalogFile is an edit of a catalog file that is ready to replace
+// it: either a temporary file next to it with the edited content, or the
+// removal of the file.
+type stagedCatalogFile struct {
+	path     string
+	filename string
+	original []byte
+	mode     fs.FileMode
+	tmp      string
+}
+
+// stageCatalogFile applies edit to the objects of the catalog file at path,
+// relative to catalogDir, and stages the result. It returns nil if edit does
+// not change the objects of the file.
+func stageCatalogFile(catalogDir string, fsys fs.FS, path string, edit func(*declcfg.DeclarativeConfig)) (*stagedCatalogFile, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		fileCfg  *declcfg.DeclarativeConfig
+		editable *declcfg.EditableYAML
+	)
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		fileCfg, editable, err = declcfg.LoadEditableYAML(bytes.NewReader(data))
+	default:
+		fileCfg, err = declcfg.LoadReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var before bytes.Buffer
+	if err := declcfg.WriteJSON(*fileCfg, &before); err != nil {
+		return nil, err
+	}
+	edit(fileCfg)
+	var after bytes.Buffer
+	if err := declcfg.WriteJSON(*fileCfg, &after); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(before.Bytes(), after.Bytes()) {
+		return nil, nil
+	}
+
+	filename := filepath.Join(catalogDir, filepath.FromSlash(path))
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := &stagedCatalogFile{path: path, filename: filename, original: data, mode: info.Mode().Perm()}
+	if isEmptyDeclarativeConfig(*fileCfg) {
+		return s, nil
+	}
+
+	out := after.Bytes()
+	if editable != nil {
+		var buf bytes.Buffer
+		if err := editable.Write(*fileCfg, &buf); err != nil {
+			return nil, err
+		}
+		out = buf.Bytes()
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	s.tmp = f.Name()
+	_, err = f.Write(out)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Chmod(s.tmp, s.mode)
+	}
+	if err != nil {
+		s.discard()
+		return nil, err
+	}
+	return s, nil
+}
+
+// commit replaces the catalog file with its staged edit.
+func (s *stagedCatalogFile) commit() error {
+	if s.tmp == "" {
+		return os.Remove(s.filename)
+	}
+	if err := os.Rename(s.tmp, s.filename); err != nil {
+		return err
+	}
+	s.tmp = ""
+	return nil
+}
+
+// restore writes back the original content of a committed catalog file.
+func (s *stagedCatalogFile) restore() error {
+	return os.WriteFile(s.filename, s.original, s.mode)
+}
+
+// discard removes the staged edit, if it was not committed.
+func (s *stagedCatalogFile) discard() {
+	if s.tmp != "" {
+		os.Remove(s.tmp)
+		s.tmp = ""
+	}
+}
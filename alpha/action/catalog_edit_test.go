@@ -0,0 +1,79 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestRewriteCatalogFiles(t *testing.T) {
+	const (
+		a = "# package a\nschema: olm.package\nname: a\ndefaultChannel: stable\n"
+		b = `{"schema":"olm.package","name":"b"}`
+	)
+	describe := func(_ string, cfg *declcfg.DeclarativeConfig) {
+		for i := range cfg.Packages {
+			cfg.Packages[i].Description = "edited"
+		}
+	}
+	setup := func(t *testing.T) (string, fstest.MapFS) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(a), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(b), 0644))
+		return dir, fstest.MapFS{
+			"a.yaml": &fstest.MapFile{Data: []byte(a)},
+			"b.json": &fstest.MapFile{Data: []byte(b)},
+		}
+	}
+	entries := func(t *testing.T, dir string) []string {
+		des, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, de := range des {
+			names = append(names, de.Name())
+		}
+		return names
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		dir, fsys := setup(t)
+		require.NoError(t, rewriteCatalogFiles(dir, fsys, []string{"a.yaml", "b.json"}, describe))
+		data, err := os.ReadFile(filepath.Join(dir, "a.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, "# package a\nschema: olm.package\nname: a\ndefaultChannel: stable\ndescription: edited\n", string(data))
+		data, err = os.ReadFile(filepath.Join(dir, "b.json"))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"schema":"olm.package","name":"b","defaultChannel":"","description":"edited"}`, string(data))
+		require.Equal(t, []string{"a.yaml", "b.json"}, entries(t, dir))
+	})
+
+	t.Run("StageFailure", func(t *testing.T) {
+		dir, fsys := setup(t)
+		err := rewriteCatalogFiles(dir, fsys, []string{"a.yaml", "missing.yaml"}, describe)
+		require.ErrorContains(t, err, `edit "missing.yaml"`)
+		// No file was replaced, and the staged edits were removed.
+		data, err := os.ReadFile(filepath.Join(dir, "a.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, a, string(data))
+		require.Equal(t, []string{"a.yaml", "b.json"}, entries(t, dir))
+	})
+
+	t.Run("CommitFailure", func(t *testing.T) {
+		dir, fsys := setup(t)
+		// b.json cannot be replaced, as it is a non-empty directory on disk.
+		require.NoError(t, os.Remove(filepath.Join(dir, "b.json")))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "b.json", "sub"), 0755))
+		err := rewriteCatalogFiles(dir, fsys, []string{"a.yaml", "b.json"}, describe)
+		require.ErrorContains(t, err, `write "b.json"`)
+		// a.yaml, which was replaced first, is restored.
+		data, err := os.ReadFile(filepath.Join(dir, "a.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, a, string(data))
+		require.Equal(t, []string{"a.yaml", "b.json"}, entries(t, dir))
+	})
+}
@@ -0,0 +1,99 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+// platformLister is implemented by registries that can report the platforms
+// of images without pulling them, such as containerdregistry.Registry.
+type platformLister interface {
+	Platforms(ctx context.Context, ref image.Reference) ([]ocispec.Platform, error)
+}
+
+// CheckArchitectures checks that the related images of the bundles of a
+// catalog provide every platform that the olm.architectures properties of
+// the bundles declare, by inspecting the manifests of the images. Bundles
+// without the property, and the bundle images themselves, are not checked.
+type CheckArchitectures struct {
+	Catalog declcfg.DeclarativeConfig
+	// Registry must be able to list the platforms of images, as
+	// containerdregistry.Registry does.
+	Registry image.Registry
+}
+
+// ArchitectureProblem is an image of a bundle that does not provide all of
+// the platforms that the bundle declares.
+type ArchitectureProblem struct {
+	Package string `json:"package"`
+	Bundle  string `json:"bundle"`
+	Image   string `json:"image"`
+	// Missing are the platforms that the image does not provide, as
+	// <os>/<architecture>.
+	Missing []string `json:"missing"`
+}
+
+func (p ArchitectureProblem) String() string {
+	return fmt.Sprintf("bundle %q of package %q: image %q does not provide platforms %v", p.Bundle, p.Package, p.Image, p.Missing)
+}
+
+func (c CheckArchitectures) Run(ctx context.Context) ([]ArchitectureProblem, error) {
+	lister, ok := c.Registry.(platformLister)
+	if !ok {
+		return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "registry %T cannot list the platforms of images", c.Registry)
+	}
+
+	// The platforms of each image, which is often shared by many bundles.
+	imagePlatforms := map[string]map[string]struct{}{}
+	var problems []ArchitectureProblem
+	for _, b := range c.Catalog.Bundles {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			return nil, liberrors.Errorf(liberrors.CodeInvalidCatalog, "parse properties of bundle %q: %v", b.Name, err)
+		}
+		if len(props.Architectures) == 0 {
+			continue
+		}
+		archs := props.Architectures[0]
+
+		for _, ri := range b.RelatedImages {
+			if ri.Image == "" || ri.Image == b.Image {
+				continue
+			}
+			provided, ok := imagePlatforms[ri.Image]
+			if !ok {
+				platforms, err := lister.Platforms(ctx, image.SimpleReference(ri.Image))
+				if err != nil {
+					return nil, liberrors.Errorf(liberrors.CodeImagePullFailed, "list platforms of image %q: %v", ri.Image, err)
+				}
+				provided = map[string]struct{}{}
+				for _, p := range platforms {
+					provided[p.OS+"/"+p.Architecture] = struct{}{}
+				}
+				imagePlatforms[ri.Image] = provided
+			}
+
+			var missing []string
+			for _, os := range archs.OperatingSystems {
+				for _, arch := range archs.Architectures {
+					if _, ok := provided[os+"/"+arch]; !ok {
+						missing = append(missing, os+"/"+arch)
+					}
+				}
+			}
+			if len(missing) > 0 {
+				sort.Strings(missing)
+				problems = append(problems, ArchitectureProblem{Package: b.Package, Bundle: b.Name, Image: ri.Image, Missing: missing})
+			}
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,104 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// platformRegistry lists the platforms of images from a map, and counts how
+// many times it is asked for each image.
+type platformRegistry struct {
+	image.Registry
+	platforms map[string][]ocispec.Platform
+	calls     map[string]int
+}
+
+func (r *platformRegistry) Platforms(_ context.Context, ref image.Reference) ([]ocispec.Platform, error) {
+	r.calls[ref.String()]++
+	p, ok := r.platforms[ref.String()]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return p, nil
+}
+
+func TestCheckArchitectures(t *testing.T) {
+	linux := func(archs ...string) []ocispec.Platform {
+		var ps []ocispec.Platform
+		for _, arch := range archs {
+			ps = append(ps, ocispec.Platform{OS: "linux", Architecture: arch})
+		}
+		return ps
+	}
+	bundle := func(name string, archs *property.Architectures, images ...string) declcfg.Bundle {
+		b := declcfg.Bundle{
+			Schema:     declcfg.SchemaBundle,
+			Package:    "foo",
+			Name:       name,
+			Image:      "example.com/foo-bundle:" + name,
+			Properties: []property.Property{property.MustBuildPackage("foo", "0.1.0")},
+			// The bundle image is not checked.
+			RelatedImages: []declcfg.RelatedImage{{Image: "example.com/foo-bundle:" + name}},
+		}
+		if archs != nil {
+			b.Properties = append(b.Properties, property.MustBuildArchitectures(archs.Architectures, archs.OperatingSystems))
+		}
+		for _, img := range images {
+			b.RelatedImages = append(b.RelatedImages, declcfg.RelatedImage{Image: img})
+		}
+		return b
+	}
+	multiArch := &property.Architectures{Architectures: []string{"amd64", "arm64", "s390x"}, OperatingSystems: []string{"linux"}}
+
+	reg := &platformRegistry{
+		platforms: map[string][]ocispec.Platform{
+			"example.com/operator:multi": linux("amd64", "arm64", "s390x"),
+			"example.com/operator:amd64": linux("amd64"),
+			"example.com/operand:multi":  linux("arm64", "amd64", "s390x", "ppc64le"),
+		},
+		calls: map[string]int{},
+	}
+	problems, err := CheckArchitectures{
+		Catalog: declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{
+			bundle("foo.v0.1.0", nil, "example.com/operator:amd64", "example.com/unknown:v1"),
+			bundle("foo.v0.2.0", multiArch, "example.com/operator:multi", "example.com/operand:multi"),
+			bundle("foo.v0.3.0", multiArch, "example.com/operator:amd64", "example.com/operand:multi"),
+		}},
+		Registry: reg,
+	}.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []ArchitectureProblem{{
+		Package: "foo",
+		Bundle:  "foo.v0.3.0",
+		Image:   "example.com/operator:amd64",
+		Missing: []string{"linux/arm64", "linux/s390x"},
+	}}, problems)
+	// Each image is only inspected once, and the images of bundles without
+	// the property are not inspected.
+	require.Equal(t, map[string]int{
+		"example.com/operator:multi": 1,
+		"example.com/operator:amd64": 1,
+		"example.com/operand:multi":  1,
+	}, reg.calls)
+
+	t.Run("Error/ImageNotFound", func(t *testing.T) {
+		_, err := CheckArchitectures{
+			Catalog:  declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{bundle("foo.v0.1.0", multiArch, "example.com/unknown:v1")}},
+			Registry: &platformRegistry{calls: map[string]int{}},
+		}.Run(context.Background())
+		require.ErrorContains(t, err, `list platforms of image "example.com/unknown:v1": not found`)
+	})
+
+	t.Run("Error/UnsupportedRegistry", func(t *testing.T) {
+		_, err := CheckArchitectures{Registry: &image.MockRegistry{}}.Run(context.Background())
+		require.ErrorContains(t, err, "cannot list the platforms of images")
+	})
+}
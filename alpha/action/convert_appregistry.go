@@ -0,0 +1,127 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+// ConvertAppRegistry converts a tree of operator manifests downloaded from
+// an appregistry to a file-based catalog, so that catalogs that are still
+// served by the deprecated appregistry-server can be migrated.
+//
+// The tree can hold manifests in both of the appregistry formats. Nested
+// manifests are directories of packages, each with a package manifest and a
+// directory per version holding a CSV and its CRDs. Flattened manifests are
+// files whose "data" holds "packages", "clusterServiceVersions" and
+// "customResourceDefinitions" lists, as in the ConfigMaps served by the
+// configmap-server. The channels of the catalog are inferred from the
+// package manifests: each channel starts at its currentCSV and follows the
+// replaces and skips of the CSVs.
+type ConvertAppRegistry struct {
+	ManifestsDir string
+}
+
+func (c ConvertAppRegistry) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
+	if _, err := os.Stat(c.ManifestsDir); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "opm-appregistry-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	db, err := sqlite.Open(filepath.Join(tmpDir, "index.db"))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	loader, err := sqlite.NewSQLLiteLoader(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := loader.Migrate(ctx); err != nil {
+		return nil, err
+	}
+
+	flattened, err := findFlattenedManifests(c.ManifestsDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range flattened {
+		populator := sqlite.NewSQLLoaderForConfigMapData(logrus.WithField("file", m.path), loader, m.Data)
+		if err := populator.Populate(); err != nil {
+			return nil, fmt.Errorf("load flattened manifests %q: %v", m.path, err)
+		}
+	}
+	if err := sqlite.NewSQLLoaderForDirectory(loader, c.ManifestsDir).Populate(); err != nil {
+		return nil, fmt.Errorf("load nested manifests: %v", err)
+	}
+
+	// The manifests are only staged in a sqlite database, so the sqlite
+	// deprecation notice does not apply.
+	logDeprecationMessage.Do(func() {})
+	cfg, err := sqliteToDeclcfg(ctx, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Packages) == 0 {
+		return nil, fmt.Errorf("no packages found in %q", c.ManifestsDir)
+	}
+	if _, err := declcfg.ConvertToModel(*cfg); err != nil {
+		return nil, fmt.Errorf("converted catalog is invalid: %v", err)
+	}
+	return cfg, nil
+}
+
+// flattenedManifests is a file of flattened appregistry manifests.
+type flattenedManifests struct {
+	path string
+	Data map[string]string `json:"data"`
+}
+
+// findFlattenedManifests returns the files of flattened manifests in dir,
+// in lexical order.
+func findFlattenedManifests(dir string) ([]flattenedManifests, error) {
+	var found []flattenedManifests
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		m := flattenedManifests{path: path}
+		if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(&m); err != nil {
+			return nil
+		}
+		if _, ok := m.Data[sqlite.ConfigMapPackageName]; ok {
+			found = append(found, m)
+		}
+		return nil
+	})
+	return found, err
+}
@@ -0,0 +1,97 @@
+package action_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestConvertAppRegistry(t *testing.T) {
+	t.Run("Nested", func(t *testing.T) {
+		cfg, err := action.ConvertAppRegistry{ManifestsDir: "../../manifests"}.Run(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, cfg.Packages, 3)
+		etcd := declcfg.SplitByPackage(*cfg)["etcd"]
+		require.Equal(t, "alpha", etcd.Packages[0].DefaultChannel)
+		var names []string
+		for _, c := range etcd.Channels {
+			names = append(names, c.Name)
+		}
+		require.ElementsMatch(t, []string{"alpha", "beta", "stable"}, names)
+		for _, c := range etcd.Channels {
+			if c.Name == "alpha" {
+				require.Equal(t, declcfg.ChannelEntry{
+					Name:      "etcdoperator.v0.9.2",
+					Replaces:  "etcdoperator.v0.9.0",
+					Skips:     []string{"etcdoperator.v0.9.1"},
+					SkipRange: "< 0.6.0",
+				}, c.Entries[len(c.Entries)-1])
+			}
+		}
+		require.Len(t, etcd.Bundles, 3)
+	})
+	t.Run("Flattened", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFlattenedManifests(t, filepath.Join(dir, "etcd", "bundle.yaml"))
+
+		cfg, err := action.ConvertAppRegistry{ManifestsDir: dir}.Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cfg.Packages, 1)
+		require.Equal(t, "etcd", cfg.Packages[0].Name)
+		require.Len(t, cfg.Channels, 1)
+		require.Equal(t, []declcfg.ChannelEntry{{Name: "etcdoperator.v0.6.1"}}, cfg.Channels[0].Entries)
+		require.Len(t, cfg.Bundles, 1)
+		require.Equal(t, "etcdoperator.v0.6.1", cfg.Bundles[0].Name)
+		require.NotEmpty(t, cfg.Bundles[0].CsvJSON)
+	})
+	t.Run("Error/NoPackages", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := action.ConvertAppRegistry{ManifestsDir: dir}.Run(context.Background())
+		require.ErrorContains(t, err, "no packages found")
+	})
+	t.Run("Error/NotExist", func(t *testing.T) {
+		_, err := action.ConvertAppRegistry{ManifestsDir: filepath.Join(t.TempDir(), "missing")}.Run(context.Background())
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+// writeFlattenedManifests writes the etcd 0.6.1 manifests as flattened
+// appregistry manifests to path.
+func writeFlattenedManifests(t *testing.T, path string) {
+	t.Helper()
+	readJSON := func(file string) json.RawMessage {
+		data, err := os.ReadFile(filepath.Join("../../manifests/etcd/0.6.1", file))
+		require.NoError(t, err)
+		data, err = yaml.YAMLToJSON(data)
+		require.NoError(t, err)
+		return data
+	}
+	list := func(items ...interface{}) string {
+		data, err := json.Marshal(items)
+		require.NoError(t, err)
+		return string(data)
+	}
+	data, err := yaml.Marshal(map[string]interface{}{
+		"data": map[string]string{
+			"packages": list(map[string]interface{}{
+				"packageName":    "etcd",
+				"defaultChannel": "alpha",
+				"channels":       []map[string]string{{"name": "alpha", "currentCSV": "etcdoperator.v0.6.1"}},
+			}),
+			"clusterServiceVersions":    list(readJSON("etcdoperator.clusterserviceversion.yaml")),
+			"customResourceDefinitions": list(readJSON("etcdcluster.crd.yaml")),
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
@@ -0,0 +1,60 @@
+package action
+
+import (
+	"context"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// ConvertBundleObjects shrinks a file-based catalog directory in place, by
+// replacing the olm.bundle.object properties of its bundles with
+// olm.csv.metadata properties, as declcfg.ConvertBundleObjectsToCSVMetadata
+// does. Like MigrateFBC, it keeps the layout and format of the catalog, and
+// only rewrites the files that contain converted bundles.
+type ConvertBundleObjects struct {
+	CatalogDir string
+
+	// DryRun reports the bundles that would be converted without rewriting
+	// their files.
+	DryRun bool
+}
+
+type ConvertBundleObjectsResult struct {
+	Bundles []declcfg.ObjectKey        `json:"bundles"`
+	Files   []ConvertBundleObjectsFile `json:"files"`
+}
+
+// ConvertBundleObjectsFile is a file that contains converted bundles.
+type ConvertBundleObjectsFile struct {
+	// Path is relative to the catalog directory.
+	Path    string `json:"path"`
+	OldSize int    `json:"oldSize"`
+	NewSize int    `json:"newSize"`
+}
+
+func (c ConvertBundleObjects) Run(ctx context.Context) (*ConvertBundleObjectsResult, error) {
+	res := &ConvertBundleObjectsResult{
+		Bundles: []declcfg.ObjectKey{},
+		Files:   []ConvertBundleObjectsFile{},
+	}
+	files, err := rewriteFBC(ctx, c.CatalogDir, c.DryRun, func(_ string, cfg *declcfg.DeclarativeConfig) error {
+		for i := range cfg.Bundles {
+			b := &cfg.Bundles[i]
+			converted, err := declcfg.ConvertBundleObjectsToCSVMetadata(b)
+			if err != nil {
+				return err
+			}
+			if converted {
+				res.Bundles = append(res.Bundles, declcfg.ObjectKey{Schema: declcfg.SchemaBundle, Package: b.Package, Name: b.Name})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		res.Files = append(res.Files, ConvertBundleObjectsFile(f))
+	}
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package action_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestConvertBundleObjects(t *testing.T) {
+	const csv = `{"kind":"ClusterServiceVersion","apiVersion":"operators.coreos.com/v1alpha1","metadata":{"name":"foo.v0.1.0"},"spec":{"displayName":"Foo Operator"}}`
+	foo := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo"}},
+		Bundles: []declcfg.Bundle{{
+			Schema:  declcfg.SchemaBundle,
+			Package: "foo",
+			Name:    "foo.v0.1.0",
+			Image:   "test.registry/foo-operator/foo-bundle:v0.1.0",
+			Properties: []property.Property{
+				property.MustBuildPackage("foo", "0.1.0"),
+				property.MustBuildBundleObject([]byte(csv)),
+			},
+		}},
+	}
+	bar := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "bar"}},
+	}
+	newCatalog := func(t *testing.T) string {
+		dir := t.TempDir()
+		writeCatalogFile(t, filepath.Join(dir, "foo.json"), foo, declcfg.WriteJSON)
+		writeCatalogFile(t, filepath.Join(dir, "bar.yaml"), bar, declcfg.WriteYAML)
+		return dir
+	}
+	wantBundles := []declcfg.ObjectKey{{Schema: declcfg.SchemaBundle, Package: "foo", Name: "foo.v0.1.0"}}
+
+	for _, dryRun := range []bool{false, true} {
+		name := "Convert"
+		if dryRun {
+			name = "DryRun"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := newCatalog(t)
+			before, err := os.ReadFile(filepath.Join(dir, "foo.json"))
+			require.NoError(t, err)
+
+			res, err := action.ConvertBundleObjects{CatalogDir: dir, DryRun: dryRun}.Run(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, wantBundles, res.Bundles)
+			require.Len(t, res.Files, 1)
+			require.Equal(t, "foo.json", res.Files[0].Path)
+			require.Equal(t, len(before), res.Files[0].OldSize)
+			require.Less(t, res.Files[0].NewSize, res.Files[0].OldSize)
+
+			after, err := os.ReadFile(filepath.Join(dir, "foo.json"))
+			require.NoError(t, err)
+			if dryRun {
+				require.Equal(t, before, after)
+				return
+			}
+			require.Len(t, after, res.Files[0].NewSize)
+			cfg, err := declcfg.LoadFile(os.DirFS(dir), "foo.json")
+			require.NoError(t, err)
+			require.Len(t, cfg.Bundles, 1)
+			props, err := property.Parse(cfg.Bundles[0].Properties)
+			require.NoError(t, err)
+			require.Empty(t, props.BundleObjects)
+			require.Len(t, props.CSVMetadatas, 1)
+			require.Equal(t, "Foo Operator", props.CSVMetadatas[0].DisplayName)
+
+			// Converted catalogs are left unchanged.
+			res, err = action.ConvertBundleObjects{CatalogDir: dir}.Run(context.Background())
+			require.NoError(t, err)
+			require.Empty(t, res.Bundles)
+			require.Empty(t, res.Files)
+		})
+	}
+}
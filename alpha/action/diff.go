@@ -0,0 +1,70 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// Diff computes the changes between two catalogs, e.g. for mirroring only
+// the content that was added to a catalog since it was last mirrored.
+type Diff struct {
+	OldRef   string
+	NewRef   string
+	Registry image.Registry
+
+	// LoadRefOptions are passed to declcfg.LoadRef when rendering
+	// archive, git, and gRPC references.
+	LoadRefOptions []declcfg.LoadRefOption
+}
+
+type DiffResult struct {
+	// Changed contains the objects of the new catalog that are not in the
+	// old catalog, or that differ from their counterpart in it.
+	Changed *declcfg.DeclarativeConfig `json:"-"`
+
+	// Removed identifies the objects of the old catalog that are not in the
+	// new catalog.
+	Removed []declcfg.ObjectKey `json:"removed"`
+}
+
+func (d Diff) Run(ctx context.Context) (*DiffResult, error) {
+	oldCfg, err := d.render(ctx, d.OldRef)
+	if err != nil {
+		return nil, fmt.Errorf("render old catalog %q: %w", d.OldRef, err)
+	}
+	newCfg, err := d.render(ctx, d.NewRef)
+	if err != nil {
+		return nil, fmt.Errorf("render new catalog %q: %w", d.NewRef, err)
+	}
+
+	changed, removed, err := declcfg.Diff(*oldCfg, *newCfg)
+	if err != nil {
+		return nil, err
+	}
+	if removed == nil {
+		removed = []declcfg.ObjectKey{}
+	}
+	return &DiffResult{Changed: changed, Removed: removed}, nil
+}
+
+func (d Diff) render(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	r := Render{
+		Refs:           []string{ref},
+		Registry:       d.Registry,
+		AllowedRefMask: RefDCImage | RefDCDir | RefSqliteImage | RefSqliteFile | RefDCArchive | RefDCGit | RefDCGRPC,
+		LoadRefOptions: d.LoadRefOptions,
+	}
+	return r.Run(ctx)
+}
+
+// WriteRemovedJSON writes the summary of removed objects to w as JSON.
+func (r *DiffResult) WriteRemovedJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,80 @@
+package action_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestDiff(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	const newRef = "testdata/foo-index-v0.2.0-declcfg"
+
+	// The old catalog does not have foo.v0.2.0 and the stable channel,
+	// and has a bar package that was removed since.
+	oldCfg, err := declcfg.LoadFS(context.Background(), os.DirFS(newRef))
+	require.NoError(t, err)
+	oldCfg.Packages = append(oldCfg.Packages, declcfg.Package{Schema: declcfg.SchemaPackage, Name: "bar", DefaultChannel: "stable"})
+	var expectedChanged declcfg.DeclarativeConfig
+	for i, ch := range oldCfg.Channels {
+		if ch.Name == "stable" {
+			expectedChanged.Channels = append(expectedChanged.Channels, ch)
+			oldCfg.Channels = append(oldCfg.Channels[:i], oldCfg.Channels[i+1:]...)
+			break
+		}
+	}
+	for i, b := range oldCfg.Bundles {
+		if b.Name == "foo.v0.2.0" {
+			expectedChanged.Bundles = append(expectedChanged.Bundles, b)
+			oldCfg.Bundles = append(oldCfg.Bundles[:i], oldCfg.Bundles[i+1:]...)
+			break
+		}
+	}
+	oldDir := t.TempDir()
+	f, err := os.Create(filepath.Join(oldDir, "catalog.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, declcfg.WriteYAML(*oldCfg, f))
+	require.NoError(t, f.Close())
+
+	t.Run("Changed", func(t *testing.T) {
+		res, err := action.Diff{OldRef: oldDir, NewRef: newRef, Registry: reg}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []declcfg.ObjectKey{{Schema: declcfg.SchemaPackage, Name: "bar"}}, res.Removed)
+
+		require.Len(t, res.Changed.Channels, 1)
+		require.Equal(t, expectedChanged.Channels[0].Name, res.Changed.Channels[0].Name)
+		require.Len(t, res.Changed.Bundles, 1)
+		require.Equal(t, expectedChanged.Bundles[0].Name, res.Changed.Bundles[0].Name)
+		require.Empty(t, res.Changed.Packages)
+		require.Empty(t, res.Changed.Others)
+	})
+
+	t.Run("Identical", func(t *testing.T) {
+		res, err := action.Diff{
+			OldRef:   "test.registry/foo-operator/foo-index-declcfg:v0.2.0",
+			NewRef:   newRef,
+			Registry: reg,
+		}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, &declcfg.DeclarativeConfig{}, res.Changed)
+		require.Empty(t, res.Removed)
+	})
+
+	t.Run("BundleNotAllowed", func(t *testing.T) {
+		_, err := action.Diff{
+			OldRef:   "test.registry/foo-operator/foo-bundle:v0.2.0",
+			NewRef:   newRef,
+			Registry: reg,
+		}.Run(context.Background())
+		require.True(t, errors.Is(err, action.ErrNotAllowed), "expected error %v to be %v", err, action.ErrNotAllowed)
+	})
+}
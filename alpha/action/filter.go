@@ -0,0 +1,47 @@
+package action
+
+import (
+	"context"
+	"errors"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/declcfg/filter"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+// Filter renders catalogs and prunes the result down to the bundles that
+// match all of the filter terms. See the filter package for the terms.
+type Filter struct {
+	Refs     []string
+	Terms    []string
+	Registry image.Registry
+
+	// LoadRefOptions are passed to declcfg.LoadRef when rendering
+	// archive, git, and gRPC references.
+	LoadRefOptions []declcfg.LoadRefOption
+}
+
+func (a Filter) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
+	if len(a.Terms) == 0 {
+		return nil, liberrors.New(liberrors.CodeInvalidArgument, errors.New("at least one filter term is required"))
+	}
+	f, err := filter.Parse(a.Terms...)
+	if err != nil {
+		return nil, liberrors.New(liberrors.CodeInvalidArgument, err)
+	}
+
+	render := Render{
+		Refs:           a.Refs,
+		Registry:       a.Registry,
+		LoadRefOptions: a.LoadRefOptions,
+	}
+	cfg, err := render.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Apply(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,55 @@
+package action_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func TestFilter(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	const ref = "testdata/foo-index-v0.2.0-declcfg"
+
+	t.Run("Success", func(t *testing.T) {
+		cfg, err := action.Filter{
+			Refs:     []string{ref},
+			Terms:    []string{"provides=test.foo/v1/Foo", "version=<0.2.0"},
+			Registry: reg,
+		}.Run(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, cfg.Packages, 1)
+		require.Len(t, cfg.Bundles, 1)
+		require.Equal(t, "foo.v0.1.0", cfg.Bundles[0].Name)
+		require.Len(t, cfg.Channels, 1)
+		require.Equal(t, "beta", cfg.Channels[0].Name)
+		require.Len(t, cfg.Channels[0].Entries, 1)
+	})
+	t.Run("Success/NoMatches", func(t *testing.T) {
+		cfg, err := action.Filter{
+			Refs:     []string{ref},
+			Terms:    []string{"package!=foo"},
+			Registry: reg,
+		}.Run(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, cfg.Packages)
+		require.Empty(t, cfg.Channels)
+		require.Empty(t, cfg.Bundles)
+	})
+	t.Run("Error/NoTerms", func(t *testing.T) {
+		_, err := action.Filter{Refs: []string{ref}, Registry: reg}.Run(context.Background())
+		require.EqualError(t, err, "at least one filter term is required")
+		require.Equal(t, liberrors.CodeInvalidArgument, liberrors.CodeOf(err))
+	})
+	t.Run("Error/InvalidTerm", func(t *testing.T) {
+		_, err := action.Filter{Refs: []string{ref}, Terms: []string{"color=blue"}, Registry: reg}.Run(context.Background())
+		require.ErrorContains(t, err, `invalid filter term "color=blue"`)
+		require.Equal(t, liberrors.CodeInvalidArgument, liberrors.CodeOf(err))
+	})
+}
@@ -0,0 +1,124 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// GarbageCollect finds the bundles in a file-based catalog directory that are
+// not an entry of any channel in their package. If Apply is set, it removes
+// them, along with any deprecation entries that reference them, by rewriting
+// the files that contain them.
+type GarbageCollect struct {
+	CatalogDir string
+	Apply      bool
+}
+
+type GarbageCollectedBundle struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	File    string `json:"file"`
+}
+
+type GarbageCollectResult struct {
+	Unreachable []GarbageCollectedBundle `json:"unreachable"`
+	Removed     bool                     `json:"removed"`
+}
+
+func (g GarbageCollect) Run(ctx context.Context) (*GarbageCollectResult, error) {
+	fsys := os.DirFS(g.CatalogDir)
+
+	var (
+		mu    sync.Mutex
+		metas []*declcfg.Meta
+		// bundleFiles maps package and bundle names to the file defining
+		// the bundle.
+		bundleFiles = map[string]map[string]string{}
+		// deprecationFiles maps package names to the files containing
+		// deprecations for the package.
+		deprecationFiles = map[string][]string{}
+	)
+	if err := declcfg.WalkMetasFS(ctx, fsys, func(path string, meta *declcfg.Meta, err error) error {
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		metas = append(metas, meta)
+		switch meta.Schema {
+		case declcfg.SchemaBundle:
+			if bundleFiles[meta.Package] == nil {
+				bundleFiles[meta.Package] = map[string]string{}
+			}
+			bundleFiles[meta.Package][meta.Name] = path
+		case declcfg.SchemaDeprecation:
+			deprecationFiles[meta.Package] = append(deprecationFiles[meta.Package], path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	cfg, err := declcfg.LoadSlice(metas)
+	if err != nil {
+		return nil, err
+	}
+
+	unreachable := declcfg.UnreachableBundles(*cfg)
+	res := &GarbageCollectResult{}
+	filesToRewrite := map[string]struct{}{}
+	for _, b := range unreachable {
+		file := bundleFiles[b.Package][b.Name]
+		res.Unreachable = append(res.Unreachable, GarbageCollectedBundle{
+			Package: b.Package,
+			Name:    b.Name,
+			Image:   b.Image,
+			File:    file,
+		})
+		filesToRewrite[file] = struct{}{}
+		for _, f := range deprecationFiles[b.Package] {
+			filesToRewrite[f] = struct{}{}
+		}
+	}
+	if !g.Apply || len(unreachable) == 0 {
+		return res, nil
+	}
+
+	files := make([]string, 0, len(filesToRewrite))
+	for f := range filesToRewrite {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	if err := rewriteCatalogFiles(g.CatalogDir, fsys, files, func(_ string, cfg *declcfg.DeclarativeConfig) {
+		declcfg.RemoveBundles(cfg, unreachable)
+	}); err != nil {
+		return nil, fmt.Errorf("remove unreachable bundles: %v", err)
+	}
+	res.Removed = true
+	return res, nil
+}
+
+func isEmptyDeclarativeConfig(cfg declcfg.DeclarativeConfig) bool {
+	return len(cfg.Packages) == 0 && len(cfg.Channels) == 0 && len(cfg.Bundles) == 0 &&
+		len(cfg.Deprecations) == 0 && len(cfg.Documentations) == 0 && len(cfg.Others) == 0
+}
+
+func (r *GarbageCollectResult) WriteColumns(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "PACKAGE\tBUNDLE\tIMAGE\tFILE"); err != nil {
+		return err
+	}
+	for _, b := range r.Unreachable {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", b.Package, b.Name, b.Image, b.File); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,109 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestGarbageCollect(t *testing.T) {
+	const catalog = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.2.0
+---
+# foo.v0.2.0 is the only supported version.
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: foo:v0.2.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.2.0
+---
+schema: olm.deprecations
+package: foo
+entries:
+- reference:
+    schema: olm.bundle
+    name: foo.v0.1.0
+  message: foo.v0.1.0 is deprecated
+`
+	const orphan = `{"schema": "olm.bundle", "package": "foo", "name": "foo.v0.1.0", "image": "foo:v0.1.0", "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "0.1.0"}}]}`
+
+	setup := func(t *testing.T, catalog string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "foo"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "foo", "catalog.yaml"), []byte(catalog), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "foo", "orphan.json"), []byte(orphan), 0600))
+		return dir
+	}
+	expected := []GarbageCollectedBundle{{Package: "foo", Name: "foo.v0.1.0", Image: "foo:v0.1.0", File: "foo/orphan.json"}}
+
+	t.Run("Report", func(t *testing.T) {
+		dir := setup(t, catalog)
+		res, err := GarbageCollect{CatalogDir: dir}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, res.Unreachable)
+		require.False(t, res.Removed)
+		require.FileExists(t, filepath.Join(dir, "foo", "orphan.json"))
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		dir := setup(t, catalog)
+		res, err := GarbageCollect{CatalogDir: dir, Apply: true}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, res.Unreachable)
+		require.True(t, res.Removed)
+		require.NoFileExists(t, filepath.Join(dir, "foo", "orphan.json"))
+		// The catalog file is edited in place.
+		data, err := os.ReadFile(filepath.Join(dir, "foo", "catalog.yaml"))
+		require.NoError(t, err)
+		require.Contains(t, string(data), "# foo.v0.2.0 is the only supported version.\n")
+		require.NotContains(t, string(data), "olm.deprecations")
+		// The mode of the catalog file is kept.
+		info, err := os.Stat(filepath.Join(dir, "foo", "catalog.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		entries, err := os.ReadDir(filepath.Join(dir, "foo"))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		cfg, err := declcfg.LoadFS(context.Background(), os.DirFS(dir))
+		require.NoError(t, err)
+		require.Len(t, cfg.Bundles, 1)
+		require.Empty(t, cfg.Deprecations)
+		_, err = declcfg.ConvertToModel(*cfg)
+		require.NoError(t, err)
+
+		res, err = GarbageCollect{CatalogDir: dir}.Run(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, res.Unreachable)
+	})
+	t.Run("ApplyUnchanged", func(t *testing.T) {
+		// The deprecations do not reference the removed bundle, so the file
+		// that contains them is not rewritten.
+		unchanged := strings.Replace(catalog, "    schema: olm.bundle\n    name: foo.v0.1.0", "    schema: olm.channel\n    name: stable", 1)
+		dir := setup(t, unchanged)
+		res, err := GarbageCollect{CatalogDir: dir, Apply: true}.Run(context.Background())
+		require.NoError(t, err)
+		require.True(t, res.Removed)
+		data, err := os.ReadFile(filepath.Join(dir, "foo", "catalog.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, unchanged, string(data))
+	})
+}
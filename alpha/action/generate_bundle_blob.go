@@ -0,0 +1,69 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// GenerateBundleBlob renders a bundle directory and writes its olm.bundle
+// blob, with the properties derived from its CSV, ready to be merged into a
+// file-based catalog or its basic template.
+type GenerateBundleBlob struct {
+	// BundleDir is the directory of the bundle, with its manifests/ and
+	// metadata/ directories as they appear in the bundle image.
+	BundleDir string
+
+	// ImageRefTemplate, if set, is the template of the pullspec of the bundle
+	// image. Like the pullspec of a rendered bundle image, it is the image of
+	// the blob and one of its related images. See Render.ImageRefTemplate.
+	ImageRefTemplate *template.Template
+
+	// Format is the format of the blob, json or yaml.
+	Format string
+	// NoCanonical writes the blob as it is rendered, instead of in canonical
+	// form (see declcfg.Canonicalize).
+	NoCanonical bool
+	Writer      io.Writer
+}
+
+func (g GenerateBundleBlob) Run(ctx context.Context) error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	render := Render{
+		Refs:             []string{g.BundleDir},
+		AllowedRefMask:   RefBundleDir,
+		ImageRefTemplate: g.ImageRefTemplate,
+	}
+	cfg, err := render.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	write := declcfg.WriteYAML
+	if g.Format == "json" {
+		write = declcfg.WriteJSON
+	}
+	if !g.NoCanonical {
+		write = declcfg.Canonical(write)
+	}
+	return write(*cfg, g.Writer)
+}
+
+func (g GenerateBundleBlob) validate() error {
+	if g.BundleDir == "" {
+		return fmt.Errorf("bundle directory is unset")
+	}
+	if g.Format != "json" && g.Format != "yaml" {
+		return fmt.Errorf("invalid format %q, expected (json|yaml)", g.Format)
+	}
+	if g.Writer == nil {
+		return fmt.Errorf("writer is unset")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestGenerateBundleBlob(t *testing.T) {
+	t.Run("ImageRefTemplate", func(t *testing.T) {
+		var buf bytes.Buffer
+		gen := GenerateBundleBlob{
+			BundleDir:        "testdata/foo-bundle-v0.2.0",
+			ImageRefTemplate: template.Must(template.New("image").Parse("quay.io/example/{{.Package}}-bundle:v{{.Version}}")),
+			Format:           "json",
+			Writer:           &buf,
+		}
+		require.NoError(t, gen.Run(context.Background()))
+
+		cfg, err := declcfg.LoadReader(&buf)
+		require.NoError(t, err)
+		require.Len(t, cfg.Bundles, 1)
+		b := cfg.Bundles[0]
+		require.Equal(t, "foo.v0.2.0", b.Name)
+		require.Equal(t, "quay.io/example/foo-bundle:v0.2.0", b.Image)
+		// The bundle image is a related image, as for rendered bundle images.
+		require.Contains(t, b.RelatedImages, declcfg.RelatedImage{Image: "quay.io/example/foo-bundle:v0.2.0"})
+	})
+
+	t.Run("YAMLWithoutImage", func(t *testing.T) {
+		var buf bytes.Buffer
+		gen := GenerateBundleBlob{BundleDir: "testdata/foo-bundle-v0.2.0", Format: "yaml", Writer: &buf}
+		require.NoError(t, gen.Run(context.Background()))
+		require.Contains(t, buf.String(), "schema: olm.bundle\n")
+
+		cfg, err := declcfg.LoadReader(&buf)
+		require.NoError(t, err)
+		require.Len(t, cfg.Bundles, 1)
+		require.Empty(t, cfg.Bundles[0].Image)
+	})
+
+	t.Run("Canonical", func(t *testing.T) {
+		render := func(noCanonical bool) (*declcfg.DeclarativeConfig, string) {
+			var buf bytes.Buffer
+			gen := GenerateBundleBlob{BundleDir: "testdata/foo-bundle-v0.2.0", Format: "json", NoCanonical: noCanonical, Writer: &buf}
+			require.NoError(t, gen.Run(context.Background()))
+			out := buf.String()
+			cfg, err := declcfg.LoadReader(&buf)
+			require.NoError(t, err)
+			return cfg, out
+		}
+		asRendered, _ := render(true)
+		canonical, err := declcfg.Canonicalize(*asRendered)
+		require.NoError(t, err)
+		var expected bytes.Buffer
+		require.NoError(t, declcfg.WriteJSON(*canonical, &expected))
+
+		_, actual := render(false)
+		require.Equal(t, expected.String(), actual)
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		gen := GenerateBundleBlob{BundleDir: "testdata/foo-bundle-v0.2.0", Format: "xml", Writer: &bytes.Buffer{}}
+		require.EqualError(t, gen.Run(context.Background()), `invalid format "xml", expected (json|yaml)`)
+	})
+}
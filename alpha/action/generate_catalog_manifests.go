@@ -0,0 +1,177 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// GenerateCatalogSource writes a legacy (OLMv0) CatalogSource manifest that
+// serves the catalog image Image.
+type GenerateCatalogSource struct {
+	// Name defaults to a name derived from the image repository.
+	Name        string
+	Namespace   string
+	Image       string
+	DisplayName string
+	Publisher   string
+	Priority    int
+
+	// PollInterval configures how often the image is checked for updates.
+	// Polling is disabled when it is zero.
+	PollInterval time.Duration
+
+	Writer io.Writer
+}
+
+func (g GenerateCatalogSource) Run() error {
+	if g.Name == "" {
+		g.Name = catalogNameFromImage(g.Image)
+	}
+	if err := g.validate(); err != nil {
+		return err
+	}
+	return executeManifestTemplate(catalogSourceTmpl, g, g.Writer)
+}
+
+func (g GenerateCatalogSource) validate() error {
+	if g.Image == "" {
+		return fmt.Errorf("image is unset")
+	}
+	if g.Namespace == "" {
+		return fmt.Errorf("namespace is unset")
+	}
+	if errs := validation.IsDNS1123Label(g.Namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", g.Namespace, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(g.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", g.Name, strings.Join(errs, ", "))
+	}
+	if g.PollInterval < 0 {
+		return fmt.Errorf("invalid poll interval %s: must not be negative", g.PollInterval)
+	}
+	return nil
+}
+
+// GenerateClusterCatalog writes an OLMv1 ClusterCatalog manifest that serves
+// the catalog image Image.
+type GenerateClusterCatalog struct {
+	// Name defaults to a name derived from the image repository.
+	Name     string
+	Image    string
+	Priority int32
+
+	// PollInterval configures how often the image is checked for updates.
+	// It must be a whole number of minutes, and it cannot be used with
+	// digest-based image references. Polling is disabled when it is zero.
+	PollInterval time.Duration
+
+	Writer io.Writer
+}
+
+func (g GenerateClusterCatalog) Run() error {
+	if g.Name == "" {
+		g.Name = catalogNameFromImage(g.Image)
+	}
+	if err := g.validate(); err != nil {
+		return err
+	}
+	return executeManifestTemplate(clusterCatalogTmpl, g, g.Writer)
+}
+
+func (g GenerateClusterCatalog) validate() error {
+	if g.Image == "" {
+		return fmt.Errorf("image is unset")
+	}
+	if errs := validation.IsDNS1123Label(g.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", g.Name, strings.Join(errs, ", "))
+	}
+	if g.PollInterval < 0 || g.PollInterval%time.Minute != 0 {
+		return fmt.Errorf("invalid poll interval %s: must be a non-negative whole number of minutes", g.PollInterval)
+	}
+	if g.PollInterval > 0 && strings.Contains(g.Image, "@") {
+		return fmt.Errorf("poll interval cannot be set for digest-based image reference %q", g.Image)
+	}
+	return nil
+}
+
+// PollIntervalMinutes returns the poll interval in the unit used by the
+// ClusterCatalog API.
+func (g GenerateClusterCatalog) PollIntervalMinutes() int {
+	return int(g.PollInterval / time.Minute)
+}
+
+// catalogNameFromImage derives a resource name from the last path component
+// of an image reference, e.g. "quay.io/example/my-catalog:latest" becomes
+// "my-catalog".
+func catalogNameFromImage(image string) string {
+	name, _, _ := strings.Cut(image, "@")
+	name = path.Base(name)
+	name, _, _ = strings.Cut(name, ":")
+	return strings.ToLower(name)
+}
+
+func executeManifestTemplate(tmpl string, data interface{}, w io.Writer) error {
+	t, err := template.New("manifest").Funcs(template.FuncMap{"quote": quoteYAML}).Parse(tmpl)
+	if err != nil {
+		// The templates are hardcoded in the binary, so if
+		// there is a parse error, it was a programmer error.
+		panic(err)
+	}
+	return t.Execute(w, data)
+}
+
+// quoteYAML quotes s as a JSON string, which is also a valid YAML scalar.
+func quoteYAML(s string) (string, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+const catalogSourceTmpl = `apiVersion: operators.coreos.com/v1alpha1
+kind: CatalogSource
+metadata:
+  name: {{ .Name }}
+  namespace: {{ quote .Namespace }}
+spec:
+  sourceType: grpc
+  image: {{ quote .Image }}
+{{- if .DisplayName }}
+  displayName: {{ quote .DisplayName }}
+{{- end }}
+{{- if .Publisher }}
+  publisher: {{ quote .Publisher }}
+{{- end }}
+{{- if .Priority }}
+  priority: {{ .Priority }}
+{{- end }}
+  grpcPodConfig:
+    securityContextConfig: restricted
+{{- if .PollInterval }}
+  updateStrategy:
+    registryPoll:
+      interval: {{ .PollInterval }}
+{{- end }}
+`
+
+const clusterCatalogTmpl = `apiVersion: olm.operatorframework.io/v1
+kind: ClusterCatalog
+metadata:
+  name: {{ .Name }}
+spec:
+{{- if .Priority }}
+  priority: {{ .Priority }}
+{{- end }}
+  source:
+    type: Image
+    image:
+      ref: {{ quote .Image }}
+{{- if .PollInterval }}
+      pollIntervalMinutes: {{ .PollIntervalMinutes }}
+{{- end }}
+`
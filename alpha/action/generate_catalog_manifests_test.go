@@ -0,0 +1,180 @@
+package action
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestGenerateCatalogSource(t *testing.T) {
+	type spec struct {
+		name             string
+		gen              GenerateCatalogSource
+		expectedManifest string
+		expectedErr      string
+	}
+
+	specs := []spec{
+		{
+			name:        "Fail/EmptyImage",
+			gen:         GenerateCatalogSource{Namespace: "olm"},
+			expectedErr: "image is unset",
+		},
+		{
+			name:        "Fail/EmptyNamespace",
+			gen:         GenerateCatalogSource{Image: "quay.io/example/my-catalog:latest"},
+			expectedErr: "namespace is unset",
+		},
+		{
+			name:        "Fail/InvalidNamespace",
+			gen:         GenerateCatalogSource{Namespace: "olm\nfoo: bar", Image: "quay.io/example/my-catalog:latest"},
+			expectedErr: `invalid namespace "olm\nfoo: bar": ` + strings.Join(validation.IsDNS1123Label("olm\nfoo: bar"), ", "),
+		},
+		{
+			name: "Success/Minimal",
+			gen: GenerateCatalogSource{
+				Namespace: "olm",
+				Image:     "quay.io/example/my-catalog:latest",
+			},
+			expectedManifest: `apiVersion: operators.coreos.com/v1alpha1
+kind: CatalogSource
+metadata:
+  name: my-catalog
+  namespace: "olm"
+spec:
+  sourceType: grpc
+  image: "quay.io/example/my-catalog:latest"
+  grpcPodConfig:
+    securityContextConfig: restricted
+`,
+		},
+		{
+			name: "Success/AllFields",
+			gen: GenerateCatalogSource{
+				Name:         "example",
+				Namespace:    "openshift-marketplace",
+				Image:        "quay.io/example/my-catalog:latest",
+				DisplayName:  "Example Operators",
+				Publisher:    "Example, Inc.",
+				Priority:     -100,
+				PollInterval: 10 * time.Minute,
+			},
+			expectedManifest: `apiVersion: operators.coreos.com/v1alpha1
+kind: CatalogSource
+metadata:
+  name: example
+  namespace: "openshift-marketplace"
+spec:
+  sourceType: grpc
+  image: "quay.io/example/my-catalog:latest"
+  displayName: "Example Operators"
+  publisher: "Example, Inc."
+  priority: -100
+  grpcPodConfig:
+    securityContextConfig: restricted
+  updateStrategy:
+    registryPoll:
+      interval: 10m0s
+`,
+		},
+	}
+
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			s.gen.Writer = &buf
+			err := s.gen.Run()
+			if s.expectedErr != "" {
+				require.EqualError(t, err, s.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, s.expectedManifest, buf.String())
+			}
+		})
+	}
+}
+
+func TestGenerateClusterCatalog(t *testing.T) {
+	type spec struct {
+		name             string
+		gen              GenerateClusterCatalog
+		expectedManifest string
+		expectedErr      string
+	}
+
+	specs := []spec{
+		{
+			name:        "Fail/EmptyImage",
+			gen:         GenerateClusterCatalog{Name: "example"},
+			expectedErr: "image is unset",
+		},
+		{
+			name:        "Fail/PartialMinutes",
+			gen:         GenerateClusterCatalog{Image: "quay.io/example/my-catalog:latest", PollInterval: 90 * time.Second},
+			expectedErr: "invalid poll interval 1m30s: must be a non-negative whole number of minutes",
+		},
+		{
+			name:        "Fail/PollDigest",
+			gen:         GenerateClusterCatalog{Image: "quay.io/example/my-catalog@sha256:0123", PollInterval: time.Minute},
+			expectedErr: `poll interval cannot be set for digest-based image reference "quay.io/example/my-catalog@sha256:0123"`,
+		},
+		{
+			name:        "Fail/InvalidName",
+			gen:         GenerateClusterCatalog{Name: "My_Catalog", Image: "quay.io/example/my-catalog:latest"},
+			expectedErr: `invalid name "My_Catalog": a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`,
+		},
+		{
+			name: "Success/Minimal",
+			gen:  GenerateClusterCatalog{Image: "quay.io/example/my-catalog@sha256:0123"},
+			expectedManifest: `apiVersion: olm.operatorframework.io/v1
+kind: ClusterCatalog
+metadata:
+  name: my-catalog
+spec:
+  source:
+    type: Image
+    image:
+      ref: "quay.io/example/my-catalog@sha256:0123"
+`,
+		},
+		{
+			name: "Success/AllFields",
+			gen: GenerateClusterCatalog{
+				Name:         "example",
+				Image:        "quay.io/example/my-catalog:latest",
+				Priority:     100,
+				PollInterval: 15 * time.Minute,
+			},
+			expectedManifest: `apiVersion: olm.operatorframework.io/v1
+kind: ClusterCatalog
+metadata:
+  name: example
+spec:
+  priority: 100
+  source:
+    type: Image
+    image:
+      ref: "quay.io/example/my-catalog:latest"
+      pollIntervalMinutes: 15
+`,
+		},
+	}
+
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			s.gen.Writer = &buf
+			err := s.gen.Run()
+			if s.expectedErr != "" {
+				require.EqualError(t, err, s.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, s.expectedManifest, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// GenerateMirrorManifests writes what is needed to install the operators of a
+// catalog in a disconnected cluster, from a registry that its images are
+// mirrored to: the mapping of each image of the catalog to its mirror, e.g.
+// for "oc image mirror -f", and the manifest of an ImageDigestMirrorSet, or
+// of an ImageContentSourcePolicy, that redirects pulls of the images to the
+// mirror registry.
+//
+// The images of a bundle are its image and related images. The related
+// images of a bundle that has none, e.g. of a catalog that was rendered by an
+// older version of opm, are extracted from its CSV, as they are when a bundle
+// is rendered.
+type GenerateMirrorManifests struct {
+	// Catalog is the rendered catalog whose images are mirrored.
+	Catalog declcfg.DeclarativeConfig
+	// Images are additional images to mirror, e.g. the catalog image.
+	Images []string
+	// Mirror is the registry that images are mirrored to, optionally with a
+	// namespace that their repositories are mirrored under, e.g.
+	// "mirror.example.com:5000/olm". The repository of an image is mirrored
+	// to the same path under Mirror, without its registry.
+	Mirror string
+	// Name is the name of the mirror set, or policy.
+	Name string
+	// ICSP writes an ImageContentSourcePolicy, for clusters that do not
+	// support ImageDigestMirrorSets, instead of an ImageDigestMirrorSet.
+	ICSP bool
+
+	// MappingWriter is written the image mapping, one "source=mirror" line
+	// per image, and ManifestWriter the mirror set manifests.
+	MappingWriter  io.Writer
+	ManifestWriter io.Writer
+}
+
+// imageMirror is the mirror of an image, or of a repository.
+type imageMirror struct {
+	Source string
+	Mirror string
+}
+
+// mirrorSets are the repositories that are mirrored, by whether their images
+// are referenced by digest or by tag.
+type mirrorSets struct {
+	Name    string
+	Digests []imageMirror
+	Tags    []imageMirror
+}
+
+func (g GenerateMirrorManifests) Run() error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+	images, err := g.images()
+	if err != nil {
+		return err
+	}
+
+	var (
+		mapping []imageMirror
+		errs    []string
+	)
+	mirrors := mirrorSets{Name: g.Name}
+	digestRepos, tagRepos := map[string]string{}, map[string]string{}
+	for _, image := range images {
+		ref, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%q: %v", image, err))
+			continue
+		}
+		source := ref.Name()
+		repo := g.Mirror + "/" + reference.Path(ref)
+		target := repo
+		switch r := ref.(type) {
+		case reference.Canonical:
+			target += "@" + r.Digest().String()
+			digestRepos[source] = repo
+		case reference.Tagged:
+			target += ":" + r.Tag()
+			tagRepos[source] = repo
+		default:
+			// An image without a tag or digest is pulled by its latest tag.
+			target += ":latest"
+			tagRepos[source] = repo
+		}
+		mapping = append(mapping, imageMirror{Source: ref.String(), Mirror: target})
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid image references: %s", strings.Join(errs, ", "))
+	}
+	mirrors.Digests = sortedImageMirrors(digestRepos)
+	mirrors.Tags = sortedImageMirrors(tagRepos)
+
+	for _, m := range mapping {
+		if _, err := fmt.Fprintf(g.MappingWriter, "%s=%s\n", m.Source, m.Mirror); err != nil {
+			return err
+		}
+	}
+	if g.ICSP {
+		return executeManifestTemplate(imageContentSourcePolicyTmpl, mirrors, g.ManifestWriter)
+	}
+	// Mirror sets are only written if they have any mirrors.
+	if len(mirrors.Digests) > 0 {
+		if err := executeManifestTemplate(imageDigestMirrorSetTmpl, mirrors, g.ManifestWriter); err != nil {
+			return err
+		}
+	}
+	if len(mirrors.Tags) > 0 {
+		if len(mirrors.Digests) > 0 {
+			if _, err := fmt.Fprintln(g.ManifestWriter, "---"); err != nil {
+				return err
+			}
+		}
+		return executeManifestTemplate(imageTagMirrorSetTmpl, mirrors, g.ManifestWriter)
+	}
+	return nil
+}
+
+func (g GenerateMirrorManifests) validate() error {
+	if g.Mirror == "" {
+		return fmt.Errorf("mirror registry is unset")
+	}
+	if _, err := reference.ParseNamed(g.Mirror + "/image"); err != nil || strings.HasSuffix(g.Mirror, "/") {
+		return fmt.Errorf("invalid mirror registry %q: must be a registry host, optionally followed by a namespace", g.Mirror)
+	}
+	if errs := validation.IsDNS1123Subdomain(g.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", g.Name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// images returns the sorted, unique images of the catalog, and g.Images.
+func (g GenerateMirrorManifests) images() ([]string, error) {
+	images := sets.New[string]()
+	for _, image := range g.Images {
+		images.Insert(image)
+	}
+	for _, b := range g.Catalog.Bundles {
+		relatedImages, err := bundleRelatedImages(b)
+		if err != nil {
+			return nil, err
+		}
+		if b.Image != "" {
+			images.Insert(b.Image)
+		}
+		for _, ri := range relatedImages {
+			// Some CSVs have related images without an image.
+			if ri.Image != "" {
+				images.Insert(ri.Image)
+			}
+		}
+	}
+	return sets.List(images), nil
+}
+
+// bundleRelatedImages returns the related images of b, which are extracted
+// from its CSV if it has none, e.g. if it is from a catalog that was rendered
+// by an older version of opm, as they are when a bundle is rendered.
+func bundleRelatedImages(b declcfg.Bundle) ([]declcfg.RelatedImage, error) {
+	if len(b.RelatedImages) > 0 || b.CsvJSON == "" {
+		return b.RelatedImages, nil
+	}
+	var csv registry.ClusterServiceVersion
+	if err := json.Unmarshal([]byte(b.CsvJSON), &csv); err != nil {
+		return nil, fmt.Errorf("parse CSV of bundle %q: %v", b.Name, err)
+	}
+	relatedImages, err := csvRelatedImages(&csv, b.Image, false)
+	if err != nil {
+		return nil, fmt.Errorf("extract related images of bundle %q: %v", b.Name, err)
+	}
+	return relatedImages, nil
+}
+
+func sortedImageMirrors(repos map[string]string) []imageMirror {
+	mirrors := make([]imageMirror, 0, len(repos))
+	for source, mirror := range repos {
+		mirrors = append(mirrors, imageMirror{Source: source, Mirror: mirror})
+	}
+	sort.Slice(mirrors, func(i, j int) bool { return mirrors[i].Source < mirrors[j].Source })
+	return mirrors
+}
+
+const imageDigestMirrorSetTmpl = `apiVersion: config.openshift.io/v1
+kind: ImageDigestMirrorSet
+metadata:
+  name: {{ .Name }}
+spec:
+  imageDigestMirrors:
+{{- range .Digests }}
+  - source: {{ quote .Source }}
+    mirrors:
+    - {{ quote .Mirror }}
+{{- end }}
+`
+
+const imageTagMirrorSetTmpl = `apiVersion: config.openshift.io/v1
+kind: ImageTagMirrorSet
+metadata:
+  name: {{ .Name }}
+spec:
+  imageTagMirrors:
+{{- range .Tags }}
+  - source: {{ quote .Source }}
+    mirrors:
+    - {{ quote .Mirror }}
+{{- end }}
+`
+
+// imageContentSourcePolicyTmpl writes an ImageContentSourcePolicy, which
+// only applies to images referenced by digest.
+const imageContentSourcePolicyTmpl = `apiVersion: operator.openshift.io/v1alpha1
+kind: ImageContentSourcePolicy
+metadata:
+  name: {{ .Name }}
+spec:
+  repositoryDigestMirrors:
+{{- range .Digests }}
+  - source: {{ quote .Source }}
+    mirrors:
+    - {{ quote .Mirror }}
+{{- end }}
+`
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestGenerateMirrorManifests(t *testing.T) {
+	const (
+		fooDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		barDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	catalog := declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{
+			{
+				Schema:  declcfg.SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v0.1.0",
+				Image:   "quay.io/example/foo-bundle@" + fooDigest,
+				RelatedImages: []declcfg.RelatedImage{
+					{Name: "operator", Image: "quay.io/example/foo-operator@" + fooDigest},
+					{Name: "operand", Image: "registry.example.com/team/foo-operand:v0.1.0"},
+					{Name: "bundle", Image: "quay.io/example/foo-bundle@" + fooDigest},
+					{Name: "empty"},
+				},
+			},
+			{
+				// Rendered without related images, which are extracted from
+				// the CSV.
+				Schema:  declcfg.SchemaBundle,
+				Package: "bar",
+				Name:    "bar.v0.1.0",
+				Image:   "quay.io/example/bar-bundle@" + barDigest,
+				CsvJSON: `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"bar.v0.1.0"},` +
+					`"spec":{"install":{"strategy":"deployment","spec":{"deployments":[{"name":"bar","spec":{"template":{"spec":` +
+					`{"containers":[{"name":"bar","image":"busybox"}]}}}}]}}}}`,
+			},
+		},
+	}
+
+	type spec struct {
+		name             string
+		gen              GenerateMirrorManifests
+		expectedMapping  string
+		expectedManifest string
+		expectedErr      string
+	}
+	specs := []spec{
+		{
+			name:        "Fail/EmptyMirror",
+			gen:         GenerateMirrorManifests{Catalog: catalog, Name: "mirror"},
+			expectedErr: "mirror registry is unset",
+		},
+		{
+			name:        "Fail/InvalidMirror",
+			gen:         GenerateMirrorManifests{Catalog: catalog, Name: "mirror", Mirror: "mirror.example.com/"},
+			expectedErr: `invalid mirror registry "mirror.example.com/"`,
+		},
+		{
+			name:        "Fail/InvalidName",
+			gen:         GenerateMirrorManifests{Catalog: catalog, Name: "Mirror", Mirror: "mirror.example.com"},
+			expectedErr: `invalid name "Mirror"`,
+		},
+		{
+			name: "Fail/InvalidImage",
+			gen: GenerateMirrorManifests{
+				Catalog: declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{{Name: "foo.v0.1.0", Image: "quay.io/Example/foo"}}},
+				Name:    "mirror",
+				Mirror:  "mirror.example.com",
+			},
+			expectedErr: `invalid image references: "quay.io/Example/foo"`,
+		},
+		{
+			name: "Success/ImageMirrorSets",
+			gen: GenerateMirrorManifests{
+				Catalog: catalog,
+				Images:  []string{"quay.io/example/catalog:latest"},
+				Name:    "catalog-mirror",
+				Mirror:  "mirror.example.com:5000/olm",
+			},
+			expectedMapping: `docker.io/library/busybox=mirror.example.com:5000/olm/library/busybox:latest
+quay.io/example/bar-bundle@` + barDigest + `=mirror.example.com:5000/olm/example/bar-bundle@` + barDigest + `
+quay.io/example/catalog:latest=mirror.example.com:5000/olm/example/catalog:latest
+quay.io/example/foo-bundle@` + fooDigest + `=mirror.example.com:5000/olm/example/foo-bundle@` + fooDigest + `
+quay.io/example/foo-operator@` + fooDigest + `=mirror.example.com:5000/olm/example/foo-operator@` + fooDigest + `
+registry.example.com/team/foo-operand:v0.1.0=mirror.example.com:5000/olm/team/foo-operand:v0.1.0
+`,
+			expectedManifest: `apiVersion: config.openshift.io/v1
+kind: ImageDigestMirrorSet
+metadata:
+  name: catalog-mirror
+spec:
+  imageDigestMirrors:
+  - source: "quay.io/example/bar-bundle"
+    mirrors:
+    - "mirror.example.com:5000/olm/example/bar-bundle"
+  - source: "quay.io/example/foo-bundle"
+    mirrors:
+    - "mirror.example.com:5000/olm/example/foo-bundle"
+  - source: "quay.io/example/foo-operator"
+    mirrors:
+    - "mirror.example.com:5000/olm/example/foo-operator"
+---
+apiVersion: config.openshift.io/v1
+kind: ImageTagMirrorSet
+metadata:
+  name: catalog-mirror
+spec:
+  imageTagMirrors:
+  - source: "docker.io/library/busybox"
+    mirrors:
+    - "mirror.example.com:5000/olm/library/busybox"
+  - source: "quay.io/example/catalog"
+    mirrors:
+    - "mirror.example.com:5000/olm/example/catalog"
+  - source: "registry.example.com/team/foo-operand"
+    mirrors:
+    - "mirror.example.com:5000/olm/team/foo-operand"
+`,
+		},
+		{
+			name: "Success/ImageContentSourcePolicy",
+			gen: GenerateMirrorManifests{
+				Catalog: declcfg.DeclarativeConfig{Bundles: catalog.Bundles[:1]},
+				Name:    "catalog-mirror",
+				Mirror:  "mirror.example.com",
+				ICSP:    true,
+			},
+			expectedMapping: `quay.io/example/foo-bundle@` + fooDigest + `=mirror.example.com/example/foo-bundle@` + fooDigest + `
+quay.io/example/foo-operator@` + fooDigest + `=mirror.example.com/example/foo-operator@` + fooDigest + `
+registry.example.com/team/foo-operand:v0.1.0=mirror.example.com/team/foo-operand:v0.1.0
+`,
+			expectedManifest: `apiVersion: operator.openshift.io/v1alpha1
+kind: ImageContentSourcePolicy
+metadata:
+  name: catalog-mirror
+spec:
+  repositoryDigestMirrors:
+  - source: "quay.io/example/foo-bundle"
+    mirrors:
+    - "mirror.example.com/example/foo-bundle"
+  - source: "quay.io/example/foo-operator"
+    mirrors:
+    - "mirror.example.com/example/foo-operator"
+`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			var mapping, manifest bytes.Buffer
+			s.gen.MappingWriter, s.gen.ManifestWriter = &mapping, &manifest
+			err := s.gen.Run()
+			if s.expectedErr != "" {
+				require.ErrorContains(t, err, s.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, s.expectedMapping, mapping.String())
+			require.Equal(t, s.expectedManifest, manifest.String())
+		})
+	}
+}
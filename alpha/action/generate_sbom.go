@@ -0,0 +1,459 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/distribution/reference"
+	"github.com/google/uuid"
+	"github.com/opencontainers/go-digest"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// SBOMFormat is the format of a software bill of materials.
+type SBOMFormat string
+
+const (
+	// SBOMFormatSPDX is an SPDX 2.3 document, in JSON.
+	SBOMFormatSPDX SBOMFormat = "spdx"
+	// SBOMFormatCycloneDX is a CycloneDX 1.5 BOM, in JSON.
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+)
+
+// GenerateSBOM writes a software bill of materials of a catalog, for supply
+// chain tooling: the bundles of the catalog, the images of each bundle, i.e.
+// its image and related images, and the bundles that each bundle depends on.
+//
+// A bundle depends on the bundles of the catalog that satisfy its
+// olm.package.required properties, and on those of other packages that
+// provide the APIs of its olm.gvk.required properties. Requirements that no
+// bundle of the catalog satisfies are not listed.
+type GenerateSBOM struct {
+	Catalog declcfg.DeclarativeConfig
+	Format  SBOMFormat
+	// Name is the name of the catalog in the document, e.g. its image.
+	Name string
+	// ResolveDigest, if set, resolves the digests of the images that are
+	// referenced by tag. Otherwise, only the images that are referenced by
+	// digest have a digest in the document.
+	ResolveDigest func(ctx context.Context, image string) (digest.Digest, error)
+	// Created is the creation time of the document. It defaults to the
+	// current time.
+	Created time.Time
+
+	Writer io.Writer
+}
+
+// sbomBundle is a bundle of the catalog, with the images of the bundle and
+// the keys of the bundles it depends on.
+type sbomBundle struct {
+	Package  string   `json:"package"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	Images   []string `json:"images,omitempty"`
+	Requires []string `json:"requires,omitempty"`
+}
+
+func (b sbomBundle) key() string {
+	return b.Package + "/" + b.Name
+}
+
+// sbomImage is an image of the catalog, by its reference in the catalog.
+type sbomImage struct {
+	Ref    string        `json:"ref"`
+	Name   string        `json:"name"`
+	Tag    string        `json:"tag,omitempty"`
+	Digest digest.Digest `json:"digest,omitempty"`
+}
+
+// version returns the version of the image in the document: its tag, or
+// digest if it is referenced by digest only.
+func (i sbomImage) version() string {
+	if i.Tag != "" {
+		return i.Tag
+	}
+	return i.Digest.String()
+}
+
+// purl returns the package URL of the image, which identifies it by digest,
+// or "" if its digest is unknown.
+func (i sbomImage) purl() string {
+	if i.Digest == "" {
+		return ""
+	}
+	purl := fmt.Sprintf("pkg:oci/%s@%s?repository_url=%s", path.Base(i.Name), strings.Replace(i.Digest.String(), ":", "%3A", 1), i.Name)
+	if i.Tag != "" {
+		purl += "&tag=" + i.Tag
+	}
+	return purl
+}
+
+func (g GenerateSBOM) Run(ctx context.Context) error {
+	if g.Format != SBOMFormatSPDX && g.Format != SBOMFormatCycloneDX {
+		return fmt.Errorf("unknown SBOM format %q: must be %q or %q", g.Format, SBOMFormatSPDX, SBOMFormatCycloneDX)
+	}
+	bundles, images, err := g.inventory(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := g.Name
+	if name == "" {
+		name = "catalog"
+	}
+	created := g.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	// The document is identified by its content, so that documents of the
+	// same catalog have the same identifier.
+	content, err := json.Marshal(struct {
+		Name    string       `json:"name"`
+		Bundles []sbomBundle `json:"bundles"`
+		Images  []sbomImage  `json:"images"`
+	}{name, bundles, images})
+	if err != nil {
+		return err
+	}
+	namespace := fmt.Sprintf("https://operatorframework.io/sbom/%s-%x", path.Base(name), sha256.Sum256(content))
+
+	var doc interface{}
+	switch g.Format {
+	case SBOMFormatSPDX:
+		doc = spdxDocument(name, namespace, created, bundles, images)
+	case SBOMFormatCycloneDX:
+		doc = cycloneDXDocument(name, namespace, created, bundles, images)
+	}
+	enc := json.NewEncoder(g.Writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// inventory returns the bundles of the catalog, sorted by package and name,
+// and their images, sorted by reference.
+func (g GenerateSBOM) inventory(ctx context.Context) ([]sbomBundle, []sbomImage, error) {
+	var (
+		bundles   = make([]sbomBundle, 0, len(g.Catalog.Bundles))
+		props     = make([]*property.Properties, 0, len(g.Catalog.Bundles))
+		imageRefs = sets.New[string]()
+	)
+	for _, b := range g.Catalog.Bundles {
+		p, err := property.Parse(b.Properties)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse properties of bundle %q: %v", b.Name, err)
+		}
+		relatedImages, err := bundleRelatedImages(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		bundleImages := sets.New[string]()
+		if b.Image != "" {
+			bundleImages.Insert(b.Image)
+		}
+		for _, ri := range relatedImages {
+			// Some CSVs have related images without an image.
+			if ri.Image != "" {
+				bundleImages.Insert(ri.Image)
+			}
+		}
+		imageRefs = imageRefs.Union(bundleImages)
+
+		sb := sbomBundle{Package: b.Package, Name: b.Name, Images: sets.List(bundleImages)}
+		if len(p.Packages) > 0 {
+			sb.Version = p.Packages[0].Version
+		}
+		bundles = append(bundles, sb)
+		props = append(props, p)
+	}
+
+	// Index the bundles by package and by the APIs they provide, to find the
+	// bundles that satisfy the requirements of each bundle.
+	byPackage := map[string][]*sbomBundle{}
+	byGVK := map[property.GVK][]*sbomBundle{}
+	for i := range bundles {
+		byPackage[bundles[i].Package] = append(byPackage[bundles[i].Package], &bundles[i])
+		for _, gvk := range props[i].GVKs {
+			byGVK[gvk] = append(byGVK[gvk], &bundles[i])
+		}
+	}
+	for i := range bundles {
+		requires := sets.New[string]()
+		for _, pr := range props[i].PackagesRequired {
+			versionRange, err := semver.ParseRange(pr.VersionRange)
+			if err != nil {
+				return nil, nil, fmt.Errorf("bundle %q: invalid version range %q of required package %q: %v", bundles[i].Name, pr.VersionRange, pr.PackageName, err)
+			}
+			for _, candidate := range byPackage[pr.PackageName] {
+				if v, err := semver.Parse(candidate.Version); err == nil && versionRange(v) {
+					requires.Insert(candidate.key())
+				}
+			}
+		}
+		for _, gvk := range props[i].GVKsRequired {
+			for _, candidate := range byGVK[property.GVK(gvk)] {
+				if candidate.Package != bundles[i].Package {
+					requires.Insert(candidate.key())
+				}
+			}
+		}
+		bundles[i].Requires = sets.List(requires)
+	}
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i].key() < bundles[j].key() })
+
+	images := make([]sbomImage, 0, imageRefs.Len())
+	var errs []string
+	for _, ref := range sets.List(imageRefs) {
+		img, err := g.image(ctx, ref)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%q: %v", ref, err))
+			continue
+		}
+		images = append(images, img)
+	}
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("resolve images: %s", strings.Join(errs, ", "))
+	}
+	return bundles, images, nil
+}
+
+func (g GenerateSBOM) image(ctx context.Context, ref string) (sbomImage, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return sbomImage{}, err
+	}
+	img := sbomImage{Ref: ref, Name: named.Name()}
+	if tagged, ok := named.(reference.Tagged); ok {
+		img.Tag = tagged.Tag()
+	}
+	if canonical, ok := named.(reference.Canonical); ok {
+		img.Digest = canonical.Digest()
+	} else if g.ResolveDigest != nil {
+		if img.Digest, err = g.ResolveDigest(ctx, ref); err != nil {
+			return sbomImage{}, fmt.Errorf("resolve digest: %v", err)
+		}
+	}
+	return img, nil
+}
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+	Comment               string            `json:"comment,omitempty"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func spdxDocument(name, namespace string, created time.Time, bundles []sbomBundle, images []sbomImage) spdxDoc {
+	const catalogID = "SPDXRef-Catalog"
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: namespace,
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: opm"},
+		},
+		Packages: []spdxPackage{{
+			SPDXID:                catalogID,
+			Name:                  name,
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: "CONTAINER",
+		}},
+		Relationships: []spdxRelationship{{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: catalogID}},
+	}
+
+	imageIDs := map[string]string{}
+	for i, img := range images {
+		id := fmt.Sprintf("SPDXRef-Image-%d", i+1)
+		imageIDs[img.Ref] = id
+		p := spdxPackage{
+			SPDXID:                id,
+			Name:                  img.Name,
+			VersionInfo:           img.version(),
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: "CONTAINER",
+		}
+		if img.Digest != "" && img.Digest.Algorithm() == digest.SHA256 {
+			p.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: img.Digest.Encoded()}}
+		}
+		if purl := img.purl(); purl != "" {
+			p.ExternalRefs = []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+		}
+		doc.Packages = append(doc.Packages, p)
+	}
+
+	bundleIDs := map[string]string{}
+	for i, b := range bundles {
+		bundleIDs[b.key()] = fmt.Sprintf("SPDXRef-Bundle-%d", i+1)
+	}
+	for _, b := range bundles {
+		id := bundleIDs[b.key()]
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:                id,
+			Name:                  b.Name,
+			VersionInfo:           b.Version,
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: "APPLICATION",
+			Comment:               fmt.Sprintf("bundle of package %q", b.Package),
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: catalogID, RelationshipType: "CONTAINS", RelatedSPDXElement: id})
+		for _, img := range b.Images {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: id, RelationshipType: "CONTAINS", RelatedSPDXElement: imageIDs[img]})
+		}
+		for _, req := range b.Requires {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: id, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: bundleIDs[req]})
+		}
+	}
+	return doc
+}
+
+type cycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     cycloneDXTools     `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Group      string              `json:"group,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	Hashes     []cycloneDXHash     `json:"hashes,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+func cycloneDXDocument(name, namespace string, created time.Time, bundles []sbomBundle, images []sbomImage) cycloneDXBOM {
+	const catalogRef = "catalog"
+	bom := cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid.NewSHA1(uuid.NameSpaceURL, []byte(namespace)).String(),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: created.UTC().Format(time.RFC3339),
+			Tools:     cycloneDXTools{Components: []cycloneDXComponent{{Type: "application", Name: "opm"}}},
+			Component: cycloneDXComponent{Type: "container", BOMRef: catalogRef, Name: name},
+		},
+		Components:   []cycloneDXComponent{},
+		Dependencies: []cycloneDXDependency{},
+	}
+
+	catalog := cycloneDXDependency{Ref: catalogRef, DependsOn: []string{}}
+	for _, b := range bundles {
+		ref := "bundle:" + b.key()
+		bom.Components = append(bom.Components, cycloneDXComponent{
+			Type:       "application",
+			BOMRef:     ref,
+			Group:      b.Package,
+			Name:       b.Name,
+			Version:    b.Version,
+			Properties: []cycloneDXProperty{{Name: "olm:package", Value: b.Package}},
+		})
+		catalog.DependsOn = append(catalog.DependsOn, ref)
+		dep := cycloneDXDependency{Ref: ref, DependsOn: []string{}}
+		for _, img := range b.Images {
+			dep.DependsOn = append(dep.DependsOn, "image:"+img)
+		}
+		for _, req := range b.Requires {
+			dep.DependsOn = append(dep.DependsOn, "bundle:"+req)
+		}
+		bom.Dependencies = append(bom.Dependencies, dep)
+	}
+	bom.Dependencies = append([]cycloneDXDependency{catalog}, bom.Dependencies...)
+
+	for _, img := range images {
+		c := cycloneDXComponent{
+			Type:    "container",
+			BOMRef:  "image:" + img.Ref,
+			Name:    img.Name,
+			Version: img.version(),
+			PURL:    img.purl(),
+		}
+		if img.Digest != "" && img.Digest.Algorithm() == digest.SHA256 {
+			c.Hashes = []cycloneDXHash{{Alg: "SHA-256", Content: img.Digest.Encoded()}}
+		}
+		bom.Components = append(bom.Components, c)
+	}
+	return bom
+}
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestGenerateSBOM(t *testing.T) {
+	const (
+		fooDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		barDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	catalog := declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{
+			{
+				Schema:  declcfg.SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v0.1.0",
+				Image:   "quay.io/example/foo-bundle@" + fooDigest,
+				Properties: []property.Property{
+					property.MustBuildPackage("foo", "0.1.0"),
+					property.MustBuildPackageRequired("bar", ">=0.2.0"),
+					property.MustBuildGVKRequired("example.com", "v1", "Baz"),
+				},
+				RelatedImages: []declcfg.RelatedImage{
+					{Name: "operator", Image: "quay.io/example/foo-operator:v0.1.0"},
+					{Name: "bundle", Image: "quay.io/example/foo-bundle@" + fooDigest},
+				},
+			},
+			{
+				Schema:     declcfg.SchemaBundle,
+				Package:    "bar",
+				Name:       "bar.v0.1.0",
+				Image:      "quay.io/example/bar-bundle:v0.1.0",
+				Properties: []property.Property{property.MustBuildPackage("bar", "0.1.0")},
+			},
+			{
+				Schema:  declcfg.SchemaBundle,
+				Package: "bar",
+				Name:    "bar.v0.2.0",
+				Image:   "quay.io/example/bar-bundle@" + barDigest,
+				Properties: []property.Property{
+					property.MustBuildPackage("bar", "0.2.0"),
+					property.MustBuildGVK("example.com", "v1", "Baz"),
+				},
+			},
+		},
+	}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("SPDX", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, GenerateSBOM{Catalog: catalog, Format: SBOMFormatSPDX, Name: "catalog", Created: created, Writer: &buf}.Run(context.Background()))
+
+		var doc spdxDoc
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		require.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+		require.Equal(t, "2024-01-02T03:04:05Z", doc.CreationInfo.Created)
+		require.Regexp(t, `^https://operatorframework.io/sbom/catalog-[0-9a-f]{64}$`, doc.DocumentNamespace)
+
+		names := map[string]string{doc.SPDXID: "DOCUMENT"}
+		for _, p := range doc.Packages {
+			names[p.SPDXID] = p.Name + "@" + p.VersionInfo
+		}
+		var relationships []string
+		for _, r := range doc.Relationships {
+			relationships = append(relationships, fmt.Sprintf("%s %s %s", names[r.SPDXElementID], r.RelationshipType, names[r.RelatedSPDXElement]))
+		}
+		require.Equal(t, []string{
+			"DOCUMENT DESCRIBES catalog@",
+			"catalog@ CONTAINS bar.v0.1.0@0.1.0",
+			"bar.v0.1.0@0.1.0 CONTAINS quay.io/example/bar-bundle@v0.1.0",
+			"catalog@ CONTAINS bar.v0.2.0@0.2.0",
+			"bar.v0.2.0@0.2.0 CONTAINS quay.io/example/bar-bundle@" + barDigest,
+			"catalog@ CONTAINS foo.v0.1.0@0.1.0",
+			"foo.v0.1.0@0.1.0 CONTAINS quay.io/example/foo-bundle@" + fooDigest,
+			"foo.v0.1.0@0.1.0 CONTAINS quay.io/example/foo-operator@v0.1.0",
+			"foo.v0.1.0@0.1.0 DEPENDS_ON bar.v0.2.0@0.2.0",
+		}, relationships)
+
+		// Images referenced by digest are identified by their digest.
+		for _, p := range doc.Packages {
+			if p.Name == "quay.io/example/foo-bundle" {
+				require.Equal(t, []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: "1111111111111111111111111111111111111111111111111111111111111111"}}, p.Checksums)
+				require.Equal(t, "pkg:oci/foo-bundle@sha256%3A1111111111111111111111111111111111111111111111111111111111111111?repository_url=quay.io/example/foo-bundle", p.ExternalRefs[0].ReferenceLocator)
+			}
+			if p.Name == "quay.io/example/foo-operator" {
+				require.Empty(t, p.Checksums)
+				require.Empty(t, p.ExternalRefs)
+			}
+		}
+	})
+
+	t.Run("CycloneDX", func(t *testing.T) {
+		resolved := digest.Digest("sha256:3333333333333333333333333333333333333333333333333333333333333333")
+		var buf bytes.Buffer
+		require.NoError(t, GenerateSBOM{
+			Catalog: catalog,
+			Format:  SBOMFormatCycloneDX,
+			Created: created,
+			ResolveDigest: func(_ context.Context, image string) (digest.Digest, error) {
+				return resolved, nil
+			},
+			Writer: &buf,
+		}.Run(context.Background()))
+
+		var bom cycloneDXBOM
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &bom))
+		require.Equal(t, "CycloneDX", bom.BOMFormat)
+		require.Regexp(t, `^urn:uuid:[0-9a-f-]{36}$`, bom.SerialNumber)
+		require.Equal(t, cycloneDXComponent{Type: "container", BOMRef: "catalog", Name: "catalog"}, bom.Metadata.Component)
+		require.Len(t, bom.Components, 7)
+		require.Contains(t, bom.Components, cycloneDXComponent{
+			Type:    "container",
+			BOMRef:  "image:quay.io/example/foo-operator:v0.1.0",
+			Name:    "quay.io/example/foo-operator",
+			Version: "v0.1.0",
+			Hashes:  []cycloneDXHash{{Alg: "SHA-256", Content: resolved.Encoded()}},
+			PURL:    "pkg:oci/foo-operator@sha256%3A3333333333333333333333333333333333333333333333333333333333333333?repository_url=quay.io/example/foo-operator&tag=v0.1.0",
+		})
+		require.Equal(t, []cycloneDXDependency{
+			{Ref: "catalog", DependsOn: []string{"bundle:bar/bar.v0.1.0", "bundle:bar/bar.v0.2.0", "bundle:foo/foo.v0.1.0"}},
+			{Ref: "bundle:bar/bar.v0.1.0", DependsOn: []string{"image:quay.io/example/bar-bundle:v0.1.0"}},
+			{Ref: "bundle:bar/bar.v0.2.0", DependsOn: []string{"image:quay.io/example/bar-bundle@" + barDigest}},
+			{Ref: "bundle:foo/foo.v0.1.0", DependsOn: []string{
+				"image:quay.io/example/foo-bundle@" + fooDigest,
+				"image:quay.io/example/foo-operator:v0.1.0",
+				"bundle:bar/bar.v0.2.0",
+			}},
+		}, bom.Dependencies)
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		var a, b bytes.Buffer
+		require.NoError(t, GenerateSBOM{Catalog: catalog, Format: SBOMFormatCycloneDX, Created: created, Writer: &a}.Run(context.Background()))
+		require.NoError(t, GenerateSBOM{Catalog: catalog, Format: SBOMFormatCycloneDX, Created: created, Writer: &b}.Run(context.Background()))
+		require.Equal(t, a.String(), b.String())
+	})
+
+	t.Run("Fail/UnknownFormat", func(t *testing.T) {
+		err := GenerateSBOM{Catalog: catalog, Format: "swid"}.Run(context.Background())
+		require.ErrorContains(t, err, `unknown SBOM format "swid"`)
+	})
+
+	t.Run("Fail/ResolveDigest", func(t *testing.T) {
+		err := GenerateSBOM{
+			Catalog: catalog,
+			Format:  SBOMFormatSPDX,
+			ResolveDigest: func(context.Context, string) (digest.Digest, error) {
+				return "", fmt.Errorf("not found")
+			},
+			Writer: &bytes.Buffer{},
+		}.Run(context.Background())
+		require.ErrorContains(t, err, `"quay.io/example/foo-operator:v0.1.0": resolve digest: not found`)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/h2non/filetype"
 
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 )
 
 type Init struct {
@@ -41,7 +42,7 @@ func (i Init) Run() (*declcfg.Package, error) {
 			return nil, fmt.Errorf("detect icon mediatype: %v", err)
 		}
 		if iconType.MIME.Type != "image" {
-			return nil, fmt.Errorf("detected invalid type %q: not an image", iconType.MIME.Value)
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "detected invalid type %q: not an image", iconType.MIME.Value)
 		}
 		pkg.Icon = &declcfg.Icon{
 			Data:      iconData,
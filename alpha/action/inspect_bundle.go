@@ -0,0 +1,260 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// InspectBundle reports the content of a bundle image without rendering it
+// into a catalog.
+type InspectBundle struct {
+	BundleImage string
+	Registry    image.Registry
+}
+
+// layerLister is implemented by registries that can report the layers of the
+// images they store, such as containerdregistry.Registry.
+type layerLister interface {
+	Layers(ctx context.Context, ref image.Reference) ([]ocispec.Descriptor, error)
+}
+
+type InspectBundleResult struct {
+	Image          string            `json:"image"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	Package        string            `json:"package"`
+	Channels       []string          `json:"channels,omitempty"`
+	DefaultChannel string            `json:"defaultChannel,omitempty"`
+	CSV            string            `json:"csv"`
+	Version        string            `json:"version,omitempty"`
+	Manifests      []InspectedObject `json:"manifests"`
+	// ManifestErrors are the manifest files that could not be parsed. The
+	// bundle parser skips such files, so they do not fail the inspection.
+	ManifestErrors []string             `json:"manifestErrors,omitempty"`
+	ProvidedAPIs   []InspectedAPI       `json:"providedAPIs,omitempty"`
+	RequiredAPIs   []InspectedAPI       `json:"requiredAPIs,omitempty"`
+	RelatedImages  []string             `json:"relatedImages,omitempty"`
+	Layers         []ocispec.Descriptor `json:"layers,omitempty"`
+}
+
+// InspectedObject is a manifest of a bundle, identified by the file it is in.
+type InspectedObject struct {
+	File       string `json:"file"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// InspectedAPI is an API that a bundle provides or requires.
+type InspectedAPI struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	Plural  string `json:"plural,omitempty"`
+}
+
+func (i InspectBundle) Run(ctx context.Context) (*InspectBundleResult, error) {
+	ref := image.SimpleReference(i.BundleImage)
+	if err := i.Registry.Pull(ctx, ref); err != nil {
+		return nil, liberrors.Errorf(liberrors.CodeImagePullFailed, "failed to pull image %q: %v", ref, err)
+	}
+	labels, err := i.Registry.Labels(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels for image %q: %v", ref, err)
+	}
+	if _, ok := labels[bundle.PackageLabel]; !ok {
+		return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "image %q is not a bundle image: label %q not found", ref, bundle.PackageLabel)
+	}
+	tmpDir, err := os.MkdirTemp("", "inspect-unpack-")
+	if err != nil {
+		return nil, fmt.Errorf("create tempdir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := i.Registry.Unpack(ctx, ref, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to unpack image %q: %v", ref, err)
+	}
+
+	img, err := registry.NewImageInput(ref, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bundle
+
+	result := &InspectBundleResult{
+		Image:    i.BundleImage,
+		Labels:   labels,
+		Package:  b.Package,
+		Channels: b.Channels,
+		CSV:      b.Name,
+	}
+	if b.Annotations != nil {
+		result.DefaultChannel = b.Annotations.DefaultChannelName
+	}
+	if result.Version, err = b.Version(); err != nil {
+		return nil, err
+	}
+	if result.Annotations, err = readAnnotations(filepath.Join(tmpDir, bundle.MetadataDir)); err != nil {
+		return nil, err
+	}
+	if result.Manifests, result.ManifestErrors, err = readManifests(filepath.Join(tmpDir, bundle.ManifestsDir)); err != nil {
+		return nil, err
+	}
+
+	provided, err := b.ProvidedAPIs()
+	if err != nil {
+		return nil, fmt.Errorf("error getting provided apis: %v", err)
+	}
+	result.ProvidedAPIs = inspectedAPIs(provided)
+	required, err := b.RequiredAPIs()
+	if err != nil {
+		return nil, fmt.Errorf("error getting required apis: %v", err)
+	}
+	result.RequiredAPIs = inspectedAPIs(required)
+
+	csv, err := b.ClusterServiceVersion()
+	if err != nil {
+		return nil, err
+	}
+	relatedImages, err := csv.GetRelatedImages()
+	if err != nil {
+		return nil, fmt.Errorf("error getting related images: %v", err)
+	}
+	for relatedImage := range relatedImages {
+		result.RelatedImages = append(result.RelatedImages, relatedImage)
+	}
+	sort.Strings(result.RelatedImages)
+
+	// Layer sizes are reported only by registries that keep image manifests.
+	if ll, ok := i.Registry.(layerLister); ok {
+		if result.Layers, err = ll.Layers(ctx, ref); err != nil {
+			return nil, fmt.Errorf("failed to get layers of image %q: %v", ref, err)
+		}
+	}
+	return result, nil
+}
+
+func (r *InspectBundleResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
+
+// readAnnotations returns the annotations of the first file in dir that has
+// any, as the bundle parser does.
+func readAnnotations(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		var metadata bundle.AnnotationMetadata
+		if err := decodeFile(filepath.Join(dir, e.Name()), &metadata); err != nil {
+			continue
+		}
+		if len(metadata.Annotations) > 0 {
+			return metadata.Annotations, nil
+		}
+	}
+	return nil, nil
+}
+
+// readManifests returns the objects of the manifest files in dir, in lexical
+// order of the files and of the documents in each file, and the files that
+// could not be parsed. Unparseable files are reported rather than returned as
+// an error, as the bundle parser skips them.
+func readManifests(dir string) ([]InspectedObject, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	objs := []InspectedObject{}
+	var parseErrs []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		manifests, err := decodeManifests(filepath.Join(dir, e.Name()))
+		if err != nil {
+			parseErrs = append(parseErrs, fmt.Sprintf("%s: %v", e.Name(), err))
+			continue
+		}
+		for _, obj := range manifests {
+			objs = append(objs, InspectedObject{
+				File:       e.Name(),
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Name:       obj.GetName(),
+			})
+		}
+	}
+	return objs, parseErrs, nil
+}
+
+func decodeFile(path string, into interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(into)
+}
+
+// decodeManifests returns the objects in the file at path, skipping empty
+// documents.
+func decodeManifests(path string) ([]*unstructured.Unstructured, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	var objs []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := dec.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(obj.Object) > 0 {
+			objs = append(objs, obj)
+		}
+	}
+}
+
+func inspectedAPIs(apis map[registry.APIKey]struct{}) []InspectedAPI {
+	var out []InspectedAPI
+	for api := range apis {
+		out = append(out, InspectedAPI{Group: api.Group, Version: api.Version, Kind: api.Kind, Plural: api.Plural})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Group != out[j].Group {
+			return out[i].Group < out[j].Group
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out
+}
@@ -0,0 +1,41 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadManifests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a-service.yaml": `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo-config
+`,
+		"b-invalid.yaml": "apiVersion: v1\nkind: [Service\n",
+		"c-empty.yaml":   "---\n",
+		".hidden.yaml":   "apiVersion: v1\nkind: Secret\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	objs, parseErrs, err := readManifests(dir)
+	require.NoError(t, err)
+	require.Equal(t, []InspectedObject{
+		{File: "a-service.yaml", APIVersion: "v1", Kind: "Service", Name: "foo"},
+		{File: "a-service.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "foo-config"},
+	}, objs)
+	require.Len(t, parseErrs, 1)
+	require.Contains(t, parseErrs[0], "b-invalid.yaml: ")
+}
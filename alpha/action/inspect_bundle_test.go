@@ -0,0 +1,86 @@
+package action_test
+
+import (
+	"context"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+)
+
+// layeredRegistry is a registry that reports the same layers for every image.
+type layeredRegistry struct {
+	image.Registry
+	layers []ocispec.Descriptor
+}
+
+func (r layeredRegistry) Layers(context.Context, image.Reference) ([]ocispec.Descriptor, error) {
+	return r.layers, nil
+}
+
+func TestInspectBundle(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	expected := &action.InspectBundleResult{
+		Image:  "test.registry/foo-operator/foo-bundle:v0.2.0",
+		Labels: map[string]string{bundle.PackageLabel: "foo"},
+		Annotations: map[string]string{
+			"operators.operatorframework.io.bundle.package.v1":         "foo",
+			"operators.operatorframework.io.bundle.channels.v1":        "beta,stable",
+			"operators.operatorframework.io.bundle.channel.default.v1": "beta",
+		},
+		Package:        "foo",
+		Channels:       []string{"beta", "stable"},
+		DefaultChannel: "beta",
+		CSV:            "foo.v0.2.0",
+		Version:        "0.2.0",
+		Manifests: []action.InspectedObject{
+			{File: "foo.v0.2.0.csv.yaml", APIVersion: "operators.coreos.com/v1alpha1", Kind: "ClusterServiceVersion", Name: "foo.v0.2.0"},
+			{File: "foos.test.foo.crd.yaml", APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition", Name: "foos.test.foo"},
+		},
+		ProvidedAPIs: []action.InspectedAPI{
+			{Group: "test.foo", Version: "v1", Kind: "Foo", Plural: "foos"},
+		},
+		RelatedImages: []string{
+			"test.registry/foo-operator/foo-other:v0.2.0",
+			"test.registry/foo-operator/foo:v0.2.0",
+		},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		actual, err := action.InspectBundle{
+			BundleImage: "test.registry/foo-operator/foo-bundle:v0.2.0",
+			Registry:    reg,
+		}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+	t.Run("Success/Layers", func(t *testing.T) {
+		layers := []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: "sha256:0123", Size: 1024}}
+		actual, err := action.InspectBundle{
+			BundleImage: "test.registry/foo-operator/foo-bundle:v0.2.0",
+			Registry:    layeredRegistry{Registry: reg, layers: layers},
+		}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, layers, actual.Layers)
+	})
+	t.Run("Error/NotABundle", func(t *testing.T) {
+		_, err := action.InspectBundle{
+			BundleImage: "test.registry/foo-operator/foo-index-declcfg:v0.2.0",
+			Registry:    reg,
+		}.Run(context.Background())
+		require.ErrorContains(t, err, "is not a bundle image")
+	})
+	t.Run("Error/NotFound", func(t *testing.T) {
+		_, err := action.InspectBundle{
+			BundleImage: "test.registry/foo-operator/foo-bundle:v9.9.9",
+			Registry:    reg,
+		}.Run(context.Background())
+		require.ErrorContains(t, err, "failed to pull image")
+	})
+}
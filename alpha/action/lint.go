@@ -0,0 +1,335 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// LintCode identifies the kind of problem a lint finding reports.
+type LintCode string
+
+const (
+	// LintUnreachableBundle is reported for bundles that are not an entry
+	// of any channel of their package, so that OLM can never install them.
+	LintUnreachableBundle LintCode = "UnreachableBundle"
+	// LintDanglingReplaces is reported for channel entries that replace a
+	// bundle that is not an entry of the channel.
+	LintDanglingReplaces LintCode = "DanglingReplaces"
+	// LintUpgradeCycle is reported for entries of a channel whose replaces
+	// and skips edges form a cycle.
+	LintUpgradeCycle LintCode = "UpgradeCycle"
+	// LintDeprecatedChannelHead is reported for channels that are not
+	// deprecated, but whose head bundle is.
+	LintDeprecatedChannelHead LintCode = "DeprecatedChannelHead"
+	// LintMissingDefaultChannel is reported for packages without a default
+	// channel, or whose default channel does not exist.
+	LintMissingDefaultChannel LintCode = "MissingDefaultChannel"
+	// LintDuplicateProperty is reported for bundles that declare the same
+	// property, with the same type and value, more than once.
+	LintDuplicateProperty LintCode = "DuplicateProperty"
+	// LintInvalidSkipRange is reported for channel entries whose skipRange
+	// is not a valid semver range.
+	LintInvalidSkipRange LintCode = "InvalidSkipRange"
+	// LintUnmatchedSkipRange is reported for channel entries whose skipRange
+	// matches the version of no other bundle of the channel.
+	LintUnmatchedSkipRange LintCode = "UnmatchedSkipRange"
+	// LintOverlappingSkipRanges is reported for pairs of channel entries
+	// whose skipRanges match the same bundles, when neither entry upgrades
+	// from the other, so that those bundles have ambiguous upgrade paths.
+	LintOverlappingSkipRanges LintCode = "OverlappingSkipRanges"
+)
+
+// Lint checks the health of the upgrade graphs and metadata of catalogs,
+// beyond what schema validation checks. Unlike validation errors, lint
+// findings do not prevent a catalog from being served.
+type Lint struct {
+	CatalogRefs    []string
+	Registry       image.Registry
+	LoadRefOptions []declcfg.LoadRefOption
+
+	// ReportSkipRanges adds the bundles that the skipRange of each channel
+	// entry covers to the result.
+	ReportSkipRanges bool
+}
+
+type LintFinding struct {
+	Code    LintCode `json:"code"`
+	Package string   `json:"package"`
+	Channel string   `json:"channel,omitempty"`
+	Bundle  string   `json:"bundle,omitempty"`
+	Message string   `json:"message"`
+}
+
+type LintResult struct {
+	Findings   []LintFinding       `json:"findings"`
+	SkipRanges []SkipRangeCoverage `json:"skipRanges,omitempty"`
+}
+
+func (l Lint) Run(ctx context.Context) (*LintResult, error) {
+	if len(l.CatalogRefs) == 0 {
+		return nil, errors.New("at least one catalog is required")
+	}
+	render := Render{
+		Refs:           l.CatalogRefs,
+		AllowedRefMask: RefDCImage | RefDCDir | RefDCArchive | RefDCGit | RefDCGRPC | RefSqliteImage | RefSqliteFile,
+		Registry:       l.Registry,
+		LoadRefOptions: l.LoadRefOptions,
+	}
+	cfg, err := render.Run(ctx)
+	if err != nil {
+		if errors.Is(err, ErrNotAllowed) {
+			return nil, fmt.Errorf("cannot lint non-catalog references %q", l.CatalogRefs)
+		}
+		return nil, err
+	}
+	res := lint(cfg)
+	if !l.ReportSkipRanges {
+		res.SkipRanges = nil
+	}
+	return res, nil
+}
+
+func lint(cfg *declcfg.DeclarativeConfig) *LintResult {
+	var (
+		res      = &LintResult{Findings: []LintFinding{}}
+		channels = map[string][]declcfg.Channel{}
+		// deprecated are the names of the deprecated channels and bundles
+		// of each package.
+		deprecatedChannels = map[string]sets.Set[string]{}
+		deprecatedBundles  = map[string]sets.Set[string]{}
+	)
+	for _, ch := range cfg.Channels {
+		channels[ch.Package] = append(channels[ch.Package], ch)
+	}
+	for _, d := range cfg.Deprecations {
+		for _, e := range d.Entries {
+			switch e.Reference.Schema {
+			case declcfg.SchemaChannel:
+				if deprecatedChannels[d.Package] == nil {
+					deprecatedChannels[d.Package] = sets.New[string]()
+				}
+				deprecatedChannels[d.Package].Insert(e.Reference.Name)
+			case declcfg.SchemaBundle:
+				if deprecatedBundles[d.Package] == nil {
+					deprecatedBundles[d.Package] = sets.New[string]()
+				}
+				deprecatedBundles[d.Package].Insert(e.Reference.Name)
+			}
+		}
+	}
+
+	for _, pkg := range cfg.Packages {
+		switch {
+		case pkg.DefaultChannel == "":
+			res.add(LintFinding{Code: LintMissingDefaultChannel, Package: pkg.Name, Message: "package has no default channel"})
+		case !hasChannel(channels[pkg.Name], pkg.DefaultChannel):
+			res.add(LintFinding{Code: LintMissingDefaultChannel, Package: pkg.Name, Message: fmt.Sprintf("default channel %q does not exist", pkg.DefaultChannel)})
+		}
+	}
+
+	inChannel := map[string]sets.Set[string]{}
+	for _, ch := range cfg.Channels {
+		entries := sets.New[string]()
+		for _, e := range ch.Entries {
+			entries.Insert(e.Name)
+		}
+		if inChannel[ch.Package] == nil {
+			inChannel[ch.Package] = sets.New[string]()
+		}
+		inChannel[ch.Package] = inChannel[ch.Package].Union(entries)
+
+		for _, e := range ch.Entries {
+			if e.Replaces != "" && !entries.Has(e.Replaces) {
+				res.add(LintFinding{Code: LintDanglingReplaces, Package: ch.Package, Channel: ch.Name, Bundle: e.Name, Message: fmt.Sprintf("replaces %q, which is not in the channel", e.Replaces)})
+			}
+		}
+		for _, cycle := range upgradeCycles(ch) {
+			res.add(LintFinding{Code: LintUpgradeCycle, Package: ch.Package, Channel: ch.Name, Bundle: cycle[0], Message: fmt.Sprintf("upgrade edges form a cycle between %s", strings.Join(cycle, ", "))})
+		}
+		if deprecatedChannels[ch.Package].Has(ch.Name) {
+			continue
+		}
+		for _, head := range channelHeads(ch) {
+			if deprecatedBundles[ch.Package].Has(head) {
+				res.add(LintFinding{Code: LintDeprecatedChannelHead, Package: ch.Package, Channel: ch.Name, Bundle: head, Message: "channel head is deprecated, but the channel is not"})
+			}
+		}
+	}
+
+	for _, b := range cfg.Bundles {
+		if !inChannel[b.Package].Has(b.Name) {
+			res.add(LintFinding{Code: LintUnreachableBundle, Package: b.Package, Bundle: b.Name, Message: "bundle is not an entry of any channel"})
+		}
+		seen := sets.New[string]()
+		reported := sets.New[string]()
+		for _, p := range b.Properties {
+			key := p.Type + "\x00" + compactJSON(p.Value)
+			if seen.Has(key) && !reported.Has(key) {
+				reported.Insert(key)
+				res.add(LintFinding{Code: LintDuplicateProperty, Package: b.Package, Bundle: b.Name, Message: fmt.Sprintf("property %s %s is declared more than once", p.Type, compactJSON(p.Value))})
+			}
+			seen.Insert(key)
+		}
+	}
+
+	res.SkipRanges = lintSkipRanges(cfg, res)
+
+	sort.SliceStable(res.Findings, func(i, j int) bool {
+		a, b := res.Findings[i], res.Findings[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		if a.Bundle != b.Bundle {
+			return a.Bundle < b.Bundle
+		}
+		return a.Code < b.Code
+	})
+	return res
+}
+
+func (r *LintResult) add(f LintFinding) {
+	r.Findings = append(r.Findings, f)
+}
+
+func hasChannel(channels []declcfg.Channel, name string) bool {
+	for _, ch := range channels {
+		if ch.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// channelHeads returns the sorted entries of ch that no other entry replaces
+// or skips.
+func channelHeads(ch declcfg.Channel) []string {
+	upgraded := sets.New[string]()
+	for _, e := range ch.Entries {
+		if e.Replaces != "" && e.Replaces != e.Name {
+			upgraded.Insert(e.Replaces)
+		}
+		for _, s := range e.Skips {
+			if s != e.Name {
+				upgraded.Insert(s)
+			}
+		}
+	}
+	heads := sets.New[string]()
+	for _, e := range ch.Entries {
+		if !upgraded.Has(e.Name) {
+			heads.Insert(e.Name)
+		}
+	}
+	return sets.List(heads)
+}
+
+// upgradeCycles returns the sets of entries of ch whose replaces and skips
+// edges form cycles, i.e. the strongly connected components of the upgrade
+// graph with more than one entry, or with an entry that upgrades from
+// itself. Each cycle is sorted, and the cycles are sorted by their first
+// entry.
+func upgradeCycles(ch declcfg.Channel) [][]string {
+	edges := map[string][]string{}
+	for _, e := range ch.Entries {
+		if e.Replaces != "" {
+			edges[e.Name] = append(edges[e.Name], e.Replaces)
+		}
+		edges[e.Name] = append(edges[e.Name], e.Skips...)
+	}
+
+	// Tarjan's strongly connected components algorithm.
+	var (
+		index   = map[string]int{}
+		lowlink = map[string]int{}
+		onStack = sets.New[string]()
+		stack   []string
+		cycles  [][]string
+		visit   func(string)
+	)
+	visit = func(v string) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack.Insert(v)
+		selfLoop := false
+		for _, w := range edges[v] {
+			if w == v {
+				selfLoop = true
+			}
+			if _, ok := index[w]; !ok {
+				visit(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack.Has(w) {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+		if lowlink[v] != index[v] {
+			return
+		}
+		var scc []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack.Delete(w)
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 || selfLoop {
+			sort.Strings(scc)
+			cycles = append(cycles, scc)
+		}
+	}
+	for _, e := range ch.Entries {
+		if _, ok := index[e.Name]; !ok {
+			visit(e.Name)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// compactJSON returns v without insignificant whitespace, so that equal
+// property values compare equal regardless of their formatting.
+func compactJSON(v json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return string(v)
+	}
+	return buf.String()
+}
+
+func (r *LintResult) WriteColumns(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "CODE\tPACKAGE\tCHANNEL\tBUNDLE\tMESSAGE"); err != nil {
+		return err
+	}
+	for _, f := range r.Findings {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Code, f.Package, f.Channel, f.Bundle, f.Message); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func (r *LintResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,149 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// SkipRangeCoverage lists the bundles of a channel that the skipRange of one
+// of its entries subsumes, i.e. that the entry upgrades from because their
+// versions are in its skipRange.
+type SkipRangeCoverage struct {
+	Package   string   `json:"package"`
+	Channel   string   `json:"channel"`
+	Bundle    string   `json:"bundle"`
+	SkipRange string   `json:"skipRange"`
+	Covers    []string `json:"covers"`
+}
+
+// lintSkipRanges adds the findings about the skipRanges of the entries of the
+// channels of cfg to res, and returns the bundles that each of them covers.
+func lintSkipRanges(cfg *declcfg.DeclarativeConfig, res *LintResult) []SkipRangeCoverage {
+	versions := map[string]map[string]semver.Version{}
+	for _, b := range cfg.Bundles {
+		props, err := property.Parse(b.Properties)
+		if err != nil || len(props.Packages) == 0 {
+			continue
+		}
+		v, err := semver.Parse(props.Packages[0].Version)
+		if err != nil {
+			continue
+		}
+		if versions[b.Package] == nil {
+			versions[b.Package] = map[string]semver.Version{}
+		}
+		versions[b.Package][b.Name] = v
+	}
+
+	var coverage []SkipRangeCoverage
+	for _, ch := range cfg.Channels {
+		pkgVersions := versions[ch.Package]
+		covers := map[string]sets.Set[string]{}
+		var ranged []declcfg.ChannelEntry
+		for _, e := range ch.Entries {
+			if e.SkipRange == "" {
+				continue
+			}
+			inRange, err := semver.ParseRange(e.SkipRange)
+			if err != nil {
+				res.add(LintFinding{Code: LintInvalidSkipRange, Package: ch.Package, Channel: ch.Name, Bundle: e.Name, Message: fmt.Sprintf("skipRange %q is invalid: %v", e.SkipRange, err)})
+				continue
+			}
+			covered := sets.New[string]()
+			for _, o := range ch.Entries {
+				if v, ok := pkgVersions[o.Name]; ok && o.Name != e.Name && inRange(v) {
+					covered.Insert(o.Name)
+				}
+			}
+			if covered.Len() == 0 {
+				res.add(LintFinding{Code: LintUnmatchedSkipRange, Package: ch.Package, Channel: ch.Name, Bundle: e.Name, Message: fmt.Sprintf("skipRange %q matches no bundle in the channel", e.SkipRange)})
+			}
+			covers[e.Name] = covered
+			ranged = append(ranged, e)
+
+			names := sets.List(covered)
+			sort.SliceStable(names, func(i, j int) bool {
+				return pkgVersions[names[i]].LT(pkgVersions[names[j]])
+			})
+			coverage = append(coverage, SkipRangeCoverage{Package: ch.Package, Channel: ch.Name, Bundle: e.Name, SkipRange: e.SkipRange, Covers: names})
+		}
+
+		edges := map[string]sets.Set[string]{}
+		for _, e := range ch.Entries {
+			edges[e.Name] = sets.New[string](e.Skips...).Union(covers[e.Name])
+			if e.Replaces != "" {
+				edges[e.Name].Insert(e.Replaces)
+			}
+		}
+		for i, a := range ranged {
+			for _, b := range ranged[i+1:] {
+				common := covers[a.Name].Intersection(covers[b.Name])
+				common.Delete(a.Name, b.Name)
+				if common.Len() == 0 || upgradesFrom(edges, a.Name, b.Name) || upgradesFrom(edges, b.Name, a.Name) {
+					continue
+				}
+				first, second := a, b
+				if second.Name < first.Name {
+					first, second = second, first
+				}
+				res.add(LintFinding{Code: LintOverlappingSkipRanges, Package: ch.Package, Channel: ch.Name, Bundle: first.Name, Message: fmt.Sprintf("skipRange %q overlaps skipRange %q of %s on %s, but neither bundle upgrades from the other", first.SkipRange, second.SkipRange, second.Name, strings.Join(sets.List(common), ", "))})
+			}
+		}
+	}
+
+	sort.SliceStable(coverage, func(i, j int) bool {
+		a, b := coverage[i], coverage[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		return a.Bundle < b.Bundle
+	})
+	return coverage
+}
+
+// upgradesFrom reports whether to can be upgraded to from, directly or
+// through other entries, along edges, which maps each entry to the entries
+// it upgrades from.
+func upgradesFrom(edges map[string]sets.Set[string], to, from string) bool {
+	visited := sets.New[string](to)
+	queue := []string{to}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range sets.List(edges[v]) {
+			if w == from {
+				return true
+			}
+			if !visited.Has(w) {
+				visited.Insert(w)
+				queue = append(queue, w)
+			}
+		}
+	}
+	return false
+}
+
+func (r *LintResult) WriteSkipRangeColumns(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "PACKAGE\tCHANNEL\tBUNDLE\tSKIPRANGE\tCOVERS"); err != nil {
+		return err
+	}
+	for _, c := range r.SkipRanges {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Package, c.Channel, c.Bundle, c.SkipRange, strings.Join(c.Covers, ",")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func lintTestBundle(pkg, name string, props ...property.Property) declcfg.Bundle {
+	return declcfg.Bundle{
+		Schema:     declcfg.SchemaBundle,
+		Package:    pkg,
+		Name:       name,
+		Image:      "quay.io/example/" + name,
+		Properties: props,
+	}
+}
+
+func TestLint(t *testing.T) {
+	cfg := &declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{
+			{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"},
+			{Schema: declcfg.SchemaPackage, Name: "bar", DefaultChannel: "missing"},
+			{Schema: declcfg.SchemaPackage, Name: "baz"},
+		},
+		Channels: []declcfg.Channel{
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1", Replaces: "foo.v0"},
+				{Name: "foo.v2", Replaces: "foo.v1"},
+				{Name: "foo.v3", Replaces: "foo.v2"},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "cyclic", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1", Replaces: "foo.v3"},
+				{Name: "foo.v2", Replaces: "foo.v1"},
+				{Name: "foo.v3", Replaces: "foo.v2"},
+				{Name: "foo.v4", Skips: []string{"foo.v4"}},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "deprecated", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v3"},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "bar", Name: "stable", Entries: []declcfg.ChannelEntry{
+				{Name: "bar.v1"},
+			}},
+		},
+		Bundles: []declcfg.Bundle{
+			lintTestBundle("foo", "foo.v1", property.MustBuildPackage("foo", "1.0.0")),
+			lintTestBundle("foo", "foo.v2", property.MustBuildPackage("foo", "2.0.0")),
+			lintTestBundle("foo", "foo.v3", property.MustBuildPackage("foo", "3.0.0")),
+			lintTestBundle("foo", "foo.v4", property.MustBuildPackage("foo", "4.0.0")),
+			lintTestBundle("foo", "foo.v5", property.MustBuildPackage("foo", "5.0.0")),
+			lintTestBundle("bar", "bar.v1",
+				property.MustBuildPackage("bar", "1.0.0"),
+				property.MustBuildGVK("example.com", "v1", "Bar"),
+				property.Property{Type: property.TypeGVK, Value: json.RawMessage(`{ "group": "example.com", "kind": "Bar", "version": "v1" }`)},
+				property.MustBuildGVK("example.com", "v1", "Bar"),
+			),
+		},
+		Deprecations: []declcfg.Deprecation{
+			{Schema: declcfg.SchemaDeprecation, Package: "foo", Entries: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "foo.v3"}, Message: "foo.v3 is deprecated"},
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "deprecated"}, Message: "deprecated is deprecated"},
+			}},
+		},
+	}
+
+	res := lint(cfg)
+	require.Equal(t, []LintFinding{
+		{Code: LintMissingDefaultChannel, Package: "bar", Message: `default channel "missing" does not exist`},
+		{Code: LintDuplicateProperty, Package: "bar", Bundle: "bar.v1", Message: `property olm.gvk {"group":"example.com","kind":"Bar","version":"v1"} is declared more than once`},
+		{Code: LintMissingDefaultChannel, Package: "baz", Message: "package has no default channel"},
+		{Code: LintUnreachableBundle, Package: "foo", Bundle: "foo.v5", Message: "bundle is not an entry of any channel"},
+		{Code: LintUpgradeCycle, Package: "foo", Channel: "cyclic", Bundle: "foo.v1", Message: "upgrade edges form a cycle between foo.v1, foo.v2, foo.v3"},
+		{Code: LintUpgradeCycle, Package: "foo", Channel: "cyclic", Bundle: "foo.v4", Message: "upgrade edges form a cycle between foo.v4"},
+		{Code: LintDanglingReplaces, Package: "foo", Channel: "stable", Bundle: "foo.v1", Message: `replaces "foo.v0", which is not in the channel`},
+		{Code: LintDeprecatedChannelHead, Package: "foo", Channel: "stable", Bundle: "foo.v3", Message: "channel head is deprecated, but the channel is not"},
+	}, res.Findings)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, (&LintResult{Findings: res.Findings[3:5]}).WriteColumns(buf))
+	require.Equal(t, `CODE               PACKAGE  CHANNEL  BUNDLE  MESSAGE
+UnreachableBundle  foo               foo.v5  bundle is not an entry of any channel
+UpgradeCycle       foo      cyclic   foo.v1  upgrade edges form a cycle between foo.v1, foo.v2, foo.v3
+`, buf.String())
+}
+
+func TestLintRun(t *testing.T) {
+	dir := t.TempDir()
+	cfg := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "foo.v1"}}}},
+		Bundles: []declcfg.Bundle{
+			lintTestBundle("foo", "foo.v1", property.MustBuildPackage("foo", "1.0.0")),
+		},
+	}
+	f, err := os.Create(filepath.Join(dir, "catalog.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, declcfg.WriteJSON(cfg, f))
+
+	res, err := Lint{CatalogRefs: []string{dir}}.Run(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, res.Findings)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.WriteJSON(buf))
+	require.JSONEq(t, `{"findings": []}`, buf.String())
+
+	_, err = Lint{}.Run(context.Background())
+	require.EqualError(t, err, "at least one catalog is required")
+}
+
+func TestLintSkipRanges(t *testing.T) {
+	cfg := &declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1.0.0"},
+				{Name: "foo.v1.1.0", SkipRange: ">=1.0.0 <1.1.0"},
+				{Name: "foo.v1.2.0", SkipRange: ">=1.0.0 <1.2.0"},
+				{Name: "foo.v1.2.1", Replaces: "foo.v1.2.0", SkipRange: "not a range"},
+				{Name: "foo.v2.0.0", Replaces: "foo.v1.2.1", SkipRange: ">=3.0.0"},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "fork", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1.0.0"},
+				{Name: "foo.v1.1.0", SkipRange: ">=1.0.0 <1.1.0"},
+				{Name: "foo.v1.2.0", SkipRange: "<1.1.0"},
+			}},
+		},
+		Bundles: []declcfg.Bundle{
+			lintTestBundle("foo", "foo.v1.0.0", property.MustBuildPackage("foo", "1.0.0")),
+			lintTestBundle("foo", "foo.v1.1.0", property.MustBuildPackage("foo", "1.1.0")),
+			lintTestBundle("foo", "foo.v1.2.0", property.MustBuildPackage("foo", "1.2.0")),
+			lintTestBundle("foo", "foo.v1.2.1", property.MustBuildPackage("foo", "1.2.1")),
+			lintTestBundle("foo", "foo.v2.0.0", property.MustBuildPackage("foo", "2.0.0")),
+		},
+	}
+
+	res := lint(cfg)
+	require.Len(t, res.Findings, 3)
+	require.Equal(t, LintFinding{Code: LintOverlappingSkipRanges, Package: "foo", Channel: "fork", Bundle: "foo.v1.1.0", Message: `skipRange ">=1.0.0 <1.1.0" overlaps skipRange "<1.1.0" of foo.v1.2.0 on foo.v1.0.0, but neither bundle upgrades from the other`}, res.Findings[0])
+	require.Equal(t, LintInvalidSkipRange, res.Findings[1].Code)
+	require.Equal(t, "foo.v1.2.1", res.Findings[1].Bundle)
+	require.Equal(t, LintFinding{Code: LintUnmatchedSkipRange, Package: "foo", Channel: "stable", Bundle: "foo.v2.0.0", Message: `skipRange ">=3.0.0" matches no bundle in the channel`}, res.Findings[2])
+
+	require.Equal(t, []SkipRangeCoverage{
+		{Package: "foo", Channel: "fork", Bundle: "foo.v1.1.0", SkipRange: ">=1.0.0 <1.1.0", Covers: []string{"foo.v1.0.0"}},
+		{Package: "foo", Channel: "fork", Bundle: "foo.v1.2.0", SkipRange: "<1.1.0", Covers: []string{"foo.v1.0.0"}},
+		{Package: "foo", Channel: "stable", Bundle: "foo.v1.1.0", SkipRange: ">=1.0.0 <1.1.0", Covers: []string{"foo.v1.0.0"}},
+		{Package: "foo", Channel: "stable", Bundle: "foo.v1.2.0", SkipRange: ">=1.0.0 <1.2.0", Covers: []string{"foo.v1.0.0", "foo.v1.1.0"}},
+		{Package: "foo", Channel: "stable", Bundle: "foo.v2.0.0", SkipRange: ">=3.0.0", Covers: []string{}},
+	}, res.SkipRanges)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, (&LintResult{SkipRanges: res.SkipRanges[3:4]}).WriteSkipRangeColumns(buf))
+	require.Equal(t, `PACKAGE  CHANNEL  BUNDLE      SKIPRANGE       COVERS
+foo      stable   foo.v1.2.0  >=1.0.0 <1.2.0  foo.v1.0.0,foo.v1.1.0
+`, buf.String())
+}
@@ -20,10 +20,11 @@ import (
 type ListPackages struct {
 	IndexReference string
 	Registry       image.Registry
+	LoadRefOptions []declcfg.LoadRefOption
 }
 
 func (l *ListPackages) Run(ctx context.Context) (*ListPackagesResult, error) {
-	m, err := indexRefToModel(ctx, l.IndexReference, l.Registry)
+	m, err := indexRefToModel(ctx, l.IndexReference, l.Registry, l.LoadRefOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -43,18 +44,47 @@ type ListPackagesResult struct {
 }
 
 func (r *ListPackagesResult) WriteColumns(w io.Writer) error {
+	// The DOCUMENTATION column is only shown when at least one package
+	// provides documentation.
+	showDocs := false
+	for _, pkg := range r.Packages {
+		if pkg.Documentation != nil {
+			showDocs = true
+			break
+		}
+	}
+
 	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
-	if _, err := fmt.Fprintln(tw, "NAME\tDISPLAY NAME\tDEFAULT CHANNEL"); err != nil {
+	header := "NAME\tDISPLAY NAME\tDEFAULT CHANNEL"
+	if showDocs {
+		header += "\tDOCUMENTATION"
+	}
+	if _, err := fmt.Fprintln(tw, header); err != nil {
 		return err
 	}
 	for _, pkg := range r.Packages {
-		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", pkg.Name, getDisplayName(pkg), pkg.DefaultChannel.Name); err != nil {
+		row := fmt.Sprintf("%s\t%s\t%s", pkg.Name, getDisplayName(pkg), pkg.DefaultChannel.Name)
+		if showDocs {
+			row += "\t" + getDocumentation(pkg)
+		}
+		if _, err := fmt.Fprintln(tw, row); err != nil {
 			return err
 		}
 	}
 	return tw.Flush()
 }
 
+func getDocumentation(pkg model.Package) string {
+	switch {
+	case pkg.Documentation == nil:
+		return ""
+	case pkg.Documentation.URL != "":
+		return pkg.Documentation.URL
+	default:
+		return "inline"
+	}
+}
+
 func getDisplayName(pkg model.Package) string {
 	if pkg.DefaultChannel == nil {
 		return ""
@@ -75,10 +105,11 @@ type ListChannels struct {
 	IndexReference string
 	PackageName    string
 	Registry       image.Registry
+	LoadRefOptions []declcfg.LoadRefOption
 }
 
 func (l *ListChannels) Run(ctx context.Context) (*ListChannelsResult, error) {
-	m, err := indexRefToModel(ctx, l.IndexReference, l.Registry)
+	m, err := indexRefToModel(ctx, l.IndexReference, l.Registry, l.LoadRefOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -132,10 +163,11 @@ type ListBundles struct {
 	IndexReference string
 	PackageName    string
 	Registry       image.Registry
+	LoadRefOptions []declcfg.LoadRefOption
 }
 
 func (l *ListBundles) Run(ctx context.Context) (*ListBundlesResult, error) {
-	m, err := indexRefToModel(ctx, l.IndexReference, l.Registry)
+	m, err := indexRefToModel(ctx, l.IndexReference, l.Registry, l.LoadRefOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -183,11 +215,12 @@ func (r *ListBundlesResult) WriteColumns(w io.Writer) error {
 	return tw.Flush()
 }
 
-func indexRefToModel(ctx context.Context, ref string, reg image.Registry) (model.Model, error) {
+func indexRefToModel(ctx context.Context, ref string, reg image.Registry, loadRefOpts []declcfg.LoadRefOption) (model.Model, error) {
 	render := Render{
 		Refs:           []string{ref},
-		AllowedRefMask: RefDCImage | RefDCDir | RefSqliteImage | RefSqliteFile,
+		AllowedRefMask: RefDCImage | RefDCDir | RefDCArchive | RefDCGit | RefDCGRPC | RefSqliteImage | RefSqliteFile,
 		Registry:       reg,
+		LoadRefOptions: loadRefOpts,
 	}
 	cfg, err := render.Run(ctx)
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/model"
 )
 
 func TestListPackages(t *testing.T) {
@@ -50,6 +52,22 @@ foo   Foo Operator  beta
 	}
 }
 
+func TestListPackagesResultDocumentation(t *testing.T) {
+	res := ListPackagesResult{Packages: []model.Package{
+		{Name: "bar", DefaultChannel: &model.Channel{Name: "beta"}, Documentation: &model.Documentation{Content: "# bar"}},
+		{Name: "baz", DefaultChannel: &model.Channel{Name: "beta"}},
+		{Name: "foo", DefaultChannel: &model.Channel{Name: "beta"}, Documentation: &model.Documentation{URL: "https://example.com/foo"}},
+	}}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.WriteColumns(buf))
+	require.Equal(t, `NAME  DISPLAY NAME  DEFAULT CHANNEL  DOCUMENTATION
+bar                 beta             inline
+baz                 beta             
+foo                 beta             https://example.com/foo
+`, buf.String())
+}
+
 func TestListChannels(t *testing.T) {
 	type spec struct {
 		name        string
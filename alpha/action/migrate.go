@@ -2,12 +2,30 @@ package action
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 
 	"github.com/operator-framework/operator-registry/alpha/action/migrations"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/pkg/image"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+// MigrateStateDir is the directory in the output dir of a migration that
+// records its progress, so that an interrupted migration can be resumed. It
+// is removed when the migration completes.
+const MigrateStateDir = ".opm-migrate"
+
+const (
+	migrateStateFile    = "state.json"
+	migratePackagesFile = "packages.json"
 )
 
 type Migrate struct {
@@ -17,33 +35,225 @@ type Migrate struct {
 
 	WriteFunc declcfg.WriteFunc
 	FileExt   string
+	Layout    declcfg.FSLayout
 	Registry  image.Registry
+
+	// Resume continues an interrupted migration into OutputDir, skipping
+	// the packages it already wrote: only the packages that are not done
+	// are rendered from the catalog. The catalog, file extension, layout,
+	// and migrations must be the same as those of the interrupted
+	// migration.
+	Resume bool
+}
+
+// migrateState identifies a migration, so that it is only resumed with the
+// same options it was started with.
+type migrateState struct {
+	CatalogRef string           `json:"catalogRef"`
+	FileExt    string           `json:"fileExt"`
+	Layout     declcfg.FSLayout `json:"layout"`
+	Migrations []string         `json:"migrations,omitempty"`
 }
 
 func (m Migrate) Run(ctx context.Context) error {
-	entries, err := os.ReadDir(m.OutputDir)
-	if err != nil && !os.IsNotExist(err) {
+	stateDir := filepath.Join(m.OutputDir, MigrateStateDir)
+	state := m.state()
+	if err := m.checkOutputDir(stateDir, state); err != nil {
 		return err
 	}
-	if len(entries) > 0 {
-		return fmt.Errorf("output dir %q must be empty", m.OutputDir)
-	}
 
 	r := Render{
-		Refs:       []string{m.CatalogRef},
-		Migrations: m.Migrations,
+		Refs: []string{m.CatalogRef},
 
 		// Only allow catalogs to be migrated.
-		AllowedRefMask: RefSqliteImage | RefSqliteFile | RefDCImage | RefDCDir,
+		AllowedRefMask: RefSqliteImage | RefSqliteFile | RefDCImage | RefDCDir | RefDCArchive | RefDCGit | RefDCGRPC,
 	}
 	if m.Registry != nil {
 		r.Registry = m.Registry
 	}
 
+	// A resumed migration only renders the packages that are not done,
+	// unless objects without a package remain, which cannot be filtered.
+	remaining, err := remainingPackages(stateDir)
+	if err != nil {
+		return fmt.Errorf("read migration state: %v", err)
+	}
+	if remaining != nil {
+		if len(remaining) == 0 {
+			return os.RemoveAll(stateDir)
+		}
+		if !slices.Contains(remaining, "") {
+			r.FilterPackages = remaining
+		}
+	}
+
 	cfg, err := r.Run(ctx)
 	if err != nil {
 		return fmt.Errorf("render catalog image: %w", err)
 	}
 
-	return declcfg.WriteFS(*cfg, m.OutputDir, m.WriteFunc, m.FileExt)
+	if err := os.MkdirAll(stateDir, 0777); err != nil {
+		return err
+	}
+	stateData, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, migrateStateFile), stateData, 0666); err != nil {
+		return fmt.Errorf("write migration state: %v", err)
+	}
+
+	// Migrate and write one package at a time, marking each package as
+	// done once it is written, so that a resumed migration can skip it.
+	byPackage := declcfg.SplitByPackage(*cfg)
+	pkgNames := make([]string, 0, len(byPackage))
+	for pkgName := range byPackage {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+	if remaining == nil {
+		pkgsData, err := json.Marshal(pkgNames)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(stateDir, migratePackagesFile), pkgsData, 0666); err != nil {
+			return fmt.Errorf("write migration state: %v", err)
+		}
+	}
+
+	for _, pkgName := range pkgNames {
+		if isMigrated(stateDir, pkgName) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.migratePackage(stateDir, byPackage[pkgName]); err != nil {
+			return fmt.Errorf("migrate package %q: %w", pkgName, err)
+		}
+		if err := os.WriteFile(filepath.Join(stateDir, pkgName+".done"), nil, 0666); err != nil {
+			return fmt.Errorf("write migration state: %v", err)
+		}
+	}
+	return os.RemoveAll(stateDir)
+}
+
+// remainingPackages returns the packages of an interrupted migration that
+// are not done, or nil if there is no interrupted migration or it did not
+// record its packages.
+func remainingPackages(stateDir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(stateDir, migratePackagesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pkgNames []string
+	if err := json.Unmarshal(data, &pkgNames); err != nil {
+		return nil, err
+	}
+	remaining := []string{}
+	for _, pkgName := range pkgNames {
+		if !isMigrated(stateDir, pkgName) {
+			remaining = append(remaining, pkgName)
+		}
+	}
+	return remaining, nil
+}
+
+// isMigrated returns true if the package was written by an interrupted
+// migration.
+func isMigrated(stateDir, pkgName string) bool {
+	_, err := os.Stat(filepath.Join(stateDir, pkgName+".done"))
+	return err == nil
+}
+
+func (m Migrate) state() migrateState {
+	s := migrateState{
+		CatalogRef: m.CatalogRef,
+		FileExt:    m.FileExt,
+		Layout:     m.Layout,
+	}
+	if s.Layout == "" {
+		s.Layout = declcfg.FSLayoutPackage
+	}
+	if m.Migrations != nil {
+		for _, migration := range m.Migrations.Migrations {
+			s.Migrations = append(s.Migrations, string(migration.Token()))
+		}
+	}
+	return s
+}
+
+// checkOutputDir returns an error unless the output dir is empty, or it
+// holds an interrupted migration with the same state and m resumes it.
+func (m Migrate) checkOutputDir(stateDir string, state migrateState) error {
+	entries, err := os.ReadDir(m.OutputDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateDir, migrateStateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return liberrors.Errorf(liberrors.CodeOutputConflict, "output dir %q must be empty", m.OutputDir)
+	}
+	if err != nil {
+		return fmt.Errorf("read migration state: %v", err)
+	}
+	if !m.Resume {
+		return liberrors.Errorf(liberrors.CodeOutputConflict, "output dir %q contains an interrupted migration, resume it or use an empty output dir", m.OutputDir)
+	}
+	var prev migrateState
+	if err := json.Unmarshal(data, &prev); err != nil {
+		return fmt.Errorf("read migration state: %v", err)
+	}
+	if !reflect.DeepEqual(prev, state) {
+		return liberrors.Errorf(liberrors.CodeOutputConflict, "cannot resume the migration in output dir %q: it was started from catalog %q with different options", m.OutputDir, prev.CatalogRef)
+	}
+	return nil
+}
+
+// migratePackage runs the migrations on the objects of one package and
+// writes them to the output dir. The files are written to a temporary
+// directory first and then moved into place, replacing any files left by an
+// interrupted migration.
+func (m Migrate) migratePackage(stateDir string, cfg *declcfg.DeclarativeConfig) error {
+	if m.Migrations != nil {
+		if err := m.Migrations.Migrate(cfg); err != nil {
+			return fmt.Errorf("migrate: %v", err)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp(stateDir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var opts []declcfg.WriteFSOption
+	if m.Layout != "" {
+		opts = append(opts, declcfg.WithFSLayout(m.Layout))
+	}
+	if err := declcfg.WriteFS(*cfg, tmpDir, m.WriteFunc, m.FileExt, opts...); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		dst := filepath.Join(m.OutputDir, e.Name())
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(tmpDir, e.Name()), dst); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// MigrateFBC upgrades a file-based catalog directory in place, by running
+// migrations on the objects of each of its files. The layout of the
+// catalog is kept: only the files that the migrations change are rewritten,
+// each in its original format. YAML files are edited in place, so that they
+// keep their comments and the formatting of the objects that are not changed.
+type MigrateFBC struct {
+	CatalogDir string
+	Migrations *migrations.Migrations
+
+	// DryRun reports the files that would be rewritten without writing
+	// them.
+	DryRun bool
+}
+
+// Run returns the paths, relative to the catalog directory, of the files
+// that were rewritten.
+func (m MigrateFBC) Run(ctx context.Context) ([]string, error) {
+	if m.Migrations == nil {
+		return nil, errors.New("no migrations to run")
+	}
+
+	files, err := rewriteFBC(ctx, m.CatalogDir, m.DryRun, func(_ string, cfg *declcfg.DeclarativeConfig) error {
+		return m.Migrations.Migrate(cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, f := range files {
+		changed = append(changed, f.Path)
+	}
+	return changed, nil
+}
+
+// rewrittenFile is a file of a file-based catalog directory that was
+// rewritten by rewriteFBC.
+type rewrittenFile struct {
+	// Path is relative to the catalog directory.
+	Path    string
+	OldSize int
+	NewSize int
+}
+
+// rewriteFBC edits the declarative config of each file of a file-based
+// catalog directory with edit, and rewrites the files whose encoding
+// changes, unless dryRun is set. YAML files are rewritten with
+// declcfg.EditableYAML, which keeps their comments and formatting. It
+// returns the files that were rewritten.
+func rewriteFBC(ctx context.Context, catalogDir string, dryRun bool, edit func(path string, cfg *declcfg.DeclarativeConfig) error) ([]rewrittenFile, error) {
+	root := os.DirFS(catalogDir)
+	var changed []rewrittenFile
+	err := declcfg.WalkFS(root, func(path string, cfg *declcfg.DeclarativeConfig, err error) error {
+		if err != nil {
+			return fmt.Errorf("load %q: %v", path, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		orig, err := fs.ReadFile(root, path)
+		if err != nil {
+			return err
+		}
+		write := fileWriteFunc(path, orig)
+
+		var before, after bytes.Buffer
+		if err := write(*cfg, &before); err != nil {
+			return fmt.Errorf("write %q: %v", path, err)
+		}
+		if err := edit(path, cfg); err != nil {
+			return fmt.Errorf("migrate %q: %v", path, err)
+		}
+		if err := write(*cfg, &after); err != nil {
+			return fmt.Errorf("write %q: %v", path, err)
+		}
+		if bytes.Equal(before.Bytes(), after.Bytes()) {
+			return nil
+		}
+		out := after.Bytes()
+		if isYAMLFile(path, orig) {
+			_, editable, err := declcfg.LoadEditableYAML(bytes.NewReader(orig))
+			if err != nil {
+				return fmt.Errorf("load %q: %v", path, err)
+			}
+			var buf bytes.Buffer
+			if err := editable.Write(*cfg, &buf); err != nil {
+				return fmt.Errorf("write %q: %v", path, err)
+			}
+			out = buf.Bytes()
+		}
+		changed = append(changed, rewrittenFile{Path: path, OldSize: len(orig), NewSize: len(out)})
+		if dryRun {
+			return nil
+		}
+		return replaceFile(filepath.Join(catalogDir, filepath.FromSlash(path)), out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// fileWriteFunc returns the function that writes a declarative config in
+// the format of the file at path, based on its extension or, without a
+// known extension, on its content.
+func fileWriteFunc(path string, data []byte) declcfg.WriteFunc {
+	if isYAMLFile(path, data) {
+		return declcfg.WriteYAML
+	}
+	return declcfg.WriteJSON
+}
+
+// isYAMLFile reports whether the file at path is a YAML file, based on its
+// extension or, without a known extension, on its content.
+func isYAMLFile(path string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return false
+	case ".yaml", ".yml":
+		return true
+	}
+	return !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// replaceFile replaces the content of filename with data, keeping its mode.
+// The data is written to a temporary file in the same directory, which is
+// then renamed over filename, so that filename is never left partially
+// written.
+func replaceFile(filename string, data []byte) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
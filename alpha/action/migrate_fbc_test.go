@@ -0,0 +1,127 @@
+package action_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestMigrateFBC(t *testing.T) {
+	const csv = `{"kind":"ClusterServiceVersion","apiVersion":"operators.coreos.com/v1alpha1","metadata":{"name":"foo.v0.1.0"},"spec":{"displayName":"Foo Operator"}}`
+	foo := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo"}},
+		Bundles: []declcfg.Bundle{{
+			Schema:  declcfg.SchemaBundle,
+			Package: "foo",
+			Name:    "foo.v0.1.0",
+			Image:   "test.registry/foo-operator/foo-bundle:v0.1.0",
+			Properties: []property.Property{
+				property.MustBuildPackage("foo", "0.1.0"),
+				property.MustBuildBundleObject([]byte(csv)),
+			},
+		}},
+	}
+	bar := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "bar"}},
+	}
+
+	newCatalog := func(t *testing.T) string {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "foo"), 0755))
+		writeCatalogFile(t, filepath.Join(dir, "foo", "catalog.json"), foo, declcfg.WriteJSON)
+		writeCatalogFile(t, filepath.Join(dir, "foo", "catalog"), foo, declcfg.WriteYAML)
+		writeCatalogFile(t, filepath.Join(dir, "bar.yaml"), bar, declcfg.WriteYAML)
+		return dir
+	}
+	all, err := migrations.NewMigrations(migrations.AllMigrations)
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		dir := newCatalog(t)
+		barBefore, err := os.ReadFile(filepath.Join(dir, "bar.yaml"))
+		require.NoError(t, err)
+
+		changed, err := action.MigrateFBC{CatalogDir: dir, Migrations: all}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo/catalog", "foo/catalog.json"}, changed)
+
+		for _, name := range changed {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			require.NoError(t, err)
+			cfg, err := declcfg.LoadFile(os.DirFS(dir), name)
+			require.NoError(t, err)
+			require.Len(t, cfg.Bundles, 1)
+			var types []string
+			for _, p := range cfg.Bundles[0].Properties {
+				types = append(types, p.Type)
+			}
+			require.Equal(t, []string{property.TypePackage, property.TypeCSVMetadata}, types)
+			if name == "foo/catalog.json" {
+				require.Equal(t, byte('{'), data[0])
+			} else {
+				require.Contains(t, string(data), "schema: olm.bundle")
+			}
+		}
+		barAfter, err := os.ReadFile(filepath.Join(dir, "bar.yaml"))
+		require.NoError(t, err)
+		require.Equal(t, barBefore, barAfter)
+
+		// Migrating an upgraded catalog again changes nothing.
+		changed, err = action.MigrateFBC{CatalogDir: dir, Migrations: all}.Run(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, changed)
+	})
+
+	t.Run("KeepsYAMLComments", func(t *testing.T) {
+		dir := newCatalog(t)
+		path := filepath.Join(dir, "foo", "catalog")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		commented := strings.Replace(string(data), "---\n", "---\n# The foo operator.\n", 1)
+		require.NoError(t, os.WriteFile(path, []byte(commented), 0644))
+
+		changed, err := action.MigrateFBC{CatalogDir: dir, Migrations: all}.Run(context.Background())
+		require.NoError(t, err)
+		require.Contains(t, changed, "foo/catalog")
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "# The foo operator.\n")
+		require.Contains(t, string(data), property.TypeCSVMetadata)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		dir := newCatalog(t)
+		before, err := os.ReadFile(filepath.Join(dir, "foo", "catalog.json"))
+		require.NoError(t, err)
+
+		changed, err := action.MigrateFBC{CatalogDir: dir, Migrations: all, DryRun: true}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo/catalog", "foo/catalog.json"}, changed)
+
+		after, err := os.ReadFile(filepath.Join(dir, "foo", "catalog.json"))
+		require.NoError(t, err)
+		require.Equal(t, before, after)
+	})
+
+	t.Run("NoMigrations", func(t *testing.T) {
+		_, err := action.MigrateFBC{CatalogDir: newCatalog(t)}.Run(context.Background())
+		require.EqualError(t, err, "no migrations to run")
+	})
+}
+
+func writeCatalogFile(t *testing.T, path string, cfg declcfg.DeclarativeConfig, write declcfg.WriteFunc) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, write(cfg, f))
+}
@@ -2,6 +2,8 @@ package action_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -141,6 +143,96 @@ func TestMigrate(t *testing.T) {
 	}
 }
 
+func TestMigrateResume(t *testing.T) {
+	sqliteBundles := map[image.Reference]string{
+		image.SimpleReference("test.registry/foo-operator/foo-bundle:v0.1.0"): "testdata/foo-bundle-v0.1.0",
+		image.SimpleReference("test.registry/foo-operator/foo-bundle:v0.2.0"): "testdata/foo-bundle-v0.2.0",
+		image.SimpleReference("test.registry/bar-operator/bar-bundle:v0.1.0"): "testdata/bar-bundle-v0.1.0",
+		image.SimpleReference("test.registry/bar-operator/bar-bundle:v0.2.0"): "testdata/bar-bundle-v0.2.0",
+	}
+	dbFile := filepath.Join(t.TempDir(), "index.db")
+	require.NoError(t, generateSqliteFile(dbFile, sqliteBundles))
+	reg, err := newMigrateRegistry(t, sqliteBundles)
+	require.NoError(t, err)
+
+	// writePackages records the packages written by a migration, and fails
+	// to write the failPackage package.
+	writePackages := func(written *[]string, failPackage string) declcfg.WriteFunc {
+		return func(cfg declcfg.DeclarativeConfig, w io.Writer) error {
+			name := cfg.Packages[0].Name
+			if name == failPackage {
+				return errors.New("interrupted")
+			}
+			*written = append(*written, name)
+			return declcfg.WriteYAML(cfg, w)
+		}
+	}
+
+	outputDir := t.TempDir()
+	var written []string
+	m := action.Migrate{
+		CatalogRef: dbFile,
+		OutputDir:  outputDir,
+		WriteFunc:  writePackages(&written, "foo"),
+		FileExt:    ".yaml",
+		Registry:   reg,
+	}
+	require.ErrorContains(t, m.Run(context.Background()), `migrate package "foo"`)
+	require.Equal(t, []string{"bar"}, written)
+	// The packages of the catalog are recorded, so that a resumed migration
+	// only renders those that are not done.
+	pkgs, err := os.ReadFile(filepath.Join(outputDir, action.MigrateStateDir, "packages.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `["bar","foo"]`, string(pkgs))
+
+	// The interrupted migration is only resumed when asked to.
+	m.WriteFunc = writePackages(&written, "")
+	require.ErrorContains(t, m.Run(context.Background()), "contains an interrupted migration")
+
+	// It cannot be resumed with different options.
+	m.Resume = true
+	m.Layout = declcfg.FSLayoutSchema
+	require.ErrorContains(t, m.Run(context.Background()), "with different options")
+
+	m.Layout = ""
+	written = nil
+	require.NoError(t, m.Run(context.Background()))
+	require.Equal(t, []string{"foo"}, written)
+
+	expectedFiles := map[string]string{
+		"foo/catalog.yaml": migrateFooCatalogSqlite(),
+		"bar/catalog.yaml": migrateBarCatalogSqlite(),
+	}
+	actualFS := os.DirFS(outputDir)
+	actualFiles := map[string]string{}
+	require.NoError(t, fs.WalkDir(actualFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(actualFS, path)
+		actualFiles[path] = string(data)
+		return err
+	}))
+	require.Equal(t, expectedFiles, actualFiles)
+
+	t.Run("AllPackagesDone", func(t *testing.T) {
+		// A migration interrupted after its last package was written is
+		// completed without rendering the catalog again.
+		// The catalog does not exist, so rendering it would fail.
+		missing := filepath.Join(t.TempDir(), "missing.db")
+		outputDir := t.TempDir()
+		stateDir := filepath.Join(outputDir, action.MigrateStateDir)
+		require.NoError(t, os.MkdirAll(stateDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(stateDir, "state.json"), []byte(`{"catalogRef":"`+missing+`","fileExt":".yaml","layout":"package"}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(stateDir, "packages.json"), []byte(`["foo"]`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(stateDir, "foo.done"), nil, 0644))
+
+		m := action.Migrate{CatalogRef: missing, OutputDir: outputDir, WriteFunc: declcfg.WriteYAML, FileExt: ".yaml", Registry: reg, Resume: true}
+		require.NoError(t, m.Run(context.Background()))
+		require.NoDirExists(t, stateDir)
+	})
+}
+
 func newMigrateRegistry(t *testing.T, imageMap map[image.Reference]string) (image.Registry, error) {
 	subSqliteImage, err := generateSqliteFS(t, imageMap)
 	if err != nil {
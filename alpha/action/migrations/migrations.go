@@ -23,14 +23,21 @@ type Migration interface {
 	Migrate(*declcfg.DeclarativeConfig) error
 }
 
-func newMigration(token string, help string, fn func(config *declcfg.DeclarativeConfig) error) Migration {
-	return &simpleMigration{token: MigrationToken(token), help: help, fn: fn}
+// ApplicabilityChecker is implemented by migrations that can determine,
+// without modifying it, whether running the migration would change a catalog.
+type ApplicabilityChecker interface {
+	IsApplicable(*declcfg.DeclarativeConfig) (bool, error)
+}
+
+func newMigration(token string, help string, fn func(config *declcfg.DeclarativeConfig) error, applicable func(config *declcfg.DeclarativeConfig) (bool, error)) Migration {
+	return &simpleMigration{token: MigrationToken(token), help: help, fn: fn, applicable: applicable}
 }
 
 type simpleMigration struct {
-	token MigrationToken
-	help  string
-	fn    func(*declcfg.DeclarativeConfig) error
+	token      MigrationToken
+	help       string
+	fn         func(*declcfg.DeclarativeConfig) error
+	applicable func(*declcfg.DeclarativeConfig) (bool, error)
 }
 
 func (s simpleMigration) Token() MigrationToken {
@@ -45,6 +52,13 @@ func (s simpleMigration) Help() string {
 	return s.help
 }
 
+func (s simpleMigration) IsApplicable(config *declcfg.DeclarativeConfig) (bool, error) {
+	if s.applicable == nil {
+		return true, nil
+	}
+	return s.applicable(config)
+}
+
 type Migrations struct {
 	Migrations []Migration
 }
@@ -52,8 +66,8 @@ type Migrations struct {
 // allMigrations represents the migration catalog
 // the order of these migrations is important
 var allMigrations = []Migration{
-	newMigration(NoMigrations, "do nothing", func(_ *declcfg.DeclarativeConfig) error { return nil }),
-	newMigration("bundle-object-to-csv-metadata", `migrates bundles' "olm.bundle.object" to "olm.csv.metadata"`, bundleObjectToCSVMetadata),
+	newMigration(NoMigrations, "do nothing", func(_ *declcfg.DeclarativeConfig) error { return nil }, func(_ *declcfg.DeclarativeConfig) (bool, error) { return false, nil }),
+	newMigration("bundle-object-to-csv-metadata", `migrates bundles' "olm.bundle.object" to "olm.csv.metadata"`, bundleObjectToCSVMetadata, bundleObjectToCSVMetadataApplicable),
 }
 
 func NewMigrations(name string) (*Migrations, error) {
@@ -78,6 +92,92 @@ func NewMigrations(name string) (*Migrations, error) {
 	return &Migrations{Migrations: keep}, nil
 }
 
+// NewMigrationsRange returns the migrations that follow since, up to and
+// including until, in the order in which they are defined. An empty since
+// starts at the first migration and an empty until ends at the last one.
+func NewMigrationsRange(since, until string) (*Migrations, error) {
+	start, end := 0, len(allMigrations)
+	if since != "" {
+		i, err := migrationIndex(since)
+		if err != nil {
+			return nil, err
+		}
+		start = i + 1
+	}
+	if until != "" {
+		i, err := migrationIndex(until)
+		if err != nil {
+			return nil, err
+		}
+		end = i + 1
+	}
+	if start > end {
+		return nil, fmt.Errorf("migration %q does not precede migration %q", since, until)
+	}
+	return &Migrations{Migrations: slices.Clone(allMigrations[start:end])}, nil
+}
+
+// NewMigrationsSubset returns only the named migrations. Regardless of the
+// order of names, the migrations are returned in the order in which they are
+// defined, so that they are always applied in a consistent sequence.
+func NewMigrationsSubset(names ...string) (*Migrations, error) {
+	selected := make([]bool, len(allMigrations))
+	for _, name := range names {
+		i, err := migrationIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		selected[i] = true
+	}
+	m := &Migrations{}
+	for i, migration := range allMigrations {
+		if selected[i] {
+			m.Migrations = append(m.Migrations, migration)
+		}
+	}
+	return m, nil
+}
+
+func migrationIndex(name string) (int, error) {
+	i := slices.IndexFunc(allMigrations, func(m Migration) bool { return m.Token() == MigrationToken(name) })
+	if i < 0 {
+		return -1, fmt.Errorf("unknown migration level %q", name)
+	}
+	return i, nil
+}
+
+// MigrationInfo describes an available migration.
+type MigrationInfo struct {
+	Token       MigrationToken `json:"token"`
+	Description string         `json:"description"`
+
+	// Applicable reports whether the migration would change the catalog
+	// it was checked against. It is nil when no catalog was provided.
+	Applicable *bool `json:"applicable,omitempty"`
+}
+
+// List describes all available migrations, in the order in which they are
+// applied. If cfg is not nil, each migration's applicability to cfg is also
+// reported. cfg is never modified.
+func List(cfg *declcfg.DeclarativeConfig) ([]MigrationInfo, error) {
+	infos := make([]MigrationInfo, 0, len(allMigrations))
+	for _, migration := range allMigrations {
+		info := MigrationInfo{Token: migration.Token(), Description: migration.Help()}
+		if cfg != nil {
+			applicable := true
+			if checker, ok := migration.(ApplicabilityChecker); ok {
+				var err error
+				if applicable, err = checker.IsApplicable(cfg); err != nil {
+					return nil, fmt.Errorf("check applicability of migration %q: %v", migration.Token(), err)
+				}
+			}
+			info.Applicable = &applicable
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
 func HelpText() string {
 	var help strings.Builder
 	help.WriteString("\nThe migrator will run all migrations up to and including the selected level.\n\n")
@@ -69,6 +69,102 @@ func TestMigrations(t *testing.T) {
 	}
 }
 
+func TestNewMigrationsRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		since, until string
+		expected     []MigrationToken
+		expectedErr  string
+	}{
+		{
+			name:     "All",
+			expected: []MigrationToken{MigrationToken(NoMigrations), "bundle-object-to-csv-metadata"},
+		},
+		{
+			name:     "Since",
+			since:    NoMigrations,
+			expected: []MigrationToken{"bundle-object-to-csv-metadata"},
+		},
+		{
+			name:     "Until",
+			until:    NoMigrations,
+			expected: []MigrationToken{MigrationToken(NoMigrations)},
+		},
+		{
+			name:     "SinceLast",
+			since:    "bundle-object-to-csv-metadata",
+			expected: nil,
+		},
+		{
+			name:        "Reversed",
+			since:       "bundle-object-to-csv-metadata",
+			until:       NoMigrations,
+			expectedErr: `migration "bundle-object-to-csv-metadata" does not precede migration "none"`,
+		},
+		{
+			name:        "Unknown",
+			since:       "unknown",
+			expectedErr: `unknown migration level "unknown"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := NewMigrationsRange(test.since, test.until)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, tokens(m))
+		})
+	}
+}
+
+func TestNewMigrationsSubset(t *testing.T) {
+	m, err := NewMigrationsSubset("bundle-object-to-csv-metadata", NoMigrations)
+	require.NoError(t, err)
+	require.Equal(t, []MigrationToken{MigrationToken(NoMigrations), "bundle-object-to-csv-metadata"}, tokens(m))
+
+	_, err = NewMigrationsSubset("unknown")
+	require.EqualError(t, err, `unknown migration level "unknown"`)
+}
+
+func TestList(t *testing.T) {
+	infos, err := List(nil)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	for _, info := range infos {
+		require.Nil(t, info.Applicable)
+	}
+
+	unmigrated := unmigratedCatalogFBC()
+	infos, err = List(&unmigrated)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true}, applicability(infos))
+	require.Equal(t, unmigratedCatalogFBC(), unmigrated, "list must not modify the catalog")
+
+	migrated := csvMetadataCatalogFBC()
+	infos, err = List(&migrated)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, false}, applicability(infos))
+}
+
+func tokens(m *Migrations) []MigrationToken {
+	var out []MigrationToken
+	for _, migration := range m.Migrations {
+		out = append(out, migration.Token())
+	}
+	return out
+}
+
+func applicability(infos []MigrationInfo) []bool {
+	var out []bool
+	for _, info := range infos {
+		out = append(out, *info.Applicable)
+	}
+	return out
+}
+
 func mustBuildCSVMetadata(r io.Reader) property.Property {
 	var csv v1alpha1.ClusterServiceVersion
 	if err := json.NewDecoder(r).Decode(&csv); err != nil {
@@ -0,0 +1,83 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// ApplyOverlays renders catalogs and patches the result with overlays, so
+// that the changes that catalog maintainers make to rendered catalogs can be
+// kept in version control as declarative patches. See declcfg.Overlay for
+// the format of overlays.
+type ApplyOverlays struct {
+	Refs []string
+	// OverlayPaths are the paths of overlay files, or of directories of
+	// them. Overlays are applied in order, and the files of a directory in
+	// the lexical order of their paths.
+	OverlayPaths []string
+	Registry     image.Registry
+
+	// LoadRefOptions are passed to declcfg.LoadRef when rendering
+	// archive, git, and gRPC references.
+	LoadRefOptions []declcfg.LoadRefOption
+}
+
+func (a ApplyOverlays) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
+	if len(a.OverlayPaths) == 0 {
+		return nil, errors.New("at least one overlay is required")
+	}
+	var overlays []*declcfg.Overlay
+	for _, path := range a.OverlayPaths {
+		o, err := loadOverlays(path)
+		if err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, o...)
+	}
+
+	render := Render{
+		Refs:           a.Refs,
+		Registry:       a.Registry,
+		LoadRefOptions: a.LoadRefOptions,
+	}
+	cfg, err := render.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overlays {
+		if err := o.Apply(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// loadOverlays loads the overlay file at path, or the overlay files in the
+// directory at path.
+func loadOverlays(path string) ([]*declcfg.Overlay, error) {
+	var overlays []*declcfg.Overlay
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		o, err := declcfg.LoadOverlay(f)
+		if err != nil {
+			return fmt.Errorf("load overlay %q: %v", p, err)
+		}
+		overlays = append(overlays, o)
+		return nil
+	})
+	return overlays, err
+}
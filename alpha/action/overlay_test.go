@@ -0,0 +1,74 @@
+package action_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestApplyOverlays(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	const ref = "testdata/foo-index-v0.2.0-declcfg"
+
+	overlayDir := t.TempDir()
+	writeOverlay := func(name, data string) {
+		require.NoError(t, os.WriteFile(filepath.Join(overlayDir, name), []byte(data), 0600))
+	}
+	// Overlays in a directory are applied in the order of their names.
+	writeOverlay("01-default-channel.yaml", `---
+schema: olm.package
+name: foo
+defaultChannel: alpha
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v0.2.0
+    skipRange: <0.2.1
+`)
+	writeOverlay("02-default-channel.json", `{"schema": "olm.package", "name": "foo", "defaultChannel": "stable"}`)
+
+	t.Run("Success", func(t *testing.T) {
+		cfg, err := action.ApplyOverlays{
+			Refs:         []string{ref},
+			OverlayPaths: []string{overlayDir},
+			Registry:     reg,
+		}.Run(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, cfg.Packages, 1)
+		require.Equal(t, "stable", cfg.Packages[0].DefaultChannel)
+		var stable *declcfg.Channel
+		for i := range cfg.Channels {
+			if cfg.Channels[i].Name == "stable" {
+				stable = &cfg.Channels[i]
+			}
+		}
+		require.NotNil(t, stable)
+		require.Equal(t, "<0.2.1", stable.Entries[0].SkipRange)
+		require.Equal(t, "foo.v0.1.0", stable.Entries[0].Replaces)
+	})
+	t.Run("Error/NoOverlays", func(t *testing.T) {
+		_, err := action.ApplyOverlays{Refs: []string{ref}, Registry: reg}.Run(context.Background())
+		require.ErrorContains(t, err, "at least one overlay is required")
+	})
+	t.Run("Error/NoSuchObject", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "overlay.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("schema: olm.package\nname: bar\ndefaultChannel: stable\n"), 0600))
+		_, err := action.ApplyOverlays{
+			Refs:         []string{ref},
+			OverlayPaths: []string{path},
+			Registry:     reg,
+		}.Run(context.Background())
+		require.ErrorContains(t, err, "overlay of olm.package bar: no such object")
+	})
+}
@@ -0,0 +1,427 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// PopulateBundle is a bundle to add to a file-based catalog with Populate,
+// with the channels and default channel that its annotations declare.
+type PopulateBundle struct {
+	declcfg.Bundle
+	Channels       []string
+	DefaultChannel string
+}
+
+// Populate adds bundles to a file-based catalog, and updates the upgrade
+// graphs of their channels in the same modes as registry.DirectoryPopulator
+// does for sqlite databases, without converting the catalog to one:
+//
+//   - registry.ReplacesMode adds each bundle as an entry of its channels
+//     that replaces and skips the bundles that its CSV does.
+//   - registry.SemVerMode inserts each bundle in its channels between the
+//     bundles with the next lower and higher versions, so that it replaces
+//     the former and is replaced by the latter.
+//   - registry.SkipPatchMode does the same, and also makes each bundle skip
+//     the bundles of its channels with the same major and minor version and
+//     a lower patch version, which are removed from the channels.
+//
+// Packages and channels that do not exist are created. Bundles that are
+// already in the catalog are replaced if Overwrite is set, which is only
+// supported in the replaces mode, and are an error otherwise.
+type Populate struct {
+	// Catalog is the file-based catalog that the bundles are added to. It is
+	// updated in place.
+	Catalog   *declcfg.DeclarativeConfig
+	Bundles   []PopulateBundle
+	Mode      registry.Mode
+	Overwrite bool
+}
+
+func (p Populate) Run() error {
+	// Packages that are valid before the bundles are added must be valid
+	// after. Packages that are already invalid are left to the caller, e.g.
+	// to add bundles to them in a permissive mode.
+	valid := map[string]bool{}
+	for _, b := range p.Bundles {
+		if _, ok := valid[b.Package]; !ok {
+			_, err := declcfg.ConvertToModel(packageConfig(*p.Catalog, b.Package))
+			valid[b.Package] = err == nil
+		}
+	}
+	if err := p.populate(); err != nil {
+		return err
+	}
+	for pkg, ok := range valid {
+		if !ok {
+			continue
+		}
+		if _, err := declcfg.ConvertToModel(packageConfig(*p.Catalog, pkg)); err != nil {
+			return liberrors.Errorf(liberrors.CodeInvalidCatalog, "adding bundles to package %s would make it invalid: %v", pkg, err)
+		}
+	}
+	return nil
+}
+
+func (p Populate) populate() error {
+	switch p.Mode {
+	case registry.ReplacesMode:
+		for _, b := range p.Bundles {
+			if err := p.addReplaces(b); err != nil {
+				return err
+			}
+		}
+	case registry.SemVerMode, registry.SkipPatchMode:
+		if p.Overwrite {
+			return liberrors.Errorf(liberrors.CodeInvalidArgument, "overwriting bundles is only supported in the replaces mode")
+		}
+		for _, b := range p.Bundles {
+			if err := p.addSemver(b, p.Mode == registry.SkipPatchMode); err != nil {
+				return err
+			}
+		}
+	default:
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "unsupported update mode %d", p.Mode)
+	}
+	return nil
+}
+
+// LoadBundleDirs loads the bundles unpacked in the directories of
+// imageDirMap, by the image that each of them is referenced by, as
+// registry.DirectoryPopulator does, so that they can be added to a catalog
+// with Populate. The bundles are returned in the order of their images.
+func LoadBundleDirs(imageDirMap map[image.Reference]string, skipReferencedImages bool) ([]PopulateBundle, error) {
+	refs := make([]image.Reference, 0, len(imageDirMap))
+	for ref := range imageDirMap {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].String() < refs[j].String() })
+
+	bundles := make([]PopulateBundle, 0, len(refs))
+	for _, ref := range refs {
+		img, err := registry.NewImageInput(ref, imageDirMap[ref])
+		if err != nil {
+			return nil, fmt.Errorf("load bundle from %q: %v", imageDirMap[ref], err)
+		}
+		b, err := bundleToDeclcfg(img.Bundle, skipReferencedImages)
+		if err != nil {
+			return nil, err
+		}
+		pb := PopulateBundle{Bundle: *b}
+		for _, ch := range img.Bundle.Channels {
+			if ch = strings.TrimSpace(ch); ch != "" {
+				pb.Channels = append(pb.Channels, ch)
+			}
+		}
+		if img.Bundle.Annotations != nil {
+			pb.DefaultChannel = img.Bundle.Annotations.DefaultChannelName
+		}
+		bundles = append(bundles, pb)
+	}
+	return bundles, nil
+}
+
+// addReplaces adds b to the catalog, as an entry of the channels it
+// declares that replaces and skips the bundles its CSV does. A bundle that
+// declares a default channel sets the default channel of its package if it
+// has the highest version in the package.
+func (p Populate) addReplaces(b PopulateBundle) error {
+	cfg := p.Catalog
+	entry, err := bundleChannelEntry(b.Bundle)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(cfg.Bundles, func(o declcfg.Bundle) bool { return o.Package == b.Package && o.Name == b.Name })
+	if i >= 0 && !p.Overwrite {
+		return bundleExistsError(b)
+	}
+	if i >= 0 {
+		cfg.Bundles[i] = b.Bundle
+		for j, ch := range cfg.Channels {
+			if ch.Package == b.Package {
+				cfg.Channels[j].Entries = slices.DeleteFunc(ch.Entries, func(e declcfg.ChannelEntry) bool { return e.Name == b.Name })
+			}
+		}
+	} else {
+		cfg.Bundles = append(cfg.Bundles, b.Bundle)
+	}
+
+	pi := slices.IndexFunc(cfg.Packages, func(pkg declcfg.Package) bool { return pkg.Name == b.Package })
+	if pi < 0 {
+		defaultChannel := b.DefaultChannel
+		if defaultChannel == "" && len(b.Channels) == 1 {
+			defaultChannel = b.Channels[0]
+		}
+		if defaultChannel == "" {
+			return liberrors.Errorf(liberrors.CodeInvalidArgument, "bundle %s adds package %s, but does not declare its default channel", b.Name, b.Package)
+		}
+		cfg.Packages = append(cfg.Packages, declcfg.Package{Schema: declcfg.SchemaPackage, Name: b.Package, DefaultChannel: defaultChannel})
+	} else if b.DefaultChannel != "" {
+		latest, err := isLatestBundle(*cfg, b.Bundle)
+		if err != nil {
+			return err
+		}
+		if latest {
+			cfg.Packages[pi].DefaultChannel = b.DefaultChannel
+		}
+	}
+
+	for _, name := range b.Channels {
+		ci := channelIndex(cfg, b.Package, name)
+		cfg.Channels[ci].Entries = append(cfg.Channels[ci].Entries, entry)
+	}
+	return nil
+}
+
+// addSemver adds b to the catalog, and inserts it in the upgrade graphs of
+// the channels it declares by its version, with the same graph
+// construction as the semver modes of sqlite databases.
+//
+// The graph of the package is generated from the catalog, b is added to it,
+// and the entries of its channels are then updated from the new graph: the
+// edge of an entry to another entry of the channel is its replaces, and its
+// other edges are skips. The skips and skip ranges that entries already
+// have, such as those of the CSV of b, are kept.
+func (p Populate) addSemver(b PopulateBundle, skipPatch bool) error {
+	cfg := p.Catalog
+	if slices.ContainsFunc(cfg.Bundles, func(o declcfg.Bundle) bool { return o.Package == b.Package && o.Name == b.Name }) {
+		return bundleExistsError(b)
+	}
+	entry, err := bundleChannelEntry(b.Bundle)
+	if err != nil {
+		return err
+	}
+	// The replaces of the bundle is determined by its version.
+	entry.Replaces = ""
+
+	graph, err := packageGraph(*cfg, b.Package)
+	if err != nil {
+		return err
+	}
+	version, err := bundleVersion(b.Bundle)
+	if err != nil {
+		return err
+	}
+	rb, err := registry.NewBundleFromStrings(b.Name, version.String(), b.Package, b.DefaultChannel, strings.Join(b.Channels, ","), "")
+	if err != nil {
+		return err
+	}
+	rb.BundleImage = b.Image
+	graph, err = (&registry.BundleGraphLoader{}).AddBundleToGraph(rb, graph, &registry.AnnotationsFile{Annotations: *rb.Annotations}, skipPatch)
+	if err != nil {
+		return fmt.Errorf("bundle %s: %v", b.Name, err)
+	}
+
+	pi := slices.IndexFunc(cfg.Packages, func(pkg declcfg.Package) bool { return pkg.Name == b.Package })
+	if pi < 0 {
+		cfg.Packages = append(cfg.Packages, declcfg.Package{Schema: declcfg.SchemaPackage, Name: b.Package})
+		pi = len(cfg.Packages) - 1
+	}
+	cfg.Packages[pi].DefaultChannel = graph.DefaultChannel
+	cfg.Bundles = append(cfg.Bundles, b.Bundle)
+
+	// removed are the bundles that were removed from a channel, because the
+	// bundle skips them.
+	removed := sets.New[string]()
+	for _, name := range b.Channels {
+		ch := graph.Channels[name]
+		edges := make(map[string]map[registry.BundleKey]struct{}, len(ch.Nodes))
+		for node, nodeEdges := range ch.Nodes {
+			edges[node.CsvName] = nodeEdges
+		}
+
+		ci := channelIndex(cfg, b.Package, name)
+		entries := make([]declcfg.ChannelEntry, 0, len(cfg.Channels[ci].Entries)+1)
+		for _, e := range append(cfg.Channels[ci].Entries, entry) {
+			nodeEdges, ok := edges[e.Name]
+			if !ok {
+				removed.Insert(e.Name)
+				continue
+			}
+			entries = append(entries, graphChannelEntry(e, nodeEdges, edges))
+		}
+		if err := replaceGreatestBehind(*cfg, entries, b.Bundle, version); err != nil {
+			return err
+		}
+		cfg.Channels[ci].Entries = entries
+	}
+
+	// Bundles that are no longer in any channel are removed from the
+	// catalog, as they can no longer be installed.
+	for _, ch := range cfg.Channels {
+		if ch.Package != b.Package {
+			continue
+		}
+		for _, e := range ch.Entries {
+			removed.Delete(e.Name)
+		}
+	}
+	cfg.Bundles = slices.DeleteFunc(cfg.Bundles, func(o declcfg.Bundle) bool { return o.Package == b.Package && removed.Has(o.Name) })
+	return nil
+}
+
+// replaceGreatestBehind makes the entry of b in entries replace the entry of
+// the bundle of cfg with the greatest version lower than version, if the
+// bundle that it would replace in the graph was removed from the channel
+// because b skips it. Otherwise, the lower entries would be left without a
+// path to b, and the channel with more than one head.
+func replaceGreatestBehind(cfg declcfg.DeclarativeConfig, entries []declcfg.ChannelEntry, b declcfg.Bundle, version semver.Version) error {
+	ei := slices.IndexFunc(entries, func(e declcfg.ChannelEntry) bool { return e.Name == b.Name })
+	if ei < 0 || entries[ei].Replaces != "" {
+		return nil
+	}
+	var (
+		greatestBehind string
+		greatest       semver.Version
+	)
+	for _, e := range entries {
+		bi := slices.IndexFunc(cfg.Bundles, func(o declcfg.Bundle) bool { return o.Package == b.Package && o.Name == e.Name })
+		if e.Name == b.Name || bi < 0 {
+			continue
+		}
+		v, err := bundleVersion(cfg.Bundles[bi])
+		if err != nil {
+			return err
+		}
+		if v.LT(version) && (greatestBehind == "" || v.GT(greatest)) {
+			greatestBehind, greatest = e.Name, v
+		}
+	}
+	if greatestBehind != "" {
+		entries[ei].Replaces = greatestBehind
+		entries[ei].Skips = slices.DeleteFunc(entries[ei].Skips, func(s string) bool { return s == greatestBehind })
+	}
+	return nil
+}
+
+// graphChannelEntry returns e, with the replaces and skips of nodeEdges, the
+// edges of e in the graph of its channel, whose nodes are the keys of
+// channelEdges.
+func graphChannelEntry(e declcfg.ChannelEntry, nodeEdges map[registry.BundleKey]struct{}, channelEdges map[string]map[registry.BundleKey]struct{}) declcfg.ChannelEntry {
+	var replaces string
+	var skips []string
+	for edge := range nodeEdges {
+		if _, ok := channelEdges[edge.CsvName]; ok {
+			replaces = edge.CsvName
+		} else {
+			skips = append(skips, edge.CsvName)
+		}
+	}
+	// An entry may replace a bundle that is not in the channel, e.g. the
+	// first entry of a channel created from another one.
+	if replaces == "" && slices.Contains(skips, e.Replaces) {
+		replaces = e.Replaces
+	}
+	sort.Strings(skips)
+
+	out := declcfg.ChannelEntry{Name: e.Name, Replaces: replaces, Skips: slices.Clone(e.Skips), SkipRange: e.SkipRange}
+	for _, skip := range skips {
+		if skip != replaces && !slices.Contains(out.Skips, skip) {
+			out.Skips = append(out.Skips, skip)
+		}
+	}
+	return out
+}
+
+// packageGraph returns the upgrade graph of the package named name in cfg,
+// or an empty graph if cfg does not have the package.
+func packageGraph(cfg declcfg.DeclarativeConfig, name string) (*registry.Package, error) {
+	pkgCfg := packageConfig(cfg, name)
+	if len(pkgCfg.Packages) == 0 {
+		return &registry.Package{}, nil
+	}
+
+	loader, err := registry.NewDeclarativeConfigGraphLoader(pkgCfg)
+	if err != nil {
+		return nil, liberrors.Errorf(liberrors.CodeInvalidCatalog, "package %s: %v", name, err)
+	}
+	graph, err := loader.Generate(name)
+	if err != nil && !errors.Is(err, registry.ErrPackageNotInDatabase) {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// packageConfig returns the package, channels, and bundles of the package
+// named name in cfg.
+func packageConfig(cfg declcfg.DeclarativeConfig, name string) declcfg.DeclarativeConfig {
+	var pkgCfg declcfg.DeclarativeConfig
+	for _, p := range cfg.Packages {
+		if p.Name == name {
+			pkgCfg.Packages = append(pkgCfg.Packages, p)
+		}
+	}
+	for _, ch := range cfg.Channels {
+		if ch.Package == name {
+			pkgCfg.Channels = append(pkgCfg.Channels, ch)
+		}
+	}
+	for _, b := range cfg.Bundles {
+		if b.Package == name {
+			pkgCfg.Bundles = append(pkgCfg.Bundles, b)
+		}
+	}
+	return pkgCfg
+}
+
+// channelIndex returns the index of the channel named name of pkg in cfg,
+// which is created if it does not exist.
+func channelIndex(cfg *declcfg.DeclarativeConfig, pkg, name string) int {
+	ci := slices.IndexFunc(cfg.Channels, func(c declcfg.Channel) bool { return c.Package == pkg && c.Name == name })
+	if ci < 0 {
+		cfg.Channels = append(cfg.Channels, declcfg.Channel{Schema: declcfg.SchemaChannel, Package: pkg, Name: name})
+		ci = len(cfg.Channels) - 1
+	}
+	return ci
+}
+
+func bundleExistsError(b PopulateBundle) error {
+	return liberrors.Errorf(liberrors.CodeBundleAlreadyExists, "bundle %s already exists in package %s", b.Name, b.Package)
+}
+
+// isLatestBundle reports whether b has a higher version than the other
+// bundles of its package in cfg.
+func isLatestBundle(cfg declcfg.DeclarativeConfig, b declcfg.Bundle) (bool, error) {
+	v, err := bundleVersion(b)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range cfg.Bundles {
+		if o.Package != b.Package || o.Name == b.Name {
+			continue
+		}
+		ov, err := bundleVersion(o)
+		if err != nil {
+			return false, err
+		}
+		if ov.GTE(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// bundleVersion returns the version of the olm.package property of b.
+func bundleVersion(b declcfg.Bundle) (semver.Version, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("bundle %s: %v", b.Name, err)
+	}
+	if len(props.Packages) != 1 {
+		return semver.Version{}, fmt.Errorf("bundle %s: must have exactly one %s property", b.Name, property.TypePackage)
+	}
+	return semver.Parse(props.Packages[0].Version)
+}
@@ -0,0 +1,264 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func populateTestBundle(t *testing.T, pkg, version, replaces string, channels ...string) PopulateBundle {
+	t.Helper()
+	name := fmt.Sprintf("%s.v%s", pkg, version)
+	csv, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "ClusterServiceVersion",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"annotations": map[string]string{"olm.skipRange": "<" + version},
+		},
+		"spec": map[string]interface{}{
+			"replaces": replaces,
+			"skips":    []string{pkg + ".v0.0.1"},
+		},
+	})
+	require.NoError(t, err)
+	return PopulateBundle{
+		Bundle: declcfg.Bundle{
+			Schema:     declcfg.SchemaBundle,
+			Name:       name,
+			Package:    pkg,
+			Image:      "example.com/" + pkg + ":v" + version,
+			Properties: []property.Property{property.MustBuildPackage(pkg, version)},
+			CsvJSON:    string(csv),
+		},
+		Channels: channels,
+	}
+}
+
+func TestPopulateReplaces(t *testing.T) {
+	base := func(t *testing.T) *declcfg.DeclarativeConfig {
+		b := populateTestBundle(t, "foo", "1.0.0", "", "stable")
+		return &declcfg.DeclarativeConfig{
+			Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}},
+			Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: b.Name}}}},
+			Bundles:  []declcfg.Bundle{b.Bundle},
+		}
+	}
+	populate := func(cfg *declcfg.DeclarativeConfig, overwrite bool, bundles ...PopulateBundle) error {
+		return Populate{Catalog: cfg, Bundles: bundles, Mode: registry.ReplacesMode, Overwrite: overwrite}.Run()
+	}
+
+	t.Run("ExistingChannel", func(t *testing.T) {
+		cfg := base(t)
+		require.NoError(t, populate(cfg, false, populateTestBundle(t, "foo", "1.1.0", "foo.v1.0.0", "stable")))
+		require.Len(t, cfg.Bundles, 2)
+		require.Equal(t, []declcfg.ChannelEntry{
+			{Name: "foo.v1.0.0"},
+			{Name: "foo.v1.1.0", Replaces: "foo.v1.0.0", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.1.0"},
+		}, cfg.Channels[0].Entries)
+		require.Equal(t, "stable", cfg.Packages[0].DefaultChannel)
+	})
+
+	t.Run("NewChannelAndDefault", func(t *testing.T) {
+		cfg := base(t)
+		b := populateTestBundle(t, "foo", "2.0.0", "foo.v1.0.0", "fast", "stable")
+		b.DefaultChannel = "fast"
+		require.NoError(t, populate(cfg, false, b))
+		require.Len(t, cfg.Channels, 2)
+		require.Equal(t, "fast", cfg.Channels[1].Name)
+		require.Equal(t, "fast", cfg.Packages[0].DefaultChannel)
+	})
+
+	t.Run("OlderBundleKeepsDefault", func(t *testing.T) {
+		cfg := base(t)
+		b := populateTestBundle(t, "foo", "0.9.0", "", "fast")
+		b.DefaultChannel = "fast"
+		require.NoError(t, populate(cfg, false, b))
+		require.Equal(t, "stable", cfg.Packages[0].DefaultChannel)
+	})
+
+	t.Run("NewPackage", func(t *testing.T) {
+		cfg := base(t)
+		require.NoError(t, populate(cfg, false, populateTestBundle(t, "bar", "1.0.0", "", "alpha")))
+		require.Len(t, cfg.Packages, 2)
+		require.Equal(t, declcfg.Package{Schema: declcfg.SchemaPackage, Name: "bar", DefaultChannel: "alpha"}, cfg.Packages[1])
+	})
+
+	t.Run("NewPackageWithoutDefault", func(t *testing.T) {
+		cfg := base(t)
+		err := populate(cfg, false, populateTestBundle(t, "bar", "1.0.0", "", "alpha", "beta"))
+		require.ErrorContains(t, err, "does not declare its default channel")
+	})
+
+	t.Run("InvalidPackage", func(t *testing.T) {
+		// A bundle that replaces nothing would be a second head of the
+		// channel.
+		cfg := base(t)
+		err := populate(cfg, false, populateTestBundle(t, "foo", "2.0.0", "", "stable"))
+		require.ErrorContains(t, err, "adding bundles to package foo would make it invalid")
+	})
+
+	t.Run("AlreadyExists", func(t *testing.T) {
+		cfg := base(t)
+		err := populate(cfg, false, populateTestBundle(t, "foo", "1.0.0", "", "stable"))
+		require.EqualError(t, err, "bundle foo.v1.0.0 already exists in package foo")
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		cfg := base(t)
+		b := populateTestBundle(t, "foo", "1.0.0", "", "stable")
+		b.Image = "example.com/foo:rebuilt"
+		require.NoError(t, populate(cfg, true, b))
+		require.Len(t, cfg.Bundles, 1)
+		require.Equal(t, "example.com/foo:rebuilt", cfg.Bundles[0].Image)
+		require.Len(t, cfg.Channels[0].Entries, 1)
+	})
+}
+
+func TestPopulateSemver(t *testing.T) {
+	// The bundles are added out of order, and replace bundles other than
+	// the ones that their CSV replaces.
+	bundles := func(t *testing.T) []PopulateBundle {
+		v100 := populateTestBundle(t, "foo", "1.0.0", "", "stable")
+		v100.DefaultChannel = "stable"
+		return []PopulateBundle{
+			v100,
+			populateTestBundle(t, "foo", "1.1.0", "foo.v1.0.0", "stable"),
+			populateTestBundle(t, "foo", "1.0.1", "foo.v0.9.0", "stable", "fast"),
+			populateTestBundle(t, "foo", "1.0.2", "", "stable"),
+		}
+	}
+	channelEntries := func(cfg declcfg.DeclarativeConfig) map[string][]declcfg.ChannelEntry {
+		entries := map[string][]declcfg.ChannelEntry{}
+		for _, ch := range cfg.Channels {
+			entries[ch.Name] = ch.Entries
+		}
+		return entries
+	}
+	bundleNames := func(cfg declcfg.DeclarativeConfig) []string {
+		var names []string
+		for _, b := range cfg.Bundles {
+			names = append(names, b.Name)
+		}
+		return names
+	}
+
+	t.Run("SemVer", func(t *testing.T) {
+		cfg := &declcfg.DeclarativeConfig{}
+		require.NoError(t, Populate{Catalog: cfg, Bundles: bundles(t), Mode: registry.SemVerMode}.Run())
+		require.Equal(t, []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}}, cfg.Packages)
+		require.Equal(t, map[string][]declcfg.ChannelEntry{
+			"stable": {
+				{Name: "foo.v1.0.0", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.0.0"},
+				{Name: "foo.v1.1.0", Replaces: "foo.v1.0.2", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.1.0"},
+				{Name: "foo.v1.0.1", Replaces: "foo.v1.0.0", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.0.1"},
+				{Name: "foo.v1.0.2", Replaces: "foo.v1.0.1", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.0.2"},
+			},
+			"fast": {
+				{Name: "foo.v1.0.1", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.0.1"},
+			},
+		}, channelEntries(*cfg))
+		require.Equal(t, []string{"foo.v1.0.0", "foo.v1.1.0", "foo.v1.0.1", "foo.v1.0.2"}, bundleNames(*cfg))
+		_, err := declcfg.ConvertToModel(*cfg)
+		require.NoError(t, err)
+	})
+
+	t.Run("SkipPatch", func(t *testing.T) {
+		cfg := &declcfg.DeclarativeConfig{}
+		require.NoError(t, Populate{Catalog: cfg, Bundles: bundles(t), Mode: registry.SkipPatchMode}.Run())
+		require.Equal(t, map[string][]declcfg.ChannelEntry{
+			"stable": {
+				{Name: "foo.v1.1.0", Replaces: "foo.v1.0.2", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.1.0"},
+				{Name: "foo.v1.0.2", Skips: []string{"foo.v0.0.1", "foo.v1.0.1"}, SkipRange: "<1.0.2"},
+			},
+			"fast": {
+				{Name: "foo.v1.0.1", Skips: []string{"foo.v0.0.1"}, SkipRange: "<1.0.1"},
+			},
+		}, channelEntries(*cfg))
+		// foo.v1.0.0 is skipped by foo.v1.0.1, and so no longer in any
+		// channel, and foo.v1.0.1, skipped in turn by foo.v1.0.2, is still in
+		// the fast channel.
+		require.Equal(t, []string{"foo.v1.1.0", "foo.v1.0.1", "foo.v1.0.2"}, bundleNames(*cfg))
+		_, err := declcfg.ConvertToModel(*cfg)
+		require.NoError(t, err)
+	})
+
+	t.Run("SkipPatchPrunesReplaces", func(t *testing.T) {
+		// foo.v1.0.2 skips foo.v1.0.1, which it would replace, so it
+		// replaces the next lower bundle of the channel instead, so that
+		// foo.v0.9.0 is not left as a second head of the channel.
+		cfg := &declcfg.DeclarativeConfig{}
+		var b []PopulateBundle
+		for _, version := range []string{"0.9.0", "1.0.1", "1.0.2"} {
+			pb := populateTestBundle(t, "foo", version, "", "stable")
+			pb.CsvJSON = strings.Replace(pb.CsvJSON, `"annotations":{"olm.skipRange":"\u003c`+version+`"},`, "", 1)
+			b = append(b, pb)
+		}
+		b[0].DefaultChannel = "stable"
+		require.NoError(t, Populate{Catalog: cfg, Bundles: b[:2], Mode: registry.SkipPatchMode}.Run())
+		require.NoError(t, Populate{Catalog: cfg, Bundles: b[2:], Mode: registry.SkipPatchMode}.Run())
+		require.Equal(t, map[string][]declcfg.ChannelEntry{
+			"stable": {
+				{Name: "foo.v0.9.0", Skips: []string{"foo.v0.0.1"}},
+				{Name: "foo.v1.0.2", Replaces: "foo.v0.9.0", Skips: []string{"foo.v0.0.1", "foo.v1.0.1"}},
+			},
+		}, channelEntries(*cfg))
+		require.Equal(t, []string{"foo.v0.9.0", "foo.v1.0.2"}, bundleNames(*cfg))
+		_, err := declcfg.ConvertToModel(*cfg)
+		require.NoError(t, err)
+	})
+
+	t.Run("AlreadyExists", func(t *testing.T) {
+		cfg := &declcfg.DeclarativeConfig{}
+		b := bundles(t)
+		err := Populate{Catalog: cfg, Bundles: append(b, b[0]), Mode: registry.SemVerMode}.Run()
+		require.EqualError(t, err, "bundle foo.v1.0.0 already exists in package foo")
+	})
+
+	t.Run("SameVersion", func(t *testing.T) {
+		cfg := &declcfg.DeclarativeConfig{}
+		b := bundles(t)
+		b = append(b, b[1])
+		b[len(b)-1].Name = "foo.v1.1.0-rebuilt"
+		err := Populate{Catalog: cfg, Bundles: b, Mode: registry.SemVerMode}.Run()
+		require.EqualError(t, err, "bundle foo.v1.1.0-rebuilt: Bundle version 1.1.0 already added to index")
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		err := Populate{Catalog: &declcfg.DeclarativeConfig{}, Bundles: bundles(t), Mode: registry.SemVerMode, Overwrite: true}.Run()
+		require.ErrorContains(t, err, "overwriting bundles is only supported in the replaces mode")
+	})
+}
+
+func TestLoadBundleDirs(t *testing.T) {
+	bundles, err := LoadBundleDirs(map[image.Reference]string{
+		image.SimpleReference("test.registry/baz-operator/baz-bundle:v1.1.0"): "testdata/baz-bundle-v1.1.0",
+		image.SimpleReference("test.registry/baz-operator/baz-bundle:v1.0.0"): "testdata/baz-bundle-v1.0.0",
+		image.SimpleReference("test.registry/baz-operator/baz-bundle:v1.0.1"): "testdata/baz-bundle-v1.0.1",
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, bundles, 3)
+	for i, version := range []string{"1.0.0", "1.0.1", "1.1.0"} {
+		require.Equal(t, "baz.v"+version, bundles[i].Name)
+		require.Equal(t, "test.registry/baz-operator/baz-bundle:v"+version, bundles[i].Image)
+		require.Equal(t, []string{"stable"}, bundles[i].Channels)
+		require.Equal(t, "stable", bundles[i].DefaultChannel)
+	}
+
+	cfg := &declcfg.DeclarativeConfig{}
+	require.NoError(t, Populate{Catalog: cfg, Bundles: bundles, Mode: registry.SkipPatchMode}.Run())
+	require.Equal(t, []declcfg.ChannelEntry{
+		{Name: "baz.v1.0.1", Skips: []string{"baz.v1.0.0"}, SkipRange: "<1.0.1"},
+		{Name: "baz.v1.1.0", Replaces: "baz.v1.0.1"},
+	}, cfg.Channels[0].Entries)
+	_, err = declcfg.ConvertToModel(*cfg)
+	require.NoError(t, err)
+}
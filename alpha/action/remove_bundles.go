@@ -0,0 +1,232 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// RemoveBundles removes bundles from a package in a file-based catalog
+// directory, by rewriting the files that contain the bundles, the channels
+// of the package, and its deprecations.
+//
+// By default, upgrade edges are rewired around the removed bundles: a channel
+// entry that replaces a removed bundle instead replaces the closest remaining
+// entry in the replaces chain of the removed bundle. With ForceTruncate, the
+// entries that a removed bundle replaces, directly or transitively, are
+// removed from the channel as well, along with the bundles that are then no
+// longer in any channel.
+//
+// Channels left without entries are removed. It is an error to remove every
+// entry of the default channel of the package.
+type RemoveBundles struct {
+	CatalogDir    string
+	Package       string
+	Bundles       []string
+	ForceTruncate bool
+}
+
+type RemoveBundlesResult struct {
+	Bundles  []string `json:"bundles"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+func (r RemoveBundles) Run(ctx context.Context) (*RemoveBundlesResult, error) {
+	fsys := os.DirFS(r.CatalogDir)
+
+	var (
+		mu    sync.Mutex
+		metas []*declcfg.Meta
+		// bundleFiles maps the names of the package's bundles to the files
+		// that define them.
+		bundleFiles = map[string]string{}
+		// files are the files to rewrite: those that contain the package's
+		// channels or deprecations, and later those of removed bundles.
+		files = sets.New[string]()
+	)
+	if err := declcfg.WalkMetasFS(ctx, fsys, func(path string, meta *declcfg.Meta, err error) error {
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		metas = append(metas, meta)
+		if meta.Package != r.Package {
+			return nil
+		}
+		switch meta.Schema {
+		case declcfg.SchemaBundle:
+			bundleFiles[meta.Name] = path
+		case declcfg.SchemaChannel, declcfg.SchemaDeprecation:
+			files.Insert(path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	cfg, err := declcfg.LoadSlice(metas)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg *declcfg.Package
+	for i := range cfg.Packages {
+		if cfg.Packages[i].Name == r.Package {
+			pkg = &cfg.Packages[i]
+		}
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("package %q not found", r.Package)
+	}
+	for _, name := range r.Bundles {
+		if _, ok := bundleFiles[name]; !ok {
+			return nil, fmt.Errorf("bundle %q not found in package %q", name, r.Package)
+		}
+	}
+
+	remove := sets.New[string](r.Bundles...)
+	// entries are the new entries of each channel of the package.
+	entries := map[string][]declcfg.ChannelEntry{}
+	remaining := sets.New[string]()
+	for _, ch := range cfg.Channels {
+		if ch.Package != r.Package {
+			continue
+		}
+		entries[ch.Name] = r.removeEntries(ch.Entries, remove)
+		for _, e := range entries[ch.Name] {
+			remaining.Insert(e.Name)
+		}
+	}
+
+	res := &RemoveBundlesResult{}
+	for name, chEntries := range entries {
+		if len(chEntries) > 0 {
+			continue
+		}
+		if name == pkg.DefaultChannel {
+			return nil, fmt.Errorf("cannot remove every entry of default channel %q of package %q", name, r.Package)
+		}
+		res.Channels = append(res.Channels, name)
+	}
+	sort.Strings(res.Channels)
+	removedChannels := sets.New[string](res.Channels...)
+
+	var removedBundles []declcfg.Bundle
+	for _, b := range cfg.Bundles {
+		if b.Package != r.Package {
+			continue
+		}
+		// With ForceTruncate, bundles whose entries were all truncated are
+		// removed too.
+		truncated := r.ForceTruncate && !remaining.Has(b.Name) && wasInChannel(cfg.Channels, r.Package, b.Name)
+		if remove.Has(b.Name) || truncated {
+			removedBundles = append(removedBundles, b)
+			res.Bundles = append(res.Bundles, b.Name)
+			files.Insert(bundleFiles[b.Name])
+		}
+	}
+	sort.Strings(res.Bundles)
+
+	if err := rewriteCatalogFiles(r.CatalogDir, fsys, sets.List(files), func(_ string, fileCfg *declcfg.DeclarativeConfig) {
+		channels := fileCfg.Channels[:0]
+		for _, ch := range fileCfg.Channels {
+			if ch.Package == r.Package {
+				if removedChannels.Has(ch.Name) {
+					continue
+				}
+				ch.Entries = entries[ch.Name]
+			}
+			channels = append(channels, ch)
+		}
+		fileCfg.Channels = channels
+		declcfg.RemoveBundles(fileCfg, removedBundles)
+		removeChannelDeprecations(fileCfg, r.Package, removedChannels)
+	}); err != nil {
+		return nil, fmt.Errorf("remove bundles: %v", err)
+	}
+	return res, nil
+}
+
+// removeEntries returns the channel entries that remain after the entries of
+// the bundles in remove are removed, rewiring or truncating the replaces
+// chains through them.
+func (r RemoveBundles) removeEntries(in []declcfg.ChannelEntry, remove sets.Set[string]) []declcfg.ChannelEntry {
+	replaces := map[string]string{}
+	for _, e := range in {
+		replaces[e.Name] = e.Replaces
+	}
+	removed := remove.Clone()
+	if r.ForceTruncate {
+		for name := range remove {
+			seen := sets.New[string]()
+			for next := replaces[name]; next != "" && !seen.Has(next); next = replaces[next] {
+				seen.Insert(next)
+				removed.Insert(next)
+			}
+		}
+	}
+
+	// closestRemaining follows the replaces chain from name to the first
+	// entry that is not removed, or returns "" if there is none.
+	closestRemaining := func(name string) string {
+		seen := sets.New[string]()
+		for name != "" && removed.Has(name) && !seen.Has(name) {
+			seen.Insert(name)
+			name = replaces[name]
+		}
+		if removed.Has(name) {
+			return ""
+		}
+		return name
+	}
+
+	out := make([]declcfg.ChannelEntry, 0, len(in))
+	for _, e := range in {
+		if removed.Has(e.Name) {
+			continue
+		}
+		if removed.Has(e.Replaces) {
+			e.Replaces = closestRemaining(e.Replaces)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func wasInChannel(channels []declcfg.Channel, pkg, name string) bool {
+	for _, ch := range channels {
+		if ch.Package != pkg {
+			continue
+		}
+		for _, e := range ch.Entries {
+			if e.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeChannelDeprecations removes the deprecation entries of pkg that
+// reference the given channels.
+func removeChannelDeprecations(cfg *declcfg.DeclarativeConfig, pkg string, channels sets.Set[string]) {
+	for i, d := range cfg.Deprecations {
+		if d.Package != pkg {
+			continue
+		}
+		entries := d.Entries[:0]
+		for _, e := range d.Entries {
+			if e.Reference.Schema == declcfg.SchemaChannel && channels.Has(e.Reference.Name) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		cfg.Deprecations[i].Entries = entries
+	}
+}
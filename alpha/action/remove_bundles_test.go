@@ -0,0 +1,160 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestRemoveBundles(t *testing.T) {
+	const catalog = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.1.0
+- name: foo.v0.2.0
+  replaces: foo.v0.1.0
+- name: foo.v0.3.0
+  replaces: foo.v0.2.0
+---
+schema: olm.channel
+package: foo
+name: candidate
+entries:
+- name: foo.v0.3.0
+---
+schema: olm.deprecations
+package: foo
+entries:
+- reference:
+    schema: olm.bundle
+    name: foo.v0.2.0
+  message: foo.v0.2.0 is deprecated
+- reference:
+    schema: olm.channel
+    name: candidate
+  message: candidate is deprecated
+`
+	bundle := func(version string) string {
+		return `{"schema": "olm.bundle", "package": "foo", "name": "foo.v` + version + `", "image": "foo:v` + version + `", "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "` + version + `"}}]}`
+	}
+
+	type spec struct {
+		name             string
+		remove           RemoveBundles
+		expectErr        string
+		expectResult     *RemoveBundlesResult
+		expectChannels   map[string][]declcfg.ChannelEntry
+		expectBundles    []string
+		expectDeprecated []string
+	}
+	specs := []spec{
+		{
+			name:         "RewireAroundRemovedBundle",
+			remove:       RemoveBundles{Bundles: []string{"foo.v0.2.0"}},
+			expectResult: &RemoveBundlesResult{Bundles: []string{"foo.v0.2.0"}},
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable":    {{Name: "foo.v0.1.0"}, {Name: "foo.v0.3.0", Replaces: "foo.v0.1.0"}},
+				"candidate": {{Name: "foo.v0.3.0"}},
+			},
+			expectBundles:    []string{"foo.v0.1.0", "foo.v0.3.0"},
+			expectDeprecated: []string{"candidate"},
+		},
+		{
+			name:         "ForceTruncate",
+			remove:       RemoveBundles{Bundles: []string{"foo.v0.2.0"}, ForceTruncate: true},
+			expectResult: &RemoveBundlesResult{Bundles: []string{"foo.v0.1.0", "foo.v0.2.0"}},
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable":    {{Name: "foo.v0.3.0"}},
+				"candidate": {{Name: "foo.v0.3.0"}},
+			},
+			expectBundles:    []string{"foo.v0.3.0"},
+			expectDeprecated: []string{"candidate"},
+		},
+		{
+			name:         "RemoveChannel",
+			remove:       RemoveBundles{Bundles: []string{"foo.v0.3.0"}},
+			expectResult: &RemoveBundlesResult{Bundles: []string{"foo.v0.3.0"}, Channels: []string{"candidate"}},
+			expectChannels: map[string][]declcfg.ChannelEntry{
+				"stable": {{Name: "foo.v0.1.0"}, {Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"}},
+			},
+			expectBundles:    []string{"foo.v0.1.0", "foo.v0.2.0"},
+			expectDeprecated: []string{"foo.v0.2.0"},
+		},
+		{
+			name:      "RemoveDefaultChannel",
+			remove:    RemoveBundles{Bundles: []string{"foo.v0.3.0"}, ForceTruncate: true},
+			expectErr: `cannot remove every entry of default channel "stable" of package "foo"`,
+		},
+		{
+			name:      "UnknownBundle",
+			remove:    RemoveBundles{Bundles: []string{"foo.v0.4.0"}},
+			expectErr: `bundle "foo.v0.4.0" not found in package "foo"`,
+		},
+		{
+			name:      "UnknownPackage",
+			remove:    RemoveBundles{Package: "bar", Bundles: []string{"bar.v0.1.0"}},
+			expectErr: `package "bar" not found`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "foo", "bundles"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "foo", "catalog.yaml"), []byte(catalog), 0600))
+			for _, v := range []string{"0.1.0", "0.2.0", "0.3.0"} {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "foo", "bundles", v+".json"), []byte(bundle(v)), 0600))
+			}
+
+			s.remove.CatalogDir = dir
+			if s.remove.Package == "" {
+				s.remove.Package = "foo"
+			}
+			res, err := s.remove.Run(context.Background())
+			if s.expectErr != "" {
+				require.EqualError(t, err, s.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, s.expectResult, res)
+
+			cfg, err := declcfg.LoadFS(context.Background(), os.DirFS(dir))
+			require.NoError(t, err)
+			_, err = declcfg.ConvertToModel(*cfg)
+			require.NoError(t, err)
+
+			channels := map[string][]declcfg.ChannelEntry{}
+			for _, ch := range cfg.Channels {
+				channels[ch.Name] = ch.Entries
+			}
+			require.Equal(t, s.expectChannels, channels)
+
+			var bundles []string
+			for _, b := range cfg.Bundles {
+				bundles = append(bundles, b.Name)
+			}
+			require.ElementsMatch(t, s.expectBundles, bundles)
+			for _, name := range res.Bundles {
+				require.NoFileExists(t, filepath.Join(dir, "foo", "bundles", name[len("foo.v"):]+".json"))
+			}
+
+			var deprecated []string
+			for _, d := range cfg.Deprecations {
+				for _, e := range d.Entries {
+					deprecated = append(deprecated, e.Reference.Name)
+				}
+			}
+			require.Equal(t, s.expectDeprecated, deprecated)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -15,15 +16,19 @@ import (
 
 	"github.com/h2non/filetype"
 	"github.com/h2non/filetype/matchers"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/operator-framework/operator-registry/alpha/action/migrations"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/model"
 	"github.com/operator-framework/operator-registry/alpha/property"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	"github.com/operator-framework/operator-registry/pkg/lib/config"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
@@ -40,6 +45,9 @@ const (
 	RefDCImage
 	RefDCDir
 	RefBundleDir
+	RefDCArchive
+	RefDCGit
+	RefDCGRPC
 
 	RefAll = 0
 )
@@ -57,6 +65,37 @@ type Render struct {
 	ImageRefTemplate *template.Template
 	Migrations       *migrations.Migrations
 
+	// LoadRefOptions are passed to declcfg.LoadRef when rendering
+	// references. Their sources take precedence over those of Render.
+	LoadRefOptions []declcfg.LoadRefOption
+
+	// SkipReferencedImages excludes the images that bundle CSVs only
+	// reference, through RELATED_IMAGE_* environment variables and image
+	// annotations, from the related images of rendered bundles.
+	SkipReferencedImages bool
+
+	// MaxParallel is the maximum number of references, e.g. bundle images,
+	// that are pulled and rendered at the same time. The rendered output is
+	// in the order of Refs regardless. Zero means one at a time.
+	MaxParallel int
+
+	// FilterPackages limits the rendered output to the named packages.
+	// File-based catalogs and sqlite databases only load the objects of
+	// those packages.
+	FilterPackages []string
+	// FilterChannels limits the rendered output to the named channels, and
+	// to the bundles that are entries of them.
+	FilterChannels []string
+
+	// BundleProperties are added to the rendered bundles that match their
+	// selectors, before the properties of the bundles are validated.
+	BundleProperties *declcfg.BundleProperties
+
+	// IncludeDeprecatedBundles renders the bundles that were deprecated in
+	// sqlite databases, along with olm.deprecations entries for them, instead
+	// of omitting them.
+	IncludeDeprecatedBundles bool
+
 	skipSqliteDeprecationLog bool
 }
 
@@ -74,25 +113,40 @@ func (r Render) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
 		r.Registry = reg
 	}
 
-	var cfgs []declcfg.DeclarativeConfig
-	for _, ref := range r.Refs {
-		cfg, err := r.renderReference(ctx, ref)
-		if err != nil {
-			return nil, fmt.Errorf("render reference %q: %w", ref, err)
-		}
-		moveBundleObjectsToEndOfPropertySlices(cfg)
+	cfgs := make([]declcfg.DeclarativeConfig, len(r.Refs))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(max(r.MaxParallel, 1))
+	for i, ref := range r.Refs {
+		eg.Go(func() error {
+			cfg, err := r.renderReference(egCtx, ref)
+			if err != nil {
+				return fmt.Errorf("render reference %q: %w", ref, err)
+			}
+			filterConfig(cfg, r.FilterPackages, r.FilterChannels)
+			if err := r.BundleProperties.Apply(cfg); err != nil {
+				return fmt.Errorf("render reference %q: add bundle properties: %v", ref, err)
+			}
+			if msgs := config.CheckConstraints(*cfg); len(msgs) > 0 {
+				return liberrors.Errorf(liberrors.CodeInvalidCatalog, "render reference %q: %s", ref, strings.Join(msgs, "\n"))
+			}
+			moveBundleObjectsToEndOfPropertySlices(cfg)
 
-		for _, b := range cfg.Bundles {
-			sort.Slice(b.RelatedImages, func(i, j int) bool {
-				return b.RelatedImages[i].Image < b.RelatedImages[j].Image
-			})
-		}
+			for _, b := range cfg.Bundles {
+				sort.Slice(b.RelatedImages, func(i, j int) bool {
+					return b.RelatedImages[i].Image < b.RelatedImages[j].Image
+				})
+			}
 
-		if err := r.migrate(cfg); err != nil {
-			return nil, fmt.Errorf("migrate: %v", err)
-		}
+			if err := r.migrate(cfg); err != nil {
+				return fmt.Errorf("migrate: %v", err)
+			}
 
-		cfgs = append(cfgs, *cfg)
+			cfgs[i] = *cfg
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
 
 	return combineConfigs(cfgs), nil
@@ -118,37 +172,84 @@ func (r Render) createRegistry() (*containerdregistry.Registry, error) {
 	return reg, nil
 }
 
+// declcfgRefTypes maps the declcfg reference sources that render delegates
+// to declcfg.LoadRef to their corresponding reference types.
+var declcfgRefTypes = map[string]RefType{
+	declcfg.RefSourceArchive: RefDCArchive,
+	declcfg.RefSourceGit:     RefDCGit,
+	declcfg.RefSourceGRPC:    RefDCGRPC,
+}
+
+// renderReference loads ref with declcfg.LoadRef. Directories, files, and
+// images are loaded by the sources of r, which also render bundles and
+// sqlite databases, while the other references are loaded by the built-in
+// sources of declcfg.
 func (r Render) renderReference(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
-	stat, err := os.Stat(ref)
+	opts := append(slices.Clone(r.LoadRefOptions),
+		declcfg.WithLoadOptions(r.loadOptions()...),
+		declcfg.WithRefSources(
+			renderSource{name: declcfg.RefSourceDir, load: r.dirToDeclcfg},
+			renderSource{name: declcfg.RefSourceFile, load: r.fileToDeclcfg},
+			renderSource{name: declcfg.RefSourceImage, load: r.imageToDeclcfg},
+		),
+	)
+	src, err := declcfg.DetectRefSource(ref, opts...)
 	if err != nil {
-		return r.imageToDeclcfg(ctx, ref)
+		return nil, liberrors.New(liberrors.CodeInvalidArgument, err)
 	}
-	if stat.IsDir() {
-		dirEntries, err := os.ReadDir(ref)
-		if err != nil {
-			return nil, err
-		}
-		if isBundle(dirEntries) {
-			// Looks like a bundle directory
-			if !r.AllowedRefMask.Allowed(RefBundleDir) {
-				return nil, fmt.Errorf("cannot render bundle directory %q: %w", ref, ErrNotAllowed)
-			}
-			return r.renderBundleDirectory(ref)
-		}
+	if refType, ok := declcfgRefTypes[src.Name()]; ok && !r.AllowedRefMask.Allowed(refType) {
+		return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render declarative config %s reference: %w", src.Name(), ErrNotAllowed)
+	}
+	return declcfg.LoadRef(ctx, ref, opts...)
+}
+
+// renderSource is a declcfg.RefSource that matches the same references as
+// the built-in declcfg source with the same name, and loads them with load.
+type renderSource struct {
+	name string
+	load func(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error)
+}
+
+func (s renderSource) Name() string { return s.name }
+
+func (s renderSource) Matches(ref string) bool {
+	src, err := declcfg.DetectRefSource(ref)
+	return err == nil && src.Name() == s.name
+}
+
+func (s renderSource) Load(ctx context.Context, ref string, _ declcfg.LoadRefOptions) (*declcfg.DeclarativeConfig, error) {
+	return s.load(ctx, ref)
+}
 
-		// Otherwise, assume it is a declarative config root directory.
-		if !r.AllowedRefMask.Allowed(RefDCDir) {
-			return nil, fmt.Errorf("cannot render declarative config directory: %w", ErrNotAllowed)
+func (r Render) dirToDeclcfg(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	dirEntries, err := os.ReadDir(ref)
+	if err != nil {
+		return nil, err
+	}
+	if isBundle(dirEntries) {
+		// Looks like a bundle directory
+		if !r.AllowedRefMask.Allowed(RefBundleDir) {
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render bundle directory %q: %w", ref, ErrNotAllowed)
 		}
-		return declcfg.LoadFS(ctx, os.DirFS(ref))
+		return r.renderBundleDirectory(ref)
+	}
+
+	// Otherwise, assume it is a declarative config root directory.
+	if !r.AllowedRefMask.Allowed(RefDCDir) {
+		return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render declarative config directory: %w", ErrNotAllowed)
 	}
+	cfg, err := declcfg.LoadFS(ctx, os.DirFS(ref), r.loadOptions()...)
+	return cfg, liberrors.Wrap(liberrors.CodeInvalidCatalog, err)
+}
+
+func (r Render) fileToDeclcfg(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
 	// The only supported file type is an sqlite DB file,
 	// since declarative configs will be in a directory.
 	if err := checkDBFile(ref); err != nil {
-		return nil, err
+		return nil, liberrors.New(liberrors.CodeInvalidArgument, err)
 	}
 	if !r.AllowedRefMask.Allowed(RefSqliteFile) {
-		return nil, fmt.Errorf("cannot render sqlite file: %w", ErrNotAllowed)
+		return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render sqlite file: %w", ErrNotAllowed)
 	}
 
 	db, err := sqlite.Open(ref)
@@ -156,13 +257,13 @@ func (r Render) renderReference(ctx context.Context, ref string) (*declcfg.Decla
 		return nil, err
 	}
 	defer db.Close()
-	return sqliteToDeclcfg(ctx, db)
+	return sqliteToDeclcfg(ctx, db, []sqlite.SQLiteQuerierOption{sqlite.OnlyPackages(r.FilterPackages...)}, sqlite.IncludeDeprecatedBundles(r.IncludeDeprecatedBundles))
 }
 
 func (r Render) imageToDeclcfg(ctx context.Context, imageRef string) (*declcfg.DeclarativeConfig, error) {
 	ref := image.SimpleReference(imageRef)
 	if err := r.Registry.Pull(ctx, ref); err != nil {
-		return nil, fmt.Errorf("failed to pull image %q: %v", ref, err)
+		return nil, liberrors.Errorf(liberrors.CodeImagePullFailed, "failed to pull image %q: %v", ref, err)
 	}
 	labels, err := r.Registry.Labels(ctx, ref)
 	if err != nil {
@@ -180,35 +281,42 @@ func (r Render) imageToDeclcfg(ctx context.Context, imageRef string) (*declcfg.D
 	var cfg *declcfg.DeclarativeConfig
 	if dbFile, ok := labels[containertools.DbLocationLabel]; ok {
 		if !r.AllowedRefMask.Allowed(RefSqliteImage) {
-			return nil, fmt.Errorf("cannot render sqlite image: %w", ErrNotAllowed)
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render sqlite image: %w", ErrNotAllowed)
 		}
 		db, err := sqlite.Open(filepath.Join(tmpDir, dbFile))
 		if err != nil {
 			return nil, err
 		}
 		defer db.Close()
-		cfg, err = sqliteToDeclcfg(ctx, db)
+		cfg, err = sqliteToDeclcfg(ctx, db, []sqlite.SQLiteQuerierOption{sqlite.OnlyPackages(r.FilterPackages...)}, sqlite.IncludeDeprecatedBundles(r.IncludeDeprecatedBundles))
 		if err != nil {
 			return nil, err
 		}
 	} else if configsDir, ok := labels[containertools.ConfigsLocationLabel]; ok {
 		if !r.AllowedRefMask.Allowed(RefDCImage) {
-			return nil, fmt.Errorf("cannot render declarative config image: %w", ErrNotAllowed)
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render declarative config image: %w", ErrNotAllowed)
 		}
-		cfg, err = declcfg.LoadFS(ctx, os.DirFS(filepath.Join(tmpDir, configsDir)))
+		cfg, err = declcfg.LoadFS(ctx, os.DirFS(filepath.Join(tmpDir, configsDir)), r.loadOptions()...)
 		if err != nil {
-			return nil, err
+			return nil, liberrors.Wrap(liberrors.CodeInvalidCatalog, err)
 		}
 	} else if _, ok := labels[bundle.PackageLabel]; ok {
 		if !r.AllowedRefMask.Allowed(RefBundleImage) {
-			return nil, fmt.Errorf("cannot render bundle image: %w", ErrNotAllowed)
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "cannot render bundle image: %w", ErrNotAllowed)
 		}
 		img, err := registry.NewImageInput(ref, tmpDir)
 		if err != nil {
 			return nil, err
 		}
+		if image.IsOCILayoutReference(imageRef) {
+			// Images in OCI layout directories have no pullable reference, so
+			// populate it from the template, if there is one.
+			if err := r.templateBundleImageRef(img.Bundle); err != nil {
+				return nil, fmt.Errorf("failed templating image reference from bundle for %q: %v", ref, err)
+			}
+		}
 
-		bundle, err := bundleToDeclcfg(img.Bundle)
+		bundle, err := bundleToDeclcfg(img.Bundle, r.SkipReferencedImages)
 		if err != nil {
 			return nil, err
 		}
@@ -220,14 +328,71 @@ func (r Render) imageToDeclcfg(ctx context.Context, imageRef string) (*declcfg.D
 			labelVals = append(labelVals, fmt.Sprintf("  %s=%s", k, labels[k]))
 		}
 		if len(labelVals) > 0 {
-			return nil, fmt.Errorf("render %q: image type could not be determined, found labels\n%s", ref, strings.Join(labelVals, "\n"))
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "render %q: image type could not be determined, found labels\n%s", ref, strings.Join(labelVals, "\n"))
 		} else {
-			return nil, fmt.Errorf("render %q: image type could not be determined: image has no labels", ref)
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "render %q: image type could not be determined: image has no labels", ref)
 		}
 	}
 	return cfg, nil
 }
 
+// loadOptions returns the options used to load file-based catalogs.
+func (r Render) loadOptions() []declcfg.LoadOption {
+	return []declcfg.LoadOption{declcfg.WithPackages(r.FilterPackages...)}
+}
+
+// filterConfig removes the objects of packages other than packages, and the
+// channels other than channels along with the bundles that are not entries
+// of the remaining channels, from cfg. Bundles of packages without any
+// channels, e.g. those rendered from bundle images, are kept. Deprecations of
+// removed channels and bundles are removed too. Empty filters keep
+// everything.
+func filterConfig(cfg *declcfg.DeclarativeConfig, packages, channels []string) {
+	if len(packages) > 0 {
+		keep := sets.New(packages...)
+		cfg.Packages = slices.DeleteFunc(cfg.Packages, func(p declcfg.Package) bool { return !keep.Has(p.Name) })
+		cfg.Channels = slices.DeleteFunc(cfg.Channels, func(c declcfg.Channel) bool { return !keep.Has(c.Package) })
+		cfg.Bundles = slices.DeleteFunc(cfg.Bundles, func(b declcfg.Bundle) bool { return !keep.Has(b.Package) })
+		cfg.Deprecations = slices.DeleteFunc(cfg.Deprecations, func(d declcfg.Deprecation) bool { return !keep.Has(d.Package) })
+		cfg.Others = slices.DeleteFunc(cfg.Others, func(m declcfg.Meta) bool {
+			return !keep.Has(m.Package) && !(m.Schema == declcfg.SchemaPackage && keep.Has(m.Name))
+		})
+	}
+	if len(channels) == 0 {
+		return
+	}
+	keep := sets.New(channels...)
+	entries := map[string]sets.Set[string]{}
+	for _, c := range cfg.Channels {
+		if entries[c.Package] == nil {
+			entries[c.Package] = sets.New[string]()
+		}
+		if !keep.Has(c.Name) {
+			continue
+		}
+		for _, e := range c.Entries {
+			entries[c.Package].Insert(e.Name)
+		}
+	}
+	cfg.Channels = slices.DeleteFunc(cfg.Channels, func(c declcfg.Channel) bool { return !keep.Has(c.Name) })
+	cfg.Bundles = slices.DeleteFunc(cfg.Bundles, func(b declcfg.Bundle) bool {
+		names, ok := entries[b.Package]
+		return ok && !names.Has(b.Name)
+	})
+	for i, d := range cfg.Deprecations {
+		cfg.Deprecations[i].Entries = slices.DeleteFunc(d.Entries, func(e declcfg.DeprecationEntry) bool {
+			switch e.Reference.Schema {
+			case declcfg.SchemaChannel:
+				return !keep.Has(e.Reference.Name)
+			case declcfg.SchemaBundle:
+				names, ok := entries[d.Package]
+				return ok && !names.Has(e.Reference.Name)
+			}
+			return false
+		})
+	}
+}
+
 // checkDBFile returns an error if ref is not an sqlite3 database.
 func checkDBFile(ref string) error {
 	typ, err := filetype.MatchFile(ref)
@@ -240,7 +405,7 @@ func checkDBFile(ref string) error {
 	return nil
 }
 
-func sqliteToDeclcfg(ctx context.Context, db *sql.DB) (*declcfg.DeclarativeConfig, error) {
+func sqliteToDeclcfg(ctx context.Context, db *sql.DB, queryOpts []sqlite.SQLiteQuerierOption, modelOpts ...sqlite.ToModelOption) (*declcfg.DeclarativeConfig, error) {
 	logDeprecationMessage.Do(func() {
 		sqlite.LogSqliteDeprecation()
 	})
@@ -257,13 +422,14 @@ func sqliteToDeclcfg(ctx context.Context, db *sql.DB) (*declcfg.DeclarativeConfi
 		return nil, err
 	}
 
-	q := sqlite.NewSQLLiteQuerierFromDb(db)
-	m, err := sqlite.ToModel(ctx, q)
+	q := sqlite.NewSQLLiteQuerierFromDb(db, queryOpts...)
+	m, err := sqlite.ToModel(ctx, q, modelOpts...)
 	if err != nil {
 		return nil, err
 	}
 
 	cfg := declcfg.ConvertFromModel(m)
+	cfg.Deprecations = bundleDeprecations(m)
 
 	if err := populateDBRelatedImages(ctx, &cfg, db); err != nil {
 		return nil, err
@@ -272,6 +438,43 @@ func sqliteToDeclcfg(ctx context.Context, db *sql.DB) (*declcfg.DeclarativeConfi
 	return &cfg, nil
 }
 
+// bundleDeprecations returns the olm.deprecations entries of the deprecated
+// bundles of m, one deprecation per package.
+func bundleDeprecations(m model.Model) []declcfg.Deprecation {
+	var deprecations []declcfg.Deprecation
+	for _, pkg := range m {
+		var entries []declcfg.DeprecationEntry
+		seen := sets.New[string]()
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				if b.Deprecation == nil || seen.Has(b.Name) {
+					continue
+				}
+				seen.Insert(b.Name)
+				entries = append(entries, declcfg.DeprecationEntry{
+					Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: b.Name},
+					Message:   b.Deprecation.Message,
+				})
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Reference.Name < entries[j].Reference.Name
+		})
+		deprecations = append(deprecations, declcfg.Deprecation{
+			Schema:  declcfg.SchemaDeprecation,
+			Package: pkg.Name,
+			Entries: entries,
+		})
+	}
+	sort.Slice(deprecations, func(i, j int) bool {
+		return deprecations[i].Package < deprecations[j].Package
+	})
+	return deprecations
+}
+
 func populateDBRelatedImages(ctx context.Context, cfg *declcfg.DeclarativeConfig, db *sql.DB) error {
 	rows, err := db.QueryContext(ctx, "SELECT image, operatorbundle_name FROM related_image")
 	if err != nil {
@@ -316,12 +519,12 @@ func populateDBRelatedImages(ctx context.Context, cfg *declcfg.DeclarativeConfig
 	return nil
 }
 
-func bundleToDeclcfg(bundle *registry.Bundle) (*declcfg.Bundle, error) {
+func bundleToDeclcfg(bundle *registry.Bundle, skipReferencedImages bool) (*declcfg.Bundle, error) {
 	objs, props, err := registry.ObjectsAndPropertiesFromBundle(bundle)
 	if err != nil {
 		return nil, fmt.Errorf("get properties for bundle %q: %v", bundle.Name, err)
 	}
-	relatedImages, err := getRelatedImages(bundle)
+	relatedImages, err := getRelatedImages(bundle, skipReferencedImages)
 	if err != nil {
 		return nil, fmt.Errorf("get related images for bundle %q: %v", bundle.Name, err)
 	}
@@ -348,21 +551,28 @@ func bundleToDeclcfg(bundle *registry.Bundle) (*declcfg.Bundle, error) {
 	}, nil
 }
 
-func getRelatedImages(b *registry.Bundle) ([]declcfg.RelatedImage, error) {
+func getRelatedImages(b *registry.Bundle, skipReferencedImages bool) ([]declcfg.RelatedImage, error) {
 	csv, err := b.ClusterServiceVersion()
 	if err != nil {
 		return nil, err
 	}
+	return csvRelatedImages(csv, b.BundleImage, skipReferencedImages)
+}
 
+// csvRelatedImages returns the related images of csv, followed by the
+// bundle image, the operator images, and, unless skipReferencedImages is
+// set, the other images referenced by the deployments of csv, that are not
+// among them.
+func csvRelatedImages(csv *registry.ClusterServiceVersion, bundleImage string, skipReferencedImages bool) ([]declcfg.RelatedImage, error) {
 	var objmap map[string]*json.RawMessage
-	if err = json.Unmarshal(csv.Spec, &objmap); err != nil {
+	if err := json.Unmarshal(csv.Spec, &objmap); err != nil {
 		return nil, err
 	}
 
 	var relatedImages []declcfg.RelatedImage
 	rawValue, ok := objmap["relatedImages"]
 	if ok && rawValue != nil {
-		if err = json.Unmarshal(*rawValue, &relatedImages); err != nil {
+		if err := json.Unmarshal(*rawValue, &relatedImages); err != nil {
 			return nil, err
 		}
 	}
@@ -374,9 +584,9 @@ func getRelatedImages(b *registry.Bundle) ([]declcfg.RelatedImage, error) {
 		allImages = allImages.Insert(ri.Image)
 	}
 
-	if b.BundleImage != "" && !allImages.Has(b.BundleImage) {
+	if bundleImage != "" && !allImages.Has(bundleImage) {
 		relatedImages = append(relatedImages, declcfg.RelatedImage{
-			Image: b.BundleImage,
+			Image: bundleImage,
 		})
 	}
 
@@ -393,6 +603,22 @@ func getRelatedImages(b *registry.Bundle) ([]declcfg.RelatedImage, error) {
 		allImages = allImages.Insert(img)
 	}
 
+	if skipReferencedImages {
+		return relatedImages, nil
+	}
+	refImages, err := csv.GetReferencedImages()
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range sets.StringKeySet(refImages).List() {
+		if !allImages.Has(img) {
+			relatedImages = append(relatedImages, declcfg.RelatedImage{
+				Image: img,
+			})
+		}
+		allImages = allImages.Insert(img)
+	}
+
 	return relatedImages, nil
 }
 
@@ -463,7 +689,7 @@ func (r *Render) renderBundleDirectory(ref string) (*declcfg.DeclarativeConfig,
 	if err := r.templateBundleImageRef(img.Bundle); err != nil {
 		return nil, fmt.Errorf("failed templating image reference from bundle for %q: %v", ref, err)
 	}
-	fbcBundle, err := bundleToDeclcfg(img.Bundle)
+	fbcBundle, err := bundleToDeclcfg(img.Bundle, r.SkipReferencedImages)
 	if err != nil {
 		return nil, err
 	}
@@ -9,6 +9,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"testing/fstest"
 	"text/template"
@@ -24,6 +25,7 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -1219,6 +1221,66 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderMaxParallel(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	refs := []string{
+		"test.registry/foo-operator/foo-bundle:v0.2.0",
+		"test.registry/foo-operator/foo-bundle:v0.1.0",
+		"test.registry/foo-operator/foo-index-declcfg:v0.2.0",
+		"test.registry/foo-operator/foo-bundle-no-csv-related-images:v0.2.0",
+	}
+	expected, err := action.Render{Refs: refs, Registry: reg}.Run(context.Background())
+	require.NoError(t, err)
+
+	for _, maxParallel := range []int{2, len(refs) + 1} {
+		t.Run(fmt.Sprintf("MaxParallel=%d", maxParallel), func(t *testing.T) {
+			actual, err := action.Render{Refs: refs, Registry: reg, MaxParallel: maxParallel}.Run(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, expected, actual)
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := action.Render{
+			Refs:        append([]string{"test.registry/foo-operator/missing:v0.1.0"}, refs...),
+			Registry:    reg,
+			MaxParallel: 2,
+		}.Run(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func TestRenderInvalidConstraint(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catalog.yaml"), []byte(`---
+schema: olm.package
+name: foo
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: test.registry/foo-operator/foo-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.1.0
+- type: olm.constraint
+  value:
+    failureMessage: requires a certified cluster
+    cel:
+      rule: 'properties.exists(p, p.type == "certified"'
+`), 0600))
+
+	_, err = action.Render{Refs: []string{dir}, Registry: reg}.Run(context.Background())
+	require.ErrorContains(t, err, `bundle "foo.v0.1.0": invalid property[1] of type "olm.constraint": invalid cel rule`)
+}
+
 func TestAllowRefMask(t *testing.T) {
 	type spec struct {
 		name      string
@@ -1366,6 +1428,9 @@ func TestAllowRefMask(t *testing.T) {
 		t.Run(s.name, func(t *testing.T) {
 			_, err := s.render.Run(context.Background())
 			require.True(t, errors.Is(err, s.expectErr), "expected error %#v to be %#v", err, s.expectErr)
+			if s.expectErr != nil {
+				require.Equal(t, liberrors.CodeInvalidArgument, liberrors.CodeOf(err))
+			}
 		})
 	}
 }
@@ -1563,3 +1628,242 @@ func generateSqliteFile(path string, imageMap map[image.Reference]string) error
 	}
 	return nil
 }
+
+func TestRenderFilter(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catalog.yaml"), []byte(`---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.1.0
+---
+schema: olm.channel
+package: foo
+name: fast
+entries:
+- name: foo.v0.1.0
+- name: foo.v0.2.0
+  replaces: foo.v0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: test.registry/foo-operator/foo-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: test.registry/foo-operator/foo-bundle:v0.2.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.2.0
+---
+schema: olm.deprecations
+package: foo
+entries:
+- reference:
+    schema: olm.channel
+    name: fast
+  message: fast is deprecated
+- reference:
+    schema: olm.bundle
+    name: foo.v0.2.0
+  message: foo.v0.2.0 is deprecated
+---
+schema: olm.package
+name: bar
+defaultChannel: stable
+---
+schema: olm.channel
+package: bar
+name: stable
+entries:
+- name: bar.v0.1.0
+---
+schema: olm.bundle
+package: bar
+name: bar.v0.1.0
+image: test.registry/bar-operator/bar-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: bar
+    version: 0.1.0
+`), 0600))
+
+	dbFile := filepath.Join(t.TempDir(), "index.db")
+	require.NoError(t, generateSqliteFile(dbFile, map[image.Reference]string{
+		image.SimpleReference("test.registry/foo-operator/foo-bundle:v0.1.0"): "testdata/foo-bundle-v0.1.0",
+		image.SimpleReference("test.registry/foo-operator/foo-bundle:v0.2.0"): "testdata/foo-bundle-v0.2.0",
+	}))
+
+	names := func(cfg *declcfg.DeclarativeConfig) []string {
+		var names []string
+		for _, p := range cfg.Packages {
+			names = append(names, "package/"+p.Name)
+		}
+		for _, c := range cfg.Channels {
+			names = append(names, "channel/"+c.Package+"/"+c.Name)
+		}
+		for _, b := range cfg.Bundles {
+			names = append(names, "bundle/"+b.Name)
+		}
+		for _, d := range cfg.Deprecations {
+			for _, e := range d.Entries {
+				names = append(names, "deprecation/"+d.Package+"/"+e.Reference.Name)
+			}
+		}
+		return names
+	}
+
+	type spec struct {
+		name     string
+		render   action.Render
+		expected []string
+	}
+	specs := []spec{
+		{
+			name:   "Packages",
+			render: action.Render{Refs: []string{dir}, FilterPackages: []string{"bar"}},
+			expected: []string{
+				"package/bar",
+				"channel/bar/stable",
+				"bundle/bar.v0.1.0",
+			},
+		},
+		{
+			name:   "Channels",
+			render: action.Render{Refs: []string{dir}, FilterChannels: []string{"stable"}},
+			expected: []string{
+				"package/foo",
+				"package/bar",
+				"channel/foo/stable",
+				"channel/bar/stable",
+				"bundle/foo.v0.1.0",
+				"bundle/bar.v0.1.0",
+			},
+		},
+		{
+			name:   "PackagesAndChannels",
+			render: action.Render{Refs: []string{dir}, FilterPackages: []string{"foo"}, FilterChannels: []string{"fast"}},
+			expected: []string{
+				"package/foo",
+				"channel/foo/fast",
+				"bundle/foo.v0.1.0",
+				"bundle/foo.v0.2.0",
+				"deprecation/foo/fast",
+				"deprecation/foo/foo.v0.2.0",
+			},
+		},
+		{
+			name:     "Sqlite/MissingPackage",
+			render:   action.Render{Refs: []string{dbFile}, FilterPackages: []string{"bar"}},
+			expected: nil,
+		},
+		{
+			name:   "Sqlite/Channels",
+			render: action.Render{Refs: []string{dbFile}, FilterPackages: []string{"foo"}, FilterChannels: []string{"stable"}},
+			expected: []string{
+				"package/foo",
+				"channel/foo/stable",
+				"bundle/foo.v0.1.0",
+				"bundle/foo.v0.2.0",
+			},
+		},
+		{
+			name:   "BundleImage",
+			render: action.Render{Refs: []string{"test.registry/foo-operator/foo-bundle:v0.2.0"}, FilterChannels: []string{"stable"}},
+			expected: []string{
+				"bundle/foo.v0.2.0",
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			s.render.Registry = reg
+			cfg, err := s.render.Run(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, s.expected, names(cfg))
+		})
+	}
+}
+
+func TestRenderBundleProperties(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catalog.yaml"), []byte(`---
+schema: olm.package
+name: foo
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: test.registry/foo-operator/foo-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: test.registry/foo-operator/foo-bundle:v0.2.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.2.0
+`), 0600))
+
+	t.Run("Success", func(t *testing.T) {
+		bp, err := declcfg.LoadBundleProperties(strings.NewReader(`
+package: foo
+versionRange: ">=0.2.0"
+properties:
+- type: example.com/support-level
+  value: premium
+`))
+		require.NoError(t, err)
+
+		cfg, err := action.Render{Refs: []string{dir}, Registry: reg, BundleProperties: bp}.Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cfg.Bundles, 2)
+		require.Equal(t, []property.Property{property.MustBuildPackage("foo", "0.1.0")}, cfg.Bundles[0].Properties)
+		require.Equal(t, []property.Property{
+			property.MustBuildPackage("foo", "0.2.0"),
+			{Type: "example.com/support-level", Value: json.RawMessage(`"premium"`)},
+		}, cfg.Bundles[1].Properties)
+	})
+	t.Run("Error/InvalidConstraint", func(t *testing.T) {
+		bp, err := declcfg.LoadBundleProperties(strings.NewReader(`
+properties:
+- type: olm.constraint
+  value:
+    failureMessage: requires a certified cluster
+    cel:
+      rule: 'properties.exists(p, p.type == "certified"'
+`))
+		require.NoError(t, err)
+
+		_, err = action.Render{Refs: []string{dir}, Registry: reg, BundleProperties: bp}.Run(context.Background())
+		require.ErrorContains(t, err, `bundle "foo.v0.1.0": invalid property[1] of type "olm.constraint": invalid cel rule`)
+	})
+}
@@ -0,0 +1,540 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// Resolve reports, for each dependency declared by a set of bundles, which
+// bundles of a set of catalogs satisfy it. Dependencies are declared with
+// olm.package.required, olm.gvk.required and olm.constraint properties, and
+// are matched against bundles the way OLM matches them during resolution.
+//
+// The bundles to check are rendered from BundleRefs, which may reference
+// bundle images, bundle directories or catalogs. If BundleRefs is empty, the
+// bundles of the catalogs are checked, optionally only those of Package.
+//
+// Bundles never satisfy the dependencies of bundles of their own package,
+// since OLM installs at most one bundle of each package.
+//
+// If MultiCatalog is set, each of CatalogRefs is a separate catalog, like the
+// CatalogSources of a cluster, and each dependency also reports the catalogs
+// that satisfy it, in the order in which OLM prefers them: the catalog of the
+// bundle that declares the dependency, then catalogs of higher priority, then
+// catalogs in order of their references. A dependency that is satisfied by
+// another catalog couples the bundle to that catalog.
+type Resolve struct {
+	CatalogRefs []string
+	BundleRefs  []string
+	Package     string
+
+	MultiCatalog bool
+	// CatalogPriorities are the priorities of CatalogRefs, keyed by ref, in
+	// multi-catalog mode. Catalogs without one have priority 0.
+	CatalogPriorities map[string]int
+
+	Registry       image.Registry
+	LoadRefOptions []declcfg.LoadRefOption
+}
+
+// Dependency is a dependency declared by a bundle.
+type Dependency struct {
+	// Type is the type of the property that declares the dependency.
+	Type string `json:"type"`
+	// Requirement describes what the dependency requires.
+	Requirement    string `json:"requirement"`
+	FailureMessage string `json:"failureMessage,omitempty"`
+	// SatisfiedBy are the names of the catalog bundles that satisfy the
+	// dependency. If it is empty, the dependency is unsatisfiable.
+	SatisfiedBy []string `json:"satisfiedBy,omitempty"`
+
+	// Catalogs are, in multi-catalog mode, the catalogs with bundles that
+	// satisfy the dependency, in the order in which OLM prefers them.
+	Catalogs []CatalogBundles `json:"catalogs,omitempty"`
+	// CrossCatalog is set if the dependency is not satisfied by the catalog
+	// of the bundle that declares it, but by another catalog.
+	CrossCatalog bool `json:"crossCatalog,omitempty"`
+	// Ambiguous is set if more than one of the most preferred catalogs
+	// satisfy the dependency, so that only the order of their references
+	// decides which of them OLM uses.
+	Ambiguous bool `json:"ambiguous,omitempty"`
+}
+
+// CatalogBundles are the bundles of a catalog that satisfy a dependency.
+type CatalogBundles struct {
+	Catalog  string   `json:"catalog"`
+	Priority int      `json:"priority,omitempty"`
+	Bundles  []string `json:"bundles"`
+}
+
+func (d Dependency) Satisfiable() bool {
+	return len(d.SatisfiedBy) > 0
+}
+
+type BundleDependencies struct {
+	Package string `json:"package"`
+	Bundle  string `json:"bundle"`
+	// Catalog is, in multi-catalog mode, the catalog of the bundle, or empty
+	// if the bundle was not rendered from one of the catalogs.
+	Catalog      string       `json:"catalog,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+type ResolveResult struct {
+	MultiCatalog bool                 `json:"multiCatalog,omitempty"`
+	Bundles      []BundleDependencies `json:"bundles"`
+}
+
+// Unsatisfiable returns the number of dependencies in r that no catalog
+// bundle satisfies.
+func (r *ResolveResult) Unsatisfiable() int {
+	n := 0
+	for _, b := range r.Bundles {
+		for _, d := range b.Dependencies {
+			if !d.Satisfiable() {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// CrossCatalog returns the number of dependencies in r that are satisfied
+// by another catalog than that of the bundle that declares them.
+func (r *ResolveResult) CrossCatalog() int {
+	n := 0
+	for _, b := range r.Bundles {
+		for _, d := range b.Dependencies {
+			if d.CrossCatalog {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+const resolveCatalogRefMask = RefDCImage | RefDCDir | RefDCArchive | RefDCGit | RefDCGRPC | RefSqliteImage | RefSqliteFile
+
+// catalogBundle is a bundle of the catalog with reference catalog, which is
+// empty if the bundles of all catalogs are resolved as a single catalog.
+type catalogBundle struct {
+	declcfg.Bundle
+	catalog string
+}
+
+func (r Resolve) Run(ctx context.Context) (*ResolveResult, error) {
+	if len(r.CatalogRefs) == 0 {
+		return nil, errors.New("at least one catalog is required")
+	}
+	if len(r.CatalogPriorities) > 0 && !r.MultiCatalog {
+		return nil, errors.New("catalog priorities require multi-catalog mode")
+	}
+	for ref := range r.CatalogPriorities {
+		if !slices.Contains(r.CatalogRefs, ref) {
+			return nil, fmt.Errorf("catalog priority set for %q, which is not one of the catalogs", ref)
+		}
+	}
+
+	var catalogBundles []catalogBundle
+	if r.MultiCatalog {
+		for _, ref := range r.CatalogRefs {
+			cfg, err := r.render(ctx, []string{ref}, resolveCatalogRefMask)
+			if err != nil {
+				return nil, err
+			}
+			for _, b := range cfg.Bundles {
+				catalogBundles = append(catalogBundles, catalogBundle{b, ref})
+			}
+		}
+	} else {
+		cfg, err := r.render(ctx, r.CatalogRefs, resolveCatalogRefMask)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range cfg.Bundles {
+			catalogBundles = append(catalogBundles, catalogBundle{Bundle: b})
+		}
+	}
+
+	toCheck := catalogBundles
+	if len(r.BundleRefs) > 0 {
+		cfg, err := r.render(ctx, r.BundleRefs, RefAll)
+		if err != nil {
+			return nil, err
+		}
+		toCheck = make([]catalogBundle, 0, len(cfg.Bundles))
+		for _, b := range cfg.Bundles {
+			toCheck = append(toCheck, catalogBundle{Bundle: b})
+		}
+	}
+
+	candidates := make([]resolveCandidate, 0, len(catalogBundles))
+	for _, b := range catalogBundles {
+		c, err := newResolveCandidate(b.Bundle)
+		if err != nil {
+			return nil, err
+		}
+		c.catalog = b.catalog
+		candidates = append(candidates, c)
+	}
+
+	m := &constraintMatcher{env: constraints.NewCelEnvironment(), programs: map[string]constraints.CelProgram{}}
+	res := &ResolveResult{MultiCatalog: r.MultiCatalog}
+	for _, b := range toCheck {
+		if r.Package != "" && b.Package != r.Package {
+			continue
+		}
+		deps, matches, err := m.resolveDependencies(b.Bundle, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if r.MultiCatalog {
+			for i := range deps {
+				r.preferCatalogs(&deps[i], b.catalog, matches[i])
+			}
+		}
+		res.Bundles = append(res.Bundles, BundleDependencies{Package: b.Package, Bundle: b.Name, Catalog: b.catalog, Dependencies: deps})
+	}
+	if r.Package != "" && len(res.Bundles) == 0 {
+		return nil, fmt.Errorf("package %q not found", r.Package)
+	}
+	sort.SliceStable(res.Bundles, func(i, j int) bool {
+		if res.Bundles[i].Package != res.Bundles[j].Package {
+			return res.Bundles[i].Package < res.Bundles[j].Package
+		}
+		if res.Bundles[i].Bundle != res.Bundles[j].Bundle {
+			return res.Bundles[i].Bundle < res.Bundles[j].Bundle
+		}
+		return r.catalogIndex(res.Bundles[i].Catalog) < r.catalogIndex(res.Bundles[j].Catalog)
+	})
+	return res, nil
+}
+
+func (r Resolve) catalogIndex(ref string) int {
+	return slices.Index(r.CatalogRefs, ref)
+}
+
+// preferCatalogs sets the catalogs of d, which is declared by a bundle of
+// the catalog own, from the candidates that satisfy it, in the order in
+// which OLM prefers them.
+func (r Resolve) preferCatalogs(d *Dependency, own string, matches []resolveCandidate) {
+	byCatalog := map[string]*CatalogBundles{}
+	for _, c := range matches {
+		cb, ok := byCatalog[c.catalog]
+		if !ok {
+			cb = &CatalogBundles{Catalog: c.catalog, Priority: r.CatalogPriorities[c.catalog]}
+			byCatalog[c.catalog] = cb
+		}
+		cb.Bundles = append(cb.Bundles, c.bundle.Name)
+	}
+	if len(byCatalog) == 0 {
+		return
+	}
+	for _, cb := range byCatalog {
+		sort.Strings(cb.Bundles)
+		d.Catalogs = append(d.Catalogs, *cb)
+	}
+	less := func(a, b CatalogBundles) bool {
+		if (a.Catalog == own) != (b.Catalog == own) {
+			return a.Catalog == own
+		}
+		return a.Priority > b.Priority
+	}
+	sort.Slice(d.Catalogs, func(i, j int) bool {
+		if less(d.Catalogs[i], d.Catalogs[j]) || less(d.Catalogs[j], d.Catalogs[i]) {
+			return less(d.Catalogs[i], d.Catalogs[j])
+		}
+		return r.catalogIndex(d.Catalogs[i].Catalog) < r.catalogIndex(d.Catalogs[j].Catalog)
+	})
+	d.CrossCatalog = own != "" && d.Catalogs[0].Catalog != own
+	d.Ambiguous = len(d.Catalogs) > 1 && !less(d.Catalogs[0], d.Catalogs[1])
+}
+
+func (r Resolve) render(ctx context.Context, refs []string, mask RefType) (*declcfg.DeclarativeConfig, error) {
+	render := Render{
+		Refs:           refs,
+		AllowedRefMask: mask,
+		Registry:       r.Registry,
+		LoadRefOptions: r.LoadRefOptions,
+	}
+	cfg, err := render.Run(ctx)
+	if err != nil {
+		if errors.Is(err, ErrNotAllowed) {
+			return nil, fmt.Errorf("cannot resolve against non-catalog references %q", refs)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// resolveCandidate is a catalog bundle that may satisfy dependencies.
+type resolveCandidate struct {
+	bundle declcfg.Bundle
+	// catalog is the reference of the catalog of the bundle in multi-catalog
+	// mode.
+	catalog string
+	props   *property.Properties
+	// celInput is the input that CEL constraints are evaluated against.
+	celInput map[string]interface{}
+}
+
+func newResolveCandidate(b declcfg.Bundle) (resolveCandidate, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return resolveCandidate{}, fmt.Errorf("parse properties of bundle %q: %v", b.Name, err)
+	}
+	// OLM evaluates CEL rules against the list of properties of a bundle,
+	// each with its type and decoded value.
+	celProps := make([]interface{}, 0, len(b.Properties))
+	for _, p := range b.Properties {
+		var value interface{}
+		if err := json.Unmarshal(p.Value, &value); err != nil {
+			return resolveCandidate{}, fmt.Errorf("parse property %q of bundle %q: %v", p.Type, b.Name, err)
+		}
+		celProps = append(celProps, map[string]interface{}{"type": p.Type, "value": value})
+	}
+	return resolveCandidate{
+		bundle:   b,
+		props:    props,
+		celInput: map[string]interface{}{constraints.PropertiesKey: celProps},
+	}, nil
+}
+
+// constraintMatcher matches constraints against candidates, compiling each
+// CEL rule once.
+type constraintMatcher struct {
+	env      *constraints.CelEnvironment
+	programs map[string]constraints.CelProgram
+}
+
+// resolveDependencies returns the dependencies declared by b, and the
+// candidates that satisfy each of them.
+func (m *constraintMatcher) resolveDependencies(b declcfg.Bundle, candidates []resolveCandidate) ([]Dependency, [][]resolveCandidate, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse properties of bundle %q: %v", b.Name, err)
+	}
+
+	type declared struct {
+		dep        Dependency
+		constraint property.Constraint
+	}
+	var all []declared
+	for _, p := range props.PackagesRequired {
+		p := p
+		all = append(all, declared{
+			dep:        Dependency{Type: property.TypePackageRequired},
+			constraint: property.Constraint{Package: &p},
+		})
+	}
+	for _, g := range props.GVKsRequired {
+		g := g
+		all = append(all, declared{
+			dep:        Dependency{Type: property.TypeGVKRequired},
+			constraint: property.Constraint{GVK: &g},
+		})
+	}
+	for _, c := range props.Constraints {
+		all = append(all, declared{
+			dep:        Dependency{Type: property.TypeConstraint, FailureMessage: c.FailureMessage},
+			constraint: c,
+		})
+	}
+
+	deps := make([]Dependency, 0, len(all))
+	matches := make([][]resolveCandidate, 0, len(all))
+	for _, d := range all {
+		if err := d.constraint.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("bundle %q: invalid %s dependency: %v", b.Name, d.dep.Type, err)
+		}
+		d.dep.Requirement = describeConstraint(d.constraint)
+		var matched []resolveCandidate
+		for _, c := range candidates {
+			if c.bundle.Package == b.Package {
+				continue
+			}
+			ok, err := m.matches(d.constraint, c)
+			if err != nil {
+				return nil, nil, fmt.Errorf("bundle %q: evaluate %s dependency %s against bundle %q: %v", b.Name, d.dep.Type, d.dep.Requirement, c.bundle.Name, err)
+			}
+			if ok {
+				d.dep.SatisfiedBy = append(d.dep.SatisfiedBy, c.bundle.Name)
+				matched = append(matched, c)
+			}
+		}
+		// Catalogs in multi-catalog mode may have bundles of the same name.
+		sort.Strings(d.dep.SatisfiedBy)
+		d.dep.SatisfiedBy = slices.Compact(d.dep.SatisfiedBy)
+		deps = append(deps, d.dep)
+		matches = append(matches, matched)
+	}
+	return deps, matches, nil
+}
+
+// matches returns whether the candidate satisfies c. c must be valid.
+func (m *constraintMatcher) matches(c property.Constraint, candidate resolveCandidate) (bool, error) {
+	switch {
+	case c.Cel != nil:
+		prog, ok := m.programs[c.Cel.Rule]
+		if !ok {
+			var err error
+			if prog, err = m.env.Validate(c.Cel.Rule); err != nil {
+				return false, err
+			}
+			m.programs[c.Cel.Rule] = prog
+		}
+		return prog.Evaluate(candidate.celInput)
+	case c.Package != nil:
+		versionRange, err := semver.ParseRange(c.Package.VersionRange)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range candidate.props.Packages {
+			if p.PackageName != c.Package.PackageName {
+				continue
+			}
+			if v, err := semver.Parse(p.Version); err == nil && versionRange(v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case c.GVK != nil:
+		for _, g := range candidate.props.GVKs {
+			if g.Group == c.GVK.Group && g.Version == c.GVK.Version && g.Kind == c.GVK.Kind {
+				return true, nil
+			}
+		}
+		return false, nil
+	case c.All != nil:
+		for _, nested := range c.All.Constraints {
+			ok, err := m.matches(nested, candidate)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case c.Any != nil:
+		for _, nested := range c.Any.Constraints {
+			ok, err := m.matches(nested, candidate)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case c.Not != nil:
+		for _, nested := range c.Not.Constraints {
+			ok, err := m.matches(nested, candidate)
+			if err != nil || ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// describeConstraint returns a short, human-readable description of c.
+func describeConstraint(c property.Constraint) string {
+	describeAll := func(op string, cs []property.Constraint) string {
+		descs := make([]string, 0, len(cs))
+		for _, nested := range cs {
+			descs = append(descs, describeConstraint(nested))
+		}
+		return fmt.Sprintf("%s(%s)", op, strings.Join(descs, ", "))
+	}
+	switch {
+	case c.Cel != nil:
+		return fmt.Sprintf("cel(%s)", c.Cel.Rule)
+	case c.Package != nil:
+		return fmt.Sprintf("%s %s", c.Package.PackageName, c.Package.VersionRange)
+	case c.GVK != nil:
+		return fmt.Sprintf("%s/%s %s", c.GVK.Group, c.GVK.Version, c.GVK.Kind)
+	case c.All != nil:
+		return describeAll("all", c.All.Constraints)
+	case c.Any != nil:
+		return describeAll("any", c.Any.Constraints)
+	case c.Not != nil:
+		return describeAll("not", c.Not.Constraints)
+	}
+	return ""
+}
+
+// WriteColumns writes a table of the dependencies of r. In multi-catalog
+// mode, the table also lists the catalog of each bundle, the catalog that
+// OLM would satisfy each dependency from, and the coupling risks of each
+// dependency.
+func (r *ResolveResult) WriteColumns(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	header := "PACKAGE\tBUNDLE\tTYPE\tREQUIREMENT\tSATISFIED BY"
+	if r.MultiCatalog {
+		header = "PACKAGE\tBUNDLE\tCATALOG\tTYPE\tREQUIREMENT\tSATISFIED BY\tSATISFIED FROM\tRISKS"
+	}
+	if _, err := fmt.Fprintln(tw, header); err != nil {
+		return err
+	}
+	for _, b := range r.Bundles {
+		for _, d := range b.Dependencies {
+			satisfiedBy := strings.Join(d.SatisfiedBy, ",")
+			if !d.Satisfiable() {
+				satisfiedBy = "<unsatisfiable>"
+			}
+			var err error
+			if r.MultiCatalog {
+				_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", b.Package, b.Bundle, orNone(b.Catalog), d.Type, d.Requirement, satisfiedBy, orNone(d.satisfiedFrom()), orNone(strings.Join(d.risks(), ",")))
+			} else {
+				_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", b.Package, b.Bundle, d.Type, d.Requirement, satisfiedBy)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// satisfiedFrom returns the catalog that OLM would satisfy d from in
+// multi-catalog mode.
+func (d Dependency) satisfiedFrom() string {
+	if len(d.Catalogs) == 0 {
+		return ""
+	}
+	return d.Catalogs[0].Catalog
+}
+
+func (d Dependency) risks() []string {
+	var risks []string
+	if d.CrossCatalog {
+		risks = append(risks, "cross-catalog")
+	}
+	if d.Ambiguous {
+		risks = append(risks, "ambiguous")
+	}
+	return risks
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (r *ResolveResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,220 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func resolveTestBundle(pkg, version string, props ...property.Property) declcfg.Bundle {
+	return declcfg.Bundle{
+		Schema:     declcfg.SchemaBundle,
+		Package:    pkg,
+		Name:       pkg + ".v" + version,
+		Image:      "quay.io/example/" + pkg + ":v" + version,
+		Properties: append([]property.Property{property.MustBuildPackage(pkg, version)}, props...),
+	}
+}
+
+func writeResolveTestCatalog(t *testing.T, bundles ...declcfg.Bundle) string {
+	t.Helper()
+	cfg := declcfg.DeclarativeConfig{Bundles: bundles}
+	for _, b := range bundles {
+		cfg.Packages = append(cfg.Packages, declcfg.Package{Schema: declcfg.SchemaPackage, Name: b.Package, DefaultChannel: "stable"})
+		cfg.Channels = append(cfg.Channels, declcfg.Channel{Schema: declcfg.SchemaChannel, Package: b.Package, Name: "stable", Entries: []declcfg.ChannelEntry{{Name: b.Name}}})
+	}
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "catalog.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, declcfg.WriteJSON(cfg, f))
+	return dir
+}
+
+func TestResolve(t *testing.T) {
+	etcd := resolveTestBundle("etcd", "1.0.0", property.MustBuildGVK("etcd.database.coreos.com", "v1", "EtcdCluster"))
+	prometheus := resolveTestBundle("prometheus", "2.0.0", property.MustBuildGVK("monitoring.coreos.com", "v1", "Prometheus"))
+	foo := resolveTestBundle("foo", "0.1.0",
+		property.MustBuildPackageRequired("etcd", ">=1.0.0"),
+		property.MustBuildPackageRequired("prometheus", "<2.0.0"),
+		property.MustBuildGVKRequired("monitoring.coreos.com", "v1", "Prometheus"),
+		property.MustBuildConstraintCEL(`properties.exists(p, p.type == "olm.package" && p.value.packageName == "etcd" && semver_compare(p.value.version, "2.0.0") < 0)`, "requires an etcd 1.x operator"),
+		property.MustBuild(&property.Constraint{
+			Any: &property.CompoundConstraint{Constraints: []property.Constraint{
+				{GVK: &property.GVKRequired{Group: "etcd.database.coreos.com", Version: "v1", Kind: "EtcdCluster"}},
+				{Package: &property.PackageRequired{PackageName: "prometheus", VersionRange: ">=1.0.0"}},
+			}},
+		}),
+		property.MustBuild(&property.Constraint{
+			All: &property.CompoundConstraint{Constraints: []property.Constraint{
+				{Package: &property.PackageRequired{PackageName: "etcd", VersionRange: ">=1.0.0"}},
+				{Not: &property.CompoundConstraint{Constraints: []property.Constraint{
+					{GVK: &property.GVKRequired{Group: "etcd.database.coreos.com", Version: "v1", Kind: "EtcdCluster"}},
+				}}},
+			}},
+		}),
+	)
+	catalog := writeResolveTestCatalog(t, etcd, prometheus, foo)
+
+	expected := BundleDependencies{
+		Package: "foo",
+		Bundle:  "foo.v0.1.0",
+		Dependencies: []Dependency{
+			{Type: property.TypePackageRequired, Requirement: "etcd >=1.0.0", SatisfiedBy: []string{"etcd.v1.0.0"}},
+			{Type: property.TypePackageRequired, Requirement: "prometheus <2.0.0"},
+			{Type: property.TypeGVKRequired, Requirement: "monitoring.coreos.com/v1 Prometheus", SatisfiedBy: []string{"prometheus.v2.0.0"}},
+			{
+				Type:           property.TypeConstraint,
+				Requirement:    `cel(properties.exists(p, p.type == "olm.package" && p.value.packageName == "etcd" && semver_compare(p.value.version, "2.0.0") < 0))`,
+				FailureMessage: "requires an etcd 1.x operator",
+				SatisfiedBy:    []string{"etcd.v1.0.0"},
+			},
+			{
+				Type:        property.TypeConstraint,
+				Requirement: "any(etcd.database.coreos.com/v1 EtcdCluster, prometheus >=1.0.0)",
+				SatisfiedBy: []string{"etcd.v1.0.0", "prometheus.v2.0.0"},
+			},
+			{
+				Type:        property.TypeConstraint,
+				Requirement: "all(etcd >=1.0.0, not(etcd.database.coreos.com/v1 EtcdCluster))",
+			},
+		},
+	}
+
+	t.Run("CatalogBundles", func(t *testing.T) {
+		res, err := Resolve{CatalogRefs: []string{catalog}}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []BundleDependencies{
+			{Package: "etcd", Bundle: "etcd.v1.0.0", Dependencies: []Dependency{}},
+			expected,
+			{Package: "prometheus", Bundle: "prometheus.v2.0.0", Dependencies: []Dependency{}},
+		}, res.Bundles)
+		require.Equal(t, 2, res.Unsatisfiable())
+	})
+
+	t.Run("Package", func(t *testing.T) {
+		res, err := Resolve{CatalogRefs: []string{catalog}, Package: "foo"}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []BundleDependencies{expected}, res.Bundles)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, res.WriteColumns(buf))
+		require.Equal(t, `PACKAGE  BUNDLE      TYPE                  REQUIREMENT                                                                                                                          SATISFIED BY
+foo      foo.v0.1.0  olm.package.required  etcd >=1.0.0                                                                                                                         etcd.v1.0.0
+foo      foo.v0.1.0  olm.package.required  prometheus <2.0.0                                                                                                                    <unsatisfiable>
+foo      foo.v0.1.0  olm.gvk.required      monitoring.coreos.com/v1 Prometheus                                                                                                  prometheus.v2.0.0
+foo      foo.v0.1.0  olm.constraint        cel(properties.exists(p, p.type == "olm.package" && p.value.packageName == "etcd" && semver_compare(p.value.version, "2.0.0") < 0))  etcd.v1.0.0
+foo      foo.v0.1.0  olm.constraint        any(etcd.database.coreos.com/v1 EtcdCluster, prometheus >=1.0.0)                                                                     etcd.v1.0.0,prometheus.v2.0.0
+foo      foo.v0.1.0  olm.constraint        all(etcd >=1.0.0, not(etcd.database.coreos.com/v1 EtcdCluster))                                                                      <unsatisfiable>
+`, buf.String())
+
+		_, err = Resolve{CatalogRefs: []string{catalog}, Package: "bar"}.Run(context.Background())
+		require.EqualError(t, err, `package "bar" not found`)
+	})
+
+	t.Run("BundleRefsAgainstMultipleCatalogs", func(t *testing.T) {
+		etcdCatalog := writeResolveTestCatalog(t, etcd)
+		prometheusCatalog := writeResolveTestCatalog(t, resolveTestBundle("prometheus", "1.5.0"))
+		bundles := writeResolveTestCatalog(t, foo)
+
+		res, err := Resolve{CatalogRefs: []string{etcdCatalog, prometheusCatalog}, BundleRefs: []string{bundles}}.Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, res.Bundles, 1)
+		var satisfiedBy [][]string
+		for _, d := range res.Bundles[0].Dependencies {
+			satisfiedBy = append(satisfiedBy, d.SatisfiedBy)
+		}
+		require.Equal(t, [][]string{
+			{"etcd.v1.0.0"},
+			{"prometheus.v1.5.0"},
+			nil,
+			{"etcd.v1.0.0"},
+			{"etcd.v1.0.0", "prometheus.v1.5.0"},
+			nil,
+		}, satisfiedBy)
+	})
+
+	t.Run("MultiCatalog", func(t *testing.T) {
+		community := writeResolveTestCatalog(t, etcd, resolveTestBundle("prometheus", "1.5.0"), foo)
+		certified := writeResolveTestCatalog(t, prometheus, resolveTestBundle("etcd", "1.1.0", property.MustBuildGVK("etcd.database.coreos.com", "v1", "EtcdCluster")))
+		other := writeResolveTestCatalog(t, resolveTestBundle("prometheus", "1.6.0"))
+
+		res, err := Resolve{
+			CatalogRefs:       []string{community, certified, other},
+			Package:           "foo",
+			MultiCatalog:      true,
+			CatalogPriorities: map[string]int{certified: 10},
+		}.Run(context.Background())
+		require.NoError(t, err)
+		require.True(t, res.MultiCatalog)
+		require.Len(t, res.Bundles, 1)
+		require.Equal(t, community, res.Bundles[0].Catalog)
+
+		deps := res.Bundles[0].Dependencies
+		// The catalog of the bundle is preferred over catalogs of higher
+		// priority.
+		require.Equal(t, []CatalogBundles{
+			{Catalog: community, Bundles: []string{"etcd.v1.0.0"}},
+			{Catalog: certified, Priority: 10, Bundles: []string{"etcd.v1.1.0"}},
+		}, deps[0].Catalogs)
+		require.False(t, deps[0].CrossCatalog)
+		require.False(t, deps[0].Ambiguous)
+
+		// prometheus <2.0.0 is satisfied by the catalog of the bundle.
+		require.Equal(t, []string{"prometheus.v1.5.0", "prometheus.v1.6.0"}, deps[1].SatisfiedBy)
+		require.Equal(t, community, deps[1].Catalogs[0].Catalog)
+		require.False(t, deps[1].CrossCatalog)
+
+		// The Prometheus API is only provided by another catalog.
+		require.Equal(t, []CatalogBundles{{Catalog: certified, Priority: 10, Bundles: []string{"prometheus.v2.0.0"}}}, deps[2].Catalogs)
+		require.True(t, deps[2].CrossCatalog)
+		require.Equal(t, 1, res.CrossCatalog())
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, res.WriteColumns(buf))
+		require.Contains(t, buf.String(), "SATISFIED FROM")
+		require.Contains(t, buf.String(), certified+"  cross-catalog\n")
+
+		t.Run("Ambiguous", func(t *testing.T) {
+			res, err := Resolve{
+				CatalogRefs:  []string{community, other},
+				BundleRefs:   []string{writeResolveTestCatalog(t, resolveTestBundle("bar", "1.0.0", property.MustBuildPackageRequired("prometheus", "<2.0.0")))},
+				MultiCatalog: true,
+			}.Run(context.Background())
+			require.NoError(t, err)
+			d := res.Bundles[0].Dependencies[0]
+			require.Empty(t, res.Bundles[0].Catalog)
+			require.Equal(t, []CatalogBundles{
+				{Catalog: community, Bundles: []string{"prometheus.v1.5.0"}},
+				{Catalog: other, Bundles: []string{"prometheus.v1.6.0"}},
+			}, d.Catalogs)
+			require.True(t, d.Ambiguous)
+			require.False(t, d.CrossCatalog)
+		})
+
+		t.Run("UnknownPriority", func(t *testing.T) {
+			_, err := Resolve{CatalogRefs: []string{community}, MultiCatalog: true, CatalogPriorities: map[string]int{"missing": 1}}.Run(context.Background())
+			require.EqualError(t, err, `catalog priority set for "missing", which is not one of the catalogs`)
+		})
+	})
+
+	t.Run("OwnPackage", func(t *testing.T) {
+		self := resolveTestBundle("etcd", "2.0.0", property.MustBuildPackageRequired("etcd", ">=1.0.0"))
+		res, err := Resolve{CatalogRefs: []string{catalog}, BundleRefs: []string{writeResolveTestCatalog(t, self)}}.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Unsatisfiable())
+	})
+
+	t.Run("InvalidConstraint", func(t *testing.T) {
+		invalid := resolveTestBundle("bar", "1.0.0", property.MustBuildConstraintCEL("properties", ""))
+		_, err := Resolve{CatalogRefs: []string{writeResolveTestCatalog(t, invalid)}}.Run(context.Background())
+		require.ErrorContains(t, err, `invalid cel rule "properties"`)
+	})
+}
@@ -0,0 +1,235 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/model"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// InstalledCSV describes a ClusterServiceVersion installed on a cluster.
+type InstalledCSV struct {
+	Namespace string
+	Name      string
+	Version   string
+
+	// Package is the package the CSV was installed from, if known. It is
+	// only used when the CSV cannot be found in the catalog by name.
+	Package string
+}
+
+// UpgradeStatus summarizes the upgrade options for an installed CSV.
+type UpgradeStatus string
+
+const (
+	UpgradeStatusAvailable      UpgradeStatus = "UpgradeAvailable"
+	UpgradeStatusUpToDate       UpgradeStatus = "UpToDate"
+	UpgradeStatusMissingBundle  UpgradeStatus = "BundleNotInCatalog"
+	UpgradeStatusMissingPackage UpgradeStatus = "PackageNotInCatalog"
+)
+
+// CheckUpgrades matches installed CSVs against the channels of a catalog and
+// reports the upgrade edges the catalog offers for each of them.
+type CheckUpgrades struct {
+	IndexReference string
+	Registry       image.Registry
+	LoadRefOptions []declcfg.LoadRefOption
+	Installed      []InstalledCSV
+}
+
+func (c CheckUpgrades) Run(ctx context.Context) (*CheckUpgradesResult, error) {
+	m, err := indexRefToModel(ctx, c.IndexReference, c.Registry, c.LoadRefOptions)
+	if err != nil {
+		return nil, err
+	}
+	return checkUpgrades(m, c.Installed), nil
+}
+
+// UpgradeEdge is an upgrade from an installed CSV to a bundle in the catalog.
+type UpgradeEdge struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Version string `json:"version"`
+
+	// Via is the upgrade mechanism: "replaces", "skips", or "skipRange".
+	Via string `json:"via"`
+
+	// Head is true when To is the head of Channel.
+	Head bool `json:"head"`
+}
+
+type InstalledCSVUpgrades struct {
+	Namespace   string        `json:"namespace"`
+	Name        string        `json:"name"`
+	Version     string        `json:"version,omitempty"`
+	Package     string        `json:"package,omitempty"`
+	Status      UpgradeStatus `json:"status"`
+	Deprecation []string      `json:"deprecation,omitempty"`
+	Upgrades    []UpgradeEdge `json:"upgrades,omitempty"`
+}
+
+type CheckUpgradesResult struct {
+	Installed []InstalledCSVUpgrades `json:"installed"`
+}
+
+func checkUpgrades(m model.Model, installed []InstalledCSV) *CheckUpgradesResult {
+	bundlesByName := map[string][]*model.Bundle{}
+	for _, pkg := range m {
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				bundlesByName[b.Name] = append(bundlesByName[b.Name], b)
+			}
+		}
+	}
+
+	res := &CheckUpgradesResult{}
+	for _, csv := range installed {
+		out := InstalledCSVUpgrades{
+			Namespace: csv.Namespace,
+			Name:      csv.Name,
+			Version:   csv.Version,
+			Package:   csv.Package,
+		}
+
+		var pkg *model.Package
+		current := bundlesByName[csv.Name]
+		if len(current) > 0 {
+			pkg = current[0].Package
+			out.Package = pkg.Name
+			if out.Version == "" {
+				out.Version = current[0].Version.String()
+			}
+		} else if p, ok := m[csv.Package]; ok {
+			pkg = p
+		}
+
+		switch {
+		case pkg == nil:
+			out.Status = UpgradeStatusMissingPackage
+		default:
+			out.Deprecation = deprecationsFor(pkg, current)
+			out.Upgrades = upgradeEdges(pkg, csv.Name, out.Version)
+			switch {
+			case len(out.Upgrades) > 0:
+				out.Status = UpgradeStatusAvailable
+			case len(current) == 0:
+				out.Status = UpgradeStatusMissingBundle
+			default:
+				out.Status = UpgradeStatusUpToDate
+			}
+		}
+		res.Installed = append(res.Installed, out)
+	}
+	sort.Slice(res.Installed, func(i, j int) bool {
+		if res.Installed[i].Namespace != res.Installed[j].Namespace {
+			return res.Installed[i].Namespace < res.Installed[j].Namespace
+		}
+		return res.Installed[i].Name < res.Installed[j].Name
+	})
+	return res
+}
+
+// deprecationsFor returns the deprecation messages that apply to the
+// installed bundle: those of its package, of the channels it is in, and of
+// the bundle itself.
+func deprecationsFor(pkg *model.Package, current []*model.Bundle) []string {
+	var msgs []string
+	if pkg.Deprecation != nil {
+		msgs = append(msgs, pkg.Deprecation.Message)
+	}
+	for _, b := range current {
+		if b.Channel.Deprecation != nil {
+			msgs = append(msgs, b.Channel.Deprecation.Message)
+		}
+	}
+	// Bundle deprecations are copied to every channel entry of the bundle,
+	// so only report the first one.
+	for _, b := range current {
+		if b.Deprecation != nil {
+			msgs = append(msgs, b.Deprecation.Message)
+			break
+		}
+	}
+	return msgs
+}
+
+// upgradeEdges returns the bundles in pkg that can be upgraded to directly
+// from the bundle named name, with the given version.
+func upgradeEdges(pkg *model.Package, name, version string) []UpgradeEdge {
+	v, versionErr := semver.Parse(version)
+
+	var edges []UpgradeEdge
+	for _, ch := range pkg.Channels {
+		head, _ := ch.Head()
+		for _, b := range ch.Bundles {
+			if b.Name == name {
+				continue
+			}
+			via := ""
+			switch {
+			case b.Replaces == name:
+				via = "replaces"
+			case slices.Contains(b.Skips, name):
+				via = "skips"
+			case b.SkipRange != "" && versionErr == nil:
+				if r, err := semver.ParseRange(b.SkipRange); err == nil && r(v) {
+					via = "skipRange"
+				}
+			}
+			if via == "" {
+				continue
+			}
+			edges = append(edges, UpgradeEdge{
+				Channel: ch.Name,
+				To:      b.Name,
+				Version: b.Version.String(),
+				Via:     via,
+				Head:    head != nil && head.Name == b.Name,
+			})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Channel != edges[j].Channel {
+			return edges[i].Channel < edges[j].Channel
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+func (r *CheckUpgradesResult) WriteColumns(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "NAMESPACE\tCSV\tPACKAGE\tSTATUS\tUPGRADES\tDEPRECATED"); err != nil {
+		return err
+	}
+	for _, i := range r.Installed {
+		var upgrades []string
+		for _, u := range i.Upgrades {
+			upgrades = append(upgrades, fmt.Sprintf("%s:%s", u.Channel, u.To))
+		}
+		deprecated := "false"
+		if len(i.Deprecation) > 0 {
+			deprecated = "true"
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", i.Namespace, i.Name, i.Package, i.Status, strings.Join(upgrades, ","), deprecated); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func (r *CheckUpgradesResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/model"
+)
+
+func TestCheckUpgrades(t *testing.T) {
+	c := CheckUpgrades{
+		IndexReference: "testdata/list-index",
+		Installed: []InstalledCSV{
+			{Namespace: "ns1", Name: "foo.v0.1.0"},
+			{Namespace: "ns2", Name: "foo.v0.2.0"},
+			{Namespace: "ns3", Name: "foo.v0.1.1", Version: "0.1.1", Package: "foo"},
+			{Namespace: "ns3", Name: "foo.v0.3.0", Version: "0.3.0", Package: "foo"},
+			{Namespace: "ns4", Name: "baz.v1.0.0", Version: "1.0.0", Package: "baz"},
+		},
+	}
+	res, err := c.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []InstalledCSVUpgrades{
+		{
+			Namespace: "ns1", Name: "foo.v0.1.0", Version: "0.1.0", Package: "foo", Status: UpgradeStatusAvailable,
+			Upgrades: []UpgradeEdge{
+				{Channel: "beta", To: "foo.v0.2.0", Version: "0.2.0", Via: "replaces", Head: true},
+				{Channel: "stable", To: "foo.v0.2.0", Version: "0.2.0", Via: "replaces", Head: true},
+			},
+		},
+		{Namespace: "ns2", Name: "foo.v0.2.0", Version: "0.2.0", Package: "foo", Status: UpgradeStatusUpToDate},
+		{
+			Namespace: "ns3", Name: "foo.v0.1.1", Version: "0.1.1", Package: "foo", Status: UpgradeStatusAvailable,
+			Upgrades: []UpgradeEdge{
+				{Channel: "beta", To: "foo.v0.2.0", Version: "0.2.0", Via: "skips", Head: true},
+				{Channel: "stable", To: "foo.v0.2.0", Version: "0.2.0", Via: "skips", Head: true},
+			},
+		},
+		{Namespace: "ns3", Name: "foo.v0.3.0", Version: "0.3.0", Package: "foo", Status: UpgradeStatusMissingBundle},
+		{Namespace: "ns4", Name: "baz.v1.0.0", Version: "1.0.0", Package: "baz", Status: UpgradeStatusMissingPackage},
+	}, res.Installed)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.WriteColumns(buf))
+	require.Equal(t, `NAMESPACE  CSV         PACKAGE  STATUS               UPGRADES                           DEPRECATED
+ns1        foo.v0.1.0  foo      UpgradeAvailable     beta:foo.v0.2.0,stable:foo.v0.2.0  false
+ns2        foo.v0.2.0  foo      UpToDate                                                false
+ns3        foo.v0.1.1  foo      UpgradeAvailable     beta:foo.v0.2.0,stable:foo.v0.2.0  false
+ns3        foo.v0.3.0  foo      BundleNotInCatalog                                      false
+ns4        baz.v1.0.0  baz      PackageNotInCatalog                                     false
+`, buf.String())
+}
+
+func TestCheckUpgradesDeprecated(t *testing.T) {
+	pkg := &model.Package{Name: "foo", Deprecation: &model.Deprecation{Message: "foo is deprecated"}}
+	ch := &model.Channel{Package: pkg, Name: "stable", Deprecation: &model.Deprecation{Message: "stable is deprecated"}}
+	b := &model.Bundle{Package: pkg, Channel: ch, Name: "foo.v1.0.0", Version: semver.MustParse("1.0.0"), Deprecation: &model.Deprecation{Message: "foo.v1.0.0 is deprecated"}}
+	ch.Bundles = map[string]*model.Bundle{b.Name: b}
+	pkg.Channels = map[string]*model.Channel{ch.Name: ch}
+	pkg.DefaultChannel = ch
+
+	res := checkUpgrades(model.Model{pkg.Name: pkg}, []InstalledCSV{{Namespace: "ns", Name: "foo.v1.0.0"}})
+	require.Equal(t, []InstalledCSVUpgrades{{
+		Namespace:   "ns",
+		Name:        "foo.v1.0.0",
+		Version:     "1.0.0",
+		Package:     "foo",
+		Status:      UpgradeStatusUpToDate,
+		Deprecation: []string{"foo is deprecated", "stable is deprecated", "foo.v1.0.0 is deprecated"},
+	}}, res.Installed)
+}
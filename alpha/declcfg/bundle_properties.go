@@ -0,0 +1,172 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// BundleProperties are properties to add to the bundles that match their
+// selectors, e.g. the support level or certification status that a vendor
+// attaches to the bundles of its catalog.
+type BundleProperties struct {
+	rules []BundlePropertiesRule
+}
+
+// BundlePropertiesRule adds Properties to the bundles of Package, or of all
+// packages if it is empty, whose version is in VersionRange, if it is set.
+type BundlePropertiesRule struct {
+	Package      string              `json:"package,omitempty"`
+	VersionRange string              `json:"versionRange,omitempty"`
+	Properties   []property.Property `json:"properties"`
+
+	versionRange semver.Range
+}
+
+// reservedBundlePropertyTypes are the property types that are derived from
+// the contents of a bundle, and cannot be added to it.
+var reservedBundlePropertyTypes = map[string]struct{}{
+	property.TypePackage:      {},
+	property.TypeBundleObject: {},
+	property.TypeCSVMetadata:  {},
+}
+
+// LoadBundleProperties loads bundle properties from r, a stream of YAML or
+// JSON rule objects, e.g.
+//
+//	package: foo
+//	versionRange: ">=1.0.0"
+//	properties:
+//	  - type: example.com/support-level
+//	    value: premium
+//
+// The properties of each rule are validated, and so are the values of the
+// properties of known types.
+func LoadBundleProperties(r io.Reader) (*BundleProperties, error) {
+	var bp BundleProperties
+	dec := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+		if len(bytes.TrimSpace(raw)) == 0 || string(raw) == "null" {
+			continue
+		}
+		var rule BundlePropertiesRule
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+		bp.rules = append(bp.rules, rule)
+	}
+	return &bp, nil
+}
+
+func (r *BundlePropertiesRule) validate() error {
+	if r.VersionRange != "" {
+		vr, err := semver.ParseRange(r.VersionRange)
+		if err != nil {
+			return fmt.Errorf("invalid versionRange %q: %v", r.VersionRange, err)
+		}
+		r.versionRange = vr
+	}
+	if len(r.Properties) == 0 {
+		return errors.New("properties must be set")
+	}
+	for i, p := range r.Properties {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("property[%d]: %v", i, err)
+		}
+		if _, ok := reservedBundlePropertyTypes[p.Type]; ok {
+			return fmt.Errorf("property[%d]: properties of type %q cannot be added to bundles", i, p.Type)
+		}
+	}
+	if _, err := property.Parse(r.Properties); err != nil {
+		return err
+	}
+	return nil
+}
+
+// matches returns whether b is selected by r.
+func (r *BundlePropertiesRule) matches(b Bundle) (bool, error) {
+	if r.Package != "" && r.Package != b.Package {
+		return false, nil
+	}
+	if r.versionRange == nil {
+		return true, nil
+	}
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return false, fmt.Errorf("parse properties: %v", err)
+	}
+	if len(props.Packages) != 1 {
+		return false, nil
+	}
+	v, err := semver.Parse(props.Packages[0].Version)
+	if err != nil {
+		return false, nil
+	}
+	return r.versionRange(v), nil
+}
+
+// Apply adds the properties of the rules that match each bundle of cfg to
+// the bundle, in the order of the rules. Properties that the bundle already
+// has, with an equal value, are not added again.
+func (bp *BundleProperties) Apply(cfg *DeclarativeConfig) error {
+	if bp == nil {
+		return nil
+	}
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		for _, rule := range bp.rules {
+			ok, err := rule.matches(*b)
+			if err != nil {
+				return fmt.Errorf("bundle %q: %v", b.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			for _, p := range rule.Properties {
+				if !hasProperty(b.Properties, p) {
+					b.Properties = append(b.Properties, p)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasProperty returns whether props has a property with the type and value
+// of p.
+func hasProperty(props []property.Property, p property.Property) bool {
+	var want interface{}
+	if err := json.Unmarshal(p.Value, &want); err != nil {
+		return false
+	}
+	for _, existing := range props {
+		if existing.Type != p.Type {
+			continue
+		}
+		var got interface{}
+		if err := json.Unmarshal(existing.Value, &got); err == nil && reflect.DeepEqual(got, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestLoadBundleProperties(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		input     string
+		expectErr string
+	}{
+		{
+			name: "Valid/YAML",
+			input: `
+package: foo
+versionRange: ">=1.0.0"
+properties:
+- type: example.com/support-level
+  value: premium
+---
+properties:
+- type: olm.maxOpenShiftVersion
+  value: "4.16"
+`,
+		},
+		{
+			name:  "Valid/JSON",
+			input: `{"package": "foo", "properties": [{"type": "example.com/certified", "value": true}]}`,
+		},
+		{
+			name:      "Invalid/UnknownField",
+			input:     `{"package": "foo", "version": "1.0.0", "properties": [{"type": "example.com/certified", "value": true}]}`,
+			expectErr: `rule 0: json: unknown field "version"`,
+		},
+		{
+			name:      "Invalid/VersionRange",
+			input:     `{"versionRange": "one", "properties": [{"type": "example.com/certified", "value": true}]}`,
+			expectErr: `rule 0: invalid versionRange "one"`,
+		},
+		{
+			name:      "Invalid/NoProperties",
+			input:     `{"package": "foo"}`,
+			expectErr: `rule 0: properties must be set`,
+		},
+		{
+			name: "Invalid/ReservedType",
+			input: `{"properties": [{"type": "example.com/certified", "value": true}]}
+{"properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "1.0.0"}}]}`,
+			expectErr: `rule 1: property[0]: properties of type "olm.package" cannot be added to bundles`,
+		},
+		{
+			name:      "Invalid/KnownTypeValue",
+			input:     `{"properties": [{"type": "olm.gvk", "value": "example.com/v1/Widget"}]}`,
+			expectErr: `rule 0: parse property[0] of type "olm.gvk"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBundleProperties(strings.NewReader(tt.input))
+			if tt.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.expectErr)
+		})
+	}
+}
+
+func TestBundlePropertiesApply(t *testing.T) {
+	supportLevel := property.Property{Type: "example.com/support-level", Value: json.RawMessage(`"premium"`)}
+	certified := property.Property{Type: "example.com/certified", Value: json.RawMessage(`true`)}
+	bundle := func(pkg, version string, props ...property.Property) Bundle {
+		return Bundle{
+			Schema:     SchemaBundle,
+			Package:    pkg,
+			Name:       pkg + ".v" + version,
+			Properties: append([]property.Property{property.MustBuildPackage(pkg, version)}, props...),
+		}
+	}
+
+	bp, err := LoadBundleProperties(strings.NewReader(`
+package: foo
+versionRange: ">=1.0.0"
+properties:
+- type: example.com/support-level
+  value: premium
+---
+properties:
+- type: example.com/certified
+  value: true
+`))
+	require.NoError(t, err)
+
+	cfg := &DeclarativeConfig{Bundles: []Bundle{
+		bundle("foo", "0.1.0"),
+		bundle("foo", "1.0.0"),
+		bundle("foo", "1.1.0", property.Property{Type: "example.com/certified", Value: json.RawMessage(`  true `)}),
+		bundle("bar", "1.0.0"),
+	}}
+	require.NoError(t, bp.Apply(cfg))
+	require.Equal(t, []Bundle{
+		bundle("foo", "0.1.0", certified),
+		bundle("foo", "1.0.0", supportLevel, certified),
+		bundle("foo", "1.1.0", property.Property{Type: "example.com/certified", Value: json.RawMessage(`  true `)}, supportLevel),
+		bundle("bar", "1.0.0", certified),
+	}, cfg.Bundles)
+
+	var nilProperties *BundleProperties
+	require.NoError(t, nilProperties.Apply(cfg))
+}
@@ -0,0 +1,230 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// Canonical returns a WriteFunc that writes declarative configs with write
+// in canonical form, so that declarative configs with the same content are
+// written byte-for-byte the same. See Canonicalize.
+func Canonical(write WriteFunc) WriteFunc {
+	return func(cfg DeclarativeConfig, w io.Writer) error {
+		c, err := Canonicalize(cfg)
+		if err != nil {
+			return err
+		}
+		return write(*c, w)
+	}
+}
+
+// Canonicalize returns a copy of cfg in canonical form, in which:
+//   - the raw JSON of property values and of other objects is compact, with
+//     the keys of its objects sorted, and its non-integer numbers in their
+//     shortest form, e.g. 1.5e+21 for 1500000000000000000000.0
+//   - the properties and related images of objects, the entries of channels
+//     and deprecations, and the skips of channel entries are sorted
+//   - objects are sorted by package, and then by name or by schema
+func Canonicalize(cfg DeclarativeConfig) (*DeclarativeConfig, error) {
+	out := DeclarativeConfig{
+		Packages:       make([]Package, 0, len(cfg.Packages)),
+		Channels:       make([]Channel, 0, len(cfg.Channels)),
+		Bundles:        make([]Bundle, 0, len(cfg.Bundles)),
+		Deprecations:   make([]Deprecation, 0, len(cfg.Deprecations)),
+		Documentations: append([]PackageDocumentation(nil), cfg.Documentations...),
+		Others:         make([]Meta, 0, len(cfg.Others)),
+	}
+
+	for _, p := range cfg.Packages {
+		props, err := canonicalProperties(p.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("package %q: %v", p.Name, err)
+		}
+		p.Properties = props
+		out.Packages = append(out.Packages, p)
+	}
+	sort.SliceStable(out.Packages, func(i, j int) bool {
+		return out.Packages[i].Name < out.Packages[j].Name
+	})
+
+	for _, c := range cfg.Channels {
+		props, err := canonicalProperties(c.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("package %q, channel %q: %v", c.Package, c.Name, err)
+		}
+		c.Properties = props
+		c.Entries = append([]ChannelEntry(nil), c.Entries...)
+		for i := range c.Entries {
+			c.Entries[i].Skips = append([]string(nil), c.Entries[i].Skips...)
+			sort.Strings(c.Entries[i].Skips)
+		}
+		sort.SliceStable(c.Entries, func(i, j int) bool {
+			return c.Entries[i].Name < c.Entries[j].Name
+		})
+		out.Channels = append(out.Channels, c)
+	}
+	sort.SliceStable(out.Channels, func(i, j int) bool {
+		if out.Channels[i].Package != out.Channels[j].Package {
+			return out.Channels[i].Package < out.Channels[j].Package
+		}
+		return out.Channels[i].Name < out.Channels[j].Name
+	})
+
+	for _, b := range cfg.Bundles {
+		props, err := canonicalProperties(b.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("package %q, bundle %q: %v", b.Package, b.Name, err)
+		}
+		b.Properties = props
+		b.RelatedImages = append([]RelatedImage(nil), b.RelatedImages...)
+		sort.SliceStable(b.RelatedImages, func(i, j int) bool {
+			if b.RelatedImages[i].Image != b.RelatedImages[j].Image {
+				return b.RelatedImages[i].Image < b.RelatedImages[j].Image
+			}
+			return b.RelatedImages[i].Name < b.RelatedImages[j].Name
+		})
+		out.Bundles = append(out.Bundles, b)
+	}
+	sort.SliceStable(out.Bundles, func(i, j int) bool {
+		if out.Bundles[i].Package != out.Bundles[j].Package {
+			return out.Bundles[i].Package < out.Bundles[j].Package
+		}
+		return out.Bundles[i].Name < out.Bundles[j].Name
+	})
+
+	for _, d := range cfg.Deprecations {
+		d.Entries = append([]DeprecationEntry(nil), d.Entries...)
+		sort.SliceStable(d.Entries, func(i, j int) bool {
+			ri, rj := d.Entries[i].Reference, d.Entries[j].Reference
+			if ri.Schema != rj.Schema {
+				return ri.Schema < rj.Schema
+			}
+			return ri.Name < rj.Name
+		})
+		out.Deprecations = append(out.Deprecations, d)
+	}
+	sort.SliceStable(out.Deprecations, func(i, j int) bool {
+		return out.Deprecations[i].Package < out.Deprecations[j].Package
+	})
+
+	sort.SliceStable(out.Documentations, func(i, j int) bool {
+		return out.Documentations[i].Package < out.Documentations[j].Package
+	})
+
+	for _, o := range cfg.Others {
+		blob, err := canonicalJSON(o.Blob)
+		if err != nil {
+			return nil, fmt.Errorf("object with schema %q, package %q, name %q: %v", o.Schema, o.Package, o.Name, err)
+		}
+		o.Blob = blob
+		out.Others = append(out.Others, o)
+	}
+	sort.SliceStable(out.Others, func(i, j int) bool {
+		oi, oj := out.Others[i], out.Others[j]
+		if oi.Package != oj.Package {
+			return oi.Package < oj.Package
+		}
+		if oi.Schema != oj.Schema {
+			return oi.Schema < oj.Schema
+		}
+		if oi.Name != oj.Name {
+			return oi.Name < oj.Name
+		}
+		return bytes.Compare(oi.Blob, oj.Blob) < 0
+	})
+	return &out, nil
+}
+
+// canonicalProperties returns a copy of props with canonical values, sorted
+// by type and then by value.
+func canonicalProperties(props []property.Property) ([]property.Property, error) {
+	if props == nil {
+		return nil, nil
+	}
+	out := make([]property.Property, 0, len(props))
+	for _, p := range props {
+		value, err := canonicalJSON(p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %v", p.Type, err)
+		}
+		out = append(out, property.Property{Type: p.Type, Value: value})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return bytes.Compare(out[i].Value, out[j].Value) < 0
+	})
+	return out, nil
+}
+
+// canonicalJSON returns the canonical form of the raw JSON of data, or data
+// itself if it is empty.
+func canonicalJSON(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	v, err := canonicalNumbers(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encoding a map sorts its keys.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// canonicalNumbers replaces the non-integer numbers in v with their shortest
+// form.
+func canonicalNumbers(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		s := v.String()
+		if !strings.ContainsAny(s, ".eE") {
+			if s == "-0" {
+				return json.Number("0"), nil
+			}
+			return v, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	case map[string]interface{}:
+		for k, e := range v {
+			ce, err := canonicalNumbers(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = ce
+		}
+	case []interface{}:
+		for i, e := range v {
+			ce, err := canonicalNumbers(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ce
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,92 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestCanonical(t *testing.T) {
+	a := DeclarativeConfig{
+		Packages: []Package{{Schema: SchemaPackage, Name: "foo", DefaultChannel: "stable"}},
+		Channels: []Channel{{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{
+			{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.2", "foo.v0.1.1"}},
+			{Name: "foo.v0.1.0"},
+		}}},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0", Image: "foo:0.2.0",
+				Properties: []property.Property{
+					{Type: "olm.package", Value: json.RawMessage(`{"version":"0.2.0","packageName":"foo"}`)},
+					{Type: "example.com/weight", Value: json.RawMessage(`1.50`)},
+					{Type: "example.com/label", Value: json.RawMessage(`"b"`)},
+					{Type: "example.com/label", Value: json.RawMessage(`"a"`)},
+				},
+				RelatedImages: []RelatedImage{{Name: "operator", Image: "operator:0.2.0"}, {Image: "foo:0.2.0"}},
+			},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "foo:0.1.0"},
+		},
+		Others: []Meta{
+			{Schema: "example.com/other", Package: "foo", Name: "b", Blob: json.RawMessage(`{"schema":"example.com/other","package":"foo","name":"b","count":2E2}`)},
+			{Schema: "example.com/other", Package: "foo", Name: "a", Blob: json.RawMessage(`{"schema":"example.com/other","package":"foo","name":"a"}`)},
+		},
+	}
+	b := DeclarativeConfig{
+		Packages: a.Packages,
+		Channels: []Channel{{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{
+			{Name: "foo.v0.1.0"},
+			{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1", "foo.v0.1.2"}},
+		}}},
+		Bundles: []Bundle{
+			a.Bundles[1],
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0", Image: "foo:0.2.0",
+				Properties: []property.Property{
+					{Type: "example.com/label", Value: json.RawMessage(`"a"`)},
+					{Type: "example.com/weight", Value: json.RawMessage(`1.5`)},
+					{Type: "example.com/label", Value: json.RawMessage(`"b"`)},
+					{Type: "olm.package", Value: json.RawMessage(`{"packageName": "foo", "version": "0.2.0"}`)},
+				},
+				RelatedImages: []RelatedImage{{Image: "foo:0.2.0"}, {Name: "operator", Image: "operator:0.2.0"}},
+			},
+		},
+		Others: []Meta{
+			{Schema: "example.com/other", Package: "foo", Name: "a", Blob: json.RawMessage(`{"name":"a","package":"foo","schema":"example.com/other"}`)},
+			{Schema: "example.com/other", Package: "foo", Name: "b", Blob: json.RawMessage(`{"count":200.0,"name":"b","package":"foo","schema":"example.com/other"}`)},
+		},
+	}
+
+	for name, write := range map[string]WriteFunc{"json": WriteJSON, "yaml": WriteYAML} {
+		t.Run(name, func(t *testing.T) {
+			var outA, outB bytes.Buffer
+			require.NoError(t, Canonical(write)(a, &outA))
+			require.NoError(t, Canonical(write)(b, &outB))
+			require.Equal(t, outA.String(), outB.String())
+		})
+	}
+
+	c, err := Canonicalize(a)
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo.v0.1.1", "foo.v0.1.2"}, c.Channels[0].Entries[1].Skips)
+	require.Equal(t, []property.Property{
+		{Type: "example.com/label", Value: json.RawMessage(`"a"`)},
+		{Type: "example.com/label", Value: json.RawMessage(`"b"`)},
+		{Type: "example.com/weight", Value: json.RawMessage(`1.5`)},
+		{Type: "olm.package", Value: json.RawMessage(`{"packageName":"foo","version":"0.2.0"}`)},
+	}, c.Bundles[1].Properties)
+	require.Equal(t, `{"count":200,"name":"b","package":"foo","schema":"example.com/other"}`, string(c.Others[1].Blob))
+
+	// The input is not modified.
+	require.Equal(t, "foo.v0.2.0", a.Channels[0].Entries[0].Name)
+	require.Equal(t, []string{"foo.v0.1.2", "foo.v0.1.1"}, a.Channels[0].Entries[0].Skips)
+	require.Equal(t, "olm.package", a.Bundles[0].Properties[0].Type)
+}
+
+func TestCanonicalInvalidJSON(t *testing.T) {
+	_, err := Canonicalize(DeclarativeConfig{Bundles: []Bundle{{Package: "foo", Name: "foo.v0.1.0",
+		Properties: []property.Property{{Type: "example.com/broken", Value: json.RawMessage(`{`)}},
+	}}})
+	require.ErrorContains(t, err, `bundle "foo.v0.1.0": property "example.com/broken"`)
+}
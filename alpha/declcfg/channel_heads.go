@@ -0,0 +1,155 @@
+package declcfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChannelHead is the head of a channel, the entry that is upgraded to from
+// every other entry of the channel, as computed by ChannelHeads.
+type ChannelHead struct {
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+	// Heads are the entries of the channel that no other entry replaces or
+	// skips. A valid channel has exactly one.
+	Heads []string `json:"heads"`
+	// Problems explain why the channel does not have exactly one head, e.g.
+	// the replaces chains of multiple heads, which typically show a missing
+	// replaces, or cycles of replaces.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Valid reports whether the channel has exactly one head, and no problems.
+func (h ChannelHead) Valid() bool {
+	return len(h.Heads) == 1 && len(h.Problems) == 0
+}
+
+// ChannelHeads computes the heads of channels from their entries alone,
+// without the bundles of the channels, or the validation of a model.Model,
+// so that the heads of the channels of a catalog can be found cheaply, and
+// even if its channels are invalid. The result is sorted by package and
+// channel.
+func ChannelHeads(channels []Channel) []ChannelHead {
+	heads := make([]ChannelHead, 0, len(channels))
+	for _, ch := range channels {
+		heads = append(heads, channelHead(ch))
+	}
+	sort.Slice(heads, func(i, j int) bool {
+		if heads[i].Package != heads[j].Package {
+			return heads[i].Package < heads[j].Package
+		}
+		return heads[i].Channel < heads[j].Channel
+	})
+	return heads
+}
+
+func channelHead(ch Channel) ChannelHead {
+	head := ChannelHead{Package: ch.Package, Channel: ch.Name, Heads: []string{}}
+
+	entries := make(map[string]ChannelEntry, len(ch.Entries))
+	incoming := map[string]struct{}{}
+	for _, e := range ch.Entries {
+		if _, ok := entries[e.Name]; ok {
+			head.Problems = append(head.Problems, fmt.Sprintf("duplicate entry %q", e.Name))
+			continue
+		}
+		entries[e.Name] = e
+		if e.Replaces != "" {
+			incoming[e.Replaces] = struct{}{}
+		}
+		for _, skip := range e.Skips {
+			incoming[skip] = struct{}{}
+		}
+	}
+	for name := range entries {
+		if _, ok := incoming[name]; !ok {
+			head.Heads = append(head.Heads, name)
+		}
+	}
+	sort.Strings(head.Heads)
+
+	switch {
+	case len(entries) == 0:
+		head.Problems = append(head.Problems, "channel has no entries")
+	case len(head.Heads) == 0:
+		head.Problems = append(head.Problems, "no head found: every entry is replaced or skipped by another entry")
+	case len(head.Heads) > 1:
+		head.Problems = append(head.Problems, fmt.Sprintf("multiple heads found: %s", strings.Join(head.Heads, ", ")))
+		for _, h := range head.Heads {
+			head.Problems = append(head.Problems, fmt.Sprintf("replaces chain from head %q: %s", h, replacesChain(entries, h)))
+		}
+	}
+	for _, cycle := range replacesCycles(entries) {
+		head.Problems = append(head.Problems, fmt.Sprintf("cycle in replaces chain: %s", strings.Join(cycle, " -> ")))
+	}
+	return head
+}
+
+// replacesChain describes the replaces chain from the entry named from, up
+// to the entry that replaces an entry that is not in the channel, if any.
+func replacesChain(entries map[string]ChannelEntry, from string) string {
+	chain := []string{from}
+	seen := map[string]struct{}{from: {}}
+	for cur := entries[from]; cur.Replaces != ""; {
+		next, ok := entries[cur.Replaces]
+		if !ok {
+			chain = append(chain, fmt.Sprintf("%s (not in channel)", cur.Replaces))
+			break
+		}
+		chain = append(chain, next.Name)
+		if _, ok := seen[next.Name]; ok {
+			break
+		}
+		seen[next.Name] = struct{}{}
+		cur = next
+	}
+	return strings.Join(chain, " -> ")
+}
+
+// replacesCycles returns the cycles of replaces among entries, each starting
+// and ending at the entry of the cycle with the lowest name.
+func replacesCycles(entries map[string]ChannelEntry) [][]string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cycles [][]string
+	done := map[string]struct{}{}
+	for _, name := range names {
+		pos := map[string]int{}
+		var path []string
+		cur, ok := entries[name]
+		for ok {
+			if _, ok := done[cur.Name]; ok {
+				break
+			}
+			if i, ok := pos[cur.Name]; ok {
+				cycles = append(cycles, rotateCycle(path[i:]))
+				break
+			}
+			pos[cur.Name] = len(path)
+			path = append(path, cur.Name)
+			cur, ok = entries[cur.Replaces]
+		}
+		for _, n := range path {
+			done[n] = struct{}{}
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// rotateCycle rotates cycle to start at its lowest name, and closes it.
+func rotateCycle(cycle []string) []string {
+	lowest := 0
+	for i, name := range cycle {
+		if name < cycle[lowest] {
+			lowest = i
+		}
+	}
+	rotated := append(append([]string{}, cycle[lowest:]...), cycle[:lowest]...)
+	return append(rotated, rotated[0])
+}
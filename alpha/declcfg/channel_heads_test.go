@@ -0,0 +1,124 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelHeads(t *testing.T) {
+	channel := func(pkg, name string, entries ...ChannelEntry) Channel {
+		return Channel{Schema: SchemaChannel, Package: pkg, Name: name, Entries: entries}
+	}
+
+	type spec struct {
+		name     string
+		channels []Channel
+		expected []ChannelHead
+	}
+	specs := []spec{
+		{
+			name: "Valid",
+			channels: []Channel{
+				channel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.2.1"}},
+				),
+				channel("bar", "alpha",
+					ChannelEntry{Name: "bar.v1.0.0", Replaces: "bar.v0.9.0"},
+				),
+			},
+			expected: []ChannelHead{
+				{Package: "bar", Channel: "alpha", Heads: []string{"bar.v1.0.0"}},
+				{Package: "foo", Channel: "stable", Heads: []string{"foo.v0.3.0"}},
+			},
+		},
+		{
+			name: "MultipleHeads",
+			channels: []Channel{
+				channel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.1"},
+				),
+			},
+			expected: []ChannelHead{
+				{
+					Package: "foo",
+					Channel: "stable",
+					Heads:   []string{"foo.v0.2.0", "foo.v0.3.0"},
+					Problems: []string{
+						"multiple heads found: foo.v0.2.0, foo.v0.3.0",
+						`replaces chain from head "foo.v0.2.0": foo.v0.2.0 -> foo.v0.1.0`,
+						`replaces chain from head "foo.v0.3.0": foo.v0.3.0 -> foo.v0.2.1 (not in channel)`,
+					},
+				},
+			},
+		},
+		{
+			name: "Cycle",
+			channels: []Channel{
+				channel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.3.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+				),
+			},
+			expected: []ChannelHead{
+				{
+					Package: "foo",
+					Channel: "stable",
+					Heads:   []string{},
+					Problems: []string{
+						"no head found: every entry is replaced or skipped by another entry",
+						"cycle in replaces chain: foo.v0.1.0 -> foo.v0.3.0 -> foo.v0.2.0 -> foo.v0.1.0",
+					},
+				},
+			},
+		},
+		{
+			name: "CycleBelowHead",
+			channels: []Channel{
+				channel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.2.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+				),
+			},
+			expected: []ChannelHead{
+				{
+					Package: "foo",
+					Channel: "stable",
+					Heads:   []string{"foo.v0.3.0"},
+					Problems: []string{
+						"cycle in replaces chain: foo.v0.1.0 -> foo.v0.2.0 -> foo.v0.1.0",
+					},
+				},
+			},
+		},
+		{
+			name: "DuplicateAndEmpty",
+			channels: []Channel{
+				channel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.1.0"},
+				),
+				channel("foo", "empty"),
+			},
+			expected: []ChannelHead{
+				{Package: "foo", Channel: "empty", Heads: []string{}, Problems: []string{"channel has no entries"}},
+				{Package: "foo", Channel: "stable", Heads: []string{"foo.v0.1.0"}, Problems: []string{`duplicate entry "foo.v0.1.0"`}},
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual := ChannelHeads(s.channels)
+			assert.Equal(t, s.expected, actual)
+			for i := range actual {
+				assert.Equal(t, len(s.expected[i].Heads) == 1 && len(s.expected[i].Problems) == 0, actual[i].Valid())
+			}
+		})
+	}
+}
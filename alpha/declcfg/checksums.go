@@ -0,0 +1,138 @@
+package declcfg
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const checksumAlgorithm = "sha256"
+
+// Checksums maps package names to a digest of that package's declarative
+// config content. It allows consumers (e.g. mirroring tools) to verify the
+// integrity of individual packages without hashing the whole catalog.
+type Checksums struct {
+	Packages map[string]string `json:"packages"`
+}
+
+// ComputeChecksums computes the canonical digest of each package in cfg: the
+// digest of the JSON encoding, as written by WriteJSON, of the canonical form
+// of the package's objects (see Canonicalize). The result is independent of
+// how the objects were originally laid out, ordered, or formatted on disk.
+// Objects that do not belong to a package are not included.
+func ComputeChecksums(cfg DeclarativeConfig) (*Checksums, error) {
+	digests, err := packageDigests(cfg)
+	if err != nil {
+		return nil, err
+	}
+	delete(digests, "")
+	return &Checksums{Packages: digests}, nil
+}
+
+// Hash computes the canonical digest of all of cfg. It is the digest, as
+// computed by DigestChecksums, of the canonical digests of its packages, as
+// computed by ComputeChecksums, and of the canonical digest of its objects
+// that do not belong to a package, if any. Catalogs with the same content
+// have the same hash, however their objects are formatted, ordered, or split
+// into files, so that it can be used to detect whether a catalog has changed,
+// and to sign it. Registries report the same digest for the catalogs they
+// serve from the checksums of their packages.
+func Hash(cfg DeclarativeConfig) (string, error) {
+	digests, err := packageDigests(cfg)
+	if err != nil {
+		return "", fmt.Errorf("compute hash: %v", err)
+	}
+	return DigestChecksums(digests), nil
+}
+
+// DigestChecksums returns the digest of a catalog from the canonical digests
+// of its packages, keyed by package name. The canonical digest of the objects
+// of the catalog that do not belong to a package, if any, is keyed by the
+// empty name.
+func DigestChecksums(checksums map[string]string) string {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\n", name, checksums[name])
+	}
+	return fmt.Sprintf("%s:%x", checksumAlgorithm, h.Sum(nil))
+}
+
+// packageDigests returns the canonical digest of the objects of each package
+// of cfg, keyed by package name, with the objects that do not belong to a
+// package keyed by the empty name.
+func packageDigests(cfg DeclarativeConfig) (map[string]string, error) {
+	digests := map[string]string{}
+	for name, pcfg := range SplitByPackage(cfg) {
+		c, err := Canonicalize(*pcfg)
+		if err != nil {
+			return nil, fmt.Errorf("compute checksum for package %q: %v", name, err)
+		}
+		h := sha256.New()
+		if err := WriteJSON(*c, h); err != nil {
+			return nil, fmt.Errorf("compute checksum for package %q: %v", name, err)
+		}
+		digests[name] = fmt.Sprintf("%s:%x", checksumAlgorithm, h.Sum(nil))
+	}
+	return digests, nil
+}
+
+// VerifyChecksums computes the checksums of cfg and compares them with
+// expected. It returns an error describing every package whose digest
+// differs, that is missing from cfg, or that is not listed in expected.
+func VerifyChecksums(cfg DeclarativeConfig, expected Checksums) error {
+	actual, err := ComputeChecksums(cfg)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for name, want := range expected.Packages {
+		got, ok := actual.Packages[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Sprintf("package %q: not found in catalog", name))
+		case got != want:
+			errs = append(errs, fmt.Sprintf("package %q: checksum mismatch: expected %q, got %q", name, want, got))
+		}
+	}
+	for name := range actual.Packages {
+		if _, ok := expected.Packages[name]; !ok {
+			errs = append(errs, fmt.Sprintf("package %q: no expected checksum", name))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs)
+	return errors.New("checksum verification failed:\n" + strings.Join(errs, "\n"))
+}
+
+// LoadChecksums reads a checksums blob, as written by WriteChecksums, from r.
+func LoadChecksums(r io.Reader) (*Checksums, error) {
+	var c Checksums
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("decode checksums: %v", err)
+	}
+	if c.Packages == nil {
+		c.Packages = map[string]string{}
+	}
+	return &c, nil
+}
+
+// WriteChecksums writes c to w as JSON.
+func WriteChecksums(c Checksums, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(c)
+}
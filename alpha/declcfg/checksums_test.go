@@ -0,0 +1,178 @@
+package declcfg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeChecksums(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(validDeclarativeConfigSpec{IncludeUnrecognized: true, IncludeDeprecations: true})
+
+	checksums, err := ComputeChecksums(cfg)
+	require.NoError(t, err)
+	require.Len(t, checksums.Packages, 2)
+	require.Contains(t, checksums.Packages, "anakin")
+	require.Contains(t, checksums.Packages, "boba-fett")
+	for _, digest := range checksums.Packages {
+		require.Regexp(t, `^sha256:[0-9a-f]{64}$`, digest)
+	}
+
+	// Checksums must not depend on the order of objects in the config.
+	reordered := cfg
+	reordered.Bundles = append([]Bundle{}, cfg.Bundles...)
+	for i, j := 0, len(reordered.Bundles)-1; i < j; i, j = i+1, j-1 {
+		reordered.Bundles[i], reordered.Bundles[j] = reordered.Bundles[j], reordered.Bundles[i]
+	}
+	reorderedChecksums, err := ComputeChecksums(reordered)
+	require.NoError(t, err)
+	require.Equal(t, checksums, reorderedChecksums)
+
+	// Changing a package's content only changes that package's checksum.
+	modified := cfg
+	modified.Bundles = append([]Bundle{}, cfg.Bundles...)
+	for i := range modified.Bundles {
+		if modified.Bundles[i].Package == "anakin" {
+			modified.Bundles[i].Image = "modified"
+			break
+		}
+	}
+	modifiedChecksums, err := ComputeChecksums(modified)
+	require.NoError(t, err)
+	require.NotEqual(t, checksums.Packages["anakin"], modifiedChecksums.Packages["anakin"])
+	require.Equal(t, checksums.Packages["boba-fett"], modifiedChecksums.Packages["boba-fett"])
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(validDeclarativeConfigSpec{IncludeUnrecognized: true, IncludeDeprecations: true})
+	checksums, err := ComputeChecksums(cfg)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteChecksums(*checksums, &buf))
+	loaded, err := LoadChecksums(&buf)
+	require.NoError(t, err)
+	require.NoError(t, VerifyChecksums(cfg, *loaded))
+
+	type spec struct {
+		name     string
+		expected Checksums
+		errMsgs  []string
+	}
+	specs := []spec{
+		{
+			name: "Mismatch",
+			expected: Checksums{Packages: map[string]string{
+				"anakin":    "sha256:0000",
+				"boba-fett": checksums.Packages["boba-fett"],
+			}},
+			errMsgs: []string{`package "anakin": checksum mismatch`},
+		},
+		{
+			name: "MissingFromCatalog",
+			expected: Checksums{Packages: map[string]string{
+				"anakin":    checksums.Packages["anakin"],
+				"boba-fett": checksums.Packages["boba-fett"],
+				"cody":      "sha256:0000",
+			}},
+			errMsgs: []string{`package "cody": not found in catalog`},
+		},
+		{
+			name: "MissingFromChecksums",
+			expected: Checksums{Packages: map[string]string{
+				"anakin": checksums.Packages["anakin"],
+			}},
+			errMsgs: []string{`package "boba-fett": no expected checksum`},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			err := VerifyChecksums(cfg, s.expected)
+			require.Error(t, err)
+			for _, msg := range s.errMsgs {
+				require.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	// The same catalog, formatted, ordered, and split into files differently.
+	yamlFS := fstest.MapFS{
+		"foo/catalog.yaml": {Data: []byte(`---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.2.0
+  replaces: foo.v0.1.0
+  skips: [foo.v0.1.2, foo.v0.1.1]
+- name: foo.v0.1.0
+`)},
+		"foo/bundles.yaml": {Data: []byte(`---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: foo:v0.1.0
+properties:
+- type: olm.package
+  value: {packageName: foo, version: 0.1.0}
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: foo:v0.2.0
+properties:
+- type: olm.gvk
+  value: {group: test.foo, kind: Foo, version: v1}
+- type: olm.package
+  value: {version: 0.2.0, packageName: foo}
+`)},
+	}
+	jsonFS := fstest.MapFS{
+		"catalog.json": {Data: []byte(`{"schema": "olm.bundle", "package": "foo", "name": "foo.v0.2.0", "image": "foo:v0.2.0", "properties": [
+  {"type": "olm.package", "value": {"packageName": "foo", "version": "0.2.0"}},
+  {"type": "olm.gvk", "value": {"version": "v1", "kind": "Foo", "group": "test.foo"}}
+]}
+{"schema": "olm.bundle", "package": "foo", "name": "foo.v0.1.0", "image": "foo:v0.1.0", "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "0.1.0"}}]}
+{"schema": "olm.channel", "package": "foo", "name": "stable", "entries": [{"name": "foo.v0.1.0"}, {"name": "foo.v0.2.0", "replaces": "foo.v0.1.0", "skips": ["foo.v0.1.1", "foo.v0.1.2"]}]}
+{"schema": "olm.package", "name": "foo", "defaultChannel": "stable"}
+`)},
+	}
+	hash := func(t *testing.T, fsys fstest.MapFS) string {
+		cfg, err := LoadFS(context.Background(), fsys)
+		require.NoError(t, err)
+		h, err := Hash(*cfg)
+		require.NoError(t, err)
+		return h
+	}
+
+	h := hash(t, yamlFS)
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, h)
+	require.Equal(t, h, hash(t, jsonFS))
+
+	// The hash of a catalog whose objects all belong to packages is the
+	// digest of the checksums of its packages, as registries report it.
+	cfg, err := LoadFS(context.Background(), yamlFS)
+	require.NoError(t, err)
+	checksums, err := ComputeChecksums(*cfg)
+	require.NoError(t, err)
+	require.Equal(t, h, DigestChecksums(checksums.Packages))
+
+	// Objects that do not belong to a package are covered as well.
+	cfg.Others = append(cfg.Others, Meta{Schema: "custom", Blob: []byte(`{"schema":"custom"}`)})
+	withOthers, err := Hash(*cfg)
+	require.NoError(t, err)
+	require.NotEqual(t, h, withOthers)
+
+	// Any change to the content changes the hash.
+	jsonFS["catalog.json"].Data = bytes.Replace(jsonFS["catalog.json"].Data, []byte(`"image": "foo:v0.1.0"`), []byte(`"image": "foo:v0.1.0-rebuilt"`), 1)
+	require.NotEqual(t, h, hash(t, jsonFS))
+}
@@ -0,0 +1,69 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// ConvertBundleObjectsToCSVMetadata replaces the olm.bundle.object properties
+// of b with an olm.csv.metadata property that holds the metadata of the CSV
+// among them, which shrinks the bundle by the size of its manifests. It
+// reports whether b was converted: bundles without an image or a CSV, and
+// bundles that already have an olm.csv.metadata property, are left as is.
+//
+// The conversion is validated by expanding the new property into a CSV, as
+// registries do to serve the bundle, and checking that the expanded CSV has
+// the metadata of the original one.
+func ConvertBundleObjectsToCSVMetadata(b *Bundle) (bool, error) {
+	if b.Image == "" || b.CsvJSON == "" {
+		return false, nil
+	}
+	for _, p := range b.Properties {
+		if p.Type == property.TypeCSVMetadata {
+			return false, nil
+		}
+	}
+
+	var csv v1alpha1.ClusterServiceVersion
+	if err := json.Unmarshal([]byte(b.CsvJSON), &csv); err != nil {
+		return false, fmt.Errorf("bundle %q: parse CSV: %v", b.Name, err)
+	}
+	metadata := property.MustBuildCSVMetadata(csv)
+	if err := validateCSVMetadata(csv, metadata); err != nil {
+		return false, fmt.Errorf("bundle %q: %v", b.Name, err)
+	}
+
+	props := make([]property.Property, 0, len(b.Properties))
+	for _, p := range b.Properties {
+		if p.Type != property.TypeBundleObject {
+			props = append(props, p)
+		}
+	}
+	b.Properties = append(props, metadata)
+	return true, nil
+}
+
+// validateCSVMetadata checks that the CSV expanded from the metadata
+// property has the metadata of csv.
+func validateCSVMetadata(csv v1alpha1.ClusterServiceVersion, metadata property.Property) error {
+	props, err := property.Parse([]property.Property{metadata})
+	if err != nil {
+		return err
+	}
+	want, err := json.Marshal(property.NewCSVMetadata(csv))
+	if err != nil {
+		return err
+	}
+	got, err := json.Marshal(property.NewCSVMetadata(props.CSVMetadatas[0].ToCSV()))
+	if err != nil {
+		return err
+	}
+	if string(got) != string(want) {
+		return fmt.Errorf("%s property does not round-trip: expanded to %s, expected %s", property.TypeCSVMetadata, got, want)
+	}
+	return nil
+}
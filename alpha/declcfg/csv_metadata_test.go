@@ -0,0 +1,81 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestConvertBundleObjectsToCSVMetadata(t *testing.T) {
+	const (
+		csv = `{"kind":"ClusterServiceVersion","apiVersion":"operators.coreos.com/v1alpha1","metadata":{"name":"foo.v0.1.0","annotations":{"capabilities":"Basic Install"}},"spec":{"displayName":"Foo Operator","keywords":["foo"]}}`
+		crd = `{"kind":"CustomResourceDefinition","apiVersion":"apiextensions.k8s.io/v1","metadata":{"name":"foos.test.foo"}}`
+	)
+	newBundle := func(props ...property.Property) Bundle {
+		return Bundle{
+			Schema:     SchemaBundle,
+			Package:    "foo",
+			Name:       "foo.v0.1.0",
+			Image:      "test.registry/foo-operator/foo-bundle:v0.1.0",
+			Properties: append([]property.Property{property.MustBuildPackage("foo", "0.1.0")}, props...),
+			CsvJSON:    csv,
+		}
+	}
+	metadata := property.MustBuild(&property.CSVMetadata{
+		Annotations: map[string]string{"capabilities": "Basic Install"},
+		DisplayName: "Foo Operator",
+		Keywords:    []string{"foo"},
+	})
+
+	type spec struct {
+		name          string
+		bundle        Bundle
+		wantConverted bool
+		wantProps     []property.Property
+		wantErr       string
+	}
+	for _, s := range []spec{
+		{
+			name:          "Converted",
+			bundle:        newBundle(property.MustBuildBundleObject([]byte(csv)), property.MustBuildBundleObject([]byte(crd))),
+			wantConverted: true,
+			wantProps:     []property.Property{property.MustBuildPackage("foo", "0.1.0"), metadata},
+		},
+		{
+			name:      "AlreadyConverted",
+			bundle:    newBundle(metadata),
+			wantProps: []property.Property{property.MustBuildPackage("foo", "0.1.0"), metadata},
+		},
+		{
+			name: "NoCSV",
+			bundle: func() Bundle {
+				b := newBundle()
+				b.CsvJSON = ""
+				return b
+			}(),
+			wantProps: []property.Property{property.MustBuildPackage("foo", "0.1.0")},
+		},
+		{
+			name: "InvalidCSV",
+			bundle: func() Bundle {
+				b := newBundle(property.MustBuildBundleObject([]byte(csv)))
+				b.CsvJSON = "{"
+				return b
+			}(),
+			wantErr: `bundle "foo.v0.1.0": parse CSV`,
+		},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			converted, err := ConvertBundleObjectsToCSVMetadata(&s.bundle)
+			if s.wantErr != "" {
+				require.ErrorContains(t, err, s.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, s.wantConverted, converted)
+			require.Equal(t, s.wantProps, s.bundle.Properties)
+		})
+	}
+}
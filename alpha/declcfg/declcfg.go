@@ -20,14 +20,17 @@ const (
 	SchemaChannel     = "olm.channel"
 	SchemaBundle      = "olm.bundle"
 	SchemaDeprecation = "olm.deprecations"
+
+	SchemaPackageDocumentation = "olm.package.documentation"
 )
 
 type DeclarativeConfig struct {
-	Packages     []Package
-	Channels     []Channel
-	Bundles      []Bundle
-	Deprecations []Deprecation
-	Others       []Meta
+	Packages       []Package
+	Channels       []Channel
+	Bundles        []Bundle
+	Deprecations   []Deprecation
+	Documentations []PackageDocumentation
+	Others         []Meta
 }
 
 type Package struct {
@@ -103,6 +106,16 @@ type DeprecationEntry struct {
 	Message   string                 `json:"message"`
 }
 
+// PackageDocumentation provides documentation for a package, either as inline
+// markdown Content or as a URL pointing to it. Exactly one of Content and URL
+// must be set.
+type PackageDocumentation struct {
+	Schema  string `json:"schema"`
+	Package string `json:"package"`
+	Content string `json:"content,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
 type PackageScopedReference struct {
 	Schema string `json:"schema"`
 	Name   string `json:"name,omitempty"`
@@ -206,4 +219,5 @@ func (destination *DeclarativeConfig) Merge(src *DeclarativeConfig) {
 	destination.Bundles = append(destination.Bundles, src.Bundles...)
 	destination.Others = append(destination.Others, src.Others...)
 	destination.Deprecations = append(destination.Deprecations, src.Deprecations...)
+	destination.Documentations = append(destination.Documentations, src.Documentations...)
 }
@@ -57,6 +57,14 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 			return nil, fmt.Errorf("package %q has duplicate channel %q", c.Package, c.Name)
 		}
 
+		props, err := property.Parse(c.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("parse properties for package %q channel %q: %v", c.Package, c.Name, err)
+		}
+		if len(props.ChannelPriorities) > 1 {
+			return nil, fmt.Errorf("package %q channel %q must have at most 1 %q property, found %d", c.Package, c.Name, property.TypeChannelPriority, len(props.ChannelPriorities))
+		}
+
 		mch := &model.Channel{
 			Package: mpkg,
 			Name:    c.Name,
@@ -238,6 +246,20 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 		}
 	}
 
+	for i, doc := range cfg.Documentations {
+		if doc.Package == "" {
+			return nil, fmt.Errorf("package name must be set for package documentation item %v", i)
+		}
+		mpkg, ok := mpkgs[doc.Package]
+		if !ok {
+			return nil, fmt.Errorf("cannot apply documentation to an unknown package %q", doc.Package)
+		}
+		if mpkg.Documentation != nil {
+			return nil, fmt.Errorf("expected a maximum of one documentation per package: %q", doc.Package)
+		}
+		mpkg.Documentation = &model.Documentation{Content: doc.Content, URL: doc.URL}
+	}
+
 	if err := mpkgs.Validate(); err != nil {
 		return nil, err
 	}
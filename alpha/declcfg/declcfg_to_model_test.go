@@ -205,6 +205,42 @@ func TestConvertToModel(t *testing.T) {
 				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
 			},
 		},
+		{
+			name:      "Error/ChannelInvalidPriority",
+			assertion: hasError(`parse properties for package "foo" channel "alpha": parse property[0] of type "olm.channel.priority": json: cannot unmarshal string into Go struct field ChannelPriority.priority of type int`),
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{addChannelProperties(
+					newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"}),
+					[]property.Property{{Type: property.TypeChannelPriority, Value: json.RawMessage(`{"priority":"high"}`)}},
+				)},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
+		{
+			name:      "Error/ChannelMultiplePriorities",
+			assertion: hasError(`package "foo" channel "alpha" must have at most 1 "olm.channel.priority" property, found 2`),
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{addChannelProperties(
+					newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"}),
+					[]property.Property{property.MustBuildChannelPriorityProperty(1), property.MustBuildChannelPriorityProperty(2)},
+				)},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
+		{
+			name:      "Success/ChannelPriority",
+			assertion: require.NoError,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{addChannelProperties(
+					newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"}),
+					[]property.Property{property.MustBuildChannelPriorityProperty(1)},
+				)},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+		},
 		{
 			name:      "Error/DuplicatePackage",
 			assertion: hasError(`duplicate package "foo"`),
@@ -442,6 +478,43 @@ func TestConvertToModel(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "Error/Documentation/UnknownPackage",
+			assertion: hasError(`cannot apply documentation to an unknown package "bar"`),
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"})},
+				Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+				Documentations: []PackageDocumentation{
+					{Schema: SchemaPackageDocumentation, Package: "bar", Content: "# bar"},
+				},
+			},
+		},
+		{
+			name:      "Error/Documentation/Duplicate",
+			assertion: hasError(`expected a maximum of one documentation per package: "foo"`),
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"})},
+				Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+				Documentations: []PackageDocumentation{
+					{Schema: SchemaPackageDocumentation, Package: "foo", Content: "# foo"},
+					{Schema: SchemaPackageDocumentation, Package: "foo", URL: "https://example.com/foo"},
+				},
+			},
+		},
+		{
+			name:      "Success/Documentation",
+			assertion: require.NoError,
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"})},
+				Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+				Documentations: []PackageDocumentation{
+					{Schema: SchemaPackageDocumentation, Package: "foo", URL: "https://example.com/foo/README.md"},
+				},
+			},
+		},
 	}
 
 	for _, s := range specs {
@@ -502,6 +575,24 @@ func TestConvertToModelRoundtrip(t *testing.T) {
 	assert.Len(t, actual.Others, 0, "expected unrecognized schemas not to make the roundtrip")
 }
 
+func TestConvertToModelDocumentationRoundtrip(t *testing.T) {
+	expected := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+		Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.1.0"})},
+		Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+		Documentations: []PackageDocumentation{
+			{Schema: SchemaPackageDocumentation, Package: "foo", Content: "# foo\n\nThe foo operator."},
+		},
+	}
+
+	m, err := ConvertToModel(expected)
+	require.NoError(t, err)
+	require.Equal(t, &model.Documentation{Content: "# foo\n\nThe foo operator."}, m["foo"].Documentation)
+
+	actual := ConvertFromModel(m)
+	assert.Equal(t, expected.Documentations, actual.Documentations)
+}
+
 func hasError(expectedError string) require.ErrorAssertionFunc {
 	return func(t require.TestingT, actualError error, args ...interface{}) {
 		if stdt, ok := t.(*testing.T); ok {
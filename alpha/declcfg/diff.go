@@ -0,0 +1,147 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ObjectKey identifies an object in a declarative config. Objects that do
+// not have a name, e.g. deprecations, are identified by schema and package
+// only.
+type ObjectKey struct {
+	Schema  string `json:"schema"`
+	Package string `json:"package,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+func (k ObjectKey) String() string {
+	switch {
+	case k.Name != "" && k.Package != "":
+		return fmt.Sprintf("%s %s/%s", k.Schema, k.Package, k.Name)
+	case k.Name != "":
+		return fmt.Sprintf("%s %s", k.Schema, k.Name)
+	default:
+		return fmt.Sprintf("%s %s", k.Schema, k.Package)
+	}
+}
+
+// Diff compares two declarative configs. It returns a declarative config
+// containing the objects of newCfg that are not in oldCfg or that differ from
+// their counterpart in oldCfg, and the keys of the objects of oldCfg that are
+// not in newCfg.
+//
+// Objects are matched by ObjectKey and compared by their JSON encoding. If
+// several objects share a key, e.g. objects of an unknown schema without a
+// name, they are compared as a group.
+func Diff(oldCfg, newCfg DeclarativeConfig) (*DeclarativeConfig, []ObjectKey, error) {
+	var (
+		out     DeclarativeConfig
+		removed []ObjectKey
+		errs    []error
+	)
+	collect := func(r []ObjectKey, err error) {
+		removed = append(removed, r...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var r []ObjectKey
+	var err error
+	out.Packages, r, err = diffObjects(oldCfg.Packages, newCfg.Packages, func(p Package) ObjectKey {
+		return ObjectKey{Schema: SchemaPackage, Name: p.Name}
+	})
+	collect(r, err)
+	out.Channels, r, err = diffObjects(oldCfg.Channels, newCfg.Channels, func(c Channel) ObjectKey {
+		return ObjectKey{Schema: SchemaChannel, Package: c.Package, Name: c.Name}
+	})
+	collect(r, err)
+	out.Bundles, r, err = diffObjects(oldCfg.Bundles, newCfg.Bundles, func(b Bundle) ObjectKey {
+		return ObjectKey{Schema: SchemaBundle, Package: b.Package, Name: b.Name}
+	})
+	collect(r, err)
+	out.Deprecations, r, err = diffObjects(oldCfg.Deprecations, newCfg.Deprecations, func(d Deprecation) ObjectKey {
+		return ObjectKey{Schema: SchemaDeprecation, Package: d.Package}
+	})
+	collect(r, err)
+	out.Documentations, r, err = diffObjects(oldCfg.Documentations, newCfg.Documentations, func(d PackageDocumentation) ObjectKey {
+		return ObjectKey{Schema: SchemaPackageDocumentation, Package: d.Package}
+	})
+	collect(r, err)
+	out.Others, r, err = diffObjects(oldCfg.Others, newCfg.Others, func(m Meta) ObjectKey {
+		return ObjectKey{Schema: m.Schema, Package: m.Package, Name: m.Name}
+	})
+	collect(r, err)
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	// Sort by package, treating olm.package objects as part of the package
+	// they define.
+	packageOf := func(k ObjectKey) string {
+		if k.Schema == SchemaPackage {
+			return k.Name
+		}
+		return k.Package
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		if pi, pj := packageOf(removed[i]), packageOf(removed[j]); pi != pj {
+			return pi < pj
+		}
+		if removed[i].Schema != removed[j].Schema {
+			return removed[i].Schema < removed[j].Schema
+		}
+		return removed[i].Name < removed[j].Name
+	})
+	return &out, removed, nil
+}
+
+// diffObjects returns the objects in newObjs whose key is not in oldObjs or
+// whose group of objects with the same key differs from that in oldObjs,
+// along with the keys in oldObjs that are not in newObjs. Objects are
+// returned in the order of newObjs.
+func diffObjects[T any](oldObjs, newObjs []T, key func(T) ObjectKey) ([]T, []ObjectKey, error) {
+	oldGroups, err := encodeGroups(oldObjs, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	newGroups, err := encodeGroups(newObjs, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changed []T
+	for _, o := range newObjs {
+		k := key(o)
+		if old, ok := oldGroups[k]; !ok || !slices.Equal(old, newGroups[k]) {
+			changed = append(changed, o)
+		}
+	}
+	var removed []ObjectKey
+	for k := range oldGroups {
+		if _, ok := newGroups[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	return changed, removed, nil
+}
+
+// encodeGroups returns the sorted JSON encodings of objs, grouped by key.
+func encodeGroups[T any](objs []T, key func(T) ObjectKey) (map[ObjectKey][]string, error) {
+	groups := map[ObjectKey][]string{}
+	for _, o := range objs {
+		k := key(o)
+		data, err := json.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %v", k, err)
+		}
+		groups[k] = append(groups[k], string(data))
+	}
+	for _, g := range groups {
+		sort.Strings(g)
+	}
+	return groups, nil
+}
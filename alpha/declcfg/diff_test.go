@@ -0,0 +1,76 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldCfg := DeclarativeConfig{
+		Packages: []Package{
+			{Schema: SchemaPackage, Name: "foo", DefaultChannel: "stable"},
+			{Schema: SchemaPackage, Name: "bar", DefaultChannel: "stable"},
+		},
+		Channels: []Channel{
+			{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{{Name: "foo.v1"}}},
+			{Schema: SchemaChannel, Package: "bar", Name: "stable", Entries: []ChannelEntry{{Name: "bar.v1"}}},
+		},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v1", Image: "foo:v1"},
+			{Schema: SchemaBundle, Package: "bar", Name: "bar.v1", Image: "bar:v1"},
+		},
+		Others: []Meta{
+			{Schema: "custom", Package: "foo", Blob: json.RawMessage(`{"schema":"custom","package":"foo","value":1}`)},
+			{Schema: "custom", Package: "foo", Blob: json.RawMessage(`{"schema":"custom","package":"foo","value":2}`)},
+		},
+	}
+	newCfg := DeclarativeConfig{
+		Packages: []Package{
+			{Schema: SchemaPackage, Name: "foo", DefaultChannel: "stable"},
+		},
+		Channels: []Channel{
+			{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{{Name: "foo.v1"}, {Name: "foo.v2", Replaces: "foo.v1"}}},
+		},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v1", Image: "foo:v1"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v2", Image: "foo:v2"},
+		},
+		Deprecations: []Deprecation{
+			{Schema: SchemaDeprecation, Package: "foo", Entries: []DeprecationEntry{
+				{Reference: PackageScopedReference{Schema: SchemaBundle, Name: "foo.v1"}, Message: "foo.v1 is deprecated"},
+			}},
+		},
+		Others: []Meta{
+			// The group of objects with this key is unchanged, despite the
+			// different order and formatting.
+			{Schema: "custom", Package: "foo", Blob: json.RawMessage(`{"schema": "custom", "package": "foo", "value": 2}`)},
+			{Schema: "custom", Package: "foo", Blob: json.RawMessage(`{"schema":"custom","package":"foo","value":1}`)},
+		},
+	}
+
+	changed, removed, err := Diff(oldCfg, newCfg)
+	require.NoError(t, err)
+	require.Equal(t, &DeclarativeConfig{
+		Channels: []Channel{
+			{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{{Name: "foo.v1"}, {Name: "foo.v2", Replaces: "foo.v1"}}},
+		},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v2", Image: "foo:v2"},
+		},
+		Deprecations: newCfg.Deprecations,
+	}, changed)
+	require.Equal(t, []ObjectKey{
+		{Schema: SchemaBundle, Package: "bar", Name: "bar.v1"},
+		{Schema: SchemaChannel, Package: "bar", Name: "stable"},
+		{Schema: SchemaPackage, Name: "bar"},
+	}, removed)
+
+	t.Run("Identical", func(t *testing.T) {
+		changed, removed, err := Diff(newCfg, newCfg)
+		require.NoError(t, err)
+		require.Equal(t, &DeclarativeConfig{}, changed)
+		require.Empty(t, removed)
+	})
+}
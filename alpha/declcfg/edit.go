@@ -0,0 +1,334 @@
+package declcfg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// EditableYAML holds the YAML documents of a declarative config file, so
+// that edits of the declarative config loaded from the file can be written
+// back without reformatting the parts of the file that were not edited.
+//
+// Objects are matched to the documents they were loaded from by schema,
+// package and name. Fields of a matched object keep their order, comments and
+// scalar styles, changed values are replaced in place, and added fields are
+// appended. Sequence items are matched by name when every item has one, and
+// by index otherwise. Documents of objects that are no longer in the
+// declarative config are dropped, and new objects are appended to the end of
+// the file in the format of WriteYAML.
+type EditableYAML struct {
+	docs          []*yaml.Node
+	explicitStart bool
+}
+
+// LoadEditableYAML reads a YAML declarative config file for a load, edit and
+// save workflow. It returns the declarative config in the file, and an
+// EditableYAML to write the edited declarative config with.
+func LoadEditableYAML(r io.Reader) (*DeclarativeConfig, *EditableYAML, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := LoadReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := &EditableYAML{explicitStart: hasExplicitStart(data)}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, fmt.Errorf("parse yaml: %v", err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		e.docs = append(e.docs, &doc)
+	}
+	return cfg, e, nil
+}
+
+// Write writes cfg as YAML, reusing the formatting of the documents that its
+// objects were loaded from.
+func (e *EditableYAML) Write(cfg DeclarativeConfig, w io.Writer) error {
+	objs, err := editObjects(cfg)
+	if err != nil {
+		return err
+	}
+	byKey := map[editKey][]*yaml.Node{}
+	for _, obj := range objs {
+		byKey[obj.key] = append(byKey[obj.key], obj.node)
+	}
+
+	var out []*yaml.Node
+	for _, doc := range e.docs {
+		key, err := editKeyForNode(doc.Content[0])
+		if err != nil {
+			return err
+		}
+		updated := byKey[key]
+		if len(updated) == 0 {
+			continue
+		}
+		byKey[key] = updated[1:]
+		// The loaded documents are merged into copies, so that they are
+		// unchanged for later writes.
+		merged := *doc
+		merged.Content = []*yaml.Node{mergeNode(copyNode(doc.Content[0]), updated[0])}
+		out = append(out, &merged)
+	}
+	for _, obj := range objs {
+		if updated := byKey[obj.key]; len(updated) > 0 && updated[0] == obj.node {
+			byKey[obj.key] = updated[1:]
+			out = append(out, &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{obj.node}})
+		}
+	}
+
+	for i, doc := range out {
+		if i > 0 || e.explicitStart {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		enc.CompactSeqIndent()
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type editKey struct {
+	schema, pkg, name string
+}
+
+type editObject struct {
+	key  editKey
+	node *yaml.Node
+}
+
+// editObjects returns the objects of cfg as YAML nodes, in the order of the
+// fields of DeclarativeConfig.
+func editObjects(cfg DeclarativeConfig) ([]editObject, error) {
+	var vs []interface{}
+	for _, v := range cfg.Packages {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Channels {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Bundles {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Deprecations {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Documentations {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Others {
+		vs = append(vs, v)
+	}
+
+	objs := make([]editObject, 0, len(vs))
+	for _, v := range vs {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		key, err := editKeyForJSON(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			return nil, err
+		}
+		node := doc.Content[0]
+		clearStyle(node)
+		objs = append(objs, editObject{key: key, node: node})
+	}
+	return objs, nil
+}
+
+func editKeyForNode(n *yaml.Node) (editKey, error) {
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return editKey{}, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return editKey{}, err
+	}
+	return editKeyForJSON(data)
+}
+
+func editKeyForJSON(data []byte) (editKey, error) {
+	var m Meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return editKey{}, err
+	}
+	return editKey{schema: m.Schema, pkg: m.Package, name: m.Name}, nil
+}
+
+// clearStyle resets the styles of nodes decoded from JSON, so that they are
+// encoded in block style, like the output of WriteYAML.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+// mergeNode returns orig updated to the value of updated, keeping the
+// comments, ordering and styles of orig where the values are the same.
+func mergeNode(orig, updated *yaml.Node) *yaml.Node {
+	if orig.Kind != updated.Kind {
+		copyComments(updated, orig)
+		return updated
+	}
+	switch orig.Kind {
+	case yaml.ScalarNode:
+		if orig.ShortTag() != updated.ShortTag() {
+			copyComments(updated, orig)
+			return updated
+		}
+		if orig.Value != updated.Value {
+			orig.Value = updated.Value
+			if orig.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 && !strings.Contains(orig.Value, "\n") {
+				orig.Style = 0
+			}
+		}
+		return orig
+	case yaml.MappingNode:
+		updatedValues := map[string]*yaml.Node{}
+		for i := 0; i+1 < len(updated.Content); i += 2 {
+			updatedValues[updated.Content[i].Value] = updated.Content[i+1]
+		}
+		content := make([]*yaml.Node, 0, len(updated.Content))
+		seen := map[string]struct{}{}
+		for i := 0; i+1 < len(orig.Content); i += 2 {
+			k := orig.Content[i]
+			v, ok := updatedValues[k.Value]
+			if !ok {
+				continue
+			}
+			seen[k.Value] = struct{}{}
+			content = append(content, k, mergeNode(orig.Content[i+1], v))
+		}
+		for i := 0; i+1 < len(updated.Content); i += 2 {
+			if _, ok := seen[updated.Content[i].Value]; !ok {
+				content = append(content, updated.Content[i], updated.Content[i+1])
+			}
+		}
+		orig.Content = content
+		return orig
+	case yaml.SequenceNode:
+		origByName, byName := itemsByName(orig.Content)
+		if _, ok := itemsByName(updated.Content); !ok {
+			byName = false
+		}
+		content := make([]*yaml.Node, 0, len(updated.Content))
+		for i, item := range updated.Content {
+			var match *yaml.Node
+			if byName {
+				name, _ := itemName(item)
+				match = origByName[name]
+			} else if i < len(orig.Content) {
+				match = orig.Content[i]
+			}
+			if match == nil {
+				content = append(content, item)
+				continue
+			}
+			content = append(content, mergeNode(match, item))
+		}
+		orig.Content = content
+		return orig
+	}
+	return updated
+}
+
+// itemsByName indexes sequence items by their name field. It returns false
+// if any item does not have a name, or if names are not unique.
+func itemsByName(items []*yaml.Node) (map[string]*yaml.Node, bool) {
+	byName := make(map[string]*yaml.Node, len(items))
+	for _, item := range items {
+		name, ok := itemName(item)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := byName[name]; ok {
+			return nil, false
+		}
+		byName[name] = item
+	}
+	return byName, true
+}
+
+func itemName(n *yaml.Node) (string, bool) {
+	if n.Kind != yaml.MappingNode {
+		return "", false
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "name" && n.Content[i+1].Kind == yaml.ScalarNode {
+			return n.Content[i+1].Value, true
+		}
+	}
+	return "", false
+}
+
+// copyNode returns a deep copy of n. Aliases of the copy still point to the
+// anchored nodes of n, which are only read.
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	if n.Content != nil {
+		c.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			c.Content[i] = copyNode(child)
+		}
+	}
+	return &c
+}
+
+func copyComments(dst, src *yaml.Node) {
+	dst.HeadComment = src.HeadComment
+	dst.LineComment = src.LineComment
+	dst.FootComment = src.FootComment
+}
+
+// hasExplicitStart reports whether the first document in data starts with a
+// document start marker, as in the output of WriteYAML.
+func hasExplicitStart(data []byte) bool {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "---")
+	}
+	return false
+}
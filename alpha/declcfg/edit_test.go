@@ -0,0 +1,202 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+const editableInput = `---
+# The foo operator.
+schema: olm.package
+name: foo # the package name
+defaultChannel: stable
+description: |
+  Foo manages foos.
+  It is very good at it.
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+# the first release
+- name: foo.v0.1.0
+- name: foo.v0.2.0 # current
+  replaces: foo.v0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: 'quay.io/foo/bundle:v0.1.0'
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: "0.1.0"
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: 'quay.io/foo/bundle:v0.2.0'
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: "0.2.0"
+`
+
+func TestEditableYAML(t *testing.T) {
+	type spec struct {
+		name     string
+		edit     func(t *testing.T, cfg *DeclarativeConfig)
+		expected string
+	}
+	specs := []spec{
+		{
+			name:     "Unchanged",
+			edit:     func(*testing.T, *DeclarativeConfig) {},
+			expected: editableInput,
+		},
+		{
+			name: "Edited",
+			edit: func(t *testing.T, cfg *DeclarativeConfig) {
+				cfg.Packages[0].DefaultChannel = "fast"
+				cfg.Channels[0].Entries = append(cfg.Channels[0].Entries, ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"})
+				cfg.Bundles = cfg.Bundles[1:]
+				cfg.Bundles[0].Image = "quay.io/foo/bundle@sha256:0123"
+				cfg.Bundles = append(cfg.Bundles, Bundle{
+					Schema:     SchemaBundle,
+					Package:    "foo",
+					Name:       "foo.v0.3.0",
+					Image:      "quay.io/foo/bundle:v0.3.0",
+					Properties: []property.Property{property.MustBuildPackage("foo", "0.3.0")},
+				})
+			},
+			expected: `---
+# The foo operator.
+schema: olm.package
+name: foo # the package name
+defaultChannel: fast
+description: |
+  Foo manages foos.
+  It is very good at it.
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+# the first release
+- name: foo.v0.1.0
+- name: foo.v0.2.0 # current
+  replaces: foo.v0.1.0
+- name: foo.v0.3.0
+  replaces: foo.v0.2.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: 'quay.io/foo/bundle@sha256:0123'
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: "0.2.0"
+---
+schema: olm.bundle
+name: foo.v0.3.0
+package: foo
+image: quay.io/foo/bundle:v0.3.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.3.0
+`,
+		},
+		{
+			name: "ReorderedEntries",
+			edit: func(t *testing.T, cfg *DeclarativeConfig) {
+				entries := cfg.Channels[0].Entries
+				cfg.Channels[0].Entries = []ChannelEntry{entries[1], entries[0]}
+			},
+			expected: strings.Replace(editableInput, `# the first release
+- name: foo.v0.1.0
+- name: foo.v0.2.0 # current
+  replaces: foo.v0.1.0
+`, `- name: foo.v0.2.0 # current
+  replaces: foo.v0.1.0
+# the first release
+- name: foo.v0.1.0
+`, 1),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, e, err := LoadEditableYAML(strings.NewReader(editableInput))
+			require.NoError(t, err)
+			s.edit(t, cfg)
+
+			var buf bytes.Buffer
+			require.NoError(t, e.Write(*cfg, &buf))
+			require.Equal(t, s.expected, buf.String())
+
+			// The output must load to the edited declarative config.
+			actual, err := LoadReader(&buf)
+			require.NoError(t, err)
+			expectedJSON, actualJSON := bytes.Buffer{}, bytes.Buffer{}
+			require.NoError(t, WriteJSON(*cfg, &expectedJSON))
+			require.NoError(t, WriteJSON(*actual, &actualJSON))
+			require.JSONEq(t, jsonArray(t, expectedJSON.Bytes()), jsonArray(t, actualJSON.Bytes()))
+		})
+	}
+}
+
+func TestEditableYAML_WriteTwice(t *testing.T) {
+	cfg, e, err := LoadEditableYAML(strings.NewReader(editableInput))
+	require.NoError(t, err)
+	orig, err := LoadReader(strings.NewReader(editableInput))
+	require.NoError(t, err)
+
+	cfg.Packages[0].DefaultChannel = "fast"
+	cfg.Channels[0].Entries = cfg.Channels[0].Entries[:1]
+	require.NoError(t, e.Write(*cfg, io.Discard))
+
+	// Writing edits must not change the documents that later writes reuse.
+	var buf bytes.Buffer
+	require.NoError(t, e.Write(*orig, &buf))
+	require.Equal(t, editableInput, buf.String())
+}
+
+func TestEditableYAML_StringValues(t *testing.T) {
+	cfg, e, err := LoadEditableYAML(strings.NewReader(editableInput))
+	require.NoError(t, err)
+	cfg.Packages[0].Description = "true"
+	cfg.Bundles[0].Image = "123"
+
+	var buf bytes.Buffer
+	require.NoError(t, e.Write(*cfg, &buf))
+	actual, err := LoadReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, "true", actual.Packages[0].Description)
+	require.Equal(t, "123", actual.Bundles[0].Image)
+}
+
+// jsonArray joins a stream of JSON objects into a JSON array.
+func jsonArray(t *testing.T, stream []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(stream))
+	var objs []json.RawMessage
+	for dec.More() {
+		var obj json.RawMessage
+		require.NoError(t, dec.Decode(&obj))
+		objs = append(objs, obj)
+	}
+	data, err := json.Marshal(objs)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,314 @@
+// Package filter selects the bundles of a declarative config by their
+// package and properties, and prunes the config down to them.
+//
+// A filter is made of terms, all of which a bundle must match to be kept.
+// Each term has the form key=value, or key!=value to keep the bundles that
+// do not match it:
+//
+//	package=<name>                 the bundle is of the package
+//	version=<range>                the package version of the bundle is in the
+//	                               semver range, e.g. ">=1.0.0 <2.0.0"
+//	provides=<group>/<version>/<kind>
+//	                               the bundle provides the API (olm.gvk)
+//	requires=<group>/<version>/<kind>
+//	                               the bundle requires the API (olm.gvk.required)
+//	property=<type>                the bundle has a property of the type
+//	property=<type>=<value>        the bundle has a property of the type whose
+//	                               value is equal to the JSON value, or is the
+//	                               string value
+//	annotation=<key>[=<value>]     the CSV of the bundle has the annotation
+//	infrastructure-feature=<name>  the CSV of the bundle declares support for
+//	                               the infrastructure feature, e.g. disconnected
+//
+// The CSV annotations of a bundle are read from its olm.csv.metadata
+// property, or else from the CSV among its olm.bundle.object properties.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+const (
+	// featuresAnnotationPrefix prefixes the CSV annotations that declare
+	// support for an infrastructure feature with the value "true".
+	featuresAnnotationPrefix = "features.operators.openshift.io/"
+	// legacyFeaturesAnnotation is the CSV annotation that lists the
+	// supported infrastructure features as a JSON array.
+	legacyFeaturesAnnotation = "operators.openshift.io/infrastructure-features"
+)
+
+// Filter selects bundles that match all of its terms.
+type Filter struct {
+	terms []term
+}
+
+type term struct {
+	expr   string
+	negate bool
+	match  func(*bundle) (bool, error)
+}
+
+// bundle is a bundle with its parsed properties, which terms match.
+type bundle struct {
+	declcfg.Bundle
+	props       *property.Properties
+	annotations map[string]string
+}
+
+// Parse parses a filter from terms. A filter without terms matches every
+// bundle.
+func Parse(terms ...string) (*Filter, error) {
+	f := &Filter{}
+	for _, expr := range terms {
+		t, err := parseTerm(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter term %q: %v", expr, err)
+		}
+		f.terms = append(f.terms, *t)
+	}
+	return f, nil
+}
+
+func parseTerm(expr string) (*term, error) {
+	key, value, ok := strings.Cut(expr, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected key=value or key!=value")
+	}
+	t := &term{expr: expr}
+	if k, ok := strings.CutSuffix(key, "!"); ok {
+		key, t.negate = k, true
+	}
+	key = strings.TrimSpace(key)
+	if value == "" {
+		return nil, fmt.Errorf("value must be set")
+	}
+
+	switch key {
+	case "package":
+		t.match = func(b *bundle) (bool, error) { return b.Package == value, nil }
+	case "version":
+		r, err := semver.ParseRange(value)
+		if err != nil {
+			return nil, err
+		}
+		t.match = func(b *bundle) (bool, error) {
+			if len(b.props.Packages) != 1 {
+				return false, fmt.Errorf("must have exactly 1 %q property, found %d", property.TypePackage, len(b.props.Packages))
+			}
+			v, err := semver.Parse(b.props.Packages[0].Version)
+			if err != nil {
+				return false, fmt.Errorf("invalid version %q: %v", b.props.Packages[0].Version, err)
+			}
+			return r(v), nil
+		}
+	case "provides":
+		gvk, err := parseGVK(value)
+		if err != nil {
+			return nil, err
+		}
+		t.match = func(b *bundle) (bool, error) {
+			return slices.Contains(b.props.GVKs, gvk), nil
+		}
+	case "requires":
+		gvk, err := parseGVK(value)
+		if err != nil {
+			return nil, err
+		}
+		t.match = func(b *bundle) (bool, error) {
+			return slices.Contains(b.props.GVKsRequired, property.GVKRequired(gvk)), nil
+		}
+	case "property":
+		typ, want, hasValue := strings.Cut(value, "=")
+		t.match = func(b *bundle) (bool, error) {
+			for _, p := range b.Properties {
+				if p.Type != typ {
+					continue
+				}
+				if !hasValue || jsonValueEqual(p.Value, want) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	case "annotation":
+		k, want, hasValue := strings.Cut(value, "=")
+		t.match = func(b *bundle) (bool, error) {
+			v, ok := b.annotations[k]
+			return ok && (!hasValue || v == want), nil
+		}
+	case "infrastructure-feature":
+		t.match = func(b *bundle) (bool, error) {
+			return hasInfrastructureFeature(b.annotations, value), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown key %q, expected one of (package|version|provides|requires|property|annotation|infrastructure-feature)", key)
+	}
+	return t, nil
+}
+
+// parseGVK parses a group/version/kind. The group of core APIs is empty,
+// e.g. "/v1/ConfigMap".
+func parseGVK(value string) (property.GVK, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return property.GVK{}, fmt.Errorf("expected <group>/<version>/<kind>")
+	}
+	return property.GVK{Group: parts[0], Version: parts[1], Kind: parts[2]}, nil
+}
+
+// jsonValueEqual returns whether value is equal to the JSON value want, or,
+// if it is a string, to want itself.
+func jsonValueEqual(value json.RawMessage, want string) bool {
+	var got interface{}
+	if err := json.Unmarshal(value, &got); err != nil {
+		return false
+	}
+	if s, ok := got.(string); ok && s == want {
+		return true
+	}
+	var wantValue interface{}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, wantValue)
+}
+
+func hasInfrastructureFeature(annotations map[string]string, feature string) bool {
+	for k, v := range annotations {
+		if name, ok := strings.CutPrefix(k, featuresAnnotationPrefix); ok && strings.EqualFold(name, feature) {
+			return v == "true"
+		}
+	}
+	var legacy []string
+	if err := json.Unmarshal([]byte(annotations[legacyFeaturesAnnotation]), &legacy); err != nil {
+		return false
+	}
+	return slices.ContainsFunc(legacy, func(f string) bool { return strings.EqualFold(f, feature) })
+}
+
+// Match returns whether b matches all of the terms of f.
+func (f *Filter) Match(b declcfg.Bundle) (bool, error) {
+	if len(f.terms) == 0 {
+		return true, nil
+	}
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return false, fmt.Errorf("bundle %q: parse properties: %v", b.Name, err)
+	}
+	pb := &bundle{Bundle: b, props: props}
+	if pb.annotations, err = annotations(b, props); err != nil {
+		return false, fmt.Errorf("bundle %q: %v", b.Name, err)
+	}
+	for _, t := range f.terms {
+		ok, err := t.match(pb)
+		if err != nil {
+			return false, fmt.Errorf("bundle %q: filter %q: %v", b.Name, t.expr, err)
+		}
+		if ok == t.negate {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// annotations returns the CSV annotations of b.
+func annotations(b declcfg.Bundle, props *property.Properties) (map[string]string, error) {
+	if len(props.CSVMetadatas) > 0 {
+		return props.CSVMetadatas[0].Annotations, nil
+	}
+	if b.CsvJSON == "" {
+		return nil, nil
+	}
+	var csv struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(b.CsvJSON), &csv); err != nil {
+		return nil, fmt.Errorf("parse CSV: %v", err)
+	}
+	return csv.Metadata.Annotations, nil
+}
+
+// Apply removes the bundles that do not match f from cfg, and prunes what
+// is left without them: channel entries of removed bundles, channels without
+// entries, deprecation entries of removed bundles and channels, and packages
+// without bundles, along with all of their objects. It fails if the default
+// channel of a package that is kept is removed.
+func (f *Filter) Apply(cfg *declcfg.DeclarativeConfig) error {
+	var (
+		remove   []declcfg.Bundle
+		kept     = map[string]sets.Set[string]{}
+		packages = sets.New[string]()
+	)
+	for _, b := range cfg.Bundles {
+		ok, err := f.Match(b)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			remove = append(remove, b)
+			continue
+		}
+		if kept[b.Package] == nil {
+			kept[b.Package] = sets.New[string]()
+		}
+		kept[b.Package].Insert(b.Name)
+		packages.Insert(b.Package)
+	}
+	declcfg.RemoveBundles(cfg, remove)
+
+	channels := map[string]sets.Set[string]{}
+	for i := range cfg.Channels {
+		c := &cfg.Channels[i]
+		c.Entries = slices.DeleteFunc(c.Entries, func(e declcfg.ChannelEntry) bool { return !kept[c.Package].Has(e.Name) })
+		if len(c.Entries) == 0 {
+			continue
+		}
+		if channels[c.Package] == nil {
+			channels[c.Package] = sets.New[string]()
+		}
+		channels[c.Package].Insert(c.Name)
+	}
+	cfg.Channels = slices.DeleteFunc(cfg.Channels, func(c declcfg.Channel) bool { return len(c.Entries) == 0 })
+
+	cfg.Packages = slices.DeleteFunc(cfg.Packages, func(p declcfg.Package) bool { return !packages.Has(p.Name) })
+	for _, p := range cfg.Packages {
+		if p.DefaultChannel != "" && len(channels[p.Name]) > 0 && !channels[p.Name].Has(p.DefaultChannel) {
+			return fmt.Errorf("package %q: default channel %q has no bundles that match the filter", p.Name, p.DefaultChannel)
+		}
+	}
+
+	deprecations := cfg.Deprecations[:0]
+	for _, d := range cfg.Deprecations {
+		if !packages.Has(d.Package) {
+			continue
+		}
+		numEntries := len(d.Entries)
+		d.Entries = slices.DeleteFunc(d.Entries, func(e declcfg.DeprecationEntry) bool {
+			return e.Reference.Schema == declcfg.SchemaChannel && !channels[d.Package].Has(e.Reference.Name)
+		})
+		if len(d.Entries) > 0 || numEntries == 0 {
+			deprecations = append(deprecations, d)
+		}
+	}
+	cfg.Deprecations = deprecations
+	cfg.Others = slices.DeleteFunc(cfg.Others, func(m declcfg.Meta) bool {
+		pkg := m.Package
+		if m.Schema == declcfg.SchemaPackage {
+			pkg = m.Name
+		}
+		return pkg != "" && !packages.Has(pkg)
+	})
+	return nil
+}
@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func testBundle(pkg, version string, props ...property.Property) declcfg.Bundle {
+	return declcfg.Bundle{
+		Schema:     declcfg.SchemaBundle,
+		Package:    pkg,
+		Name:       pkg + ".v" + version,
+		Image:      "example.com/" + pkg + ":v" + version,
+		Properties: append([]property.Property{property.MustBuildPackage(pkg, version)}, props...),
+	}
+}
+
+func csvMetadata(annotations map[string]string) property.Property {
+	return property.MustBuild(&property.CSVMetadata{Annotations: annotations})
+}
+
+func testConfig() *declcfg.DeclarativeConfig {
+	return &declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{
+			{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"},
+			{Schema: declcfg.SchemaPackage, Name: "bar", DefaultChannel: "stable"},
+		},
+		Channels: []declcfg.Channel{
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1.0.0"},
+				{Name: "foo.v2.0.0", Replaces: "foo.v1.0.0"},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "legacy", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1.0.0"},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "bar", Name: "stable", Entries: []declcfg.ChannelEntry{
+				{Name: "bar.v1.0.0"},
+			}},
+		},
+		Bundles: []declcfg.Bundle{
+			testBundle("foo", "1.0.0",
+				property.MustBuildGVK("example.com", "v1", "Widget"),
+				csvMetadata(map[string]string{"operators.openshift.io/infrastructure-features": `["disconnected"]`}),
+			),
+			testBundle("foo", "2.0.0",
+				property.MustBuildGVK("example.com", "v2", "Widget"),
+				property.MustBuildGVKRequired("", "v1", "ConfigMap"),
+				csvMetadata(map[string]string{"features.operators.openshift.io/disconnected": "true", "tier": "gold"}),
+			),
+			testBundle("bar", "1.0.0",
+				property.MustBuildGVK("example.com", "v1", "Gadget"),
+				property.Property{Type: "example.com/tier", Value: json.RawMessage(`{"level":2}`)},
+				csvMetadata(map[string]string{"features.operators.openshift.io/disconnected": "false"}),
+			),
+		},
+		Deprecations: []declcfg.Deprecation{
+			{Schema: declcfg.SchemaDeprecation, Package: "foo", Entries: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "legacy"}, Message: "use stable"},
+			}},
+			{Schema: declcfg.SchemaDeprecation, Package: "bar", Entries: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaPackage}, Message: "bar is deprecated"},
+			}},
+		},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		terms  []string
+		expect []string
+	}{
+		{name: "NoTerms", expect: []string{"foo.v1.0.0", "foo.v2.0.0", "bar.v1.0.0"}},
+		{name: "Package", terms: []string{"package=foo"}, expect: []string{"foo.v1.0.0", "foo.v2.0.0"}},
+		{name: "NotPackage", terms: []string{"package!=foo"}, expect: []string{"bar.v1.0.0"}},
+		{name: "Version", terms: []string{"version=>=1.0.0 <2.0.0"}, expect: []string{"foo.v1.0.0", "bar.v1.0.0"}},
+		{name: "Provides", terms: []string{"provides=example.com/v2/Widget"}, expect: []string{"foo.v2.0.0"}},
+		{name: "RequiresCoreAPI", terms: []string{"requires=/v1/ConfigMap"}, expect: []string{"foo.v2.0.0"}},
+		{name: "PropertyType", terms: []string{"property=example.com/tier"}, expect: []string{"bar.v1.0.0"}},
+		{name: "PropertyJSONValue", terms: []string{`property=example.com/tier={"level": 2}`}, expect: []string{"bar.v1.0.0"}},
+		{name: "PropertyStringValue", terms: []string{"property=olm.package=foo"}, expect: nil},
+		{name: "Annotation", terms: []string{"annotation=tier=gold"}, expect: []string{"foo.v2.0.0"}},
+		{name: "AnnotationKey", terms: []string{"annotation=tier"}, expect: []string{"foo.v2.0.0"}},
+		{name: "InfrastructureFeature", terms: []string{"infrastructure-feature=disconnected"}, expect: []string{"foo.v1.0.0", "foo.v2.0.0"}},
+		{name: "AllTerms", terms: []string{"package=foo", "infrastructure-feature=Disconnected", "version=<2.0.0"}, expect: []string{"foo.v1.0.0"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.terms...)
+			require.NoError(t, err)
+			var matched []string
+			for _, b := range testConfig().Bundles {
+				ok, err := f.Match(b)
+				require.NoError(t, err)
+				if ok {
+					matched = append(matched, b.Name)
+				}
+			}
+			require.Equal(t, tt.expect, matched)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		term   string
+		expect string
+	}{
+		{term: "package", expect: `invalid filter term "package": expected key=value or key!=value`},
+		{term: "package=", expect: `invalid filter term "package=": value must be set`},
+		{term: "color=blue", expect: `invalid filter term "color=blue": unknown key "color"`},
+		{term: "provides=example.com/Widget", expect: `invalid filter term "provides=example.com/Widget": expected <group>/<version>/<kind>`},
+		{term: "version=one", expect: `invalid filter term "version=one"`},
+	} {
+		t.Run(tt.term, func(t *testing.T) {
+			_, err := Parse(tt.term)
+			require.ErrorContains(t, err, tt.expect)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Run("PrunesChannelsAndPackages", func(t *testing.T) {
+		cfg := testConfig()
+		f, err := Parse("version=>=2.0.0")
+		require.NoError(t, err)
+		require.NoError(t, f.Apply(cfg))
+
+		require.Equal(t, []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}}, cfg.Packages)
+		require.Equal(t, []declcfg.Channel{
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v2.0.0", Replaces: "foo.v1.0.0"},
+			}},
+		}, cfg.Channels)
+		require.Len(t, cfg.Bundles, 1)
+		require.Equal(t, "foo.v2.0.0", cfg.Bundles[0].Name)
+		// The deprecation of the removed legacy channel is left without
+		// entries, and the deprecation of the removed package bar is
+		// removed with it.
+		require.Empty(t, cfg.Deprecations)
+	})
+	t.Run("KeepsDeprecations", func(t *testing.T) {
+		cfg := testConfig()
+		f, err := Parse("package=bar")
+		require.NoError(t, err)
+		require.NoError(t, f.Apply(cfg))
+		require.Len(t, cfg.Deprecations, 1)
+		require.Equal(t, "bar", cfg.Deprecations[0].Package)
+	})
+	t.Run("Error/DefaultChannelRemoved", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Channels[1].Entries = append(cfg.Channels[1].Entries, declcfg.ChannelEntry{Name: "foo.v2.0.0"})
+		cfg.Channels[0].Entries = cfg.Channels[0].Entries[:1]
+		f, err := Parse("version=2.0.0")
+		require.NoError(t, err)
+		require.EqualError(t, f.Apply(cfg), `package "foo": default channel "stable" has no bundles that match the filter`)
+	})
+}
@@ -0,0 +1,76 @@
+package declcfg
+
+import (
+	"sort"
+)
+
+// UnreachableBundles returns the bundles in cfg that are not an entry of any
+// channel in their package. Such bundles can never be installed or upgraded
+// to, and are typically left over from channel edits.
+func UnreachableBundles(cfg DeclarativeConfig) []Bundle {
+	reachable := map[string]map[string]struct{}{}
+	for _, ch := range cfg.Channels {
+		if reachable[ch.Package] == nil {
+			reachable[ch.Package] = map[string]struct{}{}
+		}
+		for _, e := range ch.Entries {
+			reachable[ch.Package][e.Name] = struct{}{}
+		}
+	}
+
+	var unreachable []Bundle
+	for _, b := range cfg.Bundles {
+		if _, ok := reachable[b.Package][b.Name]; !ok {
+			unreachable = append(unreachable, b)
+		}
+	}
+	sort.Slice(unreachable, func(i, j int) bool {
+		if unreachable[i].Package != unreachable[j].Package {
+			return unreachable[i].Package < unreachable[j].Package
+		}
+		return unreachable[i].Name < unreachable[j].Name
+	})
+	return unreachable
+}
+
+// RemoveBundles removes the bundles in remove, matched by package and name,
+// from cfg. Deprecation entries that reference a removed bundle are also
+// removed, and so are deprecations that are left without any entries.
+func RemoveBundles(cfg *DeclarativeConfig, remove []Bundle) {
+	removed := map[string]map[string]struct{}{}
+	for _, b := range remove {
+		if removed[b.Package] == nil {
+			removed[b.Package] = map[string]struct{}{}
+		}
+		removed[b.Package][b.Name] = struct{}{}
+	}
+	isRemoved := func(pkg, name string) bool {
+		_, ok := removed[pkg][name]
+		return ok
+	}
+
+	bundles := cfg.Bundles[:0]
+	for _, b := range cfg.Bundles {
+		if !isRemoved(b.Package, b.Name) {
+			bundles = append(bundles, b)
+		}
+	}
+	cfg.Bundles = bundles
+
+	deprecations := cfg.Deprecations[:0]
+	for _, d := range cfg.Deprecations {
+		numEntries := len(d.Entries)
+		entries := d.Entries[:0]
+		for _, e := range d.Entries {
+			if e.Reference.Schema == SchemaBundle && isRemoved(d.Package, e.Reference.Name) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		d.Entries = entries
+		if len(d.Entries) > 0 || numEntries == 0 {
+			deprecations = append(deprecations, d)
+		}
+	}
+	cfg.Deprecations = deprecations
+}
@@ -0,0 +1,68 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnreachableBundles(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{{Name: "foo.v1"}, {Name: "foo.v2", Replaces: "foo.v1"}}},
+			{Schema: SchemaChannel, Package: "bar", Name: "stable", Entries: []ChannelEntry{{Name: "bar.v1"}}},
+		},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v1"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v2"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0"},
+			{Schema: SchemaBundle, Package: "bar", Name: "bar.v1"},
+			// foo.v2 is only an entry of a channel in another package.
+			{Schema: SchemaBundle, Package: "bar", Name: "foo.v2"},
+			{Schema: SchemaBundle, Package: "baz", Name: "baz.v1"},
+		},
+	}
+
+	require.Equal(t, []Bundle{
+		{Schema: SchemaBundle, Package: "bar", Name: "foo.v2"},
+		{Schema: SchemaBundle, Package: "baz", Name: "baz.v1"},
+		{Schema: SchemaBundle, Package: "foo", Name: "foo.v0"},
+	}, UnreachableBundles(cfg))
+}
+
+func TestRemoveBundles(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v1"},
+			{Schema: SchemaBundle, Package: "bar", Name: "bar.v0"},
+		},
+		Deprecations: []Deprecation{
+			{
+				Schema:  SchemaDeprecation,
+				Package: "foo",
+				Entries: []DeprecationEntry{
+					{Reference: PackageScopedReference{Schema: SchemaPackage}, Message: "foo is deprecated"},
+					{Reference: PackageScopedReference{Schema: SchemaBundle, Name: "foo.v0"}, Message: "foo.v0 is deprecated"},
+				},
+			},
+			{
+				Schema:  SchemaDeprecation,
+				Package: "bar",
+				Entries: []DeprecationEntry{
+					{Reference: PackageScopedReference{Schema: SchemaBundle, Name: "bar.v0"}, Message: "bar.v0 is deprecated"},
+				},
+			},
+		},
+	}
+
+	RemoveBundles(&cfg, []Bundle{{Package: "foo", Name: "foo.v0"}, {Package: "bar", Name: "bar.v0"}})
+	require.Equal(t, []Bundle{{Schema: SchemaBundle, Package: "foo", Name: "foo.v1"}}, cfg.Bundles)
+	require.Equal(t, []Deprecation{{
+		Schema:  SchemaDeprecation,
+		Package: "foo",
+		Entries: []DeprecationEntry{
+			{Reference: PackageScopedReference{Schema: SchemaPackage}, Message: "foo is deprecated"},
+		},
+	}}, cfg.Deprecations)
+}
@@ -0,0 +1,297 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// The ways an edge of an upgrade graph is declared by the entry it leads to.
+const (
+	EdgeReplaces  = "replaces"
+	EdgeSkips     = "skips"
+	EdgeSkipRange = "skipRange"
+)
+
+// GraphEdge is an edge of the upgrade graph of a channel: From can be
+// upgraded to To, which declares the edge Via its replaces, skips, or
+// skipRange.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Via  string `json:"via"`
+}
+
+// GraphSnapshot is a copy of the upgrade graphs of the channels of a
+// catalog, which is not changed by later changes to the catalog, so that the
+// graphs can be compared with those after a change with CompareGraphs.
+type GraphSnapshot struct {
+	channels map[channelKey]channelGraph
+}
+
+type channelKey struct {
+	Package string
+	Channel string
+}
+
+type channelGraph struct {
+	entries map[string]ChannelEntry
+	heads   []string
+	edges   map[GraphEdge]struct{}
+}
+
+// SnapshotGraphs returns a snapshot of the upgrade graphs of the channels of
+// cfg. The skipRange edges are computed from the versions of the bundles of
+// cfg.
+func SnapshotGraphs(cfg DeclarativeConfig) GraphSnapshot {
+	versions := map[string]map[string]semver.Version{}
+	for _, b := range cfg.Bundles {
+		for _, p := range b.Properties {
+			if p.Type != property.TypePackage {
+				continue
+			}
+			var pkg property.Package
+			if err := json.Unmarshal(p.Value, &pkg); err != nil {
+				break
+			}
+			if v, err := semver.Parse(pkg.Version); err == nil {
+				if versions[b.Package] == nil {
+					versions[b.Package] = map[string]semver.Version{}
+				}
+				versions[b.Package][b.Name] = v
+			}
+			break
+		}
+	}
+
+	s := GraphSnapshot{channels: make(map[channelKey]channelGraph, len(cfg.Channels))}
+	for _, ch := range cfg.Channels {
+		g := channelGraph{
+			entries: make(map[string]ChannelEntry, len(ch.Entries)),
+			heads:   channelHead(ch).Heads,
+			edges:   map[GraphEdge]struct{}{},
+		}
+		for _, e := range ch.Entries {
+			e.Skips = append([]string(nil), e.Skips...)
+			g.entries[e.Name] = e
+		}
+		for _, e := range g.entries {
+			if e.Replaces != "" {
+				g.edges[GraphEdge{From: e.Replaces, To: e.Name, Via: EdgeReplaces}] = struct{}{}
+			}
+			for _, skip := range e.Skips {
+				g.edges[GraphEdge{From: skip, To: e.Name, Via: EdgeSkips}] = struct{}{}
+			}
+			if e.SkipRange == "" {
+				continue
+			}
+			skipRange, err := semver.ParseRange(e.SkipRange)
+			if err != nil {
+				continue
+			}
+			for name := range g.entries {
+				if v, ok := versions[ch.Package][name]; ok && name != e.Name && skipRange(v) {
+					g.edges[GraphEdge{From: name, To: e.Name, Via: EdgeSkipRange}] = struct{}{}
+				}
+			}
+		}
+		s.channels[channelKey{ch.Package, ch.Name}] = g
+	}
+	return s
+}
+
+// GraphImpact is the impact of a change of a catalog, such as adding
+// bundles, on the upgrade graphs of its channels.
+type GraphImpact struct {
+	// Channels are the channels whose upgrade graphs change, sorted by
+	// package and channel.
+	Channels []ChannelImpact `json:"channels"`
+}
+
+// ChannelImpact is the impact of a change on the upgrade graph of a channel.
+type ChannelImpact struct {
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+	// Added and Removed are set if the channel is added, or removed.
+	Added   bool `json:"added,omitempty"`
+	Removed bool `json:"removed,omitempty"`
+
+	AddedEntries   []ChannelEntry       `json:"addedEntries,omitempty"`
+	ChangedEntries []ChannelEntryChange `json:"changedEntries,omitempty"`
+	RemovedEntries []ChannelEntry       `json:"removedEntries,omitempty"`
+
+	// PreviousHeads and Heads are the heads of the channel before and after
+	// the change, if they change, e.g. when a new head replaces the head of
+	// the channel.
+	PreviousHeads []string `json:"previousHeads,omitempty"`
+	Heads         []string `json:"heads,omitempty"`
+
+	AddedEdges   []GraphEdge `json:"addedEdges,omitempty"`
+	RemovedEdges []GraphEdge `json:"removedEdges,omitempty"`
+}
+
+// ChannelEntryChange is a channel entry whose replaces, skips, or skipRange
+// change.
+type ChannelEntryChange struct {
+	Before ChannelEntry `json:"before"`
+	After  ChannelEntry `json:"after"`
+}
+
+// CompareGraphs returns the impact on the upgrade graphs of the change from
+// the catalog of before to that of after.
+func CompareGraphs(before, after GraphSnapshot) GraphImpact {
+	keys := map[channelKey]struct{}{}
+	for k := range before.channels {
+		keys[k] = struct{}{}
+	}
+	for k := range after.channels {
+		keys[k] = struct{}{}
+	}
+
+	impact := GraphImpact{Channels: []ChannelImpact{}}
+	for k := range keys {
+		b, inBefore := before.channels[k]
+		a, inAfter := after.channels[k]
+		ci := ChannelImpact{Package: k.Package, Channel: k.Channel, Added: !inBefore, Removed: !inAfter}
+
+		for name, e := range a.entries {
+			prev, ok := b.entries[name]
+			switch {
+			case !ok:
+				ci.AddedEntries = append(ci.AddedEntries, e)
+			case !reflect.DeepEqual(normalizeEntry(prev), normalizeEntry(e)):
+				ci.ChangedEntries = append(ci.ChangedEntries, ChannelEntryChange{Before: prev, After: e})
+			}
+		}
+		for name, e := range b.entries {
+			if _, ok := a.entries[name]; !ok {
+				ci.RemovedEntries = append(ci.RemovedEntries, e)
+			}
+		}
+		if !reflect.DeepEqual(b.heads, a.heads) {
+			ci.PreviousHeads, ci.Heads = b.heads, a.heads
+		}
+		for e := range a.edges {
+			if _, ok := b.edges[e]; !ok {
+				ci.AddedEdges = append(ci.AddedEdges, e)
+			}
+		}
+		for e := range b.edges {
+			if _, ok := a.edges[e]; !ok {
+				ci.RemovedEdges = append(ci.RemovedEdges, e)
+			}
+		}
+
+		if !ci.Added && !ci.Removed && len(ci.AddedEntries)+len(ci.ChangedEntries)+len(ci.RemovedEntries)+len(ci.AddedEdges)+len(ci.RemovedEdges) == 0 && ci.Heads == nil {
+			continue
+		}
+		sortEntries(ci.AddedEntries)
+		sortEntries(ci.RemovedEntries)
+		sort.Slice(ci.ChangedEntries, func(i, j int) bool { return ci.ChangedEntries[i].After.Name < ci.ChangedEntries[j].After.Name })
+		sortEdges(ci.AddedEdges)
+		sortEdges(ci.RemovedEdges)
+		impact.Channels = append(impact.Channels, ci)
+	}
+	sort.Slice(impact.Channels, func(i, j int) bool {
+		if impact.Channels[i].Package != impact.Channels[j].Package {
+			return impact.Channels[i].Package < impact.Channels[j].Package
+		}
+		return impact.Channels[i].Channel < impact.Channels[j].Channel
+	})
+	return impact
+}
+
+// String returns a human-readable report of the impact.
+func (g GraphImpact) String() string {
+	if len(g.Channels) == 0 {
+		return "no changes to upgrade graphs\n"
+	}
+	var sb strings.Builder
+	for _, ci := range g.Channels {
+		fmt.Fprintf(&sb, "package %q, channel %q", ci.Package, ci.Channel)
+		switch {
+		case ci.Added:
+			sb.WriteString(" (new channel)")
+		case ci.Removed:
+			sb.WriteString(" (removed channel)")
+		}
+		sb.WriteString(":\n")
+		for _, e := range ci.AddedEntries {
+			fmt.Fprintf(&sb, "  + entry %s\n", describeEntry(e))
+		}
+		for _, c := range ci.ChangedEntries {
+			fmt.Fprintf(&sb, "  ~ entry %s, was %s\n", describeEntry(c.After), describeEntry(c.Before))
+		}
+		for _, e := range ci.RemovedEntries {
+			fmt.Fprintf(&sb, "  - entry %s\n", describeEntry(e))
+		}
+		if ci.Heads != nil || ci.PreviousHeads != nil {
+			fmt.Fprintf(&sb, "  head: %s -> %s\n", describeHeads(ci.PreviousHeads), describeHeads(ci.Heads))
+		}
+		for _, e := range ci.AddedEdges {
+			fmt.Fprintf(&sb, "  + edge %s -> %s (%s)\n", e.From, e.To, e.Via)
+		}
+		for _, e := range ci.RemovedEdges {
+			fmt.Fprintf(&sb, "  - edge %s -> %s (%s)\n", e.From, e.To, e.Via)
+		}
+	}
+	return sb.String()
+}
+
+func describeEntry(e ChannelEntry) string {
+	var attrs []string
+	if e.Replaces != "" {
+		attrs = append(attrs, "replaces "+e.Replaces)
+	}
+	if len(e.Skips) > 0 {
+		attrs = append(attrs, "skips "+strings.Join(e.Skips, ", "))
+	}
+	if e.SkipRange != "" {
+		attrs = append(attrs, fmt.Sprintf("skipRange %q", e.SkipRange))
+	}
+	if len(attrs) == 0 {
+		return e.Name
+	}
+	return fmt.Sprintf("%s (%s)", e.Name, strings.Join(attrs, "; "))
+}
+
+func describeHeads(heads []string) string {
+	if len(heads) == 0 {
+		return "(none)"
+	}
+	return strings.Join(heads, ", ")
+}
+
+// normalizeEntry returns e with its skips sorted, and nil if empty, so that
+// entries that only differ in the order of their skips are equal.
+func normalizeEntry(e ChannelEntry) ChannelEntry {
+	if len(e.Skips) == 0 {
+		e.Skips = nil
+		return e
+	}
+	e.Skips = append([]string(nil), e.Skips...)
+	sort.Strings(e.Skips)
+	return e
+}
+
+func sortEntries(entries []ChannelEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+}
+
+func sortEdges(edges []GraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Via < edges[j].Via
+	})
+}
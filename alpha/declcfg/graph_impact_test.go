@@ -0,0 +1,107 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestCompareGraphs(t *testing.T) {
+	bundle := func(name, version string) Bundle {
+		return Bundle{Schema: SchemaBundle, Package: "foo", Name: name, Properties: []property.Property{property.MustBuildPackage("foo", version)}}
+	}
+	before := DeclarativeConfig{
+		Channels: []Channel{
+			{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{
+				{Name: "foo.v0.1.0"},
+				{Name: "foo.v0.1.1", Replaces: "foo.v0.1.0"},
+				{Name: "foo.v0.1.2", Replaces: "foo.v0.1.1"},
+			}},
+			{Schema: SchemaChannel, Package: "foo", Name: "candidate", Entries: []ChannelEntry{
+				{Name: "foo.v0.1.0"},
+			}},
+		},
+		Bundles: []Bundle{bundle("foo.v0.1.0", "0.1.0"), bundle("foo.v0.1.1", "0.1.1"), bundle("foo.v0.1.2", "0.1.2")},
+	}
+	snapshot := SnapshotGraphs(before)
+
+	// Snapshots are not changed by changes to the catalog.
+	before.Channels[0].Entries[0].Replaces = "foo.v0.0.1"
+	require.Equal(t, GraphImpact{Channels: []ChannelImpact{}}, CompareGraphs(snapshot, SnapshotGraphs(DeclarativeConfig{
+		Channels: []Channel{
+			{Package: "foo", Name: "stable", Entries: []ChannelEntry{{Name: "foo.v0.1.0"}, {Name: "foo.v0.1.1", Replaces: "foo.v0.1.0"}, {Name: "foo.v0.1.2", Replaces: "foo.v0.1.1"}}},
+			{Package: "foo", Name: "candidate", Entries: []ChannelEntry{{Name: "foo.v0.1.0"}}},
+		},
+		Bundles: before.Bundles,
+	})))
+
+	// A new head that skips a range of the channel, replaces an entry that
+	// is pruned, and is added to a new channel.
+	after := DeclarativeConfig{
+		Channels: []Channel{
+			{Schema: SchemaChannel, Package: "foo", Name: "stable", Entries: []ChannelEntry{
+				{Name: "foo.v0.1.0"},
+				{Name: "foo.v0.1.1", Replaces: "foo.v0.1.0"},
+				{Name: "foo.v0.2.0", Replaces: "foo.v0.1.1", SkipRange: ">=0.1.0 <0.2.0"},
+			}},
+			{Schema: SchemaChannel, Package: "foo", Name: "candidate", Entries: []ChannelEntry{
+				{Name: "foo.v0.1.0"},
+			}},
+			{Schema: SchemaChannel, Package: "foo", Name: "fast", Entries: []ChannelEntry{
+				{Name: "foo.v0.2.0"},
+			}},
+		},
+		Bundles: append(before.Bundles, bundle("foo.v0.2.0", "0.2.0")),
+	}
+	impact := CompareGraphs(snapshot, SnapshotGraphs(after))
+	require.Equal(t, GraphImpact{Channels: []ChannelImpact{
+		{
+			Package:      "foo",
+			Channel:      "fast",
+			Added:        true,
+			AddedEntries: []ChannelEntry{{Name: "foo.v0.2.0"}},
+			Heads:        []string{"foo.v0.2.0"},
+		},
+		{
+			Package:        "foo",
+			Channel:        "stable",
+			AddedEntries:   []ChannelEntry{{Name: "foo.v0.2.0", Replaces: "foo.v0.1.1", SkipRange: ">=0.1.0 <0.2.0"}},
+			RemovedEntries: []ChannelEntry{{Name: "foo.v0.1.2", Replaces: "foo.v0.1.1"}},
+			PreviousHeads:  []string{"foo.v0.1.2"},
+			Heads:          []string{"foo.v0.2.0"},
+			AddedEdges: []GraphEdge{
+				{From: "foo.v0.1.0", To: "foo.v0.2.0", Via: EdgeSkipRange},
+				{From: "foo.v0.1.1", To: "foo.v0.2.0", Via: EdgeReplaces},
+				{From: "foo.v0.1.1", To: "foo.v0.2.0", Via: EdgeSkipRange},
+			},
+			RemovedEdges: []GraphEdge{{From: "foo.v0.1.1", To: "foo.v0.1.2", Via: EdgeReplaces}},
+		},
+	}}, impact)
+
+	require.Equal(t, `package "foo", channel "fast" (new channel):
+  + entry foo.v0.2.0
+  head: (none) -> foo.v0.2.0
+package "foo", channel "stable":
+  + entry foo.v0.2.0 (replaces foo.v0.1.1; skipRange ">=0.1.0 <0.2.0")
+  - entry foo.v0.1.2 (replaces foo.v0.1.1)
+  head: foo.v0.1.2 -> foo.v0.2.0
+  + edge foo.v0.1.0 -> foo.v0.2.0 (skipRange)
+  + edge foo.v0.1.1 -> foo.v0.2.0 (replaces)
+  + edge foo.v0.1.1 -> foo.v0.2.0 (skipRange)
+  - edge foo.v0.1.1 -> foo.v0.1.2 (replaces)
+`, impact.String())
+
+	t.Run("ChangedEntry", func(t *testing.T) {
+		changed := DeclarativeConfig{Channels: []Channel{{Package: "foo", Name: "candidate", Entries: []ChannelEntry{{Name: "foo.v0.1.0", Skips: []string{"foo.v0.0.9"}}}}}}
+		impact := CompareGraphs(SnapshotGraphs(DeclarativeConfig{Channels: []Channel{after.Channels[1]}}), SnapshotGraphs(changed))
+		require.Equal(t, []ChannelEntryChange{{Before: ChannelEntry{Name: "foo.v0.1.0"}, After: ChannelEntry{Name: "foo.v0.1.0", Skips: []string{"foo.v0.0.9"}}}}, impact.Channels[0].ChangedEntries)
+		require.Equal(t, []GraphEdge{{From: "foo.v0.0.9", To: "foo.v0.1.0", Via: EdgeSkips}}, impact.Channels[0].AddedEdges)
+		require.Contains(t, impact.String(), "  ~ entry foo.v0.1.0 (skips foo.v0.0.9), was foo.v0.1.0\n")
+	})
+
+	t.Run("NoChanges", func(t *testing.T) {
+		require.Equal(t, "no changes to upgrade graphs\n", CompareGraphs(SnapshotGraphs(after), SnapshotGraphs(after)).String())
+	})
+}
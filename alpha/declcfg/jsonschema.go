@@ -0,0 +1,101 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	bytesType      = reflect.TypeOf([]byte{})
+)
+
+// JSONSchemas returns the JSON Schemas of the objects of the schemas that
+// declarative configs define, by schema name: olm.package, olm.channel,
+// olm.bundle, olm.deprecations and olm.package.documentation.
+//
+// The schemas are generated from the Go types of the objects, so that they
+// always describe the objects that are loaded and written: a field is
+// required unless it is omitted when empty, property values and other raw
+// JSON may be any value, and objects may have fields that are not described,
+// as they are ignored when loading.
+func JSONSchemas() map[string]map[string]interface{} {
+	schemas := map[string]map[string]interface{}{}
+	for name, v := range map[string]interface{}{
+		SchemaPackage:              Package{},
+		SchemaChannel:              Channel{},
+		SchemaBundle:               Bundle{},
+		SchemaDeprecation:          Deprecation{},
+		SchemaPackageDocumentation: PackageDocumentation{},
+	} {
+		s := typeJSONSchema(reflect.TypeOf(v))
+		// The schema field of an object is its schema name.
+		s["properties"].(map[string]interface{})["schema"] = map[string]interface{}{
+			"type": "string",
+			"enum": []string{name},
+		}
+		s["$schema"] = jsonSchemaDraft
+		s["title"] = name
+		schemas[name] = s
+	}
+	return schemas
+}
+
+// typeJSONSchema returns the JSON Schema of the JSON encoding of values of
+// type t.
+func typeJSONSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case rawMessageType:
+		return map[string]interface{}{}
+	case bytesType:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeJSONSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeJSONSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeJSONSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = typeJSONSchema(f.Type)
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				required = append(required, name)
+			}
+		}
+		s := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	default:
+		return map[string]interface{}{}
+	}
+}
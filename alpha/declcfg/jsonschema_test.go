@@ -0,0 +1,52 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchemas(t *testing.T) {
+	schemas := JSONSchemas()
+	require.Len(t, schemas, 5)
+
+	pkg := schemas[SchemaPackage]
+	require.Equal(t, jsonSchemaDraft, pkg["$schema"])
+	require.Equal(t, SchemaPackage, pkg["title"])
+	require.Equal(t, []string{"schema", "name", "defaultChannel"}, pkg["required"])
+	props := pkg["properties"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"type": "string", "enum": []string{SchemaPackage}}, props["schema"])
+	require.Equal(t, map[string]interface{}{"type": "string"}, props["defaultChannel"])
+	require.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"base64data": map[string]interface{}{"type": "string", "contentEncoding": "base64"},
+			"mediatype":  map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"base64data", "mediatype"},
+	}, props["icon"])
+
+	bundle := schemas[SchemaBundle]["properties"].(map[string]interface{})
+	// Fields that are not encoded are not described.
+	require.NotContains(t, bundle, "CsvJSON")
+	require.NotContains(t, bundle, "Objects")
+	// Property values may be any JSON value.
+	require.Equal(t, map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"type":  map[string]interface{}{"type": "string"},
+				"value": map[string]interface{}{},
+			},
+			"required": []string{"type", "value"},
+		},
+	}, bundle["properties"])
+
+	// The schemas are valid JSON.
+	for name, s := range schemas {
+		_, err := json.Marshal(s)
+		require.NoError(t, err, name)
+	}
+}
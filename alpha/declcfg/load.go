@@ -12,7 +12,9 @@ import (
 
 	"github.com/joelanford/ignore"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/operator-framework/api/pkg/operators"
@@ -29,6 +31,10 @@ type WalkMetasFSFunc func(path string, meta *Meta, err error) error
 // WalkMetasFS walks the filesystem rooted at root and calls walkFn for each individual meta object found in the root.
 // By default, WalkMetasFS is not thread-safe because it invokes walkFn concurrently. In order to make it thread-safe,
 // use the WithConcurrency(1) to avoid concurrent invocations of walkFn.
+//
+// Files are parsed concurrently, and the meta objects of each file are streamed to walkFn as they are decoded, so
+// only the files currently being parsed are held in memory. To bound that memory for very large catalogs, use
+// WithMaxMemory.
 func WalkMetasFS(ctx context.Context, root fs.FS, walkFn WalkMetasFSFunc, opts ...LoadOption) error {
 	if root == nil {
 		return fmt.Errorf("no declarative config filesystem provided")
@@ -40,6 +46,25 @@ func WalkMetasFS(ctx context.Context, root fs.FS, walkFn WalkMetasFSFunc, opts .
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if options.concurrency <= 0 {
+		options.concurrency = runtime.NumCPU()
+	}
+	if options.maxMemory < 0 {
+		return fmt.Errorf("invalid max memory %d: must not be negative", options.maxMemory)
+	}
+	if options.packages != nil {
+		unfiltered := walkFn
+		walkFn = func(path string, meta *Meta, err error) error {
+			if err == nil && !options.packages.Has(meta.packageName()) {
+				return nil
+			}
+			return unfiltered(path, meta, err)
+		}
+	}
+	var memory *semaphore.Weighted
+	if options.maxMemory > 0 {
+		memory = semaphore.NewWeighted(options.maxMemory)
+	}
 
 	pathChan := make(chan string, options.concurrency)
 
@@ -58,12 +83,21 @@ func WalkMetasFS(ctx context.Context, root fs.FS, walkFn WalkMetasFSFunc, opts .
 	// before the cfgChan is closed.
 	for i := 0; i < options.concurrency; i++ {
 		eg.Go(func() error {
-			return parseMetaPaths(ctx, root, pathChan, walkFn, options)
+			return parseMetaPaths(ctx, root, pathChan, walkFn, memory, options.maxMemory)
 		})
 	}
 	return eg.Wait()
 }
 
+// packageName returns the name of the package that m belongs to, which is its
+// own name for package objects.
+func (m *Meta) packageName() string {
+	if m.Schema == SchemaPackage {
+		return m.Name
+	}
+	return m.Package
+}
+
 type WalkMetasReaderFunc func(meta *Meta, err error) error
 
 func WalkMetasReader(r io.Reader, walkFn WalkMetasReaderFunc) error {
@@ -131,6 +165,8 @@ func walkFiles(root fs.FS, fn func(root fs.FS, path string, err error) error) er
 
 type LoadOptions struct {
 	concurrency int
+	maxMemory   int64
+	packages    sets.Set[string]
 }
 
 type LoadOption func(*LoadOptions)
@@ -141,6 +177,27 @@ func WithConcurrency(concurrency int) LoadOption {
 	}
 }
 
+// WithMaxMemory sets an approximate budget, in bytes, for the files that are
+// parsed concurrently. A file is only parsed once its size fits in the budget,
+// so files larger than the budget are parsed one at a time. Zero, the
+// default, means no limit.
+func WithMaxMemory(maxMemory int64) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.maxMemory = maxMemory
+	}
+}
+
+// WithPackages limits the meta objects that are walked or loaded to those of
+// the named packages. Objects that do not belong to a package are skipped
+// too. Without names, objects of all packages are loaded.
+func WithPackages(names ...string) LoadOption {
+	return func(opts *LoadOptions) {
+		if len(names) > 0 {
+			opts.packages = sets.New(names...)
+		}
+	}
+}
+
 // LoadFS loads a declarative config from the provided root FS. LoadFS walks the
 // filesystem from root and uses a gitignore-style filename matcher to skip files
 // that match patterns found in .indexignore files found throughout the filesystem.
@@ -174,7 +231,7 @@ func sendPaths(ctx context.Context, root fs.FS, pathChan chan<- string) error {
 	})
 }
 
-func parseMetaPaths(ctx context.Context, root fs.FS, pathChan <-chan string, walkFn WalkMetasFSFunc, options LoadOptions) error {
+func parseMetaPaths(ctx context.Context, root fs.FS, pathChan <-chan string, walkFn WalkMetasFSFunc, memory *semaphore.Weighted, maxMemory int64) error {
 	for {
 		select {
 		case <-ctx.Done(): // don't block on receiving from pathChan
@@ -183,19 +240,39 @@ func parseMetaPaths(ctx context.Context, root fs.FS, pathChan <-chan string, wal
 			if !ok {
 				return nil
 			}
-			file, err := root.Open(path)
-			if err != nil {
-				return err
-			}
-			if err := WalkMetasReader(file, func(meta *Meta, err error) error {
-				return walkFn(path, meta, err)
-			}); err != nil {
+			if err := parseMetaPath(ctx, root, path, walkFn, memory, maxMemory); err != nil {
 				return err
 			}
 		}
 	}
 }
 
+// parseMetaPath streams the meta objects of the file at path to walkFn. If
+// memory is set, the size of the file is acquired from it, up to maxMemory,
+// before the file is opened, and released once it is parsed.
+func parseMetaPath(ctx context.Context, root fs.FS, path string, walkFn WalkMetasFSFunc, memory *semaphore.Weighted, maxMemory int64) error {
+	if memory != nil {
+		info, err := fs.Stat(root, path)
+		if err != nil {
+			return err
+		}
+		cost := min(max(info.Size(), 1), maxMemory)
+		if err := memory.Acquire(ctx, cost); err != nil {
+			return err
+		}
+		defer memory.Release(cost)
+	}
+
+	file, err := root.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return WalkMetasReader(file, func(meta *Meta, err error) error {
+		return walkFn(path, meta, err)
+	})
+}
+
 func readBundleObjects(b *Bundle) error {
 	var obj property.BundleObject
 	for i, props := range b.Properties {
@@ -277,6 +354,7 @@ type fbcBuilder struct {
 	channelsMu     sync.Mutex
 	bundlesMu      sync.Mutex
 	deprecationsMu sync.Mutex
+	docsMu         sync.Mutex
 	othersMu       sync.Mutex
 }
 
@@ -317,6 +395,14 @@ func (c *fbcBuilder) addMeta(in *Meta) error {
 		c.deprecationsMu.Lock()
 		c.cfg.Deprecations = append(c.cfg.Deprecations, d)
 		c.deprecationsMu.Unlock()
+	case SchemaPackageDocumentation:
+		var d PackageDocumentation
+		if err := json.Unmarshal(in.Blob, &d); err != nil {
+			return fmt.Errorf("parse package documentation: %w", err)
+		}
+		c.docsMu.Lock()
+		c.cfg.Documentations = append(c.cfg.Documentations, d)
+		c.docsMu.Unlock()
 	case "":
 		return fmt.Errorf("object '%s' is missing root schema field", string(in.Blob))
 	default:
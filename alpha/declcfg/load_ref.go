@@ -0,0 +1,551 @@
+package declcfg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/distribution/reference"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/model"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// Names of the built-in reference sources, as returned by RefSource.Name.
+const (
+	RefSourceDir     = "dir"
+	RefSourceFile    = "file"
+	RefSourceArchive = "archive"
+	RefSourceGit     = "git"
+	RefSourceGRPC    = "grpc"
+	RefSourceImage   = "image"
+)
+
+var ErrUnsupportedRef = errors.New("unsupported reference")
+
+// RefSource knows how to recognize and load a declarative config from a
+// particular kind of reference, e.g. a directory, an image, or a git repository.
+type RefSource interface {
+	// Name returns a short, unique name for the type of source.
+	Name() string
+	// Matches returns true if the source is able to load ref.
+	Matches(ref string) bool
+	// Load loads the declarative config found at ref.
+	Load(ctx context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error)
+}
+
+type LoadRefOptions struct {
+	// Registry is used to pull and unpack image references. If unset,
+	// image references are not supported.
+	Registry image.Registry
+	// CacheDir is the parent directory used for any temporary content
+	// (e.g. unpacked images, cloned repositories, and extracted archives).
+	// If unset, the system's default temporary directory is used.
+	CacheDir string
+	// SkipTLSVerify disables TLS certificate verification for git and
+	// gRPC references.
+	SkipTLSVerify bool
+
+	// Sources are consulted, in order, before the built-in sources.
+	Sources []RefSource
+	// LoadOptions are used to load file-based catalogs, e.g. to load only
+	// some packages with WithPackages. Packages are also filtered when
+	// loading from a serving registry.
+	LoadOptions []LoadOption
+}
+
+type LoadRefOption func(*LoadRefOptions)
+
+func WithRegistry(reg image.Registry) LoadRefOption {
+	return func(opts *LoadRefOptions) {
+		opts.Registry = reg
+	}
+}
+
+func WithCacheDir(dir string) LoadRefOption {
+	return func(opts *LoadRefOptions) {
+		opts.CacheDir = dir
+	}
+}
+
+func WithSkipTLSVerify(skip bool) LoadRefOption {
+	return func(opts *LoadRefOptions) {
+		opts.SkipTLSVerify = skip
+	}
+}
+
+// WithRefSources registers additional sources that take precedence
+// over the built-in sources.
+func WithRefSources(sources ...RefSource) LoadRefOption {
+	return func(opts *LoadRefOptions) {
+		opts.Sources = append(opts.Sources, sources...)
+	}
+}
+
+// WithLoadOptions sets the options used to load the file-based catalogs
+// that references resolve to.
+func WithLoadOptions(opts ...LoadOption) LoadRefOption {
+	return func(o *LoadRefOptions) {
+		o.LoadOptions = append(o.LoadOptions, opts...)
+	}
+}
+
+// LoadRef loads a declarative config from ref, which may be any of:
+//   - a file-based catalog directory
+//   - a single file-based catalog file (JSON or YAML)
+//   - a tarball (.tar, .tar.gz, or .tgz) containing a file-based catalog
+//   - a git repository, as git+<url>[#<revision>][:<subdirectory>]
+//   - a serving registry, as grpc://<host>:<port> (plaintext) or grpcs://<host>:<port> (TLS)
+//   - a file-based catalog image
+//
+// Additional sources can be provided with WithRefSources. The errors of the
+// built-in sources are wrapped with the reference that failed to load.
+func LoadRef(ctx context.Context, ref string, opts ...LoadRefOption) (*DeclarativeConfig, error) {
+	options := newLoadRefOptions(opts...)
+	src, err := detectRefSource(ref, options)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := src.Load(ctx, ref, options)
+	if err != nil {
+		// The errors of sources registered with WithRefSources are returned
+		// as is, so that those sources control how references are reported.
+		if !isBuiltinRefSource(src) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("load %s reference %q: %w", src.Name(), ref, err)
+	}
+	return cfg, nil
+}
+
+// DetectRefSource returns the source that LoadRef would use to load ref.
+func DetectRefSource(ref string, opts ...LoadRefOption) (RefSource, error) {
+	return detectRefSource(ref, newLoadRefOptions(opts...))
+}
+
+// loadOptions returns the LoadOptions that o.LoadOptions set.
+func (o LoadRefOptions) loadOptions() LoadOptions {
+	var options LoadOptions
+	for _, opt := range o.LoadOptions {
+		opt(&options)
+	}
+	return options
+}
+
+func newLoadRefOptions(opts ...LoadRefOption) LoadRefOptions {
+	var options LoadRefOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+func detectRefSource(ref string, opts LoadRefOptions) (RefSource, error) {
+	sources := append(append([]RefSource{}, opts.Sources...), builtinRefSources...)
+	for _, src := range sources {
+		if src.Matches(ref) {
+			return src, nil
+		}
+	}
+	if err := localPathError(ref); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedRef, err)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedRef, ref)
+}
+
+// localPathError returns an error if ref is a local path that no source
+// matched, such as a mistyped directory, rather than an image reference.
+func localPathError(ref string) error {
+	if image.IsOCILayoutReference(ref) {
+		return nil
+	}
+	_, err := os.Stat(ref)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%q is not a directory or a regular file", ref)
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	case filepath.IsAbs(ref) || strings.HasPrefix(ref, ".") || strings.HasPrefix(ref, "~") || strings.HasSuffix(ref, string(filepath.Separator)):
+		return err
+	}
+	if _, perr := reference.ParseNormalizedNamed(ref); perr != nil {
+		return fmt.Errorf("%v, and it is not a valid image reference: %v", err, perr)
+	}
+	return nil
+}
+
+// builtinRefSources are ordered such that sources identified by an explicit
+// scheme are consulted before sources that inspect the local filesystem. The
+// image source matches anything else that is not a local path, so it must be
+// last.
+var builtinRefSources = []RefSource{
+	grpcSource{},
+	gitSource{},
+	archiveSource{},
+	dirSource{},
+	fileSource{},
+	imageSource{},
+}
+
+func isBuiltinRefSource(src RefSource) bool {
+	switch src.(type) {
+	case grpcSource, gitSource, archiveSource, dirSource, fileSource, imageSource:
+		return true
+	}
+	return false
+}
+
+type dirSource struct{}
+
+func (dirSource) Name() string { return RefSourceDir }
+
+func (dirSource) Matches(ref string) bool {
+	stat, err := os.Stat(ref)
+	return err == nil && stat.IsDir()
+}
+
+func (dirSource) Load(ctx context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error) {
+	return LoadFS(ctx, os.DirFS(ref), opts.LoadOptions...)
+}
+
+type fileSource struct{}
+
+func (fileSource) Name() string { return RefSourceFile }
+
+func (fileSource) Matches(ref string) bool {
+	stat, err := os.Stat(ref)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+func (fileSource) Load(_ context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error) {
+	packages := opts.loadOptions().packages
+	if packages == nil {
+		return LoadFile(os.DirFS(filepath.Dir(ref)), filepath.Base(ref))
+	}
+	f, err := os.Open(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	builder := fbcBuilder{}
+	if err := WalkMetasReader(f, func(meta *Meta, err error) error {
+		if err != nil {
+			return err
+		}
+		if !packages.Has(meta.packageName()) {
+			return nil
+		}
+		return builder.addMeta(meta)
+	}); err != nil {
+		return nil, err
+	}
+	return &builder.cfg, nil
+}
+
+type archiveSource struct{}
+
+func (archiveSource) Name() string { return RefSourceArchive }
+
+func (archiveSource) Matches(ref string) bool {
+	if !strings.HasSuffix(ref, ".tar") && !strings.HasSuffix(ref, ".tar.gz") && !strings.HasSuffix(ref, ".tgz") {
+		return false
+	}
+	stat, err := os.Stat(ref)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+func (archiveSource) Load(ctx context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error) {
+	f, err := os.Open(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if !strings.HasSuffix(ref, ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	dir, err := os.MkdirTemp(opts.CacheDir, "archive-")
+	if err != nil {
+		return nil, fmt.Errorf("create tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractTar(r, dir); err != nil {
+		return nil, fmt.Errorf("extract archive: %v", err)
+	}
+	return LoadFS(ctx, os.DirFS(dir), opts.LoadOptions...)
+}
+
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, filepath.Clean("/"+hdr.Name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		default:
+			// Links and special files are never part of a file-based catalog.
+			continue
+		}
+	}
+}
+
+type gitSource struct{}
+
+func (gitSource) Name() string { return RefSourceGit }
+
+func (gitSource) Matches(ref string) bool {
+	return strings.HasPrefix(ref, "git+") || strings.HasPrefix(ref, "git://")
+}
+
+func (gitSource) Load(ctx context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error) {
+	repo, revision, subdir := parseGitRef(ref)
+	// The repository and revision are passed to git as arguments, so they
+	// must not be mistaken for options, such as --upload-pack, that run
+	// commands.
+	if strings.HasPrefix(repo, "-") {
+		return nil, fmt.Errorf("invalid git repository %q: must not start with \"-\"", repo)
+	}
+	if strings.HasPrefix(revision, "-") {
+		return nil, fmt.Errorf("invalid git revision %q: must not start with \"-\"", revision)
+	}
+
+	dir, err := os.MkdirTemp(opts.CacheDir, "git-")
+	if err != nil {
+		return nil, fmt.Errorf("create tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"clone", "--quiet"}
+	if revision == "" {
+		args = append(args, "--depth", "1")
+	}
+	if err := runGit(ctx, opts, append(args, "--", repo, dir)...); err != nil {
+		return nil, err
+	}
+	if revision != "" {
+		// The trailing "--" makes git read revision as a revision, and never
+		// as a path.
+		if err := runGit(ctx, opts, "-C", dir, "checkout", "--quiet", revision, "--"); err != nil {
+			return nil, err
+		}
+	}
+	return LoadFS(ctx, os.DirFS(filepath.Join(dir, filepath.Clean("/"+subdir))), opts.LoadOptions...)
+}
+
+// parseGitRef splits a reference of the form git+<url>[#<revision>][:<subdirectory>]
+// into its repository URL, revision, and subdirectory.
+func parseGitRef(ref string) (string, string, string) {
+	repo, fragment, _ := strings.Cut(strings.TrimPrefix(ref, "git+"), "#")
+	revision, subdir, _ := strings.Cut(fragment, ":")
+	return repo, revision, subdir
+}
+
+func runGit(ctx context.Context, opts LoadRefOptions, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if opts.SkipTLSVerify {
+		cmd.Env = append(cmd.Env, "GIT_SSL_NO_VERIFY=true")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+type grpcSource struct{}
+
+func (grpcSource) Name() string { return RefSourceGRPC }
+
+func (grpcSource) Matches(ref string) bool {
+	return strings.HasPrefix(ref, "grpc://") || strings.HasPrefix(ref, "grpcs://")
+}
+
+func (grpcSource) Load(ctx context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error) {
+	creds := insecure.NewCredentials()
+	address := strings.TrimPrefix(ref, "grpc://")
+	if strings.HasPrefix(ref, "grpcs://") {
+		address = strings.TrimPrefix(ref, "grpcs://")
+		// nolint:gosec
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: opts.SkipTLSVerify})
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	c := client.NewClientFromConn(conn)
+	defer c.Close()
+
+	m, err := registryToModel(ctx, c, opts.loadOptions().packages)
+	if err != nil {
+		return nil, err
+	}
+	cfg := ConvertFromModel(m)
+	return &cfg, nil
+}
+
+// registryToModel builds a model from the packages and bundles served by
+// a registry. Bundles are returned by the registry once per channel, so
+// each is added to the channel it was served for. If packages is set, only
+// those packages are added.
+func registryToModel(ctx context.Context, c *client.Client, packages sets.Set[string]) (model.Model, error) {
+	pkgStream, err := c.Registry.ListPackages(ctx, &api.ListPackageRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list packages: %v", err)
+	}
+	m := model.Model{}
+	for {
+		pkgName, err := pkgStream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list packages: %v", err)
+		}
+		if packages != nil && !packages.Has(pkgName.GetName()) {
+			continue
+		}
+		apiPkg, err := c.GetPackage(ctx, pkgName.GetName())
+		if err != nil {
+			return nil, fmt.Errorf("get package %q: %v", pkgName.GetName(), err)
+		}
+		pkg := &model.Package{
+			Name:     apiPkg.GetName(),
+			Channels: map[string]*model.Channel{},
+		}
+		if d := apiPkg.GetDeprecation(); d != nil {
+			pkg.Deprecation = &model.Deprecation{Message: d.GetMessage()}
+		}
+		for _, apiCh := range apiPkg.GetChannels() {
+			ch := &model.Channel{
+				Package: pkg,
+				Name:    apiCh.GetName(),
+				Bundles: map[string]*model.Bundle{},
+			}
+			if d := apiCh.GetDeprecation(); d != nil {
+				ch.Deprecation = &model.Deprecation{Message: d.GetMessage()}
+			}
+			pkg.Channels[ch.Name] = ch
+		}
+		pkg.DefaultChannel = pkg.Channels[apiPkg.GetDefaultChannelName()]
+		m[pkg.Name] = pkg
+	}
+
+	it, err := c.ListBundles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list bundles: %v", err)
+	}
+	for b := it.Next(); b != nil; b = it.Next() {
+		if packages != nil && !packages.Has(b.GetPackageName()) {
+			continue
+		}
+		pkg, ok := m[b.GetPackageName()]
+		if !ok {
+			return nil, fmt.Errorf("bundle %q: package %q not found", b.GetCsvName(), b.GetPackageName())
+		}
+		ch, ok := pkg.Channels[b.GetChannelName()]
+		if !ok {
+			return nil, fmt.Errorf("bundle %q: channel %q not found in package %q", b.GetCsvName(), b.GetChannelName(), pkg.Name)
+		}
+		mb, err := api.ConvertAPIBundleToModelBundle(b)
+		if err != nil {
+			return nil, fmt.Errorf("convert bundle %q: %v", b.GetCsvName(), err)
+		}
+		mb.Package = pkg
+		mb.Channel = ch
+		if d := b.GetDeprecation(); d != nil {
+			mb.Deprecation = &model.Deprecation{Message: d.GetMessage()}
+		}
+		ch.Bundles[mb.Name] = mb
+	}
+	if err := it.Error(); err != nil {
+		return nil, fmt.Errorf("list bundles: %v", err)
+	}
+	return m, nil
+}
+
+type imageSource struct{}
+
+func (imageSource) Name() string { return RefSourceImage }
+
+// Matches returns true for any reference that is not a local path.
+func (imageSource) Matches(ref string) bool { return localPathError(ref) == nil }
+
+func (imageSource) Load(ctx context.Context, ref string, opts LoadRefOptions) (*DeclarativeConfig, error) {
+	if opts.Registry == nil {
+		return nil, fmt.Errorf("%w: no image registry configured", ErrUnsupportedRef)
+	}
+	imageRef := image.SimpleReference(ref)
+	if err := opts.Registry.Pull(ctx, imageRef); err != nil {
+		return nil, fmt.Errorf("pull image: %v", err)
+	}
+	labels, err := opts.Registry.Labels(ctx, imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("get image labels: %v", err)
+	}
+	configsDir, ok := labels[containertools.ConfigsLocationLabel]
+	if !ok {
+		return nil, fmt.Errorf("image is not a file-based catalog: label %q not found", containertools.ConfigsLocationLabel)
+	}
+
+	dir, err := os.MkdirTemp(opts.CacheDir, "image-")
+	if err != nil {
+		return nil, fmt.Errorf("create tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := opts.Registry.Unpack(ctx, imageRef, dir); err != nil {
+		return nil, fmt.Errorf("unpack image: %v", err)
+	}
+	return LoadFS(ctx, os.DirFS(filepath.Join(dir, configsDir)), opts.LoadOptions...)
+}
@@ -0,0 +1,234 @@
+package declcfg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const loadRefCatalog = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v1.0.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v1.0.0
+image: test.registry/foo-operator/foo-bundle:v1.0.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 1.0.0
+`
+
+type fakeRefSource struct {
+	cfg *DeclarativeConfig
+}
+
+func (fakeRefSource) Name() string            { return "fake" }
+func (fakeRefSource) Matches(ref string) bool { return ref == "fake://catalog" }
+func (s fakeRefSource) Load(context.Context, string, LoadRefOptions) (*DeclarativeConfig, error) {
+	return s.cfg, nil
+}
+
+func TestLoadRef(t *testing.T) {
+	dir := t.TempDir()
+
+	catalogDir := filepath.Join(dir, "catalog")
+	require.NoError(t, os.MkdirAll(filepath.Join(catalogDir, "foo"), 0755))
+	catalogFile := filepath.Join(catalogDir, "foo", "catalog.yaml")
+	require.NoError(t, os.WriteFile(catalogFile, []byte(loadRefCatalog), 0600))
+
+	archive := filepath.Join(dir, "catalog.tar.gz")
+	writeTestArchive(t, archive, map[string]string{"foo/catalog.yaml": loadRefCatalog})
+
+	type spec struct {
+		name         string
+		ref          string
+		opts         []LoadRefOption
+		expectSource string
+		assertion    require.ErrorAssertionFunc
+		expectErrIs  error
+		expectBundle string
+	}
+	specs := []spec{
+		{
+			name:         "Success/Dir",
+			ref:          catalogDir,
+			expectSource: RefSourceDir,
+			assertion:    require.NoError,
+			expectBundle: "foo.v1.0.0",
+		},
+		{
+			name:         "Success/File",
+			ref:          catalogFile,
+			expectSource: RefSourceFile,
+			assertion:    require.NoError,
+			expectBundle: "foo.v1.0.0",
+		},
+		{
+			name:         "Success/Archive",
+			ref:          archive,
+			expectSource: RefSourceArchive,
+			assertion:    require.NoError,
+			expectBundle: "foo.v1.0.0",
+		},
+		{
+			name:         "Success/CustomSource",
+			ref:          "fake://catalog",
+			opts:         []LoadRefOption{WithRefSources(fakeRefSource{cfg: &DeclarativeConfig{Bundles: []Bundle{{Name: "fake.v1.0.0"}}}})},
+			expectSource: "fake",
+			assertion:    require.NoError,
+			expectBundle: "fake.v1.0.0",
+		},
+		{
+			name:         "Error/ImageWithoutRegistry",
+			ref:          "test.registry/catalog:latest",
+			expectSource: RefSourceImage,
+			assertion:    require.Error,
+			expectErrIs:  ErrUnsupportedRef,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			src, err := DetectRefSource(s.ref, s.opts...)
+			require.NoError(t, err)
+			require.Equal(t, s.expectSource, src.Name())
+
+			cfg, err := LoadRef(context.Background(), s.ref, s.opts...)
+			s.assertion(t, err)
+			if s.expectErrIs != nil {
+				require.ErrorIs(t, err, s.expectErrIs)
+			}
+			if s.expectBundle != "" {
+				require.Len(t, cfg.Bundles, 1)
+				require.Equal(t, s.expectBundle, cfg.Bundles[0].Name)
+			}
+		})
+	}
+}
+
+func TestLoadRefLocalPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, ref := range []string{
+		filepath.Join(dir, "catalgo"),
+		"./catalgo",
+		"catalgo/",
+		"Catalgo",
+	} {
+		t.Run(ref, func(t *testing.T) {
+			_, err := LoadRef(context.Background(), ref)
+			require.ErrorIs(t, err, ErrUnsupportedRef)
+			require.ErrorContains(t, err, "no such file or directory")
+		})
+	}
+}
+
+func TestDetectRefSourceImage(t *testing.T) {
+	for _, ref := range []string{
+		"catalog",
+		"quay.io/foo/catalog:latest",
+		"localhost:5000/catalog@sha256:" + strings.Repeat("a", 64),
+		"oci-layout:./catalog:latest",
+	} {
+		t.Run(ref, func(t *testing.T) {
+			src, err := DetectRefSource(ref)
+			require.NoError(t, err)
+			require.Equal(t, RefSourceImage, src.Name())
+		})
+	}
+}
+
+func TestLoadRefGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "catalog", "foo"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "catalog", "foo", "catalog.yaml"), []byte(loadRefCatalog), 0600))
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "initial"},
+		{"tag", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	cfg, err := LoadRef(context.Background(), "git+file://"+repo+"#v1:catalog")
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, "foo.v1.0.0", cfg.Bundles[0].Name)
+
+	_, err = LoadRef(context.Background(), "git+file://"+repo+"#--upload-pack=touch /tmp/pwned")
+	require.ErrorContains(t, err, `invalid git revision "--upload-pack=touch /tmp/pwned"`)
+	_, err = LoadRef(context.Background(), "git+--upload-pack=touch /tmp/pwned")
+	require.ErrorContains(t, err, `invalid git repository "--upload-pack=touch /tmp/pwned"`)
+}
+
+func TestParseGitRef(t *testing.T) {
+	repo, revision, subdir := parseGitRef("git+https://example.com/org/catalog.git#main:catalogs/stable")
+	require.Equal(t, "https://example.com/org/catalog.git", repo)
+	require.Equal(t, "main", revision)
+	require.Equal(t, "catalogs/stable", subdir)
+
+	repo, revision, subdir = parseGitRef("git://example.com/org/catalog.git")
+	require.Equal(t, "git://example.com/org/catalog.git", repo)
+	require.Empty(t, revision)
+	require.Empty(t, subdir)
+}
+
+func writeTestArchive(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func TestLoadRefWithPackages(t *testing.T) {
+	dir := t.TempDir()
+	catalog := loadRefCatalog + `---
+schema: olm.package
+name: bar
+`
+	catalogDir := filepath.Join(dir, "catalog")
+	require.NoError(t, os.MkdirAll(catalogDir, 0755))
+	catalogFile := filepath.Join(catalogDir, "catalog.yaml")
+	require.NoError(t, os.WriteFile(catalogFile, []byte(catalog), 0600))
+	archive := filepath.Join(dir, "catalog.tar.gz")
+	writeTestArchive(t, archive, map[string]string{"catalog.yaml": catalog})
+
+	for _, ref := range []string{catalogDir, catalogFile, archive} {
+		t.Run(filepath.Base(ref), func(t *testing.T) {
+			cfg, err := LoadRef(context.Background(), ref, WithLoadOptions(WithPackages("bar")))
+			require.NoError(t, err)
+			require.Equal(t, &DeclarativeConfig{Packages: []Package{{Schema: SchemaPackage, Name: "bar"}}}, cfg)
+		})
+	}
+}
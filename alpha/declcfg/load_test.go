@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/operator-framework/operator-registry/alpha/property"
@@ -96,6 +98,22 @@ func TestLoadReader(t *testing.T) {
 	}
 }
 
+func TestLoadReaderDocumentation(t *testing.T) {
+	const input = `---
+schema: olm.package
+name: foo
+---
+schema: olm.package.documentation
+package: foo
+content: |
+  # foo
+`
+	cfg, err := LoadReader(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Equal(t, []PackageDocumentation{{Schema: SchemaPackageDocumentation, Package: "foo", Content: "# foo\n"}}, cfg.Documentations)
+	require.Empty(t, cfg.Others)
+}
+
 func TestWalkMetasFS(t *testing.T) {
 	type spec struct {
 		name                  string
@@ -170,6 +188,93 @@ func TestWalkMetasFS(t *testing.T) {
 	}
 }
 
+// trackingFS counts the regular files of an fs.FS that are open.
+type trackingFS struct {
+	fs.FS
+	mu   sync.Mutex
+	open int
+}
+
+func (t *trackingFS) Open(name string) (fs.File, error) {
+	f, err := t.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		return f, err
+	}
+	t.mu.Lock()
+	t.open++
+	t.mu.Unlock()
+	return &trackedFile{File: f, fsys: t}, nil
+}
+
+type trackedFile struct {
+	fs.File
+	fsys *trackingFS
+}
+
+func (f *trackedFile) Close() error {
+	f.fsys.mu.Lock()
+	f.fsys.open--
+	f.fsys.mu.Unlock()
+	return f.File.Close()
+}
+
+func TestWalkMetasFSMemory(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 8; i++ {
+		var sb strings.Builder
+		for j := 0; j < 4; j++ {
+			fmt.Fprintf(&sb, `{"schema":"olm.bundle","package":"pkg%d","name":"pkg%d.v0.%d.0"}`+"\n", i, i, j)
+		}
+		fsys[fmt.Sprintf("pkg%d/catalog.json", i)] = &fstest.MapFile{Data: []byte(sb.String())}
+	}
+
+	t.Run("FilesAreClosed", func(t *testing.T) {
+		tfs := &trackingFS{FS: fsys}
+		require.NoError(t, WalkMetasFS(context.Background(), tfs, func(path string, meta *Meta, err error) error {
+			return err
+		}, WithConcurrency(4)))
+		require.Zero(t, tfs.open)
+	})
+
+	t.Run("MaxMemory", func(t *testing.T) {
+		var (
+			mu sync.Mutex
+			// active are the files whose metas are being walked.
+			active          = sets.New[string]()
+			maxActive       int
+			metasPerPackage = map[string]int{}
+		)
+		// With a budget smaller than any file, files are parsed one at a time,
+		// even with several workers.
+		require.NoError(t, WalkMetasFS(context.Background(), fsys, func(path string, meta *Meta, err error) error {
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			active.Insert(path)
+			maxActive = max(maxActive, active.Len())
+			metasPerPackage[meta.Package]++
+			if metasPerPackage[meta.Package] == 4 {
+				active.Delete(path)
+			}
+			return nil
+		}, WithConcurrency(4), WithMaxMemory(1)))
+		require.Len(t, metasPerPackage, 8)
+		require.Equal(t, 1, maxActive)
+	})
+
+	t.Run("NegativeMaxMemory", func(t *testing.T) {
+		err := WalkMetasFS(context.Background(), fsys, func(path string, meta *Meta, err error) error {
+			return err
+		}, WithMaxMemory(-1))
+		require.EqualError(t, err, "invalid max memory -1: must not be negative")
+	})
+}
+
 func TestLoadFS(t *testing.T) {
 	type spec struct {
 		name      string
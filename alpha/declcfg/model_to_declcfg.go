@@ -32,6 +32,14 @@ func ConvertFromModel(mpkgs model.Model) DeclarativeConfig {
 		})
 		cfg.Channels = append(cfg.Channels, channels...)
 		cfg.Bundles = append(cfg.Bundles, bundles...)
+		if mpkg.Documentation != nil {
+			cfg.Documentations = append(cfg.Documentations, PackageDocumentation{
+				Schema:  SchemaPackageDocumentation,
+				Package: mpkg.Name,
+				Content: mpkg.Documentation.Content,
+				URL:     mpkg.Documentation.URL,
+			})
+		}
 	}
 
 	sort.Slice(cfg.Packages, func(i, j int) bool {
@@ -49,6 +57,9 @@ func ConvertFromModel(mpkgs model.Model) DeclarativeConfig {
 		}
 		return cfg.Bundles[i].Name < cfg.Bundles[j].Name
 	})
+	sort.Slice(cfg.Documentations, func(i, j int) bool {
+		return cfg.Documentations[i].Package < cfg.Documentations[j].Package
+	})
 
 	return cfg
 }
@@ -0,0 +1,353 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// Patch operations of overlay objects, and of the items of their sequences,
+// set in their "$patch" field.
+const (
+	// PatchMerge merges the fields of the overlay into those of the object
+	// it patches. It is the default operation.
+	PatchMerge = "merge"
+	// PatchReplace replaces the object that the overlay patches, or adds the
+	// overlay as a new object if there is none.
+	PatchReplace = "replace"
+	// PatchDelete deletes the object that the overlay patches.
+	PatchDelete = "delete"
+
+	patchKey = "$patch"
+)
+
+// An Overlay is a list of patches of the objects of a declarative config,
+// e.g. to change the default channel of a package, add a skipRange to a
+// channel entry or annotate a bundle, so that such changes can be kept in
+// version control rather than scripted.
+//
+// An overlay is a stream of YAML or JSON objects, in the format of those of
+// declarative configs. Each object patches the object of the declarative
+// config with the same schema, package and name, with the operation in its
+// "$patch" field: PatchMerge (the default), PatchReplace, or PatchDelete.
+//
+// Merges are strategic: mappings are merged key by key, and null values
+// delete their key. The items of a sequence are merged into the items of
+// the patched sequence that have the same name, or, for items without a
+// name, e.g. properties, into the item that is equal to them or else the
+// only item of the same type. Other items are appended. Sequence items can
+// also have a "$patch" field, to replace or delete the items that they
+// match. A sequence whose first item is {"$patch": "replace"} replaces the
+// patched sequence with its other items.
+type Overlay struct {
+	patches []overlayPatch
+}
+
+type overlayPatch struct {
+	key    ObjectKey
+	op     string
+	fields map[string]interface{}
+}
+
+// LoadOverlay reads an overlay from a stream of YAML or JSON objects.
+func LoadOverlay(r io.Reader) (*Overlay, error) {
+	var o Overlay
+	if err := WalkMetasReader(r, func(meta *Meta, err error) error {
+		if err != nil {
+			return err
+		}
+		if meta.Schema == "" {
+			return fmt.Errorf("overlay object '%s' is missing root schema field", string(meta.Blob))
+		}
+		fields, err := decodeFields(meta.Blob)
+		if err != nil {
+			return err
+		}
+		p := overlayPatch{key: metaKey(*meta), op: PatchMerge, fields: fields}
+		if op, ok := fields[patchKey]; ok {
+			if p.op, err = patchOp(op); err != nil {
+				return fmt.Errorf("overlay of %s: %v", p.key, err)
+			}
+			delete(fields, patchKey)
+		}
+		o.patches = append(o.patches, p)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Apply patches the objects of cfg with the patches of o, in order. It is an
+// error to merge or delete an object that is not in cfg, or that shares its
+// key with other objects.
+func (o Overlay) Apply(cfg *DeclarativeConfig) error {
+	metas, err := configMetas(*cfg)
+	if err != nil {
+		return err
+	}
+	for _, p := range o.patches {
+		var matches []int
+		for i, m := range metas {
+			if metaKey(*m) == p.key {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) > 1 {
+			return fmt.Errorf("overlay of %s: %d objects match", p.key, len(matches))
+		}
+		if len(matches) == 0 && p.op != PatchReplace {
+			return fmt.Errorf("overlay of %s: no such object", p.key)
+		}
+
+		var obj map[string]interface{}
+		switch p.op {
+		case PatchDelete:
+			metas = slices.Delete(metas, matches[0], matches[0]+1)
+			continue
+		case PatchReplace:
+			obj = map[string]interface{}{}
+		case PatchMerge:
+			if obj, err = decodeFields(metas[matches[0]].Blob); err != nil {
+				return err
+			}
+		}
+		if err := mergeFields(obj, p.fields); err != nil {
+			return fmt.Errorf("overlay of %s: %v", p.key, err)
+		}
+		meta, err := encodeMeta(obj)
+		if err != nil {
+			return fmt.Errorf("overlay of %s: %v", p.key, err)
+		}
+		if len(matches) == 0 {
+			metas = append(metas, meta)
+		} else {
+			metas[matches[0]] = meta
+		}
+	}
+
+	patched, err := LoadSlice(metas)
+	if err != nil {
+		return err
+	}
+	*cfg = *patched
+	return nil
+}
+
+func metaKey(m Meta) ObjectKey {
+	return ObjectKey{Schema: m.Schema, Package: m.Package, Name: m.Name}
+}
+
+func patchOp(v interface{}) (string, error) {
+	switch v {
+	case PatchMerge, PatchReplace, PatchDelete:
+		return v.(string), nil
+	}
+	return "", fmt.Errorf("invalid %s %v, expected (%s|%s|%s)", patchKey, v, PatchMerge, PatchReplace, PatchDelete)
+}
+
+// configMetas returns the objects of cfg as metas, in the order of the
+// fields of DeclarativeConfig.
+func configMetas(cfg DeclarativeConfig) ([]*Meta, error) {
+	var vs []interface{}
+	for _, v := range cfg.Packages {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Channels {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Bundles {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Deprecations {
+		vs = append(vs, v)
+	}
+	for _, v := range cfg.Documentations {
+		vs = append(vs, v)
+	}
+
+	metas := make([]*Meta, 0, len(vs)+len(cfg.Others))
+	for _, v := range vs {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var m Meta
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		metas = append(metas, &m)
+	}
+	for i := range cfg.Others {
+		m := cfg.Others[i]
+		metas = append(metas, &m)
+	}
+	return metas, nil
+}
+
+func decodeFields(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func encodeMeta(fields map[string]interface{}) (*Meta, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(fields); err != nil {
+		return nil, err
+	}
+	var m Meta
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// mergeFields merges the fields of patch into dst.
+func mergeFields(dst, patch map[string]interface{}) error {
+	keys := make([]string, 0, len(patch))
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if k == patchKey {
+			continue
+		}
+		merged, err := mergeValue(dst[k], patch[k])
+		if err != nil {
+			return fmt.Errorf("%s: %v", k, err)
+		}
+		if merged == nil {
+			delete(dst, k)
+		} else {
+			dst[k] = merged
+		}
+	}
+	return nil
+}
+
+// mergeValue returns the value of patch merged into dst.
+func mergeValue(dst, patch interface{}) (interface{}, error) {
+	switch p := patch.(type) {
+	case map[string]interface{}:
+		if op, ok := p[patchKey]; ok && op != PatchMerge && op != PatchReplace {
+			return nil, fmt.Errorf("invalid %s %v in mapping, expected (%s|%s)", patchKey, op, PatchMerge, PatchReplace)
+		}
+		d, ok := dst.(map[string]interface{})
+		if !ok || p[patchKey] == PatchReplace {
+			d = map[string]interface{}{}
+		}
+		if err := mergeFields(d, p); err != nil {
+			return nil, err
+		}
+		return d, nil
+	case []interface{}:
+		d, _ := dst.([]interface{})
+		return mergeItems(d, p)
+	}
+	return patch, nil
+}
+
+// mergeItems returns the items of patch merged into those of dst.
+func mergeItems(dst, patch []interface{}) ([]interface{}, error) {
+	out := append([]interface{}{}, dst...)
+	if len(patch) > 0 && reflect.DeepEqual(patch[0], map[string]interface{}{patchKey: PatchReplace}) {
+		out, patch = []interface{}{}, patch[1:]
+	}
+	for _, item := range patch {
+		p, ok := item.(map[string]interface{})
+		if !ok {
+			if !slices.ContainsFunc(out, func(v interface{}) bool { return reflect.DeepEqual(v, item) }) {
+				out = append(out, item)
+			}
+			continue
+		}
+
+		op := PatchMerge
+		if v, ok := p[patchKey]; ok {
+			var err error
+			if op, err = patchOp(v); err != nil {
+				return nil, err
+			}
+		}
+		i := matchItem(out, p)
+		switch {
+		case op == PatchDelete && i < 0:
+			return nil, fmt.Errorf("no item to delete matches %s", itemString(p))
+		case op == PatchDelete:
+			out = slices.Delete(out, i, i+1)
+		case i < 0:
+			merged, err := mergeValue(nil, p)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, merged)
+		default:
+			if op == PatchReplace {
+				out[i] = nil
+			}
+			merged, err := mergeValue(out[i], p)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = merged
+		}
+	}
+	return out, nil
+}
+
+// matchItem returns the index of the item of items that patch applies to,
+// or -1 if there is none.
+func matchItem(items []interface{}, patch map[string]interface{}) int {
+	if name, ok := patch["name"].(string); ok {
+		return slices.IndexFunc(items, func(v interface{}) bool {
+			m, ok := v.(map[string]interface{})
+			return ok && m["name"] == name
+		})
+	}
+
+	fields := make(map[string]interface{}, len(patch))
+	for k, v := range patch {
+		if k != patchKey {
+			fields[k] = v
+		}
+	}
+	if i := slices.IndexFunc(items, func(v interface{}) bool { return reflect.DeepEqual(v, fields) }); i >= 0 {
+		return i
+	}
+
+	typ, ok := patch["type"].(string)
+	if !ok {
+		return -1
+	}
+	match := -1
+	for i, v := range items {
+		if m, ok := v.(map[string]interface{}); ok && m["type"] == typ {
+			if match >= 0 {
+				return -1
+			}
+			match = i
+		}
+	}
+	return match
+}
+
+func itemString(item map[string]interface{}) string {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Sprint(item)
+	}
+	return string(data)
+}
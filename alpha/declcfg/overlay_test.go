@@ -0,0 +1,232 @@
+package declcfg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayApply(t *testing.T) {
+	const base = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v1
+  - name: foo.v2
+    replaces: foo.v1
+---
+schema: olm.bundle
+package: foo
+name: foo.v1
+image: foo:v1
+properties:
+  - type: olm.package
+    value: {packageName: foo, version: 1.0.0}
+  - type: olm.gvk
+    value: {group: foo.io, kind: Foo, version: v1}
+  - type: olm.gvk
+    value: {group: foo.io, kind: Bar, version: v1}
+  - type: olm.csv.metadata
+    value: {annotations: {a: "1"}, displayName: Foo}
+---
+schema: olm.bundle
+package: foo
+name: foo.v2
+image: foo:v2
+properties:
+  - type: olm.package
+    value: {packageName: foo, version: 2.0.0}
+`
+	type spec struct {
+		name    string
+		overlay string
+		// want is the patched base, as the replacements of strings of it.
+		want    []string
+		wantErr string
+	}
+	for _, s := range []spec{
+		{
+			name:    "DefaultChannel",
+			overlay: "schema: olm.package\nname: foo\ndefaultChannel: fast\n",
+			want:    []string{"defaultChannel: stable", "defaultChannel: fast"},
+		},
+		{
+			name: "SkipRange",
+			overlay: `schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v2
+    skipRange: <2.0.0
+`,
+			want: []string{"    replaces: foo.v1\n", "    replaces: foo.v1\n    skipRange: <2.0.0\n"},
+		},
+		{
+			name: "DeleteField",
+			overlay: `schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v2
+    replaces: null
+`,
+			want: []string{"    replaces: foo.v1\n", ""},
+		},
+		{
+			name: "AddEntry",
+			overlay: `schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v3
+    replaces: foo.v2
+`,
+			want: []string{"    replaces: foo.v1\n", "    replaces: foo.v1\n  - name: foo.v3\n    replaces: foo.v2\n"},
+		},
+		{
+			name: "ReplaceEntries",
+			overlay: `schema: olm.channel
+package: foo
+name: stable
+entries:
+  - $patch: replace
+  - name: foo.v2
+`,
+			want: []string{"  - name: foo.v1\n  - name: foo.v2\n    replaces: foo.v1\n", "  - name: foo.v2\n"},
+		},
+		{
+			name: "DeleteEntry",
+			overlay: `schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v1
+    $patch: delete
+`,
+			want: []string{"  - name: foo.v1\n", ""},
+		},
+		{
+			name: "AnnotateBundle",
+			overlay: `schema: olm.bundle
+package: foo
+name: foo.v1
+properties:
+  - type: olm.csv.metadata
+    value: {annotations: {b: "2"}}
+`,
+			want: []string{`annotations: {a: "1"}`, `annotations: {a: "1", b: "2"}`},
+		},
+		{
+			name: "AddProperty",
+			overlay: `schema: olm.bundle
+package: foo
+name: foo.v1
+properties:
+  - type: olm.gvk
+    value: {group: foo.io, kind: Baz, version: v1}
+`,
+			want: []string{
+				"    value: {annotations: {a: \"1\"}, displayName: Foo}\n",
+				"    value: {annotations: {a: \"1\"}, displayName: Foo}\n  - type: olm.gvk\n    value: {group: foo.io, kind: Baz, version: v1}\n",
+			},
+		},
+		{
+			name: "DeleteProperty",
+			overlay: `schema: olm.bundle
+package: foo
+name: foo.v1
+properties:
+  - type: olm.gvk
+    value: {group: foo.io, kind: Foo, version: v1}
+    $patch: delete
+`,
+			want: []string{"  - type: olm.gvk\n    value: {group: foo.io, kind: Foo, version: v1}\n", ""},
+		},
+		{
+			name: "ReplaceProperty",
+			overlay: `schema: olm.bundle
+package: foo
+name: foo.v2
+properties:
+  - type: olm.package
+    value: {packageName: foo, version: 2.0.1}
+    $patch: replace
+`,
+			want: []string{"version: 2.0.0", "version: 2.0.1"},
+		},
+		{
+			name:    "DeleteObject",
+			overlay: "schema: olm.bundle\npackage: foo\nname: foo.v2\n$patch: delete\n",
+			want:    []string{base[strings.Index(base, "---\nschema: olm.bundle\npackage: foo\nname: foo.v2"):], ""},
+		},
+		{
+			name: "AddObject",
+			overlay: `schema: olm.channel
+package: foo
+name: fast
+entries:
+  - name: foo.v2
+$patch: replace
+`,
+			want: []string{"---\nschema: olm.bundle\npackage: foo\nname: foo.v1", "---\nschema: olm.channel\npackage: foo\nname: fast\nentries:\n  - name: foo.v2\n---\nschema: olm.bundle\npackage: foo\nname: foo.v1"},
+		},
+		{
+			name: "InOrder",
+			overlay: `schema: olm.package
+name: foo
+defaultChannel: fast
+---
+schema: olm.package
+name: foo
+defaultChannel: candidate
+`,
+			want: []string{"defaultChannel: stable", "defaultChannel: candidate"},
+		},
+		{
+			name:    "Error/NoSuchObject",
+			overlay: "schema: olm.bundle\npackage: foo\nname: foo.v3\nimage: foo:v3\n",
+			wantErr: "overlay of olm.bundle foo/foo.v3: no such object",
+		},
+		{
+			name:    "Error/InvalidPatch",
+			overlay: "schema: olm.package\nname: foo\n$patch: remove\n",
+			wantErr: "overlay of olm.package foo: invalid $patch remove",
+		},
+		{
+			name: "Error/NoSuchItem",
+			overlay: `schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v3
+    $patch: delete
+`,
+			wantErr: `overlay of olm.channel foo/stable: entries: no item to delete matches {"$patch":"delete","name":"foo.v3"}`,
+		},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := LoadReader(strings.NewReader(base))
+			require.NoError(t, err)
+
+			o, err := LoadOverlay(strings.NewReader(s.overlay))
+			if err == nil {
+				err = o.Apply(cfg)
+			}
+			if s.wantErr != "" {
+				require.ErrorContains(t, err, s.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			want, err := LoadReader(strings.NewReader(strings.NewReplacer(s.want...).Replace(base)))
+			require.NoError(t, err)
+			require.Equal(t, want, cfg)
+		})
+	}
+}
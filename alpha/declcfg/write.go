@@ -20,6 +20,7 @@ import (
 type MermaidWriter struct {
 	MinEdgeName          string
 	SpecifiedPackageName string
+	Highlights           GraphHighlights
 }
 
 type MermaidOption func(*MermaidWriter)
@@ -32,6 +33,7 @@ func NewMermaidWriter(opts ...MermaidOption) *MermaidWriter {
 	m := &MermaidWriter{
 		MinEdgeName:          minEdgeName,
 		SpecifiedPackageName: specifiedPackageName,
+		Highlights:           GraphHighlights{Deprecations: true},
 	}
 
 	for _, opt := range opts {
@@ -52,6 +54,14 @@ func WithSpecifiedPackageName(specifiedPackageName string) MermaidOption {
 	}
 }
 
+// WithHighlights sets the elements of the graph that are highlighted. By
+// default, only deprecations are highlighted.
+func WithHighlights(highlights GraphHighlights) MermaidOption {
+	return func(o *MermaidWriter) {
+		o.Highlights = highlights
+	}
+}
+
 // writes out the channel edges of the declarative config graph in a mermaid format capable of being pasted into
 // mermaid renderers like github, mermaid.live, etc.
 // output is sorted lexicographically by package name, and then by channel name
@@ -73,158 +83,75 @@ func WithSpecifiedPackageName(specifiedPackageName string) MermaidOption {
 //	  end
 //
 // end
+//
+// Highlighted skip edges are dotted, and highlighted skipRange edges are thick.
 func (writer *MermaidWriter) WriteChannels(cfg DeclarativeConfig, out io.Writer) error {
-	pkgs := map[string]*strings.Builder{}
-
-	sort.Slice(cfg.Channels, func(i, j int) bool {
-		return cfg.Channels[i].Name < cfg.Channels[j].Name
-	})
-
-	versionMap, err := getBundleVersions(&cfg)
+	graph, err := buildUpgradeGraph(cfg, writer.MinEdgeName, writer.SpecifiedPackageName)
 	if err != nil {
 		return err
 	}
-
-	// establish a 'floor' version, either specified by user or entirely open
-	minVersion := semver.Version{Major: 0, Minor: 0, Patch: 0}
-
-	if writer.MinEdgeName != "" {
-		if _, ok := versionMap[writer.MinEdgeName]; !ok {
-			return fmt.Errorf("unknown minimum edge name: %q", writer.MinEdgeName)
-		}
-		minVersion = versionMap[writer.MinEdgeName]
-	}
-
-	// build increasing-version-ordered bundle names, so we can meaningfully iterate over a range
-	orderedBundles := []string{}
-	for n := range versionMap {
-		orderedBundles = append(orderedBundles, n)
-	}
-	sort.Slice(orderedBundles, func(i, j int) bool {
-		return versionMap[orderedBundles[i]].LT(versionMap[orderedBundles[j]])
-	})
-
-	minEdgePackage := writer.getMinEdgePackage(&cfg)
-
-	depByPackage := sets.Set[string]{}
-	depByChannel := sets.Set[string]{}
-	depByBundle := sets.Set[string]{}
-
-	for _, d := range cfg.Deprecations {
-		for _, e := range d.Entries {
-			switch e.Reference.Schema {
-			case SchemaPackage:
-				depByPackage.Insert(d.Package)
-			case SchemaChannel:
-				depByChannel.Insert(e.Reference.Name)
-			case SchemaBundle:
-				depByBundle.Insert(e.Reference.Name)
-			}
-		}
-	}
-
-	var deprecatedPackage string
-	deprecatedChannels := []string{}
-
-	for _, c := range cfg.Channels {
-		filteredChannel := writer.filterChannel(&c, versionMap, minVersion, minEdgePackage)
-		if filteredChannel != nil {
-			pkgBuilder, ok := pkgs[c.Package]
-			if !ok {
-				pkgBuilder = &strings.Builder{}
-				pkgs[c.Package] = pkgBuilder
-			}
-
-			channelID := fmt.Sprintf("%s-%s", filteredChannel.Package, filteredChannel.Name)
-			pkgBuilder.WriteString(fmt.Sprintf("    %%%% channel %q\n", filteredChannel.Name))
-			pkgBuilder.WriteString(fmt.Sprintf("    subgraph %s[%q]\n", channelID, filteredChannel.Name))
-
-			if depByPackage.Has(filteredChannel.Package) {
-				deprecatedPackage = filteredChannel.Package
-			}
-
-			if depByChannel.Has(filteredChannel.Name) {
-				deprecatedChannels = append(deprecatedChannels, channelID)
-			}
-
-			for _, ce := range filteredChannel.Entries {
-				if versionMap[ce.Name].GE(minVersion) {
-					bundleDeprecation := ""
-					if depByBundle.Has(ce.Name) {
-						bundleDeprecation = ":::deprecated"
-					}
-
-					entryId := fmt.Sprintf("%s-%s", channelID, ce.Name)
-					pkgBuilder.WriteString(fmt.Sprintf("      %s[%q]%s\n", entryId, ce.Name, bundleDeprecation))
-
-					if len(ce.Replaces) > 0 {
-						replacesId := fmt.Sprintf("%s-%s", channelID, ce.Replaces)
-						pkgBuilder.WriteString(fmt.Sprintf("      %s[%q]-- %s --> %s[%q]\n", replacesId, ce.Replaces, "replace", entryId, ce.Name))
-					}
-					if len(ce.Skips) > 0 {
-						for _, s := range ce.Skips {
-							skipsId := fmt.Sprintf("%s-%s", channelID, s)
-							pkgBuilder.WriteString(fmt.Sprintf("      %s[%q]-- %s --> %s[%q]\n", skipsId, s, "skip", entryId, ce.Name))
-						}
-					}
-					if len(ce.SkipRange) > 0 {
-						skipRange, err := semver.ParseRange(ce.SkipRange)
-						if err == nil {
-							for _, edgeName := range filteredChannel.Entries {
-								if skipRange(versionMap[edgeName.Name]) {
-									skipRangeId := fmt.Sprintf("%s-%s", channelID, edgeName.Name)
-									pkgBuilder.WriteString(fmt.Sprintf("      %s[%q]-- \"%s(%s)\" --> %s[%q]\n", skipRangeId, edgeName.Name, "skipRange", ce.SkipRange, entryId, ce.Name))
-								}
-							}
-						} else {
-							fmt.Fprintf(os.Stderr, "warning: ignoring invalid SkipRange for package/edge %q/%q: %v\n", c.Package, ce.Name, err)
-						}
+	highlights := writer.Highlights
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	if highlights.Deprecations {
+		b.WriteString("  classDef deprecated fill:#E8960F\n")
+	}
+	for _, pkg := range graph.packages {
+		b.WriteString(fmt.Sprintf("  %%%% package %q\n", pkg.name))
+		b.WriteString(fmt.Sprintf("  subgraph %q\n", pkg.name))
+		for _, ch := range pkg.channels {
+			b.WriteString(fmt.Sprintf("    %%%% channel %q\n", ch.name))
+			b.WriteString(fmt.Sprintf("    subgraph %s[%q]\n", ch.id, ch.name))
+			for _, n := range ch.nodes {
+				bundleDeprecation := ""
+				if highlights.Deprecations && n.deprecated {
+					bundleDeprecation = ":::deprecated"
+				}
+				b.WriteString(fmt.Sprintf("      %s[%q]%s\n", n.id, n.name, bundleDeprecation))
+				for _, e := range n.edges {
+					arrow := fmt.Sprintf("-- %s -->", e.label)
+					switch {
+					case e.kind == edgeSkip && highlights.Skips:
+						arrow = fmt.Sprintf("-. %s .->", e.label)
+					case e.kind == edgeSkipRange && highlights.SkipRanges:
+						arrow = fmt.Sprintf("== \"%s\" ==>", e.label)
+					case e.kind == edgeSkipRange:
+						arrow = fmt.Sprintf("-- \"%s\" -->", e.label)
 					}
+					b.WriteString(fmt.Sprintf("      %s[%q]%s %s[%q]\n", e.fromID, e.fromName, arrow, n.id, n.name))
 				}
 			}
-			pkgBuilder.WriteString("    end\n")
+			b.WriteString("    end\n")
 		}
+		b.WriteString("  end\n")
 	}
 
-	out.Write([]byte("graph LR\n"))
-	out.Write([]byte(fmt.Sprintf("  classDef deprecated fill:#E8960F\n")))
-	pkgNames := []string{}
-	for pname := range pkgs {
-		pkgNames = append(pkgNames, pname)
-	}
-	sort.Slice(pkgNames, func(i, j int) bool {
-		return pkgNames[i] < pkgNames[j]
-	})
-	for _, pkgName := range pkgNames {
-		out.Write([]byte(fmt.Sprintf("  %%%% package %q\n", pkgName)))
-		out.Write([]byte(fmt.Sprintf("  subgraph %q\n", pkgName)))
-		out.Write([]byte(pkgs[pkgName].String()))
-		out.Write([]byte("  end\n"))
-	}
-
-	if deprecatedPackage != "" {
-		out.Write([]byte(fmt.Sprintf("style %s fill:#989695\n", deprecatedPackage)))
-	}
-
-	if len(deprecatedChannels) > 0 {
-		for _, deprecatedChannel := range deprecatedChannels {
-			out.Write([]byte(fmt.Sprintf("style %s fill:#DCD0FF\n", deprecatedChannel)))
+	if highlights.Deprecations {
+		for _, pkg := range graph.packages {
+			if pkg.deprecated {
+				b.WriteString(fmt.Sprintf("style %s fill:#989695\n", pkg.name))
+			}
+		}
+		for _, id := range graph.deprecatedChannelIDs {
+			b.WriteString(fmt.Sprintf("style %s fill:#DCD0FF\n", id))
 		}
 	}
 
-	return nil
+	_, err = io.WriteString(out, b.String())
+	return err
 }
 
 // filters the channel edges to include only those which are greater-than-or-equal to the edge named by startVersion
 // returns a nil channel if all edges are filtered out
-func (writer *MermaidWriter) filterChannel(c *Channel, versionMap map[string]semver.Version, minVersion semver.Version, minEdgePackage string) *Channel {
+func filterChannel(c *Channel, minEdgeName, specifiedPackageName string, versionMap map[string]semver.Version, minVersion semver.Version, minEdgePackage string) *Channel {
 	// short-circuit if no active filters
-	if writer.MinEdgeName == "" && writer.SpecifiedPackageName == "" {
+	if minEdgeName == "" && specifiedPackageName == "" {
 		return c
 	}
 
 	// short-circuit if channel's package doesn't match filter
-	if writer.SpecifiedPackageName != "" && c.Package != writer.SpecifiedPackageName {
+	if specifiedPackageName != "" && c.Package != specifiedPackageName {
 		return nil
 	}
 
@@ -236,7 +163,7 @@ func (writer *MermaidWriter) filterChannel(c *Channel, versionMap map[string]sem
 	out := &Channel{Name: c.Name, Package: c.Package, Properties: c.Properties, Entries: []ChannelEntry{}}
 	for _, ce := range c.Entries {
 		filteredCe := ChannelEntry{Name: ce.Name}
-		if writer.MinEdgeName == "" {
+		if minEdgeName == "" {
 			// no minimum-edge specified
 			filteredCe.SkipRange = ce.SkipRange
 			filteredCe.Replaces = ce.Replaces
@@ -252,7 +179,7 @@ func (writer *MermaidWriter) filterChannel(c *Channel, versionMap map[string]sem
 				}
 			}
 		} else {
-			if ce.Name == writer.MinEdgeName {
+			if ce.Name == minEdgeName {
 				// edge is the 'floor', meaning that since all references are "backward references", and we don't want any references from this edge
 				// accumulate w/o references
 				out.Entries = append(out.Entries, filteredCe)
@@ -332,14 +259,14 @@ func getBundleVersions(cfg *DeclarativeConfig) (map[string]semver.Version, error
 	return entries, nil
 }
 
-func (writer *MermaidWriter) getMinEdgePackage(cfg *DeclarativeConfig) string {
-	if writer.MinEdgeName == "" {
+func getMinEdgePackage(cfg *DeclarativeConfig, minEdgeName string) string {
+	if minEdgeName == "" {
 		return ""
 	}
 
 	for _, c := range cfg.Channels {
 		for _, ce := range c.Entries {
-			if writer.MinEdgeName == ce.Name {
+			if minEdgeName == ce.Name {
 				return c.Package
 			}
 		}
@@ -428,6 +355,13 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 		deprecationsByPackage[pkgName] = append(deprecationsByPackage[pkgName], d)
 	}
 
+	docsByPackage := map[string][]PackageDocumentation{}
+	for _, d := range cfg.Documentations {
+		pkgName := d.Package
+		pkgNames.Insert(pkgName)
+		docsByPackage[pkgName] = append(docsByPackage[pkgName], d)
+	}
+
 	for _, pName := range pkgNames.List() {
 		if len(pName) == 0 {
 			continue
@@ -440,7 +374,7 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 		}
 
 		channels := channelsByPackage[pName]
-		sort.Slice(channels, func(i, j int) bool {
+		sort.SliceStable(channels, func(i, j int) bool {
 			return channels[i].Name < channels[j].Name
 		})
 		for _, c := range channels {
@@ -450,7 +384,7 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 		}
 
 		bundles := bundlesByPackage[pName]
-		sort.Slice(bundles, func(i, j int) bool {
+		sort.SliceStable(bundles, func(i, j int) bool {
 			return bundles[i].Name < bundles[j].Name
 		})
 		for _, b := range bundles {
@@ -485,6 +419,12 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 				return err
 			}
 		}
+
+		for _, d := range docsByPackage[pName] {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, o := range othersByPackage[""] {
@@ -498,38 +438,142 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 
 type WriteFunc func(config DeclarativeConfig, w io.Writer) error
 
-func WriteFS(cfg DeclarativeConfig, rootDir string, writeFunc WriteFunc, fileExt string) error {
-	channelsByPackage := map[string][]Channel{}
-	for _, c := range cfg.Channels {
-		channelsByPackage[c.Package] = append(channelsByPackage[c.Package], c)
+// FSLayout determines how WriteFS splits a declarative config into files.
+type FSLayout string
+
+const (
+	// FSLayoutPackage writes the objects of each package to
+	// <rootDir>/<package>/catalog<fileExt>.
+	FSLayoutPackage FSLayout = "package"
+
+	// FSLayoutSchema writes the objects of each package to one file per
+	// schema, <rootDir>/<package>/<schema><fileExt>, e.g.
+	// <rootDir>/foo/olm.bundle.yaml.
+	FSLayoutSchema FSLayout = "schema"
+)
+
+type WriteFSOptions struct {
+	Layout FSLayout
+}
+
+type WriteFSOption func(*WriteFSOptions)
+
+// WithFSLayout sets the layout used by WriteFS. The default is
+// FSLayoutPackage.
+func WithFSLayout(layout FSLayout) WriteFSOption {
+	return func(o *WriteFSOptions) {
+		o.Layout = layout
 	}
-	bundlesByPackage := map[string][]Bundle{}
-	for _, b := range cfg.Bundles {
-		bundlesByPackage[b.Package] = append(bundlesByPackage[b.Package], b)
+}
+
+// WriteFS writes cfg to rootDir, split into files according to the
+// configured layout. Objects that do not belong to a package are written
+// directly in rootDir.
+func WriteFS(cfg DeclarativeConfig, rootDir string, writeFunc WriteFunc, fileExt string, opts ...WriteFSOption) error {
+	options := WriteFSOptions{Layout: FSLayoutPackage}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Layout != FSLayoutPackage && options.Layout != FSLayoutSchema {
+		return fmt.Errorf("unknown layout %q, expected one of %q or %q", options.Layout, FSLayoutPackage, FSLayoutSchema)
 	}
 
 	if err := os.MkdirAll(rootDir, 0777); err != nil {
 		return err
 	}
 
-	for _, p := range cfg.Packages {
-		fcfg := DeclarativeConfig{
-			Packages: []Package{p},
-			Channels: channelsByPackage[p.Name],
-			Bundles:  bundlesByPackage[p.Name],
-		}
-		pkgDir := filepath.Join(rootDir, p.Name)
+	byPackage := SplitByPackage(cfg)
+	pkgNames := make([]string, 0, len(byPackage))
+	for pkgName := range byPackage {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	for _, pkgName := range pkgNames {
+		pkgDir := filepath.Join(rootDir, pkgName)
 		if err := os.MkdirAll(pkgDir, 0777); err != nil {
 			return err
 		}
-		filename := filepath.Join(pkgDir, fmt.Sprintf("catalog%s", fileExt))
-		if err := writeFile(fcfg, filename, writeFunc); err != nil {
-			return err
+
+		files := map[string]DeclarativeConfig{"catalog": *byPackage[pkgName]}
+		if options.Layout == FSLayoutSchema {
+			files = splitBySchema(*byPackage[pkgName])
+		}
+		for name, fcfg := range files {
+			filename := filepath.Join(pkgDir, fmt.Sprintf("%s%s", name, fileExt))
+			if err := writeFile(fcfg, filename, writeFunc); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// SplitByPackage groups the objects in cfg by the package they belong to.
+// Objects that do not belong to a package are grouped under "".
+func SplitByPackage(cfg DeclarativeConfig) map[string]*DeclarativeConfig {
+	byPackage := map[string]*DeclarativeConfig{}
+	get := func(name string) *DeclarativeConfig {
+		if _, ok := byPackage[name]; !ok {
+			byPackage[name] = &DeclarativeConfig{}
+		}
+		return byPackage[name]
+	}
+	for _, p := range cfg.Packages {
+		pcfg := get(p.Name)
+		pcfg.Packages = append(pcfg.Packages, p)
+	}
+	for _, c := range cfg.Channels {
+		pcfg := get(c.Package)
+		pcfg.Channels = append(pcfg.Channels, c)
+	}
+	for _, b := range cfg.Bundles {
+		pcfg := get(b.Package)
+		pcfg.Bundles = append(pcfg.Bundles, b)
+	}
+	for _, d := range cfg.Deprecations {
+		pcfg := get(d.Package)
+		pcfg.Deprecations = append(pcfg.Deprecations, d)
+	}
+	for _, d := range cfg.Documentations {
+		pcfg := get(d.Package)
+		pcfg.Documentations = append(pcfg.Documentations, d)
+	}
+	for _, o := range cfg.Others {
+		pcfg := get(o.Package)
+		pcfg.Others = append(pcfg.Others, o)
+	}
+	return byPackage
+}
+
+// splitBySchema groups the objects in cfg by schema, keyed by a file name
+// derived from the schema.
+func splitBySchema(cfg DeclarativeConfig) map[string]DeclarativeConfig {
+	bySchema := map[string]DeclarativeConfig{}
+	if len(cfg.Packages) > 0 {
+		bySchema[SchemaPackage] = DeclarativeConfig{Packages: cfg.Packages}
+	}
+	if len(cfg.Channels) > 0 {
+		bySchema[SchemaChannel] = DeclarativeConfig{Channels: cfg.Channels}
+	}
+	if len(cfg.Bundles) > 0 {
+		bySchema[SchemaBundle] = DeclarativeConfig{Bundles: cfg.Bundles}
+	}
+	if len(cfg.Deprecations) > 0 {
+		bySchema[SchemaDeprecation] = DeclarativeConfig{Deprecations: cfg.Deprecations}
+	}
+	if len(cfg.Documentations) > 0 {
+		bySchema[SchemaPackageDocumentation] = DeclarativeConfig{Documentations: cfg.Documentations}
+	}
+	for _, o := range cfg.Others {
+		name := strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(o.Schema)
+		ocfg := bySchema[name]
+		ocfg.Others = append(ocfg.Others, o)
+		bySchema[name] = ocfg
+	}
+	return bySchema
+}
+
 func writeFile(cfg DeclarativeConfig, filename string, writeFunc WriteFunc) error {
 	buf := &bytes.Buffer{}
 	if err := writeFunc(cfg, buf); err != nil {
@@ -0,0 +1,268 @@
+package declcfg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// GraphHighlights selects the elements of an upgrade graph that are visually
+// distinguished from the others when it is written by a MermaidWriter or a
+// DotWriter.
+type GraphHighlights struct {
+	// Skips highlights the edges of channel entries' skips.
+	Skips bool
+	// SkipRanges highlights the edges covered by channel entries' skipRange.
+	SkipRanges bool
+	// Deprecations highlights deprecated packages, channels and bundles.
+	Deprecations bool
+}
+
+type edgeKind int
+
+const (
+	edgeReplace edgeKind = iota
+	edgeSkip
+	edgeSkipRange
+)
+
+// graphEdge is an upgrade edge from the entry fromName to the node that
+// holds it.
+type graphEdge struct {
+	kind     edgeKind
+	label    string
+	fromID   string
+	fromName string
+}
+
+type graphNode struct {
+	id         string
+	name       string
+	deprecated bool
+	edges      []graphEdge
+}
+
+type graphChannel struct {
+	id    string
+	name  string
+	nodes []graphNode
+}
+
+type graphPackage struct {
+	name       string
+	deprecated bool
+	channels   []graphChannel
+}
+
+// upgradeGraph is the upgrade graph of a declarative config, sorted by
+// package name, and then by channel name, shared by the graph writers.
+type upgradeGraph struct {
+	packages             []graphPackage
+	deprecatedChannelIDs []string
+}
+
+func buildUpgradeGraph(cfg DeclarativeConfig, minEdgeName, specifiedPackageName string) (*upgradeGraph, error) {
+	sort.Slice(cfg.Channels, func(i, j int) bool {
+		return cfg.Channels[i].Name < cfg.Channels[j].Name
+	})
+
+	versionMap, err := getBundleVersions(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// establish a 'floor' version, either specified by user or entirely open
+	minVersion := semver.Version{Major: 0, Minor: 0, Patch: 0}
+
+	if minEdgeName != "" {
+		if _, ok := versionMap[minEdgeName]; !ok {
+			return nil, fmt.Errorf("unknown minimum edge name: %q", minEdgeName)
+		}
+		minVersion = versionMap[minEdgeName]
+	}
+
+	minEdgePackage := getMinEdgePackage(&cfg, minEdgeName)
+
+	depByPackage := sets.Set[string]{}
+	depByChannel := sets.Set[string]{}
+	depByBundle := sets.Set[string]{}
+
+	for _, d := range cfg.Deprecations {
+		for _, e := range d.Entries {
+			switch e.Reference.Schema {
+			case SchemaPackage:
+				depByPackage.Insert(d.Package)
+			case SchemaChannel:
+				depByChannel.Insert(e.Reference.Name)
+			case SchemaBundle:
+				depByBundle.Insert(e.Reference.Name)
+			}
+		}
+	}
+
+	g := &upgradeGraph{}
+	pkgs := map[string]*graphPackage{}
+	for _, c := range cfg.Channels {
+		filteredChannel := filterChannel(&c, minEdgeName, specifiedPackageName, versionMap, minVersion, minEdgePackage)
+		if filteredChannel == nil {
+			continue
+		}
+		pkg, ok := pkgs[c.Package]
+		if !ok {
+			pkg = &graphPackage{name: c.Package, deprecated: depByPackage.Has(c.Package)}
+			pkgs[c.Package] = pkg
+		}
+
+		channelID := fmt.Sprintf("%s-%s", filteredChannel.Package, filteredChannel.Name)
+		ch := graphChannel{id: channelID, name: filteredChannel.Name}
+		if depByChannel.Has(filteredChannel.Name) {
+			g.deprecatedChannelIDs = append(g.deprecatedChannelIDs, channelID)
+		}
+
+		for _, ce := range filteredChannel.Entries {
+			if versionMap[ce.Name].LT(minVersion) {
+				continue
+			}
+			n := graphNode{
+				id:         fmt.Sprintf("%s-%s", channelID, ce.Name),
+				name:       ce.Name,
+				deprecated: depByBundle.Has(ce.Name),
+			}
+			if len(ce.Replaces) > 0 {
+				n.edges = append(n.edges, graphEdge{kind: edgeReplace, label: "replace", fromID: fmt.Sprintf("%s-%s", channelID, ce.Replaces), fromName: ce.Replaces})
+			}
+			for _, s := range ce.Skips {
+				n.edges = append(n.edges, graphEdge{kind: edgeSkip, label: "skip", fromID: fmt.Sprintf("%s-%s", channelID, s), fromName: s})
+			}
+			if len(ce.SkipRange) > 0 {
+				skipRange, err := semver.ParseRange(ce.SkipRange)
+				if err == nil {
+					for _, edgeName := range filteredChannel.Entries {
+						if skipRange(versionMap[edgeName.Name]) {
+							n.edges = append(n.edges, graphEdge{kind: edgeSkipRange, label: fmt.Sprintf("skipRange(%s)", ce.SkipRange), fromID: fmt.Sprintf("%s-%s", channelID, edgeName.Name), fromName: edgeName.Name})
+						}
+					}
+				} else {
+					fmt.Fprintf(os.Stderr, "warning: ignoring invalid SkipRange for package/edge %q/%q: %v\n", c.Package, ce.Name, err)
+				}
+			}
+			ch.nodes = append(ch.nodes, n)
+		}
+		pkg.channels = append(pkg.channels, ch)
+	}
+
+	pkgNames := sets.List(sets.KeySet(pkgs))
+	for _, name := range pkgNames {
+		g.packages = append(g.packages, *pkgs[name])
+	}
+	return g, nil
+}
+
+// DotWriter writes the upgrade graph of a declarative config in the DOT
+// language of graphviz. It supports the same options as MermaidWriter.
+type DotWriter struct {
+	MermaidWriter
+}
+
+func NewDotWriter(opts ...MermaidOption) *DotWriter {
+	return &DotWriter{MermaidWriter: *NewMermaidWriter(opts...)}
+}
+
+// writes out the channel edges of the declarative config graph as a graphviz digraph, with a cluster per package
+// and per channel. output is sorted lexicographically by package name, and then by channel name
+// if provided, minEdgeName will be used as the lower bound for edges in the output graph
+//
+// Example output:
+// digraph {
+//
+//	rankdir=LR;
+//	node [shape=box];
+//	// package "neuvector-certified-operator-rhmp"
+//	subgraph "cluster_neuvector-certified-operator-rhmp" {
+//	  label="neuvector-certified-operator-rhmp";
+//	  // channel "beta"
+//	  subgraph "cluster_neuvector-certified-operator-rhmp-beta" {
+//	    label="beta";
+//	    "neuvector-certified-operator-rhmp-beta-neuvector-operator.v1.2.8" [label="neuvector-operator.v1.2.8"];
+//	    "neuvector-certified-operator-rhmp-beta-neuvector-operator.v1.3.0" [label="neuvector-operator.v1.3.0"];
+//	    "neuvector-certified-operator-rhmp-beta-neuvector-operator.v1.2.8" -> "neuvector-certified-operator-rhmp-beta-neuvector-operator.v1.3.0" [label="replace"];
+//	  }
+//	}
+//
+// }
+func (writer *DotWriter) WriteChannels(cfg DeclarativeConfig, out io.Writer) error {
+	graph, err := buildUpgradeGraph(cfg, writer.MinEdgeName, writer.SpecifiedPackageName)
+	if err != nil {
+		return err
+	}
+	highlights := writer.Highlights
+	deprecatedChannels := sets.New(graph.deprecatedChannelIDs...)
+
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, pkg := range graph.packages {
+		b.WriteString(fmt.Sprintf("  // package %q\n", pkg.name))
+		b.WriteString(fmt.Sprintf("  subgraph %q {\n", "cluster_"+pkg.name))
+		b.WriteString(fmt.Sprintf("    label=%q;\n", pkg.name))
+		if highlights.Deprecations && pkg.deprecated {
+			b.WriteString("    style=filled;\n    fillcolor=\"#989695\";\n")
+		}
+		for _, ch := range pkg.channels {
+			b.WriteString(fmt.Sprintf("    // channel %q\n", ch.name))
+			b.WriteString(fmt.Sprintf("    subgraph %q {\n", "cluster_"+ch.id))
+			b.WriteString(fmt.Sprintf("      label=%q;\n", ch.name))
+			if highlights.Deprecations && deprecatedChannels.Has(ch.id) {
+				b.WriteString("      style=filled;\n      fillcolor=\"#DCD0FF\";\n")
+			}
+
+			// edges may reference entries that are not in the channel, so
+			// declare them too, to keep them in the channel's cluster
+			declared := sets.New[string]()
+			declare := func(id, name string, deprecated bool) {
+				if declared.Has(id) {
+					return
+				}
+				declared.Insert(id)
+				attrs := fmt.Sprintf("label=%q", name)
+				if highlights.Deprecations && deprecated {
+					attrs += `, style=filled, fillcolor="#E8960F"`
+				}
+				b.WriteString(fmt.Sprintf("      %q [%s];\n", id, attrs))
+			}
+			for _, n := range ch.nodes {
+				declare(n.id, n.name, n.deprecated)
+			}
+			for _, n := range ch.nodes {
+				for _, e := range n.edges {
+					declare(e.fromID, e.fromName, false)
+				}
+			}
+
+			for _, n := range ch.nodes {
+				for _, e := range n.edges {
+					attrs := fmt.Sprintf("label=%q", e.label)
+					switch {
+					case e.kind == edgeSkip && highlights.Skips:
+						attrs += `, style=dashed, color="#1F77B4"`
+					case e.kind == edgeSkipRange && highlights.SkipRanges:
+						attrs += `, style=bold, color="#2CA02C"`
+					}
+					b.WriteString(fmt.Sprintf("      %q -> %q [%s];\n", e.fromID, n.id, attrs))
+				}
+			}
+			b.WriteString("    }\n")
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+
+	_, err = io.WriteString(out, b.String())
+	return err
+}
@@ -2,7 +2,11 @@ package declcfg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io/fs"
+	"os"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -611,3 +615,243 @@ style anakin-light fill:#DCD0FF
 		})
 	}
 }
+
+func TestWriteMermaidChannelsHighlights(t *testing.T) {
+	type spec struct {
+		name       string
+		highlights GraphHighlights
+		expected   string
+	}
+	specs := []spec{
+		{
+			name:       "SkipsAndSkipRanges",
+			highlights: GraphHighlights{Skips: true, SkipRanges: true},
+			expected: `graph LR
+  %% package "anakin"
+  subgraph "anakin"
+    %% channel "dark"
+    subgraph anakin-dark["dark"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]
+      anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]-- replace --> anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]
+      anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]-- replace --> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]-. skip .-> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]== "skipRange(<0.1.1)" ==> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]== "skipRange(<0.1.1)" ==> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+    end
+  end
+`,
+		},
+		{
+			name:       "Deprecations",
+			highlights: GraphHighlights{Deprecations: true},
+			expected: `graph LR
+  classDef deprecated fill:#E8960F
+  %% package "anakin"
+  subgraph "anakin"
+    %% channel "dark"
+    subgraph anakin-dark["dark"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]:::deprecated
+      anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]-- replace --> anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]
+      anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]-- replace --> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]-- skip --> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.0.1["anakin.v0.0.1"]-- "skipRange(<0.1.1)" --> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+      anakin-dark-anakin.v0.1.0["anakin.v0.1.0"]-- "skipRange(<0.1.1)" --> anakin-dark-anakin.v0.1.1["anakin.v0.1.1"]
+    end
+  end
+style anakin fill:#989695
+`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := buildHighlightsDeclarativeConfig()
+			var buf bytes.Buffer
+			writer := NewMermaidWriter(WithHighlights(s.highlights))
+			require.NoError(t, writer.WriteChannels(cfg, &buf))
+			require.Equal(t, s.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteDotChannels(t *testing.T) {
+	type spec struct {
+		name       string
+		highlights GraphHighlights
+		expected   string
+	}
+	specs := []spec{
+		{
+			name:       "Default",
+			highlights: GraphHighlights{Deprecations: true},
+			expected: `digraph {
+  rankdir=LR;
+  node [shape=box];
+  // package "anakin"
+  subgraph "cluster_anakin" {
+    label="anakin";
+    style=filled;
+    fillcolor="#989695";
+    // channel "dark"
+    subgraph "cluster_anakin-dark" {
+      label="dark";
+      "anakin-dark-anakin.v0.0.1" [label="anakin.v0.0.1", style=filled, fillcolor="#E8960F"];
+      "anakin-dark-anakin.v0.1.0" [label="anakin.v0.1.0"];
+      "anakin-dark-anakin.v0.1.1" [label="anakin.v0.1.1"];
+      "anakin-dark-anakin.v0.0.1" -> "anakin-dark-anakin.v0.1.0" [label="replace"];
+      "anakin-dark-anakin.v0.0.1" -> "anakin-dark-anakin.v0.1.1" [label="replace"];
+      "anakin-dark-anakin.v0.1.0" -> "anakin-dark-anakin.v0.1.1" [label="skip"];
+      "anakin-dark-anakin.v0.0.1" -> "anakin-dark-anakin.v0.1.1" [label="skipRange(<0.1.1)"];
+      "anakin-dark-anakin.v0.1.0" -> "anakin-dark-anakin.v0.1.1" [label="skipRange(<0.1.1)"];
+    }
+  }
+}
+`,
+		},
+		{
+			name:       "SkipsAndSkipRanges",
+			highlights: GraphHighlights{Skips: true, SkipRanges: true},
+			expected: `digraph {
+  rankdir=LR;
+  node [shape=box];
+  // package "anakin"
+  subgraph "cluster_anakin" {
+    label="anakin";
+    // channel "dark"
+    subgraph "cluster_anakin-dark" {
+      label="dark";
+      "anakin-dark-anakin.v0.0.1" [label="anakin.v0.0.1"];
+      "anakin-dark-anakin.v0.1.0" [label="anakin.v0.1.0"];
+      "anakin-dark-anakin.v0.1.1" [label="anakin.v0.1.1"];
+      "anakin-dark-anakin.v0.0.1" -> "anakin-dark-anakin.v0.1.0" [label="replace"];
+      "anakin-dark-anakin.v0.0.1" -> "anakin-dark-anakin.v0.1.1" [label="replace"];
+      "anakin-dark-anakin.v0.1.0" -> "anakin-dark-anakin.v0.1.1" [label="skip", style=dashed, color="#1F77B4"];
+      "anakin-dark-anakin.v0.0.1" -> "anakin-dark-anakin.v0.1.1" [label="skipRange(<0.1.1)", style=bold, color="#2CA02C"];
+      "anakin-dark-anakin.v0.1.0" -> "anakin-dark-anakin.v0.1.1" [label="skipRange(<0.1.1)", style=bold, color="#2CA02C"];
+    }
+  }
+}
+`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := buildHighlightsDeclarativeConfig()
+			var buf bytes.Buffer
+			writer := NewDotWriter(WithHighlights(s.highlights))
+			require.NoError(t, writer.WriteChannels(cfg, &buf))
+			require.Equal(t, s.expected, buf.String())
+		})
+	}
+}
+
+// buildHighlightsDeclarativeConfig returns a config with a deprecated package
+// and bundle, and a channel with replaces, skips and skipRange edges.
+func buildHighlightsDeclarativeConfig() DeclarativeConfig {
+	return DeclarativeConfig{
+		Packages: []Package{{Schema: SchemaPackage, Name: "anakin", DefaultChannel: "dark"}},
+		Channels: []Channel{{Schema: SchemaChannel, Package: "anakin", Name: "dark", Entries: []ChannelEntry{
+			{Name: "anakin.v0.0.1"},
+			{Name: "anakin.v0.1.0", Replaces: "anakin.v0.0.1"},
+			{Name: "anakin.v0.1.1", Replaces: "anakin.v0.0.1", Skips: []string{"anakin.v0.1.0"}, SkipRange: "<0.1.1"},
+		}}},
+		Bundles: []Bundle{
+			newTestBundle("anakin", "0.0.1"),
+			newTestBundle("anakin", "0.1.0"),
+			newTestBundle("anakin", "0.1.1"),
+		},
+		Deprecations: []Deprecation{{Schema: SchemaDeprecation, Package: "anakin", Entries: []DeprecationEntry{
+			{Reference: PackageScopedReference{Schema: SchemaPackage}, Message: "anakin is deprecated"},
+			{Reference: PackageScopedReference{Schema: SchemaBundle, Name: "anakin.v0.0.1"}, Message: "anakin.v0.0.1 is deprecated"},
+		}}},
+	}
+}
+
+func TestWriteFS(t *testing.T) {
+	type spec struct {
+		name          string
+		opts          []WriteFSOption
+		expectedFiles []string
+		assertion     require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Default",
+			expectedFiles: []string{
+				"anakin/catalog.yaml",
+				"boba-fett/catalog.yaml",
+				"catalog.yaml",
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "PackageLayout",
+			opts: []WriteFSOption{WithFSLayout(FSLayoutPackage)},
+			expectedFiles: []string{
+				"anakin/catalog.yaml",
+				"boba-fett/catalog.yaml",
+				"catalog.yaml",
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "SchemaLayout",
+			opts: []WriteFSOption{WithFSLayout(FSLayoutSchema)},
+			expectedFiles: []string{
+				"anakin/custom.3.yaml",
+				"anakin/olm.bundle.yaml",
+				"anakin/olm.channel.yaml",
+				"anakin/olm.deprecations.yaml",
+				"anakin/olm.package.yaml",
+				"boba-fett/custom.3.yaml",
+				"boba-fett/olm.bundle.yaml",
+				"boba-fett/olm.channel.yaml",
+				"boba-fett/olm.package.yaml",
+				"custom.1.yaml",
+				"custom.2.yaml",
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/UnknownLayout",
+			opts:      []WriteFSOption{WithFSLayout("unknown")},
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := buildValidDeclarativeConfig(validDeclarativeConfigSpec{IncludeUnrecognized: true, IncludeDeprecations: true})
+			dir := t.TempDir()
+			err := WriteFS(cfg, dir, WriteYAML, ".yaml", s.opts...)
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+
+			var files []string
+			require.NoError(t, fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				files = append(files, path)
+				return nil
+			}))
+			require.Equal(t, s.expectedFiles, files)
+
+			actual, err := LoadFS(context.Background(), os.DirFS(dir))
+			require.NoError(t, err)
+
+			// Bundle objects are loaded in property order, which the test
+			// bundles do not match.
+			for _, c := range []DeclarativeConfig{cfg, *actual} {
+				for _, b := range c.Bundles {
+					sort.Strings(b.Objects)
+				}
+			}
+			equalsDeclarativeConfig(t, cfg, *actual)
+		})
+	}
+}
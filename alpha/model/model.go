@@ -3,6 +3,7 @@ package model
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -21,6 +22,17 @@ type Deprecation struct {
 	Message string `json:"message"`
 }
 
+// MaxDocumentationContentSize is the maximum size in bytes of inline package
+// documentation content.
+const MaxDocumentationContentSize = 256 * 1024
+
+// Documentation holds package documentation, either inline markdown
+// content or a URL referencing it.
+type Documentation struct {
+	Content string `json:"content,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
 func init() {
 	t := types.NewType("svg", "image/svg+xml")
 	filetype.AddMatcher(t, svg.Is)
@@ -50,6 +62,7 @@ type Package struct {
 	DefaultChannel *Channel
 	Channels       map[string]*Channel
 	Deprecation    *Deprecation
+	Documentation  *Documentation
 }
 
 func (m *Package) Validate() error {
@@ -99,6 +112,10 @@ func (m *Package) Validate() error {
 		result.subErrors = append(result.subErrors, fmt.Errorf("invalid deprecation: %v", err))
 	}
 
+	if err := m.Documentation.Validate(); err != nil {
+		result.subErrors = append(result.subErrors, fmt.Errorf("invalid documentation: %v", err))
+	}
+
 	return result.orNil()
 }
 
@@ -365,6 +382,16 @@ func (b *Bundle) Validate() error {
 	if props != nil && len(props.Packages) != 1 {
 		result.subErrors = append(result.subErrors, fmt.Errorf("must be exactly one property with type %q", property.TypePackage))
 	}
+	if props != nil {
+		if len(props.Architectures) > 1 {
+			result.subErrors = append(result.subErrors, fmt.Errorf("must be at most one property with type %q", property.TypeArchitectures))
+		}
+		for _, a := range props.Architectures {
+			if err := a.Validate(); err != nil {
+				result.subErrors = append(result.subErrors, fmt.Errorf("invalid %q property: %v", property.TypeArchitectures, err))
+			}
+		}
+	}
 
 	if b.Image == "" && len(b.Objects) == 0 {
 		result.subErrors = append(result.subErrors, errors.New("bundle image must be set"))
@@ -440,3 +467,27 @@ func (d *Deprecation) Validate() error {
 	}
 	return nil
 }
+
+func (d *Documentation) Validate() error {
+	if d == nil {
+		return nil
+	}
+	switch {
+	case d.Content == "" && d.URL == "":
+		return errors.New("one of content or url must be set")
+	case d.Content != "" && d.URL != "":
+		return errors.New("only one of content or url may be set")
+	case len(d.Content) > MaxDocumentationContentSize:
+		return fmt.Errorf("content size %d bytes exceeds limit of %d bytes", len(d.Content), MaxDocumentationContentSize)
+	}
+	if d.URL != "" {
+		u, err := url.Parse(d.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid url %q: scheme must be http or https", d.URL)
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver/v4"
@@ -383,6 +384,36 @@ func TestValidators(t *testing.T) {
 		//	},
 		//	assertion: hasError(`icon media type "image/jpeg" does not match detected media type "image/svg+xml"`),
 		//},
+		{
+			name:      "Documentation/Success/Content",
+			v:         &Documentation{Content: "# anakin"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Documentation/Success/URL",
+			v:         &Documentation{URL: "https://example.com/anakin/README.md"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Documentation/Error/Empty",
+			v:         &Documentation{},
+			assertion: hasError(`one of content or url must be set`),
+		},
+		{
+			name:      "Documentation/Error/ContentAndURL",
+			v:         &Documentation{Content: "# anakin", URL: "https://example.com/anakin/README.md"},
+			assertion: hasError(`only one of content or url may be set`),
+		},
+		{
+			name:      "Documentation/Error/InvalidURLScheme",
+			v:         &Documentation{URL: "file:///etc/passwd"},
+			assertion: hasError(`invalid url "file:///etc/passwd": scheme must be http or https`),
+		},
+		{
+			name:      "Documentation/Error/ContentTooLarge",
+			v:         &Documentation{Content: strings.Repeat("a", MaxDocumentationContentSize+1)},
+			assertion: hasError(`content size 262145 bytes exceeds limit of 262144 bytes`),
+		},
 		{
 			name:      "Channel/Success/Valid",
 			v:         ch,
@@ -474,6 +505,23 @@ func TestValidators(t *testing.T) {
 			},
 			assertion: require.NoError,
 		},
+		{
+			// Constraints are checked by opm render and validate, so that
+			// catalogs with invalid constraints can still be served.
+			name: "Bundle/Success/InvalidConstraint",
+			v: &Bundle{
+				Package:  pkg,
+				Channel:  ch,
+				Name:     "anakin.v0.1.0",
+				Image:    "registry.io/image",
+				Replaces: "anakin.v0.0.1",
+				Properties: []property.Property{
+					property.MustBuildPackage("anakin", "0.1.0"),
+					property.MustBuildConstraintCEL(`"certified"`, ""),
+				},
+			},
+			assertion: require.NoError,
+		},
 		{
 			name: "Bundle/Success/ReplacesNotInChannel",
 			v: &Bundle{
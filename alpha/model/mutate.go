@@ -0,0 +1,141 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The mutation methods of Package edit a package in place, and re-validate
+// only the invariants that the edit can affect, rather than the whole model.
+// If an edit would leave the package invalid, it is undone, the package is
+// left as it was, and the validation error is returned.
+
+// AddBundle adds b to the channel of m that is named channel, creating the
+// channel if it does not exist, and linking b to it. The channel becomes the
+// default channel of m if m has none. b.Version must be set to the version of
+// b's package property.
+//
+// The bundle, the replaces chain of the channel, and the uniqueness of the
+// bundle versions of m are re-validated.
+func (m *Package) AddBundle(channel string, b Bundle) error {
+	result := newValidationError(fmt.Sprintf("invalid bundle %q added to channel %q", b.Name, channel))
+	if channel == "" {
+		result.subErrors = append(result.subErrors, errors.New("channel name must not be empty"))
+		return result
+	}
+
+	ch, existing := m.Channels[channel]
+	if existing {
+		if _, ok := ch.Bundles[b.Name]; ok {
+			result.subErrors = append(result.subErrors, fmt.Errorf("bundle %q already exists in channel", b.Name))
+			return result
+		}
+	} else {
+		ch = &Channel{
+			Package: m,
+			Name:    channel,
+			Bundles: map[string]*Bundle{},
+		}
+	}
+	b.Package = m
+	b.Channel = ch
+	ch.Bundles[b.Name] = &b
+	if m.Channels == nil {
+		m.Channels = map[string]*Channel{}
+	}
+	m.Channels[channel] = ch
+
+	if err := b.Validate(); err != nil {
+		result.subErrors = append(result.subErrors, err)
+	}
+	if err := ch.validateReplacesChain(); err != nil {
+		result.subErrors = append(result.subErrors, err)
+	}
+	if err := m.validateUniqueBundleVersions(); err != nil {
+		result.subErrors = append(result.subErrors, err)
+	}
+	if err := result.orNil(); err != nil {
+		delete(ch.Bundles, b.Name)
+		if !existing {
+			delete(m.Channels, channel)
+		}
+		return err
+	}
+
+	if m.DefaultChannel == nil {
+		m.DefaultChannel = ch
+	}
+	return nil
+}
+
+// RemoveChannelEntry removes the bundle named bundle from the channel of m
+// that is named channel. A channel that is left empty is removed from m,
+// unless it is the default channel of m, which must not be left empty.
+//
+// The replaces chain of the channel is re-validated, so an entry cannot be
+// removed if that would strand other entries or split the channel's head.
+func (m *Package) RemoveChannelEntry(channel, bundle string) error {
+	result := newValidationError(fmt.Sprintf("invalid removal of bundle %q from channel %q", bundle, channel))
+
+	ch, ok := m.Channels[channel]
+	if !ok {
+		result.subErrors = append(result.subErrors, fmt.Errorf("channel %q not found in package %q", channel, m.Name))
+		return result
+	}
+	b, ok := ch.Bundles[bundle]
+	if !ok {
+		result.subErrors = append(result.subErrors, fmt.Errorf("bundle %q not found in channel %q", bundle, channel))
+		return result
+	}
+
+	if len(ch.Bundles) == 1 {
+		if ch == m.DefaultChannel {
+			result.subErrors = append(result.subErrors, fmt.Errorf("default channel %q must contain at least one bundle", channel))
+			return result
+		}
+		delete(m.Channels, channel)
+		return nil
+	}
+
+	delete(ch.Bundles, bundle)
+	if err := ch.validateReplacesChain(); err != nil {
+		ch.Bundles[bundle] = b
+		result.subErrors = append(result.subErrors, err)
+		return result
+	}
+	return nil
+}
+
+// SetDefaultChannel sets the default channel of m to its channel that is
+// named channel.
+func (m *Package) SetDefaultChannel(channel string) error {
+	ch, ok := m.Channels[channel]
+	if !ok {
+		return fmt.Errorf("invalid default channel: channel %q not found in package %q", channel, m.Name)
+	}
+	m.DefaultChannel = ch
+	return nil
+}
+
+// DeprecateBundle marks the bundle named bundle as deprecated with message,
+// in every channel of m that contains it.
+func (m *Package) DeprecateBundle(bundle, message string) error {
+	deprecation := &Deprecation{Message: message}
+	if err := deprecation.Validate(); err != nil {
+		return fmt.Errorf("invalid deprecation of bundle %q: %v", bundle, err)
+	}
+
+	var found []*Bundle
+	for _, ch := range m.Channels {
+		if b, ok := ch.Bundles[bundle]; ok {
+			found = append(found, b)
+		}
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("bundle %q not found in package %q", bundle, m.Name)
+	}
+	for _, b := range found {
+		b.Deprecation = deprecation
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func newAnakinBundle(version, replaces string) Bundle {
+	return Bundle{
+		Name:     "anakin.v" + version,
+		Image:    "anakin-operator:v" + version,
+		Replaces: replaces,
+		Properties: []property.Property{
+			property.MustBuildPackage("anakin", version),
+		},
+		Version: semver.MustParse(version),
+	}
+}
+
+func TestPackageAddBundle(t *testing.T) {
+	type spec struct {
+		name      string
+		channel   string
+		bundle    Bundle
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/ExistingChannel",
+			channel:   "light",
+			bundle:    newAnakinBundle("0.0.3", "anakin.v0.0.2"),
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/NewChannel",
+			channel:   "dark",
+			bundle:    newAnakinBundle("0.0.3", "anakin.v0.0.2"),
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/EmptyChannelName",
+			bundle:    newAnakinBundle("0.0.3", "anakin.v0.0.2"),
+			assertion: hasError("channel name must not be empty"),
+		},
+		{
+			name:      "Error/AlreadyExists",
+			channel:   "light",
+			bundle:    newAnakinBundle("0.0.2", "anakin.v0.0.1"),
+			assertion: hasError(`bundle "anakin.v0.0.2" already exists in channel`),
+		},
+		{
+			name:    "Error/InvalidBundle",
+			channel: "light",
+			bundle: func() Bundle {
+				b := newAnakinBundle("0.0.3", "anakin.v0.0.2")
+				b.Image = ""
+				return b
+			}(),
+			assertion: hasError("bundle image must be set"),
+		},
+		{
+			name:      "Error/MultipleHeads",
+			channel:   "light",
+			bundle:    newAnakinBundle("0.0.3", "anakin.v0.0.1"),
+			assertion: hasError("multiple channel heads found in graph: anakin.v0.0.2, anakin.v0.0.3"),
+		},
+		{
+			name:    "Error/DuplicateVersion",
+			channel: "dark",
+			bundle: func() Bundle {
+				b := newAnakinBundle("0.0.2", "")
+				b.Name = "anakin.v0.0.2-dark"
+				return b
+			}(),
+			assertion: hasError("duplicate versions found in bundles: [{0.0.2: [anakin.v0.0.2, anakin.v0.0.2-dark]}]"),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			pkg, ch := makePackageChannelBundle()
+			err := pkg.AddBundle(s.channel, s.bundle)
+			s.assertion(t, err)
+
+			if err != nil {
+				// A failed addition leaves the package as it was.
+				assert.Len(t, pkg.Channels, 1)
+				assert.Len(t, ch.Bundles, 2)
+				assert.Same(t, ch, pkg.DefaultChannel)
+				return
+			}
+			added := pkg.Channels[s.channel].Bundles[s.bundle.Name]
+			require.NotNil(t, added)
+			assert.Same(t, pkg, added.Package)
+			assert.Same(t, pkg.Channels[s.channel], added.Channel)
+			assert.Same(t, ch, pkg.DefaultChannel)
+			require.NoError(t, pkg.Validate())
+		})
+	}
+
+	t.Run("Success/EmptyPackage", func(t *testing.T) {
+		pkg := &Package{Name: "anakin"}
+		require.NoError(t, pkg.AddBundle("light", newAnakinBundle("0.0.1", "")))
+		require.NotNil(t, pkg.DefaultChannel)
+		assert.Equal(t, "light", pkg.DefaultChannel.Name)
+		require.NoError(t, pkg.Validate())
+	})
+}
+
+func TestPackageRemoveChannelEntry(t *testing.T) {
+	type spec struct {
+		name      string
+		channel   string
+		bundle    string
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/Head",
+			channel:   "light",
+			bundle:    "anakin.v0.0.3",
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/Tail",
+			channel:   "light",
+			bundle:    "anakin.v0.0.1",
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/ChannelNotFound",
+			channel:   "dark",
+			bundle:    "anakin.v0.0.2",
+			assertion: hasError(`channel "dark" not found in package "anakin"`),
+		},
+		{
+			name:      "Error/BundleNotFound",
+			channel:   "light",
+			bundle:    "anakin.v0.0.4",
+			assertion: hasError(`bundle "anakin.v0.0.4" not found in channel "light"`),
+		},
+		{
+			name:      "Error/SplitsReplacesChain",
+			channel:   "light",
+			bundle:    "anakin.v0.0.2",
+			assertion: hasError("multiple channel heads found in graph: anakin.v0.0.1, anakin.v0.0.3"),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			pkg, ch := makePackageChannelBundle()
+			require.NoError(t, pkg.AddBundle("light", newAnakinBundle("0.0.3", "anakin.v0.0.2")))
+
+			err := pkg.RemoveChannelEntry(s.channel, s.bundle)
+			s.assertion(t, err)
+			if err != nil {
+				assert.Len(t, ch.Bundles, 3)
+				return
+			}
+			assert.Len(t, ch.Bundles, 2)
+			assert.NotContains(t, ch.Bundles, s.bundle)
+			require.NoError(t, pkg.Validate())
+		})
+	}
+
+	t.Run("EmptyChannel", func(t *testing.T) {
+		pkg, ch := makePackageChannelBundle()
+		require.NoError(t, pkg.AddBundle("dark", newAnakinBundle("0.0.3", "")))
+
+		require.NoError(t, pkg.RemoveChannelEntry("dark", "anakin.v0.0.3"))
+		assert.NotContains(t, pkg.Channels, "dark")
+
+		require.NoError(t, pkg.RemoveChannelEntry("light", "anakin.v0.0.2"))
+		err := pkg.RemoveChannelEntry("light", "anakin.v0.0.1")
+		hasError(`default channel "light" must contain at least one bundle`)(t, err)
+		assert.Len(t, ch.Bundles, 1)
+		require.NoError(t, pkg.Validate())
+	})
+}
+
+func TestPackageSetDefaultChannel(t *testing.T) {
+	pkg, ch := makePackageChannelBundle()
+	require.NoError(t, pkg.AddBundle("dark", newAnakinBundle("0.0.3", "")))
+
+	require.NoError(t, pkg.SetDefaultChannel("dark"))
+	assert.Same(t, pkg.Channels["dark"], pkg.DefaultChannel)
+	require.NoError(t, pkg.Validate())
+
+	require.EqualError(t, pkg.SetDefaultChannel("gray"), `invalid default channel: channel "gray" not found in package "anakin"`)
+	assert.Same(t, pkg.Channels["dark"], pkg.DefaultChannel)
+
+	require.NoError(t, pkg.SetDefaultChannel(ch.Name))
+	assert.Same(t, ch, pkg.DefaultChannel)
+}
+
+func TestPackageDeprecateBundle(t *testing.T) {
+	pkg, ch := makePackageChannelBundle()
+	require.NoError(t, pkg.AddBundle("dark", newAnakinBundle("0.0.2", "")))
+
+	require.NoError(t, pkg.DeprecateBundle("anakin.v0.0.2", "turned to the dark side"))
+	for _, c := range pkg.Channels {
+		require.NotNil(t, c.Bundles["anakin.v0.0.2"].Deprecation)
+		assert.Equal(t, "turned to the dark side", c.Bundles["anakin.v0.0.2"].Deprecation.Message)
+	}
+	assert.Nil(t, ch.Bundles["anakin.v0.0.1"].Deprecation)
+
+	require.EqualError(t, pkg.DeprecateBundle("anakin.v0.0.3", "gone"), `bundle "anakin.v0.0.3" not found in package "anakin"`)
+	require.EqualError(t, pkg.DeprecateBundle("anakin.v0.0.1", ""), `invalid deprecation of bundle "anakin.v0.0.1": message must be set`)
+	assert.Nil(t, ch.Bundles["anakin.v0.0.1"].Deprecation)
+}
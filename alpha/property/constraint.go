@@ -0,0 +1,69 @@
+package property
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
+)
+
+// celEnvironment is the environment that OLM evaluates CEL constraints in.
+var celEnvironment = sync.OnceValue(constraints.NewCelEnvironment)
+
+// Validate returns an error if c, or any constraint nested in it, does not
+// set exactly one constraint, or if a CEL rule does not compile to a boolean
+// expression.
+func (c Constraint) Validate() error {
+	set := 0
+	for _, isSet := range []bool{c.Cel != nil, c.Package != nil, c.GVK != nil, c.All != nil, c.Any != nil, c.Not != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of cel, package, gvk, all, any or not must be set, found %d", set)
+	}
+
+	switch {
+	case c.Cel != nil:
+		if c.Cel.Rule == "" {
+			return errors.New("cel rule must be set")
+		}
+		if _, err := celEnvironment().Validate(c.Cel.Rule); err != nil {
+			return fmt.Errorf("invalid cel rule %q: %v", c.Cel.Rule, strings.TrimSpace(err.Error()))
+		}
+	case c.Package != nil:
+		if c.Package.PackageName == "" {
+			return errors.New("package name must be set")
+		}
+		if _, err := semver.ParseRange(c.Package.VersionRange); err != nil {
+			return fmt.Errorf("invalid package version range %q: %v", c.Package.VersionRange, err)
+		}
+	case c.GVK != nil:
+		if c.GVK.Kind == "" || c.GVK.Version == "" {
+			return errors.New("gvk kind and version must be set")
+		}
+	case c.All != nil:
+		return c.All.validate("all")
+	case c.Any != nil:
+		return c.Any.validate("any")
+	case c.Not != nil:
+		return c.Not.validate("not")
+	}
+	return nil
+}
+
+func (c CompoundConstraint) validate(name string) error {
+	if len(c.Constraints) == 0 {
+		return fmt.Errorf("%s constraints must be set", name)
+	}
+	for i, nested := range c.Constraints {
+		if err := nested.Validate(); err != nil {
+			return fmt.Errorf("%s constraint[%d]: %v", name, i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package property
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraintValidate(t *testing.T) {
+	type spec struct {
+		name       string
+		constraint Constraint
+		expectErr  string
+	}
+	specs := []spec{
+		{
+			name:       "Success/Cel",
+			constraint: Constraint{Cel: &CelConstraint{Rule: `properties.exists(p, p.type == "certified")`}},
+		},
+		{
+			name:       "Success/CelSemver",
+			constraint: Constraint{Cel: &CelConstraint{Rule: `properties.exists(p, p.type == "olm.package" && semver_compare(p.value.version, "1.0.0") >= 0)`}},
+		},
+		{
+			name: "Success/Compound",
+			constraint: Constraint{All: &CompoundConstraint{Constraints: []Constraint{
+				{Package: &PackageRequired{PackageName: "foo", VersionRange: ">=1.0.0"}},
+				{Not: &CompoundConstraint{Constraints: []Constraint{
+					{GVK: &GVKRequired{Group: "example.com", Kind: "Foo", Version: "v1"}},
+				}}},
+			}}},
+		},
+		{
+			name:       "Error/Empty",
+			constraint: Constraint{FailureMessage: "nothing to see here"},
+			expectErr:  "exactly one of cel, package, gvk, all, any or not must be set, found 0",
+		},
+		{
+			name: "Error/Multiple",
+			constraint: Constraint{
+				Cel:     &CelConstraint{Rule: "true"},
+				Package: &PackageRequired{PackageName: "foo", VersionRange: ">=1.0.0"},
+			},
+			expectErr: "exactly one of cel, package, gvk, all, any or not must be set, found 2",
+		},
+		{
+			name:       "Error/EmptyCelRule",
+			constraint: Constraint{Cel: &CelConstraint{}},
+			expectErr:  "cel rule must be set",
+		},
+		{
+			name:       "Error/InvalidCelSyntax",
+			constraint: Constraint{Cel: &CelConstraint{Rule: `properties.exists(p, p.type ==`}},
+			expectErr:  `invalid cel rule "properties.exists(p, p.type ==": ERROR: <input>:1:`,
+		},
+		{
+			name:       "Error/NonBooleanCelRule",
+			constraint: Constraint{Cel: &CelConstraint{Rule: `"certified"`}},
+			expectErr:  `invalid cel rule "\"certified\"": cel expressions must have type Bool`,
+		},
+		{
+			name:       "Error/InvalidVersionRange",
+			constraint: Constraint{Package: &PackageRequired{PackageName: "foo", VersionRange: "one"}},
+			expectErr:  `invalid package version range "one"`,
+		},
+		{
+			name:       "Error/MissingGVKKind",
+			constraint: Constraint{GVK: &GVKRequired{Group: "example.com", Version: "v1"}},
+			expectErr:  "gvk kind and version must be set",
+		},
+		{
+			name:       "Error/EmptyCompound",
+			constraint: Constraint{Any: &CompoundConstraint{}},
+			expectErr:  "any constraints must be set",
+		},
+		{
+			name: "Error/InvalidNested",
+			constraint: Constraint{All: &CompoundConstraint{Constraints: []Constraint{
+				{Cel: &CelConstraint{Rule: "true"}},
+				{Any: &CompoundConstraint{Constraints: []Constraint{{Cel: &CelConstraint{}}}}},
+			}}},
+			expectErr: "all constraint[1]: any constraint[0]: cel rule must be set",
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			err := s.constraint.Validate()
+			if s.expectErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), s.expectErr)
+			}
+		})
+	}
+}
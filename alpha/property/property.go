@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/operator-framework/api/pkg/operators"
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -49,6 +50,36 @@ type Channel struct {
 	Priority int `json:"priority"`
 }
 
+// ChannelPriority is the value of an olm.channel.priority property, which a
+// channel declares to rank itself among the channels that provide the same
+// API. Channels with a higher priority are preferred; channels without the
+// property have priority 0.
+type ChannelPriority struct {
+	Priority int `json:"priority"`
+}
+
+// Architectures is the value of an olm.architectures property, which lists
+// the platforms that a bundle supports, as declared by the
+// operatorframework.io/arch.<arch> and operatorframework.io/os.<os> labels
+// of its CSV. The images of the bundle are expected to provide every
+// combination of them.
+type Architectures struct {
+	Architectures    []string `json:"architectures"`
+	OperatingSystems []string `json:"operatingSystems"`
+}
+
+// Validate returns an error if a does not list at least one architecture and
+// one operating system.
+func (a Architectures) Validate() error {
+	if len(a.Architectures) == 0 {
+		return errors.New("architectures must be set")
+	}
+	if len(a.OperatingSystems) == 0 {
+		return errors.New("operatingSystems must be set")
+	}
+	return nil
+}
+
 type PackageRequired struct {
 	PackageName  string `json:"packageName"`
 	VersionRange string `json:"versionRange"`
@@ -66,6 +97,34 @@ type GVKRequired struct {
 	Version string `json:"version"`
 }
 
+// Constraint is the value of an olm.constraint property. Exactly one of
+// Cel, Package, GVK, All, Any and Not must be set.
+type Constraint struct {
+	// FailureMessage is surfaced in resolution when the constraint cannot be
+	// satisfied.
+	FailureMessage string `json:"failureMessage,omitempty"`
+
+	Cel     *CelConstraint   `json:"cel,omitempty"`
+	Package *PackageRequired `json:"package,omitempty"`
+	GVK     *GVKRequired     `json:"gvk,omitempty"`
+
+	// All, Any and Not are satisfied if all, any or none of their
+	// constraints are satisfied.
+	All *CompoundConstraint `json:"all,omitempty"`
+	Any *CompoundConstraint `json:"any,omitempty"`
+	Not *CompoundConstraint `json:"not,omitempty"`
+}
+
+// CelConstraint is satisfied by the bundles for which the CEL expression
+// Rule evaluates to true. Rule is evaluated against the bundle's properties.
+type CelConstraint struct {
+	Rule string `json:"rule"`
+}
+
+type CompoundConstraint struct {
+	Constraints []Constraint `json:"constraints"`
+}
+
 type BundleObject struct {
 	Data []byte `json:"data"`
 }
@@ -87,14 +146,69 @@ type CSVMetadata struct {
 	Provider                  v1alpha1.AppLink                   `json:"provider,omitempty"`
 }
 
+// NewCSVMetadata returns the metadata of csv that an olm.csv.metadata
+// property holds in place of the CSV.
+func NewCSVMetadata(csv v1alpha1.ClusterServiceVersion) CSVMetadata {
+	return CSVMetadata{
+		Annotations:               csv.GetAnnotations(),
+		APIServiceDefinitions:     csv.Spec.APIServiceDefinitions,
+		CustomResourceDefinitions: csv.Spec.CustomResourceDefinitions,
+		Description:               csv.Spec.Description,
+		DisplayName:               csv.Spec.DisplayName,
+		InstallModes:              csv.Spec.InstallModes,
+		Keywords:                  csv.Spec.Keywords,
+		Labels:                    csv.GetLabels(),
+		Links:                     csv.Spec.Links,
+		Maintainers:               csv.Spec.Maintainers,
+		Maturity:                  csv.Spec.Maturity,
+		MinKubeVersion:            csv.Spec.MinKubeVersion,
+		NativeAPIs:                csv.Spec.NativeAPIs,
+		Provider:                  csv.Spec.Provider,
+	}
+}
+
+// ToCSV expands m into a CSV that has only the metadata of m, as registries
+// do to serve bundles that have an olm.csv.metadata property instead of
+// olm.bundle.object properties. The name, version, icon, install strategy
+// and related images of the CSV are left for the caller to set.
+func (m CSVMetadata) ToCSV() v1alpha1.ClusterServiceVersion {
+	return v1alpha1.ClusterServiceVersion{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       operators.ClusterServiceVersionKind,
+			APIVersion: v1alpha1.ClusterServiceVersionAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: m.Annotations,
+			Labels:      m.Labels,
+		},
+		Spec: v1alpha1.ClusterServiceVersionSpec{
+			APIServiceDefinitions:     m.APIServiceDefinitions,
+			CustomResourceDefinitions: m.CustomResourceDefinitions,
+			Description:               m.Description,
+			DisplayName:               m.DisplayName,
+			InstallModes:              m.InstallModes,
+			Keywords:                  m.Keywords,
+			Links:                     m.Links,
+			Maintainers:               m.Maintainers,
+			Maturity:                  m.Maturity,
+			MinKubeVersion:            m.MinKubeVersion,
+			NativeAPIs:                m.NativeAPIs,
+			Provider:                  m.Provider,
+		},
+	}
+}
+
 type Properties struct {
-	Packages         []Package         `hash:"set"`
-	PackagesRequired []PackageRequired `hash:"set"`
-	GVKs             []GVK             `hash:"set"`
-	GVKsRequired     []GVKRequired     `hash:"set"`
-	BundleObjects    []BundleObject    `hash:"set"`
-	Channels         []Channel         `hash:"set"`
-	CSVMetadatas     []CSVMetadata     `hash:"set"`
+	Packages          []Package         `hash:"set"`
+	PackagesRequired  []PackageRequired `hash:"set"`
+	GVKs              []GVK             `hash:"set"`
+	GVKsRequired      []GVKRequired     `hash:"set"`
+	BundleObjects     []BundleObject    `hash:"set"`
+	Channels          []Channel         `hash:"set"`
+	CSVMetadatas      []CSVMetadata     `hash:"set"`
+	Constraints       []Constraint      `hash:"set"`
+	ChannelPriorities []ChannelPriority `hash:"set"`
+	Architectures     []Architectures   `hash:"set"`
 
 	Others []Property `hash:"set"`
 }
@@ -108,6 +222,8 @@ const (
 	TypeCSVMetadata     = "olm.csv.metadata"
 	TypeConstraint      = "olm.constraint"
 	TypeChannel         = "olm.channel"
+	TypeChannelPriority = "olm.channel.priority"
+	TypeArchitectures   = "olm.architectures"
 )
 
 func Parse(in []Property) (*Properties, error) {
@@ -150,6 +266,12 @@ func Parse(in []Property) (*Properties, error) {
 				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
 			}
 			out.CSVMetadatas = append(out.CSVMetadatas, p)
+		case TypeConstraint:
+			var p Constraint
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.Constraints = append(out.Constraints, p)
 		// NOTICE: The Channel properties are for internal use only.
 		//   DO NOT use it for any public-facing functionalities.
 		//   This API is in alpha stage and it is subject to change.
@@ -159,6 +281,18 @@ func Parse(in []Property) (*Properties, error) {
 				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
 			}
 			out.Channels = append(out.Channels, p)
+		case TypeChannelPriority:
+			var p ChannelPriority
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.ChannelPriorities = append(out.ChannelPriorities, p)
+		case TypeArchitectures:
+			var p Architectures
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.Architectures = append(out.Architectures, p)
 		default:
 			var p json.RawMessage
 			if err := json.Unmarshal(prop.Value, &p); err != nil {
@@ -257,24 +391,13 @@ func MustBuildGVKRequired(group, version, kind string) Property {
 func MustBuildBundleObject(data []byte) Property {
 	return MustBuild(&BundleObject{Data: data})
 }
+func MustBuildConstraintCEL(rule, failureMessage string) Property {
+	return MustBuild(&Constraint{FailureMessage: failureMessage, Cel: &CelConstraint{Rule: rule}})
+}
 
 func MustBuildCSVMetadata(csv v1alpha1.ClusterServiceVersion) Property {
-	return MustBuild(&CSVMetadata{
-		Annotations:               csv.GetAnnotations(),
-		APIServiceDefinitions:     csv.Spec.APIServiceDefinitions,
-		CustomResourceDefinitions: csv.Spec.CustomResourceDefinitions,
-		Description:               csv.Spec.Description,
-		DisplayName:               csv.Spec.DisplayName,
-		InstallModes:              csv.Spec.InstallModes,
-		Keywords:                  csv.Spec.Keywords,
-		Labels:                    csv.GetLabels(),
-		Links:                     csv.Spec.Links,
-		Maintainers:               csv.Spec.Maintainers,
-		Maturity:                  csv.Spec.Maturity,
-		MinKubeVersion:            csv.Spec.MinKubeVersion,
-		NativeAPIs:                csv.Spec.NativeAPIs,
-		Provider:                  csv.Spec.Provider,
-	})
+	m := NewCSVMetadata(csv)
+	return MustBuild(&m)
 }
 
 // NOTICE: The Channel properties are for internal use only.
@@ -284,3 +407,16 @@ func MustBuildCSVMetadata(csv v1alpha1.ClusterServiceVersion) Property {
 func MustBuildChannelPriority(name string, priority int) Property {
 	return MustBuild(&Channel{ChannelName: name, Priority: priority})
 }
+
+// MustBuildChannelPriorityProperty builds the olm.channel.priority property
+// of a channel. Unlike the olm.channel property of MustBuildChannelPriority,
+// it is set on channels, not on bundles.
+func MustBuildChannelPriorityProperty(priority int) Property {
+	return MustBuild(&ChannelPriority{Priority: priority})
+}
+
+// MustBuildArchitectures builds the olm.architectures property of a bundle
+// that supports the given architectures and operating systems.
+func MustBuildArchitectures(architectures, operatingSystems []string) Property {
+	return MustBuild(&Architectures{Architectures: architectures, OperatingSystems: operatingSystems})
+}
@@ -108,6 +108,27 @@ func TestParse(t *testing.T) {
 			},
 			assertion: assert.Error,
 		},
+		{
+			name: "Error/InvalidConstraint",
+			input: []Property{
+				{Type: TypeConstraint, Value: json.RawMessage(`{`)},
+			},
+			assertion: assert.Error,
+		},
+		{
+			name: "Error/InvalidChannelPriority",
+			input: []Property{
+				{Type: TypeChannelPriority, Value: json.RawMessage(`{"priority":"high"}`)},
+			},
+			assertion: assert.Error,
+		},
+		{
+			name: "Error/InvalidArchitectures",
+			input: []Property{
+				{Type: TypeArchitectures, Value: json.RawMessage(`{"architectures":"amd64"}`)},
+			},
+			assertion: assert.Error,
+		},
 		{
 			name: "Error/InvalidOther",
 			input: []Property{
@@ -127,6 +148,9 @@ func TestParse(t *testing.T) {
 				MustBuildGVKRequired("other", "v2", "Kind3"),
 				MustBuildGVKRequired("other", "v2", "Kind4"),
 				MustBuildBundleObject([]byte("testdata2")),
+				MustBuildConstraintCEL("true", "always satisfied"),
+				MustBuildChannelPriorityProperty(10),
+				MustBuildArchitectures([]string{"amd64", "arm64"}, []string{"linux"}),
 				{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 				{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
 			},
@@ -150,6 +174,15 @@ func TestParse(t *testing.T) {
 				BundleObjects: []BundleObject{
 					{Data: []byte("testdata2")},
 				},
+				Constraints: []Constraint{
+					{FailureMessage: "always satisfied", Cel: &CelConstraint{Rule: "true"}},
+				},
+				ChannelPriorities: []ChannelPriority{
+					{Priority: 10},
+				},
+				Architectures: []Architectures{
+					{Architectures: []string{"amd64", "arm64"}, OperatingSystems: []string{"linux"}},
+				},
 				Others: []Property{
 					{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 					{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
@@ -273,3 +306,9 @@ func TestMustBuild(t *testing.T) {
 func propPtr(in Property) *Property {
 	return &in
 }
+
+func TestArchitecturesValidate(t *testing.T) {
+	require.NoError(t, Architectures{Architectures: []string{"amd64"}, OperatingSystems: []string{"linux"}}.Validate())
+	require.EqualError(t, Architectures{OperatingSystems: []string{"linux"}}.Validate(), "architectures must be set")
+	require.EqualError(t, Architectures{Architectures: []string{"amd64"}}.Validate(), "operatingSystems must be set")
+}
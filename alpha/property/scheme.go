@@ -13,6 +13,9 @@ func init() {
 		reflect.TypeOf(&GVKRequired{}):     TypeGVKRequired,
 		reflect.TypeOf(&BundleObject{}):    TypeBundleObject,
 		reflect.TypeOf(&CSVMetadata{}):     TypeCSVMetadata,
+		reflect.TypeOf(&Constraint{}):      TypeConstraint,
+		reflect.TypeOf(&ChannelPriority{}): TypeChannelPriority,
+		reflect.TypeOf(&Architectures{}):   TypeArchitectures,
 		// NOTICE: The Channel properties are for internal use only.
 		//   DO NOT use it for any public-facing functionalities.
 		//   This API is in alpha stage and it is subject to change.
@@ -3,47 +3,154 @@ package basic
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 
 	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/renderer"
 )
 
 const schema string = "olm.template.basic"
 
 type Template struct {
 	RenderBundle func(context.Context, string) (*declcfg.DeclarativeConfig, error)
+
+	// ResolveDigest, if set, is used to pin the image and related images of
+	// each rendered bundle to a digest. See PinImages.
+	ResolveDigest ResolveDigestFunc
 }
 
 type BasicTemplate struct {
-	Schema  string          `json:"schema"`
-	Entries []*declcfg.Meta `json:"entries"`
+	Schema            string            `json:"schema"`
+	SkipRangeStrategy SkipRangeStrategy `json:"skipRangeStrategy,omitempty"`
+	Entries           []*declcfg.Meta   `json:"entries"`
 }
 
-func parseSpec(reader io.Reader) (*BasicTemplate, error) {
-	bt := &BasicTemplate{}
-	btDoc := json.RawMessage{}
+// parseSpecs parses the stream of templates, in YAML or JSON, read from
+// reader.
+func parseSpecs(reader io.Reader) ([]*BasicTemplate, error) {
+	var bts []*BasicTemplate
 	btDecoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
-	err := btDecoder.Decode(&btDoc)
-	if err != nil {
-		return nil, fmt.Errorf("decoding template schema: %v", err)
+	for {
+		btDoc := json.RawMessage{}
+		err := btDecoder.Decode(&btDoc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding template schema: %v", err)
+		}
+		if len(btDoc) == 0 || string(btDoc) == "null" {
+			continue
+		}
+		bt := &BasicTemplate{}
+		err = json.Unmarshal(btDoc, bt)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling template: %v", err)
+		}
+
+		if bt.Schema != schema {
+			return nil, fmt.Errorf("template has unknown schema (%q), should be %q", bt.Schema, schema)
+		}
+		if err := bt.SkipRangeStrategy.validate(); err != nil {
+			return nil, err
+		}
+		bts = append(bts, bt)
 	}
-	err = json.Unmarshal(btDoc, bt)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshalling template: %v", err)
+	if len(bts) == 0 {
+		return nil, fmt.Errorf("decoding template schema: no template found")
 	}
+	return bts, nil
+}
 
-	if bt.Schema != schema {
-		return nil, fmt.Errorf("template has unknown schema (%q), should be %q", bt.Schema, schema)
+// mergeSpecs merges templates into one, whose entries are the entries of
+// the templates, in order. The templates must not define the same package,
+// channel, or bundle image, and must not set different skipRangeStrategies.
+func mergeSpecs(bts []*BasicTemplate) (*BasicTemplate, error) {
+	if len(bts) == 1 {
+		return bts[0], nil
 	}
+	merged := &BasicTemplate{Schema: schema}
+	defined := map[string]int{}
+	for i, bt := range bts {
+		if bt.SkipRangeStrategy != "" {
+			if merged.SkipRangeStrategy != "" && merged.SkipRangeStrategy != bt.SkipRangeStrategy {
+				return nil, fmt.Errorf("template %d: skipRangeStrategy %q conflicts with %q of a previous template", i, bt.SkipRangeStrategy, merged.SkipRangeStrategy)
+			}
+			merged.SkipRangeStrategy = bt.SkipRangeStrategy
+		}
+		for _, e := range bt.Entries {
+			key, err := entryKey(e)
+			if err != nil {
+				return nil, fmt.Errorf("template %d: %v", i, err)
+			}
+			if key != "" {
+				if j, ok := defined[key]; ok && j != i {
+					return nil, fmt.Errorf("template %d: %s is also defined in template %d", i, key, j)
+				}
+				defined[key] = i
+			}
+			merged.Entries = append(merged.Entries, e)
+		}
+	}
+	return merged, nil
+}
 
-	return bt, nil
+// entryKey identifies the object that e defines, for the detection of
+// objects that are defined by more than one template.
+func entryKey(e *declcfg.Meta) (string, error) {
+	switch e.Schema {
+	case declcfg.SchemaPackage:
+		return fmt.Sprintf("package %q", e.Name), nil
+	case declcfg.SchemaChannel:
+		return fmt.Sprintf("channel %q of package %q", e.Name, e.Package), nil
+	case declcfg.SchemaBundle:
+		var b declcfg.Bundle
+		if err := json.Unmarshal(e.Blob, &b); err != nil {
+			return "", fmt.Errorf("parse bundle: %v", err)
+		}
+		return fmt.Sprintf("bundle image %q", b.Image), nil
+	}
+	return "", nil
+}
+
+// Validate reads the templates of reader, and checks that they are valid and
+// can be merged, without rendering them.
+func Validate(reader io.Reader) error {
+	bts, err := parseSpecs(reader)
+	if err != nil {
+		return err
+	}
+	_, err = mergeSpecs(bts)
+	return err
 }
 
+// Render renders the templates read from reader, a stream of one or more
+// templates, into a declarative config. See RenderAll.
 func (t Template) Render(ctx context.Context, reader io.Reader) (*declcfg.DeclarativeConfig, error) {
-	bt, err := parseSpec(reader)
+	return t.RenderAll(ctx, reader)
+}
+
+// RenderAll renders the templates read from readers, each a stream of one
+// or more templates, into a single declarative config. The templates are
+// merged before they are rendered, so their entries are rendered in the
+// order of the readers.
+func (t Template) RenderAll(ctx context.Context, readers ...io.Reader) (*declcfg.DeclarativeConfig, error) {
+	var bts []*BasicTemplate
+	for _, reader := range readers {
+		specs, err := parseSpecs(reader)
+		if err != nil {
+			return nil, err
+		}
+		bts = append(bts, specs...)
+	}
+	if len(bts) == 0 {
+		return nil, fmt.Errorf("decoding template schema: no template found")
+	}
+	bt, err := mergeSpecs(bts)
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +160,7 @@ func (t Template) Render(ctx context.Context, reader io.Reader) (*declcfg.Declar
 	}
 
 	outb := cfg.Bundles[:0]
+	failed := 0
 	for _, b := range cfg.Bundles {
 		if !isBundleTemplate(&b) {
 			return nil, fmt.Errorf("unexpected fields present in basic template bundle")
@@ -61,10 +169,27 @@ func (t Template) Render(ctx context.Context, reader io.Reader) (*declcfg.Declar
 		if err != nil {
 			return nil, err
 		}
+		if renderer.IsPlaceholder(contributor) {
+			failed++
+			continue
+		}
 		outb = append(outb, contributor.Bundles...)
 	}
 
 	cfg.Bundles = outb
+	if err := renderer.RemoveUnrendered(cfg, failed); err != nil {
+		return nil, err
+	}
+
+	if t.ResolveDigest != nil {
+		if err := PinImages(ctx, cfg, t.ResolveDigest); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := synthesizeSkipRanges(cfg, bt.SkipRangeStrategy); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -0,0 +1,148 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/renderer"
+)
+
+func TestRenderPlaceholders(t *testing.T) {
+	const template = `schema: olm.template.basic
+skipRangeStrategy: all-previous-patches
+entries:
+- schema: olm.package
+  name: foo
+  defaultChannel: stable
+- schema: olm.channel
+  package: foo
+  name: stable
+  entries:
+  - name: foo.v1.0.0
+  - name: foo.v1.0.1
+    replaces: foo.v1.0.0
+  - name: foo.v1.0.2
+    replaces: foo.v1.0.1
+- schema: olm.bundle
+  image: foo:1.0.0
+- schema: olm.bundle
+  image: foo:1.0.1
+- schema: olm.bundle
+  image: foo:1.0.2
+`
+	r := renderer.New(func(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+		if image == "foo:1.0.1" {
+			return nil, errors.New("unavailable")
+		}
+		return fakeRenderBundle(ctx, image)
+	}, renderer.WithContinueOnError(true))
+
+	cfg, err := Template{RenderBundle: r.RenderBundle}.Render(context.Background(), strings.NewReader(template))
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 2)
+	require.Empty(t, cfg.Others)
+	require.Equal(t, []renderer.Failure{{Image: "foo:1.0.1", Error: "unavailable"}}, r.Failures())
+
+	// The bundle that was not rendered is left out of the channel, so that
+	// the catalog is valid without it.
+	require.Len(t, cfg.Channels[0].Entries, 2)
+	require.Equal(t, "foo.v1.0.2", cfg.Channels[0].Entries[1].Name)
+	require.Equal(t, "foo.v1.0.0", cfg.Channels[0].Entries[1].Replaces)
+	require.Equal(t, ">=1.0.0 <1.0.2", cfg.Channels[0].Entries[1].SkipRange)
+	_, err = declcfg.ConvertToModel(*cfg)
+	require.NoError(t, err)
+}
+
+func TestRenderAll(t *testing.T) {
+	const fooTemplate = `schema: olm.template.basic
+skipRangeStrategy: all-previous-patches
+entries:
+- schema: olm.package
+  name: foo
+  defaultChannel: stable
+- schema: olm.channel
+  package: foo
+  name: stable
+  entries:
+  - name: foo.v1.0.0
+  - name: foo.v1.0.1
+- schema: olm.bundle
+  image: foo:1.0.0
+- schema: olm.bundle
+  image: foo:1.0.1
+`
+	const barTemplates = `{"schema": "olm.template.basic", "entries": [
+  {"schema": "olm.package", "name": "bar", "defaultChannel": "stable"},
+  {"schema": "olm.channel", "package": "bar", "name": "stable", "entries": [{"name": "bar.v1.0.0"}]},
+  {"schema": "olm.bundle", "image": "bar:1.0.0"}
+]}
+{"schema": "olm.template.basic", "entries": [
+  {"schema": "olm.package", "name": "baz", "defaultChannel": "stable"},
+  {"schema": "olm.channel", "package": "baz", "name": "stable", "entries": [{"name": "baz.v1.0.0"}]},
+  {"schema": "olm.bundle", "image": "baz:1.0.0"}
+]}
+`
+	tmpl := Template{RenderBundle: fakeRenderBundle}
+
+	t.Run("Merged", func(t *testing.T) {
+		cfg, err := tmpl.RenderAll(context.Background(), strings.NewReader(fooTemplate), strings.NewReader(barTemplates))
+		require.NoError(t, err)
+
+		var packages, bundles []string
+		for _, p := range cfg.Packages {
+			packages = append(packages, p.Name)
+		}
+		for _, b := range cfg.Bundles {
+			bundles = append(bundles, b.Name)
+		}
+		require.Equal(t, []string{"foo", "bar", "baz"}, packages)
+		require.Equal(t, []string{"foo.v1.0.0", "foo.v1.0.1", "bar.v1.0.0", "baz.v1.0.0"}, bundles)
+		// The skipRangeStrategy of a template applies to the merged templates.
+		require.Equal(t, ">=1.0.0 <1.0.1", cfg.Channels[0].Entries[1].SkipRange)
+	})
+	t.Run("MultiDocumentYAML", func(t *testing.T) {
+		cfg, err := tmpl.Render(context.Background(), strings.NewReader(fooTemplate+`---
+schema: olm.template.basic
+entries:
+- schema: olm.package
+  name: bar
+- schema: olm.bundle
+  image: bar:1.0.0
+`))
+		require.NoError(t, err)
+		require.Len(t, cfg.Packages, 2)
+		require.Len(t, cfg.Bundles, 3)
+	})
+	t.Run("Error/DuplicatePackage", func(t *testing.T) {
+		_, err := tmpl.RenderAll(context.Background(), strings.NewReader(fooTemplate), strings.NewReader(`schema: olm.template.basic
+entries:
+- schema: olm.package
+  name: foo
+`))
+		require.EqualError(t, err, `template 1: package "foo" is also defined in template 0`)
+	})
+	t.Run("Error/DuplicateBundleImage", func(t *testing.T) {
+		_, err := tmpl.RenderAll(context.Background(), strings.NewReader(fooTemplate), strings.NewReader(`schema: olm.template.basic
+entries:
+- schema: olm.bundle
+  image: foo:1.0.1
+`))
+		require.EqualError(t, err, `template 1: bundle image "foo:1.0.1" is also defined in template 0`)
+	})
+	t.Run("Error/ConflictingSkipRangeStrategies", func(t *testing.T) {
+		_, err := tmpl.RenderAll(context.Background(), strings.NewReader(fooTemplate), strings.NewReader(`schema: olm.template.basic
+skipRangeStrategy: all-previous-in-minor
+entries: []
+`))
+		require.EqualError(t, err, `template 1: skipRangeStrategy "all-previous-in-minor" conflicts with "all-previous-patches" of a previous template`)
+	})
+	t.Run("Error/NoTemplate", func(t *testing.T) {
+		_, err := tmpl.Render(context.Background(), strings.NewReader(""))
+		require.EqualError(t, err, "decoding template schema: no template found")
+	})
+}
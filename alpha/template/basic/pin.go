@@ -0,0 +1,63 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// ResolveDigestFunc returns the digest of the image that a reference
+// currently refers to.
+type ResolveDigestFunc func(ctx context.Context, image string) (digest.Digest, error)
+
+// PinImages rewrites the image and related images of each bundle in cfg to
+// reference their images by the digests returned by resolve, so that the
+// catalog always refers to the same image content. References that already
+// include a digest are left unchanged.
+func PinImages(ctx context.Context, cfg *declcfg.DeclarativeConfig, resolve ResolveDigestFunc) error {
+	pinned := map[string]string{}
+	pin := func(image string) (string, error) {
+		if image == "" {
+			return image, nil
+		}
+		if p, ok := pinned[image]; ok {
+			return p, nil
+		}
+		named, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			return "", fmt.Errorf("parse image %q: %v", image, err)
+		}
+		if _, ok := named.(reference.Canonical); ok {
+			pinned[image] = image
+			return image, nil
+		}
+		dgst, err := resolve(ctx, image)
+		if err != nil {
+			return "", fmt.Errorf("resolve digest of image %q: %v", image, err)
+		}
+		canonical, err := reference.WithDigest(reference.TrimNamed(named), dgst)
+		if err != nil {
+			return "", fmt.Errorf("pin image %q: %v", image, err)
+		}
+		pinned[image] = reference.FamiliarString(canonical)
+		return pinned[image], nil
+	}
+
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		var err error
+		if b.Image, err = pin(b.Image); err != nil {
+			return fmt.Errorf("bundle %q: %v", b.Name, err)
+		}
+		for j := range b.RelatedImages {
+			if b.RelatedImages[j].Image, err = pin(b.RelatedImages[j].Image); err != nil {
+				return fmt.Errorf("bundle %q: related image %q: %v", b.Name, b.RelatedImages[j].Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestPinImages(t *testing.T) {
+	digests := map[string]digest.Digest{
+		"quay.io/foo/bundle:v1.0.0": digest.FromString("bundle"),
+		"quay.io/foo/operator:v1":   digest.FromString("operator"),
+		"foo:1.0.0":                 digest.FromString("foo"),
+	}
+	resolved := map[string]int{}
+	resolve := func(_ context.Context, image string) (digest.Digest, error) {
+		resolved[image]++
+		d, ok := digests[image]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return d, nil
+	}
+	pinnedOperator := "quay.io/foo/operator@" + digests["quay.io/foo/operator:v1"].String()
+
+	t.Run("Success", func(t *testing.T) {
+		resolved = map[string]int{}
+		cfg := &declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{
+			{
+				Name:  "foo.v1.0.0",
+				Image: "quay.io/foo/bundle:v1.0.0",
+				RelatedImages: []declcfg.RelatedImage{
+					{Image: "quay.io/foo/bundle:v1.0.0"},
+					{Name: "operator", Image: "quay.io/foo/operator:v1"},
+				},
+			},
+			{
+				Name:  "foo.v1.0.1",
+				Image: "foo:1.0.0",
+				RelatedImages: []declcfg.RelatedImage{
+					{Name: "operator", Image: "quay.io/foo/operator:v1"},
+					{Name: "pinned", Image: pinnedOperator},
+				},
+			},
+		}}
+		require.NoError(t, PinImages(context.Background(), cfg, resolve))
+
+		pinnedBundle := "quay.io/foo/bundle@" + digests["quay.io/foo/bundle:v1.0.0"].String()
+		require.Equal(t, pinnedBundle, cfg.Bundles[0].Image)
+		require.Equal(t, []declcfg.RelatedImage{
+			{Image: pinnedBundle},
+			{Name: "operator", Image: pinnedOperator},
+		}, cfg.Bundles[0].RelatedImages)
+		require.Equal(t, "foo@"+digests["foo:1.0.0"].String(), cfg.Bundles[1].Image)
+		require.Equal(t, []declcfg.RelatedImage{
+			{Name: "operator", Image: pinnedOperator},
+			{Name: "pinned", Image: pinnedOperator},
+		}, cfg.Bundles[1].RelatedImages)
+
+		// Each image is resolved once, and images that are already pinned
+		// are not resolved.
+		require.Equal(t, map[string]int{
+			"quay.io/foo/bundle:v1.0.0": 1,
+			"quay.io/foo/operator:v1":   1,
+			"foo:1.0.0":                 1,
+		}, resolved)
+	})
+
+	t.Run("ResolveError", func(t *testing.T) {
+		cfg := &declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{
+			{Name: "foo.v1.0.0", Image: "quay.io/foo/bundle:v1.0.0", RelatedImages: []declcfg.RelatedImage{{Name: "missing", Image: "quay.io/foo/missing:v1"}}},
+		}}
+		err := PinImages(context.Background(), cfg, resolve)
+		require.EqualError(t, err, `bundle "foo.v1.0.0": related image "missing": resolve digest of image "quay.io/foo/missing:v1": not found`)
+	})
+}
+
+func TestRenderPinImages(t *testing.T) {
+	const template = `schema: olm.template.basic
+entries:
+- schema: olm.package
+  name: foo
+  defaultChannel: stable
+- schema: olm.channel
+  package: foo
+  name: stable
+  entries:
+  - name: foo.v1.0.0
+- schema: olm.bundle
+  image: foo:1.0.0
+`
+	dgst := digest.FromString("foo")
+	cfg, err := Template{
+		RenderBundle: fakeRenderBundle,
+		ResolveDigest: func(_ context.Context, image string) (digest.Digest, error) {
+			return dgst, nil
+		},
+	}.Render(context.Background(), strings.NewReader(template))
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, "foo@"+dgst.String(), cfg.Bundles[0].Image)
+}
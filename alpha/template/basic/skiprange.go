@@ -0,0 +1,109 @@
+package basic
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// SkipRangeStrategy controls how skipRange values are synthesized for
+// channel entries that do not already declare one.
+type SkipRangeStrategy string
+
+const (
+	// SkipRangeStrategyNone leaves channel entries unchanged.
+	SkipRangeStrategyNone SkipRangeStrategy = ""
+
+	// SkipRangeStrategyAllPreviousPatches sets each entry's skipRange to cover
+	// the released patch versions of preceding entries in the channel that
+	// share the entry's major and minor version, e.g. ">=1.2.0 <1.2.3".
+	SkipRangeStrategyAllPreviousPatches SkipRangeStrategy = "all-previous-patches"
+
+	// SkipRangeStrategyAllPreviousInMinor sets each entry's skipRange to cover
+	// the versions of preceding entries in the channel that share the entry's
+	// major and minor version, pre-releases included, e.g.
+	// ">=1.2.0-rc.1 <1.2.3".
+	SkipRangeStrategyAllPreviousInMinor SkipRangeStrategy = "all-previous-in-minor"
+)
+
+func (s SkipRangeStrategy) validate() error {
+	switch s {
+	case SkipRangeStrategyNone, SkipRangeStrategyAllPreviousPatches, SkipRangeStrategyAllPreviousInMinor:
+		return nil
+	}
+	return fmt.Errorf("unknown skipRangeStrategy %q, expected one of (%s|%s)", s, SkipRangeStrategyAllPreviousPatches, SkipRangeStrategyAllPreviousInMinor)
+}
+
+// synthesizeSkipRanges populates the skipRange of every channel entry in cfg
+// according to strategy. The range for an entry is computed from the versions
+// of the entries listed before it in the same channel, so channel entries are
+// expected to be listed in ascending version order. Entries that already have
+// a skipRange, or that have no qualifying predecessors, are left unchanged.
+// Every entry must reference a bundle of cfg.
+func synthesizeSkipRanges(cfg *declcfg.DeclarativeConfig, strategy SkipRangeStrategy) error {
+	if strategy == SkipRangeStrategyNone {
+		return nil
+	}
+
+	versions := map[string]map[string]semver.Version{}
+	for _, b := range cfg.Bundles {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			return fmt.Errorf("parse properties for bundle %q: %v", b.Name, err)
+		}
+		if len(props.Packages) != 1 {
+			return fmt.Errorf("bundle %q has %d %q properties, expected exactly 1", b.Name, len(props.Packages), property.TypePackage)
+		}
+		v, err := semver.Parse(props.Packages[0].Version)
+		if err != nil {
+			return fmt.Errorf("bundle %q has invalid version %q: %v", b.Name, props.Packages[0].Version, err)
+		}
+		if versions[b.Package] == nil {
+			versions[b.Package] = map[string]semver.Version{}
+		}
+		versions[b.Package][b.Name] = v
+	}
+
+	for ci := range cfg.Channels {
+		ch := &cfg.Channels[ci]
+		var previous []semver.Version
+		for ei := range ch.Entries {
+			entry := &ch.Entries[ei]
+			v, ok := versions[ch.Package][entry.Name]
+			if !ok {
+				return fmt.Errorf("channel %q of package %q: entry %q: bundle not found, so its skipRange cannot be synthesized", ch.Name, ch.Package, entry.Name)
+			}
+			if entry.SkipRange == "" {
+				if lowest, ok := lowestSkippable(v, previous, strategy); ok {
+					entry.SkipRange = fmt.Sprintf(">=%s <%s", lowest, v)
+				}
+			}
+			previous = append(previous, v)
+		}
+	}
+	return nil
+}
+
+// lowestSkippable returns the lowest version in previous that an entry at
+// version v should skip under strategy.
+func lowestSkippable(v semver.Version, previous []semver.Version, strategy SkipRangeStrategy) (semver.Version, bool) {
+	var (
+		lowest semver.Version
+		found  bool
+	)
+	for _, p := range previous {
+		if !p.LT(v) || p.Major != v.Major || p.Minor != v.Minor {
+			continue
+		}
+		if strategy == SkipRangeStrategyAllPreviousPatches && (p.Patch == v.Patch || len(p.Pre) > 0) {
+			continue
+		}
+		if !found || p.LT(lowest) {
+			lowest, found = p, true
+		}
+	}
+	return lowest, found
+}
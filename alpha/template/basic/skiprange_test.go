@@ -0,0 +1,133 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func fakeRenderBundle(_ context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+	// images are of the form "<package>:<version>"
+	pkg, version, _ := strings.Cut(image, ":")
+	return &declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{{
+		Schema:     declcfg.SchemaBundle,
+		Name:       fmt.Sprintf("%s.v%s", pkg, version),
+		Package:    pkg,
+		Image:      image,
+		Properties: []property.Property{property.MustBuildPackage(pkg, version)},
+	}}}, nil
+}
+
+func TestRenderSkipRangeStrategy(t *testing.T) {
+	template := func(strategy string) string {
+		return fmt.Sprintf(`schema: olm.template.basic
+skipRangeStrategy: %q
+entries:
+- schema: olm.package
+  name: foo
+  defaultChannel: stable
+- schema: olm.channel
+  package: foo
+  name: stable
+  entries:
+  - name: foo.v1.0.0
+  - name: foo.v1.0.1
+    replaces: foo.v1.0.0
+  - name: foo.v1.1.0-rc.1
+    replaces: foo.v1.0.1
+  - name: foo.v1.1.0
+    replaces: foo.v1.1.0-rc.1
+  - name: foo.v1.1.1
+    replaces: foo.v1.1.0
+  - name: foo.v1.1.2
+    replaces: foo.v1.1.1
+    skipRange: ">=1.1.1 <1.1.2"
+- schema: olm.bundle
+  image: foo:1.0.0
+- schema: olm.bundle
+  image: foo:1.0.1
+- schema: olm.bundle
+  image: foo:1.1.0-rc.1
+- schema: olm.bundle
+  image: foo:1.1.0
+- schema: olm.bundle
+  image: foo:1.1.1
+- schema: olm.bundle
+  image: foo:1.1.2
+`, strategy)
+	}
+
+	type spec struct {
+		name       string
+		strategy   string
+		assertion  require.ErrorAssertionFunc
+		skipRanges []string
+	}
+	specs := []spec{
+		{
+			name:       "None",
+			strategy:   "",
+			assertion:  require.NoError,
+			skipRanges: []string{"", "", "", "", "", ">=1.1.1 <1.1.2"},
+		},
+		{
+			name:       "AllPreviousPatches",
+			strategy:   string(SkipRangeStrategyAllPreviousPatches),
+			assertion:  require.NoError,
+			skipRanges: []string{"", ">=1.0.0 <1.0.1", "", "", ">=1.1.0 <1.1.1", ">=1.1.1 <1.1.2"},
+		},
+		{
+			name:       "AllPreviousInMinor",
+			strategy:   string(SkipRangeStrategyAllPreviousInMinor),
+			assertion:  require.NoError,
+			skipRanges: []string{"", ">=1.0.0 <1.0.1", "", ">=1.1.0-rc.1 <1.1.0", ">=1.1.0-rc.1 <1.1.1", ">=1.1.1 <1.1.2"},
+		},
+		{
+			name:      "Unknown",
+			strategy:  "everything",
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			tmpl := Template{RenderBundle: fakeRenderBundle}
+			cfg, err := tmpl.Render(context.Background(), strings.NewReader(template(s.strategy)))
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+			require.Len(t, cfg.Channels, 1)
+			var skipRanges []string
+			for _, e := range cfg.Channels[0].Entries {
+				skipRanges = append(skipRanges, e.SkipRange)
+			}
+			require.Equal(t, s.skipRanges, skipRanges)
+		})
+	}
+}
+
+func TestRenderSkipRangeStrategyMissingBundle(t *testing.T) {
+	const template = `schema: olm.template.basic
+skipRangeStrategy: all-previous-patches
+entries:
+- schema: olm.package
+  name: foo
+  defaultChannel: stable
+- schema: olm.channel
+  package: foo
+  name: stable
+  entries:
+  - name: foo.v1.0.0
+  - name: foo.v1.0.1
+- schema: olm.bundle
+  image: foo:1.0.0
+`
+	_, err := Template{RenderBundle: fakeRenderBundle}.Render(context.Background(), strings.NewReader(template))
+	require.EqualError(t, err, `channel "stable" of package "foo": entry "foo.v1.0.1": bundle not found, so its skipRange cannot be synthesized`)
+}
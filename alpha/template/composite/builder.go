@@ -0,0 +1,305 @@
+package composite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/basic"
+	"github.com/operator-framework/operator-registry/alpha/template/renderer"
+	"github.com/operator-framework/operator-registry/alpha/template/semver"
+)
+
+const (
+	BasicBuilderSchema  = "olm.builder.basic"
+	SemverBuilderSchema = "olm.builder.semver"
+	RawBuilderSchema    = "olm.builder.raw"
+	CustomBuilderSchema = "olm.builder.custom"
+)
+
+// RenderBundleFunc renders a bundle image into a declarative config.
+type RenderBundleFunc func(context.Context, string) (*declcfg.DeclarativeConfig, error)
+
+// BuilderConfig is the configuration that is common to the builders of the
+// components of a catalog.
+type BuilderConfig struct {
+	// WorkingDir is the directory of the catalog. Components are written to
+	// subdirectories of it.
+	WorkingDir string
+	// OutputType is the format of the files that builders write, json or
+	// yaml.
+	OutputType string
+	// RenderBundle renders the bundle images that components reference.
+	RenderBundle RenderBundleFunc
+	// HTTPClient fetches the https inputs of components. If it is nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// Builder builds components of a catalog.
+type Builder interface {
+	// Build builds the component defined by td into dir, relative to the
+	// working directory of the catalog.
+	Build(ctx context.Context, dir string, td TemplateDefinition) error
+	// Validate checks that the component in dir, relative to the working
+	// directory of the catalog, is a valid file-based catalog.
+	Validate(ctx context.Context, dir string) error
+}
+
+// NewBuilder returns the builder of schema.
+func NewBuilder(schema string, cfg BuilderConfig) (Builder, error) {
+	switch cfg.OutputType {
+	case "json", "yaml":
+	default:
+		return nil, fmt.Errorf("invalid output type %q, expected (json|yaml)", cfg.OutputType)
+	}
+	switch schema {
+	case BasicBuilderSchema:
+		return &BasicBuilder{cfg: cfg}, nil
+	case SemverBuilderSchema:
+		return &SemverBuilder{cfg: cfg}, nil
+	case RawBuilderSchema:
+		return &RawBuilder{cfg: cfg}, nil
+	case CustomBuilderSchema:
+		return &CustomBuilder{cfg: cfg}, nil
+	case WasmBuilderSchema:
+		return &WasmBuilder{cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown builder %q", schema)
+}
+
+// InputConfig is the configuration of the builders that render an input
+// file into an output file.
+type InputConfig struct {
+	// Input is the file to render: a local path, an https:// URL, or a file
+	// in a git repository, as git+<url>//<path>[?ref=<revision>].
+	Input string `json:"input"`
+	// Checksum is the digest that the content of Input must match, like
+	// sha256:<hex>. It is optional, but recommended for remote inputs.
+	Checksum string `json:"checksum,omitempty"`
+	// Output is the name of the file to write in the directory of the
+	// component.
+	Output string `json:"output"`
+}
+
+func (c InputConfig) validate() error {
+	if c.Input == "" {
+		return fmt.Errorf("input must be set")
+	}
+	if c.Output == "" {
+		return fmt.Errorf("output must be set")
+	}
+	return nil
+}
+
+// BasicBuilder renders basic templates.
+type BasicBuilder struct {
+	cfg BuilderConfig
+}
+
+func (b *BasicBuilder) Build(ctx context.Context, dir string, td TemplateDefinition) error {
+	var c InputConfig
+	if err := parseBuilderConfig(td, BasicBuilderSchema, &c); err != nil {
+		return err
+	}
+	input, err := readInput(ctx, b.cfg, c.Input, c.Checksum)
+	if err != nil {
+		return err
+	}
+	fbc, err := basic.Template{RenderBundle: b.cfg.RenderBundle}.Render(ctx, bytes.NewReader(input))
+	if err != nil {
+		return fmt.Errorf("render basic template %q: %v", c.Input, err)
+	}
+	return writeOutput(b.cfg, dir, c.Output, *fbc)
+}
+
+func (b *BasicBuilder) Validate(ctx context.Context, dir string) error {
+	return validate(ctx, b.cfg, dir)
+}
+
+// SemverBuilder renders semver templates.
+type SemverBuilder struct {
+	cfg BuilderConfig
+}
+
+func (b *SemverBuilder) Build(ctx context.Context, dir string, td TemplateDefinition) error {
+	var c InputConfig
+	if err := parseBuilderConfig(td, SemverBuilderSchema, &c); err != nil {
+		return err
+	}
+	input, err := readInput(ctx, b.cfg, c.Input, c.Checksum)
+	if err != nil {
+		return err
+	}
+	fbc, err := semver.Template{Data: bytes.NewReader(input), RenderBundle: b.cfg.RenderBundle}.Render(ctx)
+	if err != nil {
+		return fmt.Errorf("render semver template %q: %v", c.Input, err)
+	}
+	return writeOutput(b.cfg, dir, c.Output, *fbc)
+}
+
+func (b *SemverBuilder) Validate(ctx context.Context, dir string) error {
+	return validate(ctx, b.cfg, dir)
+}
+
+// RawBuilder copies file-based catalogs.
+type RawBuilder struct {
+	cfg BuilderConfig
+}
+
+func (b *RawBuilder) Build(ctx context.Context, dir string, td TemplateDefinition) error {
+	var c InputConfig
+	if err := parseBuilderConfig(td, RawBuilderSchema, &c); err != nil {
+		return err
+	}
+	input, err := readInput(ctx, b.cfg, c.Input, c.Checksum)
+	if err != nil {
+		return err
+	}
+	fbc, err := declcfg.LoadReader(bytes.NewReader(input))
+	if err != nil {
+		return fmt.Errorf("load %q: %v", c.Input, err)
+	}
+	return writeOutput(b.cfg, dir, c.Output, *fbc)
+}
+
+func (b *RawBuilder) Validate(ctx context.Context, dir string) error {
+	return validate(ctx, b.cfg, dir)
+}
+
+// CustomConfig is the configuration of the custom builder.
+type CustomConfig struct {
+	// Command is the command to run. It writes a file-based catalog to its
+	// standard output.
+	Command string `json:"command"`
+	// Args are the arguments of the command.
+	Args []string `json:"args,omitempty"`
+	// Output is the name of the file to write in the directory of the
+	// component.
+	Output string `json:"output"`
+}
+
+// CustomBuilder runs a command that writes a file-based catalog.
+type CustomBuilder struct {
+	cfg BuilderConfig
+}
+
+func (b *CustomBuilder) Build(ctx context.Context, dir string, td TemplateDefinition) error {
+	var c CustomConfig
+	if err := parseBuilderConfig(td, CustomBuilderSchema, &c); err != nil {
+		return err
+	}
+	if c.Command == "" {
+		return fmt.Errorf("command must be set")
+	}
+	if c.Output == "" {
+		return fmt.Errorf("output must be set")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command, c.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("run %q: %v: %s", c.Command, err, msg)
+		}
+		return fmt.Errorf("run %q: %v", c.Command, err)
+	}
+	fbc, err := declcfg.LoadReader(&stdout)
+	if err != nil {
+		return fmt.Errorf("run %q: invalid output: %v", c.Command, err)
+	}
+	return writeOutput(b.cfg, dir, c.Output, *fbc)
+}
+
+func (b *CustomBuilder) Validate(ctx context.Context, dir string) error {
+	return validate(ctx, b.cfg, dir)
+}
+
+// parseBuilderConfig checks that td is a definition for the builder of
+// schema, and decodes its configuration into c.
+func parseBuilderConfig(td TemplateDefinition, schema string, c any) error {
+	if td.Schema != schema {
+		return fmt.Errorf("invalid schema %q, expected %q", td.Schema, schema)
+	}
+	if len(td.Config) == 0 {
+		return fmt.Errorf("%s: config must be set", schema)
+	}
+	dec := json.NewDecoder(bytes.NewReader(td.Config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("%s: parse config: %v", schema, err)
+	}
+	if v, ok := c.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("%s: %v", schema, err)
+		}
+	}
+	return nil
+}
+
+// renderBundleImages renders the bundles of fbc that only have a schema and
+// an image, like basic templates do, and keeps the other bundles as they are.
+func renderBundleImages(ctx context.Context, fbc *declcfg.DeclarativeConfig, render RenderBundleFunc) error {
+	bundles := fbc.Bundles[:0]
+	failed := 0
+	for _, b := range fbc.Bundles {
+		if b.Image == "" || b.Name != "" || b.Package != "" || len(b.Properties) > 0 || len(b.RelatedImages) > 0 {
+			bundles = append(bundles, b)
+			continue
+		}
+		rendered, err := render(ctx, b.Image)
+		if err != nil {
+			return err
+		}
+		if renderer.IsPlaceholder(rendered) {
+			failed++
+			continue
+		}
+		bundles = append(bundles, rendered.Bundles...)
+	}
+	fbc.Bundles = bundles
+	return renderer.RemoveUnrendered(fbc, failed)
+}
+
+// writeOutput writes fbc to the file named output in dir, relative to the
+// working directory of the catalog.
+func writeOutput(cfg BuilderConfig, dir, output string, fbc declcfg.DeclarativeConfig) error {
+	if filepath.Base(output) != output {
+		return fmt.Errorf("invalid output %q: must be a file name", output)
+	}
+	outDir := filepath.Join(cfg.WorkingDir, dir)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	write := declcfg.WriteJSON
+	if cfg.OutputType == "yaml" {
+		write = declcfg.WriteYAML
+	}
+	var buf bytes.Buffer
+	if err := write(fbc, &buf); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, output), buf.Bytes(), 0644)
+}
+
+// validate checks that dir, relative to the working directory of the
+// catalog, is a valid file-based catalog.
+func validate(ctx context.Context, cfg BuilderConfig, dir string) error {
+	path := filepath.Join(cfg.WorkingDir, dir)
+	fbc, err := declcfg.LoadFS(ctx, os.DirFS(path))
+	if err != nil {
+		return fmt.Errorf("load %q: %v", path, err)
+	}
+	if _, err := declcfg.ConvertToModel(*fbc); err != nil {
+		return fmt.Errorf("validate %q: %v", path, err)
+	}
+	return nil
+}
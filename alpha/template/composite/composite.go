@@ -0,0 +1,88 @@
+// Package composite implements the composite template, which builds the
+// components of one or more catalogs, e.g. their packages, each with its own
+// builder: a basic or semver template, a file-based catalog, a command, or a
+// WASM module.
+package composite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+)
+
+// Template builds the components defined in a contributions file into the
+// catalogs defined in a catalogs file.
+type Template struct {
+	// CatalogFile is the catalogs file, see CatalogConfig.
+	CatalogFile io.Reader
+	// ContributionFile is the contributions file, see CompositeConfig.
+	ContributionFile io.Reader
+	// Validate checks that each component is a valid file-based catalog
+	// once it is built.
+	Validate bool
+	// OutputType is the format of the files of the components, json or
+	// yaml.
+	OutputType string
+	// RenderBundle renders the bundle images that components reference.
+	RenderBundle RenderBundleFunc
+	// HTTPClient fetches the https inputs of components. If it is nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// Render builds each component of the contributions file with its builder,
+// into the working directory of its catalog.
+func (t Template) Render(ctx context.Context) error {
+	catalogs, err := ParseCatalogConfig(t.CatalogFile)
+	if err != nil {
+		return fmt.Errorf("catalogs file: %v", err)
+	}
+	contributions, err := ParseCompositeConfig(t.ContributionFile)
+	if err != nil {
+		return fmt.Errorf("contributions file: %v", err)
+	}
+
+	byName := map[string]Catalog{}
+	for _, c := range catalogs.Catalogs {
+		byName[c.Name] = c
+	}
+	for _, component := range contributions.Components {
+		if err := t.build(ctx, byName, component); err != nil {
+			return fmt.Errorf("component %q of catalog %q: %v", component.Destination.Path, component.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t Template) build(ctx context.Context, catalogs map[string]Catalog, component Component) error {
+	catalog, ok := catalogs[component.Name]
+	if !ok {
+		return fmt.Errorf("catalog not found in catalogs file")
+	}
+	if !filepath.IsLocal(component.Destination.Path) {
+		return fmt.Errorf("invalid destination path: must be a relative path within the working directory of the catalog")
+	}
+	schema := component.Strategy.Template.Schema
+	if !slices.Contains(catalog.Builders, schema) {
+		return fmt.Errorf("builder %q is not enabled for the catalog, expected one of %q", schema, catalog.Builders)
+	}
+	builder, err := NewBuilder(schema, BuilderConfig{
+		WorkingDir:   catalog.Destination.WorkingDir,
+		OutputType:   t.OutputType,
+		RenderBundle: t.RenderBundle,
+		HTTPClient:   t.HTTPClient,
+	})
+	if err != nil {
+		return err
+	}
+	if err := builder.Build(ctx, component.Destination.Path, component.Strategy.Template); err != nil {
+		return err
+	}
+	if t.Validate {
+		return builder.Validate(ctx, component.Destination.Path)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package composite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+const testFBC = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: quay.io/foo/foo-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.1.0
+`
+
+func TestParseCatalogConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "Valid",
+			input: `schema: olm.composite.catalogs
+catalogs:
+- name: v4.14
+  destination:
+    workingDir: catalogs/v4.14
+  builders:
+  - olm.builder.basic
+`,
+		},
+		{
+			name:    "InvalidSchema",
+			input:   "schema: olm.composite\n",
+			wantErr: `invalid schema "olm.composite", expected "olm.composite.catalogs"`,
+		},
+		{
+			name: "UnknownField",
+			input: `schema: olm.composite.catalogs
+catalogs:
+- name: v4.14
+  workdir: catalogs/v4.14
+`,
+			wantErr: `unknown field "workdir"`,
+		},
+		{
+			name: "DuplicateCatalog",
+			input: `schema: olm.composite.catalogs
+catalogs:
+- name: v4.14
+  destination:
+    workingDir: a
+- name: v4.14
+  destination:
+    workingDir: b
+`,
+			wantErr: `duplicate catalog "v4.14"`,
+		},
+		{
+			name: "MissingWorkingDir",
+			input: `schema: olm.composite.catalogs
+catalogs:
+- name: v4.14
+`,
+			wantErr: `catalog "v4.14": destination.workingDir must be set`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCatalogConfig(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "foo.yaml")
+	require.NoError(t, os.WriteFile(input, []byte(testFBC), 0644))
+	workingDir := filepath.Join(dir, "catalog")
+
+	catalogs := `schema: olm.composite.catalogs
+catalogs:
+- name: test
+  destination:
+    workingDir: ` + workingDir + `
+  builders:
+  - olm.builder.raw
+`
+	component := func(path, schema string) string {
+		return `schema: olm.composite
+components:
+- name: test
+  destination:
+    path: ` + path + `
+  strategy:
+    name: copy
+    template:
+      schema: ` + schema + `
+      config:
+        input: ` + input + `
+        output: catalog.json
+`
+	}
+
+	tests := []struct {
+		name          string
+		contributions string
+		wantErr       string
+	}{
+		{
+			name:          "Raw",
+			contributions: component("foo", RawBuilderSchema),
+		},
+		{
+			name:          "BuilderNotEnabled",
+			contributions: component("foo", BasicBuilderSchema),
+			wantErr:       `component "foo" of catalog "test": builder "olm.builder.basic" is not enabled for the catalog`,
+		},
+		{
+			name:          "DestinationOutsideWorkingDir",
+			contributions: component("../foo", RawBuilderSchema),
+			wantErr:       "invalid destination path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Template{
+				CatalogFile:      strings.NewReader(catalogs),
+				ContributionFile: strings.NewReader(tt.contributions),
+				Validate:         true,
+				OutputType:       "json",
+			}.Render(context.Background())
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			fbc, err := declcfg.LoadFS(context.Background(), os.DirFS(filepath.Join(workingDir, "foo")))
+			require.NoError(t, err)
+			require.Len(t, fbc.Packages, 1)
+			require.Len(t, fbc.Bundles, 1)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package composite
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CatalogsSchema is the schema of the catalogs file, which defines the
+	// catalogs that components are built into.
+	CatalogsSchema = "olm.composite.catalogs"
+	// ContributionsSchema is the schema of the contributions file, which
+	// defines the components of the catalogs and how to build them.
+	ContributionsSchema = "olm.composite"
+)
+
+// CatalogConfig is the content of a catalogs file.
+type CatalogConfig struct {
+	Schema   string    `json:"schema"`
+	Catalogs []Catalog `json:"catalogs"`
+}
+
+// Catalog is a catalog that components are built into.
+type Catalog struct {
+	// Name is the name of the catalog, which components refer to.
+	Name        string             `json:"name"`
+	Destination CatalogDestination `json:"destination"`
+	// Builders are the schemas of the builders that components of the
+	// catalog may use.
+	Builders []string `json:"builders"`
+}
+
+// CatalogDestination is where the components of a catalog are written.
+type CatalogDestination struct {
+	// WorkingDir is the directory of the catalog, which the outputs of its
+	// components are written to.
+	WorkingDir string `json:"workingDir"`
+}
+
+// CompositeConfig is the content of a contributions file.
+type CompositeConfig struct {
+	Schema     string      `json:"schema"`
+	Components []Component `json:"components"`
+}
+
+// Component is a part of a catalog, e.g. a package, that is built with a
+// builder.
+type Component struct {
+	// Name is the name of the catalog that the component is part of.
+	Name        string               `json:"name"`
+	Destination ComponentDestination `json:"destination"`
+	Strategy    BuildStrategy        `json:"strategy"`
+}
+
+// ComponentDestination is where a component is written.
+type ComponentDestination struct {
+	// Path is the directory of the component, relative to the working
+	// directory of its catalog.
+	Path string `json:"path"`
+}
+
+// BuildStrategy is how a component is built.
+type BuildStrategy struct {
+	Name     string             `json:"name"`
+	Template TemplateDefinition `json:"template"`
+}
+
+// TemplateDefinition selects the builder of a component by its schema, and
+// holds the configuration of the builder.
+type TemplateDefinition struct {
+	Schema string          `json:"schema"`
+	Config json.RawMessage `json:"config"`
+}
+
+// ParseCatalogConfig reads a catalogs file, in YAML or JSON, from r.
+func ParseCatalogConfig(r io.Reader) (*CatalogConfig, error) {
+	var cfg CatalogConfig
+	if err := parseConfig(r, CatalogsSchema, &cfg, &cfg.Schema); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, c := range cfg.Catalogs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("catalog name must be set")
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicate catalog %q", c.Name)
+		}
+		names[c.Name] = true
+		if c.Destination.WorkingDir == "" {
+			return nil, fmt.Errorf("catalog %q: destination.workingDir must be set", c.Name)
+		}
+	}
+	return &cfg, nil
+}
+
+// ParseCompositeConfig reads a contributions file, in YAML or JSON, from r.
+func ParseCompositeConfig(r io.Reader) (*CompositeConfig, error) {
+	var cfg CompositeConfig
+	if err := parseConfig(r, ContributionsSchema, &cfg, &cfg.Schema); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func parseConfig(r io.Reader, schema string, cfg any, actualSchema *string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return fmt.Errorf("parse %s config: %v", schema, err)
+	}
+	if *actualSchema != schema {
+		return fmt.Errorf("invalid schema %q, expected %q", *actualSchema, schema)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package composite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// maxRemoteInputSize bounds the size of the inputs fetched over https.
+const maxRemoteInputSize = 64 << 20
+
+// readInput reads the input of a component, which is one of:
+//   - the path of a local file;
+//   - an https:// URL;
+//   - a file in a git repository, as git+<url>//<path>[?ref=<revision>], e.g.
+//     git+https://github.com/org/repo//catalog/foo.yaml?ref=main.
+//
+// If checksum is set, as a digest like sha256:<hex>, the content of the input
+// must match it.
+func readInput(ctx context.Context, cfg BuilderConfig, input, checksum string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(input, "git+"):
+		data, err = readGitInput(ctx, input)
+	case strings.HasPrefix(input, "https://"):
+		data, err = readHTTPSInput(ctx, cfg.HTTPClient, input)
+	case strings.HasPrefix(input, "http://"):
+		err = fmt.Errorf("insecure http inputs are not supported, use https")
+	default:
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read input %q: %v", input, err)
+	}
+	if checksum != "" {
+		if err := verifyChecksum(data, checksum); err != nil {
+			return nil, fmt.Errorf("read input %q: %v", input, err)
+		}
+	}
+	return data, nil
+}
+
+func verifyChecksum(data []byte, checksum string) error {
+	want, err := digest.Parse(checksum)
+	if err != nil {
+		return fmt.Errorf("invalid checksum %q: %v", checksum, err)
+	}
+	if got := want.Algorithm().FromBytes(data); got != want {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", want, got)
+	}
+	return nil
+}
+
+func readHTTPSInput(ctx context.Context, client *http.Client, input string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteInputSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRemoteInputSize {
+		return nil, fmt.Errorf("input is larger than %d bytes", maxRemoteInputSize)
+	}
+	return data, nil
+}
+
+// parseGitInput splits an input of the form git+<url>//<path>[?ref=<revision>]
+// into its repository URL, path and revision.
+func parseGitInput(input string) (string, string, string, error) {
+	u, err := url.Parse(strings.TrimPrefix(input, "git+"))
+	if err != nil {
+		return "", "", "", err
+	}
+	query := u.Query()
+	revision := query.Get("ref")
+	query.Del("ref")
+	if len(query) > 0 {
+		return "", "", "", fmt.Errorf("unsupported query parameters, expected only ref")
+	}
+	u.RawQuery = ""
+
+	repoPath, path, ok := strings.Cut(u.Path, "//")
+	if !ok || path == "" {
+		return "", "", "", fmt.Errorf("missing path of the input in the repository, expected git+<url>//<path>[?ref=<revision>]")
+	}
+	if !filepath.IsLocal(path) {
+		return "", "", "", fmt.Errorf("invalid path %q in the repository", path)
+	}
+	u.Path, u.RawPath = repoPath, ""
+	repo := u.String()
+	if strings.HasPrefix(repo, "-") || strings.HasPrefix(revision, "-") {
+		return "", "", "", fmt.Errorf("invalid repository or revision")
+	}
+	return repo, path, revision, nil
+}
+
+func readGitInput(ctx context.Context, input string) ([]byte, error) {
+	repo, path, revision, err := parseGitInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "composite-git-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"clone", "--quiet"}
+	if revision == "" {
+		args = append(args, "--depth", "1")
+	}
+	if err := runGit(ctx, "", append(args, "--", repo, dir)...); err != nil {
+		return nil, err
+	}
+	if revision != "" {
+		if err := runGit(ctx, dir, "checkout", "--quiet", revision, "--"); err != nil {
+			return nil, err
+		}
+	}
+
+	// The file must not be a symlink out of the repository.
+	file, err := filepath.EvalSymlinks(filepath.Join(dir, path))
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(root, file); err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("path %q is outside of the repository", path)
+	}
+	return os.ReadFile(file)
+}
+
+// runGit runs git with args in dir, or in the current directory if dir is
+// empty.
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
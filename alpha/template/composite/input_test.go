@@ -0,0 +1,171 @@
+package composite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadInput(t *testing.T) {
+	content := []byte(testFBC)
+	checksum := digest.FromBytes(content).String()
+
+	local := filepath.Join(t.TempDir(), "foo.yaml")
+	require.NoError(t, os.WriteFile(local, content, 0644))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/foo.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	cfg := BuilderConfig{HTTPClient: server.Client()}
+
+	tests := []struct {
+		name     string
+		input    string
+		checksum string
+		wantErr  string
+	}{
+		{
+			name:  "Local",
+			input: local,
+		},
+		{
+			name:     "LocalChecksum",
+			input:    local,
+			checksum: checksum,
+		},
+		{
+			name:     "LocalChecksumMismatch",
+			input:    local,
+			checksum: digest.FromString("other").String(),
+			wantErr:  "checksum mismatch",
+		},
+		{
+			name:     "InvalidChecksum",
+			input:    local,
+			checksum: "sha256:abc",
+			wantErr:  "invalid checksum",
+		},
+		{
+			name:     "HTTPS",
+			input:    server.URL + "/foo.yaml",
+			checksum: checksum,
+		},
+		{
+			name:    "HTTPSNotFound",
+			input:   server.URL + "/bar.yaml",
+			wantErr: "unexpected status 404 Not Found",
+		},
+		{
+			name:    "HTTP",
+			input:   "http://example.com/foo.yaml",
+			wantErr: "insecure http inputs are not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := readInput(context.Background(), cfg, tt.input, tt.checksum)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, content, data)
+		})
+	}
+}
+
+func TestReadGitInput(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "catalog"), 0755))
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "catalog", "foo.yaml"), []byte("v1"), 0644))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "catalog", "foo.yaml"), []byte("v2"), 0644))
+	require.NoError(t, os.Symlink("/etc/hostname", filepath.Join(repo, "catalog", "escape.yaml")))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "v2")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Head",
+			input: "git+file://" + repo + "//catalog/foo.yaml",
+			want:  "v2",
+		},
+		{
+			name:  "Ref",
+			input: "git+file://" + repo + "//catalog/foo.yaml?ref=v1",
+			want:  "v1",
+		},
+		{
+			name:    "MissingPath",
+			input:   "git+file://" + repo,
+			wantErr: "missing path of the input in the repository",
+		},
+		{
+			name:    "PathOutsideRepository",
+			input:   "git+file://" + repo + "//../foo.yaml",
+			wantErr: "invalid path",
+		},
+		{
+			name:    "SymlinkOutsideRepository",
+			input:   "git+file://" + repo + "//catalog/escape.yaml",
+			wantErr: "outside of the repository",
+		},
+		{
+			name:    "UnknownRef",
+			input:   "git+file://" + repo + "//catalog/foo.yaml?ref=v3",
+			wantErr: "git checkout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := readInput(context.Background(), BuilderConfig{}, tt.input, "")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestParseGitInput(t *testing.T) {
+	repo, path, revision, err := parseGitInput("git+https://github.com/org/repo//catalogs/foo/template.yaml?ref=main")
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/org/repo", repo)
+	require.Equal(t, "catalogs/foo/template.yaml", path)
+	require.Equal(t, "main", revision)
+
+	_, _, _, err = parseGitInput("git+https://github.com/org/repo//template.yaml?ref=main&depth=1")
+	require.ErrorContains(t, err, "unsupported query parameters")
+}
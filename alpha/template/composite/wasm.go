@@ -0,0 +1,144 @@
+package composite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// WasmBuilderSchema is the schema of the builder that runs WASM modules.
+const WasmBuilderSchema = "olm.builder.wasm"
+
+// wasmMemoryLimitPages bounds the memory of WASM modules, in 64KiB pages, to
+// 1GiB.
+const wasmMemoryLimitPages = 16384
+
+// WasmConfig is the configuration of the WASM builder.
+type WasmConfig struct {
+	// Module is the path of the WASM module to run.
+	Module string `json:"module"`
+	// Input is the file that the module reads from its standard input, e.g.
+	// a template or a file-based catalog, see InputConfig.Input. If it is
+	// not set, the standard input of the module is empty.
+	Input string `json:"input,omitempty"`
+	// Checksum is the digest that the content of Input must match, see
+	// InputConfig.Checksum.
+	Checksum string `json:"checksum,omitempty"`
+	// Args are the arguments of the module.
+	Args []string `json:"args,omitempty"`
+	// Output is the name of the file to write in the directory of the
+	// component.
+	Output string `json:"output"`
+}
+
+// WasmBuilder runs a WASM module that implements a template, as a safer
+// alternative to the custom builder in environments that forbid running
+// arbitrary commands.
+//
+// The module is a WASI (preview 1) command, whose _start function is run
+// with:
+//   - the base name of the module file followed by Args as its arguments;
+//   - the content of Input as its standard input;
+//   - no environment variables, no access to the filesystem or the network,
+//     a deterministic clock and random source, and at most 1GiB of memory.
+//
+// The module writes a file-based catalog, in JSON or YAML, to its standard
+// output, and exits with a non-zero code if it fails, with an error message
+// on its standard error. As the module cannot pull images, bundles that only
+// have a schema and an image in its output are rendered, like in basic
+// templates.
+type WasmBuilder struct {
+	cfg BuilderConfig
+}
+
+func (b *WasmBuilder) Build(ctx context.Context, dir string, td TemplateDefinition) error {
+	var c WasmConfig
+	if err := parseBuilderConfig(td, WasmBuilderSchema, &c); err != nil {
+		return err
+	}
+	if c.Module == "" {
+		return fmt.Errorf("%s: module must be set", WasmBuilderSchema)
+	}
+	if c.Output == "" {
+		return fmt.Errorf("%s: output must be set", WasmBuilderSchema)
+	}
+	module, err := os.ReadFile(c.Module)
+	if err != nil {
+		return fmt.Errorf("read module: %v", err)
+	}
+	var input []byte
+	if c.Input != "" {
+		if input, err = readInput(ctx, b.cfg, c.Input, c.Checksum); err != nil {
+			return err
+		}
+	}
+
+	out, err := runWasm(ctx, module, append([]string{filepath.Base(c.Module)}, c.Args...), input)
+	if err != nil {
+		return fmt.Errorf("run module %q: %v", c.Module, err)
+	}
+	fbc, err := declcfg.LoadReader(bytes.NewReader(out))
+	if err != nil {
+		return fmt.Errorf("run module %q: invalid output: %v", c.Module, err)
+	}
+	if err := renderBundleImages(ctx, fbc, b.cfg.RenderBundle); err != nil {
+		return err
+	}
+	return writeOutput(b.cfg, dir, c.Output, *fbc)
+}
+
+func (b *WasmBuilder) Validate(ctx context.Context, dir string) error {
+	return validate(ctx, b.cfg, dir)
+}
+
+// runWasm runs the WASI command module with args and stdin, and returns its
+// standard output. The module is stopped when ctx is done.
+func runWasm(ctx context.Context, module []byte, args []string, stdin []byte) ([]byte, error) {
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(wasmMemoryLimitPages))
+	defer rt.Close(ctx)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		return nil, err
+	}
+	compiled, err := rt.CompileModule(ctx, module)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cfg := wazero.NewModuleConfig().
+		WithArgs(args...).
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+	mod, err := rt.InstantiateModule(ctx, compiled, cfg)
+	if mod != nil {
+		defer mod.Close(ctx)
+	}
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 0 {
+		err = nil
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
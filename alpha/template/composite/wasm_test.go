@@ -0,0 +1,97 @@
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// The modules in testdata are hand-assembled WASI commands: echo.wasm copies
+// its standard input to its standard output, and fail.wasm writes "template
+// failed" to its standard error and exits with code 3.
+
+func TestWasmBuilder(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.yaml")
+	require.NoError(t, os.WriteFile(input, []byte(testFBC+`---
+schema: olm.bundle
+image: quay.io/foo/foo-bundle:v0.2.0
+`), 0644))
+
+	renderBundle := func(_ context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+		return &declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{{
+			Schema:  declcfg.SchemaBundle,
+			Package: "foo",
+			Name:    "foo.v0.2.0",
+			Image:   image,
+		}}}, nil
+	}
+
+	config := func(module string) TemplateDefinition {
+		cfg, err := json.Marshal(WasmConfig{
+			Module: filepath.Join("testdata", module),
+			Input:  input,
+			Output: "catalog.yaml",
+		})
+		require.NoError(t, err)
+		return TemplateDefinition{Schema: WasmBuilderSchema, Config: cfg}
+	}
+
+	tests := []struct {
+		name    string
+		td      TemplateDefinition
+		wantErr string
+	}{
+		{
+			name: "Echo",
+			td:   config("echo.wasm"),
+		},
+		{
+			name:    "Fail",
+			td:      config("fail.wasm"),
+			wantErr: "template failed",
+		},
+		{
+			name:    "MissingModule",
+			td:      TemplateDefinition{Schema: WasmBuilderSchema, Config: json.RawMessage(`{"output":"catalog.yaml"}`)},
+			wantErr: "module must be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workingDir := t.TempDir()
+			b, err := NewBuilder(WasmBuilderSchema, BuilderConfig{WorkingDir: workingDir, OutputType: "yaml", RenderBundle: renderBundle})
+			require.NoError(t, err)
+
+			err = b.Build(context.Background(), "foo", tt.td)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			fbc, err := declcfg.LoadFS(context.Background(), os.DirFS(filepath.Join(workingDir, "foo")))
+			require.NoError(t, err)
+			var names []string
+			for _, b := range fbc.Bundles {
+				names = append(names, b.Name)
+			}
+			require.ElementsMatch(t, []string{"foo.v0.1.0", "foo.v0.2.0"}, names)
+		})
+	}
+}
+
+func TestRunWasmContextCanceled(t *testing.T) {
+	module, err := os.ReadFile(filepath.Join("testdata", "echo.wasm"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = runWasm(ctx, module, []string{"echo.wasm"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/renderer"
+)
+
+// PluginPrefix is the prefix of the names of the executables of template
+// plugins, which is followed by the schema of their template files.
+const PluginPrefix = "opm-template-"
+
+// SchemaEnv is the environment variable that holds the schema of the
+// template file for template plugins.
+const SchemaEnv = "OPM_TEMPLATE_SCHEMA"
+
+// ExecTemplate is a template implemented by a plugin executable.
+//
+// The plugin is run with a single argument, "parse" or "render", and with the
+// template file on its standard input. It must exit with a non-zero status if
+// the template is invalid or cannot be rendered, and may explain why on its
+// standard error. For "render", it writes the rendered file-based catalog to
+// its standard output, in JSON or YAML. Like in the basic template, bundles
+// of that catalog that only have a schema and an image are bundle images to
+// render, and are replaced with the bundles rendered from their image.
+type ExecTemplate struct {
+	// SchemaName is the schema of the template files of the plugin.
+	SchemaName string
+
+	// Path is the path of the plugin executable.
+	Path string
+}
+
+func (t ExecTemplate) Schema() string {
+	return t.SchemaName
+}
+
+func (t ExecTemplate) Parse(ctx context.Context, r io.Reader) error {
+	_, err := t.run(ctx, "parse", r)
+	return err
+}
+
+func (t ExecTemplate) Render(ctx context.Context, r io.Reader, resolver RegistryResolver) (*declcfg.DeclarativeConfig, error) {
+	out, err := t.run(ctx, "render", r)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := declcfg.LoadReader(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("template plugin %q: invalid output: %v", t.Path, err)
+	}
+
+	outb := cfg.Bundles[:0]
+	failed := 0
+	for _, b := range cfg.Bundles {
+		if !isBundleImage(b) {
+			outb = append(outb, b)
+			continue
+		}
+		contributor, err := resolver.RenderBundle(ctx, b.Image)
+		if err != nil {
+			return nil, err
+		}
+		if renderer.IsPlaceholder(contributor) {
+			failed++
+			continue
+		}
+		outb = append(outb, contributor.Bundles...)
+	}
+	cfg.Bundles = outb
+	if err := renderer.RemoveUnrendered(cfg, failed); err != nil {
+		return nil, fmt.Errorf("template plugin %q: %v", t.Path, err)
+	}
+	return cfg, nil
+}
+
+// run runs the plugin with the argument arg and the standard input r, and
+// returns its standard output.
+func (t ExecTemplate) run(ctx context.Context, arg string, r io.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.Path, arg)
+	cmd.Env = append(os.Environ(), SchemaEnv+"="+t.SchemaName)
+	cmd.Stdin = r
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("template plugin %q %s: %v: %s", t.Path, arg, err, msg)
+		}
+		return nil, fmt.Errorf("template plugin %q %s: %v", t.Path, arg, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// isBundleImage reports whether b only has a schema and an image, and is thus
+// a bundle image to render.
+func isBundleImage(b declcfg.Bundle) bool {
+	return b.Image != "" && b.Name == "" && b.Package == "" && len(b.Properties) == 0 && len(b.RelatedImages) == 0
+}
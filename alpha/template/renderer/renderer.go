@@ -0,0 +1,225 @@
+// Package renderer provides a bundle rendering wrapper for catalog templates
+// that adds per-image timeouts, retries, and a continue-on-error mode.
+package renderer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+const defaultRetryBackoff = time.Second
+
+// RenderBundleFunc renders a single bundle reference.
+type RenderBundleFunc func(context.Context, string) (*declcfg.DeclarativeConfig, error)
+
+// Failure records a bundle reference that could not be rendered.
+type Failure struct {
+	Image string `json:"image"`
+	Error string `json:"error"`
+}
+
+type Options struct {
+	// Timeout bounds each attempt to render a single bundle. Zero means no timeout.
+	Timeout time.Duration
+	// Retries is the number of additional attempts made after a failed render.
+	Retries int
+	// ContinueOnError causes a bundle that cannot be rendered to be recorded
+	// as a failure and left out of the template output, rather than failing
+	// the whole template.
+	ContinueOnError bool
+}
+
+type Option func(*Options)
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+func WithRetries(retries int) Option {
+	return func(o *Options) {
+		o.Retries = retries
+	}
+}
+
+func WithContinueOnError(continueOnError bool) Option {
+	return func(o *Options) {
+		o.ContinueOnError = continueOnError
+	}
+}
+
+// BundleRenderer wraps a RenderBundleFunc and records failed references.
+// It is safe for concurrent use.
+type BundleRenderer struct {
+	render  RenderBundleFunc
+	opts    Options
+	backoff time.Duration
+
+	mu       sync.Mutex
+	failures []Failure
+}
+
+func New(render RenderBundleFunc, opts ...Option) *BundleRenderer {
+	r := &BundleRenderer{
+		render:  render,
+		backoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+	return r
+}
+
+// RenderBundle renders ref, retrying failed attempts as configured. If every
+// attempt fails and ContinueOnError is set, the failure is recorded and a
+// placeholder config is returned in place of the bundle. Templates must not
+// write placeholders to their output: they leave the bundle out, along with
+// the channel entries that reference it, see IsPlaceholder and
+// RemoveUnrendered.
+func (r *BundleRenderer) RenderBundle(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	var err error
+	for attempt := 0; attempt <= r.opts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(r.backoff * time.Duration(attempt)):
+			}
+		}
+		var cfg *declcfg.DeclarativeConfig
+		cfg, err = r.renderOnce(ctx, ref)
+		if err == nil {
+			return cfg, nil
+		}
+	}
+	if !r.opts.ContinueOnError {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.failures = append(r.failures, Failure{Image: ref, Error: err.Error()})
+	r.mu.Unlock()
+	return newPlaceholder(ref)
+}
+
+func (r *BundleRenderer) renderOnce(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+	if r.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+	return r.render(ctx, ref)
+}
+
+// Failures returns the references that could not be rendered.
+func (r *BundleRenderer) Failures() []Failure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Failure{}, r.failures...)
+}
+
+// WriteFailures writes failures to w as a JSON array.
+func WriteFailures(failures []Failure, w io.Writer) error {
+	if failures == nil {
+		failures = []Failure{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(failures)
+}
+
+func newPlaceholder(ref string) (*declcfg.DeclarativeConfig, error) {
+	blob, err := json.Marshal(declcfg.Bundle{Schema: declcfg.SchemaBundle, Image: ref})
+	if err != nil {
+		return nil, fmt.Errorf("create placeholder for %q: %v", ref, err)
+	}
+	return &declcfg.DeclarativeConfig{
+		Others: []declcfg.Meta{{Schema: declcfg.SchemaBundle, Blob: blob}},
+	}, nil
+}
+
+// IsPlaceholder reports whether cfg is a placeholder returned in place of a
+// bundle that could not be rendered.
+func IsPlaceholder(cfg *declcfg.DeclarativeConfig) bool {
+	return cfg != nil && len(cfg.Bundles) == 0 && len(cfg.Others) == 1 &&
+		cfg.Others[0].Schema == declcfg.SchemaBundle && cfg.Others[0].Package == ""
+}
+
+// RemoveUnrendered removes the channel entries and bundle deprecations of cfg
+// that reference bundles that are not in cfg, after failed bundle images
+// could not be rendered and were left out of cfg, so that cfg is a valid
+// catalog without them. Entries that replace a removed entry replace the entry
+// that it replaced instead.
+//
+// The names of the bundles of the failed images are unknown, so at most
+// failed bundles may be missing from cfg: if more are missing, some entries
+// reference bundles that the template does not define, which is an error. If
+// failed is zero, cfg is left unchanged.
+func RemoveUnrendered(cfg *declcfg.DeclarativeConfig, failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	type key struct{ pkg, name string }
+	bundles := map[key]bool{}
+	for _, b := range cfg.Bundles {
+		bundles[key{b.Package, b.Name}] = true
+	}
+
+	missing := map[key]bool{}
+	for i := range cfg.Channels {
+		ch := &cfg.Channels[i]
+		// Entries that replace a dropped entry replace what it replaced
+		// instead, so that the upgrade graph of the channel stays connected.
+		replaces := map[string]string{}
+		entries := ch.Entries[:0]
+		for _, e := range ch.Entries {
+			if !bundles[key{ch.Package, e.Name}] {
+				missing[key{ch.Package, e.Name}] = true
+				replaces[e.Name] = e.Replaces
+				continue
+			}
+			entries = append(entries, e)
+		}
+		for j := range entries {
+			for seen := map[string]bool{}; !seen[entries[j].Replaces]; {
+				r, ok := replaces[entries[j].Replaces]
+				if !ok {
+					break
+				}
+				seen[entries[j].Replaces] = true
+				entries[j].Replaces = r
+			}
+		}
+		ch.Entries = entries
+	}
+	if len(missing) > failed {
+		var names []string
+		for k := range missing {
+			names = append(names, fmt.Sprintf("%s/%s", k.pkg, k.name))
+		}
+		sort.Strings(names)
+		return fmt.Errorf("channel entries reference %d bundles that are not defined, but only %d bundle images failed to render: %s", len(missing), failed, strings.Join(names, ", "))
+	}
+
+	for i := range cfg.Deprecations {
+		d := &cfg.Deprecations[i]
+		entries := d.Entries[:0]
+		for _, e := range d.Entries {
+			if e.Reference.Schema == declcfg.SchemaBundle && missing[key{d.Package, e.Reference.Name}] {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		d.Entries = entries
+	}
+	return nil
+}
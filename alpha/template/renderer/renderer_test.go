@@ -0,0 +1,158 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestBundleRenderer(t *testing.T) {
+	bundle := &declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{{Name: "foo.v1.0.0", Image: "foo:v1.0.0"}}}
+
+	// failingRender fails the first failures calls, and succeeds afterwards.
+	failingRender := func(failures int) (RenderBundleFunc, *int) {
+		calls := 0
+		return func(context.Context, string) (*declcfg.DeclarativeConfig, error) {
+			calls++
+			if calls <= failures {
+				return nil, errors.New("unavailable")
+			}
+			return bundle, nil
+		}, &calls
+	}
+
+	type spec struct {
+		name              string
+		failures          int
+		opts              []Option
+		assertion         require.ErrorAssertionFunc
+		expectCalls       int
+		expectPlaceholder bool
+		expectFailures    []Failure
+	}
+	specs := []spec{
+		{
+			name:        "Success",
+			assertion:   require.NoError,
+			expectCalls: 1,
+		},
+		{
+			name:      "FailNoRetry",
+			failures:  1,
+			assertion: require.Error,
+			// a single attempt is made
+			expectCalls: 1,
+		},
+		{
+			name:        "SucceedAfterRetry",
+			failures:    2,
+			opts:        []Option{WithRetries(2)},
+			assertion:   require.NoError,
+			expectCalls: 3,
+		},
+		{
+			name:              "ContinueOnError",
+			failures:          3,
+			opts:              []Option{WithRetries(1), WithContinueOnError(true)},
+			assertion:         require.NoError,
+			expectCalls:       2,
+			expectPlaceholder: true,
+			expectFailures:    []Failure{{Image: "foo:v1.0.0", Error: "unavailable"}},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			render, calls := failingRender(s.failures)
+			r := New(render, s.opts...)
+			r.backoff = time.Millisecond
+
+			cfg, err := r.RenderBundle(context.Background(), "foo:v1.0.0")
+			s.assertion(t, err)
+			require.Equal(t, s.expectCalls, *calls)
+			if err != nil {
+				return
+			}
+			require.Equal(t, s.expectPlaceholder, IsPlaceholder(cfg))
+			require.Equal(t, s.expectFailures, failuresOrNil(r.Failures()))
+		})
+	}
+}
+
+func TestBundleRendererTimeout(t *testing.T) {
+	r := New(func(ctx context.Context, _ string) (*declcfg.DeclarativeConfig, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithTimeout(10*time.Millisecond), WithContinueOnError(true))
+
+	cfg, err := r.RenderBundle(context.Background(), "foo:v1.0.0")
+	require.NoError(t, err)
+	require.True(t, IsPlaceholder(cfg))
+	require.Len(t, r.Failures(), 1)
+	require.Equal(t, context.DeadlineExceeded.Error(), r.Failures()[0].Error)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFailures(r.Failures(), &buf))
+	require.JSONEq(t, `[{"image":"foo:v1.0.0","error":"context deadline exceeded"}]`, buf.String())
+}
+
+func failuresOrNil(f []Failure) []Failure {
+	if len(f) == 0 {
+		return nil
+	}
+	return f
+}
+
+func TestRemoveUnrendered(t *testing.T) {
+	newConfig := func() *declcfg.DeclarativeConfig {
+		return &declcfg.DeclarativeConfig{
+			Channels: []declcfg.Channel{{
+				Package: "foo",
+				Name:    "stable",
+				Entries: []declcfg.ChannelEntry{
+					{Name: "foo.v1.0.0"},
+					{Name: "foo.v1.0.1", Replaces: "foo.v1.0.0"},
+					{Name: "foo.v1.0.2", Replaces: "foo.v1.0.1"},
+				},
+			}},
+			Bundles: []declcfg.Bundle{
+				{Package: "foo", Name: "foo.v1.0.0"},
+				{Package: "foo", Name: "foo.v1.0.2"},
+			},
+			Deprecations: []declcfg.Deprecation{{
+				Package: "foo",
+				Entries: []declcfg.DeprecationEntry{
+					{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "foo.v1.0.0"}},
+					{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "foo.v1.0.1"}},
+				},
+			}},
+		}
+	}
+
+	t.Run("NoFailures", func(t *testing.T) {
+		cfg := newConfig()
+		require.NoError(t, RemoveUnrendered(cfg, 0))
+		require.Equal(t, newConfig(), cfg)
+	})
+	t.Run("Failures", func(t *testing.T) {
+		cfg := newConfig()
+		require.NoError(t, RemoveUnrendered(cfg, 1))
+		require.Equal(t, []declcfg.ChannelEntry{
+			{Name: "foo.v1.0.0"},
+			{Name: "foo.v1.0.2", Replaces: "foo.v1.0.0"},
+		}, cfg.Channels[0].Entries)
+		require.Equal(t, []declcfg.DeprecationEntry{
+			{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "foo.v1.0.0"}},
+		}, cfg.Deprecations[0].Entries)
+	})
+	t.Run("UndefinedBundles", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Bundles = cfg.Bundles[:1]
+		require.EqualError(t, RemoveUnrendered(cfg, 1), "channel entries reference 2 bundles that are not defined, but only 1 bundle images failed to render: foo/foo.v1.0.1, foo/foo.v1.0.2")
+	})
+}
@@ -1,17 +1,23 @@
 package semver
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/blang/semver/v4"
 	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/yaml"
 
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/alpha/template/renderer"
 )
 
 func (t Template) Render(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
@@ -30,6 +36,15 @@ func (t Template) Render(ctx context.Context) (*declcfg.DeclarativeConfig, error
 		if err != nil {
 			return nil, err
 		}
+		if renderer.IsPlaceholder(c) {
+			// the bundle could not be rendered; leave it out of the output
+			// and of the generated channels.
+			if sv.placeholders == nil {
+				sv.placeholders = map[string]bool{}
+			}
+			sv.placeholders[b] = true
+			continue
+		}
 		if len(c.Bundles) != 1 {
 			return nil, fmt.Errorf("bundle reference %q resulted in %d bundles, expected 1", b, len(c.Bundles))
 		}
@@ -47,13 +62,81 @@ func (t Template) Render(ctx context.Context) (*declcfg.DeclarativeConfig, error
 		return nil, fmt.Errorf("render: unable to post-process bundle info: %v", err)
 	}
 
-	channels := sv.generateChannels(channelBundleVersions)
+	if len(out.Packages) == 0 {
+		return nil, fmt.Errorf("render: no bundles could be rendered")
+	}
+
+	channels, err := sv.generateChannels(channelBundleVersions)
+	if err != nil {
+		return nil, fmt.Errorf("render: %v", err)
+	}
 	out.Channels = channels
 	out.Packages[0].DefaultChannel = sv.defaultChannel
 
+	deprecations, err := sv.generateDeprecations(&out, bundleDict)
+	if err != nil {
+		return nil, fmt.Errorf("render: %v", err)
+	}
+	out.Deprecations = append(out.Deprecations, deprecations...)
+
 	return &out, nil
 }
 
+// generateDeprecations returns the olm.deprecations object for the channels
+// and bundles marked deprecated in the template, if any. Channels must be
+// generated by the template, and bundles must be listed in its channels.
+// Bundles that could not be rendered are ignored.
+func (sv *semverTemplate) generateDeprecations(cfg *declcfg.DeclarativeConfig, bundleDict map[string]string) ([]declcfg.Deprecation, error) {
+	var entries []declcfg.DeprecationEntry
+
+	channels := make(map[string]bool, len(cfg.Channels))
+	for _, ch := range cfg.Channels {
+		channels[ch.Name] = true
+	}
+	for _, d := range sv.Deprecated.Channels {
+		if !channels[d.Name] {
+			return nil, fmt.Errorf("deprecated channel %q is not generated by the template", d.Name)
+		}
+		if d.Message == "" {
+			return nil, fmt.Errorf("deprecated channel %q has no message", d.Name)
+		}
+		entries = append(entries, declcfg.DeprecationEntry{
+			Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: d.Name},
+			Message:   d.Message,
+		})
+	}
+
+	bundleNames := make(map[string]string, len(cfg.Bundles))
+	for _, b := range cfg.Bundles {
+		bundleNames[b.Image] = b.Name
+	}
+	for _, d := range sv.Deprecated.Bundles {
+		image, ok := bundleDict[d.Image]
+		if !ok {
+			return nil, fmt.Errorf("deprecated bundle %q is not in any channel of the template", d.Image)
+		}
+		if d.Message == "" {
+			return nil, fmt.Errorf("deprecated bundle %q has no message", d.Image)
+		}
+		if sv.placeholders[d.Image] {
+			continue
+		}
+		entries = append(entries, declcfg.DeprecationEntry{
+			Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: bundleNames[image]},
+			Message:   d.Message,
+		})
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return []declcfg.Deprecation{{
+		Schema:  declcfg.SchemaDeprecation,
+		Package: sv.pkg,
+		Entries: entries,
+	}}, nil
+}
+
 func buildBundleList(t semverTemplate) map[string]string {
 	dict := make(map[string]string)
 	for _, bl := range []semverTemplateChannelBundles{t.Candidate, t.Fast, t.Stable} {
@@ -66,6 +149,13 @@ func buildBundleList(t semverTemplate) map[string]string {
 	return dict
 }
 
+// Validate reads the template of reader, and checks that it is valid,
+// without rendering it.
+func Validate(reader io.Reader) error {
+	_, err := readFile(reader)
+	return err
+}
+
 func readFile(reader io.Reader) (*semverTemplate, error) {
 	data, err := io.ReadAll(reader)
 	if err != nil {
@@ -108,6 +198,23 @@ func readFile(reader io.Reader) (*semverTemplate, error) {
 		return nil, fmt.Errorf("unknown DefaultChannelTypePreference: %q\nValid values are 'major' or 'minor'", sv.DefaultChannelTypePreference)
 	}
 
+	switch sv.BuildMetadataOrdering {
+	case "", rejectBuildMetadataOrdering, compareBuildMetadataOrdering:
+	default:
+		return nil, fmt.Errorf("unknown BuildMetadataOrdering: %q\nValid values are 'reject' or 'compare'", sv.BuildMetadataOrdering)
+	}
+
+	if sv.ChannelNames.Major != "" {
+		if sv.majorChannelName, err = parseChannelName("major", sv.ChannelNames.Major); err != nil {
+			return nil, err
+		}
+	}
+	if sv.ChannelNames.Minor != "" {
+		if sv.minorChannelName, err = parseChannelName("minor", sv.ChannelNames.Minor); err != nil {
+			return nil, err
+		}
+	}
+
 	return &sv, nil
 }
 
@@ -118,7 +225,7 @@ func (sv *semverTemplate) getVersionsFromStandardChannels(cfg *declcfg.Declarati
 	if err != nil {
 		return nil, err
 	}
-	if err = validateVersions(&bdm); err != nil {
+	if err = validateVersions(&bdm, sv.BuildMetadataOrdering); err != nil {
 		return nil, err
 	}
 	versions[candidateChannelArchetype] = bdm
@@ -127,7 +234,7 @@ func (sv *semverTemplate) getVersionsFromStandardChannels(cfg *declcfg.Declarati
 	if err != nil {
 		return nil, err
 	}
-	if err = validateVersions(&bdm); err != nil {
+	if err = validateVersions(&bdm, sv.BuildMetadataOrdering); err != nil {
 		return nil, err
 	}
 	versions[fastChannelArchetype] = bdm
@@ -136,7 +243,7 @@ func (sv *semverTemplate) getVersionsFromStandardChannels(cfg *declcfg.Declarati
 	if err != nil {
 		return nil, err
 	}
-	if err = validateVersions(&bdm); err != nil {
+	if err = validateVersions(&bdm, sv.BuildMetadataOrdering); err != nil {
 		return nil, err
 	}
 	versions[stableChannelArchetype] = bdm
@@ -152,6 +259,9 @@ func (sv *semverTemplate) getVersionsFromChannel(semverBundles []semverTemplateB
 	// - maintain the channel-bundle relationship as we map from un-rendered semver template bundles to rendered bundles in `entries` which is accumulated by the caller
 	//   in a per-channel structure to which we can safely refer when generating/linking channels
 	for _, semverBundle := range semverBundles {
+		if sv.placeholders[semverBundle.Image] {
+			continue
+		}
 		// test if the bundle specified in the template is present in the successfully-rendered bundles
 		index := 0
 		for index < len(cfg.Bundles) {
@@ -206,7 +316,7 @@ func (sv *semverTemplate) getVersionsFromChannel(semverBundles []semverTemplateB
 // - within the same minor version (Y-stream), the head of the channel should have a 'skips' encompassing all lesser Y.Z versions of the bundle enumerated in the template.
 // along the way, uses a highwaterChannel marker to identify the "most stable" channel head to be used as the default channel for the generated package
 
-func (sv *semverTemplate) generateChannels(semverChannels *bundleVersions) []declcfg.Channel {
+func (sv *semverTemplate) generateChannels(semverChannels *bundleVersions) ([]declcfg.Channel, error) {
 	outChannels := []declcfg.Channel{}
 
 	// sort the channel archetypes in ascending order so we can traverse the bundles in order of
@@ -221,6 +331,9 @@ func (sv *semverTemplate) generateChannels(semverChannels *bundleVersions) []dec
 	hwc := highwaterChannel{archetype: archetypesByPriority[0], version: semver.Version{Major: 0, Minor: 0}}
 
 	unlinkedChannels := make(map[string]*declcfg.Channel)
+	// channel name --> the stream and version it was generated for, so that
+	// name templates which map different channels to the same name are caught
+	channelSources := make(map[string]string)
 
 	for _, archetype := range archetypesByPriority {
 		bundles := (*semverChannels)[archetype]
@@ -235,7 +348,7 @@ func (sv *semverTemplate) generateChannels(semverChannels *bundleVersions) []dec
 			bundleNamesByVersion = append(bundleNamesByVersion, b)
 		}
 		sort.Slice(bundleNamesByVersion, func(i, j int) bool {
-			return bundles[bundleNamesByVersion[i]].LT(bundles[bundleNamesByVersion[j]])
+			return sv.versionLess(bundles[bundleNamesByVersion[i]], bundles[bundleNamesByVersion[j]])
 		})
 
 		// for each bundle (by version):
@@ -245,25 +358,51 @@ func (sv *semverTemplate) generateChannels(semverChannels *bundleVersions) []dec
 		//     save the channel name --> channel archetype mapping
 		//     test the channel object for 'more stable' than previous best
 		for _, bundleName := range bundleNamesByVersion {
+			// pre-release bundles are split out of the archetype's channels
+			// into channels of their pre-release tag, if so configured
+			stream, prerelease := string(archetype), ""
+			if sv.GeneratePrereleaseChannels && len(bundles[bundleName].Pre) > 0 {
+				tag, err := prereleaseTag(bundles[bundleName])
+				if err != nil {
+					return nil, fmt.Errorf("bundle %q: %v", bundleName, err)
+				}
+				stream, prerelease = tag, fmt.Sprintf("%s pre-release of ", tag)
+			}
+
 			// a dodge to avoid duplicating channel processing body; accumulate a map of the channels which need creating from the bundle
 			// we need to associate by kind so we can partition the resulting entries
 			channelNameKeys := make(map[streamType]string)
 			if sv.GenerateMajorChannels {
-				channelNameKeys[majorStreamType] = channelNameFromMajor(archetype, bundles[bundleName])
+				cName, err := sv.channelName(majorStreamType, stream, bundles[bundleName])
+				if err != nil {
+					return nil, err
+				}
+				channelNameKeys[majorStreamType] = cName
 			}
 			if sv.GenerateMinorChannels {
-				channelNameKeys[minorStreamType] = channelNameFromMinor(archetype, bundles[bundleName])
+				cName, err := sv.channelName(minorStreamType, stream, bundles[bundleName])
+				if err != nil {
+					return nil, err
+				}
+				channelNameKeys[minorStreamType] = cName
 			}
 
 			for cKey, cName := range channelNameKeys {
+				source := prerelease + channelSource(cKey, archetype, bundles[bundleName])
+				if prev, ok := channelSources[cName]; ok && prev != source {
+					return nil, fmt.Errorf("channel name %q is generated for both %s and %s channels, the channel name templates must produce unique names", cName, prev, source)
+				}
+				channelSources[cName] = source
+
 				ch, ok := unlinkedChannels[cName]
 				if !ok {
 					ch = newChannel(sv.pkg, cName)
 
 					unlinkedChannels[cName] = ch
 
+					// pre-release channels are never the default channel
 					hwcCandidate := highwaterChannel{archetype: archetype, kind: cKey, version: bundles[bundleName], name: cName}
-					if hwcCandidate.gt(&hwc, sv.DefaultChannelTypePreference) {
+					if prerelease == "" && hwcCandidate.gt(&hwc, sv.DefaultChannelTypePreference) {
 						hwc = hwcCandidate
 					}
 				}
@@ -277,7 +416,7 @@ func (sv *semverTemplate) generateChannels(semverChannels *bundleVersions) []dec
 
 	outChannels = append(outChannels, sv.linkChannels(unlinkedChannels, semverChannels)...)
 
-	return outChannels
+	return outChannels, nil
 }
 
 func (sv *semverTemplate) linkChannels(unlinkedChannels map[string]*declcfg.Channel, harvestedVersions *bundleVersions) []declcfg.Channel {
@@ -296,7 +435,7 @@ func (sv *semverTemplate) linkChannels(unlinkedChannels map[string]*declcfg.Chan
 	for _, channel := range unlinkedChannels {
 		entries := &channel.Entries
 		sort.Slice(*entries, func(i, j int) bool {
-			return bundleVersions[(*entries)[i].Name].LT(bundleVersions[(*entries)[j].Name])
+			return sv.versionLess(bundleVersions[(*entries)[i].Name], bundleVersions[(*entries)[j].Name])
 		})
 
 		// "inchworm" through the sorted entries, iterating curEdge but extending yProbe to the next Y-transition
@@ -351,12 +490,66 @@ func (sv *semverTemplate) linkChannels(unlinkedChannels map[string]*declcfg.Chan
 	return channels
 }
 
-func channelNameFromMinor(prefix channelArchetype, version semver.Version) string {
-	return fmt.Sprintf("%s-v%d.%d", prefix, version.Major, version.Minor)
+var (
+	defaultMajorChannelNameTemplate = template.Must(template.New("major").Option("missingkey=error").Parse(defaultMajorChannelName))
+	defaultMinorChannelNameTemplate = template.Must(template.New("minor").Option("missingkey=error").Parse(defaultMinorChannelName))
+)
+
+// parseChannelName parses a channel name template and checks that it can be
+// executed and produces a non-empty name.
+func parseChannelName(kind string, text string) (*template.Template, error) {
+	tmpl, err := template.New(kind).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s channel name template %q: %v", kind, text, err)
+	}
+	if _, err := executeChannelName(tmpl, channelNameData{Stream: string(stableChannelArchetype), Major: 1, Minor: 2}); err != nil {
+		return nil, fmt.Errorf("invalid %s channel name template %q: %v", kind, text, err)
+	}
+	return tmpl, nil
+}
+
+func executeChannelName(tmpl *template.Template, data channelNameData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(sb.String())
+	if name == "" {
+		return "", fmt.Errorf("channel name is empty")
+	}
+	return name, nil
 }
 
-func channelNameFromMajor(prefix channelArchetype, version semver.Version) string {
-	return fmt.Sprintf("%s-v%d", prefix, version.Major)
+// channelName returns the name of the channel of the given kind that version
+// belongs to in the stream, i.e. a channel archetype or a pre-release tag,
+// using the template's channel name templates, or the default "<stream>-vX"
+// and "<stream>-vX.Y" names.
+func (sv *semverTemplate) channelName(kind streamType, stream string, version semver.Version) (string, error) {
+	data := channelNameData{Stream: stream, Major: version.Major}
+	tmpl := sv.majorChannelName
+	if tmpl == nil {
+		tmpl = defaultMajorChannelNameTemplate
+	}
+	if kind == minorStreamType {
+		data.Minor = version.Minor
+		tmpl = sv.minorChannelName
+		if tmpl == nil {
+			tmpl = defaultMinorChannelNameTemplate
+		}
+	}
+	name, err := executeChannelName(tmpl, data)
+	if err != nil {
+		return "", fmt.Errorf("generate %s channel name for %s version %s: %v", kind, stream, version, err)
+	}
+	return name, nil
+}
+
+// channelSource describes the channel a name is generated for, e.g. "stable minor 1.2".
+func channelSource(kind streamType, archetype channelArchetype, version semver.Version) string {
+	if kind == minorStreamType {
+		return fmt.Sprintf("%s %s %d.%d", archetype, kind, version.Major, version.Minor)
+	}
+	return fmt.Sprintf("%s %s %d", archetype, kind, version.Major)
 }
 
 func newPackage(name string) *declcfg.Package {
@@ -397,6 +590,49 @@ func getMajorVersion(v semver.Version) semver.Version {
 	}
 }
 
+// prereleaseTag returns the tag of a pre-release version, i.e. its first
+// pre-release identifier, e.g. "beta" for 1.2.0-beta.1.
+func prereleaseTag(v semver.Version) (string, error) {
+	if v.Pre[0].IsNum {
+		return "", fmt.Errorf("pre-release version %q has no tag, its first pre-release identifier must not be numeric", v)
+	}
+	return v.Pre[0].VersionStr, nil
+}
+
+// versionLess orders versions by semver precedence, and versions that differ
+// only in build metadata according to the template's BuildMetadataOrdering.
+func (sv *semverTemplate) versionLess(a, b semver.Version) bool {
+	if c := a.Compare(b); c != 0 {
+		return c < 0
+	}
+	return sv.BuildMetadataOrdering == compareBuildMetadataOrdering && compareBuildMetadata(a.Build, b.Build) < 0
+}
+
+// compareBuildMetadata compares build identifiers with the precedence rules
+// of pre-release identifiers.
+func compareBuildMetadata(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareBuildIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func compareBuildIdentifier(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
 func withoutBuildMetadataConflict(versions *map[string]semver.Version) error {
 	errs := []error{}
 
@@ -421,14 +657,36 @@ func withoutBuildMetadataConflict(versions *map[string]semver.Version) error {
 	return nil
 }
 
-func validateVersions(versions *map[string]semver.Version) error {
+func validateVersions(versions *map[string]semver.Version, ordering buildMetadataOrdering) error {
 	// short-circuit if empty, since that is not an error
 	if len(*versions) == 0 {
 		return nil
 	}
+	if ordering == compareBuildMetadataOrdering {
+		return withoutDuplicateVersions(versions)
+	}
 	return withoutBuildMetadataConflict(versions)
 }
 
+// withoutDuplicateVersions checks that no two bundles have the same version,
+// including build metadata, since they cannot be ordered.
+func withoutDuplicateVersions(versions *map[string]semver.Version) error {
+	errs := []error{}
+	seen := make(map[string]string)
+	for _, b := range sets.List(sets.KeySet(*versions)) {
+		v := (*versions)[b].String()
+		if prev, ok := seen[v]; ok {
+			errs = append(errs, fmt.Errorf("bundles %q and %q have the same version %q", prev, b, v))
+			continue
+		}
+		seen[v] = b
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("encountered bundles with the same version, which cannot be ordered: %v", errors.NewAggregate(errs))
+	}
+	return nil
+}
+
 // strips out the build metadata from a semver.Version and then stringifies it to make it suitable for collision detection
 func stripBuildMetadata(v semver.Version) string {
 	v.Build = nil
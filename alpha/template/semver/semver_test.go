@@ -1,6 +1,7 @@
 package semver
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -465,7 +466,8 @@ func TestGenerateChannels(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			sv := &semverTemplate{GenerateMajorChannels: tt.generateMajorChannels, GenerateMinorChannels: tt.generateMinorChannels, pkg: "a", DefaultChannelTypePreference: tt.channelTypePreference}
-			out := sv.generateChannels(&channelOperatorVersions)
+			out, err := sv.generateChannels(&channelOperatorVersions)
+			require.NoError(t, err)
 			require.ElementsMatch(t, tt.out, out)
 			require.Equal(t, tt.defaultChannel, sv.defaultChannel)
 		})
@@ -704,6 +706,48 @@ invalid:
 				require.ErrorContains(t, err, "unknown DefaultChannelTypePreference")
 			},
 		},
+		{
+			name: "channel name templates",
+			input: fmt.Sprintf(templateFstr, "true", "true", "minor") + `channelNames:
+    major: "{{.Stream}}-{{.Major}}"
+    minor: "{{.Major}}.{{.Minor}}-{{.Stream}}"
+`,
+			assertions: func(t *testing.T, template *semverTemplate, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, template.majorChannelName)
+				require.NotNil(t, template.minorChannelName)
+			},
+		},
+		{
+			name: "unparsable channel name template",
+			input: fmt.Sprintf(templateFstr, "true", "true", "minor") + `channelNames:
+    minor: "{{.Major"
+`,
+			assertions: func(t *testing.T, template *semverTemplate, err error) {
+				require.Nil(t, template)
+				require.ErrorContains(t, err, "invalid minor channel name template")
+			},
+		},
+		{
+			name: "channel name template with unknown variable",
+			input: fmt.Sprintf(templateFstr, "true", "true", "minor") + `channelNames:
+    major: "{{.Patch}}"
+`,
+			assertions: func(t *testing.T, template *semverTemplate, err error) {
+				require.Nil(t, template)
+				require.ErrorContains(t, err, "invalid major channel name template")
+			},
+		},
+		{
+			name: "empty channel name",
+			input: fmt.Sprintf(templateFstr, "true", "true", "minor") + `channelNames:
+    major: "{{if false}}x{{end}}"
+`,
+			assertions: func(t *testing.T, template *semverTemplate, err error) {
+				require.Nil(t, template)
+				require.ErrorContains(t, err, "channel name is empty")
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -713,3 +757,280 @@ invalid:
 		})
 	}
 }
+
+func TestGenerateChannelNames(t *testing.T) {
+	versions := bundleVersions{
+		"candidate": {
+			"a-v1.0.0": semver.MustParse("1.0.0"),
+			"a-v1.1.0": semver.MustParse("1.1.0"),
+		},
+		"stable": {
+			"a-v1.0.0": semver.MustParse("1.0.0"),
+		},
+	}
+	channelNames := func(channels []declcfg.Channel) []string {
+		var names []string
+		for _, ch := range channels {
+			names = append(names, ch.Name)
+		}
+		return names
+	}
+
+	type testCase struct {
+		name           string
+		channelNames   string
+		expected       []string
+		defaultChannel string
+		expectedErr    string
+	}
+	testCases := []testCase{
+		{
+			name:           "default names",
+			expected:       []string{"candidate-v1", "candidate-v1.0", "candidate-v1.1", "stable-v1", "stable-v1.0"},
+			defaultChannel: "stable-v1.0",
+		},
+		{
+			name: "custom names",
+			channelNames: `channelNames:
+    major: "release-{{.Major}}-{{.Stream}}"
+    minor: "{{.Major}}.{{.Minor}}-{{.Stream}}"
+`,
+			expected:       []string{"release-1-candidate", "1.0-candidate", "1.1-candidate", "release-1-stable", "1.0-stable"},
+			defaultChannel: "1.0-stable",
+		},
+		{
+			name: "names without stream",
+			channelNames: `channelNames:
+    minor: "release-{{.Major}}.{{.Minor}}"
+`,
+			expectedErr: `channel name "release-1.0" is generated for both candidate minor 1.0 and stable minor 1.0 channels`,
+		},
+		{
+			name: "major and minor names collide",
+			channelNames: `channelNames:
+    major: "{{.Stream}}-{{.Major}}.0"
+    minor: "{{.Stream}}-{{.Major}}.{{.Minor}}"
+`,
+			expectedErr: `channel name "candidate-1.0" is generated for both`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sv, err := readFile(strings.NewReader("schema: olm.semver\ngenerateMajorChannels: true\ngenerateMinorChannels: true\n" + tc.channelNames))
+			require.NoError(t, err)
+			sv.pkg = "a"
+			channels, err := sv.generateChannels(&versions)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, channelNames(channels))
+			require.Equal(t, tc.defaultChannel, sv.defaultChannel)
+		})
+	}
+}
+
+func TestRenderDeprecations(t *testing.T) {
+	renderBundle := func(_ context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+		version := strings.TrimPrefix(image, "repo/origin/a-v")
+		return &declcfg.DeclarativeConfig{
+			Bundles: []declcfg.Bundle{{
+				Schema:     declcfg.SchemaBundle,
+				Name:       "a-v" + version,
+				Package:    "a",
+				Image:      image,
+				Properties: []property.Property{property.MustBuildPackage("a", version)},
+			}},
+		}, nil
+	}
+	templateFstr := `---
+schema: olm.semver
+generateMinorChannels: true
+stable:
+    bundles:
+        - image: repo/origin/a-v0.1.0
+        - image: repo/origin/a-v0.2.0
+%s`
+
+	type testCase struct {
+		name        string
+		deprecated  string
+		expected    []declcfg.Deprecation
+		expectedErr string
+	}
+	testCases := []testCase{
+		{
+			name: "none",
+		},
+		{
+			name: "channel and bundle",
+			deprecated: `deprecated:
+    channels:
+        - name: stable-v0.1
+          message: stable-v0.1 is no longer maintained
+    bundles:
+        - image: repo/origin/a-v0.2.0
+          message: a-v0.2.0 has a known issue
+`,
+			expected: []declcfg.Deprecation{{
+				Schema:  declcfg.SchemaDeprecation,
+				Package: "a",
+				Entries: []declcfg.DeprecationEntry{
+					{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "stable-v0.1"}, Message: "stable-v0.1 is no longer maintained"},
+					{Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "a-v0.2.0"}, Message: "a-v0.2.0 has a known issue"},
+				},
+			}},
+		},
+		{
+			name: "unknown channel",
+			deprecated: `deprecated:
+    channels:
+        - name: fast-v0.1
+          message: fast-v0.1 is no longer maintained
+`,
+			expectedErr: `render: deprecated channel "fast-v0.1" is not generated by the template`,
+		},
+		{
+			name: "unknown bundle",
+			deprecated: `deprecated:
+    bundles:
+        - image: repo/origin/a-v0.3.0
+          message: a-v0.3.0 has a known issue
+`,
+			expectedErr: `render: deprecated bundle "repo/origin/a-v0.3.0" is not in any channel of the template`,
+		},
+		{
+			name: "missing message",
+			deprecated: `deprecated:
+    bundles:
+        - image: repo/origin/a-v0.1.0
+`,
+			expectedErr: `render: deprecated bundle "repo/origin/a-v0.1.0" has no message`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl := Template{
+				Data:         strings.NewReader(fmt.Sprintf(templateFstr, tc.deprecated)),
+				RenderBundle: renderBundle,
+			}
+			out, err := tmpl.Render(context.Background())
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.Deprecations)
+		})
+	}
+}
+
+func TestGeneratePrereleaseChannels(t *testing.T) {
+	versions := bundleVersions{
+		"candidate": {
+			"a-v1.0.0":        semver.MustParse("1.0.0"),
+			"a-v1.1.0-beta.1": semver.MustParse("1.1.0-beta.1"),
+			"a-v1.1.0-beta.2": semver.MustParse("1.1.0-beta.2"),
+			"a-v1.1.0-rc.1":   semver.MustParse("1.1.0-rc.1"),
+			"a-v1.1.0":        semver.MustParse("1.1.0"),
+		},
+		"stable": {
+			"a-v1.0.0": semver.MustParse("1.0.0"),
+		},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		sv := &semverTemplate{pkg: "a", GenerateMinorChannels: true, DefaultChannelTypePreference: minorStreamType}
+		channels, err := sv.generateChannels(&versions)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []declcfg.Channel{
+			{Schema: "olm.channel", Name: "candidate-v1.0", Package: "a", Entries: []declcfg.ChannelEntry{{Name: "a-v1.0.0"}}},
+			{Schema: "olm.channel", Name: "candidate-v1.1", Package: "a", Entries: []declcfg.ChannelEntry{
+				{Name: "a-v1.1.0-beta.1"},
+				{Name: "a-v1.1.0-beta.2"},
+				{Name: "a-v1.1.0-rc.1"},
+				{Name: "a-v1.1.0", Skips: []string{"a-v1.1.0-beta.1", "a-v1.1.0-beta.2", "a-v1.1.0-rc.1"}},
+			}},
+			{Schema: "olm.channel", Name: "stable-v1.0", Package: "a", Entries: []declcfg.ChannelEntry{{Name: "a-v1.0.0"}}},
+		}, channels)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		sv := &semverTemplate{pkg: "a", GenerateMinorChannels: true, DefaultChannelTypePreference: minorStreamType, GeneratePrereleaseChannels: true}
+		channels, err := sv.generateChannels(&versions)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []declcfg.Channel{
+			{Schema: "olm.channel", Name: "candidate-v1.0", Package: "a", Entries: []declcfg.ChannelEntry{{Name: "a-v1.0.0"}}},
+			{Schema: "olm.channel", Name: "candidate-v1.1", Package: "a", Entries: []declcfg.ChannelEntry{{Name: "a-v1.1.0"}}},
+			{Schema: "olm.channel", Name: "beta-v1.1", Package: "a", Entries: []declcfg.ChannelEntry{
+				{Name: "a-v1.1.0-beta.1"},
+				{Name: "a-v1.1.0-beta.2", Skips: []string{"a-v1.1.0-beta.1"}},
+			}},
+			{Schema: "olm.channel", Name: "rc-v1.1", Package: "a", Entries: []declcfg.ChannelEntry{{Name: "a-v1.1.0-rc.1"}}},
+			{Schema: "olm.channel", Name: "stable-v1.0", Package: "a", Entries: []declcfg.ChannelEntry{{Name: "a-v1.0.0"}}},
+		}, channels)
+		require.Equal(t, "stable-v1.0", sv.defaultChannel)
+	})
+
+	t.Run("untagged pre-release", func(t *testing.T) {
+		sv := &semverTemplate{pkg: "a", GenerateMinorChannels: true, GeneratePrereleaseChannels: true}
+		_, err := sv.generateChannels(&bundleVersions{"candidate": {"a-v1.0.0-1": semver.MustParse("1.0.0-1")}})
+		require.EqualError(t, err, `bundle "a-v1.0.0-1": pre-release version "1.0.0-1" has no tag, its first pre-release identifier must not be numeric`)
+	})
+
+	t.Run("tag collides with stream", func(t *testing.T) {
+		sv := &semverTemplate{pkg: "a", GenerateMinorChannels: true, GeneratePrereleaseChannels: true}
+		_, err := sv.generateChannels(&bundleVersions{"stable": {
+			"a-v1.0.0":        semver.MustParse("1.0.0"),
+			"a-v1.0.1-stable": semver.MustParse("1.0.1-stable"),
+		}})
+		require.ErrorContains(t, err, `channel name "stable-v1.0" is generated for both`)
+	})
+}
+
+func TestBuildMetadataOrdering(t *testing.T) {
+	versions := map[string]semver.Version{
+		"a-v1.0.0+10":      semver.MustParse("1.0.0+10"),
+		"a-v1.0.0+9":       semver.MustParse("1.0.0+9"),
+		"a-v1.0.0+9.1":     semver.MustParse("1.0.0+9.1"),
+		"a-v1.0.0+git.abc": semver.MustParse("1.0.0+git.abc"),
+		"a-v1.0.0":         semver.MustParse("1.0.0"),
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		for _, ordering := range []buildMetadataOrdering{"", rejectBuildMetadataOrdering} {
+			v := versions
+			require.ErrorContains(t, validateVersions(&v, ordering), "differ only by build metadata")
+		}
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		v := versions
+		require.NoError(t, validateVersions(&v, compareBuildMetadataOrdering))
+
+		sv := &semverTemplate{pkg: "a", GenerateMinorChannels: true, BuildMetadataOrdering: compareBuildMetadataOrdering}
+		channels, err := sv.generateChannels(&bundleVersions{"stable": versions})
+		require.NoError(t, err)
+		require.Len(t, channels, 1)
+		var names []string
+		for _, e := range channels[0].Entries {
+			names = append(names, e.Name)
+		}
+		require.Equal(t, []string{"a-v1.0.0", "a-v1.0.0+9", "a-v1.0.0+9.1", "a-v1.0.0+10", "a-v1.0.0+git.abc"}, names)
+	})
+
+	t.Run("compare duplicate", func(t *testing.T) {
+		v := map[string]semver.Version{
+			"a":  semver.MustParse("1.0.0+1"),
+			"a2": semver.MustParse("1.0.0+1"),
+		}
+		require.EqualError(t, validateVersions(&v, compareBuildMetadataOrdering), `encountered bundles with the same version, which cannot be ordered: bundles "a" and "a2" have the same version "1.0.0+1"`)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := readFile(strings.NewReader("schema: olm.semver\nbuildMetadataOrdering: newest\n"))
+		require.ErrorContains(t, err, `unknown BuildMetadataOrdering: "newest"`)
+	})
+}
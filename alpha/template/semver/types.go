@@ -3,6 +3,7 @@ package semver
 import (
 	"context"
 	"io"
+	"text/template"
 
 	"github.com/blang/semver/v4"
 
@@ -24,17 +25,54 @@ type semverTemplateChannelBundles struct {
 	Bundles []semverTemplateBundleEntry `json:"bundles,omitempty"`
 }
 
+// semverTemplateBundleDeprecation deprecates the bundle with the given
+// image, which must be one of the bundles of the template's channels.
+type semverTemplateBundleDeprecation struct {
+	Image   string `json:"image"`
+	Message string `json:"message"`
+}
+
+// semverTemplateChannelDeprecation deprecates a channel generated by the
+// template, e.g. "stable-v1.2".
+type semverTemplateChannelDeprecation struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+type semverTemplateDeprecations struct {
+	Bundles  []semverTemplateBundleDeprecation  `json:"bundles,omitempty"`
+	Channels []semverTemplateChannelDeprecation `json:"channels,omitempty"`
+}
+
+// semverTemplateChannelNames holds Go templates for the names of the
+// generated channels, e.g. "{{.Major}}.{{.Minor}}-{{.Stream}}". Templates are
+// executed with the channel's Stream ("candidate", "fast", or "stable", or the
+// pre-release tag of a pre-release channel), Major, and Minor versions; Minor
+// is always 0 for major-version channels.
+type semverTemplateChannelNames struct {
+	Major string `json:"major,omitempty"`
+	Minor string `json:"minor,omitempty"`
+}
+
 type semverTemplate struct {
 	Schema                       string                       `json:"schema"`
 	GenerateMajorChannels        bool                         `json:"generateMajorChannels,omitempty"`
 	GenerateMinorChannels        bool                         `json:"generateMinorChannels,omitempty"`
+	GeneratePrereleaseChannels   bool                         `json:"generatePrereleaseChannels,omitempty"`
+	BuildMetadataOrdering        buildMetadataOrdering        `json:"buildMetadataOrdering,omitempty"`
 	DefaultChannelTypePreference streamType                   `json:"defaultChannelTypePreference,omitempty"`
 	Candidate                    semverTemplateChannelBundles `json:"candidate,omitempty"`
 	Fast                         semverTemplateChannelBundles `json:"fast,omitempty"`
 	Stable                       semverTemplateChannelBundles `json:"stable,omitempty"`
+	ChannelNames                 semverTemplateChannelNames   `json:"channelNames,omitempty"`
+	Deprecated                   semverTemplateDeprecations   `json:"deprecated,omitempty"`
+
+	pkg            string          `json:"-"` // the derived package name
+	defaultChannel string          `json:"-"` // detected "most stable" channel head
+	placeholders   map[string]bool `json:"-"` // bundle images that could not be rendered
 
-	pkg            string `json:"-"` // the derived package name
-	defaultChannel string `json:"-"` // detected "most stable" channel head
+	majorChannelName *template.Template `json:"-"` // parsed ChannelNames.Major, or nil for the default
+	minorChannelName *template.Template `json:"-"` // parsed ChannelNames.Minor, or nil for the default
 }
 
 // IO structs -- END
@@ -62,12 +100,41 @@ func (b byChannelPriority) Less(i, j int) bool {
 }
 func (b byChannelPriority) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
 
+// buildMetadataOrdering is the policy for ordering bundle versions that differ
+// only in build metadata, which semver does not order.
+type buildMetadataOrdering string
+
+const (
+	// rejectBuildMetadataOrdering rejects versions that differ only in build
+	// metadata. It is the default.
+	rejectBuildMetadataOrdering buildMetadataOrdering = "reject"
+	// compareBuildMetadataOrdering orders versions that differ only in build
+	// metadata by their build identifiers, with the precedence rules of
+	// pre-release identifiers: numeric identifiers are compared numerically
+	// and are lower than alphanumeric ones, which are compared lexically, and
+	// a shorter set of identifiers is lower than a longer one it prefixes.
+	compareBuildMetadataOrdering buildMetadataOrdering = "compare"
+)
+
 type streamType string
 
 const defaultStreamType streamType = ""
 const minorStreamType streamType = "minor"
 const majorStreamType streamType = "major"
 
+// default channel name templates for each stream type
+const (
+	defaultMajorChannelName = "{{.Stream}}-v{{.Major}}"
+	defaultMinorChannelName = "{{.Stream}}-v{{.Major}}.{{.Minor}}"
+)
+
+// the data passed to the channel name templates
+type channelNameData struct {
+	Stream string
+	Major  uint64
+	Minor  uint64
+}
+
 // general preference for minor channels
 var streamTypePriorities = map[streamType]int{minorStreamType: 2, majorStreamType: 1, defaultStreamType: 0}
 
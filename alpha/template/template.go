@@ -0,0 +1,97 @@
+// Package template defines the interface of catalog templates, and looks up
+// the built-in templates and the template plugins that implement it by the
+// schema of their template files.
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/basic"
+	"github.com/operator-framework/operator-registry/alpha/template/semver"
+)
+
+// RegistryResolver renders the bundle images that templates reference into
+// declarative configs.
+type RegistryResolver interface {
+	RenderBundle(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error)
+}
+
+// RegistryResolverFunc is a function that implements RegistryResolver.
+type RegistryResolverFunc func(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error)
+
+func (f RegistryResolverFunc) RenderBundle(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+	return f(ctx, image)
+}
+
+// Template is a type of catalog template, which renders template files of its
+// schema into file-based catalogs.
+type Template interface {
+	// Schema returns the schema of the template files of the template.
+	Schema() string
+
+	// Parse reads a template file from r and checks that it is valid,
+	// without rendering it.
+	Parse(ctx context.Context, r io.Reader) error
+
+	// Render renders the template file read from r into a declarative
+	// config, rendering the bundle images it references with resolver.
+	Render(ctx context.Context, r io.Reader, resolver RegistryResolver) (*declcfg.DeclarativeConfig, error)
+}
+
+// builtins are the templates that are part of opm, by schema.
+var builtins = map[string]Template{
+	basicTemplate{}.Schema():  basicTemplate{},
+	semverTemplate{}.Schema(): semverTemplate{},
+}
+
+// Lookup returns the template of schema: the built-in template of schema, if
+// there is one, or else the plugin named PluginPrefix followed by schema in
+// the directories of the PATH environment variable.
+func Lookup(schema string) (Template, error) {
+	if t, ok := builtins[schema]; ok {
+		return t, nil
+	}
+	if schema == "" || strings.ContainsAny(schema, `/\`) {
+		return nil, fmt.Errorf("invalid template schema %q", schema)
+	}
+	path, err := exec.LookPath(PluginPrefix + schema)
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("no template found for schema %q: no built-in template and no %q plugin in PATH", schema, PluginPrefix+schema)
+		}
+		return nil, fmt.Errorf("looking up template plugin for schema %q: %v", schema, err)
+	}
+	return ExecTemplate{SchemaName: schema, Path: path}, nil
+}
+
+type basicTemplate struct{}
+
+func (basicTemplate) Schema() string { return "olm.template.basic" }
+
+func (basicTemplate) Parse(_ context.Context, r io.Reader) error {
+	return basic.Validate(r)
+}
+
+func (basicTemplate) Render(ctx context.Context, r io.Reader, resolver RegistryResolver) (*declcfg.DeclarativeConfig, error) {
+	t := basic.Template{RenderBundle: resolver.RenderBundle}
+	return t.Render(ctx, r)
+}
+
+type semverTemplate struct{}
+
+func (semverTemplate) Schema() string { return "olm.semver" }
+
+func (semverTemplate) Parse(_ context.Context, r io.Reader) error {
+	return semver.Validate(r)
+}
+
+func (semverTemplate) Render(ctx context.Context, r io.Reader, resolver RegistryResolver) (*declcfg.DeclarativeConfig, error) {
+	t := semver.Template{Data: r, RenderBundle: resolver.RenderBundle}
+	return t.Render(ctx)
+}
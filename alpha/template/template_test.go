@@ -0,0 +1,110 @@
+package template
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// installPlugin writes a template plugin for schema, running script, to a
+// directory that is made the only one in PATH. The script may only use shell
+// builtins.
+func installPlugin(t *testing.T, schema, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PluginPrefix+schema), []byte("#!/bin/sh\n"+script), 0755))
+	t.Setenv("PATH", dir)
+}
+
+var fakeResolver = RegistryResolverFunc(func(_ context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+	return &declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{{
+		Schema:     declcfg.SchemaBundle,
+		Name:       "foo.v1.0.0",
+		Package:    "foo",
+		Image:      image,
+		Properties: []property.Property{property.MustBuildPackage("foo", "1.0.0")},
+	}}}, nil
+})
+
+func TestLookupBuiltin(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	for _, schema := range []string{"olm.template.basic", "olm.semver"} {
+		tmpl, err := Lookup(schema)
+		require.NoError(t, err)
+		require.Equal(t, schema, tmpl.Schema())
+	}
+
+	tmpl, err := Lookup("olm.template.basic")
+	require.NoError(t, err)
+	require.Error(t, tmpl.Parse(context.Background(), strings.NewReader(`{"schema":"olm.semver"}`)))
+
+	cfg, err := tmpl.Render(context.Background(), strings.NewReader(`schema: olm.template.basic
+entries:
+- schema: olm.bundle
+  image: foo:1.0.0
+`), fakeResolver)
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, "foo.v1.0.0", cfg.Bundles[0].Name)
+}
+
+func TestLookupNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := Lookup("example.com.custom")
+	require.ErrorContains(t, err, `no "opm-template-example.com.custom" plugin in PATH`)
+
+	_, err = Lookup("../custom")
+	require.ErrorContains(t, err, "invalid template schema")
+}
+
+func TestExecTemplate(t *testing.T) {
+	installPlugin(t, "example.com.custom", `
+case "$1" in
+parse)
+	read -r line
+	case "$line" in
+	*custom*) ;;
+	*) echo "not a custom template" >&2; exit 1 ;;
+	esac
+	;;
+render)
+	echo '{"schema":"olm.package","name":"foo","defaultChannel":"'"$OPM_TEMPLATE_SCHEMA"'"}'
+	echo '{"schema":"olm.bundle","image":"foo:1.0.0"}'
+	;;
+esac
+`)
+
+	tmpl, err := Lookup("example.com.custom")
+	require.NoError(t, err)
+	require.Equal(t, "example.com.custom", tmpl.Schema())
+
+	require.NoError(t, tmpl.Parse(context.Background(), strings.NewReader("schema: example.com.custom")))
+	err = tmpl.Parse(context.Background(), strings.NewReader("schema: other"))
+	require.ErrorContains(t, err, "not a custom template")
+
+	cfg, err := tmpl.Render(context.Background(), strings.NewReader("schema: example.com.custom"), fakeResolver)
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	require.Equal(t, "example.com.custom", cfg.Packages[0].DefaultChannel)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, "foo.v1.0.0", cfg.Bundles[0].Name)
+	require.Equal(t, "foo:1.0.0", cfg.Bundles[0].Image)
+}
+
+func TestExecTemplateInvalidOutput(t *testing.T) {
+	installPlugin(t, "example.com.custom", `echo 'not a catalog'`)
+
+	tmpl, err := Lookup("example.com.custom")
+	require.NoError(t, err)
+	_, err = tmpl.Render(context.Background(), strings.NewReader(""), fakeResolver)
+	require.ErrorContains(t, err, "invalid output")
+}
@@ -3,19 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
-	"net"
+	"os"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	health "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/cache"
+	"github.com/operator-framework/operator-registry/pkg/configmap"
 	"github.com/operator-framework/operator-registry/pkg/lib/dns"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
 	"github.com/operator-framework/operator-registry/pkg/registry"
@@ -25,9 +28,28 @@ import (
 
 var rootCmd = &cobra.Command{
 	Short: "configmap-server",
-	Long:  `configmap-server reads a configmap and builds a sqlite database containing operator manifests and serves a grpc API to query it`,
+	Long: `configmap-server reads configmaps and serves a grpc API to query the operators they contain.
+
+By default, it reads operator manifests from a single configmap and builds a
+sqlite database from them. This mode is deprecated.
+
+With --fbc, it reads a declarative config from one or more configmaps, named
+with --configMapName or selected with --selector, and builds a cache from it
+like "opm serve". Each key of the configmaps with a .json, .yaml or .yml
+extension is a declarative config file. Files that are too large for one key
+or configmap can be split into chunks stored in keys named
+"<file>.chunk-<index>", e.g. "catalog.json.chunk-0", "catalog.json.chunk-1",
+which may be spread across the configmaps.
+
+With --fbc and --watch, the configmaps are watched, and the served catalog is
+rebuilt from them whenever one of them is added, updated, or deleted, so that
+teams can contribute packages in separate configmaps that match --selector. If
+a rebuild fails, the previously loaded catalog continues to be served. Each
+rebuild uses a new cache in --cache-dir. Watching requires permission to list
+and watch the configmaps of the namespace.`,
 
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		log.Configure(logOptions)
 		if debug, _ := cmd.Flags().GetBool("debug"); debug {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
@@ -37,13 +59,21 @@ var rootCmd = &cobra.Command{
 	RunE: runCmdFunc,
 }
 
+var logOptions log.Options
+
 func init() {
+	logOptions.AddFlags(rootCmd.Flags())
 	rootCmd.Flags().Bool("debug", false, "enable debug logging")
 	rootCmd.Flags().StringP("kubeconfig", "k", "", "absolute path to kubeconfig file")
-	rootCmd.Flags().StringP("database", "d", "bundles.db", "name of db to output")
-	rootCmd.Flags().StringP("configMapName", "c", "", "name of a configmap")
+	rootCmd.Flags().StringP("database", "d", "bundles.db", "name of db to output, used without --fbc")
+	rootCmd.Flags().StringSliceP("configMapName", "c", nil, "name of a configmap; may be repeated or comma-separated with --fbc")
+	rootCmd.Flags().StringP("selector", "l", "", "label selector of the configmaps of a declarative config, used with --fbc")
+	rootCmd.Flags().Bool("fbc", false, "serve a declarative config stored in the configmaps instead of loading manifests into a sqlite database")
+	rootCmd.Flags().Bool("watch", false, "rebuild the served catalog when the configmaps change, used with --fbc")
+	rootCmd.Flags().String("cache-dir", "", "directory of the declarative config cache, used with --fbc; defaults to a temporary directory")
 	rootCmd.Flags().StringP("configMapNamespace", "n", "", "namespace of a configmap")
 	rootCmd.Flags().StringP("port", "p", "50051", "port number to serve on")
+	rootCmd.Flags().String("listen", "", "if set, address to serve on instead of --port: a TCP address (host:port), a unix domain socket (unix:///path/to/registry.sock), or fd:// or fd://<name> for a socket passed by systemd socket activation")
 	rootCmd.Flags().StringP("termination-log", "t", "/dev/termination-log", "path to a container termination log file")
 	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
 	if err := rootCmd.Flags().MarkHidden("debug"); err != nil {
@@ -82,7 +112,12 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	configMapName, err := cmd.Flags().GetString("configMapName")
+	listen, err := cmd.Flags().GetString("listen")
+	if err != nil {
+		return err
+	}
+	addr := server.ListenAddress(listen, port)
+	configMapNames, err := cmd.Flags().GetStringSlice("configMapName")
 	if err != nil {
 		return err
 	}
@@ -90,7 +125,11 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	dbName, err := cmd.Flags().GetString("database")
+	selector, err := cmd.Flags().GetString("selector")
+	if err != nil {
+		return err
+	}
+	fbc, err := cmd.Flags().GetBool("fbc")
 	if err != nil {
 		return err
 	}
@@ -98,28 +137,270 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	logger := logrus.WithFields(logrus.Fields{"configMapName": configMapName, "configMapNamespace": configMapNamespace, "port": port})
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return err
+	}
+	logger := logrus.WithFields(logrus.Fields{"configMapName": configMapNames, "configMapNamespace": configMapNamespace, "address": addr})
 
 	client := NewClientFromConfig(kubeconfig, logger.Logger)
-	configMap, err := client.CoreV1().ConfigMaps(configMapNamespace).Get(ctx, configMapName, metav1.GetOptions{})
+
+	var store registry.GRPCQuery
+	if fbc {
+		cacheDir, err := cmd.Flags().GetString("cache-dir")
+		if err != nil {
+			return err
+		}
+		if cacheDir == "" {
+			cacheDir, err = os.MkdirTemp("", "configmap-server-cache-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(cacheDir)
+		}
+		if watch {
+			swappable, err := watchDeclarativeConfigCache(ctx, client, configMapNamespace, configMapNames, selector, cacheDir, permissive, logger)
+			if err != nil {
+				return err
+			}
+			defer swappable.Close()
+			store = swappable
+		} else {
+			configMaps, err := getConfigMaps(ctx, client, configMapNamespace, configMapNames, selector)
+			if err != nil {
+				logger.Fatalf("error getting configmaps: %s", err)
+			}
+			c, err := loadDeclarativeConfigCache(ctx, configMaps, cacheDir, logger)
+			if err != nil {
+				err = fmt.Errorf("error loading declarative config from configmaps: %s", err)
+				if !permissive {
+					logger.WithError(err).Fatal("permissive mode disabled")
+				}
+				logger.WithError(err).Warn("permissive mode enabled")
+			} else {
+				defer c.Close()
+				store = c
+			}
+		}
+	} else {
+		if watch {
+			return fmt.Errorf("--watch requires --fbc")
+		}
+		if len(configMapNames) != 1 || selector != "" {
+			return fmt.Errorf("exactly one --configMapName must be set without --fbc")
+		}
+		logger.Warn("loading manifests from a configmap into a sqlite database is deprecated, use --fbc to serve a declarative config instead")
+		dbName, err := cmd.Flags().GetString("database")
+		if err != nil {
+			return err
+		}
+		configMap, err := client.CoreV1().ConfigMaps(configMapNamespace).Get(ctx, configMapNames[0], metav1.GetOptions{})
+		if err != nil {
+			logger.Fatalf("error getting configmap: %s", err)
+		}
+		store, err = loadSqliteStore(ctx, *configMap, dbName, permissive, logger)
+		if err != nil {
+			return err
+		}
+	}
+	if store == nil {
+		store = registry.NewEmptyQuerier()
+	}
+
+	lis, err := server.Listen(addr)
+	if err != nil {
+		logger.Fatalf("failed to listen: %s", err)
+	}
+	s := grpc.NewServer()
+
+	api.RegisterRegistryServer(s, server.NewRegistryServer(store))
+	health.RegisterHealthServer(s, server.NewHealthServer())
+	reflection.Register(s)
+
+	go func() {
+		<-ctx.Done()
+		logger.Info("shutting down server")
+		s.GracefulStop()
+	}()
+
+	logger.Info("serving registry")
+	return s.Serve(lis)
+}
+
+// getConfigMaps returns the configmaps of namespace that are named by names or
+// that match selector.
+func getConfigMaps(ctx context.Context, client kubernetes.Interface, namespace string, names []string, selector string) ([]corev1.ConfigMap, error) {
+	if len(names) == 0 && selector == "" {
+		return nil, fmt.Errorf("at least one of --configMapName or --selector must be set")
+	}
+	var configMaps []corev1.ConfigMap
+	seen := map[string]struct{}{}
+	for _, name := range names {
+		cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		seen[cm.GetName()] = struct{}{}
+		configMaps = append(configMaps, *cm)
+	}
+	if selector != "" {
+		list, err := client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, cm := range list.Items {
+			if _, ok := seen[cm.GetName()]; ok {
+				continue
+			}
+			configMaps = append(configMaps, cm)
+		}
+	}
+	if len(configMaps) == 0 {
+		return nil, fmt.Errorf("no configmaps match selector %q", selector)
+	}
+	return configMaps, nil
+}
+
+// loadDeclarativeConfigCache writes the declarative config of configMaps to a
+// directory in cacheDir, and builds a cache from it.
+func loadDeclarativeConfigCache(ctx context.Context, configMaps []corev1.ConfigMap, cacheDir string, logger *logrus.Entry) (cache.Cache, error) {
+	configsDir, err := os.MkdirTemp("", "configmap-server-configs-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(configsDir)
+	if err := configmap.WriteDeclarativeConfigDir(configMaps, configsDir); err != nil {
+		return nil, err
+	}
+
+	c, err := cache.New(cacheDir, cache.WithLog(logger))
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.LoadOrRebuild(ctx, c, os.DirFS(configsDir)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// watchDeclarativeConfigCache watches the configmaps of namespace that are
+// named by names or that match selector, and returns a cache of their
+// declarative config that is rebuilt in a new directory of cacheDir whenever
+// they change, until ctx is done. If the configmaps cannot be loaded at first
+// in permissive mode, the cache is empty until they can.
+func watchDeclarativeConfigCache(ctx context.Context, client kubernetes.Interface, namespace string, names []string, selector string, cacheDir string, permissive bool, logger *logrus.Entry) (*cache.Swappable, error) {
+	w, err := configmap.NewWatcher(client, namespace, names, selector)
 	if err != nil {
-		logger.Fatalf("error getting configmap: %s", err)
+		return nil, err
+	}
+	if err := w.Start(ctx); err != nil {
+		logger.Fatalf("error watching configmaps: %s", err)
+	}
+
+	load := func(ctx context.Context) (cache.Cache, error) {
+		configMaps, err := w.ConfigMaps()
+		if err != nil {
+			return nil, err
+		}
+		dir, err := os.MkdirTemp(cacheDir, "cache-")
+		if err != nil {
+			return nil, err
+		}
+		c, err := loadDeclarativeConfigCache(ctx, configMaps, dir, logger)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		return &tempDirCache{Cache: c, dir: dir}, nil
 	}
 
+	c, err := load(ctx)
+	if err != nil {
+		err = fmt.Errorf("error loading declarative config from configmaps: %s", err)
+		if !permissive {
+			logger.WithError(err).Fatal("permissive mode disabled")
+		}
+		logger.WithError(err).Warn("permissive mode enabled")
+		if c, err = newEmptyCache(ctx, cacheDir, logger); err != nil {
+			return nil, err
+		}
+	}
+	swappable := cache.NewSwappable(c)
+
+	go func() {
+		defer w.Stop()
+		w.Watch(ctx, func(ctx context.Context) {
+			logger.Info("configmaps changed, reloading declarative config")
+			c, err := load(ctx)
+			if err != nil {
+				logger.WithError(err).Error("failed to reload declarative config from configmaps, continuing to serve the previously loaded catalog")
+				return
+			}
+			swappable.Swap(c)
+			logger.Info("reloaded declarative config")
+		})
+	}()
+	return swappable, nil
+}
+
+// newEmptyCache returns a cache of an empty declarative config in a new
+// directory of cacheDir.
+func newEmptyCache(ctx context.Context, cacheDir string, logger *logrus.Entry) (cache.Cache, error) {
+	configsDir, err := os.MkdirTemp("", "configmap-server-configs-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(configsDir)
+
+	dir, err := os.MkdirTemp(cacheDir, "cache-")
+	if err != nil {
+		return nil, err
+	}
+	c, err := cache.New(dir, cache.WithLog(logger))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	empty := &tempDirCache{Cache: c, dir: dir}
+	if err := cache.LoadOrRebuild(ctx, empty, os.DirFS(configsDir)); err != nil {
+		empty.Close()
+		return nil, err
+	}
+	return empty, nil
+}
+
+// tempDirCache is a cache that removes its cache directory when it is closed.
+type tempDirCache struct {
+	cache.Cache
+	dir string
+}
+
+func (c *tempDirCache) Close() error {
+	err := c.Cache.Close()
+	if rmErr := os.RemoveAll(c.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// loadSqliteStore loads the manifests of configMap into the sqlite database
+// dbName, and returns a querier for it.
+func loadSqliteStore(ctx context.Context, configMap corev1.ConfigMap, dbName string, permissive bool, logger *logrus.Entry) (registry.GRPCQuery, error) {
 	db, err := sqlite.Open(dbName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	sqlLoader, err := sqlite.NewSQLLiteLoader(db)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := sqlLoader.Migrate(ctx); err != nil {
-		return err
+		return nil, err
 	}
 
-	configMapPopulator := sqlite.NewSQLLoaderForConfigMap(sqlLoader, *configMap)
+	configMapPopulator := sqlite.NewSQLLoaderForConfigMap(sqlLoader, configMap)
 	if err := configMapPopulator.Populate(); err != nil {
 		err = fmt.Errorf("error loading manifests from configmap: %s", err)
 		if !permissive {
@@ -128,13 +409,10 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 		logger.WithError(err).Warn("permissive mode enabled")
 	}
 
-	var store registry.Query
-	store, err = sqlite.NewSQLLiteQuerier(dbName)
+	store, err := sqlite.NewSQLLiteQuerier(dbName)
 	if err != nil {
 		logger.WithError(err).Warnf("failed to load db")
-	}
-	if store == nil {
-		store = registry.NewEmptyQuerier()
+		return nil, nil
 	}
 
 	// sanity check that the db is available
@@ -145,25 +423,7 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	if len(tables) == 0 {
 		logger.Warn("no tables found in db")
 	}
-
-	lis, err := net.Listen("tcp", ":"+port)
-	if err != nil {
-		logger.Fatalf("failed to listen: %s", err)
-	}
-	s := grpc.NewServer()
-
-	api.RegisterRegistryServer(s, server.NewRegistryServer(store))
-	health.RegisterHealthServer(s, server.NewHealthServer())
-	reflection.Register(s)
-
-	go func() {
-		<-ctx.Done()
-		logger.Info("shutting down server")
-		s.GracefulStop()
-	}()
-
-	logger.Info("serving registry")
-	return s.Serve(lis)
+	return store, nil
 }
 
 // NewClient creates a kubernetes client or bails out on on failures.
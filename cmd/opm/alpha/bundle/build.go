@@ -66,7 +66,7 @@ Note:
 			"(Required if `directory` is not pointing to a bundle in the nested bundle format)")
 
 	bundleBuildCmd.Flags().StringVarP(&containerTool, "image-builder", "b", "docker",
-		"Tool used to manage container images. One of: [docker, podman, buildah]")
+		"Tool used to manage container images. One of: [docker, podman, nerdctl, buildah]")
 
 	bundleBuildCmd.Flags().StringVarP(&defaultChannel, "default", "e", "",
 		"The default channel for the bundle image")
@@ -1,12 +1,26 @@
 package bundle
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
 )
 
+var (
+	fbcOutput      string
+	fbcImage       string
+	fbcNoCanonical bool
+)
+
 // newBundleGenerateCmd returns a command that will generate operator bundle
 // annotations.yaml metadata
 func newBundleGenerateCmd() *cobra.Command {
@@ -19,8 +33,17 @@ bundle metadata if needed and a Dockerfile to build Operator bundle image.
 $ opm alpha bundle generate --directory /test/0.1.0/ --package test-operator \
 	--channels stable,beta --default stable
 
+To also generate the olm.bundle blob of the bundle, with the properties
+derived from its CSV, for a file-based catalog or its basic template:
+
+$ opm alpha bundle generate --directory /test/0.1.0/ --output-dir /test/bundle \
+	--package test-operator --channels stable --default stable \
+	--fbc-output test-operator.v0.1.0.yaml --fbc-image quay.io/example/test-operator-bundle:v0.1.0
+
 Note:
-* All manifests yaml must be in the same directory.`,
+* All manifests yaml must be in the same directory.
+* --fbc-output requires --output-dir, unless the manifests directory is named
+  "manifests", as the bundle is rendered as it would appear in its image.`,
 		RunE: generateFunc,
 		Args: cobra.NoArgs,
 	}
@@ -46,11 +69,37 @@ Note:
 		"Optional output directory for operator manifests")
 
 	bundleGenerateCmd.Flags().StringVar(&baseImage, "base-image", "scratch", "Use a custom image pullspec as the base bundle image")
+
+	bundleGenerateCmd.Flags().StringVar(&fbcOutput, "fbc-output", "",
+		"Optional file to write the olm.bundle blob of the bundle to, for a file-based catalog. "+
+			"It is written as JSON if the file has a .json extension, and as YAML otherwise")
+	bundleGenerateCmd.Flags().StringVar(&fbcImage, "fbc-image", "",
+		"The pullspec of the bundle image, set as the image of the olm.bundle blob written to --fbc-output and added to its related images. "+
+			"It may use the {{.Package}}, {{.Name}}, and {{.Version}} template variables of the bundle")
+	bundleGenerateCmd.Flags().BoolVar(&fbcNoCanonical, "fbc-no-canonical", false,
+		"Write the olm.bundle blob to --fbc-output as it is rendered, instead of in canonical form")
 	return bundleGenerateCmd
 }
 
 func generateFunc(cmd *cobra.Command, _ []string) error {
-	return bundle.GenerateFunc(
+	if fbcImage != "" && fbcOutput == "" {
+		return fmt.Errorf("--fbc-image requires --fbc-output")
+	}
+	// The bundle is rendered from the directory with the manifests/ and
+	// metadata/ directories, as they would appear in the bundle image.
+	bundleDir := outputDir
+	if fbcOutput != "" && bundleDir == "" {
+		manifestsDir, err := filepath.Abs(buildDir)
+		if err != nil {
+			return err
+		}
+		if filepath.Base(manifestsDir) != strings.TrimSuffix(bundle.ManifestsDir, "/") {
+			return fmt.Errorf("--fbc-output requires --output-dir, unless --directory is named %q", strings.TrimSuffix(bundle.ManifestsDir, "/"))
+		}
+		bundleDir = filepath.Dir(manifestsDir)
+	}
+
+	if err := bundle.GenerateFunc(
 		buildDir,
 		outputDir,
 		pkg,
@@ -58,5 +107,33 @@ func generateFunc(cmd *cobra.Command, _ []string) error {
 		defaultChannel,
 		true,
 		baseImage,
-	)
+	); err != nil {
+		return err
+	}
+	if fbcOutput == "" {
+		return nil
+	}
+
+	log.Info("Building olm.bundle blob")
+	gen := action.GenerateBundleBlob{
+		BundleDir:   bundleDir,
+		Format:      "yaml",
+		NoCanonical: fbcNoCanonical,
+	}
+	if filepath.Ext(fbcOutput) == ".json" {
+		gen.Format = "json"
+	}
+	if fbcImage != "" {
+		tmpl, err := template.New("fbc-image").Parse(fbcImage)
+		if err != nil {
+			return fmt.Errorf("invalid --fbc-image: %v", err)
+		}
+		gen.ImageRefTemplate = tmpl
+	}
+	var buf bytes.Buffer
+	gen.Writer = &buf
+	if err := gen.Run(cmd.Context()); err != nil {
+		return err
+	}
+	return os.WriteFile(fbcOutput, buf.Bytes(), bundle.DefaultPermission)
 }
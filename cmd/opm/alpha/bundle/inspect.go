@@ -0,0 +1,55 @@
+package bundle
+
+import (
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func newBundleInspectCmd() *cobra.Command {
+	logger := log.New()
+
+	return &cobra.Command{
+		Use:   "inspect BUNDLE_NAME[:TAG|@DIGEST]",
+		Short: "Inspect the content of an operator bundle image",
+		Long: `Pull an operator bundle image and print a JSON description of it: its labels
+and annotations, the manifests it contains, its package and channels, the APIs
+it provides and requires, its related images and the size of each image layer.
+
+Unlike rendering the bundle, this needs no catalog, so it is suited to checks
+of bundle images in CI.`,
+		Example: `  # Fail a CI job if a bundle requires any APIs
+  opm alpha bundle inspect quay.io/my-org/my-operator-bundle:v1.0.0 | jq -e '.requiredAPIs == null'`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer reg.Destroy()
+
+			inspect := action.InspectBundle{
+				BundleImage: args[0],
+				Registry:    reg,
+			}
+			res, err := inspect.Run(cmd.Context())
+			if err != nil {
+				logger.Fatal(err)
+			}
+			if err := res.WriteJSON(os.Stdout); err != nil {
+				logger.Fatal(err)
+			}
+			return nil
+		},
+	}
+}
@@ -2,7 +2,6 @@ package bundle
 
 import (
 	"context"
-	"crypto/x509"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -21,7 +20,7 @@ func newBundleUnpackCmd() *cobra.Command {
 	unpack := &cobra.Command{
 		Use:   "unpack BUNDLE_NAME[:TAG|@DIGEST]",
 		Short: "Unpacks the content of an operator bundle",
-		Long:  "Unpacks the content of an operator bundle into a directory",
+		Long:  "Unpacks the content of an operator bundle into a directory, or with --out=-, writes a tar archive of it to stdout",
 		Args: func(cmd *cobra.Command, args []string) error {
 			return cobra.ExactArgs(1)(cmd, args)
 		},
@@ -33,11 +32,14 @@ func newBundleUnpackCmd() *cobra.Command {
 	unpack.Flags().Bool("use-http", false, "use plain HTTP")
 	unpack.Flags().BoolP("skip-validation", "v", false, "disable bundle validation")
 	unpack.Flags().StringP("root-ca", "c", "", "file path of a root CA to use when communicating with image registries")
-	unpack.Flags().StringP("out", "o", "./", "directory in which to unpack operator bundle content")
+	unpack.Flags().StringP("out", "o", "./", "directory in which to unpack operator bundle content, or - to write a tar archive of it to stdout")
 
 	if err := unpack.Flags().MarkDeprecated("skip-tls", "use --use-http and --skip-tls-verify instead"); err != nil {
 		logrus.Panic(err.Error())
 	}
+	if err := unpack.Flags().MarkDeprecated("root-ca", "use --ca-file instead"); err != nil {
+		logrus.Panic(err.Error())
+	}
 	return unpack
 }
 
@@ -58,33 +60,17 @@ func unpackBundle(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if info, err := os.Stat(out); err != nil {
-		if os.IsNotExist(err) {
-			err = os.MkdirAll(out, 0755)
-		}
-		if err != nil {
+	if out != "-" {
+		if err := ensureOutputDir(out); err != nil {
 			return err
 		}
-	} else {
-		if info == nil {
-			return fmt.Errorf("failed to get output directory info")
-		}
-		if !info.IsDir() {
-			return fmt.Errorf("out %s is not a directory", out)
-		}
 	}
 
-	var (
-		registryOpts []containerdregistry.RegistryOption
-	)
-
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	clientConfig, err := util.GetClientConfig(cmd)
 	if err != nil {
 		return err
 	}
 
-	registryOpts = append(registryOpts, containerdregistry.SkipTLSVerify(skipTLSVerify), containerdregistry.WithPlainHTTP(useHTTP))
-
 	var skipValidation bool
 	skipValidation, err = cmd.Flags().GetBool("skip-validation")
 	if err != nil {
@@ -97,20 +83,10 @@ func unpackBundle(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if rootCA != "" {
-		rootCAs := x509.NewCertPool()
-		certs, err := os.ReadFile(rootCA)
-		if err != nil {
-			return err
-		}
-
-		if !rootCAs.AppendCertsFromPEM(certs) {
-			return fmt.Errorf("failed to fetch root CA from %s", rootCA)
-		}
-
-		registryOpts = append(registryOpts, containerdregistry.WithRootCAs(rootCAs))
+		clientConfig.CAFile = rootCA
 	}
 
-	registry, err := containerdregistry.NewRegistry(registryOpts...)
+	registry, err := containerdregistry.NewRegistry(containerdregistry.WithClientConfig(clientConfig))
 	if err != nil {
 		return err
 	}
@@ -159,9 +135,35 @@ func unpackBundle(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if out == "-" {
+		if err := bundle.WriteTar(os.Stdout, dir); err != nil {
+			return fmt.Errorf("failed to write unpacked content to stdout: %s", err)
+		}
+		return nil
+	}
+
 	if err := dircopy.Copy(dir, out); err != nil {
 		return fmt.Errorf("failed to copy unpacked content to output directory: %s", err)
 	}
 
 	return nil
 }
+
+func ensureOutputDir(out string) error {
+	if info, err := os.Stat(out); err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(out, 0755)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		if info == nil {
+			return fmt.Errorf("failed to get output directory info")
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("out %s is not a directory", out)
+		}
+	}
+	return nil
+}
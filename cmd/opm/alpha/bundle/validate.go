@@ -4,19 +4,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	"github.com/operator-framework/operator-registry/pkg/lib/validation"
 )
 
 var (
-	optional string
+	optional   string
+	k8sVersion string
 )
 
 func newBundleValidateCmd() *cobra.Command {
@@ -33,6 +37,8 @@ Required validators. These validators will run by default on every invocation of
 Optional validators. These validators are disabled by default and can be enabled via the --optional-validators flag. 
  * Operatorhub validator - performs operatorhub.io validation. To validate a bundle using custom categories use with the OPERATOR_BUNDLE_CATEGORIES environmental variable to point to a json-encoded categories file.
  * Bundle objects validator - performs validation on resources like PodDisruptionBudgets and PriorityClasses. 
+ * Operatorhub UI validator - checks the CSV fields displayed by operatorhub.io (icon, categories, short and long description, and container image), that all images are referenced by digest, and that they can be resolved in their registries using the credentials of the docker config.
+ * Deprecated APIs validator - checks the API versions of the bundle manifests against the schedule of the removals of Kubernetes APIs. APIs that are removed in the Kubernetes version set by --k8s-version fail validation, and APIs that are deprecated in it are reported as warnings. Setting --k8s-version enables this validator.
 
 See https://olm.operatorframework.io/docs/tasks/validate-package/#validation for more info.
 
@@ -49,8 +55,9 @@ Note that this subcommand is deprecated and will be removed in a future release.
 		log.Fatalf("Failed to mark `tag` flag for `validate` subcommand as required")
 	}
 
-	bundleValidateCmd.Flags().StringVarP(&containerTool, "image-builder", "b", "docker", "Tool used to pull and unpack bundle images. One of: [none, docker, podman]")
-	bundleValidateCmd.Flags().StringVarP(&optional, "optional-validators", "o", "", "Specifies optional validations to be run. One or more of: [operatorhub, bundle-objects]")
+	bundleValidateCmd.Flags().StringVarP(&containerTool, "image-builder", "b", "docker", "Tool used to pull and unpack bundle images. One of: [none, docker, podman, nerdctl, crane]")
+	bundleValidateCmd.Flags().StringVarP(&optional, "optional-validators", "o", "", "Specifies optional validations to be run. One or more of: [operatorhub, operatorhub-ui, bundle-objects, deprecated-apis]")
+	bundleValidateCmd.Flags().StringVar(&k8sVersion, "k8s-version", "", "Kubernetes version that the deprecated-apis validator checks bundles against (e.g. 1.25); defaults to "+validation.DefaultKubernetesVersion)
 
 	return bundleValidateCmd
 }
@@ -64,12 +71,17 @@ func validateFunc(cmd *cobra.Command, _ []string) error {
 		err      error
 	)
 
+	clientConfig, err := util.GetClientConfig(cmd)
+	if err != nil {
+		return err
+	}
+
 	tool := containertools.NewContainerTool(containerTool, containertools.NoneTool)
 	switch tool {
-	case containertools.PodmanTool, containertools.DockerTool:
-		registry, err = execregistry.NewRegistry(tool, logger)
+	case containertools.PodmanTool, containertools.DockerTool, containertools.NerdctlTool, containertools.CraneTool:
+		registry, err = execregistry.NewRegistryWithClientConfig(tool, logger, clientConfig)
 	case containertools.NoneTool:
-		registry, err = containerdregistry.NewRegistry(containerdregistry.WithLog(logger))
+		registry, err = containerdregistry.NewRegistry(containerdregistry.WithClientConfig(clientConfig), containerdregistry.WithLog(logger))
 	default:
 		err = fmt.Errorf("unrecognized container-tool option: %s", containerTool)
 	}
@@ -77,7 +89,24 @@ func validateFunc(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return err
 	}
-	imageValidator := bundle.NewImageValidator(registry, logger, optional)
+
+	// Images are probed with the containerd registry client, which reads
+	// registry credentials from the docker config, whichever tool pulls
+	// the bundle.
+	prober, ok := registry.(bundle.ImageProber)
+	if !ok && strings.Contains(optional, "operatorhub-ui") {
+		probingRegistry, err := containerdregistry.NewRegistry(containerdregistry.WithClientConfig(clientConfig), containerdregistry.WithLog(logger))
+		if err != nil {
+			return err
+		}
+		defer probingRegistry.Destroy()
+		prober = probingRegistry
+	}
+	options := []string{optional}
+	if k8sVersion != "" {
+		options = append(options, "deprecated-apis", bundle.K8sVersionOption+"="+k8sVersion)
+	}
+	imageValidator := bundle.NewImageProbingValidator(registry, prober, logger, options...)
 
 	dir, err := os.MkdirTemp("", "bundle-")
 	logger.Infof("Create a temp directory at %s", dir)
@@ -0,0 +1,322 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Maintain file-based catalog directories",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newGCCmd(), newAddBundleCmd(), newRmBundleCmd(), newPopulateCmd())
+	return cmd
+}
+
+func newGCCmd() *cobra.Command {
+	var (
+		gc     action.GarbageCollect
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "gc <catalogDir>",
+		Short: "Find and remove bundles that are unreachable from any channel",
+		Long: `Find bundles in a file-based catalog directory that are not an entry of any
+channel in their package. Such bundles can never be installed or upgraded to,
+and are usually left over from channel edits.
+
+By default, unreachable bundles are only reported. With --apply, they are
+removed, along with any deprecation entries that reference them. Each file
+that contains a removed bundle or such a deprecation is rewritten in its
+original format. Files left empty are deleted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (table|json)", output)
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			gc.CatalogDir = args[0]
+			res, err := gc.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				err = enc.Encode(res)
+			} else if len(res.Unreachable) == 0 {
+				fmt.Fprintln(os.Stderr, "no unreachable bundles found")
+				return nil
+			} else {
+				err = res.WriteColumns(os.Stdout)
+			}
+			if err != nil {
+				return err
+			}
+			if res.Removed {
+				fmt.Fprintf(os.Stderr, "removed %d unreachable bundle(s)\n", len(res.Unreachable))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&gc.Apply, "apply", false, "Remove the unreachable bundles from the catalog")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	return cmd
+}
+
+func newAddBundleCmd() *cobra.Command {
+	var add action.AddBundle
+	cmd := &cobra.Command{
+		Use:   "add-bundle <catalogDir> <bundleRef>",
+		Short: "Add a bundle to a package of a file-based catalog directory",
+		Long: `Add a bundle, rendered from a bundle image or directory, to its package in a
+file-based catalog directory. Each file that contains the package, a changed
+channel, or the bundle is rewritten in its original format.
+
+A new bundle is added to the channels set with --channels, with the replaces,
+skips and skipRange of its CSV. Channels that do not exist are created in the
+file of the package, along with the bundle.
+
+Adding a bundle that is already in the package fails, unless --overwrite-latest
+is set and the bundle is the head of every channel it is in. Its content, e.g.
+its image, is then replaced, and its entry in each of those channels is rebuilt
+from the replaces, skips and skipRange of its new CSV. It is also added to the
+channels set with --channels that it is not in yet. If --audit-dir is set, the
+previous content of the bundle is written to a file in that directory, named
+after the bundle and the time it was overwritten.
+
+The package is validated with the added bundle before any file is rewritten,
+so a bundle that breaks the upgrade graph of a channel is not added.
+
+With --dry-run, the bundle is validated, and a report of the changes to the
+upgrade graphs of the package is printed to stdout: the new and changed channel
+entries, the entries that are removed, the channel heads that are replaced, and
+the upgrade edges that are added or removed. No file is changed.`,
+		Example: `
+# Add a new bundle to the stable channel
+$ opm alpha catalog add-bundle catalog quay.io/example/foo-bundle:v0.3.0 --channels stable
+
+# Show how adding a bundle would change the upgrade graphs of its package
+$ opm alpha catalog add-bundle catalog quay.io/example/foo-bundle:v0.3.0 --channels stable,fast --dry-run
+
+# Replace the latest bundle of a package with a rebuild of it
+$ opm alpha catalog add-bundle catalog quay.io/example/foo-bundle:v0.3.0 --overwrite-latest --audit-dir audit
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			render := action.Render{
+				Refs:           []string{args[1]},
+				Registry:       reg,
+				AllowedRefMask: action.RefBundleImage | action.RefBundleDir,
+			}
+			cfg, err := render.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if len(cfg.Bundles) != 1 {
+				return fmt.Errorf("expected %q to be a single bundle, found %d bundles", args[1], len(cfg.Bundles))
+			}
+
+			add.CatalogDir = args[0]
+			add.Bundle = cfg.Bundles[0]
+			res, err := add.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if add.DryRun {
+				fmt.Print(res.Impact.String())
+				fmt.Fprintf(os.Stderr, "dry run: bundle %q was not added to %q\n", res.Bundle, res.File)
+				return nil
+			}
+			if res.Previous != nil {
+				fmt.Fprintf(os.Stderr, "overwrote bundle %q in %q, previous image %q\n", res.Bundle, res.File, res.Previous.Image)
+				if res.AuditFile != "" {
+					fmt.Fprintf(os.Stderr, "recorded previous bundle in %q\n", res.AuditFile)
+				}
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "added bundle %q to %q\n", res.Bundle, res.File)
+			for _, c := range res.Channels {
+				fmt.Fprintf(os.Stderr, "added bundle %q to channel %q\n", res.Bundle, c)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&add.Channels, "channels", nil, "Comma separated list of channels to add the bundle to")
+	cmd.Flags().BoolVar(&add.OverwriteLatest, "overwrite-latest", false, "Overwrite a bundle that is already in the package, if it is the head of every channel it is in")
+	cmd.Flags().StringVar(&add.AuditDir, "audit-dir", "", "Directory to record the previous content of an overwritten bundle in")
+	cmd.Flags().BoolVar(&add.DryRun, "dry-run", false, "Validate the bundle and print the changes to the upgrade graphs of its package, without changing any file")
+	return cmd
+}
+
+func newRmBundleCmd() *cobra.Command {
+	var rm action.RemoveBundles
+	cmd := &cobra.Command{
+		Use:   "rm-bundle <catalogDir>",
+		Short: "Remove bundles from a package of a file-based catalog directory",
+		Long: `Remove bundles from a package of a file-based catalog directory, along with
+their channel entries and any deprecation entries that reference them. Each
+file that contains a removed bundle, or a channel or deprecation of the
+package, is rewritten in its original format. Files left empty are deleted.
+
+By default, the upgrade graph is rewired around the removed bundles: channel
+entries that replaced a removed bundle instead replace the bundle that it
+replaced. With --force-truncate, the entries that a removed bundle replaces,
+directly or transitively, are removed as well, along with the bundles that are
+then no longer in any channel.
+
+Channels left without entries are removed. Removing every entry of the default
+channel of the package fails.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			rm.CatalogDir = args[0]
+			res, err := rm.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			for _, b := range res.Bundles {
+				fmt.Fprintf(os.Stderr, "removed bundle %q\n", b)
+			}
+			for _, c := range res.Channels {
+				fmt.Fprintf(os.Stderr, "removed channel %q\n", c)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&rm.Package, "package", "", "Package of the bundles to remove")
+	cmd.Flags().StringSliceVar(&rm.Bundles, "bundles", nil, "Comma separated list of names of the bundles to remove")
+	cmd.Flags().BoolVar(&rm.ForceTruncate, "force-truncate", false, "Also remove the bundles that the removed bundles replace, instead of rewiring the upgrade graph")
+	for _, f := range []string{"package", "bundles"} {
+		if err := cmd.MarkFlagRequired(f); err != nil {
+			log.Fatalf("Failed to mark `%s` flag for `rm-bundle` subcommand as required", f)
+		}
+	}
+	return cmd
+}
+
+func newPopulateCmd() *cobra.Command {
+	var (
+		populate             action.Populate
+		mode                 string
+		output               string
+		skipReferencedImages bool
+	)
+	cmd := &cobra.Command{
+		Use:   "populate <catalogDir> <image>=<bundleDir>...",
+		Short: "Add unpacked bundle directories to a file-based catalog with a graph update mode",
+		Long: `Add bundles, unpacked in directories, to a file-based catalog, and update
+the upgrade graphs of their channels with the same graph update modes as
+'opm registry add' uses for sqlite databases:
+
+  replaces          a bundle replaces and skips the bundles its CSV does
+  semver            a bundle is inserted in its channels by its version
+  semver-skippatch  as semver, and a bundle also skips the bundles of its
+                    channels with a lower patch version of the same minor
+                    version, which are removed from the channels
+
+Each bundle is given as the image that it is referenced by and the directory
+that it is unpacked in. The channels and default channel of each bundle are
+read from its annotations. Bundles are added in the order of their images.
+
+The populated catalog is validated and written to stdout. The catalog
+directory is not changed.`,
+		Example: `
+# Add two bundles to a catalog in semver mode
+$ opm alpha catalog populate catalog quay.io/example/foo-bundle:v0.2.0=foo-v0.2.0 \
+    quay.io/example/foo-bundle:v0.3.0=foo-v0.3.0 --mode semver -o yaml > catalog.yaml
+`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			switch output {
+			case "yaml":
+				write = declcfg.WriteYAML
+			case "json":
+				write = declcfg.WriteJSON
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+			if populate.Mode, err = registry.GetModeFromString(mode); err != nil {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "%v", err)
+			}
+			imageDirMap := map[image.Reference]string{}
+			for _, arg := range args[1:] {
+				ref, dir, ok := strings.Cut(arg, "=")
+				if !ok || ref == "" || dir == "" {
+					return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid bundle %q, expected <image>=<bundleDir>", arg)
+				}
+				imageDirMap[image.SimpleReference(ref)] = dir
+			}
+			if len(imageDirMap) != len(args)-1 {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "bundle images must be unique")
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle parser is verbose, even on the happy path, so
+			// discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			populate.Catalog, err = declcfg.LoadFS(cmd.Context(), os.DirFS(args[0]))
+			if err != nil {
+				return err
+			}
+			if populate.Bundles, err = action.LoadBundleDirs(imageDirMap, skipReferencedImages); err != nil {
+				return err
+			}
+			if err := populate.Run(); err != nil {
+				return err
+			}
+			if _, err := declcfg.ConvertToModel(*populate.Catalog); err != nil {
+				return liberrors.Errorf(liberrors.CodeInvalidCatalog, "invalid catalog: %v", err)
+			}
+			return write(*populate.Catalog, os.Stdout)
+		},
+	}
+	cmd.Flags().StringVar(&mode, "mode", "replaces", "Graph update mode that defines how channel graphs are updated. One of: [replaces, semver, semver-skippatch]")
+	cmd.Flags().BoolVar(&populate.Overwrite, "overwrite-latest", false, "Overwrite bundles that are already in the catalog, in replaces mode")
+	cmd.Flags().BoolVar(&skipReferencedImages, "skip-referenced-images", false, "Do not add the images referenced by RELATED_IMAGE_* environment variables and image annotations of bundle CSVs to their related images")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format of the streamed file-based catalog objects (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
+	return cmd
+}
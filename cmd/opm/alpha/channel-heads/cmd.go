@@ -0,0 +1,131 @@
+package channelheads
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		packages []string
+		output   string
+	)
+	cmd := &cobra.Command{
+		Use:   "channel-heads <fbcRef>",
+		Short: "Report the heads of the channels of a file-based catalog",
+		Long: `Report the heads of the channels of a file-based catalog.
+
+The head of a channel is its entry that no other entry replaces or skips. Heads
+are computed from the channel entries alone, so the catalog does not have to be
+valid, and its bundles are not checked. A channel that does not have exactly
+one head is reported with its problems: the replaces chain from each of its
+heads, which typically shows a missing or misspelled replaces, and any cycles of
+replaces.
+
+The reference may be a file-based catalog directory, file, archive, git
+repository, serving registry, or image. The command exits with a non-zero
+status if any channel has problems.`,
+		Example: `
+# Report the heads of the channels of a catalog directory
+$ opm alpha channel-heads ./catalog
+
+# Report the heads of the channels of one package of a catalog image, as JSON
+$ opm alpha channel-heads quay.io/operatorhubio/catalog:latest -p etcd -o json
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (table|json)", output)
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
+			if len(packages) > 0 {
+				loadRefOpts = append(loadRefOpts, declcfg.WithLoadOptions(declcfg.WithPackages(packages...)))
+			}
+
+			cfg, err := declcfg.LoadRef(cmd.Context(), args[0], loadRefOpts...)
+			if err != nil {
+				return err
+			}
+			heads := declcfg.ChannelHeads(cfg.Channels)
+
+			if output == "json" {
+				err = writeJSON(os.Stdout, heads)
+			} else {
+				err = writeColumns(os.Stdout, heads)
+			}
+			if err != nil {
+				return err
+			}
+			invalid := 0
+			for _, h := range heads {
+				if !h.Valid() {
+					invalid++
+				}
+			}
+			if invalid > 0 {
+				return liberrors.Errorf(liberrors.CodeInvalidCatalog, "%d channels have problems", invalid)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVarP(&packages, "package", "p", nil, "only report the channels of these packages (default: all packages)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	return cmd
+}
+
+func writeJSON(w io.Writer, heads []declcfg.ChannelHead) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(heads)
+}
+
+// writeColumns writes a row for each channel, followed by a row for each of
+// its problems after the first.
+func writeColumns(w io.Writer, heads []declcfg.ChannelHead) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "PACKAGE\tCHANNEL\tHEADS\tPROBLEMS"); err != nil {
+		return err
+	}
+	for _, h := range heads {
+		problems := append([]string{}, h.Problems...)
+		if len(problems) == 0 {
+			problems = []string{""}
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", h.Package, h.Channel, strings.Join(h.Heads, ","), problems[0]); err != nil {
+			return err
+		}
+		for _, p := range problems[1:] {
+			if _, err := fmt.Fprintf(tw, "\t\t\t%s\n", p); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Flush()
+}
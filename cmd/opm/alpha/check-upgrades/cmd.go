@@ -0,0 +1,148 @@
+package checkupgrades
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+var csvGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+
+func NewCmd() *cobra.Command {
+	logger := log.New()
+	var (
+		kubeconfig string
+		namespace  string
+		output     string
+	)
+	cmd := &cobra.Command{
+		Use:   "check-upgrades <indexRef>",
+		Short: "Report upgrades a catalog offers for the operators installed on a cluster",
+		Long: `Report upgrades a catalog offers for the operators installed on a cluster.
+
+The ClusterServiceVersions installed on the cluster are read using the current
+kubeconfig context and matched by name against the bundles in the catalog. For
+each installed CSV, the command reports the upgrade edges available in the
+catalog's channels, whether the CSV or its package is missing from the catalog,
+and any deprecations that apply to the installed version.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				logger.Fatalf("invalid --output value %q, expected (table|json)", output)
+			}
+
+			installed, err := listInstalledCSVs(cmd.Context(), kubeconfig, namespace)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			check := action.CheckUpgrades{
+				IndexReference: args[0],
+				Registry:       reg,
+				LoadRefOptions: loadRefOpts,
+				Installed:      installed,
+			}
+			res, err := check.Run(cmd.Context())
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			if output == "json" {
+				err = res.WriteJSON(os.Stdout)
+			} else {
+				err = res.WriteColumns(os.Stdout)
+			}
+			if err != nil {
+				logger.Fatal(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file (defaults to $KUBECONFIG or ~/.kube/config)")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Only check CSVs installed in this namespace (default: all namespaces)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	return cmd
+}
+
+// listInstalledCSVs lists the CSVs installed in namespace, or in all
+// namespaces if namespace is empty. CSVs copied by OLM into the target
+// namespaces of an operator group are ignored.
+func listInstalledCSVs(ctx context.Context, kubeconfig, namespace string) ([]action.InstalledCSV, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %v", err)
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := client.Resource(csvGVR).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: "!" + v1alpha1.CopiedLabelKey})
+	if err != nil {
+		return nil, fmt.Errorf("list cluster service versions: %v", err)
+	}
+
+	installed := make([]action.InstalledCSV, 0, len(list.Items))
+	for _, item := range list.Items {
+		var csv v1alpha1.ClusterServiceVersion
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &csv); err != nil {
+			return nil, fmt.Errorf("decode cluster service version %s/%s: %v", item.GetNamespace(), item.GetName(), err)
+		}
+		var version string
+		if csv.Spec.Version.Version.String() != "0.0.0" {
+			version = csv.Spec.Version.String()
+		}
+		installed = append(installed, action.InstalledCSV{
+			Namespace: csv.Namespace,
+			Name:      csv.Name,
+			Version:   version,
+			Package:   packageFromLabels(csv.Labels, csv.Namespace),
+		})
+	}
+	return installed, nil
+}
+
+// packageFromLabels returns the package name recorded by OLM in the
+// "operators.coreos.com/<package>.<namespace>" label of an installed CSV.
+func packageFromLabels(labels map[string]string, namespace string) string {
+	const prefix = "operators.coreos.com/"
+	for k := range labels {
+		if name, ok := strings.CutPrefix(k, prefix); ok {
+			if pkg, ok := strings.CutSuffix(name, "."+namespace); ok {
+				return pkg
+			}
+		}
+	}
+	return ""
+}
@@ -4,9 +4,24 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/bundle"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/catalog"
+	channelheads "github.com/operator-framework/operator-registry/cmd/opm/alpha/channel-heads"
+	checkupgrades "github.com/operator-framework/operator-registry/cmd/opm/alpha/check-upgrades"
+	convertappregistry "github.com/operator-framework/operator-registry/cmd/opm/alpha/convert-appregistry"
+	convertbundleobjects "github.com/operator-framework/operator-registry/cmd/opm/alpha/convert-bundle-objects"
 	converttemplate "github.com/operator-framework/operator-registry/cmd/opm/alpha/convert-template"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/diff"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/fbc"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/filter"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/generate"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/hash"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/lint"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/list"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/migrations"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/overlay"
 	rendergraph "github.com/operator-framework/operator-registry/cmd/opm/alpha/render-graph"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/resolve"
+	"github.com/operator-framework/operator-registry/cmd/opm/alpha/schema"
 	"github.com/operator-framework/operator-registry/cmd/opm/alpha/template"
 )
 
@@ -24,10 +39,26 @@ func NewCmd(showAlphaHelp bool) *cobra.Command {
 
 	runCmd.AddCommand(
 		bundle.NewCmd(),
+		catalog.NewCmd(),
+		channelheads.NewCmd(),
+		checkupgrades.NewCmd(),
+		diff.NewCmd(),
+		fbc.NewCmd(),
+		filter.NewCmd(),
+		hash.NewCmd(),
+		lint.NewCmd(),
 		list.NewCmd(),
+		migrations.NewCmd(),
+		overlay.NewCmd(),
 		rendergraph.NewCmd(),
+		resolve.NewCmd(),
+		schema.NewCmd(),
 		template.NewCmd(),
+		template.NewTemplateCmd(),
 		converttemplate.NewCmd(),
+		convertbundleobjects.NewCmd(),
+		convertappregistry.NewCmd(),
+		generate.NewCmd(),
 	)
 	return runCmd
 }
@@ -0,0 +1,80 @@
+package convertappregistry
+
+import (
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		convert action.ConvertAppRegistry
+		output  string
+	)
+	cmd := &cobra.Command{
+		Use:   "convert-appregistry <manifests-dir>",
+		Short: "Convert operator manifests downloaded from an appregistry to a file-based catalog",
+		Long: `Convert a directory of operator manifests downloaded from an appregistry to a
+file-based catalog, so that catalogs served by the deprecated
+appregistry-server can be migrated. The converted catalog is written to stdout.
+
+The directory can hold manifests in both of the appregistry formats:
+
+  nested     a directory per package, with a package manifest and a directory
+             per version that holds a CSV and its CRDs
+  flattened  files whose data holds "packages", "clusterServiceVersions" and
+             "customResourceDefinitions" lists
+
+Channels are inferred from the package manifests: each channel starts at its
+currentCSV and follows the replaces and skips of the CSVs. Converted bundles
+have no image, and embed their manifests as olm.bundle.object properties. The
+converted catalog is validated before it is written.
+`,
+		Example: `  # Convert downloaded manifests to a file-based catalog directory
+  mkdir -p catalog
+  opm alpha convert-appregistry ./downloaded -o yaml > catalog/index.yaml
+  opm validate catalog`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convert.ManifestsDir = args[0]
+
+			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			switch output {
+			case "yaml":
+				write = declcfg.WriteYAML
+			case "json":
+				write = declcfg.WriteJSON
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The manifest loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs. Any important failures will be
+			// returned from convert.Run and reported as the error of the command.
+			logrus.SetOutput(io.Discard)
+
+			cfg, err := convert.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return write(*cfg, os.Stdout)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format of the streamed file-based catalog objects (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
+	return cmd
+}
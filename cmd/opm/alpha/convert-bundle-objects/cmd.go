@@ -0,0 +1,83 @@
+package convertbundleobjects
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		convert action.ConvertBundleObjects
+		output  string
+	)
+	cmd := &cobra.Command{
+		Use:   "convert-bundle-objects <catalog-dir>",
+		Short: "Replace the olm.bundle.object properties of bundles with olm.csv.metadata",
+		Long: `Shrink a file-based catalog directory in place, by replacing the
+olm.bundle.object properties of its bundles, which embed all of the manifests
+of the bundles, with an olm.csv.metadata property that holds only the metadata
+of their ClusterServiceVersion. Registries serve the same CSV metadata for
+converted bundles, and clients pull the manifests from the bundle images.
+
+Each conversion is validated by expanding the new property into a CSV, as
+registries do, and checking that the metadata of the original CSV is kept.
+Bundles that already have an olm.csv.metadata property are left as is.
+
+The files of the catalog keep their layout and format, and only the files that
+contain converted bundles are rewritten. Rewritten YAML files lose their
+comments. This is the bundle-object-to-csv-metadata migration of
+'opm alpha fbc migrate'.
+`,
+		Example: `  # Report the bundles that would be converted, and the resulting file sizes
+  opm alpha convert-bundle-objects ./catalog --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			convert.CatalogDir = args[0]
+			if output != "table" && output != "json" {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (table|json)", output)
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			res, err := convert.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				return enc.Encode(res)
+			}
+			verb := "converted"
+			if convert.DryRun {
+				verb = "would convert"
+			}
+			for _, b := range res.Bundles {
+				fmt.Fprintf(os.Stdout, "%s %s\n", verb, b)
+			}
+			for _, f := range res.Files {
+				fmt.Fprintf(os.Stdout, "%s: %d -> %d bytes\n", f.Path, f.OldSize, f.NewSize)
+			}
+			if len(res.Bundles) == 0 {
+				fmt.Fprintln(os.Stderr, "no bundles to convert")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&convert.DryRun, "dry-run", false, "Report the bundles that would be converted without rewriting any file")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	return cmd
+}
@@ -2,12 +2,12 @@ package converttemplate
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/alpha/template/converter"
 	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 )
 
 func NewCmd() *cobra.Command {
@@ -41,7 +41,7 @@ If no argument is specified or is '-' input is assumed from STDIN.
 			case "yaml", "json":
 				converter.OutputFormat = output
 			default:
-				log.Fatalf("invalid --output value %q, expected (json|yaml)", output)
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
 			}
 
 			reader, name, err := util.OpenFileOrStdin(c, args)
@@ -52,7 +52,7 @@ If no argument is specified or is '-' input is assumed from STDIN.
 			converter.FbcReader = reader
 			err = converter.Convert()
 			if err != nil {
-				return fmt.Errorf("converting: %v", err)
+				return fmt.Errorf("converting: %w", err)
 			}
 
 			return nil
@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		output      string
+		removedFile string
+	)
+	cmd := &cobra.Command{
+		Use:   "diff <old-catalog> <new-catalog>",
+		Short: "Compute the changes between two file-based catalogs",
+		Long: `Compute the changes between two catalogs.
+
+Each catalog can be a catalog image, a file-based catalog directory, archive,
+git repository, or serving registry, or a sqlite database file or image.
+
+The objects of the new catalog that are not in the old catalog, or that differ
+from their counterpart in it, are written to stdout as a file-based catalog
+fragment. Objects are matched by schema, package, and name, and are always
+written in full, e.g. a channel with a new entry is written with all of its
+entries.
+
+If --removed-file is set, a JSON summary of the objects of the old catalog that
+are not in the new catalog is written to that file.
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			switch output {
+			case "yaml":
+				write = declcfg.WriteYAML
+			case "json":
+				write = declcfg.WriteJSON
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs. Any important failures will be
+			// returned from diff.Run and reported as the error of the command.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
+
+			diff := action.Diff{
+				OldRef:         args[0],
+				NewRef:         args[1],
+				Registry:       reg,
+				LoadRefOptions: loadRefOpts,
+			}
+			res, err := diff.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := write(*res.Changed, os.Stdout); err != nil {
+				return err
+			}
+
+			if removedFile != "" {
+				if err := writeRemoved(res, removedFile); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format of the streamed file-based catalog objects (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
+	cmd.Flags().StringVar(&removedFile, "removed-file", "", "If set, write a JSON summary of the objects removed from the old catalog to this file")
+	return cmd
+}
+
+func writeRemoved(res *action.DiffResult, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return res.WriteRemovedJSON(f)
+}
@@ -0,0 +1,87 @@
+package fbc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fbc",
+		Short: "Maintain file-based catalogs",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newMigrateCmd())
+	return cmd
+}
+
+func newMigrateCmd() *cobra.Command {
+	var (
+		migrate      action.MigrateFBC
+		migrateLevel string
+		since        string
+		until        string
+		subset       []string
+	)
+	logger := log.New()
+	cmd := &cobra.Command{
+		Use:   "migrate <catalog-dir>",
+		Short: "Upgrade a file-based catalog directory in place",
+		Long: `Upgrade a file-based catalog directory in place by running schema migrations
+on its objects, e.g. to replace the olm.bundle.object properties of bundles
+with olm.csv.metadata properties.
+
+By default, all migrations are run. The files of the catalog keep their layout
+and format: each file is migrated on its own, and only the files that the
+migrations change are rewritten. YAML files keep their comments.
+The names of the rewritten files are printed to stdout.
+` + migrations.HelpText(),
+		Example: `  # Run all migrations on a catalog
+  opm alpha fbc migrate ./catalog
+
+  # List the files that one migration would change, without changing them
+  opm alpha fbc migrate ./catalog --migrations bundle-object-to-csv-metadata --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrate.CatalogDir = args[0]
+
+			m, err := util.SelectMigrations(migrateLevel, since, until, subset)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			if m == nil {
+				if m, err = migrations.NewMigrations(migrations.AllMigrations); err != nil {
+					logger.Fatal(err)
+				}
+			}
+			migrate.Migrations = m
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			changed, err := migrate.Run(cmd.Context())
+			if err != nil {
+				logger.Fatal(err)
+			}
+			for _, path := range changed {
+				fmt.Fprintln(cmd.OutOrStdout(), path)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&migrateLevel, "migrate-level", "", "Name of the last migration to run (default: all)")
+	cmd.Flags().StringVar(&since, "since", "", "Only run migrations that follow the named migration, e.g. to resume a staged upgrade")
+	cmd.Flags().StringVar(&until, "until", "", "Name of the last migration to run, for use with --since (equivalent to --migrate-level)")
+	cmd.Flags().StringSliceVar(&subset, "migrations", nil, "Comma-separated names of the migrations to run. They are always run in the order listed by \"opm alpha migrations list\"")
+	cmd.Flags().BoolVar(&migrate.DryRun, "dry-run", false, "Print the files that would be rewritten without rewriting them")
+	return cmd
+}
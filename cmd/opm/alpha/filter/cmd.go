@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		filter action.Filter
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "filter <catalog>... --filter <term>...",
+		Short: "Render catalogs and keep only the bundles that match a filter",
+		Long: `Render catalogs and keep only the bundles that match all of the --filter
+terms. The filtered catalog is written to stdout. Channel entries of removed
+bundles, channels and packages left without bundles, and deprecations of
+removed objects are removed too. Filtering fails if it removes the default
+channel of a package that is kept.
+
+Each catalog can be a catalog image, a file-based catalog directory, archive,
+git repository, or serving registry, or a sqlite database file or image.
+
+Each term has the form key=value, or key!=value to keep the bundles that do
+not match it:
+
+  package=<name>                     the bundle is of the package
+  version=<range>                    the package version of the bundle is in the
+                                     semver range, e.g. ">=1.0.0 <2.0.0"
+  provides=<group>/<version>/<kind>  the bundle provides the API
+  requires=<group>/<version>/<kind>  the bundle requires the API
+  property=<type>[=<value>]          the bundle has a property of the type, with
+                                     the JSON or string value if set
+  annotation=<key>[=<value>]         the CSV of the bundle has the annotation
+  infrastructure-feature=<name>      the CSV of the bundle declares support for
+                                     the infrastructure feature
+`,
+		Example: `  # Keep the 1.x bundles that provide an API and support disconnected clusters
+  opm alpha filter ./catalog \
+    --filter provides=example.com/v1/Widget \
+    --filter 'version=>=1.0.0 <2.0.0' \
+    --filter infrastructure-feature=disconnected -o yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter.Refs = args
+
+			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			switch output {
+			case "yaml":
+				write = declcfg.WriteYAML
+			case "json":
+				write = declcfg.WriteJSON
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs. Any important failures will be
+			// returned from filter.Run and reported as the error of the command.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			filter.Registry = reg
+			filter.LoadRefOptions, err = util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := filter.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return write(*cfg, os.Stdout)
+		},
+	}
+	cmd.Flags().StringArrayVar(&filter.Terms, "filter", nil, "Filter terms that the kept bundles must all match")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format of the streamed file-based catalog objects (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
+	if err := cmd.MarkFlagRequired("filter"); err != nil {
+		log.Fatalf("Failed to mark `filter` flag for `filter` subcommand as required")
+	}
+	return cmd
+}
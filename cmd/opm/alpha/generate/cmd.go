@@ -0,0 +1,231 @@
+package generate
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate manifests to deploy catalog images",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(
+		newCatalogSourceCmd(),
+		newClusterCatalogCmd(),
+		newMirrorManifestsCmd(),
+		newSBOMCmd(),
+	)
+	return cmd
+}
+
+func newCatalogSourceCmd() *cobra.Command {
+	gen := action.GenerateCatalogSource{Writer: os.Stdout}
+	cmd := &cobra.Command{
+		Use:   "catalogsource",
+		Short: "Generate an OLMv0 CatalogSource manifest for a catalog image",
+		Long: `Generate an OLMv0 CatalogSource manifest for a catalog image and print it to
+stdout, ready to be applied to a cluster.
+
+If --name is not set, the name is derived from the image repository.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return gen.Run()
+		},
+	}
+	cmd.Flags().StringVar(&gen.Image, "image", "", "Catalog image reference")
+	cmd.Flags().StringVar(&gen.Name, "name", "", "Name of the CatalogSource")
+	cmd.Flags().StringVarP(&gen.Namespace, "namespace", "n", "olm", "Namespace of the CatalogSource")
+	cmd.Flags().StringVar(&gen.DisplayName, "display-name", "", "Display name of the catalog")
+	cmd.Flags().StringVar(&gen.Publisher, "publisher", "", "Publisher of the catalog")
+	cmd.Flags().IntVar(&gen.Priority, "priority", 0, "Priority of the catalog, used to break ties during dependency resolution")
+	cmd.Flags().DurationVar(&gen.PollInterval, "poll-interval", 0, "Interval at which to poll the image for updates (0 disables polling)")
+	if err := cmd.MarkFlagRequired("image"); err != nil {
+		log.Fatal(err)
+	}
+	return cmd
+}
+
+func newClusterCatalogCmd() *cobra.Command {
+	gen := action.GenerateClusterCatalog{Writer: os.Stdout}
+	cmd := &cobra.Command{
+		Use:   "clustercatalog",
+		Short: "Generate an OLMv1 ClusterCatalog manifest for a catalog image",
+		Long: `Generate an OLMv1 ClusterCatalog manifest for a catalog image and print it to
+stdout, ready to be applied to a cluster.
+
+If --name is not set, the name is derived from the image repository.
+
+The poll interval must be a whole number of minutes and cannot be used with
+digest-based image references.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return gen.Run()
+		},
+	}
+	cmd.Flags().StringVar(&gen.Image, "image", "", "Catalog image reference")
+	cmd.Flags().StringVar(&gen.Name, "name", "", "Name of the ClusterCatalog")
+	cmd.Flags().Int32Var(&gen.Priority, "priority", 0, "Priority of the catalog, used to break ties when the same bundle is provided by multiple catalogs")
+	cmd.Flags().DurationVar(&gen.PollInterval, "poll-interval", 0, "Interval at which to poll the image for updates (0 disables polling)")
+	if err := cmd.MarkFlagRequired("image"); err != nil {
+		log.Fatal(err)
+	}
+	return cmd
+}
+
+func newMirrorManifestsCmd() *cobra.Command {
+	gen := action.GenerateMirrorManifests{ManifestWriter: os.Stdout}
+	var mappingFile string
+	cmd := &cobra.Command{
+		Use:   "mirror-manifests <catalogRef>",
+		Short: "Generate the image mapping and mirror manifests to mirror a catalog",
+		Long: `Generate the image mapping and mirror manifests needed to install the
+operators of a catalog in a disconnected cluster, from a registry that its
+images are mirrored to.
+
+The images of the catalog are its bundle images and their related images, and
+the catalog image itself, if the catalog reference is an image. The repository
+of each image is mirrored to the same path under --mirror, without its
+registry. The mapping of each image to its mirror is written to --mapping-file,
+one "source=mirror" line per image, in the format of "oc image mirror -f".
+
+An ImageDigestMirrorSet for the repositories of images referenced by digest,
+and an ImageTagMirrorSet for those referenced by tag, are printed to stdout, ready to be applied to the cluster. With --icsp, an
+ImageContentSourcePolicy is printed instead, for clusters that do not support
+mirror sets. It only applies to images referenced by digest.`,
+		Example: `
+# Mirror the images of a catalog image, and configure a cluster to pull them
+# from the mirror
+$ opm alpha generate mirror-manifests quay.io/example/catalog:latest \
+    --mirror mirror.example.com:5000/olm > mirror-sets.yaml
+$ oc image mirror -f mapping.txt
+$ oc apply -f mirror-sets.yaml
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
+			cfg, err := declcfg.LoadRef(cmd.Context(), args[0], loadRefOpts...)
+			if err != nil {
+				return err
+			}
+			gen.Catalog = *cfg
+			if src, err := declcfg.DetectRefSource(args[0], loadRefOpts...); err == nil && src.Name() == declcfg.RefSourceImage {
+				gen.Images = append(gen.Images, args[0])
+			}
+
+			f, err := os.Create(mappingFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			gen.MappingWriter = f
+			return gen.Run()
+		},
+	}
+	cmd.Flags().StringVar(&gen.Mirror, "mirror", "", "Registry to mirror images to, optionally followed by a namespace, e.g. mirror.example.com:5000/olm")
+	cmd.Flags().StringVar(&gen.Name, "name", "catalog-mirror", "Name of the mirror sets, or of the ImageContentSourcePolicy")
+	cmd.Flags().BoolVar(&gen.ICSP, "icsp", false, "Generate an ImageContentSourcePolicy instead of mirror sets")
+	cmd.Flags().StringVar(&mappingFile, "mapping-file", "mapping.txt", "File to write the image mapping to")
+	if err := cmd.MarkFlagRequired("mirror"); err != nil {
+		log.Fatal(err)
+	}
+	return cmd
+}
+
+func newSBOMCmd() *cobra.Command {
+	gen := action.GenerateSBOM{Writer: os.Stdout}
+	var (
+		format         string
+		resolveDigests bool
+	)
+	cmd := &cobra.Command{
+		Use:   "sbom <catalogRef>",
+		Short: "Generate a software bill of materials of a catalog",
+		Long: `Generate a software bill of materials (SBOM) of a catalog, for supply chain
+tooling, and print it to stdout.
+
+The SBOM lists every bundle of the catalog, with its package and version, the
+images of each bundle, i.e. its bundle image and related images, and the
+dependencies between bundles: a bundle depends on the bundles of the catalog
+that satisfy its required packages, and on those of other packages that provide
+its required APIs.
+
+Images are identified by digest. Images that are referenced by tag only have a
+digest if --resolve-digests is set, which resolves it from their registries.
+
+The SBOM is written as an SPDX 2.3 document or a CycloneDX 1.5 BOM, in JSON,
+depending on --format.`,
+		Example: `
+# Generate an SPDX SBOM of a catalog image
+$ opm alpha generate sbom quay.io/example/catalog:latest > catalog.spdx.json
+
+# Generate a CycloneDX SBOM of a catalog directory, resolving image digests
+$ opm alpha generate sbom ./catalog --format cyclonedx --resolve-digests > catalog.cdx.json
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
+			cfg, err := declcfg.LoadRef(cmd.Context(), args[0], loadRefOpts...)
+			if err != nil {
+				return err
+			}
+			gen.Catalog = *cfg
+			gen.Format = action.SBOMFormat(format)
+			if gen.Name == "" {
+				gen.Name = args[0]
+			}
+			if resolveDigests {
+				gen.ResolveDigest = func(ctx context.Context, img string) (digest.Digest, error) {
+					return reg.Digest(ctx, image.SimpleReference(img))
+				}
+			}
+			return gen.Run(cmd.Context())
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", string(action.SBOMFormatSPDX), "Format of the SBOM (spdx|cyclonedx)")
+	cmd.Flags().StringVar(&gen.Name, "name", "", "Name of the catalog in the SBOM (default: the catalog reference)")
+	cmd.Flags().BoolVar(&resolveDigests, "resolve-digests", false, "Resolve the digests of images that are referenced by tag from their registries")
+	return cmd
+}
@@ -0,0 +1,72 @@
+package hash
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func NewCmd() *cobra.Command {
+	logger := log.New()
+	var packages []string
+	cmd := &cobra.Command{
+		Use:   "hash <fbcRef>",
+		Short: "Print a content hash of a file-based catalog",
+		Long: `Print a content hash of a file-based catalog.
+
+The hash is computed over the canonical form of the catalog, so catalogs with
+the same content have the same hash, however their objects are formatted,
+ordered, or split into files. It is the digest that catalog signatures cover,
+and the digest that registries report for the catalogs they serve. Pipelines
+can compare it with the hash of a previous build to skip rebuilding and pushing
+catalog images whose content has not changed.
+
+The reference may be a file-based catalog directory, file, archive, git
+repository, serving registry, or image.`,
+		Example: `
+# Print the hash of a catalog directory
+$ opm alpha hash ./catalog
+
+# Print the hash of one package of a catalog image
+$ opm alpha hash quay.io/operatorhubio/catalog:latest -p etcd
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			if len(packages) > 0 {
+				loadRefOpts = append(loadRefOpts, declcfg.WithLoadOptions(declcfg.WithPackages(packages...)))
+			}
+
+			cfg, err := declcfg.LoadRef(cmd.Context(), args[0], loadRefOpts...)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			hash, err := declcfg.Hash(*cfg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			fmt.Println(hash)
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVarP(&packages, "package", "p", nil, "only hash the objects of these packages (default: all packages)")
+	return cmd
+}
@@ -0,0 +1,102 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func NewCmd() *cobra.Command {
+	logger := log.New()
+	var (
+		output     string
+		skipRanges bool
+	)
+	cmd := &cobra.Command{
+		Use:   "lint <catalogRef>...",
+		Short: "Check the health of the upgrade graphs of catalogs",
+		Long: `Check the health of the upgrade graphs and metadata of catalogs, beyond
+what schema validation checks.
+
+Each finding is reported with one of the following codes:
+
+  UnreachableBundle      the bundle is not an entry of any channel
+  DanglingReplaces       the channel entry replaces a bundle that is not in the channel
+  UpgradeCycle           the replaces and skips edges of channel entries form a cycle
+  DeprecatedChannelHead  the head of a channel that is not deprecated is deprecated
+  MissingDefaultChannel  the package has no default channel, or it does not exist
+  DuplicateProperty      the bundle declares the same property more than once
+  InvalidSkipRange       the skipRange of the channel entry is not a valid semver range
+  UnmatchedSkipRange     the skipRange of the channel entry matches no other bundle of the channel
+  OverlappingSkipRanges  the skipRanges of two channel entries match the same bundles,
+                         but neither entry upgrades from the other
+
+With --skip-ranges, the bundles of the channel that the skipRange of each
+channel entry covers are reported too.
+
+The command exits with a non-zero status if there are any findings.`,
+		Example: `  # Lint a file-based catalog directory
+  opm alpha lint ./catalog
+
+  # Lint a catalog image, with machine-readable output
+  opm alpha lint quay.io/example/catalog:latest -o json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				logger.Fatalf("invalid --output value %q, expected (table|json)", output)
+			}
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			lint := action.Lint{
+				CatalogRefs:      args,
+				Registry:         reg,
+				LoadRefOptions:   loadRefOpts,
+				ReportSkipRanges: skipRanges,
+			}
+			res, err := lint.Run(cmd.Context())
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			if output == "json" {
+				err = res.WriteJSON(os.Stdout)
+			} else {
+				err = res.WriteColumns(os.Stdout)
+				if err == nil && skipRanges {
+					fmt.Fprintln(os.Stdout)
+					err = res.WriteSkipRangeColumns(os.Stdout)
+				}
+			}
+			if err != nil {
+				logger.Fatal(err)
+			}
+			if n := len(res.Findings); n > 0 {
+				logger.Fatalf("%d lint findings", n)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	cmd.Flags().BoolVar(&skipRanges, "skip-ranges", false, "Also report the bundles that the skipRange of each channel entry covers")
+	return cmd
+}
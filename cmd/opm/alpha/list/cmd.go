@@ -3,11 +3,11 @@ package list
 import (
 	"os"
 
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
 )
 
 const humanReadabilityOnlyNote = `NOTE: This is meant to be used for convenience and human-readability only. The
@@ -29,7 +29,7 @@ func NewCmd() *cobra.Command {
 }
 
 func newPackagesCmd() *cobra.Command {
-	logger := logrus.New()
+	logger := log.New()
 
 	return &cobra.Command{
 		Use:   "packages <indexRef>",
@@ -44,7 +44,11 @@ func newPackagesCmd() *cobra.Command {
 				logger.Fatal(err)
 			}
 			defer reg.Destroy()
-			lp := action.ListPackages{IndexReference: args[0], Registry: reg}
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			lp := action.ListPackages{IndexReference: args[0], Registry: reg, LoadRefOptions: loadRefOpts}
 			res, err := lp.Run(cmd.Context())
 			if err != nil {
 				logger.Fatal(err)
@@ -58,7 +62,7 @@ func newPackagesCmd() *cobra.Command {
 }
 
 func newChannelsCmd() *cobra.Command {
-	logger := logrus.New()
+	logger := log.New()
 
 	return &cobra.Command{
 		Use:   "channels <indexRef> [packageName]",
@@ -73,7 +77,11 @@ func newChannelsCmd() *cobra.Command {
 				logger.Fatal(err)
 			}
 			defer reg.Destroy()
-			lc := action.ListChannels{IndexReference: args[0], Registry: reg}
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			lc := action.ListChannels{IndexReference: args[0], Registry: reg, LoadRefOptions: loadRefOpts}
 			if len(args) > 1 {
 				lc.PackageName = args[1]
 			}
@@ -90,7 +98,7 @@ func newChannelsCmd() *cobra.Command {
 }
 
 func newBundlesCmd() *cobra.Command {
-	logger := logrus.New()
+	logger := log.New()
 
 	return &cobra.Command{
 		Use:   "bundles <indexRef> <packageName>",
@@ -107,7 +115,11 @@ for each channel in which the bundle is present).
 				logger.Fatal(err)
 			}
 			defer reg.Destroy()
-			lb := action.ListBundles{IndexReference: args[0], Registry: reg}
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			lb := action.ListBundles{IndexReference: args[0], Registry: reg, LoadRefOptions: loadRefOpts}
 			if len(args) > 1 {
 				lb.PackageName = args[1]
 			}
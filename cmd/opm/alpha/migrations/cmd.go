@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrations",
+		Short: "Inspect the available file-based catalog migrations",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newListCmd())
+	return cmd
+}
+
+func newListCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list [catalog-ref]",
+		Short: "List the available migrations",
+		Long: `List the available file-based catalog migrations, in the order in which they
+are applied.
+
+If a catalog reference is provided, the catalog is rendered and each migration
+is checked to determine whether it would change the catalog. The catalog itself
+is not modified.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (table|json)", output)
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			var cfg *declcfg.DeclarativeConfig
+			if len(args) == 1 {
+				// The bundle loading impl is somewhat verbose, even on the happy path,
+				// so discard all logrus default logger logs.
+				logrus.SetOutput(io.Discard)
+
+				reg, err := util.CreateCLIRegistry(cmd)
+				if err != nil {
+					return err
+				}
+				defer reg.Destroy()
+
+				loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+				if err != nil {
+					return err
+				}
+				render := action.Render{
+					Refs:           args,
+					Registry:       reg,
+					LoadRefOptions: loadRefOpts,
+				}
+				cfg, err = render.Run(cmd.Context())
+				if err != nil {
+					return err
+				}
+			}
+
+			infos, err := migrations.List(cfg)
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				return enc.Encode(infos)
+			}
+			return writeTable(infos, cfg != nil, os.Stdout)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	return cmd
+}
+
+func writeTable(infos []migrations.MigrationInfo, showApplicable bool, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	header := "NAME\tDESCRIPTION"
+	if showApplicable {
+		header += "\tAPPLICABLE"
+	}
+	if _, err := fmt.Fprintln(tw, header); err != nil {
+		return err
+	}
+	for _, info := range infos {
+		row := fmt.Sprintf("%s\t%s", info.Token, info.Description)
+		if showApplicable {
+			row += fmt.Sprintf("\t%t", *info.Applicable)
+		}
+		if _, err := fmt.Fprintln(tw, row); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
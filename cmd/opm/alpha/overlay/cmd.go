@@ -0,0 +1,114 @@
+package overlay
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		apply  action.ApplyOverlays
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "overlay <catalog>... --overlay <file-or-dir>...",
+		Short: "Render catalogs and patch the result with overlay files",
+		Long: `Render catalogs and patch the result with overlay files, so that changes to
+rendered catalogs, e.g. a new default channel, a skipRange on a channel entry,
+or an annotation of a bundle, can be kept in version control as declarative
+patches instead of scripts. The patched catalog is written to stdout.
+
+Each catalog can be a catalog image, a file-based catalog directory, archive,
+git repository, or serving registry, or a sqlite database file or image.
+
+An overlay file is a stream of YAML or JSON objects. Each object patches the
+catalog object with the same schema, package, and name. By default, its fields
+are merged into those of the catalog object: mappings are merged key by key,
+and null values delete their key. Sequence items are merged into the items
+with the same name or, for items without a name such as properties, into the
+equal item or else the only item of the same type. Other items are appended.
+
+The "$patch" field of an object or sequence item selects another operation:
+"replace" replaces the matching object or item, or adds it if there is none,
+and "delete" deletes it. A sequence whose first item is {"$patch": "replace"}
+replaces the patched sequence with its other items.
+
+Overlays are applied in the order of the --overlay flags, and the files of an
+overlay directory in the lexical order of their paths. Patching an object
+that does not exist fails.
+`,
+		Example: `  # Change the default channel of a package
+  cat > overlay.yaml <<EOF
+  schema: olm.package
+  name: foo
+  defaultChannel: fast
+  ---
+  schema: olm.channel
+  package: foo
+  name: fast
+  entries:
+    - name: foo.v1.2.0
+      skipRange: <1.2.0
+  EOF
+  opm alpha overlay ./catalog --overlay overlay.yaml -o yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apply.Refs = args
+
+			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			switch output {
+			case "yaml":
+				write = declcfg.WriteYAML
+			case "json":
+				write = declcfg.WriteJSON
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs. Any important failures will be
+			// returned from apply.Run and reported as the error of the command.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return err
+			}
+			defer reg.Destroy()
+			apply.Registry = reg
+			apply.LoadRefOptions, err = util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := apply.Run(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return write(*cfg, os.Stdout)
+		},
+	}
+	cmd.Flags().StringSliceVar(&apply.OverlayPaths, "overlay", nil, "Overlay files, or directories of them, to apply in order")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format of the streamed file-based catalog objects (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
+	if err := cmd.MarkFlagRequired("overlay"); err != nil {
+		log.Fatalf("Failed to mark `overlay` flag for `overlay` subcommand as required")
+	}
+	return cmd
+}
@@ -18,18 +18,30 @@ func NewCmd() *cobra.Command {
 		render               action.Render
 		minEdge              string
 		specifiedPackageName string
+		output               string
+		highlights           declcfg.GraphHighlights
 	)
 	cmd := &cobra.Command{
 		Use:   "render-graph [index-image | fbc-dir]",
-		Short: "Generate mermaid-formatted view of upgrade graph of operators in an index",
-		Long:  `Generate mermaid-formatted view of upgrade graphs of operators in an index`,
-		Args:  cobra.MinimumNArgs(1),
+		Short: "Generate a mermaid or graphviz view of upgrade graph of operators in an index",
+		Long: `Generate a mermaid or graphviz (DOT) view of upgrade graphs of operators in an index.
+
+Skip edges, edges covered by a skipRange, and deprecated packages, channels and
+bundles can be highlighted to make them stand out from replaces edges.`,
+		Args: cobra.MinimumNArgs(1),
 		Example: `
 #
 # Output channel graph of a catalog in mermaid format
 #
 $ opm alpha render-graph quay.io/operatorhubio/catalog:latest
 
+#
+# Output the channel graph of one package of a catalog in DOT format, highlighting skips and skipRanges,
+# and generate a scaled vector graphic (SVG) representation with graphviz
+#
+$ opm alpha render-graph quay.io/operatorhubio/catalog:latest -p etcd -o dot --highlight-skips --highlight-skip-ranges | \
+    dot -Tsvg -o etcd.svg
+
 #
 # Output channel graph of a catalog and generate a scaled vector graphic (SVG) representation
 #
@@ -59,7 +71,7 @@ $ opm alpha render-graph quay.io/operatorhubio/catalog:latest | \
 			}
 
 			render.Refs = args
-			render.AllowedRefMask = action.RefDCImage | action.RefDCDir | action.RefSqliteImage | action.RefSqliteFile
+			render.AllowedRefMask = action.RefDCImage | action.RefDCDir | action.RefDCArchive | action.RefDCGit | action.RefDCGRPC | action.RefSqliteImage | action.RefSqliteFile
 			render.Registry = registry
 
 			cfg, err := render.Run(cmd.Context())
@@ -67,7 +79,22 @@ $ opm alpha render-graph quay.io/operatorhubio/catalog:latest | \
 				log.Fatal(err)
 			}
 
-			writer := declcfg.NewMermaidWriter(declcfg.WithMinEdgeName(minEdge), declcfg.WithSpecifiedPackageName(specifiedPackageName))
+			opts := []declcfg.MermaidOption{
+				declcfg.WithMinEdgeName(minEdge),
+				declcfg.WithSpecifiedPackageName(specifiedPackageName),
+				declcfg.WithHighlights(highlights),
+			}
+			var writer interface {
+				WriteChannels(declcfg.DeclarativeConfig, io.Writer) error
+			}
+			switch output {
+			case "mermaid":
+				writer = declcfg.NewMermaidWriter(opts...)
+			case "dot":
+				writer = declcfg.NewDotWriter(opts...)
+			default:
+				log.Fatalf("invalid --output value %q, expected (mermaid|dot)", output)
+			}
 			if err := writer.WriteChannels(*cfg, os.Stdout); err != nil {
 				log.Fatal(err)
 			}
@@ -75,5 +102,9 @@ $ opm alpha render-graph quay.io/operatorhubio/catalog:latest | \
 	}
 	cmd.Flags().StringVar(&minEdge, "minimum-edge", "", "the channel edge to be used as the lower bound of the set of edges composing the upgrade graph; default is to include all edges")
 	cmd.Flags().StringVarP(&specifiedPackageName, "package-name", "p", "", "a specific package name to filter output; default is to include all packages in reference")
+	cmd.Flags().StringVarP(&output, "output", "o", "mermaid", "output format (mermaid|dot)")
+	cmd.Flags().BoolVar(&highlights.Skips, "highlight-skips", false, "highlight the edges of skips")
+	cmd.Flags().BoolVar(&highlights.SkipRanges, "highlight-skip-ranges", false, "highlight the edges covered by skipRanges")
+	cmd.Flags().BoolVar(&highlights.Deprecations, "highlight-deprecated", true, "highlight deprecated packages, channels and bundles")
 	return cmd
 }
@@ -0,0 +1,116 @@
+package resolve
+
+import (
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func NewCmd() *cobra.Command {
+	logger := log.New()
+	var (
+		bundles      []string
+		pkg          string
+		output       string
+		multiCatalog bool
+		priorities   map[string]int
+	)
+	cmd := &cobra.Command{
+		Use:   "resolve <catalogRef>...",
+		Short: "Preview whether bundle dependencies can be satisfied by catalogs",
+		Long: `Preview whether bundle dependencies can be satisfied by catalogs.
+
+For each olm.package.required, olm.gvk.required and olm.constraint dependency
+declared by a bundle, the command reports the bundles of the given catalogs
+that satisfy it, or that the dependency is unsatisfiable. Dependencies are
+matched the way OLM matches them, including CEL and compound constraints, but
+the command does not run a full resolution: it does not check that a set of
+satisfying bundles can be installed together.
+
+The bundles to check are given with --bundle, as bundle images, bundle
+directories or catalogs. Without --bundle, the bundles of the catalogs
+themselves are checked, optionally only those of --package.
+
+With --multi-catalog, each catalog is resolved as a separate catalog, like the
+CatalogSources of a cluster, and the command also reports the catalog that OLM
+would satisfy each dependency from: the catalog of the bundle that declares
+the dependency, then catalogs of higher --catalog-priority, then catalogs in
+the order they are given. Dependencies that are satisfied from another
+catalog than that of their bundle are reported as cross-catalog, and
+dependencies that are satisfied by several equally preferred catalogs are
+reported as ambiguous. Both couple the bundle to the other catalogs of the
+cluster.
+
+The command exits with a non-zero status if any dependency is unsatisfiable.`,
+		Example: `  # Check a bundle image against a catalog before shipping it
+  opm alpha resolve quay.io/example/catalog:latest --bundle quay.io/example/my-operator-bundle:v1.0.0
+
+  # Check the bundles of a package against the catalog they are published in
+  opm alpha resolve ./catalog --package my-operator
+
+  # Report which of two catalogs satisfies the dependencies of a package
+  opm alpha resolve ./community ./certified --multi-catalog --catalog-priority ./certified=10 --package my-operator`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				logger.Fatalf("invalid --output value %q, expected (table|json)", output)
+			}
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			resolve := action.Resolve{
+				CatalogRefs:       args,
+				BundleRefs:        bundles,
+				Package:           pkg,
+				MultiCatalog:      multiCatalog || len(priorities) > 0,
+				CatalogPriorities: priorities,
+				Registry:          reg,
+				LoadRefOptions:    loadRefOpts,
+			}
+			res, err := resolve.Run(cmd.Context())
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			if output == "json" {
+				err = res.WriteJSON(os.Stdout)
+			} else {
+				err = res.WriteColumns(os.Stdout)
+			}
+			if err != nil {
+				logger.Fatal(err)
+			}
+			if n := res.CrossCatalog(); n > 0 {
+				logger.Warnf("%d dependencies are satisfied from another catalog than that of their bundle", n)
+			}
+			if n := res.Unsatisfiable(); n > 0 {
+				logger.Fatalf("%d unsatisfiable dependencies", n)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&bundles, "bundle", nil, "Bundle image, bundle directory or catalog whose bundles to check (can be repeated)")
+	cmd.Flags().StringVar(&pkg, "package", "", "Only check the bundles of this package")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	cmd.Flags().BoolVar(&multiCatalog, "multi-catalog", false, "Resolve against each catalog separately and report which catalog satisfies each dependency")
+	cmd.Flags().StringToIntVar(&priorities, "catalog-priority", nil, "Priority of a catalog in multi-catalog mode, as <catalogRef>=<priority> (can be repeated; implies --multi-catalog)")
+	return cmd
+}
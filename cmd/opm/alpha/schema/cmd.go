@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func NewCmd() *cobra.Command {
+	logger := log.New()
+	var outputDir string
+	cmd := &cobra.Command{
+		Use:   "schema [<schema>...]",
+		Short: "Print the JSON Schemas of declarative config objects",
+		Long: `Print the JSON Schemas of the objects of the schemas that declarative configs
+define: olm.package, olm.channel, olm.bundle, olm.deprecations, and
+olm.package.documentation.
+
+The JSON Schemas are generated from the types that opm loads the objects into,
+so they can be used by editors and other tools to check catalog objects before
+they are rendered or validated.
+
+With no arguments, the JSON Schemas of all schemas are printed as a JSON object
+keyed by schema name; with arguments, the JSON Schema of each schema is printed.
+Use --output-dir to write each JSON Schema to <schema>.json in a directory
+instead.
+
+Objects of other schemas can be validated against draft-07 JSON Schemas with
+'opm validate --json-schema', which supports a subset of draft-07: see
+'opm validate --help'.`,
+		Example: `
+# Print the JSON Schema of olm.bundle objects
+$ opm alpha schema olm.bundle
+
+# Write all JSON Schemas to the schemas directory
+$ opm alpha schema --output-dir schemas
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schemas := declcfg.JSONSchemas()
+			names := args
+			if len(names) == 0 {
+				for name := range schemas {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+			}
+			for _, name := range names {
+				if _, ok := schemas[name]; !ok {
+					return fmt.Errorf("unknown schema %q", name)
+				}
+			}
+
+			if outputDir != "" {
+				if err := os.MkdirAll(outputDir, 0755); err != nil {
+					logger.Fatal(err)
+				}
+				for _, name := range names {
+					data, err := json.MarshalIndent(schemas[name], "", "    ")
+					if err != nil {
+						logger.Fatal(err)
+					}
+					if err := os.WriteFile(filepath.Join(outputDir, name+".json"), append(data, '\n'), 0644); err != nil {
+						logger.Fatal(err)
+					}
+				}
+				return nil
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "    ")
+			if len(args) == 0 {
+				return enc.Encode(schemas)
+			}
+			for _, name := range names {
+				if err := enc.Encode(schemas[name]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "write each JSON Schema to <schema>.json in this directory instead of printing them")
+	return cmd
+}
@@ -2,10 +2,11 @@ package template
 
 import (
 	"context"
+	"fmt"
 	"io"
-	"log"
 	"os"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
@@ -14,35 +15,80 @@ import (
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/template/basic"
 	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
 )
 
 func newBasicTemplateCmd() *cobra.Command {
+	logger := log.New()
 	var (
 		template     basic.Template
 		migrateLevel string
+		pinImages    bool
 	)
 	cmd := &cobra.Command{
-		Use: "basic basic-template-file",
-		Short: `Generate a file-based catalog from a single 'basic template' file
+		Use: "basic [basic-template-file...]",
+		Short: `Generate a file-based catalog from 'basic template' files
 When FILE is '-' or not provided, the template is read from standard input`,
-		Long: `Generate a file-based catalog from a single 'basic template' file
-When FILE is '-' or not provided, the template is read from standard input`,
-		Args: cobra.MaximumNArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: `Generate a file-based catalog from 'basic template' files
+When FILE is '-' or not provided, the template is read from standard input
+
+The generated catalog is written to standard output, with its objects in a
+deterministic order, so that the command can be used in shell pipelines.
+
+Each file, including standard input, may hold more than one template as a
+stream of YAML documents or JSON objects. All of the templates are merged into
+a single catalog. The templates must not define the same package, channel, or
+bundle image, and must not set different skipRangeStrategies.
+
+The template may set 'skipRangeStrategy' to synthesize the skipRange of each
+channel entry that does not already declare one, based on the versions of the
+entries that precede it in the channel:
+  - all-previous-patches  : skip preceding released patches of the same major.minor version
+  - all-previous-in-minor : skip preceding entries with the same major.minor version,
+                            including pre-releases
+
+With --pin-images, the image and related images of each bundle are resolved to
+digests at render time, and referenced by digest in the generated catalog, so
+that rendering the catalog again produces the same content.`,
+		Example: `  # Merge the templates of two teams into one catalog
+  opm alpha render-template basic team-a.yaml team-b.yaml -o yaml > catalog.yaml
+
+  # Render a generated template from a pipeline
+  generate-template | opm alpha render-template basic - > catalog.json`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// Handle different input argument types
 			// When no arguments or "-" is passed to the command,
 			// assume input is coming from stdin
-			// Otherwise open the file passed to the command
-			data, source, err := util.OpenFileOrStdin(cmd, args)
-			if err != nil {
-				log.Fatalf("unable to open %q: %v", source, err)
+			// Otherwise open the files passed to the command
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+			var (
+				readers []io.Reader
+				stdin   bool
+			)
+			for _, arg := range args {
+				if arg == "-" {
+					if stdin {
+						return liberrors.Errorf(liberrors.CodeInvalidArgument, "standard input can only be read once")
+					}
+					stdin = true
+				}
+				data, source, err := util.OpenFileOrStdin(cmd, []string{arg})
+				if err != nil {
+					return liberrors.Errorf(liberrors.CodeInvalidArgument, "unable to open %q: %v", source, err)
+				}
+				defer data.Close()
+				readers = append(readers, data)
 			}
-			defer data.Close()
 
 			var write func(declcfg.DeclarativeConfig, io.Writer) error
 			output, err := cmd.Flags().GetString("output")
 			if err != nil {
-				log.Fatalf("unable to determine output format")
+				return err
 			}
 			switch output {
 			case "yaml":
@@ -50,29 +96,41 @@ When FILE is '-' or not provided, the template is read from standard input`,
 			case "json":
 				write = declcfg.WriteJSON
 			default:
-				log.Fatalf("invalid --output value %q, expected (json|yaml)", output)
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
 			}
+			write, err = util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
 
 			// The bundle loading impl is somewhat verbose, even on the happy path,
 			// so discard all logrus default logger logs. Any important failures will be
-			// returned from template.Render and logged as fatal errors.
+			// returned from template.Render.
 			logrus.SetOutput(io.Discard)
 
 			reg, err := util.CreateCLIRegistry(cmd)
 			if err != nil {
-				log.Fatalf("creating containerd registry: %v", err)
+				return fmt.Errorf("creating containerd registry: %w", err)
 			}
 			defer reg.Destroy()
 
+			bundleProperties, err := util.LoadBundleProperties(cmd)
+			if err != nil {
+				return err
+			}
+
 			var m *migrations.Migrations
 			if migrateLevel != "" {
 				m, err = migrations.NewMigrations(migrateLevel)
 				if err != nil {
-					log.Fatal(err)
+					return err
 				}
 			}
 
-			template.RenderBundle = func(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error) {
+			bundleRenderer, err := newBundleRenderer(cmd, func(ctx context.Context, image string) (*declcfg.DeclarativeConfig, error) {
 				// populate registry, incl any flags from CLI, and enforce only rendering bundle images
 				r := action.Render{
 					Refs:           []string{image},
@@ -81,21 +139,35 @@ When FILE is '-' or not provided, the template is read from standard input`,
 					Migrations:     m,
 				}
 				return r.Run(ctx)
+			})
+			if err != nil {
+				return err
+			}
+			template.RenderBundle = bundleRenderer.RenderBundle
+			if pinImages {
+				template.ResolveDigest = func(ctx context.Context, img string) (digest.Digest, error) {
+					return reg.Digest(ctx, image.SimpleReference(img))
+				}
 			}
 
-			// only taking first file argument
-			cfg, err := template.Render(cmd.Context(), data)
+			cfg, err := template.RenderAll(cmd.Context(), readers...)
 			if err != nil {
-				log.Fatal(err)
+				return err
+			}
+			if err := bundleProperties.Apply(cfg); err != nil {
+				return err
 			}
 
 			if err := write(*cfg, os.Stdout); err != nil {
-				log.Fatal(err)
+				return err
 			}
+
+			return reportBundleRenderFailures(cmd, logger, bundleRenderer)
 		},
 	}
 
 	cmd.Flags().StringVar(&migrateLevel, "migrate-level", "", "Name of the last migration to run (default: none)\n"+migrations.HelpText())
+	cmd.Flags().BoolVar(&pinImages, "pin-images", false, "Resolve bundle images and related images to digests, and reference them by digest")
 
 	return cmd
 }
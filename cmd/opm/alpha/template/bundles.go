@@ -0,0 +1,73 @@
+package template
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/template/renderer"
+)
+
+const (
+	flagTimeout         = "timeout"
+	flagRetries         = "retries"
+	flagContinueOnError = "continue-on-error"
+	flagFailuresFile    = "failures-file"
+)
+
+func addBundleRenderFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Duration(flagTimeout, 0, "Timeout for rendering each bundle image (0 means no timeout)")
+	cmd.PersistentFlags().Int(flagRetries, 0, "Number of times to retry rendering a bundle image that failed")
+	cmd.PersistentFlags().Bool(flagContinueOnError, false, "Leave bundle images that cannot be rendered, and the channel entries of their bundles, out of the catalog instead of failing")
+	cmd.PersistentFlags().String(flagFailuresFile, "", "With --continue-on-error, write a JSON list of bundle images that could not be rendered to this file")
+}
+
+// newBundleRenderer wraps render with the timeout, retry, and failure policy
+// configured by the flags added in addBundleRenderFlags.
+func newBundleRenderer(cmd *cobra.Command, render renderer.RenderBundleFunc) (*renderer.BundleRenderer, error) {
+	timeout, err := cmd.Flags().GetDuration(flagTimeout)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := cmd.Flags().GetInt(flagRetries)
+	if err != nil {
+		return nil, err
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("invalid --%s value %d, must not be negative", flagRetries, retries)
+	}
+	continueOnError, err := cmd.Flags().GetBool(flagContinueOnError)
+	if err != nil {
+		return nil, err
+	}
+	return renderer.New(render,
+		renderer.WithTimeout(timeout),
+		renderer.WithRetries(retries),
+		renderer.WithContinueOnError(continueOnError),
+	), nil
+}
+
+// reportBundleRenderFailures writes the failures recorded by r to the
+// configured failures file, and logs them as warnings.
+func reportBundleRenderFailures(cmd *cobra.Command, logger *logrus.Logger, r *renderer.BundleRenderer) error {
+	failures := r.Failures()
+	for _, f := range failures {
+		logger.WithField("image", f.Image).Warnf("unable to render bundle, left it out of the catalog: %s", f.Error)
+	}
+
+	failuresFile, err := cmd.Flags().GetString(flagFailuresFile)
+	if err != nil {
+		return err
+	}
+	if failuresFile == "" {
+		return nil
+	}
+	f, err := os.Create(failuresFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return renderer.WriteFailures(failures, f)
+}
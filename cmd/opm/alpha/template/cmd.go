@@ -2,6 +2,8 @@ package template
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 )
 
 func NewCmd() *cobra.Command {
@@ -10,7 +12,12 @@ func NewCmd() *cobra.Command {
 	runCmd := &cobra.Command{
 		Use:   "render-template",
 		Short: "Render a catalog template type",
-		Args:  cobra.NoArgs,
+		Long: `Render a catalog template type.
+
+If --properties-file is set, the properties of the rules in that YAML or JSON
+file are added to the rendered bundles that match the package and versionRange
+of the rules, as with 'opm render --properties-file'.`,
+		Args: cobra.NoArgs,
 	}
 
 	bc := newBasicTemplateCmd()
@@ -21,7 +28,12 @@ func NewCmd() *cobra.Command {
 	// sc.Hidden = true
 	runCmd.AddCommand(sc)
 
+	runCmd.AddCommand(newCompositeTemplateCmd())
+
 	runCmd.PersistentFlags().StringVarP(&output, "output", "o", "json", "Output format (json|yaml)")
+	addBundleRenderFlags(runCmd)
+	util.AddBundlePropertiesFlags(runCmd.PersistentFlags())
+	util.AddCanonicalFlag(runCmd.PersistentFlags())
 
 	return runCmd
 }
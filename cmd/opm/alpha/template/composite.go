@@ -0,0 +1,108 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/composite"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func newCompositeTemplateCmd() *cobra.Command {
+	logger := log.New()
+	var (
+		catalogFile      string
+		contributionFile string
+		validate         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "composite",
+		Short: `Generate the file-based catalogs of a 'composite template'`,
+		Long: `Generate the file-based catalogs of a 'composite template'
+
+The catalogs file defines the catalogs, their working directories, and the
+builders that their components may use. The contributions file defines the
+components of the catalogs, e.g. their packages, and how to build them: with a
+basic or semver template (olm.builder.basic, olm.builder.semver), from a
+file-based catalog (olm.builder.raw), with a command (olm.builder.custom), or
+with a sandboxed WASM module (olm.builder.wasm).
+
+The inputs of components are local files, https:// URLs, or files in git
+repositories, as git+<url>//<path>[?ref=<revision>]. A checksum, like
+sha256:<hex>, pins the content of an input.
+
+Each component is written to its destination path in the working directory of
+its catalog.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return err
+			}
+
+			catalogs, err := os.Open(catalogFile)
+			if err != nil {
+				return fmt.Errorf("open catalogs file: %v", err)
+			}
+			defer catalogs.Close()
+			contributions, err := os.Open(contributionFile)
+			if err != nil {
+				return fmt.Errorf("open contributions file: %v", err)
+			}
+			defer contributions.Close()
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs. Any important failures will be
+			// returned from template.Render.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return fmt.Errorf("creating containerd registry: %v", err)
+			}
+			defer reg.Destroy()
+
+			bundleRenderer, err := newBundleRenderer(cmd, func(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+				renderer := action.Render{
+					Refs:           []string{ref},
+					Registry:       reg,
+					AllowedRefMask: action.RefBundleImage,
+				}
+				return renderer.Run(ctx)
+			})
+			if err != nil {
+				return err
+			}
+
+			template := composite.Template{
+				CatalogFile:      catalogs,
+				ContributionFile: contributions,
+				Validate:         validate,
+				OutputType:       output,
+				RenderBundle:     bundleRenderer.RenderBundle,
+			}
+			if err := template.Render(cmd.Context()); err != nil {
+				return fmt.Errorf("composite: %v", err)
+			}
+			return reportBundleRenderFailures(cmd, logger, bundleRenderer)
+		},
+	}
+
+	cmd.Flags().StringVarP(&catalogFile, "catalog-config", "f", "catalogs.yaml", "File that defines the catalogs and their builders")
+	cmd.Flags().StringVarP(&contributionFile, "composite-config", "c", "catalog/config.yaml", "File that defines the components of the catalogs")
+	cmd.Flags().BoolVar(&validate, "validate", true, "Validate each component once it is built")
+
+	return cmd
+}
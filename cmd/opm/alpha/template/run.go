@@ -0,0 +1,129 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+// NewTemplateCmd returns the command that runs catalog templates by schema,
+// including the template plugins found in PATH.
+func NewTemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Run catalog templates",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newRunCmd())
+	return cmd
+}
+
+func newRunCmd() *cobra.Command {
+	logger := log.New()
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "run <schema> [FILE]",
+		Short: "Render a catalog template of the given schema",
+		Long: `Render a catalog template of the given schema into a file-based catalog.
+When FILE is '-' or not provided, the template is read from standard input.
+
+The schemas of the built-in templates are olm.template.basic and olm.semver.
+For any other schema, the template plugin named opm-template-<schema> is
+looked up in PATH and run with the argument "parse" and then "render", with
+the template on its standard input and the schema in the ` + template.SchemaEnv + `
+environment variable. A plugin writes the rendered file-based catalog to its
+standard output; bundles in that catalog that only have a schema and an image
+are rendered from their bundle image by opm, as in the basic template.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			switch output {
+			case "json":
+				write = declcfg.WriteJSON
+			case "yaml":
+				write = declcfg.WriteYAML
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			t, err := template.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+
+			reader, source, err := util.OpenFileOrStdin(cmd, args[1:])
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+
+			// The template is read twice, to parse and to render it.
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return fmt.Errorf("reading template %q: %w", source, err)
+			}
+			if err := t.Parse(cmd.Context(), bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("%s %q: %w", t.Schema(), source, err)
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
+			// The bundle loading impl is somewhat verbose, even on the happy path,
+			// so discard all logrus default logger logs. Any important failures will be
+			// returned from template.Render.
+			logrus.SetOutput(io.Discard)
+
+			reg, err := util.CreateCLIRegistry(cmd)
+			if err != nil {
+				return fmt.Errorf("creating containerd registry: %w", err)
+			}
+			defer reg.Destroy()
+
+			bundleRenderer, err := newBundleRenderer(cmd, func(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+				renderer := action.Render{
+					Refs:           []string{ref},
+					Registry:       reg,
+					AllowedRefMask: action.RefBundleImage,
+				}
+				return renderer.Run(ctx)
+			})
+			if err != nil {
+				return err
+			}
+
+			out, err := t.Render(cmd.Context(), bytes.NewReader(data), bundleRenderer)
+			if err != nil {
+				return fmt.Errorf("%s %q: %w", t.Schema(), source, err)
+			}
+			if err := write(*out, os.Stdout); err != nil {
+				return err
+			}
+
+			return reportBundleRenderFailures(cmd, logger, bundleRenderer)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format (json|yaml)")
+	addBundleRenderFlags(cmd)
+	util.AddCanonicalFlag(cmd.Flags())
+
+	return cmd
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"os"
 
 	"github.com/sirupsen/logrus"
@@ -15,9 +14,12 @@ import (
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/template/semver"
 	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
 )
 
 func newSemverTemplateCmd() *cobra.Command {
+	logger := log.New()
 	var (
 		migrateLevel string
 	)
@@ -43,7 +45,7 @@ When FILE is '-' or not provided, the template is read from standard input`,
 			var write func(declcfg.DeclarativeConfig, io.Writer) error
 			output, err := cmd.Flags().GetString("output")
 			if err != nil {
-				log.Fatalf("unable to determine output format")
+				return err
 			}
 			switch output {
 			case "json":
@@ -56,52 +58,76 @@ When FILE is '-' or not provided, the template is read from standard input`,
 					return mermaidWriter.WriteChannels(cfg, writer)
 				}
 			default:
-				return fmt.Errorf("invalid output format %q", output)
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml|mermaid)", output)
 			}
+			write, err = util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
 
 			// The bundle loading impl is somewhat verbose, even on the happy path,
 			// so discard all logrus default logger logs. Any important failures will be
-			// returned from template.Render and logged as fatal errors.
+			// returned from template.Render.
 			logrus.SetOutput(io.Discard)
 
 			reg, err := util.CreateCLIRegistry(cmd)
 			if err != nil {
-				log.Fatalf("creating containerd registry: %v", err)
+				return fmt.Errorf("creating containerd registry: %w", err)
 			}
 			defer reg.Destroy()
 
+			bundleProperties, err := util.LoadBundleProperties(cmd)
+			if err != nil {
+				return err
+			}
+
 			var m *migrations.Migrations
 			if migrateLevel != "" {
 				m, err = migrations.NewMigrations(migrateLevel)
 				if err != nil {
-					log.Fatal(err)
+					return err
 				}
 			}
 
+			bundleRenderer, err := newBundleRenderer(cmd, func(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+				renderer := action.Render{
+					Refs:           []string{ref},
+					Registry:       reg,
+					AllowedRefMask: action.RefBundleImage,
+					Migrations:     m,
+				}
+				return renderer.Run(ctx)
+			})
+			if err != nil {
+				return err
+			}
+
 			template := semver.Template{
-				Data: data,
-				RenderBundle: func(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
-					renderer := action.Render{
-						Refs:           []string{ref},
-						Registry:       reg,
-						AllowedRefMask: action.RefBundleImage,
-						Migrations:     m,
-					}
-					return renderer.Run(ctx)
-				},
+				Data:         data,
+				RenderBundle: bundleRenderer.RenderBundle,
 			}
 
 			out, err := template.Render(cmd.Context())
 			if err != nil {
-				log.Fatalf("semver %q: %v", source, err)
+				return fmt.Errorf("semver %q: %w", source, err)
 			}
 
 			if out != nil {
+				if err := bundleProperties.Apply(out); err != nil {
+					return fmt.Errorf("semver %q: %w", source, err)
+				}
 				if err := write(*out, os.Stdout); err != nil {
-					log.Fatal(err)
+					return err
 				}
 			}
 
+			if err := reportBundleRenderFailures(cmd, logger, bundleRenderer); err != nil {
+				return err
+			}
+
 			return nil
 		},
 	}
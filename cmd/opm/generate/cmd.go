@@ -11,6 +11,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 )
 
@@ -45,6 +47,11 @@ When specifying extra labels, note that if duplicate keys exist, only the last
 value of each duplicate key will be added to the generated Dockerfile.
 
 A separate builder and base image can be specified. The builder image may not be "scratch".
+
+If --signature-file is set, the content of the catalog in <fbcRootDir> is
+signed with cosign, which must be installed, and the signature bundle is
+written to that file, so that it can be published alongside the built image.
+Use --attest to create an in-toto attestation of the content instead.
 `,
 		RunE: func(inCmd *cobra.Command, args []string) error {
 			fromDir := filepath.Clean(args[0])
@@ -92,6 +99,12 @@ A separate builder and base image can be specified. The builder image may not be
 			if err := gen.Run(); err != nil {
 				log.Fatal(err)
 			}
+
+			if inCmd.Flags().Changed("signature-file") {
+				if err := signCatalog(inCmd, fromDir); err != nil {
+					log.Fatal(err)
+				}
+			}
 			return nil
 		},
 	}
@@ -101,9 +114,18 @@ A separate builder and base image can be specified. The builder image may not be
 	cmd.Flags().StringSliceVarP(&extraLabelStrs, "extra-labels", "l", []string{}, "Extra labels to include in the generated Dockerfile. Labels should be of the form 'key=value'.")
 	cmd.Flags().MarkDeprecated("binary-image", "use --base-image instead")
 	cmd.MarkFlagsMutuallyExclusive("binary-image", "base-image")
+	util.AddSignFlags(cmd.Flags())
 	return cmd
 }
 
+func signCatalog(cmd *cobra.Command, fromDir string) error {
+	cfg, err := declcfg.LoadFS(cmd.Context(), os.DirFS(fromDir))
+	if err != nil {
+		return err
+	}
+	return util.SignCatalog(cmd.Context(), cmd, *cfg)
+}
+
 func parseLabels(labelStrs []string) (map[string]string, error) {
 	labels := map[string]string{}
 	for _, l := range labelStrs {
@@ -2,6 +2,7 @@ package index
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
@@ -27,6 +29,10 @@ var (
 		0.1.1 -> 0.1.2 -> 0.1.2-1  
 		will be pruned on add to:  
 		0.1.1 -> 0.1.2
+
+		With --push, the bundles are added to the file-based catalog of the --from-index image instead, as entries of the channels that their images declare, with the same graph update --mode as for sqlite databases, and the result is pushed to --tag. The image is built without docker or podman, by adding a layer with the updated catalog to the --from-index image.
+
+		With --dry-run, the bundles are validated by adding them to a copy of the index, and a report of the changes to the upgrade graphs of the index is printed to stdout, without building or pushing an image: the new and changed channel entries, the entries that are pruned, the channel heads that are replaced, and the upgrade edges that are added or removed.
 `) + "\n\n" + sqlite.DeprecationMessage
 
 	addExample = templates.Examples(`
@@ -38,6 +44,12 @@ var (
 
 		# Add multiple bundles to an index and generate a Dockerfile instead of an image
 		%[1]s --bundles quay.io/operator-framework/operator-bundle-prometheus:0.15.0,quay.io/operator-framework/operator-bundle-prometheus:0.22.2 --generate
+
+		# Show how adding a bundle would change the upgrade graphs of an index image, without building it
+		%[1]s --bundles quay.io/operator-framework/operator-bundle-prometheus:0.22.2 --from-index quay.io/operator-framework/monitoring:1.0.0 --dry-run
+
+		# Add a bundle to a file-based catalog index image and push the result, without docker or podman
+		%[1]s --bundles quay.io/operator-framework/operator-bundle-prometheus:0.22.2 --from-index quay.io/operator-framework/monitoring-fbc:1.0.0 --tag quay.io/operator-framework/monitoring-fbc:1.0.1 --push
 	`)
 )
 
@@ -66,13 +78,18 @@ func addIndexAddCmd(parent *cobra.Command, showAlphaHelp bool) {
 		logrus.Panic("Failed to set required `bundles` flag for `index add`")
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
-	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
-	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman]. Defaults to none. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [docker, podman, nerdctl]")
+	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman, nerdctl]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman, nerdctl, crane]. Defaults to none. Overrides part of container-tool.")
 	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
 	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
+	util.AddBlobCacheFlags(indexCmd.Flags())
+	indexCmd.Flags().Bool("skip-referenced-images", false, "do not add the images referenced by RELATED_IMAGE_* environment variables and image annotations of bundle CSVs to their related images")
+	indexCmd.Flags().Int("max-parallel", 1, "maximum number of bundle images to pull and unpack at the same time")
 	indexCmd.Flags().StringP("mode", "", "replaces", "graph update mode that defines how channel graphs are updated. One of: [replaces, semver, semver-skippatch]")
+	indexCmd.Flags().Bool("push", false, "add the bundles to the file-based catalog of --from-index and push the result to --tag, without a container tool. The channel graphs are updated according to --mode, and a serve cache in the index image is rebuilt")
 
+	indexCmd.Flags().Bool("dry-run", false, "validate the bundles and print the changes to the upgrade graphs of the index, without building or pushing an image")
 	indexCmd.Flags().Bool("overwrite-latest", false, "overwrite the latest bundles (channel heads) with those of the same csv name given by --bundles")
 	if err := indexCmd.Flags().MarkHidden("overwrite-latest"); err != nil {
 		logrus.Panic(err.Error())
@@ -129,7 +146,7 @@ func runIndexAddCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	clientConfig, err := util.GetClientConfig(cmd)
 	if err != nil {
 		return err
 	}
@@ -149,7 +166,35 @@ func runIndexAddCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
+	skipReferencedImages, err := cmd.Flags().GetBool("skip-referenced-images")
+	if err != nil {
+		return err
+	}
+
+	maxParallel, err := cmd.Flags().GetInt("max-parallel")
+	if err != nil {
+		return err
+	}
+	if maxParallel < 1 {
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --max-parallel value %d, must be at least 1", maxParallel)
+	}
+
+	blobCacheDir, err := util.GetBlobCacheDir(cmd)
+	if err != nil {
+		return err
+	}
+
 	modeEnum, err := registry.GetModeFromString(mode)
+	if err != nil {
+		return liberrors.New(liberrors.CodeInvalidArgument, err)
+	}
+
+	push, err := cmd.Flags().GetBool("push")
+	if err != nil {
+		return err
+	}
+
+	dryRun, err := cmd.Flags().GetBool("dry-run")
 	if err != nil {
 		return err
 	}
@@ -169,18 +214,25 @@ func runIndexAddCmdFunc(cmd *cobra.Command, _ []string) error {
 		logger)
 
 	request := indexer.AddToIndexRequest{
-		Generate:          generate,
-		FromIndex:         fromIndex,
-		BinarySourceImage: binaryImage,
-		OutDockerfile:     outDockerfile,
-		Tag:               tag,
-		Bundles:           bundles,
-		Permissive:        permissive,
-		Mode:              modeEnum,
-		SkipTLSVerify:     skipTLSVerify,
-		PlainHTTP:         useHTTP,
-		Overwrite:         overwrite,
-		EnableAlpha:       enableAlpha,
+		Generate:             generate,
+		FromIndex:            fromIndex,
+		BinarySourceImage:    binaryImage,
+		OutDockerfile:        outDockerfile,
+		Tag:                  tag,
+		Bundles:              bundles,
+		Permissive:           permissive,
+		Mode:                 modeEnum,
+		CaFile:               clientConfig.CAFile,
+		SkipTLSVerify:        clientConfig.SkipTLSVerify,
+		PlainHTTP:            clientConfig.PlainHTTP,
+		Overwrite:            overwrite,
+		EnableAlpha:          enableAlpha,
+		MaxParallel:          maxParallel,
+		SkipReferencedImages: skipReferencedImages,
+		BlobCacheDir:         blobCacheDir,
+		Push:                 push,
+		DryRun:               dryRun,
+		DryRunOutput:         os.Stdout,
 	}
 
 	err = indexAdder.AddToIndex(request)
@@ -200,7 +252,7 @@ func getContainerTools(cmd *cobra.Command) (string, string, error) {
 	}
 
 	if buildTool == "none" {
-		return "", "", fmt.Errorf("none is not a valid container-tool for index add")
+		return "", "", liberrors.Errorf(liberrors.CodeInvalidArgument, "none is not a valid container-tool for index add")
 	}
 
 	pullTool, err := cmd.Flags().GetString("pull-tool")
@@ -215,10 +267,18 @@ func getContainerTools(cmd *cobra.Command) (string, string, error) {
 
 	// Backwards compatiblity mode
 	if containerTool != "" {
-		if pullTool == "" && buildTool == "" {
-			return containerTool, containerTool, nil
+		if pullTool != "" || buildTool != "" {
+			return "", "", liberrors.Errorf(liberrors.CodeInvalidArgument, "container-tool cannot be set alongside pull-tool or build-tool")
 		}
-		return "", "", fmt.Errorf("container-tool cannot be set alongside pull-tool or build-tool")
+		if containerTool == "crane" {
+			return "", "", liberrors.Errorf(liberrors.CodeInvalidArgument, "crane cannot build images, set --pull-tool=crane with a --build-tool instead of --container-tool=crane")
+		}
+		return containerTool, containerTool, nil
+	}
+
+	// crane only reads images from their registry.
+	if buildTool == "crane" {
+		return "", "", liberrors.Errorf(liberrors.CodeInvalidArgument, "crane is not a valid build-tool, it cannot build images")
 	}
 
 	// Check for defaults, then return
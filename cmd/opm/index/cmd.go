@@ -34,6 +34,7 @@ func AddCommand(parent *cobra.Command, showAlphaHelp bool) {
 	parent.AddCommand(cmd)
 
 	cmd.AddCommand(newIndexDeleteCmd())
+	cmd.AddCommand(newIndexRmBundleCmd())
 	addIndexAddCmd(cmd, showAlphaHelp)
 	cmd.AddCommand(newIndexExportCmd())
 	cmd.AddCommand(newIndexPruneCmd())
@@ -41,9 +41,9 @@ func newIndexDeleteCmd() *cobra.Command {
 		logrus.Panic("Failed to set required `operators` flag for `index delete`")
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
-	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman]. Defaults to podman. Overrides part of container-tool.")
-	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman]. Defaults to none. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman, nerdctl]")
+	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman, nerdctl]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman, nerdctl, crane]. Defaults to none. Overrides part of container-tool.")
 	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
 	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
 
@@ -96,7 +96,7 @@ func runIndexDeleteCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	clientConfig, err := util.GetClientConfig(cmd)
 	if err != nil {
 		return err
 	}
@@ -118,8 +118,9 @@ func runIndexDeleteCmdFunc(cmd *cobra.Command, _ []string) error {
 		Operators:         operators,
 		Tag:               tag,
 		Permissive:        permissive,
-		SkipTLSVerify:     skipTLSVerify,
-		PlainHTTP:         useHTTP,
+		CaFile:            clientConfig.CAFile,
+		SkipTLSVerify:     clientConfig.SkipTLSVerify,
+		PlainHTTP:         clientConfig.PlainHTTP,
 	}
 
 	err = indexDeleter.DeleteFromIndex(request)
@@ -20,6 +20,10 @@ var exportLong = templates.LongDesc(`
 	the --package option) and export the operator metadata into an appregistry compliant format (a package.yaml file). 
 
 	Note: the appregistry format is being deprecated in favor of the new index image and image bundle format. 
+
+	With --output=fbc, the operator(s) are instead exported as a file-based catalog, in a directory per package of the 
+	download folder, with their bundle properties and deprecations. This is the supported way to export operators 
+	from an index image going forward.
 	`) + "\n\n" + sqlite.DeprecationMessage
 
 func newIndexExportCmd() *cobra.Command {
@@ -45,7 +49,8 @@ func newIndexExportCmd() *cobra.Command {
 	}
 	indexCmd.Flags().StringSliceP("package", "p", nil, "comma separated list of packages to export")
 	indexCmd.Flags().StringP("download-folder", "f", "downloaded", "directory where downloaded operator bundle(s) will be stored")
-	indexCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	indexCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman, nerdctl, crane]. crane can only pull images, not build or push them.")
+	indexCmd.Flags().String("output", string(indexer.ExportFormatAppregistry), fmt.Sprintf("format to export the operator(s) in. One of: [%s, %s]", indexer.ExportFormatAppregistry, indexer.ExportFormatFBC))
 	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
 		logrus.Panic(err.Error())
 	}
@@ -101,7 +106,12 @@ func runIndexExportCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	clientConfig, err := util.GetClientConfig(cmd)
 	if err != nil {
 		return err
 	}
@@ -117,8 +127,10 @@ func runIndexExportCmdFunc(cmd *cobra.Command, _ []string) error {
 		Packages:      packages,
 		DownloadPath:  downloadPath,
 		ContainerTool: containertools.NewContainerTool(containerTool, containertools.NoneTool),
-		SkipTLSVerify: skipTLSVerify,
-		PlainHTTP:     useHTTP,
+		CaFile:        clientConfig.CAFile,
+		SkipTLSVerify: clientConfig.SkipTLSVerify,
+		PlainHTTP:     clientConfig.PlainHTTP,
+		Format:        indexer.ExportFormat(output),
 	}
 
 	err = indexExporter.ExportFromIndex(request)
@@ -18,6 +18,9 @@ func newIndexPruneCmd() *cobra.Command {
 		Short: "prune an index of all but specified packages",
 		Long: `prune an index of all but specified packages
 
+Use --keep-channels and --keep-versions to also prune the channels and the
+bundle versions of the kept packages.
+
 ` + sqlite.DeprecationMessage,
 
 		PreRunE: func(cmd *cobra.Command, _ []string) error {
@@ -42,8 +45,10 @@ func newIndexPruneCmd() *cobra.Command {
 	if err := indexCmd.MarkFlagRequired("packages"); err != nil {
 		logrus.Panic("Failed to set required `packages` flag for `index prune`")
 	}
+	indexCmd.Flags().StringSlice("keep-channels", nil, "comma separated list of channels to keep, as <channel> for every kept package or <package>:<channel> for one package. Packages without entries keep all channels")
+	indexCmd.Flags().StringArray("keep-versions", nil, "semver range of bundle versions to keep, as <range> for every kept package or <package>:<range> for one package, e.g. 'etcd:>=1.2.0 <2.0.0'. May be repeated; a bundle is kept if its version is in any range for its package. Upgrade edges are rewired around pruned bundles")
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
+	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [docker, podman, nerdctl]")
 	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
 	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
 
@@ -76,6 +81,16 @@ func runIndexPruneCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
+	keepChannels, err := cmd.Flags().GetStringSlice("keep-channels")
+	if err != nil {
+		return err
+	}
+
+	keepVersions, err := cmd.Flags().GetStringArray("keep-versions")
+	if err != nil {
+		return err
+	}
+
 	binaryImage, err := cmd.Flags().GetString("binary-image")
 	if err != nil {
 		return err
@@ -86,8 +101,8 @@ func runIndexPruneCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if containerTool == "none" {
-		return fmt.Errorf("none is not a valid container-tool for index prune")
+	if containerTool == "none" || containerTool == "crane" {
+		return fmt.Errorf("%s is not a valid container-tool for index prune", containerTool)
 	}
 
 	tag, err := cmd.Flags().GetString("tag")
@@ -100,7 +115,7 @@ func runIndexPruneCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	clientConfig, err := util.GetClientConfig(cmd)
 	if err != nil {
 		return err
 	}
@@ -117,10 +132,13 @@ func runIndexPruneCmdFunc(cmd *cobra.Command, _ []string) error {
 		BinarySourceImage: binaryImage,
 		OutDockerfile:     outDockerfile,
 		Packages:          packages,
+		KeepChannels:      keepChannels,
+		KeepVersions:      keepVersions,
 		Tag:               tag,
 		Permissive:        permissive,
-		SkipTLSVerify:     skipTLSVerify,
-		PlainHTTP:         useHTTP,
+		CaFile:            clientConfig.CAFile,
+		SkipTLSVerify:     clientConfig.SkipTLSVerify,
+		PlainHTTP:         clientConfig.PlainHTTP,
 	}
 
 	err = indexPruner.PruneFromIndex(request)
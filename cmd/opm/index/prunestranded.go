@@ -39,7 +39,7 @@ func newIndexPruneStrandedCmd() *cobra.Command {
 		logrus.Panic("Failed to set required `from-index` flag for `index prune-stranded`")
 	}
 	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
-	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [docker, podman]")
+	indexCmd.Flags().StringP("container-tool", "c", "podman", "tool to interact with container images (save, build, etc.). One of: [docker, podman, nerdctl]")
 	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
 
 	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
@@ -76,8 +76,8 @@ func runIndexPruneStrandedCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if containerTool == "none" {
-		return fmt.Errorf("none is not a valid container-tool for index prune")
+	if containerTool == "none" || containerTool == "crane" {
+		return fmt.Errorf("%s is not a valid container-tool for index prune", containerTool)
 	}
 
 	tag, err := cmd.Flags().GetString("tag")
@@ -85,7 +85,7 @@ func runIndexPruneStrandedCmdFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	clientConfig, err := util.GetClientConfig(cmd)
 	if err != nil {
 		return err
 	}
@@ -102,8 +102,9 @@ func runIndexPruneStrandedCmdFunc(cmd *cobra.Command, _ []string) error {
 		BinarySourceImage: binaryImage,
 		OutDockerfile:     outDockerfile,
 		Tag:               tag,
-		SkipTLSVerify:     skipTLSVerify,
-		PlainHTTP:         useHTTP,
+		CaFile:            clientConfig.CAFile,
+		SkipTLSVerify:     clientConfig.SkipTLSVerify,
+		PlainHTTP:         clientConfig.PlainHTTP,
 	}
 
 	err = indexPruner.PruneStrandedFromIndex(request)
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/lib/indexer"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func newIndexRmBundleCmd() *cobra.Command {
+	indexCmd := &cobra.Command{
+		Use:   "rm-bundle",
+		Short: "remove bundles from an index",
+		Long: `remove bundles from an index
+
+By default, the upgrade graph is rewired around the removed bundles: bundles
+that replaced a removed bundle instead replace the bundle that it replaced.
+With --force-truncate, the bundles that a removed bundle replaces, directly or
+transitively, are removed as well.
+
+` + sqlite.DeprecationMessage,
+
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+
+		RunE: runIndexRmBundleCmdFunc,
+		Args: cobra.NoArgs,
+	}
+
+	indexCmd.Flags().Bool("debug", false, "enable debug logging")
+	indexCmd.Flags().Bool("generate", false, "if enabled, just creates the dockerfile and saves it to local disk")
+	indexCmd.Flags().StringP("out-dockerfile", "d", "", "if generating the dockerfile, this flag is used to (optionally) specify a dockerfile name")
+	indexCmd.Flags().StringP("from-index", "f", "", "previous index to remove bundles from")
+	if err := indexCmd.MarkFlagRequired("from-index"); err != nil {
+		logrus.Panic("Failed to set required `from-index` flag for `index rm-bundle`")
+	}
+	indexCmd.Flags().StringSliceP("bundles", "b", nil, "comma separated list of names of the bundles to remove")
+	if err := indexCmd.MarkFlagRequired("bundles"); err != nil {
+		logrus.Panic("Failed to set required `bundles` flag for `index rm-bundle`")
+	}
+	indexCmd.Flags().Bool("force-truncate", false, "also remove the bundles that the removed bundles replace, instead of rewiring the upgrade graph")
+	indexCmd.Flags().StringP("binary-image", "i", "", "container image for on-image `opm` command")
+	indexCmd.Flags().StringP("container-tool", "c", "", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman, nerdctl]")
+	indexCmd.Flags().StringP("build-tool", "u", "", "tool to build container images. One of: [docker, podman, nerdctl]. Defaults to podman. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("pull-tool", "p", "", "tool to pull container images. One of: [none, docker, podman, nerdctl, crane]. Defaults to none. Overrides part of container-tool.")
+	indexCmd.Flags().StringP("tag", "t", "", "custom tag for container image being built")
+	indexCmd.Flags().Bool("permissive", false, "allow registry load errors")
+
+	if err := indexCmd.Flags().MarkHidden("debug"); err != nil {
+		logrus.Panic(err.Error())
+	}
+
+	return indexCmd
+}
+
+func runIndexRmBundleCmdFunc(cmd *cobra.Command, _ []string) error {
+	generate, err := cmd.Flags().GetBool("generate")
+	if err != nil {
+		return err
+	}
+
+	outDockerfile, err := cmd.Flags().GetString("out-dockerfile")
+	if err != nil {
+		return err
+	}
+
+	fromIndex, err := cmd.Flags().GetString("from-index")
+	if err != nil {
+		return err
+	}
+
+	bundles, err := cmd.Flags().GetStringSlice("bundles")
+	if err != nil {
+		return err
+	}
+
+	forceTruncate, err := cmd.Flags().GetBool("force-truncate")
+	if err != nil {
+		return err
+	}
+
+	binaryImage, err := cmd.Flags().GetString("binary-image")
+	if err != nil {
+		return err
+	}
+
+	pullTool, buildTool, err := getContainerTools(cmd)
+	if err != nil {
+		return err
+	}
+
+	tag, err := cmd.Flags().GetString("tag")
+	if err != nil {
+		return err
+	}
+
+	permissive, err := cmd.Flags().GetBool("permissive")
+	if err != nil {
+		return err
+	}
+
+	clientConfig, err := util.GetClientConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"bundles": bundles})
+
+	logger.Info("building the index")
+
+	bundleRemover := indexer.NewIndexBundleRemover(
+		containertools.NewContainerTool(buildTool, containertools.PodmanTool),
+		containertools.NewContainerTool(pullTool, containertools.NoneTool),
+		logger)
+
+	request := indexer.RemoveBundlesFromIndexRequest{
+		Generate:          generate,
+		FromIndex:         fromIndex,
+		BinarySourceImage: binaryImage,
+		OutDockerfile:     outDockerfile,
+		Bundles:           bundles,
+		ForceTruncate:     forceTruncate,
+		Tag:               tag,
+		Permissive:        permissive,
+		CaFile:            clientConfig.CAFile,
+		SkipTLSVerify:     clientConfig.SkipTLSVerify,
+		PlainHTTP:         clientConfig.PlainHTTP,
+	}
+
+	return bundleRemover.RemoveBundlesFromIndex(request)
+}
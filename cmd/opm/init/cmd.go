@@ -9,6 +9,8 @@ import (
 
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 )
 
 func NewCmd() *cobra.Command {
@@ -22,7 +24,7 @@ func NewCmd() *cobra.Command {
 		Use:   "init <packageName>",
 		Short: "Generate an olm.package declarative config blob",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			init.Package = args[0]
 
 			var write func(declcfg.DeclarativeConfig, io.Writer) error
@@ -32,13 +34,17 @@ func NewCmd() *cobra.Command {
 			case "json":
 				write = declcfg.WriteJSON
 			default:
-				log.Fatalf("invalid --output value %q, expected (json|yaml)", output)
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
 			}
 
 			if iconFile != "" {
 				iconReader, err := os.Open(iconFile)
 				if err != nil {
-					log.Fatalf("open icon file: %v", err)
+					return liberrors.Errorf(liberrors.CodeInvalidArgument, "open icon file: %v", err)
 				}
 				defer closeReader(iconReader)
 				init.IconReader = iconReader
@@ -47,26 +53,26 @@ func NewCmd() *cobra.Command {
 			if descriptionFile != "" {
 				descriptionReader, err := os.Open(descriptionFile)
 				if err != nil {
-					log.Fatalf("open description file: %v", err)
+					return liberrors.Errorf(liberrors.CodeInvalidArgument, "open description file: %v", err)
 				}
 				defer closeReader(descriptionReader)
 				init.DescriptionReader = descriptionReader
 			}
 
+			cmd.SilenceUsage = true
 			pkg, err := init.Run()
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 			cfg := declcfg.DeclarativeConfig{Packages: []declcfg.Package{*pkg}}
-			if err := write(cfg, os.Stdout); err != nil {
-				log.Fatal(err)
-			}
+			return write(cfg, os.Stdout)
 		},
 	}
 	cmd.Flags().StringVarP(&init.DefaultChannel, "default-channel", "c", "", "The channel that subscriptions will default to if unspecified")
 	cmd.Flags().StringVarP(&iconFile, "icon", "i", "", "Path to package's icon")
 	cmd.Flags().StringVarP(&descriptionFile, "description", "d", "", "Path to the operator's README.md (or other documentation)")
 	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
 	return cmd
 }
 
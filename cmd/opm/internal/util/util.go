@@ -1,14 +1,21 @@
 package util
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/signing"
 )
 
 // GetTLSOptions validates and returns TLS options set by opm flags
@@ -43,10 +50,79 @@ func GetTLSOptions(cmd *cobra.Command) (bool, bool, error) {
 	}
 }
 
+// GetClientConfig returns the image client configuration set by opm flags.
+// It works in tandem with the root command, which adds the flags as
+// persistent. Mirrors are not set by flags: they are read from the
+// registries.conf file of $CONTAINERS_REGISTRIES_CONF or the system, which
+// podman reads too.
+func GetClientConfig(cmd *cobra.Command) (image.ClientConfig, error) {
+	skipTLSVerify, useHTTP, err := GetTLSOptions(cmd)
+	if err != nil {
+		return image.ClientConfig{}, err
+	}
+	caFile, err := cmd.Flags().GetString("ca-file")
+	if err != nil {
+		return image.ClientConfig{}, err
+	}
+	if caFile != "" && skipTLSVerify {
+		return image.ClientConfig{}, errors.New("invalid flag combination: cannot use --ca-file with --skip-tls-verify")
+	}
+
+	return image.ClientConfig{
+		CAFile:        caFile,
+		SkipTLSVerify: skipTLSVerify,
+		PlainHTTP:     useHTTP,
+	}, nil
+}
+
+// AddBlobCacheFlags adds the flags that configure the persistent cache of
+// image blobs, which is shared across opm invocations.
+func AddBlobCacheFlags(flags *pflag.FlagSet) {
+	flags.String("cache-dir", "", "directory of the persistent cache of image blobs shared across opm invocations (default is opm in the user cache directory, e.g. $XDG_CACHE_HOME/opm)")
+	flags.Bool("no-cache", false, "do not use the persistent cache of image blobs")
+}
+
+// GetBlobCacheDir returns the directory of the persistent cache of image
+// blobs set by the flags added by AddBlobCacheFlags. It returns "" if the
+// cache is disabled, or if the command does not have the flags.
+func GetBlobCacheDir(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Lookup("no-cache") == nil {
+		return "", nil
+	}
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return "", err
+	}
+	cacheDir, err := cmd.Flags().GetString("cache-dir")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case noCache && cmd.Flags().Changed("cache-dir"):
+		return "", errors.New("invalid flag combination: cannot use --cache-dir with --no-cache")
+	case noCache:
+		return "", nil
+	case cacheDir != "":
+		return cacheDir, nil
+	}
+	// The default cache is best effort, since the user cache directory may
+	// not be set or writable, e.g. in a container.
+	cacheDir, err = containerdregistry.DefaultBlobCacheDir()
+	if err != nil || os.MkdirAll(cacheDir, 0755) != nil {
+		return "", nil
+	}
+	return cacheDir, nil
+}
+
 // This works in tandem with opm/index/cmd, which adds the relevant flags as persistent
 // as part of the root command (cmd/root/cmd) initialization
 func CreateCLIRegistry(cmd *cobra.Command) (*containerdregistry.Registry, error) {
-	skipTlsVerify, useHTTP, err := GetTLSOptions(cmd)
+	clientConfig, err := GetClientConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	blobCacheDir, err := GetBlobCacheDir(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +134,8 @@ func CreateCLIRegistry(cmd *cobra.Command) (*containerdregistry.Registry, error)
 
 	reg, err := containerdregistry.NewRegistry(
 		containerdregistry.WithCacheDir(cacheDir),
-		containerdregistry.SkipTLSVerify(skipTlsVerify),
-		containerdregistry.WithPlainHTTP(useHTTP),
+		containerdregistry.WithBlobCacheDir(blobCacheDir),
+		containerdregistry.WithClientConfig(clientConfig),
 		containerdregistry.WithLog(log.Null()),
 	)
 	if err != nil {
@@ -68,6 +144,97 @@ func CreateCLIRegistry(cmd *cobra.Command) (*containerdregistry.Registry, error)
 	return reg, nil
 }
 
+// CreateLoadRefOptions returns the declcfg.LoadRef options that correspond to
+// the TLS flags set on the command. Image references are loaded using reg.
+func CreateLoadRefOptions(cmd *cobra.Command, reg image.Registry) ([]declcfg.LoadRefOption, error) {
+	skipTlsVerify, _, err := GetTLSOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return []declcfg.LoadRefOption{
+		declcfg.WithRegistry(reg),
+		declcfg.WithSkipTLSVerify(skipTlsVerify),
+	}, nil
+}
+
+// AddSignFlags adds the flags of commands that sign the catalogs they write
+// with cosign.
+func AddSignFlags(flags *pflag.FlagSet) {
+	flags.String("signature-file", "", "if set, sign the content of the catalog with cosign, and write the signature bundle to this file")
+	flags.String("sign-key", "", "private key to sign the catalog with, as a file path or KMS URI; if not set, keyless signing is used")
+	flags.Bool("attest", false, "create an in-toto attestation of the catalog content instead of a plain signature")
+}
+
+// SignCatalog signs the content of cfg as configured by the flags added by
+// AddSignFlags. It does nothing if --signature-file is not set.
+func SignCatalog(ctx context.Context, cmd *cobra.Command, cfg declcfg.DeclarativeConfig) error {
+	signatureFile, err := cmd.Flags().GetString("signature-file")
+	if err != nil || signatureFile == "" {
+		return err
+	}
+	key, err := cmd.Flags().GetString("sign-key")
+	if err != nil {
+		return err
+	}
+	attest, err := cmd.Flags().GetBool("attest")
+	if err != nil {
+		return err
+	}
+	signature, err := signing.SignConfig(ctx, signing.Cosign{Key: key, Attest: attest}, cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(signatureFile, signature, 0644); err != nil {
+		return fmt.Errorf("write signature: %v", err)
+	}
+	return nil
+}
+
+// AddBundlePropertiesFlags adds the flags of commands that add properties
+// to the bundles they render.
+func AddBundlePropertiesFlags(flags *pflag.FlagSet) {
+	flags.String("properties-file", "", "if set, add the properties of the rules in this YAML or JSON file to the rendered bundles that match their package and versionRange")
+}
+
+// LoadBundleProperties loads the bundle properties configured by the flags
+// added by AddBundlePropertiesFlags. It returns nil if --properties-file is
+// not set.
+func LoadBundleProperties(cmd *cobra.Command) (*declcfg.BundleProperties, error) {
+	propertiesFile, err := cmd.Flags().GetString("properties-file")
+	if err != nil || propertiesFile == "" {
+		return nil, err
+	}
+	f, err := os.Open(propertiesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	bp, err := declcfg.LoadBundleProperties(f)
+	if err != nil {
+		return nil, fmt.Errorf("load properties file %q: %v", propertiesFile, err)
+	}
+	return bp, nil
+}
+
+// AddCanonicalFlag adds the flag of commands that write file-based catalogs
+// to write them as they are, rather than in canonical form.
+func AddCanonicalFlag(flags *pflag.FlagSet) {
+	flags.Bool("no-canonical", false, "write the file-based catalog as it is, instead of in canonical form. The canonical form, which is the default, is the same byte-for-byte for catalogs with the same content: sets such as properties are sorted, and the raw JSON of property values and other objects has sorted keys and normalized numbers")
+}
+
+// CanonicalWriteFunc returns write in canonical form, or write itself if the
+// flag added by AddCanonicalFlag is set.
+func CanonicalWriteFunc(cmd *cobra.Command, write declcfg.WriteFunc) (declcfg.WriteFunc, error) {
+	noCanonical, err := cmd.Flags().GetBool("no-canonical")
+	if err != nil {
+		return nil, err
+	}
+	if noCanonical {
+		return write, nil
+	}
+	return declcfg.Canonical(write), nil
+}
+
 func OpenFileOrStdin(cmd *cobra.Command, args []string) (io.ReadCloser, string, error) {
 	if len(args) == 0 || args[0] == "-" {
 		return io.NopCloser(cmd.InOrStdin()), "stdin", nil
@@ -75,3 +242,25 @@ func OpenFileOrStdin(cmd *cobra.Command, args []string) (io.ReadCloser, string,
 	reader, err := os.Open(args[0])
 	return reader, args[0], err
 }
+
+// SelectMigrations returns the migrations selected by the --migrate-level,
+// --since, --until, and --migrations flags of commands that migrate
+// catalogs, or nil if none of them are set.
+func SelectMigrations(level, since, until string, subset []string) (*migrations.Migrations, error) {
+	switch {
+	case len(subset) > 0 && (level != "" || since != "" || until != ""):
+		return nil, errors.New("--migrations cannot be combined with --migrate-level, --since, or --until")
+	case level != "" && until != "":
+		return nil, errors.New("--migrate-level and --until are mutually exclusive")
+	case len(subset) > 0:
+		return migrations.NewMigrationsSubset(subset...)
+	case since != "" || until != "":
+		if until == "" {
+			until = level
+		}
+		return migrations.NewMigrationsRange(since, until)
+	case level != "":
+		return migrations.NewMigrations(level)
+	}
+	return nil, nil
+}
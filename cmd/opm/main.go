@@ -5,32 +5,62 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
 
 	"github.com/operator-framework/operator-registry/cmd/opm/root"
-	registrylib "github.com/operator-framework/operator-registry/pkg/registry"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+	"github.com/operator-framework/operator-registry/pkg/lib/tracing"
 )
 
 func main() {
 	showAlphaHelp := os.Getenv("HELP_ALPHA") == "true"
 	cmd := root.NewCmd(showAlphaHelp)
 
+	errorFormat, err := root.ErrorFormat(os.Args[1:])
+	if err != nil {
+		root.PrintError(os.Stderr, errorFormat, err)
+		os.Exit(1)
+	}
+	root.SetErrorFormat(cmd, errorFormat, os.Stderr)
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	if err := cmd.ExecuteContext(ctx); err != nil {
-		agg, ok := err.(utilerrors.Aggregate)
-		if !ok {
-			os.Exit(1)
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("unable to set up tracing and metrics")
+	}
+	flushTraces := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.WithError(err).Warn("unable to flush traces and metrics")
 		}
-		for _, e := range agg.Errors() {
-			if _, ok := e.(registrylib.BundleImageAlreadyAddedErr); ok {
-				os.Exit(2)
-			}
-			if _, ok := e.(registrylib.PackageVersionAlreadyAddedErr); ok {
-				os.Exit(3)
-			}
+	}
+	// Commands that fail with logrus.Fatal exit without returning here.
+	logrus.RegisterExitHandler(flushTraces)
+
+	// Trace the whole command, so that the spans of its image pulls and
+	// queries share a trace.
+	spanName := cmd.Name()
+	if c, _, err := cmd.Find(os.Args[1:]); err == nil {
+		spanName = c.CommandPath()
+	}
+	ctx, span := otel.Tracer("github.com/operator-framework/operator-registry/cmd/opm").Start(ctx, spanName)
+	err = cmd.ExecuteContext(ctx)
+	span.End()
+	flushTraces()
+
+	if err != nil {
+		root.PrintError(os.Stderr, errorFormat, err)
+		switch liberrors.CodeOf(err) {
+		case liberrors.CodeBundleAlreadyExists:
+			os.Exit(2)
+		case liberrors.CodePackageVersionAlreadyExists:
+			os.Exit(3)
 		}
 		os.Exit(1)
 	}
@@ -1,14 +1,14 @@
 package migrate
 
 import (
-	"log"
-
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/alpha/action/migrations"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
 
@@ -16,13 +16,23 @@ func NewCmd() *cobra.Command {
 	var (
 		migrate      action.Migrate
 		migrateLevel string
+		since        string
+		until        string
+		subset       []string
 		output       string
+		layout       string
 	)
 	cmd := &cobra.Command{
 		Use:   "migrate <indexRef> <outputDir>",
 		Short: "Migrate a sqlite-based index image or database file to a file-based catalog",
 		Long: `Migrate a sqlite-based index image or database file to a file-based catalog.
 
+Packages are migrated and written one at a time. If a migration is
+interrupted, rerun it with the same arguments and --resume to skip the
+packages that were already written. The progress of a migration is recorded
+in the ` + action.MigrateStateDir + ` directory of the output directory, which is removed
+when the migration completes.
+
 NOTE: the --output=json format produces streamable, concatenated JSON files.
 These are suitable to opm and jq, but may not be supported by arbitrary JSON
 parsers that assume that a file contains exactly one valid JSON object.
@@ -41,27 +51,44 @@ parsers that assume that a file contains exactly one valid JSON object.
 				migrate.WriteFunc = declcfg.WriteJSON
 				migrate.FileExt = ".json"
 			default:
-				log.Fatalf("invalid --output value %q, expected (json|yaml)", output)
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			writeFunc, err := util.CanonicalWriteFunc(cmd, migrate.WriteFunc)
+			if err != nil {
+				return err
+			}
+			migrate.WriteFunc = writeFunc
+
+			switch declcfg.FSLayout(layout) {
+			case declcfg.FSLayoutPackage, declcfg.FSLayoutSchema:
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --layout value %q, expected (package|schema)", layout)
 			}
 
-			if migrateLevel != "" {
-				m, err := migrations.NewMigrations(migrateLevel)
-				if err != nil {
-					log.Fatal(err)
-				}
-				migrate.Migrations = m
+			m, err := util.SelectMigrations(migrateLevel, since, until, subset)
+			if err != nil {
+				return liberrors.New(liberrors.CodeInvalidArgument, err)
 			}
+			migrate.Migrations = m
+			migrate.Layout = declcfg.FSLayout(layout)
 
+			cmd.SilenceUsage = true
 			logrus.Infof("rendering index %q as file-based catalog", migrate.CatalogRef)
 			if err := migrate.Run(cmd.Context()); err != nil {
-				logrus.New().Fatal(err)
+				return err
 			}
 			logrus.Infof("wrote rendered file-based catalog to %q\n", migrate.OutputDir)
 			return nil
 		},
 	}
 	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format (json|yaml)")
+	util.AddCanonicalFlag(cmd.Flags())
+	cmd.Flags().StringVar(&layout, "layout", string(declcfg.FSLayoutPackage), "Layout of the output directory: one catalog file per package (package), or one file per package and schema (schema)")
 	cmd.Flags().StringVar(&migrateLevel, "migrate-level", "", "Name of the last migration to run (default: none)\n"+migrations.HelpText())
+	cmd.Flags().StringVar(&since, "since", "", "Only run migrations that follow the named migration, e.g. to resume a staged upgrade")
+	cmd.Flags().StringVar(&until, "until", "", "Name of the last migration to run, for use with --since (equivalent to --migrate-level)")
+	cmd.Flags().BoolVar(&migrate.Resume, "resume", false, "Resume an interrupted migration into the output directory, skipping the packages it already wrote")
+	cmd.Flags().StringSliceVar(&subset, "migrations", nil, "Comma-separated names of the migrations to run. They are always run in the order listed by \"opm alpha migrations list\"")
 
 	return cmd
 }
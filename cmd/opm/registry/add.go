@@ -1,7 +1,6 @@
 package registry
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -37,12 +36,14 @@ func newRegistryAddCmd(showAlphaHelp bool) *cobra.Command {
 	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to database file")
 	rootCmd.Flags().StringSliceP("bundle-images", "b", []string{}, "comma separated list of links to bundle image")
 	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
+	rootCmd.Flags().Bool("skip-referenced-images", false, "do not add the images referenced by RELATED_IMAGE_* environment variables and image annotations of bundle CSVs to their related images")
+	rootCmd.Flags().Int("max-parallel", 1, "maximum number of bundle images to pull and unpack at the same time")
 	rootCmd.Flags().Bool("skip-tls", false, "use Plain HTTP for container image registries while pulling bundles")
 	rootCmd.Flags().Bool("skip-tls-verify", false, "skip TLS certificate verification for container image registries while pulling bundles")
 	rootCmd.Flags().Bool("use-http", false, "use plain HTTP for container image registries while pulling bundles")
-	rootCmd.Flags().String("ca-file", "", "the root certificates to use when --container-tool=none; see docker/podman docs for certificate loading instructions")
+	rootCmd.Flags().String("ca-file", "", "the root certificates to use when --container-tool=none or podman; see docker docs for certificate loading instructions")
 	rootCmd.Flags().StringP("mode", "", "replaces", "graph update mode that defines how channel graphs are updated. One of: [replaces, semver, semver-skippatch]")
-	rootCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman]")
+	rootCmd.Flags().StringP("container-tool", "c", "none", "tool to interact with container images (save, build, etc.). One of: [none, docker, podman, nerdctl, crane]. crane can only pull images, not build or push them.")
 	rootCmd.Flags().Bool("overwrite-latest", false, "overwrite the latest bundles (channel heads) with those of the same csv name given by --bundles")
 	if err := rootCmd.Flags().MarkHidden("overwrite-latest"); err != nil {
 		logrus.Panic(err.Error())
@@ -64,10 +65,6 @@ func addFunc(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return err
 	}
-	caFile, err := cmd.Flags().GetString("ca-file")
-	if err != nil {
-		return err
-	}
 	fromFilename, err := cmd.Flags().GetString("database")
 	if err != nil {
 		return err
@@ -99,41 +96,51 @@ func addFunc(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	skipTLSVerify, useHTTP, err := util.GetTLSOptions(cmd)
+	skipReferencedImages, err := cmd.Flags().GetBool("skip-referenced-images")
 	if err != nil {
 		return err
 	}
 
-	if caFile != "" {
-		if skipTLSVerify {
-			return errors.New("--skip-tls-verify must be false when --ca-file is set")
-		}
-		if containerTool != containertools.NoneTool {
-			return fmt.Errorf("--ca-file cannot be set with --container-tool=%[1]s; "+
-				"certificates must be configured specifically for %[1]s", containerTool)
-		}
+	maxParallel, err := cmd.Flags().GetInt("max-parallel")
+	if err != nil {
+		return err
+	}
+	if maxParallel < 1 {
+		return fmt.Errorf("invalid --max-parallel value %d, must be at least 1", maxParallel)
+	}
+
+	clientConfig, err := util.GetClientConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if clientConfig.CAFile != "" && containerTool != containertools.NoneTool && containerTool != containertools.PodmanTool {
+		return fmt.Errorf("--ca-file cannot be set with --container-tool=%[1]s; "+
+			"certificates must be configured specifically for %[1]s", containerTool)
 	}
 
 	request := registry.AddToRegistryRequest{
-		Permissive:    permissive,
-		SkipTLSVerify: skipTLSVerify,
-		PlainHTTP:     useHTTP,
-		CaFile:        caFile,
-		InputDatabase: fromFilename,
-		Bundles:       bundleImages,
-		Mode:          modeEnum,
-		ContainerTool: containerTool,
-		Overwrite:     overwrite,
-		EnableAlpha:   enableAlpha,
+		Permissive:           permissive,
+		SkipTLSVerify:        clientConfig.SkipTLSVerify,
+		PlainHTTP:            clientConfig.PlainHTTP,
+		CaFile:               clientConfig.CAFile,
+		InputDatabase:        fromFilename,
+		Bundles:              bundleImages,
+		Mode:                 modeEnum,
+		ContainerTool:        containerTool,
+		Overwrite:            overwrite,
+		EnableAlpha:          enableAlpha,
+		MaxParallel:          maxParallel,
+		SkipReferencedImages: skipReferencedImages,
 	}
 
 	logger := logrus.WithFields(logrus.Fields{"bundles": bundleImages})
 
-	if skipTLSVerify {
+	if clientConfig.SkipTLSVerify {
 		logger.Warn("--skip-tls-verify flag is set: this mode is insecure and meant for development purposes only.")
 	}
 
-	if useHTTP {
+	if clientConfig.PlainHTTP {
 		logger.Warn("--use-http flag is set: this mode is insecure and meant for development purposes only.")
 	}
 
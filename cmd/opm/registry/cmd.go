@@ -30,6 +30,7 @@ func NewOpmRegistryCmd(showAlphaHelp bool) *cobra.Command {
 	rootCmd.AddCommand(newRegistryServeCmd())
 	rootCmd.AddCommand(newRegistryAddCmd(showAlphaHelp))
 	rootCmd.AddCommand(newRegistryRmCmd())
+	rootCmd.AddCommand(newRegistryRmBundleCmd())
 	rootCmd.AddCommand(newRegistryPruneCmd())
 	rootCmd.AddCommand(newRegistryPruneStrandedCmd())
 	rootCmd.AddCommand(newRegistryDeprecateCmd())
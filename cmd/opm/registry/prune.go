@@ -14,6 +14,9 @@ func newRegistryPruneCmd() *cobra.Command {
 		Short: "prune an operator registry DB of all but specified packages",
 		Long: `prune an operator registry DB of all but specified packages
 
+Use --keep-channels and --keep-versions to also prune the channels and the
+bundle versions of the kept packages.
+
 ` + sqlite.DeprecationMessage,
 
 		PreRunE: func(cmd *cobra.Command, _ []string) error {
@@ -33,6 +36,8 @@ func newRegistryPruneCmd() *cobra.Command {
 	if err := rootCmd.MarkFlagRequired("packages"); err != nil {
 		logrus.Panic("Failed to set required `packages` flag for `registry rm`")
 	}
+	rootCmd.Flags().StringSlice("keep-channels", nil, "comma separated list of channels to keep, as <channel> for every kept package or <package>:<channel> for one package. Packages without entries keep all channels")
+	rootCmd.Flags().StringArray("keep-versions", nil, "semver range of bundle versions to keep, as <range> for every kept package or <package>:<range> for one package, e.g. 'etcd:>=1.2.0 <2.0.0'. May be repeated; a bundle is kept if its version is in any range for its package. Upgrade edges are rewired around pruned bundles")
 	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
 
 	return rootCmd
@@ -47,6 +52,14 @@ func runRegistryPruneCmdFunc(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return err
 	}
+	keepChannels, err := cmd.Flags().GetStringSlice("keep-channels")
+	if err != nil {
+		return err
+	}
+	keepVersions, err := cmd.Flags().GetStringArray("keep-versions")
+	if err != nil {
+		return err
+	}
 	permissive, err := cmd.Flags().GetBool("permissive")
 	if err != nil {
 		return err
@@ -54,6 +67,8 @@ func runRegistryPruneCmdFunc(cmd *cobra.Command, _ []string) error {
 
 	request := registry.PruneFromRegistryRequest{
 		Packages:      packages,
+		KeepChannels:  keepChannels,
+		KeepVersions:  keepVersions,
 		InputDatabase: fromFilename,
 		Permissive:    permissive,
 	}
@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func newRegistryRmBundleCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "rm-bundle",
+		Short: "remove bundles from operator registry DB",
+		Long: `Remove bundles from operator registry DB
+
+By default, the upgrade graph is rewired around the removed bundles: bundles
+that replaced a removed bundle instead replace the bundle that it replaced.
+With --force-truncate, the bundles that a removed bundle replaces, directly or
+transitively, are removed as well.
+
+` + sqlite.DeprecationMessage,
+
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			return nil
+		},
+
+		RunE: rmBundleFunc,
+		Args: cobra.NoArgs,
+	}
+
+	rootCmd.Flags().Bool("debug", false, "enable debug logging")
+	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to database file")
+	rootCmd.Flags().StringSliceP("bundles", "b", nil, "comma separated list of names of the bundles to be removed")
+	if err := rootCmd.MarkFlagRequired("bundles"); err != nil {
+		logrus.Panic("Failed to set required `bundles` flag for `registry rm-bundle`")
+	}
+	rootCmd.Flags().Bool("force-truncate", false, "also remove the bundles that the removed bundles replace, instead of rewiring the upgrade graph")
+	rootCmd.Flags().Bool("permissive", false, "allow registry load errors")
+
+	return rootCmd
+}
+
+func rmBundleFunc(cmd *cobra.Command, _ []string) error {
+	fromFilename, err := cmd.Flags().GetString("database")
+	if err != nil {
+		return err
+	}
+	bundles, err := cmd.Flags().GetStringSlice("bundles")
+	if err != nil {
+		return err
+	}
+	forceTruncate, err := cmd.Flags().GetBool("force-truncate")
+	if err != nil {
+		return err
+	}
+	permissive, err := cmd.Flags().GetBool("permissive")
+	if err != nil {
+		return err
+	}
+
+	request := registry.RemoveBundlesFromRegistryRequest{
+		Bundles:       bundles,
+		ForceTruncate: forceTruncate,
+		InputDatabase: fromFilename,
+		Permissive:    permissive,
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"bundles": bundles})
+
+	logger.Info("removing bundles from the registry")
+
+	bundleRemover := registry.NewRegistryBundleRemover(logger)
+
+	err = bundleRemover.RemoveBundlesFromRegistry(request)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,10 +2,7 @@ package registry
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"net"
-	"os"
 	"strconv"
 	"time"
 
@@ -18,12 +15,12 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/lib/dns"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
-	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
 	"github.com/operator-framework/operator-registry/pkg/server"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
 
 func newRegistryServeCmd() *cobra.Command {
+	var serveOptions sqlite.ServeOptions
 	rootCmd := &cobra.Command{
 		Use:   "serve",
 		Short: "serve an operator-registry database",
@@ -38,21 +35,24 @@ func newRegistryServeCmd() *cobra.Command {
 			return nil
 		},
 
-		RunE: serveFunc,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return serveFunc(cmd, serveOptions)
+		},
 		Args: cobra.NoArgs,
 	}
 
 	rootCmd.Flags().Bool("debug", false, "enable debug logging")
 	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to sqlite db")
 	rootCmd.Flags().StringP("port", "p", "50051", "port number to serve on")
+	rootCmd.Flags().String("listen", "", "if set, address to serve on instead of --port: a TCP address (host:port), a unix domain socket (unix:///path/to/registry.sock), or fd:// or fd://<name> for a socket passed by systemd socket activation")
 	rootCmd.Flags().StringP("termination-log", "t", "/dev/termination-log", "path to a container termination log file")
-	rootCmd.Flags().Bool("skip-migrate", false, "do  not attempt to migrate to the latest db revision when starting")
+	serveOptions.AddFlags(rootCmd.Flags())
 	rootCmd.Flags().String("timeout-seconds", "infinite", "Timeout in seconds. This flag will be removed later.")
 
 	return rootCmd
 }
 
-func serveFunc(cmd *cobra.Command, _ []string) error {
+func serveFunc(cmd *cobra.Command, serveOptions sqlite.ServeOptions) error {
 	ctx, cancel := context.WithCancel(cmd.Context())
 	defer cancel()
 
@@ -80,34 +80,24 @@ func serveFunc(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return err
 	}
-
-	logger := logrus.WithFields(logrus.Fields{"database": dbName, "port": port})
-
-	// make a writable copy of the db for migrations
-	tmpdb, err := tmp.CopyTmpDB(dbName)
+	listen, err := cmd.Flags().GetString("listen")
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tmpdb)
+	addr := server.ListenAddress(listen, port)
 
-	db, err := sqlite.Open(tmpdb)
+	logger := logrus.WithFields(logrus.Fields{"database": dbName, "address": addr})
+
+	db, cleanup, err := serveOptions.OpenForServing(ctx, dbName, logger)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
 	if _, err := db.ExecContext(ctx, `PRAGMA soft_heap_limit=1`); err != nil {
 		logger.WithError(err).Warnf("error setting soft heap limit for sqlite")
 	}
 
-	// migrate to the latest version
-	shouldSkipMigrate, err := cmd.Flags().GetBool("skip-migrate")
-	if err != nil {
-		return err
-	}
-	if err := migrate(ctx, shouldSkipMigrate, db); err != nil {
-		logger.WithError(err).Warnf("couldn't migrate db")
-	}
-
 	store := sqlite.NewSQLLiteQuerierFromDb(db, sqlite.OmitManifests(true))
 
 	// sanity check that the db is available
@@ -119,7 +109,7 @@ func serveFunc(cmd *cobra.Command, _ []string) error {
 		logger.Warn("no tables found in db")
 	}
 
-	lis, err := net.Listen("tcp", ":"+port)
+	lis, err := server.Listen(addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %s", err)
 	}
@@ -158,19 +148,3 @@ func serveFunc(cmd *cobra.Command, _ []string) error {
 	logger.Info("serving registry")
 	return s.Serve(lis)
 }
-
-func migrate(ctx context.Context, shouldSkipMigrate bool, db *sql.DB) error {
-	if shouldSkipMigrate {
-		return nil
-	}
-
-	migrator, err := sqlite.NewSQLLiteMigrator(db)
-	if err != nil {
-		return err
-	}
-	if migrator == nil {
-		return fmt.Errorf("failed to load migrator")
-	}
-
-	return migrator.Migrate(ctx)
-}
@@ -2,17 +2,20 @@ package render
 
 import (
 	"io"
-	"log"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/alpha/action/migrations"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
+	"github.com/operator-framework/operator-registry/pkg/lib/config"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
 
@@ -21,48 +24,155 @@ func NewCmd(showAlphaHelp bool) *cobra.Command {
 		render           action.Render
 		output           string
 		imageRefTemplate string
+		checksumsFile    string
+		outputDir        string
+		layout           string
+		maxBundleSize    string
+		maxCatalogSize   string
+		sizeReportFile   string
 
 		oldMigrateAllFlag bool
 		migrateLevel      string
 	)
 	cmd := &cobra.Command{
-		Use:   "render [catalog-image | catalog-directory | bundle-image | bundle-directory | sqlite-file]...",
+		Use:   "render [catalog-image | catalog-oci-layout | catalog-directory | catalog-archive | catalog-git-repo | catalog-grpc-address | bundle-image | bundle-oci-layout | bundle-directory | sqlite-file]...",
 		Short: "Generate a stream of file-based catalog objects from catalogs and bundles",
 		Long: `Generate a stream of file-based catalog objects to stdout from the provided
 catalog images, file-based catalog directories, bundle images, and sqlite
 database files.
+
+File-based catalogs can also be rendered from tarballs (.tar, .tar.gz, .tgz),
+git repositories (git+<url>[#<revision>][:<subdirectory>]), and serving
+registries (grpc://<host>:<port> or grpcs://<host>:<port>). Catalog and bundle
+images can also be rendered from local OCI image layout directories, such as
+those produced by skopeo or oras, with oci-layout:<dir>[:<tag>|@<digest>].
+
+If --output-dir is set, the objects are written to files in that directory
+instead of stdout. With --layout=package (the default), each package is written
+to <output-dir>/<package>/catalog.<ext>. With --layout=schema, each package is
+split into one file per schema, e.g. <output-dir>/<package>/olm.bundle.<ext>.
+Objects that do not belong to a package are written directly in <output-dir>.
+
+If --filter-package is set, only the objects of the named packages are
+rendered, and only those packages are read from file-based catalogs, sqlite
+databases, and serving registries. If --filter-channel is set, only the named
+channels, and the bundles that are entries of them, are rendered.
+
+If --signature-file is set, the content of the rendered catalog is signed with
+cosign, which must be installed, and the signature bundle is written to that
+file. Use --attest to create an in-toto attestation of the content instead. The
+signature can be verified with 'opm validate --verify-signature'.
+
+If --properties-file is set, properties are added to the rendered bundles from
+a YAML or JSON stream of rules, e.g. to attach the support level of a vendor:
+
+  package: foo              # optional, all packages if not set
+  versionRange: ">=1.0.0"   # optional, all versions if not set
+  properties:
+    - type: example.com/support-level
+      value: premium
+
+Properties of the types olm.package, olm.bundle.object, and olm.csv.metadata
+cannot be added, and properties that a bundle already has are not added again.
+
+If --max-bundle-size or --max-catalog-size is set, render fails without writing
+any output when the combined size of the objects of a bundle, or the size of
+the whole catalog as encoded in JSON, exceeds it. Limits are expressed as
+quantities (e.g. 1Mi, 500Ki). Use --size-report to write the raw and
+gzip-compressed sizes of the catalog, and of each of its packages and bundles,
+as encoded in JSON, to a JSON file.
 `,
 		Args: cobra.MinimumNArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			render.Refs = args
 
-			var write func(declcfg.DeclarativeConfig, io.Writer) error
+			var (
+				write   func(declcfg.DeclarativeConfig, io.Writer) error
+				fileExt string
+			)
 			switch output {
 			case "yaml":
 				write = declcfg.WriteYAML
+				fileExt = ".yaml"
 			case "json":
 				write = declcfg.WriteJSON
+				fileExt = ".json"
+			default:
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --output value %q, expected (json|yaml)", output)
+			}
+			write, err := util.CanonicalWriteFunc(cmd, write)
+			if err != nil {
+				return err
+			}
+			switch declcfg.FSLayout(layout) {
+			case declcfg.FSLayoutPackage, declcfg.FSLayoutSchema:
 			default:
-				log.Fatalf("invalid --output value %q, expected (json|yaml)", output)
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --layout value %q, expected (package|schema)", layout)
+			}
+			if render.MaxParallel < 1 {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --max-parallel value %d, must be at least 1", render.MaxParallel)
+			}
+			if cmd.Flags().Changed("layout") && outputDir == "" {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "--layout requires --output-dir")
+			}
+			if outputDir != "" {
+				entries, err := os.ReadDir(outputDir)
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				if len(entries) > 0 {
+					return liberrors.Errorf(liberrors.CodeOutputConflict, "output dir %q must be empty", outputDir)
+				}
+			}
+
+			var limits config.SizeLimits
+			for _, l := range []struct {
+				flag  string
+				value string
+				dest  *int64
+			}{
+				{"max-bundle-size", maxBundleSize, &limits.MaxBundleSize},
+				{"max-catalog-size", maxCatalogSize, &limits.MaxCatalogSize},
+			} {
+				if l.value == "" {
+					continue
+				}
+				q, err := resource.ParseQuantity(l.value)
+				if err != nil {
+					return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --%s value %q: %v", l.flag, l.value, err)
+				}
+				*l.dest = q.Value()
+			}
+
+			render.BundleProperties, err = util.LoadBundleProperties(cmd)
+			if err != nil {
+				return liberrors.Errorf(liberrors.CodeInvalidArgument, "%v", err)
 			}
 
+			// The flags are valid, so usage does not help with the errors that follow.
+			cmd.SilenceUsage = true
+
 			// The bundle loading impl is somewhat verbose, even on the happy path,
 			// so discard all logrus default logger logs. Any important failures will be
-			// returned from render.Run and logged as fatal errors.
+			// returned from render.Run and reported as the error of the command.
 			logrus.SetOutput(io.Discard)
 
 			reg, err := util.CreateCLIRegistry(cmd)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 			defer reg.Destroy()
 
 			render.Registry = reg
+			render.LoadRefOptions, err = util.CreateLoadRefOptions(cmd, reg)
+			if err != nil {
+				return err
+			}
 
 			if imageRefTemplate != "" {
 				tmpl, err := template.New("image-ref-template").Parse(imageRefTemplate)
 				if err != nil {
-					log.Fatalf("invalid image reference template: %v", err)
+					return liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid image reference template: %v", err)
 				}
 				render.ImageRefTemplate = tmpl
 			}
@@ -75,18 +185,43 @@ database files.
 				m, err = migrations.NewMigrations(migrateLevel)
 			}
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 			render.Migrations = m
 
 			cfg, err := render.Run(cmd.Context())
 			if err != nil {
-				log.Fatal(err)
+				return err
+			}
+
+			if err := checkSizes(*cfg, limits); err != nil {
+				return err
+			}
+
+			if outputDir != "" {
+				if err := declcfg.WriteFS(*cfg, outputDir, write, fileExt, declcfg.WithFSLayout(declcfg.FSLayout(layout))); err != nil {
+					return err
+				}
+			} else if err := write(*cfg, os.Stdout); err != nil {
+				return err
+			}
+
+			if err := util.SignCatalog(cmd.Context(), cmd, *cfg); err != nil {
+				return err
+			}
+
+			if checksumsFile != "" {
+				if err := writeChecksums(*cfg, checksumsFile); err != nil {
+					return err
+				}
 			}
 
-			if err := write(*cfg, os.Stdout); err != nil {
-				log.Fatal(err)
+			if sizeReportFile != "" {
+				if err := config.WriteSizeReport(*cfg, sizeReportFile); err != nil {
+					return err
+				}
 			}
+			return nil
 		},
 	}
 	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format of the streamed file-based catalog objects (json|yaml)")
@@ -94,6 +229,20 @@ database files.
 	cmd.Flags().StringVar(&migrateLevel, "migrate-level", "", "Name of the last migration to run (default: none)\n"+migrations.HelpText())
 	cmd.Flags().BoolVar(&oldMigrateAllFlag, "migrate", false, "Perform all available schema migrations on the rendered FBC")
 	cmd.MarkFlagsMutuallyExclusive("migrate", "migrate-level")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "If set, write the file-based catalog objects to files in this directory instead of stdout. The directory must be empty or not exist")
+	cmd.Flags().StringVar(&layout, "layout", string(declcfg.FSLayoutPackage), "Layout of --output-dir: one catalog file per package (package), or one file per package and schema (schema)")
+	cmd.Flags().BoolVar(&render.SkipReferencedImages, "skip-referenced-images", false, "Do not add the images referenced by RELATED_IMAGE_* environment variables and image annotations of bundle CSVs to their related images")
+	cmd.Flags().IntVar(&render.MaxParallel, "max-parallel", 1, "Maximum number of references, e.g. bundle images, to pull and render at the same time. The output order does not depend on it")
+	cmd.Flags().StringSliceVar(&render.FilterPackages, "filter-package", nil, "Only render the objects of these packages")
+	cmd.Flags().StringSliceVar(&render.FilterChannels, "filter-channel", nil, "Only render these channels, and the bundles that are entries of them")
+	util.AddBlobCacheFlags(cmd.Flags())
+	util.AddSignFlags(cmd.Flags())
+	util.AddBundlePropertiesFlags(cmd.Flags())
+	util.AddCanonicalFlag(cmd.Flags())
+	cmd.Flags().StringVar(&checksumsFile, "checksums-file", "", "If set, write per-package content checksums of the rendered file-based catalog to this file")
+	cmd.Flags().StringVar(&maxBundleSize, "max-bundle-size", "", "If set, fail when the combined size of all objects in a bundle exceeds this quantity (e.g. 1Mi)")
+	cmd.Flags().StringVar(&maxCatalogSize, "max-catalog-size", "", "If set, fail when the size of the rendered catalog, as encoded in JSON, exceeds this quantity (e.g. 100Mi)")
+	cmd.Flags().StringVar(&sizeReportFile, "size-report", "", "If set, write a JSON report of the sizes of the rendered catalog, its packages, and its bundles to this file")
 
 	// Alpha flags
 	cmd.Flags().StringVar(&imageRefTemplate, "alpha-image-ref-template", "", "When bundle image reference information is unavailable, populate it with this template")
@@ -101,8 +250,9 @@ database files.
 	if showAlphaHelp {
 		cmd.Long += `
 If rendering sources that do not carry bundle image reference information
-(e.g. bundle directories), the --alpha-image-ref-template flag can be used to
-generate image references for the rendered file-based catalog objects.
+(e.g. bundle directories or OCI layouts), the --alpha-image-ref-template flag
+can be used to generate image references for the rendered file-based catalog
+objects.
 This is useful when generating a catalog with image references prior to
 those images actually existing. Available template variables are:
   - {{.Package}} : the package name the bundle belongs to
@@ -113,3 +263,33 @@ those images actually existing. Available template variables are:
 	cmd.Long += "\n" + sqlite.DeprecationMessage
 	return cmd
 }
+
+func writeChecksums(cfg declcfg.DeclarativeConfig, filename string) error {
+	checksums, err := declcfg.ComputeChecksums(cfg)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return declcfg.WriteChecksums(*checksums, f)
+}
+
+// checkSizes returns an error that lists the size limits that cfg exceeds, if
+// any.
+func checkSizes(cfg declcfg.DeclarativeConfig, limits config.SizeLimits) error {
+	violations, err := config.CheckSizes(cfg, limits)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(violations))
+	for _, v := range violations {
+		msgs = append(msgs, v.String())
+	}
+	return liberrors.Errorf(liberrors.CodeInvalidCatalog, "rendered catalog exceeds size limits:\n%s", strings.Join(msgs, "\n"))
+}
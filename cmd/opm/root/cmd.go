@@ -18,6 +18,7 @@ import (
 	"github.com/operator-framework/operator-registry/cmd/opm/serve"
 	"github.com/operator-framework/operator-registry/cmd/opm/validate"
 	"github.com/operator-framework/operator-registry/cmd/opm/version"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
 )
 
 func NewCmd(showAlphaHelp bool) *cobra.Command {
@@ -40,6 +41,13 @@ To view help related to alpha features, set HELP_ALPHA=true in the environment.`
 	cmd.PersistentFlags().Bool("skip-tls", false, "skip TLS certificate verification for container image registries while pulling bundles or index")
 	cmd.PersistentFlags().Bool("skip-tls-verify", false, "skip TLS certificate verification for container image registries while pulling bundles")
 	cmd.PersistentFlags().Bool("use-http", false, "use plain HTTP for container image registries while pulling bundles")
+	cmd.PersistentFlags().String("ca-file", "", "file of PEM certificates that are trusted, in addition to the system's, to verify container image registries")
+	var logOptions log.Options
+	logOptions.AddFlags(cmd.PersistentFlags())
+	// Subcommands set their own pre-run hooks, so the options are applied
+	// once the flags of any command are parsed.
+	cobra.OnInitialize(func() { log.Configure(logOptions) })
+	cmd.PersistentFlags().String("error-format", ErrorFormatText, "Format of the error that opm reports when it fails (text|json). In json format, errors are written to stderr as JSON objects with a machine-readable code")
 	if err := cmd.PersistentFlags().MarkDeprecated("skip-tls", "use --use-http and --skip-tls-verify instead"); err != nil {
 		logrus.Panic(err.Error())
 	}
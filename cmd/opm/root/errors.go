@@ -0,0 +1,111 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+// The formats of the --error-format flag.
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// jsonError is the JSON object that opm writes to stderr for the error it
+// fails with, when --error-format=json is set.
+type jsonError struct {
+	Code    liberrors.Code `json:"code"`
+	Message string         `json:"message"`
+	// Errors are the errors of an aggregate error.
+	Errors []jsonError `json:"errors,omitempty"`
+}
+
+func newJSONError(err error) jsonError {
+	e := jsonError{Code: liberrors.CodeOf(err), Message: err.Error()}
+	if agg, ok := err.(utilerrors.Aggregate); ok && len(agg.Errors()) > 1 {
+		for _, err := range agg.Errors() {
+			e.Errors = append(e.Errors, newJSONError(err))
+		}
+	}
+	return e
+}
+
+// ErrorFormat returns the value of the --error-format flag in args, the
+// arguments of opm. It is read from the arguments, rather than from the
+// parsed flags, so that errors parsing the flags are reported in the format
+// too.
+func ErrorFormat(args []string) (string, error) {
+	format := ErrorFormatText
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--error-format="); ok {
+			format = v
+		} else if arg == "--error-format" && i+1 < len(args) {
+			format = args[i+1]
+		}
+	}
+	switch format {
+	case ErrorFormatText, ErrorFormatJSON:
+		return format, nil
+	}
+	return ErrorFormatText, liberrors.Errorf(liberrors.CodeInvalidArgument, "invalid --error-format value %q, expected (text|json)", format)
+}
+
+// SetErrorFormat configures cmd, and the loggers that its commands use, to
+// report errors in format to w. Errors that cmd returns are not printed by
+// cmd; print them with PrintError.
+func SetErrorFormat(cmd *cobra.Command, format string, w io.Writer) {
+	cmd.SilenceErrors = true
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return liberrors.New(liberrors.CodeInvalidArgument, err)
+	})
+	if format != ErrorFormatJSON {
+		return
+	}
+	cmd.SilenceUsage = true
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	log.SetFlags(0)
+	log.SetOutput(jsonLogWriter{w})
+}
+
+// PrintError writes err to w in format.
+func PrintError(w io.Writer, format string, err error) {
+	if format != ErrorFormatJSON {
+		fmt.Fprintln(w, "Error:", err.Error())
+		return
+	}
+	data, jsonErr := json.Marshal(newJSONError(err))
+	if jsonErr != nil {
+		fmt.Fprintln(w, "Error:", err.Error())
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
+
+// jsonLogWriter writes the messages of the standard logger, which commands
+// use to fail with log.Fatal, as JSON errors without a code.
+type jsonLogWriter struct {
+	w io.Writer
+}
+
+func (l jsonLogWriter) Write(p []byte) (int, error) {
+	data, err := json.Marshal(jsonError{Code: liberrors.CodeUnknown, Message: strings.TrimSuffix(string(p), "\n")})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fmt.Fprintln(l.w, string(data)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,112 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/cache"
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+// reloader reloads the served catalog from the config directories, one
+// reload at a time, whether it is triggered by --watch, by SIGHUP, or by the
+// Admin API. If a reload fails, the previously loaded configs continue to be
+// served, and readiness reports the catalog as degraded until a reload
+// succeeds.
+type reloader struct {
+	s         *serve
+	store     *cache.Swappable
+	readiness *server.Readiness
+	logger    *logrus.Entry
+
+	mu sync.Mutex
+}
+
+var _ server.Reloader = &reloader{}
+
+// errStillLoading is returned by reloads that are requested while the catalog
+// is loading for the first time.
+var errStillLoading = status.Error(codes.FailedPrecondition, "catalog is still loading")
+
+// Reload rebuilds the cache from the config directories, swaps it into the
+// store, and returns the digest of the new content.
+func (r *reloader) Reload(ctx context.Context) (string, error) {
+	if state, _ := r.readiness.State(); state == api.CatalogStatus_LOADING {
+		return "", errStillLoading
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := time.Now()
+	if err := r.s.reload(ctx, r.store, r.logger); err != nil {
+		if ctx.Err() != nil {
+			// The reload was canceled, which says nothing about the configs.
+			return "", status.FromContextError(ctx.Err()).Err()
+		}
+		r.logger.WithError(err).Error("failed to reload configs, continuing to serve the previously loaded configs")
+		r.readiness.SetDegraded(fmt.Sprintf("failed to reload configs: %v", err))
+		return "", status.Errorf(codes.FailedPrecondition, "failed to reload configs: %v", err)
+	}
+	r.readiness.SetServing()
+
+	checksums, err := r.store.GetPackageChecksums(ctx)
+	if err != nil {
+		return "", err
+	}
+	digest := server.CatalogDigest(checksums)
+	r.logger.WithFields(logrus.Fields{
+		"duration": time.Since(start).String(),
+		"digest":   digest,
+	}).Info("reloaded configs")
+	return digest, nil
+}
+
+// notifySIGHUP returns a channel that receives SIGHUP, instead of the process
+// being terminated by it, and a function that stops the notifications.
+func notifySIGHUP() (<-chan os.Signal, func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	return sigs, func() { signal.Stop(sigs) }
+}
+
+// reloadOnSignals reloads the catalog whenever sigs receives a signal, until
+// ctx is done.
+func (r *reloader) reloadOnSignals(ctx context.Context, sigs <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+		}
+		r.logger.Info("received SIGHUP, reloading configs")
+		if _, err := r.Reload(ctx); errors.Is(err, errStillLoading) {
+			r.logger.Warn("ignoring SIGHUP: the catalog is still loading")
+		}
+	}
+}
+
+// readAdminToken reads the bearer token of the Admin API from path.
+func readAdminToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("admin token file %q is empty", path)
+	}
+	return token, nil
+}
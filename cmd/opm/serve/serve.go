@@ -3,22 +3,30 @@ package serve
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net"
 	"net/http"
 	endpoint "net/http/pprof"
 	"os"
+	"runtime/debug"
 	"runtime/pprof"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	health "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/cache"
@@ -28,13 +36,27 @@ import (
 )
 
 type serve struct {
-	configDir             string
+	configDirs            []string
 	cacheDir              string
 	cacheOnly             bool
 	cacheEnforceIntegrity bool
+	cacheConcurrency      int
+	maxMemory             string
+	maxMemoryBytes        int64
+	warmupTimeout         time.Duration
+	watch                 bool
+	watchInterval         time.Duration
 
 	port           string
+	listen         string
+	httpPort       string
 	terminationLog string
+	compression    string
+	tlsCert        string
+	tlsKey         string
+	clientCA       string
+	adminTokenFile string
+	rateLimits     server.RateLimits
 
 	debug           bool
 	pprofAddr       string
@@ -48,29 +70,87 @@ const (
 )
 
 func NewCmd() *cobra.Command {
-	logger := logrus.New()
+	logger := log.New()
 	s := serve{
 		logger: logrus.NewEntry(logger),
 	}
 	cmd := &cobra.Command{
-		Use:   "serve <source_path>",
+		Use:   "serve <source_path>...",
 		Short: "serve declarative configs",
 		Long: `This command serves declarative configs via a GRPC server.
 
 NOTE: The declarative config directory is loaded by the serve command at
 startup. Changes made to the declarative config after the this command starts
-will not be reflected in the served content.
+will not be reflected in the served content, unless --watch is set.
+
+When more than one declarative config directory is given, the union of their
+catalogs is served. Each package must be defined by a single directory: the
+command fails if the objects of a package, such as the package itself or one of
+its bundles, are found in more than one. The directories are merged in the
+order they are given, so the same directories in the same order always produce
+the same cache.
+
+With --watch, the declarative config directory is checked for changes every
+--watch-interval. Once a change has settled, a new cache is built from the
+directory and swapped in atomically. Requests that are in flight during the
+swap complete against the previous content, and connections are not dropped.
+If the changed configs fail to load, the previous content continues to be
+served. Reloaded caches are built in temporary directories, so --cache-dir
+only holds the cache of the configs loaded at startup.
+
+A reload can also be triggered explicitly, whether or not --watch is set, by
+sending the process SIGHUP, or, with --admin-token-file, by calling the Reload
+RPC of the Admin gRPC service, or POST /api/v1/admin/reload with --http-port,
+with the token of the file as a bearer token in the authorization header. The
+Admin API returns once the new content is served, with its digest. Reloads
+are done one at a time, and fail while the catalog is loading.
+
+The registry accepts connections while the declarative config directory is
+being loaded. Until it has been loaded, the health check reports NOT_SERVING
+and the Registry API returns UNAVAILABLE. The GetCatalogStatus RPC, and
+/api/v1/catalog/status with --http-port, report the state of the catalog:
+LOADING, SERVING, or DEGRADED if a --watch reload failed and the previous
+content is still served, with the digest of the served content.
+
+With --listen, the registry is served on a TCP address, a unix domain socket,
+e.g. unix:///run/registry/registry.sock for sidecars, or a socket passed by
+systemd socket activation (fd://), instead of on --port.
+
+With --tls-cert and --tls-key, the registry is served over TLS, on both the
+gRPC and the --http-port endpoints. With --client-ca as well, clients must
+present a certificate signed by one of its CAs. The certificate and key are
+reloaded when their files change.
+
+Calls of the Registry API, over gRPC and --http-port, can be rate limited per
+client, by IP address, and globally, and the number of calls that are handled
+at once can be capped, so that a runaway client cannot exhaust the CPU of the
+registry. A stream, e.g. of ListBundles, counts as one call, until it ends.
+Calls that exceed a limit fail with RESOURCE_EXHAUSTED, or 429 Too Many
+Requests over HTTP, and should be retried with backoff. All limits are disabled
+by default. Limits that suit a catalog pod are:
+
+  --rate-limit=200 --rate-limit-burst=400
+  --client-rate-limit=50 --client-rate-limit-burst=100
+  --max-concurrent-requests=64 --max-concurrent-requests-per-client=16
+
+Clients behind a proxy or NAT share the limits of a single client. Health checks
+and the Admin API are not limited.
+
+RPCs are traced with OpenTelemetry when the OTEL_TRACES_EXPORTER environment
+variable is set, e.g. to otlp, with spans exported as configured by the
+standard OTEL_EXPORTER_OTLP_* variables. Trace context propagated by clients is
+honored.
 `,
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MinimumNArgs(1),
 		PreRun: func(_ *cobra.Command, args []string) {
-			s.configDir = args[0]
+			s.configDirs = args
 			if s.debug {
 				logger.SetLevel(logrus.DebugLevel)
 			}
 		},
 		Run: func(cmd *cobra.Command, _ []string) {
 			if !cmd.Flags().Changed("cache-enforce-integrity") {
-				s.cacheEnforceIntegrity = s.cacheDir != "" && !s.cacheOnly
+				s.cacheEnforceIntegrity = s.cacheDir != "" && !s.cacheOnly && !s.watch
 			}
 			if err := s.run(cmd.Context()); err != nil {
 				logger.Fatal(err)
@@ -81,11 +161,29 @@ will not be reflected in the served content.
 	cmd.Flags().BoolVar(&s.debug, "debug", false, "enable debug logging")
 	cmd.Flags().StringVarP(&s.terminationLog, "termination-log", "t", "/dev/termination-log", "path to a container termination log file")
 	cmd.Flags().StringVarP(&s.port, "port", "p", "50051", "port number to serve on")
+	cmd.Flags().StringVar(&s.listen, "listen", "", "if set, address to serve on instead of --port: a TCP address (host:port), a unix domain socket (unix:///path/to/registry.sock), or fd:// or fd://<name> for a socket passed by systemd socket activation")
+	cmd.Flags().StringVar(&s.httpPort, "http-port", "", "if set, also serve the registry API as JSON over HTTP on this port")
+	cmd.Flags().StringVar(&s.compression, "compression", "", "if set, compress gRPC responses with this algorithm (gzip|deflate) when the client supports it, even if its requests are not compressed")
+	cmd.Flags().StringVar(&s.tlsCert, "tls-cert", "", "path to a PEM encoded certificate to serve the registry over TLS with. Requires --tls-key")
+	cmd.Flags().StringVar(&s.tlsKey, "tls-key", "", "path to the PEM encoded private key of --tls-cert")
+	cmd.Flags().StringVar(&s.adminTokenFile, "admin-token-file", "", "if set, serve the Admin API, authenticated with the bearer token in this file")
+	cmd.Flags().StringVar(&s.clientCA, "client-ca", "", "path to PEM encoded CA certificates. If set, clients must present a certificate signed by one of them (mutual TLS). Requires --tls-cert")
 	cmd.Flags().StringVar(&s.pprofAddr, "pprof-addr", "localhost:6060", "address of startup profiling endpoint (addr:port format)")
 	cmd.Flags().BoolVar(&s.captureProfiles, "pprof-capture-profiles", false, "capture pprof CPU profiles")
 	cmd.Flags().StringVar(&s.cacheDir, "cache-dir", "", "if set, sync and persist server cache directory")
 	cmd.Flags().BoolVar(&s.cacheOnly, "cache-only", false, "sync the serve cache and exit without serving")
 	cmd.Flags().BoolVar(&s.cacheEnforceIntegrity, "cache-enforce-integrity", false, "exit with error if cache is not present or has been invalidated. (default: true when --cache-dir is set and --cache-only is false, false otherwise), ")
+	cmd.Flags().IntVar(&s.cacheConcurrency, "cache-build-concurrency", 0, "maximum number of packages processed in parallel when building the cache (default: number of CPUs)")
+	cmd.Flags().DurationVar(&s.warmupTimeout, "cache-warmup-timeout", 0, "if set, pre-load the package index and default channel head bundles before serving, for at most this long. The health check does not report SERVING until warmup finishes or times out")
+	cmd.Flags().BoolVar(&s.watch, "watch", false, "reload the served content when the declarative config directory changes")
+	cmd.Flags().DurationVar(&s.watchInterval, "watch-interval", 10*time.Second, "how often to check the declarative config directory for changes when --watch is set")
+	cmd.Flags().Float64Var(&s.rateLimits.Rate, "rate-limit", 0, "number of Registry API calls per second allowed across all clients (0 disables the limit)")
+	cmd.Flags().IntVar(&s.rateLimits.Burst, "rate-limit-burst", 0, "number of Registry API calls above --rate-limit allowed at once (0 defaults to --rate-limit)")
+	cmd.Flags().Float64Var(&s.rateLimits.ClientRate, "client-rate-limit", 0, "number of Registry API calls per second allowed for each client (0 disables the limit)")
+	cmd.Flags().IntVar(&s.rateLimits.ClientBurst, "client-rate-limit-burst", 0, "number of Registry API calls above --client-rate-limit allowed at once for each client (0 defaults to --client-rate-limit)")
+	cmd.Flags().IntVar(&s.rateLimits.MaxConcurrent, "max-concurrent-requests", 0, "maximum number of Registry API calls handled at once across all clients (0 disables the limit)")
+	cmd.Flags().IntVar(&s.rateLimits.MaxConcurrentPerClient, "max-concurrent-requests-per-client", 0, "maximum number of Registry API calls handled at once for each client (0 disables the limit)")
+	cmd.Flags().StringVar(&s.maxMemory, "max-memory", "", "approximate memory available to the process, as a quantity (e.g. 256Mi). Bounds the memory used to build the cache, and sets the Go runtime soft memory limit unless GOMEMLIMIT is set")
 	return cmd
 }
 
@@ -93,6 +191,35 @@ func (s *serve) run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	var (
+		streamCompression grpc.StreamServerInterceptor
+		unaryCompression  grpc.UnaryServerInterceptor
+	)
+	if s.compression != "" {
+		var err error
+		streamCompression, unaryCompression, err = server.CompressionInterceptors(s.compression)
+		if err != nil {
+			return fmt.Errorf("invalid --compression value: %v", err)
+		}
+	}
+
+	streamRateLimit, unaryRateLimit, err := server.RateLimitInterceptors(s.rateLimits)
+	if err != nil {
+		return fmt.Errorf("invalid rate limits: %v", err)
+	}
+
+	var tlsConfig *tls.Config
+	if s.tlsCert != "" || s.tlsKey != "" || s.clientCA != "" {
+		if s.tlsCert == "" || s.tlsKey == "" {
+			return fmt.Errorf("--tls-cert and --tls-key must be set together, and are required by --client-ca")
+		}
+		var err error
+		tlsConfig, err = server.TLSConfig(s.tlsCert, s.tlsKey, s.clientCA)
+		if err != nil {
+			return fmt.Errorf("invalid TLS configuration: %v", err)
+		}
+	}
+
 	mainLogger := s.logger.Dup()
 	p := newProfilerInterface(s.pprofAddr, mainLogger)
 	if err := p.startEndpoint(); err != nil {
@@ -105,7 +232,7 @@ func (s *serve) run(ctx context.Context) error {
 	}
 
 	// Immediately set up termination log
-	err := log.AddDefaultWriterHooks(s.terminationLog)
+	err = log.AddDefaultWriterHooks(s.terminationLog)
 	if err != nil {
 		mainLogger.WithError(err).Warn("unable to set termination log path")
 	}
@@ -118,6 +245,17 @@ func (s *serve) run(ctx context.Context) error {
 	if s.cacheDir == "" && s.cacheEnforceIntegrity {
 		return fmt.Errorf("--cache-dir must be specified with --cache-enforce-integrity")
 	}
+	if s.watch {
+		if s.cacheOnly {
+			return fmt.Errorf("--watch cannot be used with --cache-only")
+		}
+		if s.cacheEnforceIntegrity {
+			return fmt.Errorf("--watch cannot be used with --cache-enforce-integrity")
+		}
+		if s.watchInterval <= 0 {
+			return fmt.Errorf("invalid --watch-interval %s: must be positive", s.watchInterval)
+		}
+	}
 
 	if s.cacheDir == "" {
 		s.cacheDir, err = os.MkdirTemp("", "opm-serve-cache-")
@@ -127,60 +265,253 @@ func (s *serve) run(ctx context.Context) error {
 		defer os.RemoveAll(s.cacheDir)
 	}
 	mainLogger = mainLogger.WithFields(logrus.Fields{
-		"configs": s.configDir,
+		"configs": strings.Join(s.configDirs, ","),
 		"cache":   s.cacheDir,
 	})
 
-	store, err := cache.New(s.cacheDir, cache.WithLog(mainLogger))
-	if err != nil {
-		return err
-	}
-	defer store.Close()
-	if s.cacheEnforceIntegrity {
-		if err := store.CheckIntegrity(ctx, os.DirFS(s.configDir)); err != nil {
-			return fmt.Errorf("integrity check failed: %v", err)
+	if s.maxMemory != "" {
+		q, err := resource.ParseQuantity(s.maxMemory)
+		if err != nil {
+			return fmt.Errorf("invalid --max-memory value %q: %v", s.maxMemory, err)
 		}
-		if err := store.Load(ctx); err != nil {
-			return fmt.Errorf("failed to load cache: %v", err)
+		s.maxMemoryBytes = q.Value()
+		if _, ok := os.LookupEnv("GOMEMLIMIT"); !ok {
+			debug.SetMemoryLimit(s.maxMemoryBytes)
 		}
-	} else {
-		if err := cache.LoadOrRebuild(ctx, store, os.DirFS(s.configDir)); err != nil {
-			return fmt.Errorf("failed to load or rebuild cache: %v", err)
+	}
+
+	// Fingerprint the configs before they are loaded, so that changes made
+	// while they are loading are picked up by the watcher.
+	var loaded string
+	if s.watch {
+		loaded, err = fingerprint(s.configDirs)
+		if err != nil {
+			return fmt.Errorf("failed to watch configs: %v", err)
 		}
 	}
 
+	c, err := cache.New(s.cacheDir, cache.WithLog(mainLogger), cache.WithConcurrency(s.cacheConcurrency), cache.WithMaxMemory(s.maxMemoryBytes))
+	if err != nil {
+		return err
+	}
+	store := cache.Cache(c)
+	defer func() { store.Close() }()
+
 	if s.cacheOnly {
-		return nil
+		return s.load(ctx, store)
+	}
+
+	var adminToken string
+	if s.adminTokenFile != "" {
+		adminToken, err = readAdminToken(s.adminTokenFile)
+		if err != nil {
+			return fmt.Errorf("invalid --admin-token-file: %v", err)
+		}
 	}
 
-	mainLogger = mainLogger.WithFields(logrus.Fields{"port": s.port})
+	swappable := cache.NewSwappable(store)
+	store = swappable
+
+	// Serve before the cache is loaded, so that clients can tell a registry
+	// that is loading from one that is down. Until the cache is loaded, the
+	// health check reports NOT_SERVING and the Registry API is unavailable.
+	readiness := server.NewReadiness()
+	reloader := &reloader{s: s, store: swappable, readiness: readiness, logger: s.logger.WithField("configs", strings.Join(s.configDirs, ","))}
+
+	// Handle SIGHUP from now on, so that it does not terminate the process
+	// while the catalog is loading.
+	sighup, stopSIGHUP := notifySIGHUP()
+	defer stopSIGHUP()
 
-	lis, err := net.Listen("tcp", ":"+s.port)
+	addr := server.ListenAddress(s.listen, s.port)
+	mainLogger = mainLogger.WithFields(logrus.Fields{"address": addr})
+
+	lis, err := server.Listen(addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %s", err)
 	}
 
+	streamReadiness, unaryReadiness := readiness.Interceptors()
+	streamTracing, unaryTracing := server.TracingInterceptors()
 	streamLogger, unaryLogger := loggingInterceptors(s.logger.Dup())
-	grpcServer := grpc.NewServer(
-		grpc.ChainStreamInterceptor(streamLogger),
-		grpc.ChainUnaryInterceptor(unaryLogger),
-	)
-	api.RegisterRegistryServer(grpcServer, server.NewRegistryServer(store))
-	health.RegisterHealthServer(grpcServer, server.NewHealthServer())
+	streamInterceptors := []grpc.StreamServerInterceptor{streamTracing, streamLogger, streamReadiness, streamRateLimit}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{unaryTracing, unaryLogger, unaryReadiness, unaryRateLimit}
+	// HTTP requests are served through the same interceptors, other than
+	// compression, which is specific to the gRPC transport.
+	httpOpts := []server.HTTPOption{server.WithHTTPInterceptors(slices.Clone(streamInterceptors), slices.Clone(unaryInterceptors))}
+	if streamCompression != nil {
+		streamInterceptors = append(streamInterceptors, streamCompression)
+		unaryInterceptors = append(unaryInterceptors, unaryCompression)
+		mainLogger = mainLogger.WithField("compression", s.compression)
+	}
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+	}
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		mainLogger = mainLogger.WithFields(logrus.Fields{"tls": true, "mtls": s.clientCA != ""})
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	registryServer := server.NewRegistryServer(store, server.WithReadiness(readiness))
+	healthServer := server.NewHealthServer(server.WithHealthReadiness(readiness))
+	api.RegisterRegistryServer(grpcServer, registryServer)
+	health.RegisterHealthServer(grpcServer, healthServer)
+	var adminServer *server.AdminServer
+	if adminToken != "" {
+		adminServer = server.NewAdminServer(reloader, adminToken)
+		api.RegisterAdminServer(grpcServer, adminServer)
+	}
 	reflection.Register(grpcServer)
-	mainLogger.Info("serving registry")
-	p.stopCpuProfileCache()
 
+	var httpServer *http.Server
+	if s.httpPort != "" {
+		httpLis, err := net.Listen("tcp", ":"+s.httpPort)
+		if err != nil {
+			return fmt.Errorf("failed to listen for http: %s", err)
+		}
+		var handler http.Handler = server.NewHTTPHandler(registryServer, healthServer, httpOpts...)
+		if adminServer != nil {
+			mux := http.NewServeMux()
+			mux.Handle("/", handler)
+			mux.Handle("/api/v1/admin/", server.NewAdminHTTPHandler(adminServer))
+			handler = mux
+		}
+		httpServer = &http.Server{Handler: handler}
+		serveHTTP := func() error { return httpServer.Serve(httpLis) }
+		if tlsConfig != nil {
+			httpServer.TLSConfig = tlsConfig.Clone()
+			serveHTTP = func() error { return httpServer.ServeTLS(httpLis, "", "") }
+		}
+		go func() {
+			mainLogger.WithField("http-port", s.httpPort).Info("serving registry over http")
+			if err := serveHTTP(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				mainLogger.WithError(err).Error("http server failed")
+			}
+		}()
+	}
+
+	serveDone := make(chan struct{})
 	go func() {
+		defer close(serveDone)
 		<-ctx.Done()
 		mainLogger.Info("shutting down server")
+		if httpServer != nil {
+			if err := httpServer.Shutdown(context.Background()); err != nil {
+				mainLogger.Warnf("error shutting down http server: %v", err)
+			}
+		}
 		grpcServer.GracefulStop()
 		if err := p.stopEndpoint(ctx); err != nil {
 			mainLogger.Warnf("error shutting down pprof server: %v", err)
 		}
 	}()
 
-	return grpcServer.Serve(lis)
+	serveErr := make(chan error, 1)
+	go func() {
+		mainLogger.Info("serving registry")
+		serveErr <- grpcServer.Serve(lis)
+	}()
+	// Stop serving before the store is closed.
+	defer func() {
+		cancel()
+		<-serveDone
+	}()
+
+	// Stop reloading before the store is closed, so that no cache is
+	// swapped in after that.
+	reloadsDone := make(chan struct{})
+	go func() {
+		defer close(reloadsDone)
+		reloader.reloadOnSignals(ctx, sighup)
+	}()
+	defer func() {
+		cancel()
+		<-reloadsDone
+	}()
+
+	if err := s.load(ctx, store); err != nil {
+		return err
+	}
+	if s.warmupTimeout > 0 {
+		s.warmup(ctx, store, mainLogger)
+	}
+	readiness.SetServing()
+	mainLogger.Info("catalog loaded")
+	p.stopCpuProfileCache()
+
+	if s.watch {
+		watchDone := make(chan struct{})
+		go func() {
+			defer close(watchDone)
+			s.watchConfigs(ctx, reloader, loaded, s.logger.WithField("configs", strings.Join(s.configDirs, ",")))
+		}()
+		// Stop the watcher before the store is closed, so that it does
+		// not swap in a cache after that.
+		defer func() {
+			cancel()
+			<-watchDone
+		}()
+		mainLogger.WithField("interval", s.watchInterval.String()).Info("watching configs for changes")
+	}
+
+	return <-serveErr
+}
+
+// load loads the cache from the config directory, rebuilding it if needed,
+// or, with --cache-enforce-integrity, fails if it needs rebuilding.
+func (s *serve) load(ctx context.Context, store cache.Cache) error {
+	if s.cacheEnforceIntegrity {
+		if err := store.CheckIntegrity(ctx, s.configs()); err != nil {
+			return fmt.Errorf("integrity check failed: %v", err)
+		}
+		if err := store.Load(ctx); err != nil {
+			return fmt.Errorf("failed to load cache: %v", err)
+		}
+		return nil
+	}
+	if err := cache.LoadOrRebuild(ctx, store, s.configs()); err != nil {
+		return fmt.Errorf("failed to load or rebuild cache: %v", err)
+	}
+	return nil
+}
+
+// configs returns the filesystem of the declarative configs to serve. A
+// single config directory is served as is, so that the digest of its cache
+// does not depend on how many directories are served.
+func (s *serve) configs() fs.FS {
+	if len(s.configDirs) == 1 {
+		return os.DirFS(s.configDirs[0])
+	}
+	roots := make([]cache.Root, 0, len(s.configDirs))
+	for _, dir := range s.configDirs {
+		roots = append(roots, cache.Root{Name: dir, FS: os.DirFS(dir)})
+	}
+	return cache.MergeRoots(roots...)
+}
+
+// warmup pre-loads the most commonly queried parts of the cache, bounded by
+// the warmup timeout. Warmup failures are not fatal: they only mean the first
+// requests are slower.
+func (s *serve) warmup(ctx context.Context, store cache.Cache, logger *logrus.Entry) {
+	ctx, cancel := context.WithTimeout(ctx, s.warmupTimeout)
+	defer cancel()
+
+	start := time.Now()
+	stats, err := cache.Warmup(ctx, store)
+	logger = logger.WithFields(logrus.Fields{
+		"packages": stats.Packages,
+		"bundles":  stats.Bundles,
+		"duration": time.Since(start).String(),
+	})
+	switch {
+	case err != nil:
+		logger.WithError(err).Warn("cache warmup failed")
+	case !stats.Complete:
+		logger.Warn("cache warmup timed out")
+	default:
+		logger.Info("cache warmup complete")
+	}
 }
 
 // manages an HTTP pprof endpoint served by `server`,
@@ -313,6 +644,11 @@ func loggingInterceptors(logger *logrus.Entry) (grpc.StreamServerInterceptor, gr
 			metadataFields := logging.Fields{}
 			if md, ok := metadata.FromIncomingContext(ctx); ok {
 				for k, v := range md {
+					if k == "authorization" {
+						// Do not log the credentials of callers, such as the
+						// bearer token of the Admin API.
+						continue
+					}
 					metadataFields = append(metadataFields, k, v)
 				}
 				fields = fields.AppendUnique(metadataFields)
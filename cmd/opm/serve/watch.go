@@ -0,0 +1,151 @@
+package serve
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/pkg/cache"
+)
+
+// watchConfigs polls the config directories for changes and, when they have
+// changed, builds a new cache from them and swaps it into store. The directories
+// are only reloaded once they have been unchanged for a full watch interval, so
+// that an update that is still being written is not loaded. If a reload
+// fails, the previously loaded configs continue to be served, and readiness
+// reports the catalog as degraded until a reload succeeds.
+func (s *serve) watchConfigs(ctx context.Context, r *reloader, loaded string, logger *logrus.Entry) {
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var pending string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := fingerprint(s.configDirs)
+		if err != nil {
+			logger.WithError(err).Warn("unable to check configs for changes")
+			continue
+		}
+		if current == loaded {
+			pending = ""
+			continue
+		}
+		if current != pending {
+			logger.Debug("configs changed, waiting for them to settle before reloading")
+			pending = current
+			continue
+		}
+
+		pending = ""
+		loaded = current
+		// Failures are logged and reported by the reloader.
+		_, _ = r.Reload(ctx)
+	}
+}
+
+// reload builds a cache from the config directories in a new temporary
+// directory and swaps it into store. The directory is removed when the cache
+// is closed after it has been replaced in turn.
+func (s *serve) reload(ctx context.Context, store *cache.Swappable, logger *logrus.Entry) error {
+	dir, err := os.MkdirTemp("", "opm-serve-cache-")
+	if err != nil {
+		return err
+	}
+	logger = logger.WithField("cache", dir)
+	c, err := cache.New(dir, cache.WithLog(logger), cache.WithConcurrency(s.cacheConcurrency), cache.WithMaxMemory(s.maxMemoryBytes))
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	reloaded := &tempDirCache{Cache: c, dir: dir}
+	if err := reloaded.Build(ctx, s.configs()); err != nil {
+		reloaded.Close()
+		return fmt.Errorf("failed to build cache: %v", err)
+	}
+	if err := reloaded.Load(ctx); err != nil {
+		reloaded.Close()
+		return fmt.Errorf("failed to load cache: %v", err)
+	}
+	if s.warmupTimeout > 0 {
+		s.warmup(ctx, reloaded, logger)
+	}
+	store.Swap(reloaded)
+	return nil
+}
+
+// tempDirCache is a cache that removes its cache directory when it is closed.
+type tempDirCache struct {
+	cache.Cache
+	dir string
+}
+
+func (c *tempDirCache) Close() error {
+	err := c.Cache.Close()
+	if rmErr := os.RemoveAll(c.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// fingerprint summarizes the paths, sizes and modification times of the
+// files in dirs, so that changes to the directories can be detected without
+// reading the files. Symlinks are followed, so that updates to a mounted
+// ConfigMap, which replace the target of a symlink, are detected too.
+func fingerprint(dirs []string) (string, error) {
+	h := sha256.New()
+	for _, dir := range dirs {
+		if err := fingerprintDir(h, dir); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fingerprintDir(h io.Writer, dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "%s\n", root)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// WalkDir does not descend into symlinked directories, so
+			// record where the symlink points instead.
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s -> %s\n", rel, target)
+			return nil
+		}
+		fmt.Fprintf(h, "%s %d %d\n", rel, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+}
@@ -3,36 +3,252 @@ package validate
 import (
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/cmd/opm/internal/util"
 	"github.com/operator-framework/operator-registry/pkg/lib/config"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/signing"
 )
 
 func NewCmd() *cobra.Command {
-	logger := logrus.New()
+	var (
+		maxBundleSize   string
+		maxObjectSize   string
+		maxPropertySize string
+		maxCatalogSize  string
+		sizeReportFile  string
+		failOnSizes     bool
+		checksumsFile   string
+		reportDupes     bool
+		enableRules     []string
+		disableRules    []string
+		warningsAsErrs  bool
+		signatureFile   string
+		verifier        signing.Cosign
+		checkArchs      bool
+		jsonSchemas     map[string]string
+	)
+	logger := log.New()
 	validate := &cobra.Command{
-		Use:   "validate <directory>",
+		Use:   "validate <catalog-ref>",
 		Short: "Validate the declarative index config",
-		Long:  "Validate the declarative config JSON file(s) in a given directory",
-		Args:  cobra.ExactArgs(1),
+		Long: `Validate the declarative config JSON file(s) in a given catalog reference.
+
+The catalog reference may be a file-based catalog directory or file, a tarball
+(.tar, .tar.gz, .tgz), a git repository (git+<url>[#<revision>][:<subdirectory>]),
+a serving registry (grpc://<host>:<port> or grpcs://<host>:<port>), or a
+file-based catalog image.
+
+Bundle content can optionally be checked against size limits using the
+--max-bundle-size, --max-object-size, and --max-property-size flags, and the
+whole catalog, as encoded in JSON, using the --max-catalog-size flag. Limits
+are expressed as quantities (e.g. 1Mi, 500Ki). By default, exceeding a limit
+produces a warning; use --fail-on-size-limits to fail validation instead.
+
+Use --size-report to write the raw and gzip-compressed sizes of the catalog,
+and of each of its packages and bundles, as encoded in JSON, to a JSON file.
+
+Per-package content checksums, as written by 'opm render --checksums-file',
+can be verified with the --verify-checksums flag.
+
+A signature or attestation of the catalog content, as written by
+'opm render --signature-file', can be verified with cosign, which must be
+installed, using the --verify-signature flag. Signatures are verified with
+--verify-key, or for keyless signatures, with --certificate-identity and
+--certificate-oidc-issuer. Use --attest to verify an attestation.
+
+Bundles declare the platforms they support with the
+operatorframework.io/arch.<arch> and operatorframework.io/os.<os> labels of
+their CSV, which are rendered into their olm.architectures property. Use
+--check-architectures to check that the related images of each bundle provide
+all of its declared platforms, by inspecting the image manifests in their
+registries.
+
+Objects of schemas that declarative configs do not define can be validated
+against JSON Schemas with --json-schema <schema>=<file>, which may be repeated.
+The JSON Schemas are draft-07 JSON Schemas, which must be self-contained,
+without $ref references, and must not use the contains, propertyNames, if,
+then or else keywords. The JSON Schemas of the schemas that declarative
+configs define are printed by 'opm alpha schema'.
+
+Use --report-duplicate-bundles to warn about bundles that are registered under
+different names or packages but share the same bundle image or content.
+
+Validation is performed by a set of rules, which can be enabled and disabled
+with the --enable-rule and --disable-rule flags. Problems found by rules with
+error severity fail validation, while others are logged. Use
+--warnings-as-errors to fail validation on warnings too. The available rules
+are:
+` + ruleHelp(),
+		Args: cobra.ExactArgs(1),
 		RunE: func(c *cobra.Command, args []string) error {
-			directory := args[0]
-			s, err := os.Stat(directory)
+			limits := config.SizeLimits{Fail: failOnSizes}
+			for _, l := range []struct {
+				flag  string
+				value string
+				dest  *int64
+			}{
+				{"max-bundle-size", maxBundleSize, &limits.MaxBundleSize},
+				{"max-object-size", maxObjectSize, &limits.MaxObjectSize},
+				{"max-property-size", maxPropertySize, &limits.MaxPropertySize},
+				{"max-catalog-size", maxCatalogSize, &limits.MaxCatalogSize},
+			} {
+				if l.value == "" {
+					continue
+				}
+				q, err := resource.ParseQuantity(l.value)
+				if err != nil {
+					return fmt.Errorf("invalid --%s value %q: %v", l.flag, l.value, err)
+				}
+				*l.dest = q.Value()
+			}
+
+			reg, err := util.CreateCLIRegistry(c)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer reg.Destroy()
+			loadRefOpts, err := util.CreateLoadRefOptions(c, reg)
 			if err != nil {
-				return err
+				logger.Fatal(err)
+			}
+			cfg, err := declcfg.LoadRef(c.Context(), args[0], loadRefOpts...)
+			if err != nil {
+				logger.Fatal(err)
 			}
-			if !s.IsDir() {
-				return fmt.Errorf("%q is not a directory", directory)
+
+			if sizeReportFile != "" {
+				if err := config.WriteSizeReport(*cfg, sizeReportFile); err != nil {
+					logger.Fatal(err)
+				}
 			}
 
-			if err := config.Validate(c.Context(), os.DirFS(directory)); err != nil {
+			rules := config.DefaultRuleSet(limits)
+			if reportDupes {
+				enableRules = append(enableRules, config.RuleDuplicateBundles)
+			}
+			if err := rules.Enable(enableRules...); err != nil {
+				return fmt.Errorf("invalid --enable-rule value: %v", err)
+			}
+			if len(jsonSchemas) > 0 {
+				schemas := map[string][]byte{}
+				for name, file := range jsonSchemas {
+					data, err := os.ReadFile(file)
+					if err != nil {
+						return fmt.Errorf("invalid --json-schema value: %v", err)
+					}
+					schemas[name] = data
+				}
+				rule, err := config.NewJSONSchemaRule(schemas)
+				if err != nil {
+					return fmt.Errorf("invalid --json-schema value: %v", err)
+				}
+				if err := rules.Register(rule, true); err != nil {
+					return err
+				}
+			}
+			if err := rules.Disable(disableRules...); err != nil {
+				return fmt.Errorf("invalid --disable-rule value: %v", err)
+			}
+			validateOpts := []config.ValidateOption{
+				config.WithLog(logrus.NewEntry(logger)),
+				config.WithRuleSet(rules),
+			}
+			if warningsAsErrs {
+				validateOpts = append(validateOpts, config.WithWarningsAsErrors())
+			}
+			if err := config.ValidateConfig(*cfg, validateOpts...); err != nil {
 				logger.Fatal(err)
 			}
+
+			if checkArchs {
+				problems, err := action.CheckArchitectures{Catalog: *cfg, Registry: reg}.Run(c.Context())
+				if err != nil {
+					logger.Fatal(err)
+				}
+				if len(problems) > 0 {
+					msgs := make([]string, 0, len(problems))
+					for _, p := range problems {
+						msgs = append(msgs, p.String())
+					}
+					logger.Fatal("architecture check failed:\n" + strings.Join(msgs, "\n"))
+				}
+			}
+
+			if checksumsFile != "" {
+				f, err := os.Open(checksumsFile)
+				if err != nil {
+					logger.Fatal(err)
+				}
+				defer f.Close()
+				checksums, err := declcfg.LoadChecksums(f)
+				if err != nil {
+					logger.Fatal(err)
+				}
+				if err := declcfg.VerifyChecksums(*cfg, *checksums); err != nil {
+					logger.Fatal(err)
+				}
+			}
+
+			if signatureFile != "" {
+				signature, err := os.ReadFile(signatureFile)
+				if err != nil {
+					logger.Fatal(err)
+				}
+				if err := signing.VerifyConfig(c.Context(), verifier, *cfg, signature); err != nil {
+					logger.Fatal(err)
+				}
+			}
 			return nil
 		},
 	}
 
+	validate.Flags().StringVar(&maxBundleSize, "max-bundle-size", "", "maximum combined size of all objects in a bundle (e.g. 1Mi)")
+	validate.Flags().StringVar(&maxObjectSize, "max-object-size", "", "maximum size of a single bundle object (e.g. 500Ki)")
+	validate.Flags().StringVar(&maxPropertySize, "max-property-size", "", "maximum size of a single encoded bundle property value (e.g. 500Ki)")
+	validate.Flags().StringVar(&maxCatalogSize, "max-catalog-size", "", "maximum size of the whole catalog, as encoded in JSON (e.g. 100Mi)")
+	validate.Flags().StringVar(&sizeReportFile, "size-report", "", "If set, write a JSON report of the sizes of the catalog, its packages, and its bundles to this file")
+	validate.Flags().StringVar(&checksumsFile, "verify-checksums", "", "verify the catalog against the per-package content checksums in this file")
+	validate.Flags().BoolVar(&checkArchs, "check-architectures", false, "check that the related images of bundles provide the platforms that the bundles declare, by inspecting their manifests")
+	validate.Flags().StringToStringVar(&jsonSchemas, "json-schema", nil, "validate the objects of a schema against a JSON Schema file, as <schema>=<file>")
+	validate.Flags().BoolVar(&reportDupes, "report-duplicate-bundles", false, "warn about bundles that share the same image or content under different names or packages")
+	validate.Flags().BoolVar(&failOnSizes, "fail-on-size-limits", false, "fail validation when a size limit is exceeded, rather than warning")
+	validate.Flags().StringSliceVar(&enableRules, "enable-rule", nil, "enable the validation rules with these IDs")
+	validate.Flags().StringSliceVar(&disableRules, "disable-rule", nil, "disable the validation rules with these IDs")
+	validate.Flags().BoolVar(&warningsAsErrs, "warnings-as-errors", false, "fail validation when a rule reports a warning")
+	validate.Flags().StringVar(&signatureFile, "verify-signature", "", "verify the catalog content against the cosign signature bundle in this file")
+	validate.Flags().StringVar(&verifier.Key, "verify-key", "", "public key to verify the signature with, as a file path or KMS URI")
+	validate.Flags().StringVar(&verifier.CertificateIdentity, "certificate-identity", "", "identity that a keyless signature must have been issued for")
+	validate.Flags().StringVar(&verifier.CertificateOIDCIssuer, "certificate-oidc-issuer", "", "OIDC issuer that must have issued the identity of a keyless signature")
+	validate.Flags().BoolVar(&verifier.Attest, "attest", false, "verify an in-toto attestation of the catalog content instead of a plain signature")
+
 	return validate
 }
+
+// ruleHelp describes the built-in validation rules, with the severity of
+// each rule, and how --fail-on-size-limits changes it.
+func ruleHelp() string {
+	failing := map[string]config.Severity{}
+	for _, r := range config.DefaultRuleSet(config.SizeLimits{Fail: true}).Rules() {
+		failing[r.ID()] = r.Severity()
+	}
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, r := range config.DefaultRuleSet(config.SizeLimits{}).Rules() {
+		severity := string(r.Severity())
+		if s := failing[r.ID()]; s != r.Severity() {
+			severity = fmt.Sprintf("%s (%s with --fail-on-size-limits)", r.Severity(), s)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", r.ID(), severity, r.Description())
+	}
+	_ = tw.Flush()
+	return sb.String()
+}
@@ -2,10 +2,9 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
+	"errors"
 	"net"
-	"os"
+	"net/http"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -16,7 +15,6 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/lib/dns"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
-	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
 	"github.com/operator-framework/operator-registry/pkg/server"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -30,6 +28,7 @@ var rootCmd = &cobra.Command{
 		sqlite.LogSqliteDeprecation()
 	},
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		log.Configure(logOptions)
 		if debug, _ := cmd.Flags().GetBool("debug"); debug {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
@@ -39,12 +38,20 @@ var rootCmd = &cobra.Command{
 	RunE: runCmdFunc,
 }
 
+var (
+	logOptions   log.Options
+	serveOptions sqlite.ServeOptions
+)
+
 func init() {
+	logOptions.AddFlags(rootCmd.Flags())
+	serveOptions.AddFlags(rootCmd.Flags())
 	rootCmd.Flags().Bool("debug", false, "enable debug logging")
 	rootCmd.Flags().StringP("database", "d", "bundles.db", "relative path to sqlite db")
 	rootCmd.Flags().StringP("port", "p", "50051", "port number to serve on")
+	rootCmd.Flags().String("listen", "", "if set, address to serve on instead of --port: a TCP address (host:port), a unix domain socket (unix:///path/to/registry.sock), or fd:// or fd://<name> for a socket passed by systemd socket activation")
+	rootCmd.Flags().String("http-port", "", "if set, also serve the registry API as JSON over HTTP on this port")
 	rootCmd.Flags().StringP("termination-log", "t", "/dev/termination-log", "path to a container termination log file")
-	rootCmd.Flags().Bool("skip-migrate", false, "do  not attempt to migrate to the latest db revision when starting")
 	if err := rootCmd.Flags().MarkHidden("debug"); err != nil {
 		logrus.Panic(err.Error())
 	}
@@ -82,34 +89,24 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-
-	logger := logrus.WithFields(logrus.Fields{"database": dbName, "port": port})
-
-	// make a writable copy of the db for migrations
-	tmpdb, err := tmp.CopyTmpDB(dbName)
+	listen, err := cmd.Flags().GetString("listen")
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tmpdb)
+	addr := server.ListenAddress(listen, port)
 
-	db, err := sqlite.Open(tmpdb)
+	logger := logrus.WithFields(logrus.Fields{"database": dbName, "address": addr})
+
+	db, cleanup, err := serveOptions.OpenForServing(ctx, dbName, logger)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
 	if _, err := db.ExecContext(ctx, `PRAGMA soft_heap_limit=1`); err != nil {
 		logger.WithError(err).Warnf("error setting soft heap limit for sqlite")
 	}
 
-	// migrate to the latest version
-	shouldSkipMigrate, err := cmd.Flags().GetBool("skip-migrate")
-	if err != nil {
-		return err
-	}
-	if err := migrate(ctx, shouldSkipMigrate, db); err != nil {
-		logger.WithError(err).Warnf("couldn't migrate db")
-	}
-
 	store := sqlite.NewSQLLiteQuerierFromDb(db, sqlite.OmitManifests(true))
 
 	// sanity check that the db is available
@@ -121,38 +118,48 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 		logger.Warn("no tables found in db")
 	}
 
-	lis, err := net.Listen("tcp", ":"+port)
+	lis, err := server.Listen(addr)
 	if err != nil {
 		logger.Fatalf("failed to listen: %s", err)
 	}
 	s := grpc.NewServer()
 
-	api.RegisterRegistryServer(s, server.NewRegistryServer(store))
-	health.RegisterHealthServer(s, server.NewHealthServer())
+	registryServer := server.NewRegistryServer(store)
+	healthServer := server.NewHealthServer()
+	api.RegisterRegistryServer(s, registryServer)
+	health.RegisterHealthServer(s, healthServer)
 	reflection.Register(s)
 
+	httpPort, err := cmd.Flags().GetString("http-port")
+	if err != nil {
+		return err
+	}
+	var httpServer *http.Server
+	if httpPort != "" {
+		httpLis, err := net.Listen("tcp", ":"+httpPort)
+		if err != nil {
+			logger.Fatalf("failed to listen for http: %s", err)
+		}
+		httpServer = &http.Server{Handler: server.NewHTTPHandler(registryServer, healthServer)}
+		go func() {
+			logger.WithField("http-port", httpPort).Info("serving registry over http")
+			if err := httpServer.Serve(httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.WithError(err).Error("http server failed")
+			}
+		}()
+	}
+
 	go func() {
 		<-ctx.Done()
 		logger.Info("shutting down server")
+		if httpServer != nil {
+			if err := httpServer.Shutdown(context.Background()); err != nil {
+				logger.WithError(err).Warn("error shutting down http server")
+			}
+		}
 		s.GracefulStop()
 	}()
 
 	logger.Info("serving registry")
 	return s.Serve(lis)
 }
-
-func migrate(ctx context.Context, shouldSkipMigrate bool, db *sql.DB) error {
-	if shouldSkipMigrate {
-		return nil
-	}
-
-	migrator, err := sqlite.NewSQLLiteMigrator(db)
-	if err != nil {
-		return err
-	}
-	if migrator == nil {
-		return fmt.Errorf("failed to load migrator")
-	}
-
-	return migrator.Migrate(ctx)
-}
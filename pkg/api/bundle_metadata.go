@@ -0,0 +1,21 @@
+package api
+
+// ConvertBundleToBundleMetadata returns the metadata of b, i.e. all of its
+// fields except for its manifests.
+func ConvertBundleToBundleMetadata(b *Bundle) *BundleMetadata {
+	return &BundleMetadata{
+		CsvName:      b.GetCsvName(),
+		PackageName:  b.GetPackageName(),
+		ChannelName:  b.GetChannelName(),
+		BundlePath:   b.GetBundlePath(),
+		ProvidedApis: b.GetProvidedApis(),
+		RequiredApis: b.GetRequiredApis(),
+		Version:      b.GetVersion(),
+		SkipRange:    b.GetSkipRange(),
+		Dependencies: b.GetDependencies(),
+		Properties:   b.GetProperties(),
+		Replaces:     b.GetReplaces(),
+		Skips:        b.GetSkips(),
+		Deprecation:  b.GetDeprecation(),
+	}
+}
@@ -27,10 +27,15 @@ func TestConvertModelBundleToAPIBundle(t *testing.T) {
 	modelBundle := testModelBundle(t)
 	modelBundle.Package = &model.Package{Name: "etcd"}
 	modelBundle.Channel = &model.Channel{Name: "singlenamespace-alpha"}
+	modelBundle.Properties = append(modelBundle.Properties, property.MustBuildConstraintCEL(`properties.exists(p, p.type == "certified")`, "must be certified"))
 	expected := testAPIBundle()
 	expected.Properties = append(expected.Properties,
 		&Property{Type: "olm.package.required", Value: "{\"packageName\":\"test\",\"versionRange\":\">=1.2.3 <2.0.0-0\"}"},
 		&Property{Type: "olm.gvk.required", Value: "{\"group\":\"testapi.coreos.com\",\"kind\":\"Testapi\",\"version\":\"v1\"}"},
+		&Property{Type: "olm.constraint", Value: `{"failureMessage":"must be certified","cel":{"rule":"properties.exists(p, p.type == \"certified\")"}}`},
+	)
+	expected.Dependencies = append(expected.Dependencies,
+		&Dependency{Type: "olm.constraint", Value: `{"failureMessage":"must be certified","cel":{"rule":"properties.exists(p, p.type == \"certified\")"}}`},
 	)
 
 	actual, err := ConvertModelBundleToAPIBundle(modelBundle)
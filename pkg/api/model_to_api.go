@@ -6,9 +6,7 @@ import (
 	"fmt"
 
 	"github.com/operator-framework/api/pkg/lib/version"
-	"github.com/operator-framework/api/pkg/operators"
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/operator-framework/operator-registry/alpha/model"
 	"github.com/operator-framework/operator-registry/alpha/property"
@@ -29,7 +27,7 @@ func ConvertModelBundleToAPIBundle(b model.Bundle) (*Bundle, error) {
 				MediaType: b.Package.Icon.MediaType,
 			}}
 		}
-		csv := csvMetadataToCsv(props.CSVMetadatas[0])
+		csv := props.CSVMetadatas[0].ToCSV()
 		csv.Name = b.Name
 		csv.Spec.Icon = icons
 		csv.Spec.InstallStrategy = v1alpha1.NamedInstallStrategy{
@@ -99,33 +97,6 @@ func parseProperties(in []property.Property) (*property.Properties, error) {
 	return props, nil
 }
 
-func csvMetadataToCsv(m property.CSVMetadata) v1alpha1.ClusterServiceVersion {
-	return v1alpha1.ClusterServiceVersion{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       operators.ClusterServiceVersionKind,
-			APIVersion: v1alpha1.ClusterServiceVersionAPIVersion,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Annotations: m.Annotations,
-			Labels:      m.Labels,
-		},
-		Spec: v1alpha1.ClusterServiceVersionSpec{
-			APIServiceDefinitions:     m.APIServiceDefinitions,
-			CustomResourceDefinitions: m.CustomResourceDefinitions,
-			Description:               m.Description,
-			DisplayName:               m.DisplayName,
-			InstallModes:              m.InstallModes,
-			Keywords:                  m.Keywords,
-			Links:                     m.Links,
-			Maintainers:               m.Maintainers,
-			Maturity:                  m.Maturity,
-			MinKubeVersion:            m.MinKubeVersion,
-			NativeAPIs:                m.NativeAPIs,
-			Provider:                  m.Provider,
-		},
-	}
-}
-
 func gvksProvidedtoAPIGVKs(in []property.GVK) []*GroupVersionKind {
 	var out []*GroupVersionKind
 	for _, gvk := range in {
@@ -190,6 +161,11 @@ func convertModelPropertiesToAPIDependencies(props []property.Property) ([]*Depe
 				Type:  pkg.Type,
 				Value: string(pkg.Value),
 			})
+		case property.TypeConstraint:
+			out = append(out, &Dependency{
+				Type:  property.TypeConstraint,
+				Value: string(prop.Value),
+			})
 		}
 	}
 	return out, nil
@@ -0,0 +1,6 @@
+package api
+
+// NextPageTokenTrailer is the trailer of the responses of paged list RPCs,
+// e.g. ListPackages and ListBundles, that holds the page token of the next
+// page, if more items follow.
+const NextPageTokenTrailer = "next-page-token"
@@ -29,6 +29,13 @@ const (
 	Registry_GetLatestChannelEntriesThatProvide_FullMethodName = "/api.Registry/GetLatestChannelEntriesThatProvide"
 	Registry_GetDefaultBundleThatProvides_FullMethodName       = "/api.Registry/GetDefaultBundleThatProvides"
 	Registry_ListBundles_FullMethodName                        = "/api.Registry/ListBundles"
+	Registry_GetCatalogInfo_FullMethodName                     = "/api.Registry/GetCatalogInfo"
+	Registry_GetPackageDocumentation_FullMethodName            = "/api.Registry/GetPackageDocumentation"
+	Registry_GetPackageMetadata_FullMethodName                 = "/api.Registry/GetPackageMetadata"
+	Registry_GetBundleMetadata_FullMethodName                  = "/api.Registry/GetBundleMetadata"
+	Registry_ListBundleMetadata_FullMethodName                 = "/api.Registry/ListBundleMetadata"
+	Registry_ListDeprecations_FullMethodName                   = "/api.Registry/ListDeprecations"
+	Registry_GetCatalogStatus_FullMethodName                   = "/api.Registry/GetCatalogStatus"
 )
 
 // RegistryClient is the client API for Registry service.
@@ -46,6 +53,13 @@ type RegistryClient interface {
 	GetLatestChannelEntriesThatProvide(ctx context.Context, in *GetLatestProvidersRequest, opts ...grpc.CallOption) (Registry_GetLatestChannelEntriesThatProvideClient, error)
 	GetDefaultBundleThatProvides(ctx context.Context, in *GetDefaultProviderRequest, opts ...grpc.CallOption) (*Bundle, error)
 	ListBundles(ctx context.Context, in *ListBundlesRequest, opts ...grpc.CallOption) (Registry_ListBundlesClient, error)
+	GetCatalogInfo(ctx context.Context, in *GetCatalogInfoRequest, opts ...grpc.CallOption) (*CatalogInfo, error)
+	GetPackageDocumentation(ctx context.Context, in *GetPackageDocumentationRequest, opts ...grpc.CallOption) (*PackageDocumentation, error)
+	GetPackageMetadata(ctx context.Context, in *GetPackageMetadataRequest, opts ...grpc.CallOption) (*PackageMetadata, error)
+	GetBundleMetadata(ctx context.Context, in *GetBundleMetadataRequest, opts ...grpc.CallOption) (*BundleMetadata, error)
+	ListBundleMetadata(ctx context.Context, in *ListBundleMetadataRequest, opts ...grpc.CallOption) (Registry_ListBundleMetadataClient, error)
+	ListDeprecations(ctx context.Context, in *ListDeprecationsRequest, opts ...grpc.CallOption) (Registry_ListDeprecationsClient, error)
+	GetCatalogStatus(ctx context.Context, in *GetCatalogStatusRequest, opts ...grpc.CallOption) (*CatalogStatus, error)
 }
 
 type registryClient struct {
@@ -262,6 +276,115 @@ func (x *registryListBundlesClient) Recv() (*Bundle, error) {
 	return m, nil
 }
 
+func (c *registryClient) GetCatalogInfo(ctx context.Context, in *GetCatalogInfoRequest, opts ...grpc.CallOption) (*CatalogInfo, error) {
+	out := new(CatalogInfo)
+	err := c.cc.Invoke(ctx, Registry_GetCatalogInfo_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) GetPackageDocumentation(ctx context.Context, in *GetPackageDocumentationRequest, opts ...grpc.CallOption) (*PackageDocumentation, error) {
+	out := new(PackageDocumentation)
+	err := c.cc.Invoke(ctx, Registry_GetPackageDocumentation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) GetPackageMetadata(ctx context.Context, in *GetPackageMetadataRequest, opts ...grpc.CallOption) (*PackageMetadata, error) {
+	out := new(PackageMetadata)
+	err := c.cc.Invoke(ctx, Registry_GetPackageMetadata_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) GetBundleMetadata(ctx context.Context, in *GetBundleMetadataRequest, opts ...grpc.CallOption) (*BundleMetadata, error) {
+	out := new(BundleMetadata)
+	err := c.cc.Invoke(ctx, Registry_GetBundleMetadata_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryClient) ListBundleMetadata(ctx context.Context, in *ListBundleMetadataRequest, opts ...grpc.CallOption) (Registry_ListBundleMetadataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Registry_ServiceDesc.Streams[5], Registry_ListBundleMetadata_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryListBundleMetadataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_ListBundleMetadataClient interface {
+	Recv() (*BundleMetadata, error)
+	grpc.ClientStream
+}
+
+type registryListBundleMetadataClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryListBundleMetadataClient) Recv() (*BundleMetadata, error) {
+	m := new(BundleMetadata)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *registryClient) ListDeprecations(ctx context.Context, in *ListDeprecationsRequest, opts ...grpc.CallOption) (Registry_ListDeprecationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Registry_ServiceDesc.Streams[6], Registry_ListDeprecations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryListDeprecationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_ListDeprecationsClient interface {
+	Recv() (*DeprecationEntry, error)
+	grpc.ClientStream
+}
+
+type registryListDeprecationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryListDeprecationsClient) Recv() (*DeprecationEntry, error) {
+	m := new(DeprecationEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *registryClient) GetCatalogStatus(ctx context.Context, in *GetCatalogStatusRequest, opts ...grpc.CallOption) (*CatalogStatus, error) {
+	out := new(CatalogStatus)
+	err := c.cc.Invoke(ctx, Registry_GetCatalogStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RegistryServer is the server API for Registry service.
 // All implementations must embed UnimplementedRegistryServer
 // for forward compatibility
@@ -277,6 +400,13 @@ type RegistryServer interface {
 	GetLatestChannelEntriesThatProvide(*GetLatestProvidersRequest, Registry_GetLatestChannelEntriesThatProvideServer) error
 	GetDefaultBundleThatProvides(context.Context, *GetDefaultProviderRequest) (*Bundle, error)
 	ListBundles(*ListBundlesRequest, Registry_ListBundlesServer) error
+	GetCatalogInfo(context.Context, *GetCatalogInfoRequest) (*CatalogInfo, error)
+	GetPackageDocumentation(context.Context, *GetPackageDocumentationRequest) (*PackageDocumentation, error)
+	GetPackageMetadata(context.Context, *GetPackageMetadataRequest) (*PackageMetadata, error)
+	GetBundleMetadata(context.Context, *GetBundleMetadataRequest) (*BundleMetadata, error)
+	ListBundleMetadata(*ListBundleMetadataRequest, Registry_ListBundleMetadataServer) error
+	ListDeprecations(*ListDeprecationsRequest, Registry_ListDeprecationsServer) error
+	GetCatalogStatus(context.Context, *GetCatalogStatusRequest) (*CatalogStatus, error)
 	mustEmbedUnimplementedRegistryServer()
 }
 
@@ -314,6 +444,27 @@ func (UnimplementedRegistryServer) GetDefaultBundleThatProvides(context.Context,
 func (UnimplementedRegistryServer) ListBundles(*ListBundlesRequest, Registry_ListBundlesServer) error {
 	return status.Errorf(codes.Unimplemented, "method ListBundles not implemented")
 }
+func (UnimplementedRegistryServer) GetCatalogInfo(context.Context, *GetCatalogInfoRequest) (*CatalogInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCatalogInfo not implemented")
+}
+func (UnimplementedRegistryServer) GetPackageDocumentation(context.Context, *GetPackageDocumentationRequest) (*PackageDocumentation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackageDocumentation not implemented")
+}
+func (UnimplementedRegistryServer) GetPackageMetadata(context.Context, *GetPackageMetadataRequest) (*PackageMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackageMetadata not implemented")
+}
+func (UnimplementedRegistryServer) GetBundleMetadata(context.Context, *GetBundleMetadataRequest) (*BundleMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBundleMetadata not implemented")
+}
+func (UnimplementedRegistryServer) ListBundleMetadata(*ListBundleMetadataRequest, Registry_ListBundleMetadataServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBundleMetadata not implemented")
+}
+func (UnimplementedRegistryServer) ListDeprecations(*ListDeprecationsRequest, Registry_ListDeprecationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListDeprecations not implemented")
+}
+func (UnimplementedRegistryServer) GetCatalogStatus(context.Context, *GetCatalogStatusRequest) (*CatalogStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCatalogStatus not implemented")
+}
 func (UnimplementedRegistryServer) mustEmbedUnimplementedRegistryServer() {}
 
 // UnsafeRegistryServer may be embedded to opt out of forward compatibility for this service.
@@ -522,6 +673,138 @@ func (x *registryListBundlesServer) Send(m *Bundle) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Registry_GetCatalogInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCatalogInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetCatalogInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Registry_GetCatalogInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetCatalogInfo(ctx, req.(*GetCatalogInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_GetPackageDocumentation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPackageDocumentationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetPackageDocumentation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Registry_GetPackageDocumentation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetPackageDocumentation(ctx, req.(*GetPackageDocumentationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_GetPackageMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPackageMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetPackageMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Registry_GetPackageMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetPackageMetadata(ctx, req.(*GetPackageMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_GetBundleMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBundleMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetBundleMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Registry_GetBundleMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetBundleMetadata(ctx, req.(*GetBundleMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Registry_ListBundleMetadata_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListBundleMetadataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).ListBundleMetadata(m, &registryListBundleMetadataServer{stream})
+}
+
+type Registry_ListBundleMetadataServer interface {
+	Send(*BundleMetadata) error
+	grpc.ServerStream
+}
+
+type registryListBundleMetadataServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryListBundleMetadataServer) Send(m *BundleMetadata) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Registry_ListDeprecations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListDeprecationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).ListDeprecations(m, &registryListDeprecationsServer{stream})
+}
+
+type Registry_ListDeprecationsServer interface {
+	Send(*DeprecationEntry) error
+	grpc.ServerStream
+}
+
+type registryListDeprecationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryListDeprecationsServer) Send(m *DeprecationEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Registry_GetCatalogStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCatalogStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).GetCatalogStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Registry_GetCatalogStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).GetCatalogStatus(ctx, req.(*GetCatalogStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Registry_ServiceDesc is the grpc.ServiceDesc for Registry service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -549,6 +832,26 @@ var Registry_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetDefaultBundleThatProvides",
 			Handler:    _Registry_GetDefaultBundleThatProvides_Handler,
 		},
+		{
+			MethodName: "GetCatalogInfo",
+			Handler:    _Registry_GetCatalogInfo_Handler,
+		},
+		{
+			MethodName: "GetPackageDocumentation",
+			Handler:    _Registry_GetPackageDocumentation_Handler,
+		},
+		{
+			MethodName: "GetPackageMetadata",
+			Handler:    _Registry_GetPackageMetadata_Handler,
+		},
+		{
+			MethodName: "GetBundleMetadata",
+			Handler:    _Registry_GetBundleMetadata_Handler,
+		},
+		{
+			MethodName: "GetCatalogStatus",
+			Handler:    _Registry_GetCatalogStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -576,6 +879,110 @@ var Registry_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Registry_ListBundles_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ListBundleMetadata",
+			Handler:       _Registry_ListBundleMetadata_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListDeprecations",
+			Handler:       _Registry_ListDeprecations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "registry.proto",
+}
+
+const (
+	Admin_Reload_FullMethodName = "/api.Admin/Reload"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	// Reload rebuilds the cache of the served catalog from its source, swaps
+	// it in atomically, and returns the digest of the new content.
+	Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error) {
+	out := new(ReloadResponse)
+	err := c.cc.Invoke(ctx, Admin_Reload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	// Reload rebuilds the cache of the served catalog from its source, swaps
+	// it in atomically, and returns the digest of the new content.
+	Reload(context.Context, *ReloadRequest) (*ReloadResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) Reload(context.Context, *ReloadRequest) (*ReloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reload not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Reload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Reload(ctx, req.(*ReloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "api.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Reload",
+			Handler:    _Admin_Reload_Handler,
+		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "registry.proto",
 }
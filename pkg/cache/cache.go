@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,11 +11,13 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/pkg/api"
@@ -23,6 +27,13 @@ import (
 
 type Cache interface {
 	registry.GRPCQuery
+	registry.PagedBundleQuery
+	registry.FilteredBundleQuery
+	registry.ChecksumQuery
+	registry.DocumentationQuery
+	registry.PackageMetadataQuery
+	registry.BundleMetadataQuery
+	registry.DeprecationQuery
 
 	CheckIntegrity(ctx context.Context, fbc fs.FS) error
 	Build(ctx context.Context, fbc fs.FS) error
@@ -48,11 +59,32 @@ type backend interface {
 	GetDigest(context.Context) (string, error)
 	ComputeDigest(context.Context, fs.FS) (string, error)
 	PutDigest(context.Context, string) error
+
+	GetChecksums(context.Context) (map[string]string, error)
+	PutChecksums(context.Context, map[string]string) error
+}
+
+// providerIndex is implemented by backends that index bundles by the APIs
+// they provide, so that finding the providers of an API does not require
+// reading every bundle.
+type providerIndex interface {
+	BundlesThatProvide(ctx context.Context, group, version, kind string) ([]bundleKey, error)
 }
 
 type CacheOptions struct {
 	Log    *logrus.Entry
 	Format string
+
+	// Concurrency is the maximum number of packages processed in parallel
+	// while building the cache. Zero means the number of CPUs.
+	Concurrency int
+
+	// MaxMemory is an approximate budget, in bytes, for the memory used to
+	// process packages while building the cache. Packages whose estimated
+	// memory usage would exceed the remaining budget wait until other
+	// packages are done, and internal buffers are sized to fit the budget.
+	// Zero means no budget.
+	MaxMemory int64
 }
 
 func WithLog(log *logrus.Entry) CacheOption {
@@ -67,6 +99,18 @@ func WithFormat(format string) CacheOption {
 	}
 }
 
+func WithConcurrency(concurrency int) CacheOption {
+	return func(o *CacheOptions) {
+		o.Concurrency = concurrency
+	}
+}
+
+func WithMaxMemory(maxMemory int64) CacheOption {
+	return func(o *CacheOptions) {
+		o.MaxMemory = maxMemory
+	}
+}
+
 type CacheOption func(*CacheOptions)
 
 // New creates a new Cache. It chooses a cache implementation based
@@ -80,6 +124,15 @@ func New(cacheDir string, cacheOpts ...CacheOption) (Cache, error) {
 	for _, opt := range cacheOpts {
 		opt(opts)
 	}
+	if opts.Concurrency < 0 {
+		return nil, fmt.Errorf("invalid concurrency %d: must not be negative", opts.Concurrency)
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = runtime.NumCPU()
+	}
+	if opts.MaxMemory < 0 {
+		return nil, fmt.Errorf("invalid max memory %d: must not be negative", opts.MaxMemory)
+	}
 	cacheBackend, err := getBackend(cacheDir, opts.Format, opts.Log)
 	if err != nil {
 		return nil, err
@@ -88,7 +141,7 @@ func New(cacheDir string, cacheOpts ...CacheOption) (Cache, error) {
 	if err := cacheBackend.Open(); err != nil {
 		return nil, fmt.Errorf("open cache: %v", err)
 	}
-	return &cache{backend: cacheBackend, log: opts.Log}, nil
+	return &cache{backend: cacheBackend, log: opts.Log, concurrency: opts.Concurrency, maxMemory: opts.MaxMemory}, nil
 }
 
 func getBackend(cacheDir string, backendName string, log *logrus.Entry) (backend, error) {
@@ -98,6 +151,7 @@ func getBackend(cacheDir string, backendName string, log *logrus.Entry) (backend
 	}
 
 	backends := []backend{
+		newIndexV2Backend(cacheDir),
 		newPogrebV1Backend(cacheDir),
 		newJSONBackend(cacheDir),
 	}
@@ -146,11 +200,42 @@ func LoadOrRebuild(ctx context.Context, c Cache, fbc fs.FS) error {
 var _ Cache = &cache{}
 
 type cache struct {
-	backend backend
-	log     *logrus.Entry
+	backend     backend
+	log         *logrus.Entry
+	concurrency int
+	maxMemory   int64
+	checksums   map[string]string
 	packageIndex
 }
 
+const (
+	// packageMemoryFactor approximates the peak memory used to process a
+	// package as a multiple of the size of its serialized FBC blobs. While
+	// a package is processed, it is held in memory at once as declarative
+	// config, as model, and as the API bundles being stored.
+	packageMemoryFactor = 4
+
+	defaultWriteBufferSize = 64 << 10
+	minWriteBufferSize     = 4 << 10
+	maxWriteBufferSize     = 1 << 20
+)
+
+// writeBufferSize returns the size of the buffer used to stage FBC blobs on
+// disk while building the cache, scaled down to fit within the memory budget.
+func (c *cache) writeBufferSize() int {
+	if c.maxMemory <= 0 {
+		return defaultWriteBufferSize
+	}
+	return int(min(max(c.maxMemory/1024, minWriteBufferSize), maxWriteBufferSize))
+}
+
+// packageMemoryCost returns the share of the memory budget to reserve for
+// processing a package whose FBC blobs are size bytes. Packages larger than
+// the whole budget reserve all of it, so that they are processed alone.
+func (c *cache) packageMemoryCost(size int64) int64 {
+	return min(max(size*packageMemoryFactor, 1), c.maxMemory)
+}
+
 type bundleStreamTransformer func(*api.Bundle)
 type transformingBundleSender struct {
 	stream      registry.BundleSender
@@ -162,6 +247,14 @@ func (t *transformingBundleSender) Send(b *api.Bundle) error {
 	return t.stream.Send(b)
 }
 
+type bundleMetadataSender struct {
+	stream registry.BundleMetadataSender
+}
+
+func (s bundleMetadataSender) Send(b *api.Bundle) error {
+	return s.stream.Send(api.ConvertBundleToBundleMetadata(b))
+}
+
 type sliceBundleSender []*api.Bundle
 
 func (s *sliceBundleSender) Send(b *api.Bundle) error {
@@ -169,18 +262,75 @@ func (s *sliceBundleSender) Send(b *api.Bundle) error {
 	return nil
 }
 
+// omitPathManifests omits the manifests of bundles that have a bundle path,
+// as the SQLite-based server configures its querier to.
+func omitPathManifests(bundle *api.Bundle) {
+	if bundle.BundlePath != "" {
+		bundle.CsvJson = ""
+		bundle.Object = nil
+	}
+}
+
 func (c *cache) SendBundles(ctx context.Context, stream registry.BundleSender) error {
-	transform := func(bundle *api.Bundle) {
-		if bundle.BundlePath != "" {
-			// The SQLite-based server
-			// configures its querier to
-			// omit these fields when
-			// key path is set.
-			bundle.CsvJson = ""
-			bundle.Object = nil
+	return c.backend.SendBundles(ctx, &transformingBundleSender{stream, omitPathManifests})
+}
+
+func (c *cache) SendBundlesPage(ctx context.Context, stream registry.BundleSender, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("invalid page size %d: must be positive", limit)
+	}
+	cursors := c.packageIndex.bundleCursorsAfter(after)
+	limit = min(limit, len(cursors))
+	sender := &transformingBundleSender{stream, omitPathManifests}
+	for _, cursor := range cursors[:limit] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		bundle, err := c.backend.GetBundle(ctx, bundleKey{cursor.PackageName, cursor.ChannelName, cursor.CsvName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle for package %q, channel %q, key %q: %w", cursor.PackageName, cursor.ChannelName, cursor.CsvName, err)
+		}
+		if err := sender.Send(bundle); err != nil {
+			return nil, err
+		}
+	}
+	if limit == len(cursors) {
+		return nil, nil
+	}
+	return &cursors[limit-1], nil
+}
+
+func (c *cache) SendFilteredBundles(ctx context.Context, stream registry.BundleSender, filter registry.BundleFilter, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("invalid page size %d: must not be negative", limit)
+	}
+	cursors := slices.DeleteFunc(c.packageIndex.bundleCursorsAfter(after), func(cursor registry.BundleCursor) bool {
+		return !filter.MatchesChannel(cursor.PackageName, cursor.ChannelName)
+	})
+	if limit == 0 {
+		limit = len(cursors)
+	}
+	limit = min(limit, len(cursors))
+	sender := &transformingBundleSender{stream, omitPathManifests}
+	for _, cursor := range cursors[:limit] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
+		bundle, err := c.backend.GetBundle(ctx, bundleKey{cursor.PackageName, cursor.ChannelName, cursor.CsvName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle for package %q, channel %q, key %q: %w", cursor.PackageName, cursor.ChannelName, cursor.CsvName, err)
+		}
+		if !filter.MatchesProperties(bundle.GetProperties()) {
+			continue
+		}
+		if err := sender.Send(bundle); err != nil {
+			return nil, err
+		}
+	}
+	if limit == len(cursors) {
+		return nil, nil
 	}
-	return c.backend.SendBundles(ctx, &transformingBundleSender{stream, transform})
+	return &cursors[limit-1], nil
 }
 
 func (c *cache) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
@@ -217,19 +367,49 @@ func (c *cache) GetBundle(ctx context.Context, pkgName, channelName, csvName str
 	return c.getTrimmedBundle(ctx, bundleKey{pkg.Name, ch.Name, b.Name})
 }
 
+func (c *cache) GetBundleMetadata(ctx context.Context, pkgName, channelName, csvName string) (*api.BundleMetadata, error) {
+	b, err := c.GetBundle(ctx, pkgName, channelName, csvName)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertBundleToBundleMetadata(b), nil
+}
+
+func (c *cache) SendBundleMetadata(ctx context.Context, stream registry.BundleMetadataSender) error {
+	return c.backend.SendBundles(ctx, bundleMetadataSender{stream})
+}
+
 func (c *cache) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
 	return c.packageIndex.GetBundleForChannel(ctx, c.getTrimmedBundle, pkgName, channelName)
 }
 
+func (c *cache) GetPackageMetadata(ctx context.Context, pkgName string) (*api.PackageMetadata, error) {
+	return c.packageIndex.GetPackageMetadata(ctx, c.getTrimmedBundle, pkgName)
+}
+
 func (c *cache) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
 	return c.packageIndex.GetBundleThatReplaces(ctx, c.getTrimmedBundle, name, pkgName, channelName)
 }
 
 func (c *cache) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	if pi, ok := c.backend.(providerIndex); ok {
+		keys, err := pi.BundlesThatProvide(ctx, group, version, kind)
+		if err != nil {
+			return nil, err
+		}
+		return c.packageIndex.channelEntriesThatProvide(keys, false, group, version, kind)
+	}
 	return c.packageIndex.GetChannelEntriesThatProvide(ctx, c.backend.GetBundle, group, version, kind)
 }
 
 func (c *cache) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	if pi, ok := c.backend.(providerIndex); ok {
+		keys, err := pi.BundlesThatProvide(ctx, group, version, kind)
+		if err != nil {
+			return nil, err
+		}
+		return c.packageIndex.channelEntriesThatProvide(keys, true, group, version, kind)
+	}
 	return c.packageIndex.GetLatestChannelEntriesThatProvide(ctx, c.backend.GetBundle, group, version, kind)
 }
 
@@ -237,6 +417,10 @@ func (c *cache) GetBundleThatProvides(ctx context.Context, group, version, kind
 	return c.packageIndex.GetBundleThatProvides(ctx, c, group, version, kind)
 }
 
+func (c *cache) GetPackageChecksums(_ context.Context) (map[string]string, error) {
+	return c.checksums, nil
+}
+
 func (c *cache) CheckIntegrity(ctx context.Context, fbc fs.FS) error {
 	existingDigest, err := c.backend.GetDigest(ctx)
 	if err != nil {
@@ -250,6 +434,18 @@ func (c *cache) CheckIntegrity(ctx context.Context, fbc fs.FS) error {
 		c.log.WithField("existingDigest", existingDigest).WithField("computedDigest", computedDigest).Warn("cache requires rebuild")
 		return fmt.Errorf("cache requires rebuild: cache reports digest as %q, but computed digest is %q", existingDigest, computedDigest)
 	}
+
+	// Caches built before their content version was current lack content
+	// that is not covered by their digest, such as package checksums or
+	// documentation, so they report no checksums.
+	checksums, err := c.backend.GetChecksums(ctx)
+	if err != nil {
+		return fmt.Errorf("read existing package checksums: %v", err)
+	}
+	if checksums == nil {
+		c.log.Warn("cache requires rebuild: it was built with an outdated content version")
+		return fmt.Errorf("cache requires rebuild: cache content version is outdated")
+	}
 	return nil
 }
 
@@ -273,12 +469,20 @@ func (c *cache) Build(ctx context.Context, fbcFsys fs.FS) error {
 		os.Remove(tmpFile.Name())
 	}()
 
+	// Stage blobs in a temp file, grouped by package, so that only the
+	// packages currently being processed need to be held in memory.
 	var (
-		concurrency      = runtime.NumCPU()
+		concurrency      = c.concurrency
 		byPackageReaders = map[string][]io.Reader{}
+		byPackageSize    = map[string]int64{}
 		walkMu           sync.Mutex
 		offset           int64
+		tmpWriter        = bufio.NewWriterSize(tmpFile, c.writeBufferSize())
+		roots            = newPackageRoots(fbcFsys)
 	)
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
 	if err := declcfg.WalkMetasFS(ctx, fbcFsys, func(path string, meta *declcfg.Meta, err error) error {
 		if err != nil {
 			return err
@@ -290,14 +494,21 @@ func (c *cache) Build(ctx context.Context, fbcFsys fs.FS) error {
 
 		walkMu.Lock()
 		defer walkMu.Unlock()
-		if _, err := tmpFile.Write(meta.Blob); err != nil {
+		if err := roots.add(packageName, meta.Schema, meta.Name, path); err != nil {
+			return err
+		}
+		if _, err := tmpWriter.Write(meta.Blob); err != nil {
 			return err
 		}
 		sr := io.NewSectionReader(tmpFile, offset, int64(len(meta.Blob)))
 		byPackageReaders[packageName] = append(byPackageReaders[packageName], sr)
+		byPackageSize[packageName] += int64(len(meta.Blob))
 		offset += int64(len(meta.Blob))
 		return nil
-	}, declcfg.WithConcurrency(concurrency)); err != nil {
+	}, declcfg.WithConcurrency(concurrency), declcfg.WithMaxMemory(c.maxMemory)); err != nil {
+		return err
+	}
+	if err := tmpWriter.Flush(); err != nil {
 		return err
 	}
 	if err := tmpFile.Sync(); err != nil {
@@ -319,9 +530,14 @@ func (c *cache) Build(ctx context.Context, fbcFsys fs.FS) error {
 	})
 
 	var (
-		pkgs   = packageIndex{}
-		pkgsMu sync.Mutex
+		pkgs      = packageIndex{}
+		checksums = map[string]string{}
+		pkgsMu    sync.Mutex
+		memory    *semaphore.Weighted
 	)
+	if c.maxMemory > 0 {
+		memory = semaphore.NewWeighted(c.maxMemory)
+	}
 	for i := 0; i < concurrency; i++ {
 		eg.Go(func() error {
 			for {
@@ -332,13 +548,26 @@ func (c *cache) Build(ctx context.Context, fbcFsys fs.FS) error {
 					if !ok {
 						return nil
 					}
-					pkgIndex, err := c.processPackage(egCtx, io.MultiReader(byPackageReaders[pkgName]...))
+					var cost int64
+					if memory != nil {
+						cost = c.packageMemoryCost(byPackageSize[pkgName])
+						if err := memory.Acquire(egCtx, cost); err != nil {
+							return err
+						}
+					}
+					pkgIndex, pkgChecksums, err := c.processPackage(egCtx, io.MultiReader(byPackageReaders[pkgName]...))
+					if memory != nil {
+						memory.Release(cost)
+					}
 					if err != nil {
 						return fmt.Errorf("process package %q: %v", pkgName, err)
 					}
 
 					pkgsMu.Lock()
 					pkgs[pkgName] = pkgIndex[pkgName]
+					if checksum, ok := pkgChecksums.Packages[pkgName]; ok {
+						checksums[pkgName] = checksum
+					}
 					pkgsMu.Unlock()
 				}
 			}
@@ -352,6 +581,9 @@ func (c *cache) Build(ctx context.Context, fbcFsys fs.FS) error {
 	if err := c.backend.PutPackageIndex(ctx, pkgs); err != nil {
 		return fmt.Errorf("store package index: %v", err)
 	}
+	if err := c.backend.PutChecksums(ctx, checksums); err != nil {
+		return fmt.Errorf("store package checksums: %v", err)
+	}
 
 	digest, err := c.backend.ComputeDigest(ctx, fbcFsys)
 	if err != nil {
@@ -363,33 +595,37 @@ func (c *cache) Build(ctx context.Context, fbcFsys fs.FS) error {
 	return nil
 }
 
-func (c *cache) processPackage(ctx context.Context, reader io.Reader) (packageIndex, error) {
+func (c *cache) processPackage(ctx context.Context, reader io.Reader) (packageIndex, *declcfg.Checksums, error) {
 	pkgFbc, err := declcfg.LoadReader(reader)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	pkgModel, err := declcfg.ConvertToModel(*pkgFbc)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	pkgIndex, err := packagesFromModel(pkgModel)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	checksums, err := declcfg.ComputeChecksums(*pkgFbc)
+	if err != nil {
+		return nil, nil, err
 	}
 	for _, p := range pkgModel {
 		for _, ch := range p.Channels {
 			for _, b := range ch.Bundles {
 				apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				if err := c.backend.PutBundle(ctx, bundleKey{p.Name, ch.Name, b.Name}, apiBundle); err != nil {
-					return nil, fmt.Errorf("store bundle %q: %v", b.Name, err)
+					return nil, nil, fmt.Errorf("store bundle %q: %v", b.Name, err)
 				}
 			}
 		}
 	}
-	return pkgIndex, nil
+	return pkgIndex, checksums, nil
 }
 
 func (c *cache) Load(ctx context.Context) error {
@@ -398,6 +634,15 @@ func (c *cache) Load(ctx context.Context) error {
 		return fmt.Errorf("get package index: %v", err)
 	}
 	c.packageIndex = pi
+
+	checksums, err := c.backend.GetChecksums(ctx)
+	if err != nil {
+		return fmt.Errorf("get package checksums: %v", err)
+	}
+	if checksums == nil {
+		c.log.Warn("cache content version is outdated, rebuild it to report canonical package checksums and documentation")
+	}
+	c.checksums = checksums
 	return nil
 }
 
@@ -433,6 +678,56 @@ func writeDigestFile(file string, digest string, mode os.FileMode) error {
 	return os.WriteFile(file, []byte(digest), mode)
 }
 
+// contentVersion is the version of what caches store. It is recorded in the
+// checksums file rather than the cache digest, so that bumping it does not
+// change the digests of caches built from the same catalog. Bump it when
+// caches store new content that caches built without it would silently lack.
+//
+// Version 1 added package checksums, version 2 package documentation, and
+// version 3 computed package checksums over canonical content.
+const contentVersion = 3
+
+// checksumsFile is the content of the checksums file stored alongside the
+// cache.
+type checksumsFile struct {
+	// ContentVersion is unset in files written before it was recorded.
+	ContentVersion int               `json:"contentVersion,omitempty"`
+	Packages       map[string]string `json:"packages"`
+}
+
+// readChecksumsFile reads package checksums stored alongside the cache. The
+// checksums file is not part of the cache digest, so for caches built before
+// checksums were stored, or with an earlier content version, it returns nil
+// checksums, which CheckIntegrity reports as requiring a rebuild.
+func readChecksumsFile(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checksums checksumsFile
+	if err := json.Unmarshal(data, &checksums); err != nil {
+		return nil, fmt.Errorf("decode checksums: %v", err)
+	}
+	if checksums.ContentVersion < contentVersion {
+		return nil, nil
+	}
+	if checksums.Packages == nil {
+		return map[string]string{}, nil
+	}
+	return checksums.Packages, nil
+}
+
+func writeChecksumsFile(file string, checksums map[string]string, mode os.FileMode) error {
+	data, err := json.MarshalIndent(checksumsFile{ContentVersion: contentVersion, Packages: checksums}, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), mode)
+}
+
 func doesBundleProvide(ctx context.Context, getBundle getBundleFunc, pkgName, chName, bundleName, group, version, kind string) (bool, error) {
 	apiBundle, err := getBundle(ctx, bundleKey{pkgName, chName, bundleName})
 	if err != nil {
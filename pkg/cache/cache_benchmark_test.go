@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+// BenchmarkBuild measures cache builds with different concurrency limits and
+// memory budgets. Lower concurrency and smaller budgets reduce the peak heap
+// size while building the cache (reported as peak-heap-bytes) at the cost of
+// longer build times, which is a worthwhile trade-off for small catalog Pods
+// that build their cache in an init container. The total allocations
+// (B/op) are the same for all configurations.
+func BenchmarkBuild(b *testing.B) {
+	fbc := generateBenchmarkFS(b, 50, 40)
+
+	type spec struct {
+		concurrency int
+		maxMemory   int64
+	}
+	specs := []spec{
+		{concurrency: 1},
+		{concurrency: 4},
+		{concurrency: 0},
+		{concurrency: 4, maxMemory: 1 << 20},
+		{concurrency: 4, maxMemory: 16 << 20},
+		{concurrency: 0, maxMemory: 16 << 20},
+	}
+	for _, s := range specs {
+		b.Run(fmt.Sprintf("concurrency=%d/maxMemory=%d", s.concurrency, s.maxMemory), func(b *testing.B) {
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				c, err := New(b.TempDir(), WithFormat(FormatJSON), WithLog(log.Null()), WithConcurrency(s.concurrency), WithMaxMemory(s.maxMemory))
+				if err != nil {
+					b.Fatal(err)
+				}
+				runtime.GC()
+				stop := samplePeakHeap()
+				b.StartTimer()
+
+				if err := c.Build(context.Background(), fbc); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+				peak = max(peak, stop())
+				if err := c.Close(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(peak), "peak-heap-bytes")
+		})
+	}
+}
+
+// samplePeakHeap periodically samples the heap size until the returned
+// function is called, which returns the largest sample.
+func samplePeakHeap() func() uint64 {
+	var (
+		peak uint64
+		done = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		var ms runtime.MemStats
+		for {
+			runtime.ReadMemStats(&ms)
+			peak = max(peak, ms.HeapInuse)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		wg.Wait()
+		return peak
+	}
+}
+
+func generateBenchmarkFS(b *testing.B, numPackages, bundlesPerPackage int) fstest.MapFS {
+	b.Helper()
+	fsys := fstest.MapFS{}
+	description := strings.Repeat("lorem ipsum ", 1000)
+	for p := 0; p < numPackages; p++ {
+		pkgName := fmt.Sprintf("pkg-%d", p)
+		cfg := declcfg.DeclarativeConfig{
+			Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: pkgName, DefaultChannel: "stable"}},
+			Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Package: pkgName, Name: "stable"}},
+		}
+		for i := 0; i < bundlesPerPackage; i++ {
+			name := fmt.Sprintf("%s.v0.%d.0", pkgName, i)
+			entry := declcfg.ChannelEntry{Name: name}
+			if i > 0 {
+				entry.Replaces = fmt.Sprintf("%s.v0.%d.0", pkgName, i-1)
+			}
+			cfg.Channels[0].Entries = append(cfg.Channels[0].Entries, entry)
+
+			csvMetadata, err := json.Marshal(map[string]string{"description": description})
+			if err != nil {
+				b.Fatal(err)
+			}
+			cfg.Bundles = append(cfg.Bundles, declcfg.Bundle{
+				Schema:  declcfg.SchemaBundle,
+				Package: pkgName,
+				Name:    name,
+				Image:   fmt.Sprintf("example.com/%s:v0.%d.0", pkgName, i),
+				Properties: []property.Property{
+					property.MustBuildPackage(pkgName, fmt.Sprintf("0.%d.0", i)),
+					{Type: property.TypeCSVMetadata, Value: csvMetadata},
+				},
+			})
+		}
+
+		var sb strings.Builder
+		if err := declcfg.WriteJSON(cfg, &sb); err != nil {
+			b.Fatal(err)
+		}
+		fsys[pkgName+"/catalog.json"] = &fstest.MapFile{Data: []byte(sb.String())}
+	}
+	return fsys
+}
@@ -2,14 +2,25 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/lib/log"
 	"github.com/operator-framework/operator-registry/pkg/registry"
+	"github.com/operator-framework/operator-registry/pkg/registry/querytest"
 )
 
 func TestCache_GetBundle(t *testing.T) {
@@ -160,6 +171,91 @@ func TestCache_GetLatestChannelEntriesThatProvide(t *testing.T) {
 	}
 }
 
+// priorityFS returns a catalog of the packages foo and bar, whose bundles
+// all provide the same API. foo has the channels stable, its default, and
+// fast; bar has the channel stable. priorities sets the
+// olm.channel.priority property of the channels named "package/channel".
+func priorityFS(priorities map[string]int) fstest.MapFS {
+	fsys := fstest.MapFS{".": &fstest.MapFile{Mode: fs.ModeDir}}
+	channel := func(pkg, ch, bundle string) string {
+		props := ""
+		if p, ok := priorities[pkg+"/"+ch]; ok {
+			props = fmt.Sprintf(`,"properties":[{"type":"olm.channel.priority","value":{"priority":%d}}]`, p)
+		}
+		return fmt.Sprintf(`{"schema":"olm.channel","package":%q,"name":%q,"entries":[{"name":%q}]%s}`, pkg, ch, bundle, props)
+	}
+	bundle := func(pkg, name, version string) string {
+		return fmt.Sprintf(`{"schema":"olm.bundle","package":%q,"name":%q,"image":"example.com/%s","properties":[`+
+			`{"type":"olm.package","value":{"packageName":%q,"version":%q}},`+
+			`{"type":"olm.gvk","value":{"group":"example.com","version":"v1","kind":"Widget"}}]}`, pkg, name, name, pkg, version)
+	}
+	fsys["foo.json"] = &fstest.MapFile{Data: []byte(strings.Join([]string{
+		`{"schema":"olm.package","name":"foo","defaultChannel":"stable"}`,
+		channel("foo", "stable", "foo.v1.0.0"),
+		channel("foo", "fast", "foo.v2.0.0"),
+		bundle("foo", "foo.v1.0.0", "1.0.0"),
+		bundle("foo", "foo.v2.0.0", "2.0.0"),
+	}, "\n"))}
+	fsys["bar.json"] = &fstest.MapFile{Data: []byte(strings.Join([]string{
+		`{"schema":"olm.package","name":"bar","defaultChannel":"stable"}`,
+		channel("bar", "stable", "bar.v1.0.0"),
+		bundle("bar", "bar.v1.0.0", "1.0.0"),
+	}, "\n"))}
+	return fsys
+}
+
+func TestCache_ChannelPriority(t *testing.T) {
+	type spec struct {
+		name          string
+		priorities    map[string]int
+		expectBundle  string
+		expectEntries []string
+	}
+	for _, s := range []spec{
+		{
+			name:          "NoPriorities",
+			expectBundle:  "bar.v1.0.0",
+			expectEntries: []string{"bar/stable", "foo/fast", "foo/stable"},
+		},
+		{
+			name:          "DefaultChannelPriority",
+			priorities:    map[string]int{"foo/stable": 1},
+			expectBundle:  "foo.v1.0.0",
+			expectEntries: []string{"foo/stable", "bar/stable", "foo/fast"},
+		},
+		{
+			name:          "NonDefaultChannelPriority",
+			priorities:    map[string]int{"foo/stable": 1, "foo/fast": 2},
+			expectBundle:  "foo.v2.0.0",
+			expectEntries: []string{"foo/fast", "foo/stable", "bar/stable"},
+		},
+		{
+			name:          "NegativePriority",
+			priorities:    map[string]int{"bar/stable": -1},
+			expectBundle:  "foo.v1.0.0",
+			expectEntries: []string{"foo/fast", "foo/stable", "bar/stable"},
+		},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			for name, testQuerier := range genTestCaches(t, priorityFS(s.priorities)) {
+				t.Run(name, func(t *testing.T) {
+					b, err := testQuerier.GetBundleThatProvides(context.TODO(), "example.com", "v1", "Widget")
+					require.NoError(t, err)
+					require.Equal(t, s.expectBundle, b.CsvName)
+
+					entries, err := testQuerier.GetLatestChannelEntriesThatProvide(context.TODO(), "example.com", "v1", "Widget")
+					require.NoError(t, err)
+					var channels []string
+					for _, e := range entries {
+						channels = append(channels, e.PackageName+"/"+e.ChannelName)
+					}
+					require.Equal(t, s.expectEntries, channels)
+				})
+			}
+		})
+	}
+}
+
 func TestCache_GetPackage(t *testing.T) {
 	for name, testQuerier := range genTestCaches(t, validFS) {
 		t.Run(name, func(t *testing.T) {
@@ -208,6 +304,71 @@ func TestCache_ListBundles(t *testing.T) {
 	}
 }
 
+type countingBundleSender struct {
+	sent    int
+	failAt  int
+	failErr error
+}
+
+func (s *countingBundleSender) Send(_ *api.Bundle) error {
+	s.sent++
+	if s.sent == s.failAt {
+		return s.failErr
+	}
+	return nil
+}
+
+func TestCache_SendBundles(t *testing.T) {
+	for name, testQuerier := range genTestCaches(t, validFS) {
+		t.Run(name, func(t *testing.T) {
+			sender := &countingBundleSender{}
+			require.NoError(t, testQuerier.SendBundles(context.TODO(), sender))
+			require.Equal(t, 12, sender.sent)
+
+			// Sending stops at the first error.
+			failErr := errors.New("send failed")
+			sender = &countingBundleSender{failAt: 3, failErr: failErr}
+			require.ErrorIs(t, testQuerier.SendBundles(context.TODO(), sender), failErr)
+			require.Equal(t, 3, sender.sent)
+
+			// Sending stops when the context is done.
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			sender = &countingBundleSender{}
+			require.ErrorIs(t, testQuerier.SendBundles(ctx, sender), context.Canceled)
+			require.Zero(t, sender.sent)
+		})
+	}
+}
+
+type sliceBundleMetadataSender []*api.BundleMetadata
+
+func (s *sliceBundleMetadataSender) Send(b *api.BundleMetadata) error {
+	*s = append(*s, b)
+	return nil
+}
+
+func TestCache_BundleMetadata(t *testing.T) {
+	for name, testQuerier := range genTestCaches(t, validFS) {
+		t.Run(name, func(t *testing.T) {
+			b, err := testQuerier.GetBundleMetadata(context.TODO(), "etcd", "singlenamespace-alpha", "etcdoperator.v0.9.4")
+			require.NoError(t, err)
+			require.Equal(t, "etcd", b.PackageName)
+			require.Equal(t, "singlenamespace-alpha", b.ChannelName)
+			require.Equal(t, "etcdoperator.v0.9.4", b.CsvName)
+			require.Equal(t, "0.9.4", b.Version)
+			require.NotEmpty(t, b.Properties)
+
+			_, err = testQuerier.GetBundleMetadata(context.TODO(), "etcd", "singlenamespace-alpha", "missing")
+			require.Error(t, err)
+
+			var sender sliceBundleMetadataSender
+			require.NoError(t, testQuerier.SendBundleMetadata(context.TODO(), &sender))
+			require.Len(t, sender, 12)
+		})
+	}
+}
+
 func TestCache_ListPackages(t *testing.T) {
 	for name, testQuerier := range genTestCaches(t, validFS) {
 		t.Run(name, func(t *testing.T) {
@@ -219,11 +380,306 @@ func TestCache_ListPackages(t *testing.T) {
 	}
 }
 
+func TestCache_GetPackageChecksums(t *testing.T) {
+	cfg, err := declcfg.LoadFS(context.Background(), validFS)
+	require.NoError(t, err)
+	expected, err := declcfg.ComputeChecksums(*cfg)
+	require.NoError(t, err)
+
+	for name, testQuerier := range genTestCaches(t, validFS) {
+		t.Run(name, func(t *testing.T) {
+			checksums, err := testQuerier.GetPackageChecksums(context.TODO())
+			require.NoError(t, err)
+			require.Equal(t, expected.Packages, checksums)
+		})
+	}
+}
+
+func TestLoadOrRebuildWithoutChecksums(t *testing.T) {
+	cacheDir := t.TempDir()
+	c, err := New(cacheDir, WithFormat(FormatJSON), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Build(context.Background(), validFS))
+
+	// Caches built before package checksums were stored are rebuilt, so that
+	// they report their checksums.
+	require.NoError(t, os.Remove(filepath.Join(cacheDir, jsonChecksumsFile)))
+	require.NoError(t, LoadOrRebuild(context.Background(), c, validFS))
+	checksums, err := c.GetPackageChecksums(context.Background())
+	require.NoError(t, err)
+	require.Len(t, checksums, 2)
+}
+
+func TestCache_GetPackageDocumentation(t *testing.T) {
+	fbcFS := fstest.MapFS{}
+	for k, v := range validFS {
+		fbcFS[k] = v
+	}
+	fbcFS["etcd-docs.json"] = &fstest.MapFile{
+		Data: []byte(`{"schema": "olm.package.documentation", "package": "etcd", "url": "https://example.com/etcd/README.md"}`),
+	}
+
+	for name, testQuerier := range genTestCaches(t, fbcFS) {
+		t.Run(name, func(t *testing.T) {
+			doc, err := testQuerier.GetPackageDocumentation(context.TODO(), "etcd")
+			require.NoError(t, err)
+			require.Equal(t, "etcd", doc.PackageName)
+			require.Equal(t, "https://example.com/etcd/README.md", doc.Url)
+			require.Empty(t, doc.Content)
+
+			_, err = testQuerier.GetPackageDocumentation(context.TODO(), "cockroachdb")
+			require.Equal(t, codes.NotFound, status.Code(err))
+			require.Equal(t, `package "cockroachdb" has no documentation`, status.Convert(err).Message())
+
+			_, err = testQuerier.GetPackageDocumentation(context.TODO(), "missing")
+			require.Equal(t, codes.NotFound, status.Code(err))
+			require.Equal(t, `package "missing" not found`, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestLoadOrRebuildWithoutDocumentation(t *testing.T) {
+	fbcFS := fstest.MapFS{}
+	for k, v := range validFS {
+		fbcFS[k] = v
+	}
+	fbcFS["etcd-docs.json"] = &fstest.MapFile{
+		Data: []byte(`{"schema": "olm.package.documentation", "package": "etcd", "url": "https://example.com/etcd/README.md"}`),
+	}
+
+	cacheDir := t.TempDir()
+	c, err := New(cacheDir, WithFormat(FormatJSON), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Build(context.Background(), fbcFS))
+
+	// Simulate a cache built before package documentation was stored: no
+	// documentation, a checksums file without a content version, and a
+	// digest that matches both.
+	packagesFile := filepath.Join(cacheDir, jsonPackagesFile)
+	packagesJSON, err := os.ReadFile(packagesFile)
+	require.NoError(t, err)
+	var pkgs map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(packagesJSON, &pkgs))
+	for _, pkg := range pkgs {
+		delete(pkg, "documentation")
+	}
+	packagesJSON, err = json.Marshal(pkgs)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(packagesFile, packagesJSON, jsonCacheModeFile))
+
+	checksums, err := c.GetPackageChecksums(context.Background())
+	require.NoError(t, err)
+	checksumsFile, err := os.Create(filepath.Join(cacheDir, jsonChecksumsFile))
+	require.NoError(t, err)
+	require.NoError(t, declcfg.WriteChecksums(declcfg.Checksums{Packages: checksums}, checksumsFile))
+	require.NoError(t, checksumsFile.Close())
+
+	backend := c.(*cache).backend
+	digest, err := backend.ComputeDigest(context.Background(), fbcFS)
+	require.NoError(t, err)
+	require.NoError(t, backend.PutDigest(context.Background(), digest))
+
+	require.NoError(t, LoadOrRebuild(context.Background(), c, fbcFS))
+	doc, err := c.GetPackageDocumentation(context.Background(), "etcd")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/etcd/README.md", doc.Url)
+}
+
+func TestCache_GetPackageMetadata(t *testing.T) {
+	fbcFS := fstest.MapFS{}
+	for k, v := range validFS {
+		fbcFS[k] = v
+	}
+	fbcFS["cockroachdb-deprecations.yaml"] = &fstest.MapFile{
+		Data: []byte(`---
+schema: olm.deprecations
+package: cockroachdb
+entries:
+- reference:
+    schema: olm.channel
+    name: stable-3.x
+  message: stable-3.x is deprecated
+- reference:
+    schema: olm.package
+  message: cockroachdb is deprecated
+`),
+	}
+
+	for name, testQuerier := range genTestCaches(t, fbcFS) {
+		t.Run(name, func(t *testing.T) {
+			md, err := testQuerier.GetPackageMetadata(context.TODO(), "cockroachdb")
+			require.NoError(t, err)
+			require.Equal(t, "cockroachdb", md.Name)
+			require.Equal(t, "stable-5.x", md.DefaultChannelName)
+			require.Equal(t, "image/svg+xml", md.Icon.MediaType)
+			require.NotEmpty(t, md.Icon.Data)
+			require.Equal(t, "cockroachdb is deprecated", md.Deprecation.GetMessage())
+
+			var channels []string
+			for _, ch := range md.Channels {
+				channels = append(channels, fmt.Sprintf("%s %s %s %d %q", ch.Name, ch.HeadCsvName, ch.HeadVersion, ch.EntryCount, ch.Deprecation.GetMessage()))
+			}
+			require.Equal(t, []string{
+				`stable cockroachdb.v2.1.11 2.1.11 3 ""`,
+				`stable-3.x cockroachdb.v3.0.7 3.0.7 1 "stable-3.x is deprecated"`,
+				`stable-5.x cockroachdb.v5.0.3 5.0.3 1 ""`,
+			}, channels)
+
+			md, err = testQuerier.GetPackageMetadata(context.TODO(), "etcd")
+			require.NoError(t, err)
+			require.Equal(t, "A message about etcd operator, a description of channels", md.Description)
+			require.Nil(t, md.Deprecation)
+
+			_, err = testQuerier.GetPackageMetadata(context.TODO(), "missing")
+			require.Equal(t, codes.NotFound, status.Code(err))
+			require.Equal(t, `package "missing" not found`, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestCache_ListDeprecations(t *testing.T) {
+	fbcFS := fstest.MapFS{}
+	for k, v := range validFS {
+		fbcFS[k] = v
+	}
+	fbcFS["cockroachdb-deprecations.yaml"] = &fstest.MapFile{
+		Data: []byte(`---
+schema: olm.deprecations
+package: cockroachdb
+entries:
+- reference:
+    schema: olm.bundle
+    name: cockroachdb.v2.1.1
+  message: cockroachdb.v2.1.1 is deprecated
+- reference:
+    schema: olm.channel
+    name: stable-3.x
+  message: stable-3.x is deprecated
+- reference:
+    schema: olm.bundle
+    name: cockroachdb.v2.0.9
+  message: cockroachdb.v2.0.9 is deprecated
+- reference:
+    schema: olm.package
+  message: cockroachdb is deprecated
+`),
+	}
+	expected := []*api.DeprecationEntry{
+		{PackageName: "cockroachdb", Schema: "olm.package", Deprecation: &api.Deprecation{Message: "cockroachdb is deprecated"}},
+		{PackageName: "cockroachdb", Schema: "olm.channel", Name: "stable-3.x", Deprecation: &api.Deprecation{Message: "stable-3.x is deprecated"}},
+		{PackageName: "cockroachdb", Schema: "olm.bundle", Name: "cockroachdb.v2.0.9", Deprecation: &api.Deprecation{Message: "cockroachdb.v2.0.9 is deprecated"}},
+		{PackageName: "cockroachdb", Schema: "olm.bundle", Name: "cockroachdb.v2.1.1", Deprecation: &api.Deprecation{Message: "cockroachdb.v2.1.1 is deprecated"}},
+	}
+
+	for name, testQuerier := range genTestCaches(t, fbcFS) {
+		t.Run(name, func(t *testing.T) {
+			deprecations, err := testQuerier.ListDeprecations(context.TODO(), "cockroachdb")
+			require.NoError(t, err)
+			require.Equal(t, expected, deprecations)
+
+			deprecations, err = testQuerier.ListDeprecations(context.TODO(), "")
+			require.NoError(t, err)
+			require.Equal(t, expected, deprecations)
+
+			deprecations, err = testQuerier.ListDeprecations(context.TODO(), "etcd")
+			require.NoError(t, err)
+			require.Empty(t, deprecations)
+
+			_, err = testQuerier.ListDeprecations(context.TODO(), "missing")
+			require.EqualError(t, err, `package "missing" not found`)
+		})
+	}
+}
+
+func TestCache_Warmup(t *testing.T) {
+	for name, testQuerier := range genTestCaches(t, validFS) {
+		t.Run(name, func(t *testing.T) {
+			stats, err := Warmup(context.TODO(), testQuerier)
+			require.NoError(t, err)
+			require.Equal(t, &WarmupStats{Packages: 2, Bundles: 2, Complete: true}, stats)
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			stats, err = Warmup(ctx, testQuerier)
+			require.NoError(t, err)
+			require.False(t, stats.Complete)
+		})
+	}
+}
+
+func TestCache_BuildLimits(t *testing.T) {
+	type spec struct {
+		name      string
+		opts      []CacheOption
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Default",
+			assertion: require.NoError,
+		},
+		{
+			name:      "SerialWithTinyMemoryBudget",
+			opts:      []CacheOption{WithConcurrency(1), WithMaxMemory(1)},
+			assertion: require.NoError,
+		},
+		{
+			name:      "ConcurrentWithMemoryBudget",
+			opts:      []CacheOption{WithConcurrency(4), WithMaxMemory(64 << 10)},
+			assertion: require.NoError,
+		},
+		{
+			name:      "InvalidConcurrency",
+			opts:      []CacheOption{WithConcurrency(-1)},
+			assertion: require.Error,
+		},
+		{
+			name:      "InvalidMaxMemory",
+			opts:      []CacheOption{WithMaxMemory(-1)},
+			assertion: require.Error,
+		},
+	}
+	for _, format := range []string{FormatJSON, FormatPogrebV1, FormatIndexV2} {
+		for _, s := range specs {
+			t.Run(format+"/"+s.name, func(t *testing.T) {
+				c, err := New(t.TempDir(), append([]CacheOption{WithFormat(format), WithLog(log.Null())}, s.opts...)...)
+				s.assertion(t, err)
+				if err != nil {
+					return
+				}
+				defer c.Close()
+
+				require.NoError(t, c.Build(context.Background(), validFS))
+				require.NoError(t, c.Load(context.Background()))
+				pkgs, err := c.ListPackages(context.Background())
+				require.NoError(t, err)
+				require.ElementsMatch(t, []string{"cockroachdb", "etcd"}, pkgs)
+			})
+		}
+	}
+}
+
+func TestCache_Conformance(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatPogrebV1, FormatIndexV2} {
+		t.Run(format, func(t *testing.T) {
+			querytest.Run(t, func(t *testing.T, fbc fs.FS) registry.GRPCQuery {
+				c, err := New(t.TempDir(), WithFormat(format), WithLog(log.Null()))
+				require.NoError(t, err)
+				require.NoError(t, c.Build(context.Background(), fbc))
+				require.NoError(t, c.Load(context.Background()))
+				return c
+			})
+		})
+	}
+}
+
 func genTestCaches(t *testing.T, fbcFS fs.FS) map[string]Cache {
 	t.Helper()
 
 	caches := make(map[string]Cache)
-	for _, format := range []string{FormatJSON, FormatPogrebV1} {
+	for _, format := range []string{FormatJSON, FormatPogrebV1, FormatIndexV2} {
 		c, err := New(t.TempDir(), WithFormat(format), WithLog(log.Null()))
 		require.NoError(t, err)
 		caches[format] = c
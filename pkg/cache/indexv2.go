@@ -0,0 +1,670 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/exp/mmap"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+var _ backend = &indexV2Backend{}
+
+func newIndexV2Backend(baseDir string) *indexV2Backend {
+	return &indexV2Backend{baseDir: baseDir}
+}
+
+const (
+	FormatIndexV2 = "index.v2"
+
+	indexV2CacheModeDir  = 0770
+	indexV2CacheModeFile = 0660
+
+	indexV2CacheDir      = FormatIndexV2
+	indexV2DigestFile    = indexV2CacheDir + "/digest"
+	indexV2ChecksumsFile = indexV2CacheDir + "/checksums.json"
+	indexV2IndexFile     = indexV2CacheDir + "/index.bin"
+	indexV2BundlesFile   = indexV2CacheDir + "/bundles.bin"
+	indexV2StagedFile    = indexV2CacheDir + "/bundles.staged"
+
+	indexV2Magic      = "OPMCACHE"
+	indexV2Version    = 2
+	indexV2HeaderSize = 64
+	indexV2SlotSize   = 32
+)
+
+// indexV2Backend stores bundles as concatenated protobuf messages in a single
+// file, along with an index file that is memory-mapped when the cache is
+// opened. Opening the cache only requires decoding the package index, and
+// looking up a bundle, or the bundles that provide an API, takes a constant
+// number of reads from the index.
+//
+// The index file consists of a fixed-size header followed by its sections:
+//
+//	header:   magic "OPMCACHE", uint32 version, uint32 reserved, and the
+//	          offset (uint64) and length (uint32) of the sections below
+//	packages: the package index, as JSON
+//	providers: the lists of bundles that provide each API, each a sequence
+//	          of uvarint-length-prefixed encoded bundle keys
+//	bundles:  a hash table mapping encoded bundle keys to the offset and
+//	          length of the bundle in the bundles file
+//	gvks:     a hash table mapping encoded group/version/kind keys to the
+//	          offset and length of their list in the providers section
+//
+// All integers are little-endian. Hash tables are stored as a uint64 slot
+// count (a power of two) followed by the slots, and the keys. Each slot
+// holds the FNV-1a hash of its key (zero for empty slots), the key offset
+// (uint64) and length (uint32), and the value length (uint32) and offset
+// (uint64). Collisions are resolved by linear probing.
+type indexV2Backend struct {
+	baseDir string
+
+	index   *mmap.ReaderAt
+	bundles *mmap.ReaderAt
+	header  indexV2Header
+
+	// Build state. Bundles are appended to a staging file as they are put,
+	// in whatever order the build puts them. The bundles file and the index
+	// are written along with the package index, with the bundles in key
+	// order, so that the same catalog always produces the same cache.
+	mu         sync.Mutex
+	stagedFile *os.File
+	stagedBuf  *bufio.Writer
+	offset     uint64
+	locations  map[string]indexV2Location
+	providers  map[string][]string
+}
+
+type indexV2Location struct {
+	offset uint64
+	length uint32
+}
+
+type indexV2Header struct {
+	packages, providers, bundles, gvks indexV2Location
+}
+
+func (q *indexV2Backend) Name() string {
+	return FormatIndexV2
+}
+
+func (q *indexV2Backend) IsCachePresent() bool {
+	entries, err := os.ReadDir(q.baseDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() == indexV2CacheDir {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *indexV2Backend) Init() error {
+	if err := q.Close(); err != nil {
+		return fmt.Errorf("failed to close existing cache: %v", err)
+	}
+	if err := ensureEmptyDir(filepath.Join(q.baseDir, indexV2CacheDir), indexV2CacheModeDir); err != nil {
+		return fmt.Errorf("ensure empty cache directory: %v", err)
+	}
+	q.offset = 0
+	q.locations = map[string]indexV2Location{}
+	q.providers = map[string][]string{}
+	return nil
+}
+
+// Open maps the index and bundles files into memory, if the cache has been
+// built.
+func (q *indexV2Backend) Open() error {
+	index, err := mmap.Open(filepath.Join(q.baseDir, indexV2IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	header, err := readIndexV2Header(index)
+	if err != nil {
+		index.Close()
+		return fmt.Errorf("read cache index: %v", err)
+	}
+	bundles, err := mmap.Open(filepath.Join(q.baseDir, indexV2BundlesFile))
+	if err != nil {
+		index.Close()
+		return err
+	}
+	q.index, q.bundles, q.header = index, bundles, *header
+	return nil
+}
+
+func (q *indexV2Backend) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var errs []error
+	if q.stagedFile != nil {
+		errs = append(errs, q.stagedBuf.Flush(), q.stagedFile.Close())
+		q.stagedFile, q.stagedBuf = nil, nil
+	}
+	if q.index != nil {
+		errs = append(errs, q.index.Close())
+		q.index = nil
+	}
+	if q.bundles != nil {
+		errs = append(errs, q.bundles.Close())
+		q.bundles = nil
+	}
+	return errors.Join(errs...)
+}
+
+func (q *indexV2Backend) GetPackageIndex(_ context.Context) (packageIndex, error) {
+	if q.index == nil {
+		return nil, fmt.Errorf("cache index not found")
+	}
+	data, err := readIndexV2(q.index, q.header.packages)
+	if err != nil {
+		return nil, err
+	}
+	var pi packageIndex
+	if err := json.Unmarshal(data, &pi); err != nil {
+		return nil, err
+	}
+	return pi, nil
+}
+
+// PutPackageIndex writes the index of all bundles put since Init, along with
+// pi, and opens the cache for reading.
+func (q *indexV2Backend) PutPackageIndex(_ context.Context, pi packageIndex) error {
+	packagesJSON, err := json.Marshal(pi)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	locations, err := q.writeBundlesFile()
+	if err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	index := encodeIndexV2(packagesJSON, locations, q.providers)
+	q.mu.Unlock()
+
+	if err := q.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(q.baseDir, indexV2IndexFile), index, indexV2CacheModeFile); err != nil {
+		return err
+	}
+	return q.Open()
+}
+
+// writeBundlesFile copies the staged bundles to the bundles file in key
+// order, removes the staging file, and returns the locations of the bundles
+// in the bundles file. The caller must hold q.mu.
+func (q *indexV2Backend) writeBundlesFile() (map[string]indexV2Location, error) {
+	bundlesFile, err := os.OpenFile(filepath.Join(q.baseDir, indexV2BundlesFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, indexV2CacheModeFile)
+	if err != nil {
+		return nil, err
+	}
+	defer bundlesFile.Close()
+	if q.stagedFile == nil {
+		// No bundles were put, so leave the bundles file empty.
+		return map[string]indexV2Location{}, bundlesFile.Close()
+	}
+
+	if err := q.stagedBuf.Flush(); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(q.locations))
+	for k := range q.locations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := bufio.NewWriter(bundlesFile)
+	locations := make(map[string]indexV2Location, len(q.locations))
+	var offset uint64
+	for _, k := range keys {
+		staged := q.locations[k]
+		if _, err := io.Copy(w, io.NewSectionReader(q.stagedFile, int64(staged.offset), int64(staged.length))); err != nil {
+			return nil, fmt.Errorf("copy staged bundle: %v", err)
+		}
+		locations[k] = indexV2Location{offset: offset, length: staged.length}
+		offset += uint64(staged.length)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	if err := bundlesFile.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := q.stagedFile.Close(); err != nil {
+		return nil, err
+	}
+	q.stagedFile, q.stagedBuf = nil, nil
+	if err := os.Remove(filepath.Join(q.baseDir, indexV2StagedFile)); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (q *indexV2Backend) SendBundles(ctx context.Context, s registry.BundleSender) error {
+	if q.index == nil {
+		return fmt.Errorf("cache index not found")
+	}
+	return walkIndexV2Table(q.index, q.header.bundles, func(key string, loc indexV2Location) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		bundle, err := q.readBundle(loc)
+		if err != nil {
+			k := decodeIndexV2BundleKey(key)
+			return fmt.Errorf("failed to read bundle for package %q, channel %q, key %q: %w", k.PackageName, k.ChannelName, k.Name, err)
+		}
+		return s.Send(bundle)
+	})
+}
+
+func (q *indexV2Backend) GetBundle(_ context.Context, key bundleKey) (*api.Bundle, error) {
+	if q.index == nil {
+		return nil, fmt.Errorf("cache index not found")
+	}
+	loc, ok, err := lookupIndexV2Table(q.index, q.header.bundles, encodeIndexV2BundleKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("package %q, channel %q, bundle %q not found in cache", key.PackageName, key.ChannelName, key.Name)
+	}
+	return q.readBundle(loc)
+}
+
+func (q *indexV2Backend) readBundle(loc indexV2Location) (*api.Bundle, error) {
+	data, err := readIndexV2(q.bundles, loc)
+	if err != nil {
+		return nil, err
+	}
+	var b api.Bundle
+	if err := proto.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// PutBundle appends bundle to the staging file. It is not visible to
+// GetBundle until the bundles file and the index are written by
+// PutPackageIndex.
+func (q *indexV2Backend) PutBundle(_ context.Context, key bundleKey, bundle *api.Bundle) error {
+	d, err := proto.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stagedFile == nil {
+		if err := q.openStagedFile(); err != nil {
+			return err
+		}
+	}
+	if _, err := q.stagedBuf.Write(d); err != nil {
+		return err
+	}
+	k := encodeIndexV2BundleKey(key)
+	q.locations[k] = indexV2Location{offset: q.offset, length: uint32(len(d))}
+	q.offset += uint64(len(d))
+	for _, gvk := range bundle.ProvidedApis {
+		gk := encodeIndexV2GVKKey(gvk.Group, gvk.Version, gvk.Kind)
+		q.providers[gk] = append(q.providers[gk], k)
+	}
+	return nil
+}
+
+// openStagedFile opens the staging file for appending. The caller must hold
+// q.mu.
+func (q *indexV2Backend) openStagedFile() error {
+	f, err := os.OpenFile(filepath.Join(q.baseDir, indexV2StagedFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, indexV2CacheModeFile)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if q.locations == nil {
+		q.locations = map[string]indexV2Location{}
+		q.providers = map[string][]string{}
+	}
+	q.stagedFile, q.stagedBuf, q.offset = f, bufio.NewWriter(f), uint64(info.Size())
+	return nil
+}
+
+// BundlesThatProvide returns the keys of the bundles that provide the
+// given API, using the index rather than reading every bundle.
+func (q *indexV2Backend) BundlesThatProvide(_ context.Context, group, version, kind string) ([]bundleKey, error) {
+	if q.index == nil {
+		return nil, fmt.Errorf("cache index not found")
+	}
+	loc, ok, err := lookupIndexV2Table(q.index, q.header.gvks, encodeIndexV2GVKKey(group, version, kind))
+	if err != nil || !ok {
+		return nil, err
+	}
+	data, err := readIndexV2(q.index, loc)
+	if err != nil {
+		return nil, err
+	}
+	var keys []bundleKey
+	for len(data) > 0 {
+		n, l := binary.Uvarint(data)
+		if l <= 0 || uint64(len(data)-l) < n {
+			return nil, fmt.Errorf("corrupt provider list for %s/%s/%s", group, version, kind)
+		}
+		keys = append(keys, decodeIndexV2BundleKey(string(data[l:l+int(n)])))
+		data = data[l+int(n):]
+	}
+	return keys, nil
+}
+
+func (q *indexV2Backend) GetDigest(_ context.Context) (string, error) {
+	return readDigestFile(filepath.Join(q.baseDir, indexV2DigestFile))
+}
+
+// ComputeDigest hashes the FBC along with the index and bundles files, so
+// that both changes to the FBC and changes to the cache invalidate it.
+func (q *indexV2Backend) ComputeDigest(ctx context.Context, fbcFsys fs.FS) (string, error) {
+	q.mu.Lock()
+	if q.stagedBuf != nil {
+		if err := q.stagedBuf.Flush(); err != nil {
+			q.mu.Unlock()
+			return "", err
+		}
+	}
+	q.mu.Unlock()
+
+	computedHasher := fnv.New64a()
+
+	// Use concurrency=1 to ensure deterministic ordering of meta blobs.
+	loadOpts := []declcfg.LoadOption{declcfg.WithConcurrency(1)}
+	if err := declcfg.WalkMetasFS(ctx, fbcFsys, func(path string, meta *declcfg.Meta, err error) error {
+		if err != nil {
+			return err
+		}
+		_, err = computedHasher.Write(meta.Blob)
+		return err
+	}, loadOpts...); err != nil {
+		return "", err
+	}
+
+	// The staging file only exists if bundles were put after the cache was
+	// built.
+	for _, file := range []string{indexV2IndexFile, indexV2BundlesFile, indexV2StagedFile} {
+		if err := hashFile(computedHasher, filepath.Join(q.baseDir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("compute hash: %v", err)
+		}
+	}
+	return fmt.Sprintf("%x", computedHasher.Sum(nil)), nil
+}
+
+func (q *indexV2Backend) PutDigest(_ context.Context, digest string) error {
+	return writeDigestFile(filepath.Join(q.baseDir, indexV2DigestFile), digest, indexV2CacheModeFile)
+}
+
+func (q *indexV2Backend) GetChecksums(_ context.Context) (map[string]string, error) {
+	return readChecksumsFile(filepath.Join(q.baseDir, indexV2ChecksumsFile))
+}
+
+func (q *indexV2Backend) PutChecksums(_ context.Context, checksums map[string]string) error {
+	return writeChecksumsFile(filepath.Join(q.baseDir, indexV2ChecksumsFile), checksums, indexV2CacheModeFile)
+}
+
+func hashFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func encodeIndexV2BundleKey(k bundleKey) string {
+	return strings.Join([]string{k.PackageName, k.ChannelName, k.Name}, "\x00")
+}
+
+func decodeIndexV2BundleKey(s string) bundleKey {
+	parts := strings.SplitN(s, "\x00", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	return bundleKey{PackageName: parts[0], ChannelName: parts[1], Name: parts[2]}
+}
+
+func encodeIndexV2GVKKey(group, version, kind string) string {
+	return strings.Join([]string{group, version, kind}, "\x00")
+}
+
+func indexV2Hash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = io.WriteString(h, key)
+	// Zero marks an empty slot.
+	return max(h.Sum64(), 1)
+}
+
+func encodeIndexV2(packagesJSON []byte, locations map[string]indexV2Location, providers map[string][]string) []byte {
+	buf := make([]byte, indexV2HeaderSize)
+	var header indexV2Header
+
+	header.packages = indexV2Location{offset: uint64(len(buf)), length: uint32(len(packagesJSON))}
+	buf = append(buf, packagesJSON...)
+
+	// Sort the provider lists, so that the index only depends on the
+	// bundles that were put, not on the order they were put in.
+	gvkLocations := make(map[string]indexV2Location, len(providers))
+	gvkKeys := make([]string, 0, len(providers))
+	for gk := range providers {
+		gvkKeys = append(gvkKeys, gk)
+	}
+	sort.Strings(gvkKeys)
+	providersStart := len(buf)
+	for _, gk := range gvkKeys {
+		keys := providers[gk]
+		sort.Strings(keys)
+		start := len(buf)
+		for _, k := range keys {
+			buf = binary.AppendUvarint(buf, uint64(len(k)))
+			buf = append(buf, k...)
+		}
+		gvkLocations[gk] = indexV2Location{offset: uint64(start), length: uint32(len(buf) - start)}
+	}
+	header.providers = indexV2Location{offset: uint64(providersStart), length: uint32(len(buf) - providersStart)}
+
+	start := len(buf)
+	buf = appendIndexV2Table(buf, locations)
+	header.bundles = indexV2Location{offset: uint64(start), length: uint32(len(buf) - start)}
+
+	start = len(buf)
+	buf = appendIndexV2Table(buf, gvkLocations)
+	header.gvks = indexV2Location{offset: uint64(start), length: uint32(len(buf) - start)}
+
+	copy(buf, indexV2Magic)
+	binary.LittleEndian.PutUint32(buf[8:], indexV2Version)
+	for i, loc := range []indexV2Location{header.packages, header.providers, header.bundles, header.gvks} {
+		binary.LittleEndian.PutUint64(buf[16+i*12:], loc.offset)
+		binary.LittleEndian.PutUint32(buf[24+i*12:], loc.length)
+	}
+	return buf
+}
+
+func appendIndexV2Table(buf []byte, entries map[string]indexV2Location) []byte {
+	numSlots := uint64(1)
+	if len(entries) > 0 {
+		// Keep the load factor at or below 1/2.
+		numSlots = 1 << bits.Len64(uint64(2*len(entries)-1))
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, numSlots)
+	slotsStart := len(buf)
+	buf = append(buf, make([]byte, numSlots*indexV2SlotSize)...)
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h := indexV2Hash(k)
+		slot := h & (numSlots - 1)
+		for binary.LittleEndian.Uint64(buf[slotsStart+int(slot)*indexV2SlotSize:]) != 0 {
+			slot = (slot + 1) & (numSlots - 1)
+		}
+		s := buf[slotsStart+int(slot)*indexV2SlotSize:]
+		binary.LittleEndian.PutUint64(s, h)
+		binary.LittleEndian.PutUint64(s[8:], uint64(len(buf)))
+		binary.LittleEndian.PutUint32(s[16:], uint32(len(k)))
+		binary.LittleEndian.PutUint32(s[20:], entries[k].length)
+		binary.LittleEndian.PutUint64(s[24:], entries[k].offset)
+		buf = append(buf, k...)
+	}
+	return buf
+}
+
+func readIndexV2Header(r *mmap.ReaderAt) (*indexV2Header, error) {
+	buf := make([]byte, indexV2HeaderSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, err
+	}
+	if string(buf[:8]) != indexV2Magic {
+		return nil, fmt.Errorf("unexpected magic %q", buf[:8])
+	}
+	if v := binary.LittleEndian.Uint32(buf[8:]); v != indexV2Version {
+		return nil, fmt.Errorf("unsupported version %d", v)
+	}
+	var h indexV2Header
+	for i, loc := range []*indexV2Location{&h.packages, &h.providers, &h.bundles, &h.gvks} {
+		loc.offset = binary.LittleEndian.Uint64(buf[16+i*12:])
+		loc.length = binary.LittleEndian.Uint32(buf[24+i*12:])
+		if loc.offset+uint64(loc.length) > uint64(r.Len()) {
+			return nil, fmt.Errorf("section %d exceeds index size", i)
+		}
+	}
+	return &h, nil
+}
+
+func readIndexV2(r *mmap.ReaderAt, loc indexV2Location) ([]byte, error) {
+	if loc.offset+uint64(loc.length) > uint64(r.Len()) {
+		return nil, fmt.Errorf("read %d bytes at offset %d: %w", loc.length, loc.offset, io.ErrUnexpectedEOF)
+	}
+	buf := make([]byte, loc.length)
+	if _, err := r.ReadAt(buf, int64(loc.offset)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+type indexV2Slot struct {
+	hash  uint64
+	key   indexV2Location
+	value indexV2Location
+}
+
+func readIndexV2Slot(r *mmap.ReaderAt, table indexV2Location, i uint64) (indexV2Slot, error) {
+	data, err := readIndexV2(r, indexV2Location{offset: table.offset + 8 + i*indexV2SlotSize, length: indexV2SlotSize})
+	if err != nil {
+		return indexV2Slot{}, err
+	}
+	return indexV2Slot{
+		hash:  binary.LittleEndian.Uint64(data),
+		key:   indexV2Location{offset: binary.LittleEndian.Uint64(data[8:]), length: binary.LittleEndian.Uint32(data[16:])},
+		value: indexV2Location{offset: binary.LittleEndian.Uint64(data[24:]), length: binary.LittleEndian.Uint32(data[20:])},
+	}, nil
+}
+
+func readIndexV2NumSlots(r *mmap.ReaderAt, table indexV2Location) (uint64, error) {
+	data, err := readIndexV2(r, indexV2Location{offset: table.offset, length: 8})
+	if err != nil {
+		return 0, err
+	}
+	numSlots := binary.LittleEndian.Uint64(data)
+	if numSlots == 0 || numSlots&(numSlots-1) != 0 || 8+numSlots*indexV2SlotSize > uint64(table.length) {
+		return 0, fmt.Errorf("corrupt hash table with %d slots", numSlots)
+	}
+	return numSlots, nil
+}
+
+func lookupIndexV2Table(r *mmap.ReaderAt, table indexV2Location, key string) (indexV2Location, bool, error) {
+	numSlots, err := readIndexV2NumSlots(r, table)
+	if err != nil {
+		return indexV2Location{}, false, err
+	}
+	h := indexV2Hash(key)
+	for i, slot := uint64(0), h&(numSlots-1); i < numSlots; i, slot = i+1, (slot+1)&(numSlots-1) {
+		s, err := readIndexV2Slot(r, table, slot)
+		if err != nil {
+			return indexV2Location{}, false, err
+		}
+		if s.hash == 0 {
+			break
+		}
+		if s.hash != h || int(s.key.length) != len(key) {
+			continue
+		}
+		k, err := readIndexV2(r, s.key)
+		if err != nil {
+			return indexV2Location{}, false, err
+		}
+		if bytes.Equal(k, []byte(key)) {
+			return s.value, true, nil
+		}
+	}
+	return indexV2Location{}, false, nil
+}
+
+// walkIndexV2Table calls f for each entry of a hash table, in key order.
+func walkIndexV2Table(r *mmap.ReaderAt, table indexV2Location, f func(key string, value indexV2Location) error) error {
+	numSlots, err := readIndexV2NumSlots(r, table)
+	if err != nil {
+		return err
+	}
+	// Keys are stored after the slots in sorted order, so sort the slots by
+	// key offset.
+	var slots []indexV2Slot
+	for i := uint64(0); i < numSlots; i++ {
+		s, err := readIndexV2Slot(r, table, i)
+		if err != nil {
+			return err
+		}
+		if s.hash != 0 {
+			slots = append(slots, s)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].key.offset < slots[j].key.offset })
+	for _, s := range slots {
+		k, err := readIndexV2(r, s.key)
+		if err != nil {
+			return err
+		}
+		if err := f(string(k), s.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
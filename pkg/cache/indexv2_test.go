@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func TestIndexV2_CheckIntegrity(t *testing.T) {
+	type testCase struct {
+		name   string
+		build  bool
+		fbcFS  fs.FS
+		mod    func(t *testing.T, tc *testCase, cacheDir string, backend backend)
+		expect func(t *testing.T, err error)
+	}
+	testCases := []testCase{
+		{
+			name:  "empty cache dir",
+			fbcFS: validFS,
+			expect: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "read existing cache digest")
+			},
+		},
+		{
+			name:  "valid cache dir",
+			build: true,
+			fbcFS: validFS,
+			expect: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "different FBC",
+			build: true,
+			fbcFS: validFS,
+			mod: func(t *testing.T, tc *testCase, _ string, _ backend) {
+				tc.fbcFS = badBundleFS
+			},
+			expect: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "cache requires rebuild")
+			},
+		},
+		{
+			name:  "different cache",
+			build: true,
+			fbcFS: validFS,
+			mod: func(t *testing.T, tc *testCase, cacheDir string, b backend) {
+				require.NoError(t, b.PutBundle(context.Background(), bundleKey{"foo", "bar", "baz"}, &api.Bundle{PackageName: "foo", ChannelName: "bar", CsvName: "baz"}))
+			},
+			expect: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "cache requires rebuild")
+			},
+		},
+		{
+			name:  "missing package checksums",
+			build: true,
+			fbcFS: validFS,
+			mod: func(t *testing.T, tc *testCase, cacheDir string, _ backend) {
+				require.NoError(t, os.Remove(filepath.Join(cacheDir, indexV2ChecksumsFile)))
+			},
+			expect: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "cache requires rebuild: cache content version is outdated")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			c := &cache{backend: newIndexV2Backend(cacheDir), log: log.Null()}
+			defer c.Close()
+
+			if tc.build {
+				require.NoError(t, c.Build(context.Background(), tc.fbcFS))
+			}
+			if tc.mod != nil {
+				tc.mod(t, &tc, cacheDir, c.backend)
+			}
+			tc.expect(t, c.CheckIntegrity(context.Background(), tc.fbcFS))
+		})
+	}
+}
+
+func TestIndexV2_Index(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	b := newIndexV2Backend(cacheDir)
+	require.NoError(t, b.Init())
+
+	// Enough bundles to exercise collisions in the hash tables.
+	const numBundles = 1000
+	for i := 0; i < numBundles; i++ {
+		key := bundleKey{PackageName: fmt.Sprintf("pkg%d", i%7), ChannelName: "stable", Name: fmt.Sprintf("bundle.v%d", i)}
+		bundle := &api.Bundle{PackageName: key.PackageName, ChannelName: key.ChannelName, CsvName: key.Name}
+		if i%10 == 0 {
+			bundle.ProvidedApis = []*api.GroupVersionKind{{Group: "example.com", Version: "v1", Kind: "Tenth"}}
+		}
+		require.NoError(t, b.PutBundle(ctx, key, bundle))
+	}
+	require.NoError(t, b.PutPackageIndex(ctx, packageIndex{"pkg0": {Name: "pkg0"}}))
+	require.NoError(t, b.Close())
+
+	// Reopen the cache, as opm serve would.
+	b = newIndexV2Backend(cacheDir)
+	require.NoError(t, b.Open())
+	defer b.Close()
+
+	pi, err := b.GetPackageIndex(ctx)
+	require.NoError(t, err)
+	require.Equal(t, packageIndex{"pkg0": {Name: "pkg0"}}, pi)
+
+	for i := 0; i < numBundles; i++ {
+		key := bundleKey{PackageName: fmt.Sprintf("pkg%d", i%7), ChannelName: "stable", Name: fmt.Sprintf("bundle.v%d", i)}
+		bundle, err := b.GetBundle(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, key.Name, bundle.CsvName)
+	}
+	_, err = b.GetBundle(ctx, bundleKey{PackageName: "pkg0", ChannelName: "stable", Name: "missing"})
+	require.ErrorContains(t, err, "not found")
+
+	keys, err := b.BundlesThatProvide(ctx, "example.com", "v1", "Tenth")
+	require.NoError(t, err)
+	require.Len(t, keys, numBundles/10)
+	keys, err = b.BundlesThatProvide(ctx, "example.com", "v1", "Missing")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	var sent sliceBundleSender
+	require.NoError(t, b.SendBundles(ctx, &sent))
+	require.Len(t, sent, numBundles)
+}
+
+func TestIndexV2_Reproducible(t *testing.T) {
+	ctx := context.Background()
+	keys := make([]bundleKey, 0, 20)
+	for i := 0; i < 20; i++ {
+		keys = append(keys, bundleKey{PackageName: fmt.Sprintf("pkg%d", i%3), ChannelName: "stable", Name: fmt.Sprintf("bundle.v%d", i)})
+	}
+
+	// Build workers put bundles in whatever order they finish in, which
+	// must not change the cache.
+	build := func(order []bundleKey) string {
+		cacheDir := t.TempDir()
+		b := newIndexV2Backend(cacheDir)
+		require.NoError(t, b.Init())
+		for _, key := range order {
+			bundle := &api.Bundle{PackageName: key.PackageName, ChannelName: key.ChannelName, CsvName: key.Name}
+			require.NoError(t, b.PutBundle(ctx, key, bundle))
+		}
+		require.NoError(t, b.PutPackageIndex(ctx, packageIndex{"pkg0": {Name: "pkg0"}}))
+		require.NoError(t, b.Close())
+		_, err := os.Stat(filepath.Join(cacheDir, indexV2StagedFile))
+		require.ErrorIs(t, err, os.ErrNotExist)
+		return cacheDir
+	}
+	reversed := slices.Clone(keys)
+	slices.Reverse(reversed)
+	dirA, dirB := build(keys), build(reversed)
+
+	for _, file := range []string{indexV2IndexFile, indexV2BundlesFile} {
+		a, err := os.ReadFile(filepath.Join(dirA, file))
+		require.NoError(t, err)
+		b, err := os.ReadFile(filepath.Join(dirB, file))
+		require.NoError(t, err)
+		require.Equal(t, a, b, file)
+	}
+}
+
+func TestIndexV2_CorruptIndex(t *testing.T) {
+	cacheDir := t.TempDir()
+	b := newIndexV2Backend(cacheDir)
+	require.NoError(t, b.Init())
+	require.NoError(t, b.PutPackageIndex(context.Background(), packageIndex{}))
+	require.NoError(t, b.Close())
+
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, indexV2IndexFile), []byte("not an index"), indexV2CacheModeFile))
+	require.Error(t, newIndexV2Backend(cacheDir).Open())
+}
@@ -6,13 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
-	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
@@ -30,9 +27,10 @@ const (
 	jsonCacheModeDir  = 0750
 	jsonCacheModeFile = 0640
 
-	jsonDigestFile   = "digest"
-	jsonDir          = "cache"
-	jsonPackagesFile = jsonDir + string(filepath.Separator) + "packages.json"
+	jsonDigestFile    = "digest"
+	jsonChecksumsFile = "checksums.json"
+	jsonDir           = "cache"
+	jsonPackagesFile  = jsonDir + string(filepath.Separator) + "packages.json"
 )
 
 type jsonBackend struct {
@@ -70,6 +68,9 @@ func (q *jsonBackend) Init() error {
 	if err := os.RemoveAll(filepath.Join(q.baseDir, jsonDigestFile)); err != nil {
 		return fmt.Errorf("failed to remove existing JSON digest file: %v", err)
 	}
+	if err := os.RemoveAll(filepath.Join(q.baseDir, jsonChecksumsFile)); err != nil {
+		return fmt.Errorf("failed to remove existing JSON checksums file: %v", err)
+	}
 	q.bundles = newBundleKeys()
 	return nil
 }
@@ -165,43 +166,25 @@ func (q *jsonBackend) PutDigest(_ context.Context, digest string) error {
 	return writeDigestFile(filepath.Join(q.baseDir, jsonDigestFile), digest, jsonCacheModeFile)
 }
 
-func (q *jsonBackend) SendBundles(_ context.Context, s registry.BundleSender) error {
-	keys := make([]bundleKey, 0, q.bundles.Len())
-	files := make([]*os.File, 0, q.bundles.Len())
-	readers := make([]io.Reader, 0, q.bundles.Len())
-	if err := q.bundles.Walk(func(key bundleKey) error {
-		file, err := os.Open(q.bundleFile(key))
-		if err != nil {
-			return fmt.Errorf("failed to open file for package %q, channel %q, key %q: %w", key.PackageName, key.ChannelName, key.Name, err)
-		}
-		keys = append(keys, key)
-		files = append(files, file)
-		readers = append(readers, file)
-		return nil
-	}); err != nil {
-		return err
-	}
-	defer func() {
-		for _, file := range files {
-			if err := file.Close(); err != nil {
-				logrus.WithError(err).WithField("file", file.Name()).Warn("could not close file")
-			}
-		}
-	}()
-	multiReader := io.MultiReader(readers...)
-	decoder := json.NewDecoder(multiReader)
-	index := 0
-	for {
-		var bundle api.Bundle
-		if err := decoder.Decode(&bundle); err == io.EOF {
-			break
-		} else if err != nil {
-			return fmt.Errorf("failed to decode file for package %q, channel %q, key %q: %w", keys[index].PackageName, keys[index].ChannelName, keys[index].Name, err)
-		}
-		if err := s.Send(&bundle); err != nil {
+func (q *jsonBackend) GetChecksums(_ context.Context) (map[string]string, error) {
+	return readChecksumsFile(filepath.Join(q.baseDir, jsonChecksumsFile))
+}
+
+func (q *jsonBackend) PutChecksums(_ context.Context, checksums map[string]string) error {
+	return writeChecksumsFile(filepath.Join(q.baseDir, jsonChecksumsFile), checksums, jsonCacheModeFile)
+}
+
+// SendBundles sends each bundle as soon as it is read, so that only one
+// bundle is held in memory, and one bundle file open, at a time.
+func (q *jsonBackend) SendBundles(ctx context.Context, s registry.BundleSender) error {
+	return q.bundles.Walk(func(key bundleKey) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		index += 1
-	}
-	return nil
+		bundle, err := q.GetBundle(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read bundle for package %q, channel %q, key %q: %w", key.PackageName, key.ChannelName, key.Name, err)
+		}
+		return s.Send(bundle)
+	})
 }
@@ -92,6 +92,18 @@ func TestJSON_CheckIntegrity(t *testing.T) {
 				require.Contains(t, err.Error(), "cache requires rebuild")
 			},
 		},
+		{
+			name:  "missing package checksums",
+			build: true,
+			fbcFS: validFS,
+			mod: func(_ *testCase, cacheDir string) error {
+				return os.Remove(filepath.Join(cacheDir, jsonChecksumsFile))
+			},
+			expect: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "cache requires rebuild: cache content version is outdated")
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
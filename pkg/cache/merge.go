@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// Root is a file-based catalog that is merged with others by MergeRoots.
+type Root struct {
+	// Name identifies the root in errors, e.g. the path it was loaded from.
+	Name string
+	FS   fs.FS
+}
+
+// MergedFS is a filesystem that holds the file-based catalogs of several
+// roots, each in a directory of its own. The directories are named after
+// the position of their root, padded so that they sort in the order of the
+// roots, which makes walking the merged filesystem, and thus the digest of
+// a cache built from it, deterministic.
+//
+// A cache built from a MergedFS serves the union of its roots. Each package
+// must be defined by a single root: building fails if the objects of a
+// package, e.g. a package or one of its bundles, are found in more than one.
+type MergedFS struct {
+	roots []Root
+	dirs  []string
+}
+
+var (
+	_ fs.ReadDirFS = &MergedFS{}
+	_ fs.StatFS    = &MergedFS{}
+)
+
+// MergeRoots returns a filesystem that merges roots, in order.
+func MergeRoots(roots ...Root) *MergedFS {
+	m := &MergedFS{roots: roots}
+	width := len(fmt.Sprint(len(roots) - 1))
+	for i := range roots {
+		m.dirs = append(m.dirs, fmt.Sprintf("%0*d", width, i))
+	}
+	return m
+}
+
+// resolve returns the root that holds name, and the name within it.
+func (m *MergedFS) resolve(op, name string) (*Root, string, error) {
+	if !fs.ValidPath(name) {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	dir, rest, _ := strings.Cut(name, "/")
+	for i := range m.dirs {
+		if m.dirs[i] == dir {
+			if rest == "" {
+				rest = "."
+			}
+			return &m.roots[i], rest, nil
+		}
+	}
+	return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// rootName returns the name of the root that holds name, which is a path
+// in m.
+func (m *MergedFS) rootName(name string) string {
+	root, _, err := m.resolve("open", name)
+	if err != nil {
+		return name
+	}
+	return root.Name
+}
+
+func (m *MergedFS) Open(name string) (fs.File, error) {
+	if name == "." {
+		entries, err := m.ReadDir(".")
+		if err != nil {
+			return nil, err
+		}
+		return &mergedDir{entries: entries}, nil
+	}
+	root, rest, err := m.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := root.FS.Open(rest)
+	if err != nil || rest != "." {
+		return f, err
+	}
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		f.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("root %q is not a directory", root.Name)}
+	}
+	return mergedRootDir{ReadDirFile: dir, name: name}, nil
+}
+
+func (m *MergedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "." {
+		entries := make([]fs.DirEntry, 0, len(m.roots))
+		for i := range m.roots {
+			entries = append(entries, fs.FileInfoToDirEntry(mergedDirInfo{name: m.dirs[i]}))
+		}
+		return entries, nil
+	}
+	root, rest, err := m.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(root.FS, rest)
+}
+
+func (m *MergedFS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return mergedDirInfo{name: "."}, nil
+	}
+	root, rest, err := m.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	if rest == "." {
+		// The directory of a root is named after its position, not
+		// after the root itself.
+		if _, err := fs.Stat(root.FS, rest); err != nil {
+			return nil, err
+		}
+		return mergedDirInfo{name: path.Base(name)}, nil
+	}
+	return fs.Stat(root.FS, rest)
+}
+
+// mergedDirInfo describes the directories of a MergedFS, which do not exist
+// on disk. They have fixed modes and modification times, so that they do
+// not affect the digest of the merged filesystem.
+type mergedDirInfo struct {
+	name string
+}
+
+func (i mergedDirInfo) Name() string       { return i.name }
+func (i mergedDirInfo) Size() int64        { return 0 }
+func (i mergedDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i mergedDirInfo) ModTime() time.Time { return time.Time{} }
+func (i mergedDirInfo) IsDir() bool        { return true }
+func (i mergedDirInfo) Sys() interface{}   { return nil }
+
+// mergedRootDir is the directory of a root in a MergedFS.
+type mergedRootDir struct {
+	fs.ReadDirFile
+	name string
+}
+
+func (d mergedRootDir) Stat() (fs.FileInfo, error) {
+	if _, err := d.ReadDirFile.Stat(); err != nil {
+		return nil, err
+	}
+	return mergedDirInfo{name: d.name}, nil
+}
+
+// mergedDir is the top-level directory of a MergedFS.
+type mergedDir struct {
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *mergedDir) Stat() (fs.FileInfo, error) { return mergedDirInfo{name: "."}, nil }
+func (d *mergedDir) Close() error               { return nil }
+
+func (d *mergedDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+func (d *mergedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// packageRoots records the root of each package found while walking a
+// MergedFS, to detect packages whose objects are in more than one root.
+type packageRoots struct {
+	fsys  *MergedFS
+	roots map[string]string
+}
+
+func newPackageRoots(fsys fs.FS) *packageRoots {
+	m, ok := fsys.(*MergedFS)
+	if !ok {
+		return nil
+	}
+	return &packageRoots{fsys: m, roots: map[string]string{}}
+}
+
+// add records that an object of package packageName is at path. It returns
+// an error if objects of the package were found in another root.
+func (p *packageRoots) add(packageName, schema, name, path string) error {
+	if p == nil || packageName == "" {
+		return nil
+	}
+	root := p.fsys.rootName(path)
+	existing, ok := p.roots[packageName]
+	if !ok {
+		p.roots[packageName] = root
+		return nil
+	}
+	if existing == root {
+		return nil
+	}
+	object := schema
+	if name != "" && schema != declcfg.SchemaPackage {
+		object = fmt.Sprintf("%s %q", schema, name)
+	}
+	return fmt.Errorf("package %q is defined in more than one root: %s found in %q, but the package is in %q", packageName, object, root, existing)
+}
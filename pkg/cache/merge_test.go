@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+// splitPriorityFS returns the packages of priorityFS in roots of their own.
+func splitPriorityFS() (foo, bar fstest.MapFS) {
+	fsys := priorityFS(nil)
+	foo = fstest.MapFS{"foo.json": fsys["foo.json"]}
+	bar = fstest.MapFS{"catalog/bar.json": fsys["bar.json"]}
+	return foo, bar
+}
+
+func TestMergedFS(t *testing.T) {
+	foo, bar := splitPriorityFS()
+	fsys := MergeRoots(Root{Name: "foo", FS: foo}, Root{Name: "bar", FS: bar})
+	require.NoError(t, fstest.TestFS(fsys, "0/foo.json", "1/catalog/bar.json"))
+
+	_, err := fs.Stat(fsys, "2")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMergedFS_Order(t *testing.T) {
+	var roots []Root
+	for i := 0; i < 11; i++ {
+		roots = append(roots, Root{Name: fmt.Sprint(i), FS: fstest.MapFS{}})
+	}
+	entries, err := fs.ReadDir(MergeRoots(roots...), ".")
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	require.Equal(t, []string{"00", "01", "02", "03", "04", "05", "06", "07", "08", "09", "10"}, names)
+}
+
+func TestCache_MergedRoots(t *testing.T) {
+	foo, bar := splitPriorityFS()
+	merged := MergeRoots(Root{Name: "foo", FS: foo}, Root{Name: "bar", FS: bar})
+	for name, testQuerier := range genTestCaches(t, merged) {
+		t.Run(name, func(t *testing.T) {
+			pkgs, err := testQuerier.ListPackages(context.TODO())
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"foo", "bar"}, pkgs)
+
+			b, err := testQuerier.GetBundle(context.TODO(), "bar", "stable", "bar.v1.0.0")
+			require.NoError(t, err)
+			require.Equal(t, "bar", b.PackageName)
+		})
+	}
+
+	c, err := New(t.TempDir(), WithLog(log.Null()))
+	require.NoError(t, err)
+	require.NoError(t, c.Build(context.Background(), merged))
+	require.NoError(t, c.CheckIntegrity(context.Background(), MergeRoots(Root{Name: "foo", FS: foo}, Root{Name: "bar", FS: bar})))
+	require.Error(t, c.CheckIntegrity(context.Background(), MergeRoots(Root{Name: "bar", FS: bar}, Root{Name: "foo", FS: foo})))
+}
+
+func TestCache_MergedRootsConflict(t *testing.T) {
+	foo, bar := splitPriorityFS()
+	for _, tt := range []struct {
+		name   string
+		second fstest.MapFS
+	}{
+		{
+			name:   "DuplicatePackage",
+			second: fstest.MapFS{"foo.json": foo["foo.json"]},
+		},
+		{
+			name: "DuplicateBundle",
+			second: fstest.MapFS{"bundle.json": &fstest.MapFile{Data: []byte(
+				`{"schema":"olm.bundle","package":"foo","name":"foo.v1.0.0","image":"example.com/foo.v1.0.0","properties":[{"type":"olm.package","value":{"packageName":"foo","version":"1.0.0"}}]}`,
+			)}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(t.TempDir(), WithLog(log.Null()))
+			require.NoError(t, err)
+			err = c.Build(context.Background(), MergeRoots(
+				Root{Name: "foo", FS: foo},
+				Root{Name: "bar", FS: bar},
+				Root{Name: "other", FS: tt.second},
+			))
+			require.ErrorContains(t, err, `package "foo" is defined in more than one root`)
+		})
+	}
+}
@@ -6,7 +6,12 @@ import (
 	"sort"
 	"strings"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/model"
+	"github.com/operator-framework/operator-registry/alpha/property"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
@@ -51,6 +56,119 @@ func (pkgs packageIndex) GetPackage(_ context.Context, name string) (*registry.P
 	return registryPackage, nil
 }
 
+func (pkgs packageIndex) GetPackageDocumentation(_ context.Context, name string) (*api.PackageDocumentation, error) {
+	pkg, ok := pkgs[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "package %q not found", name)
+	}
+	if pkg.Documentation == nil {
+		return nil, status.Errorf(codes.NotFound, "package %q has no documentation", name)
+	}
+	return &api.PackageDocumentation{
+		PackageName: pkg.Name,
+		Content:     pkg.Documentation.Content,
+		Url:         pkg.Documentation.URL,
+	}, nil
+}
+
+// GetPackageMetadata returns the metadata of the named package. The version
+// of the head of each channel is read from its bundle, which getBundle
+// returns.
+func (pkgs packageIndex) GetPackageMetadata(ctx context.Context, getBundle getBundleFunc, name string) (*api.PackageMetadata, error) {
+	pkg, ok := pkgs[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "package %q not found", name)
+	}
+
+	md := &api.PackageMetadata{
+		Name:               pkg.Name,
+		DefaultChannelName: pkg.DefaultChannel,
+		Description:        pkg.Description,
+	}
+	if pkg.Icon != nil {
+		md.Icon = &api.Icon{Data: pkg.Icon.Data, MediaType: pkg.Icon.MediaType}
+	}
+	if pkg.Deprecation != nil {
+		md.Deprecation = &api.Deprecation{Message: pkg.Deprecation.Message}
+	}
+	for _, ch := range pkg.Channels {
+		head, err := getBundle(ctx, bundleKey{pkg.Name, ch.Name, ch.Head})
+		if err != nil {
+			return nil, fmt.Errorf("get head of package %q, channel %q: %v", pkg.Name, ch.Name, err)
+		}
+		chMd := &api.ChannelMetadata{
+			Name:        ch.Name,
+			HeadCsvName: ch.Head,
+			HeadVersion: head.GetVersion(),
+			EntryCount:  int32(len(ch.Bundles)),
+		}
+		if ch.Deprecation != nil {
+			chMd.Deprecation = &api.Deprecation{Message: ch.Deprecation.Message}
+		}
+		md.Channels = append(md.Channels, chMd)
+	}
+	sort.Slice(md.Channels, func(i, j int) bool { return md.Channels[i].Name < md.Channels[j].Name })
+	return md, nil
+}
+
+func (pkgs packageIndex) ListDeprecations(_ context.Context, name string) ([]*api.DeprecationEntry, error) {
+	var names []string
+	if name != "" {
+		if _, ok := pkgs[name]; !ok {
+			return nil, fmt.Errorf("package %q not found", name)
+		}
+		names = []string{name}
+	} else {
+		for pkgName := range pkgs {
+			names = append(names, pkgName)
+		}
+		sort.Strings(names)
+	}
+
+	var entries []*api.DeprecationEntry
+	for _, pkgName := range names {
+		entries = append(entries, pkgs[pkgName].deprecations()...)
+	}
+	return entries, nil
+}
+
+// deprecations returns the deprecations of the package, then those of its
+// channels and bundles, each sorted by name.
+func (p cPkg) deprecations() []*api.DeprecationEntry {
+	entry := func(schema, name string, d *model.Deprecation) *api.DeprecationEntry {
+		return &api.DeprecationEntry{
+			PackageName: p.Name,
+			Schema:      schema,
+			Name:        name,
+			Deprecation: &api.Deprecation{Message: d.Message},
+		}
+	}
+
+	var entries []*api.DeprecationEntry
+	if p.Deprecation != nil {
+		entries = append(entries, entry(declcfg.SchemaPackage, "", p.Deprecation))
+	}
+	var channels, bundles []*api.DeprecationEntry
+	seenBundles := map[string]struct{}{}
+	for _, ch := range p.Channels {
+		if ch.Deprecation != nil {
+			channels = append(channels, entry(declcfg.SchemaChannel, ch.Name, ch.Deprecation))
+		}
+		// Bundle deprecations are copied to every channel entry of the
+		// bundle, so only report each bundle once.
+		for _, b := range ch.Bundles {
+			if _, ok := seenBundles[b.Name]; ok || b.Deprecation == nil {
+				continue
+			}
+			seenBundles[b.Name] = struct{}{}
+			bundles = append(bundles, entry(declcfg.SchemaBundle, b.Name, b.Deprecation))
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i].Name < bundles[j].Name })
+	return append(append(entries, channels...), bundles...)
+}
+
 func (pkgs packageIndex) GetChannelEntriesThatReplace(_ context.Context, name string) ([]*registry.ChannelEntry, error) {
 	var entries []*registry.ChannelEntry
 
@@ -157,6 +275,32 @@ func (pkgs packageIndex) GetLatestChannelEntriesThatProvide(ctx context.Context,
 	if len(entries) == 0 {
 		return nil, fmt.Errorf("no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
 	}
+	pkgs.sortByChannelPriority(entries)
+	return entries, nil
+}
+
+// channelEntriesThatProvide returns the same entries as
+// GetChannelEntriesThatProvide, or GetLatestChannelEntriesThatProvide if
+// latest is set, given the keys of the bundles that provide the API.
+func (pkgs packageIndex) channelEntriesThatProvide(keys []bundleKey, latest bool, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	var entries []*registry.ChannelEntry
+	for _, k := range keys {
+		ch, ok := pkgs[k.PackageName].Channels[k.ChannelName]
+		if !ok {
+			continue
+		}
+		b, ok := ch.Bundles[k.Name]
+		if !ok || (latest && ch.Head != b.Name) {
+			continue
+		}
+		entries = append(entries, pkgs.channelEntriesForBundle(b, !latest)...)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no channel entries found that provide group:%q version:%q kind:%q", group, version, kind)
+	}
+	if latest {
+		pkgs.sortByChannelPriority(entries)
+	}
 	return entries, nil
 }
 
@@ -166,13 +310,11 @@ func (pkgs packageIndex) GetBundleThatProvides(ctx context.Context, c Cache, gro
 		return nil, err
 	}
 
-	// It's possible for multiple packages to provide an API, but this function is forced to choose one.
-	// To do that deterministically, we'll pick the the bundle based on a lexicographical sort of its
-	// package name.
-	sort.Slice(latestEntries, func(i, j int) bool {
-		return latestEntries[i].PackageName < latestEntries[j].PackageName
-	})
-
+	// It's possible for multiple channels to provide an API, but this function is forced to choose one.
+	// Default channels, and channels that declare a priority, are candidates. To choose deterministically,
+	// we'll pick the candidate with the highest channel priority, preferring default channels and then
+	// a lexicographical sort of the package name on ties.
+	var candidates []*registry.ChannelEntry
 	for _, entry := range latestEntries {
 		pkg, ok := pkgs[entry.PackageName]
 		if !ok {
@@ -180,20 +322,81 @@ func (pkgs packageIndex) GetBundleThatProvides(ctx context.Context, c Cache, gro
 			// collected based on iterating over the packages in q.packageIndex.
 			continue
 		}
-		if entry.ChannelName == pkg.DefaultChannel {
-			return c.GetBundle(ctx, entry.PackageName, entry.ChannelName, entry.BundleName)
+		if entry.ChannelName == pkg.DefaultChannel || pkg.Channels[entry.ChannelName].Priority != 0 {
+			candidates = append(candidates, entry)
 		}
 	}
+	sort.Slice(candidates, func(i, j int) bool {
+		pi, pj := pkgs.channelPriority(candidates[i]), pkgs.channelPriority(candidates[j])
+		if pi != pj {
+			return pi > pj
+		}
+		di := candidates[i].ChannelName == pkgs[candidates[i].PackageName].DefaultChannel
+		dj := candidates[j].ChannelName == pkgs[candidates[j].PackageName].DefaultChannel
+		if di != dj {
+			return di
+		}
+		return candidates[i].PackageName < candidates[j].PackageName
+	})
+	if len(candidates) > 0 {
+		entry := candidates[0]
+		return c.GetBundle(ctx, entry.PackageName, entry.ChannelName, entry.BundleName)
+	}
 	return nil, fmt.Errorf("no entry found that provides group:%q version:%q kind:%q", group, version, kind)
 }
 
+func (pkgs packageIndex) channelPriority(entry *registry.ChannelEntry) int {
+	return pkgs[entry.PackageName].Channels[entry.ChannelName].Priority
+}
+
+// sortByChannelPriority sorts entries by the priority of their channels,
+// highest first, and then by package, channel, bundle and replaced bundle
+// name.
+func (pkgs packageIndex) sortByChannelPriority(entries []*registry.ChannelEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if pi, pj := pkgs.channelPriority(entries[i]), pkgs.channelPriority(entries[j]); pi != pj {
+			return pi > pj
+		}
+		if entries[i].PackageName != entries[j].PackageName {
+			return entries[i].PackageName < entries[j].PackageName
+		}
+		if entries[i].ChannelName != entries[j].ChannelName {
+			return entries[i].ChannelName < entries[j].ChannelName
+		}
+		if entries[i].BundleName != entries[j].BundleName {
+			return entries[i].BundleName < entries[j].BundleName
+		}
+		return entries[i].Replaces < entries[j].Replaces
+	})
+}
+
+// bundleCursorsAfter returns the cursors of the bundles in each of their
+// channels that follow after, or of all bundles if after is nil, in cursor
+// order.
+func (pkgs packageIndex) bundleCursorsAfter(after *registry.BundleCursor) []registry.BundleCursor {
+	var cursors []registry.BundleCursor
+	for _, pkg := range pkgs {
+		for _, ch := range pkg.Channels {
+			for _, b := range ch.Bundles {
+				c := registry.BundleCursor{PackageName: pkg.Name, ChannelName: ch.Name, CsvName: b.Name}
+				if after == nil || after.Less(c) {
+					cursors = append(cursors, c)
+				}
+			}
+		}
+	}
+	sort.Slice(cursors, func(i, j int) bool { return cursors[i].Less(cursors[j]) })
+	return cursors
+}
+
 type cPkg struct {
 	Name           string      `json:"name"`
 	Description    string      `json:"description"`
 	Icon           *model.Icon `json:"icon"`
 	DefaultChannel string      `json:"defaultChannel"`
 	Channels       map[string]cChannel
-	Deprecation    *model.Deprecation `json:"deprecation,omitempty"`
+	Deprecation    *model.Deprecation   `json:"deprecation,omitempty"`
+	Documentation  *model.Documentation `json:"documentation,omitempty"`
 }
 
 type cChannel struct {
@@ -201,14 +404,17 @@ type cChannel struct {
 	Head        string
 	Bundles     map[string]cBundle
 	Deprecation *model.Deprecation `json:"deprecation,omitempty"`
+	// Priority is the value of the channel's olm.channel.priority property.
+	Priority int `json:"priority,omitempty"`
 }
 
 type cBundle struct {
-	Package  string   `json:"package"`
-	Channel  string   `json:"channel"`
-	Name     string   `json:"name"`
-	Replaces string   `json:"replaces"`
-	Skips    []string `json:"skips"`
+	Package     string             `json:"package"`
+	Channel     string             `json:"channel"`
+	Name        string             `json:"name"`
+	Replaces    string             `json:"replaces"`
+	Skips       []string           `json:"skips"`
+	Deprecation *model.Deprecation `json:"deprecation,omitempty"`
 }
 
 func packagesFromModel(m model.Model) (map[string]cPkg, error) {
@@ -221,25 +427,34 @@ func packagesFromModel(m model.Model) (map[string]cPkg, error) {
 			DefaultChannel: p.DefaultChannel.Name,
 			Channels:       map[string]cChannel{},
 			Deprecation:    p.Deprecation,
+			Documentation:  p.Documentation,
 		}
 		for _, ch := range p.Channels {
 			head, err := ch.Head()
 			if err != nil {
 				return nil, err
 			}
+			props, err := property.Parse(ch.Properties)
+			if err != nil {
+				return nil, fmt.Errorf("parse properties for package %q channel %q: %v", p.Name, ch.Name, err)
+			}
 			newCh := cChannel{
 				Name:        ch.Name,
 				Head:        head.Name,
 				Bundles:     map[string]cBundle{},
 				Deprecation: ch.Deprecation,
 			}
+			if len(props.ChannelPriorities) > 0 {
+				newCh.Priority = props.ChannelPriorities[0].Priority
+			}
 			for _, b := range ch.Bundles {
 				newB := cBundle{
-					Package:  b.Package.Name,
-					Channel:  b.Channel.Name,
-					Name:     b.Name,
-					Replaces: b.Replaces,
-					Skips:    b.Skips,
+					Package:     b.Package.Name,
+					Channel:     b.Channel.Name,
+					Name:        b.Name,
+					Replaces:    b.Replaces,
+					Skips:       b.Skips,
+					Deprecation: b.Deprecation,
 				}
 				newCh.Bundles[b.Name] = newB
 			}
@@ -36,9 +36,10 @@ const (
 	pogrebV1CacheModeDir  = 0770
 	pogrebV1CacheModeFile = 0660
 
-	pograbV1CacheDir = FormatPogrebV1
-	pogrebDigestFile = pograbV1CacheDir + "/digest"
-	pogrebDbDir      = pograbV1CacheDir + "/db"
+	pograbV1CacheDir    = FormatPogrebV1
+	pogrebDigestFile    = pograbV1CacheDir + "/digest"
+	pogrebChecksumsFile = pograbV1CacheDir + "/checksums.json"
+	pogrebDbDir         = pograbV1CacheDir + "/db"
 )
 
 type pogrebV1Backend struct {
@@ -167,6 +168,14 @@ func (q *pogrebV1Backend) GetDigest(_ context.Context) (string, error) {
 	return readDigestFile(filepath.Join(q.baseDir, pogrebDigestFile))
 }
 
+func (q *pogrebV1Backend) GetChecksums(_ context.Context) (map[string]string, error) {
+	return readChecksumsFile(filepath.Join(q.baseDir, pogrebChecksumsFile))
+}
+
+func (q *pogrebV1Backend) PutChecksums(_ context.Context, checksums map[string]string) error {
+	return writeChecksumsFile(filepath.Join(q.baseDir, pogrebChecksumsFile), checksums, pogrebV1CacheModeFile)
+}
+
 func (q *pogrebV1Backend) orderedKeys() ([]string, error) {
 	it := q.db.Items()
 	keys := make([]string, 0, q.db.Count())
@@ -231,8 +240,11 @@ func (q *pogrebV1Backend) PutDigest(_ context.Context, digest string) error {
 	return writeDigestFile(filepath.Join(q.baseDir, pogrebDigestFile), digest, pogrebV1CacheModeFile)
 }
 
-func (q *pogrebV1Backend) SendBundles(_ context.Context, s registry.BundleSender) error {
+func (q *pogrebV1Backend) SendBundles(ctx context.Context, s registry.BundleSender) error {
 	return q.bundles.Walk(func(key bundleKey) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		bundleData, err := q.db.Get(q.dbKey(key))
 		if err != nil {
 			return fmt.Errorf("failed to get data for package %q, channel %q, key %q: %w", key.PackageName, key.ChannelName, key.Name, err)
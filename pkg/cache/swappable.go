@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+var _ Cache = &Swappable{}
+
+// Swappable is a Cache that serves from an underlying cache which can be
+// replaced while requests are being served. Each request is served entirely
+// by the cache that was current when it started, so that a swap never mixes
+// the contents of two caches in one response. A replaced cache is closed once
+// the requests it is serving have completed.
+type Swappable struct {
+	mu      sync.RWMutex
+	current *swappableRef
+	closers sync.WaitGroup
+}
+
+type swappableRef struct {
+	cache    Cache
+	inflight sync.WaitGroup
+}
+
+// NewSwappable returns a Swappable that initially serves from c.
+func NewSwappable(c Cache) *Swappable {
+	return &Swappable{current: &swappableRef{cache: c}}
+}
+
+// Swap replaces the cache that new requests are served from with c. The
+// replaced cache is closed in the background once its in-flight requests
+// have completed.
+func (s *Swappable) Swap(c Cache) {
+	s.mu.Lock()
+	old := s.current
+	s.current = &swappableRef{cache: c}
+	s.mu.Unlock()
+
+	s.closers.Add(1)
+	go func() {
+		defer s.closers.Done()
+		old.inflight.Wait()
+		_ = old.cache.Close()
+	}()
+}
+
+// acquire returns the current cache and registers a request against it. The
+// returned function must be called when the request completes.
+func (s *Swappable) acquire() (Cache, func()) {
+	s.mu.RLock()
+	ref := s.current
+	ref.inflight.Add(1)
+	s.mu.RUnlock()
+	return ref.cache, ref.inflight.Done
+}
+
+func (s *Swappable) ListPackages(ctx context.Context) ([]string, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.ListPackages(ctx)
+}
+
+func (s *Swappable) SendBundles(ctx context.Context, stream registry.BundleSender) error {
+	c, release := s.acquire()
+	defer release()
+	return c.SendBundles(ctx, stream)
+}
+
+func (s *Swappable) SendBundlesPage(ctx context.Context, stream registry.BundleSender, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.SendBundlesPage(ctx, stream, after, limit)
+}
+
+func (s *Swappable) SendFilteredBundles(ctx context.Context, stream registry.BundleSender, filter registry.BundleFilter, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.SendFilteredBundles(ctx, stream, filter, after, limit)
+}
+
+func (s *Swappable) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.ListBundles(ctx)
+}
+
+func (s *Swappable) GetPackage(ctx context.Context, name string) (*registry.PackageManifest, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetPackage(ctx, name)
+}
+
+func (s *Swappable) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetBundle(ctx, pkgName, channelName, csvName)
+}
+
+func (s *Swappable) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetBundleForChannel(ctx, pkgName, channelName)
+}
+
+func (s *Swappable) GetChannelEntriesThatReplace(ctx context.Context, name string) ([]*registry.ChannelEntry, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetChannelEntriesThatReplace(ctx, name)
+}
+
+func (s *Swappable) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetBundleThatReplaces(ctx, name, pkgName, channelName)
+}
+
+func (s *Swappable) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (s *Swappable) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (s *Swappable) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetBundleThatProvides(ctx, group, version, kind)
+}
+
+func (s *Swappable) GetPackageChecksums(ctx context.Context) (map[string]string, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetPackageChecksums(ctx)
+}
+
+func (s *Swappable) GetPackageDocumentation(ctx context.Context, pkgName string) (*api.PackageDocumentation, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetPackageDocumentation(ctx, pkgName)
+}
+
+func (s *Swappable) GetPackageMetadata(ctx context.Context, pkgName string) (*api.PackageMetadata, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetPackageMetadata(ctx, pkgName)
+}
+
+func (s *Swappable) GetBundleMetadata(ctx context.Context, pkgName, channelName, csvName string) (*api.BundleMetadata, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.GetBundleMetadata(ctx, pkgName, channelName, csvName)
+}
+
+func (s *Swappable) SendBundleMetadata(ctx context.Context, stream registry.BundleMetadataSender) error {
+	c, release := s.acquire()
+	defer release()
+	return c.SendBundleMetadata(ctx, stream)
+}
+
+func (s *Swappable) ListDeprecations(ctx context.Context, pkgName string) ([]*api.DeprecationEntry, error) {
+	c, release := s.acquire()
+	defer release()
+	return c.ListDeprecations(ctx, pkgName)
+}
+
+func (s *Swappable) CheckIntegrity(ctx context.Context, fbc fs.FS) error {
+	c, release := s.acquire()
+	defer release()
+	return c.CheckIntegrity(ctx, fbc)
+}
+
+func (s *Swappable) Build(ctx context.Context, fbc fs.FS) error {
+	c, release := s.acquire()
+	defer release()
+	return c.Build(ctx, fbc)
+}
+
+func (s *Swappable) Load(ctx context.Context) error {
+	c, release := s.acquire()
+	defer release()
+	return c.Load(ctx)
+}
+
+// Close waits for the caches replaced by Swap to be closed, and then closes
+// the current cache.
+func (s *Swappable) Close() error {
+	s.closers.Wait()
+	c, release := s.acquire()
+	release()
+	return c.Close()
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCache serves a fixed package list. ListPackages blocks until release
+// is closed, if it is set.
+type fakeCache struct {
+	Cache
+	packages []string
+	release  chan struct{}
+	started  chan struct{}
+	closed   atomic.Bool
+}
+
+func (c *fakeCache) ListPackages(_ context.Context) ([]string, error) {
+	if c.started != nil {
+		close(c.started)
+	}
+	if c.release != nil {
+		<-c.release
+	}
+	return c.packages, nil
+}
+
+func (c *fakeCache) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestSwappable(t *testing.T) {
+	ctx := context.Background()
+	oldCache := &fakeCache{packages: []string{"old"}, release: make(chan struct{}), started: make(chan struct{})}
+	newCache := &fakeCache{packages: []string{"new"}}
+	s := NewSwappable(oldCache)
+
+	// Start a request against the old cache and hold it in flight.
+	inflight := make(chan []string)
+	go func() {
+		pkgs, err := s.ListPackages(ctx)
+		require.NoError(t, err)
+		inflight <- pkgs
+	}()
+	<-oldCache.started
+
+	s.Swap(newCache)
+
+	// New requests are served by the new cache.
+	pkgs, err := s.ListPackages(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"new"}, pkgs)
+
+	// The old cache stays open until its in-flight request completes.
+	time.Sleep(10 * time.Millisecond)
+	require.False(t, oldCache.closed.Load())
+	close(oldCache.release)
+	require.Equal(t, []string{"old"}, <-inflight)
+	require.Eventually(t, oldCache.closed.Load, time.Second, time.Millisecond)
+
+	require.NoError(t, s.Close())
+	require.True(t, newCache.closed.Load())
+}
+
+func TestSwappable_CloseWaitsForReplacedCaches(t *testing.T) {
+	ctx := context.Background()
+	oldCache := &fakeCache{packages: []string{"old"}, release: make(chan struct{}), started: make(chan struct{})}
+	newCache := &fakeCache{packages: []string{"new"}}
+	s := NewSwappable(oldCache)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := s.ListPackages(ctx)
+		require.NoError(t, err)
+	}()
+	<-oldCache.started
+	s.Swap(newCache)
+
+	closed := make(chan error)
+	go func() { closed <- s.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a replaced cache had a request in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(oldCache.release)
+	<-done
+	require.NoError(t, <-closed)
+	require.True(t, oldCache.closed.Load())
+	require.True(t, newCache.closed.Load())
+}
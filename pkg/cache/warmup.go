@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// WarmupStats reports how much of a catalog was pre-loaded by Warmup.
+type WarmupStats struct {
+	Packages int
+	Bundles  int
+
+	// Complete is false if Warmup stopped before visiting every package.
+	Complete bool
+}
+
+// Warmup issues the queries a resolver typically makes right after a catalog
+// is (re)started: it lists the packages, then fetches every package and the
+// bundle at the head of its default channel. This moves the cold-path cost of
+// opening and decoding the underlying cache files out of the first requests.
+//
+// Warmup stops early, without an error, when ctx is done, so its duration can
+// be bounded with a context deadline. Query errors are returned immediately.
+func Warmup(ctx context.Context, q registry.GRPCQuery) (*WarmupStats, error) {
+	stats := &WarmupStats{}
+	pkgNames, err := q.ListPackages(ctx)
+	if err != nil {
+		return stats, warmupError(ctx, fmt.Errorf("list packages: %v", err))
+	}
+	for _, pkgName := range pkgNames {
+		if ctx.Err() != nil {
+			return stats, nil
+		}
+		pkg, err := q.GetPackage(ctx, pkgName)
+		if err != nil {
+			return stats, warmupError(ctx, fmt.Errorf("get package %q: %v", pkgName, err))
+		}
+		stats.Packages++
+
+		defaultChannel := pkg.GetDefaultChannel()
+		for _, ch := range pkg.Channels {
+			if ch.Name != defaultChannel {
+				continue
+			}
+			if _, err := q.GetBundle(ctx, pkgName, ch.Name, ch.CurrentCSVName); err != nil {
+				return stats, warmupError(ctx, fmt.Errorf("get bundle %q: %v", ch.CurrentCSVName, err))
+			}
+			stats.Bundles++
+		}
+	}
+	stats.Complete = true
+	return stats, nil
+}
+
+// warmupError returns err, unless ctx is done, in which case err is most
+// likely a consequence of the warmup budget running out.
+func warmupError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
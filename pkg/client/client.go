@@ -8,6 +8,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/operator-framework/operator-registry/pkg/api"
 )
@@ -86,10 +87,126 @@ func (c *Client) ListBundles(ctx context.Context) (*BundleIterator, error) {
 	return NewBundleIterator(stream), nil
 }
 
+// ListFilteredBundles lists the bundles of the named packages and channels,
+// or of all of them if none are named, that have a property that matches each
+// of properties. The server filters the bundles, so those that do not match
+// are not transferred.
+func (c *Client) ListFilteredBundles(ctx context.Context, packages, channels []string, properties ...*api.PropertySelector) (*BundleIterator, error) {
+	stream, err := c.Registry.ListBundles(ctx, &api.ListBundlesRequest{Packages: packages, Channels: channels, Properties: properties})
+	if err != nil {
+		return nil, err
+	}
+	return NewBundleIterator(stream), nil
+}
+
+// ListPackagesPage returns at most pageSize package names, in order, that
+// follow the page of pageToken, or that start the list if pageToken is
+// empty. It also returns the token of the next page, or an empty token if no
+// packages follow.
+func (c *Client) ListPackagesPage(ctx context.Context, pageSize int32, pageToken string) ([]string, string, error) {
+	var trailer metadata.MD
+	stream, err := c.Registry.ListPackages(ctx, &api.ListPackageRequest{PageSize: pageSize, PageToken: pageToken}, grpc.Trailer(&trailer))
+	if err != nil {
+		return nil, "", err
+	}
+	var names []string
+	for {
+		p, err := stream.Recv()
+		if err == io.EOF {
+			return names, nextPageToken(trailer), nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		names = append(names, p.GetName())
+	}
+}
+
+// ListBundlesPage returns a page of at most pageSize bundles that follow the
+// page of pageToken, or that start the list if pageToken is empty. It also
+// returns the token of the next page, or an empty token if no bundles
+// follow. A page may hold fewer than pageSize bundles even if more follow.
+func (c *Client) ListBundlesPage(ctx context.Context, pageSize int32, pageToken string) ([]*api.Bundle, string, error) {
+	var trailer metadata.MD
+	stream, err := c.Registry.ListBundles(ctx, &api.ListBundlesRequest{PageSize: pageSize, PageToken: pageToken}, grpc.Trailer(&trailer))
+	if err != nil {
+		return nil, "", err
+	}
+	var bundles []*api.Bundle
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			return bundles, nextPageToken(trailer), nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		bundles = append(bundles, b)
+	}
+}
+
+// nextPageToken returns the token of the next page from the trailer of a
+// paged list response.
+func nextPageToken(trailer metadata.MD) string {
+	if v := trailer.Get(api.NextPageTokenTrailer); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
 func (c *Client) GetPackage(ctx context.Context, packageName string) (*api.Package, error) {
 	return c.Registry.GetPackage(ctx, &api.GetPackageRequest{Name: packageName})
 }
 
+// GetPackageChecksums returns the content checksum of each package served by
+// the registry, keyed by package name. The checksums can be compared with the
+// output of declcfg.ComputeChecksums to verify the served catalog content.
+func (c *Client) GetPackageChecksums(ctx context.Context) (map[string]string, error) {
+	info, err := c.Registry.GetCatalogInfo(ctx, &api.GetCatalogInfoRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return info.GetPackageChecksums(), nil
+}
+
+// GetCatalogStatus returns the state of the catalog served by the registry,
+// and the digest of its content once it is loaded.
+func (c *Client) GetCatalogStatus(ctx context.Context) (*api.CatalogStatus, error) {
+	return c.Registry.GetCatalogStatus(ctx, &api.GetCatalogStatusRequest{})
+}
+
+// GetPackageDocumentation returns the documentation attached to a package.
+// Exactly one of the Content and Url fields of the result is set.
+func (c *Client) GetPackageDocumentation(ctx context.Context, packageName string) (*api.PackageDocumentation, error) {
+	return c.Registry.GetPackageDocumentation(ctx, &api.GetPackageDocumentationRequest{PkgName: packageName})
+}
+
+// GetPackageMetadata returns the metadata of a package and of its channels,
+// including the heads and deprecations of the channels.
+func (c *Client) GetPackageMetadata(ctx context.Context, packageName string) (*api.PackageMetadata, error) {
+	return c.Registry.GetPackageMetadata(ctx, &api.GetPackageMetadataRequest{PkgName: packageName})
+}
+
+// ListDeprecations returns the deprecations of a package, and of its channels
+// and bundles, or of all packages if packageName is empty.
+func (c *Client) ListDeprecations(ctx context.Context, packageName string) ([]*api.DeprecationEntry, error) {
+	stream, err := c.Registry.ListDeprecations(ctx, &api.ListDeprecationsRequest{PkgName: packageName})
+	if err != nil {
+		return nil, err
+	}
+	var entries []*api.DeprecationEntry
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
+
 func (c *Client) Close() error {
 	if c.Conn == nil {
 		return nil
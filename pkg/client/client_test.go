@@ -61,6 +61,34 @@ func (s *RegistryClientStub) ListBundles(ctx context.Context, in *api.ListBundle
 	return s.ListBundlesClient, s.Error
 }
 
+func (s *RegistryClientStub) GetCatalogInfo(ctx context.Context, in *api.GetCatalogInfoRequest, opts ...grpc.CallOption) (*api.CatalogInfo, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) GetPackageDocumentation(ctx context.Context, in *api.GetPackageDocumentationRequest, opts ...grpc.CallOption) (*api.PackageDocumentation, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) GetPackageMetadata(ctx context.Context, in *api.GetPackageMetadataRequest, opts ...grpc.CallOption) (*api.PackageMetadata, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) GetBundleMetadata(ctx context.Context, in *api.GetBundleMetadataRequest, opts ...grpc.CallOption) (*api.BundleMetadata, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) ListBundleMetadata(ctx context.Context, in *api.ListBundleMetadataRequest, opts ...grpc.CallOption) (api.Registry_ListBundleMetadataClient, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) ListDeprecations(ctx context.Context, in *api.ListDeprecationsRequest, opts ...grpc.CallOption) (api.Registry_ListDeprecationsClient, error) {
+	return nil, nil
+}
+
+func (s *RegistryClientStub) GetCatalogStatus(ctx context.Context, in *api.GetCatalogStatusRequest, opts ...grpc.CallOption) (*api.CatalogStatus, error) {
+	return nil, nil
+}
+
 func (s *RegistryClientStub) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest, opts ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
 	return nil, nil
 }
@@ -0,0 +1,264 @@
+// Package client is a client for the registry gRPC API.
+//
+// Unlike the stubs generated from the API definition, a Client retries
+// requests that fail because the registry is temporarily unavailable, can
+// wait for the registry to report that it is serving before sending
+// requests, and returns errors that can be checked with errors.Is against
+// ErrNotFound, ErrUnavailable and the other errors of this package.
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// HealthService is the service name that the registry reports its health
+// for.
+const HealthService = "Registry"
+
+// DefaultBackoff is the backoff between attempts of a request, unless
+// WithBackoff is used. A request is attempted at most Steps times.
+var DefaultBackoff = wait.Backoff{
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      5 * time.Second,
+}
+
+type options struct {
+	dialOptions  []grpc.DialOption
+	backoff      wait.Backoff
+	healthGating bool
+}
+
+type Option func(*options)
+
+// WithDialOptions adds options for the connection that New creates. By
+// default, the connection does not use transport security.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// WithBackoff sets the backoff between attempts of a request, and the number
+// of attempts as its Steps. A Steps of 1 or less disables retries.
+func WithBackoff(backoff wait.Backoff) Option {
+	return func(o *options) {
+		o.backoff = backoff
+	}
+}
+
+// WithHealthGating makes requests wait until the registry's health check
+// reports that it is serving. Once it has, requests are sent right away
+// until a request fails because the registry is unavailable.
+func WithHealthGating() Option {
+	return func(o *options) {
+		o.healthGating = true
+	}
+}
+
+// Client is a client for the registry gRPC API. It is safe for concurrent
+// use.
+type Client struct {
+	conn     *grpc.ClientConn
+	ownsConn bool
+	registry api.RegistryClient
+	health   grpc_health_v1.HealthClient
+	opts     options
+
+	// serving is set when the registry is known to be serving, so that
+	// health gated requests do not need to check it.
+	serving atomic.Bool
+}
+
+// New returns a client for the registry at target, which is a gRPC target
+// such as "localhost:50051" or "dns:///registry.example.com:50051". The
+// connection is established lazily, on the first request, and is closed by
+// Close.
+func New(target string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, o.dialOptions...)
+	conn, err := grpc.NewClient(target, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(conn, o)
+	c.ownsConn = true
+	return c, nil
+}
+
+// NewFromConn returns a client that sends requests on conn. Close does not
+// close conn, and WithDialOptions is ignored.
+func NewFromConn(conn *grpc.ClientConn, opts ...Option) *Client {
+	return newClient(conn, newOptions(opts))
+}
+
+func newOptions(opts []Option) options {
+	o := options{backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func newClient(conn *grpc.ClientConn, o options) *Client {
+	return &Client{
+		conn:     conn,
+		registry: api.NewRegistryClient(conn),
+		health:   grpc_health_v1.NewHealthClient(conn),
+		opts:     o,
+	}
+}
+
+// Close closes the connection of a client created by New.
+func (c *Client) Close() error {
+	if !c.ownsConn {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Healthy reports whether the registry's health check reports that it is
+// serving. The health check is not retried.
+func (c *Client) Healthy(ctx context.Context) (bool, error) {
+	res, err := c.health.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: HealthService})
+	if err != nil {
+		return false, newError("Healthy", err)
+	}
+	return res.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING, nil
+}
+
+// WaitForServing checks the registry's health with backoff until it reports
+// that it is serving. If ctx is done first, it returns an error that matches
+// ErrNotServing.
+func (c *Client) WaitForServing(ctx context.Context) error {
+	if c.serving.Load() {
+		return nil
+	}
+	backoff := c.opts.backoff
+	for {
+		res, err := c.health.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: HealthService})
+		if err == nil && res.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING {
+			c.serving.Store(true)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return &Error{Op: "WaitForServing", Code: codes.Unavailable, Err: errors.Join(ErrNotServing, ctx.Err())}
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+// do calls f, retrying it with backoff while it fails with a retryable
+// error. f is attempted at most Steps times of the client's backoff.
+func (c *Client) do(ctx context.Context, op string, f func(ctx context.Context) error) error {
+	if c.opts.healthGating {
+		if err := c.WaitForServing(ctx); err != nil {
+			return err
+		}
+	}
+
+	backoff, attempts := c.opts.backoff, c.opts.backoff.Steps
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if !retryable(err) {
+			return newError(op, err)
+		}
+		if status.Code(err) == codes.Unavailable {
+			c.serving.Store(false)
+		}
+		if attempt >= attempts {
+			return newError(op, err)
+		}
+		select {
+		case <-ctx.Done():
+			return newError(op, err)
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+func (c *Client) GetPackage(ctx context.Context, packageName string) (*api.Package, error) {
+	var out *api.Package
+	err := c.do(ctx, "GetPackage", func(ctx context.Context) (err error) {
+		out, err = c.registry.GetPackage(ctx, &api.GetPackageRequest{Name: packageName})
+		return err
+	})
+	return out, err
+}
+
+func (c *Client) GetBundle(ctx context.Context, packageName, channelName, csvName string) (*api.Bundle, error) {
+	var out *api.Bundle
+	err := c.do(ctx, "GetBundle", func(ctx context.Context) (err error) {
+		out, err = c.registry.GetBundle(ctx, &api.GetBundleRequest{PkgName: packageName, ChannelName: channelName, CsvName: csvName})
+		return err
+	})
+	return out, err
+}
+
+// GetBundleForChannel returns the head bundle of a channel.
+func (c *Client) GetBundleForChannel(ctx context.Context, packageName, channelName string) (*api.Bundle, error) {
+	var out *api.Bundle
+	err := c.do(ctx, "GetBundleForChannel", func(ctx context.Context) (err error) {
+		out, err = c.registry.GetBundleForChannel(ctx, &api.GetBundleInChannelRequest{PkgName: packageName, ChannelName: channelName})
+		return err
+	})
+	return out, err
+}
+
+// GetBundleThatReplaces returns the bundle that replaces csvName in a channel.
+func (c *Client) GetBundleThatReplaces(ctx context.Context, csvName, packageName, channelName string) (*api.Bundle, error) {
+	var out *api.Bundle
+	err := c.do(ctx, "GetBundleThatReplaces", func(ctx context.Context) (err error) {
+		out, err = c.registry.GetBundleThatReplaces(ctx, &api.GetReplacementRequest{CsvName: csvName, PkgName: packageName, ChannelName: channelName})
+		return err
+	})
+	return out, err
+}
+
+// GetDefaultBundleThatProvides returns the head bundle of the default
+// channel of a package that provides an API.
+func (c *Client) GetDefaultBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	var out *api.Bundle
+	err := c.do(ctx, "GetDefaultBundleThatProvides", func(ctx context.Context) (err error) {
+		out, err = c.registry.GetDefaultBundleThatProvides(ctx, &api.GetDefaultProviderRequest{Group: group, Version: version, Kind: kind})
+		return err
+	})
+	return out, err
+}
+
+// GetPackageChecksums returns the content checksum of each package served by
+// the registry, keyed by package name.
+func (c *Client) GetPackageChecksums(ctx context.Context) (map[string]string, error) {
+	var out *api.CatalogInfo
+	err := c.do(ctx, "GetPackageChecksums", func(ctx context.Context) (err error) {
+		out, err = c.registry.GetCatalogInfo(ctx, &api.GetCatalogInfoRequest{})
+		return err
+	})
+	return out.GetPackageChecksums(), err
+}
@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// fakeRegistry serves a fixed set of bundles, and fails the first requests
+// of each method with the errors in failures.
+type fakeRegistry struct {
+	api.UnimplementedRegistryServer
+	grpc_health_v1.UnimplementedHealthServer
+
+	mu       sync.Mutex
+	bundles  []*api.Bundle
+	failures map[string][]error
+	calls    map[string]int
+	serving  bool
+}
+
+func (r *fakeRegistry) call(method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[method]++
+	if errs := r.failures[method]; len(errs) > 0 {
+		r.failures[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+func (r *fakeRegistry) count(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[method]
+}
+
+func (r *fakeRegistry) setServing(serving bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.serving = serving
+}
+
+func (r *fakeRegistry) GetPackage(_ context.Context, req *api.GetPackageRequest) (*api.Package, error) {
+	if err := r.call("GetPackage"); err != nil {
+		return nil, err
+	}
+	if req.GetName() != "foo" {
+		return nil, status.Errorf(codes.NotFound, "package %q not found", req.GetName())
+	}
+	return &api.Package{Name: "foo"}, nil
+}
+
+func (r *fakeRegistry) ListBundles(_ *api.ListBundlesRequest, stream api.Registry_ListBundlesServer) error {
+	if err := r.call("ListBundles"); err != nil {
+		return err
+	}
+	for _, b := range r.bundles {
+		if err := stream.Send(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRegistry) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls["Check"]++
+	if !r.serving {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+var testBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+func newTestClient(t *testing.T, registry *fakeRegistry, opts ...Option) *Client {
+	t.Helper()
+	if registry.calls == nil {
+		registry.calls = map[string]int{}
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	api.RegisterRegistryServer(s, registry)
+	grpc_health_v1.RegisterHealthServer(s, registry)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	c, err := New("passthrough:///bufnet", append([]Option{
+		WithBackoff(testBackoff),
+		WithDialOptions(
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		),
+	}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, c.Close()) })
+	return c
+}
+
+func TestClientRetries(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	type spec struct {
+		name          string
+		failures      []error
+		expectCalls   int
+		expectErr     error
+		expectErrCode codes.Code
+	}
+	specs := []spec{
+		{
+			name:        "Success",
+			expectCalls: 1,
+		},
+		{
+			name:        "RecoversFromUnavailable",
+			failures:    []error{unavailable, unavailable},
+			expectCalls: 3,
+		},
+		{
+			name:          "GivesUpAfterSteps",
+			failures:      []error{unavailable, unavailable, unavailable},
+			expectCalls:   3,
+			expectErr:     ErrUnavailable,
+			expectErrCode: codes.Unavailable,
+		},
+		{
+			name:          "DoesNotRetryNotFound",
+			failures:      []error{status.Error(codes.NotFound, "package not found")},
+			expectCalls:   1,
+			expectErr:     ErrNotFound,
+			expectErrCode: codes.NotFound,
+		},
+		{
+			name:          "DoesNotRetryPermissionDenied",
+			failures:      []error{status.Error(codes.PermissionDenied, "tenant may not see package")},
+			expectCalls:   1,
+			expectErr:     ErrUnauthorized,
+			expectErrCode: codes.PermissionDenied,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			registry := &fakeRegistry{failures: map[string][]error{"GetPackage": s.failures}}
+			c := newTestClient(t, registry)
+
+			pkg, err := c.GetPackage(context.Background(), "foo")
+			require.Equal(t, s.expectCalls, registry.count("GetPackage"))
+			if s.expectErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, "foo", pkg.GetName())
+				return
+			}
+			require.ErrorIs(t, err, s.expectErr)
+			require.Equal(t, s.expectErrCode, status.Code(err))
+
+			var clientErr *Error
+			require.ErrorAs(t, err, &clientErr)
+			require.Equal(t, "GetPackage", clientErr.Op)
+		})
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	c := newTestClient(t, &fakeRegistry{})
+
+	_, err := c.GetPackage(context.Background(), "bar")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NotErrorIs(t, err, ErrUnavailable)
+	require.EqualError(t, err, `GetPackage: rpc error: code = NotFound desc = package "bar" not found`)
+}
+
+func TestClientListBundlePages(t *testing.T) {
+	var bundles []*api.Bundle
+	for i := 0; i < 5; i++ {
+		bundles = append(bundles, &api.Bundle{CsvName: fmt.Sprintf("foo.v0.%d.0", i)})
+	}
+
+	t.Run("Pages", func(t *testing.T) {
+		c := newTestClient(t, &fakeRegistry{bundles: bundles})
+		var sizes []int
+		var names []string
+		require.NoError(t, c.ListBundlePages(context.Background(), 2, func(page []*api.Bundle) error {
+			sizes = append(sizes, len(page))
+			for _, b := range page {
+				names = append(names, b.GetCsvName())
+			}
+			return nil
+		}))
+		require.Equal(t, []int{2, 2, 1}, sizes)
+		require.Equal(t, []string{"foo.v0.0.0", "foo.v0.1.0", "foo.v0.2.0", "foo.v0.3.0", "foo.v0.4.0"}, names)
+	})
+
+	t.Run("StopsOnError", func(t *testing.T) {
+		c := newTestClient(t, &fakeRegistry{bundles: bundles})
+		stop := errors.New("stop")
+		pages := 0
+		err := c.ListBundlePages(context.Background(), 2, func([]*api.Bundle) error {
+			pages++
+			return stop
+		})
+		require.Equal(t, stop, err)
+		require.Equal(t, 1, pages)
+	})
+
+	t.Run("RetriesBeforeFirstBundle", func(t *testing.T) {
+		registry := &fakeRegistry{
+			bundles:  bundles,
+			failures: map[string][]error{"ListBundles": {status.Error(codes.Unavailable, "connection refused")}},
+		}
+		c := newTestClient(t, registry)
+		actual, err := c.ListBundles(context.Background())
+		require.NoError(t, err)
+		require.Len(t, actual, len(bundles))
+		require.Equal(t, 2, registry.count("ListBundles"))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		c := newTestClient(t, &fakeRegistry{})
+		actual, err := c.ListBundles(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, actual)
+	})
+}
+
+func TestClientHealthGating(t *testing.T) {
+	t.Run("WaitsForServing", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		c := newTestClient(t, registry, WithHealthGating())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			registry.setServing(true)
+		}()
+
+		_, err := c.GetPackage(context.Background(), "foo")
+		require.NoError(t, err)
+		checks := registry.count("Check")
+		require.Greater(t, checks, 1)
+
+		// The registry is known to be serving, so it is not checked again.
+		_, err = c.GetPackage(context.Background(), "foo")
+		require.NoError(t, err)
+		require.Equal(t, checks, registry.count("Check"))
+	})
+
+	t.Run("NotServing", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		c := newTestClient(t, registry, WithHealthGating())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := c.GetPackage(ctx, "foo")
+		require.ErrorIs(t, err, ErrNotServing)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Zero(t, registry.count("GetPackage"))
+	})
+
+	t.Run("Healthy", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		c := newTestClient(t, registry)
+
+		healthy, err := c.Healthy(context.Background())
+		require.NoError(t, err)
+		require.False(t, healthy)
+
+		registry.setServing(true)
+		healthy, err = c.Healthy(context.Background())
+		require.NoError(t, err)
+		require.True(t, healthy)
+	})
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrNotFound matches errors for requests of packages, channels or
+	// bundles that the registry does not serve.
+	ErrNotFound = errors.New("not found")
+	// ErrUnavailable matches errors for requests that failed because the
+	// registry could not be reached, after all retries.
+	ErrUnavailable = errors.New("registry unavailable")
+	// ErrNotServing matches errors for requests that were not sent because
+	// the registry did not report that it is serving in time.
+	ErrNotServing = errors.New("registry not serving")
+	// ErrUnimplemented matches errors for requests that the registry does
+	// not implement, e.g. because it is an older version.
+	ErrUnimplemented = errors.New("not implemented by registry")
+	// ErrUnauthorized matches errors for requests that the registry refused
+	// to serve to the caller.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Error is the error returned by the requests of a Client. Use errors.Is to
+// check it against the errors of this package, or status.Code to get its
+// gRPC status code.
+type Error struct {
+	// Op is the name of the request, e.g. "GetBundle".
+	Op   string
+	Code codes.Code
+	Err  error
+}
+
+func newError(op string, err error) *Error {
+	return &Error{Op: op, Code: status.Code(err), Err: err}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e matches target, one of the errors of this package.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == codes.NotFound
+	case ErrUnavailable:
+		return e.Code == codes.Unavailable
+	case ErrUnimplemented:
+		return e.Code == codes.Unimplemented
+	case ErrUnauthorized:
+		return e.Code == codes.Unauthenticated || e.Code == codes.PermissionDenied
+	}
+	return false
+}
+
+// GRPCStatus returns the gRPC status of e, so that status.Code and
+// status.FromError work on errors returned by a Client.
+func (e *Error) GRPCStatus() *status.Status {
+	if s, ok := status.FromError(e.Err); ok {
+		return s
+	}
+	return status.New(e.Code, e.Err.Error())
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// DefaultPageSize is the number of bundles per page of ListBundlePages if
+// the page size is not positive.
+const DefaultPageSize = 100
+
+type receiver[T any] interface {
+	Recv() (T, error)
+}
+
+// receive opens a stream with open and calls fn with pages of at most
+// pageSize of its messages. Opening the stream is retried like other
+// requests, and so is receiving its first message, but a stream that fails
+// after a message was received is not, since the registry cannot resume it.
+func receive[T any](ctx context.Context, c *Client, op string, pageSize int, open func(ctx context.Context) (receiver[T], error), fn func(page []T) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		stream receiver[T]
+		first  T
+		done   bool
+	)
+	err := c.do(ctx, op, func(ctx context.Context) error {
+		s, err := open(ctx)
+		if err != nil {
+			return err
+		}
+		first, err = s.Recv()
+		if errors.Is(err, io.EOF) {
+			done = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		stream = s
+		return nil
+	})
+	if err != nil || done {
+		return err
+	}
+
+	page := make([]T, 0, pageSize)
+	page = append(page, first)
+	for {
+		if len(page) == pageSize {
+			if err := fn(page); err != nil {
+				return err
+			}
+			page = make([]T, 0, pageSize)
+		}
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return newError(op, err)
+		}
+		page = append(page, msg)
+	}
+	if len(page) > 0 {
+		return fn(page)
+	}
+	return nil
+}
+
+// receiveAll returns all messages of the stream opened by open.
+func receiveAll[T any](ctx context.Context, c *Client, op string, open func(ctx context.Context) (receiver[T], error)) ([]T, error) {
+	var out []T
+	err := receive(ctx, c, op, DefaultPageSize, open, func(page []T) error {
+		out = append(out, page...)
+		return nil
+	})
+	return out, err
+}
+
+// ListBundlePages calls fn with consecutive pages of at most pageSize of the
+// bundles served by the registry, so that callers can process large catalogs
+// without holding all of their bundles in memory. If fn returns an error,
+// listing stops and the error is returned.
+//
+// The pages are received on one stream, so fn should return quickly; a
+// stream that fails after its first page is not retried.
+func (c *Client) ListBundlePages(ctx context.Context, pageSize int, fn func(page []*api.Bundle) error) error {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return receive(ctx, c, "ListBundles", pageSize, func(ctx context.Context) (receiver[*api.Bundle], error) {
+		return c.registry.ListBundles(ctx, &api.ListBundlesRequest{})
+	}, fn)
+}
+
+// ListBundles returns all bundles served by the registry.
+func (c *Client) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	return receiveAll(ctx, c, "ListBundles", func(ctx context.Context) (receiver[*api.Bundle], error) {
+		return c.registry.ListBundles(ctx, &api.ListBundlesRequest{})
+	})
+}
+
+// ListPackages returns the names of the packages served by the registry.
+func (c *Client) ListPackages(ctx context.Context) ([]string, error) {
+	names, err := receiveAll(ctx, c, "ListPackages", func(ctx context.Context) (receiver[*api.PackageName], error) {
+		return c.registry.ListPackages(ctx, &api.ListPackageRequest{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		out = append(out, n.GetName())
+	}
+	return out, nil
+}
+
+// GetChannelEntriesThatProvide returns the channel entries of the bundles
+// that provide an API.
+func (c *Client) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*api.ChannelEntry, error) {
+	return receiveAll(ctx, c, "GetChannelEntriesThatProvide", func(ctx context.Context) (receiver[*api.ChannelEntry], error) {
+		return c.registry.GetChannelEntriesThatProvide(ctx, &api.GetAllProvidersRequest{Group: group, Version: version, Kind: kind})
+	})
+}
+
+// GetLatestChannelEntriesThatProvide returns the channel entries of the
+// latest bundle of each channel that provides an API.
+func (c *Client) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*api.ChannelEntry, error) {
+	return receiveAll(ctx, c, "GetLatestChannelEntriesThatProvide", func(ctx context.Context) (receiver[*api.ChannelEntry], error) {
+		return c.registry.GetLatestChannelEntriesThatProvide(ctx, &api.GetLatestProvidersRequest{Group: group, Version: version, Kind: kind})
+	})
+}
+
+// GetChannelEntriesThatReplace returns the channel entries of the bundles
+// that replace csvName.
+func (c *Client) GetChannelEntriesThatReplace(ctx context.Context, csvName string) ([]*api.ChannelEntry, error) {
+	return receiveAll(ctx, c, "GetChannelEntriesThatReplace", func(ctx context.Context) (receiver[*api.ChannelEntry], error) {
+		return c.registry.GetChannelEntriesThatReplace(ctx, &api.GetAllReplacementsRequest{CsvName: csvName})
+	})
+}
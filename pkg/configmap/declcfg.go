@@ -0,0 +1,149 @@
+package configmap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DeclarativeConfigChunkSeparator separates the name of a declarative config
+// file from the index of one of its chunks in a ConfigMap key, for files that
+// are too large to be stored in one key, or one ConfigMap. For example, the
+// keys "catalog.json.chunk-0" and "catalog.json.chunk-1" hold the first and
+// second chunks of "catalog.json". The chunks of a file may be spread across
+// ConfigMaps, and must be numbered from 0 without gaps.
+const DeclarativeConfigChunkSeparator = ".chunk-"
+
+type fileChunk struct {
+	index  int
+	source string
+	data   []byte
+}
+
+// DeclarativeConfigFiles returns the declarative config files stored in the
+// data of configMaps, keyed by file name. Each key of a ConfigMap whose name
+// has a ".json", ".yaml" or ".yml" extension, after removing any chunk
+// suffix, is a file or a chunk of a file; other keys are ignored. Binary data
+// is read if the ConfigMap has the gzip encoding annotation.
+//
+// A file must be stored whole in exactly one ConfigMap, or as chunks, which
+// are concatenated in the order of their indices.
+func DeclarativeConfigFiles(configMaps []corev1.ConfigMap) (map[string][]byte, error) {
+	sorted := make([]corev1.ConfigMap, len(configMaps))
+	copy(sorted, configMaps)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+
+	var (
+		whole   = map[string]string{}
+		files   = map[string][]byte{}
+		chunked = map[string][]fileChunk{}
+	)
+	for i := range sorted {
+		cm := &sorted[i]
+		data := cm.Data
+		if hasGzipEncodingAnnotation(cm) {
+			var err error
+			data, err = decodeGzipBinaryData(cm)
+			if err != nil {
+				return nil, fmt.Errorf("configmap %s/%s: %v", cm.GetNamespace(), cm.GetName(), err)
+			}
+		}
+
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			source := fmt.Sprintf("%s/%s[%s]", cm.GetNamespace(), cm.GetName(), key)
+			name, index, isChunk, err := parseDeclarativeConfigKey(key)
+			if !isDeclarativeConfigFile(name) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("configmap key %s: %v", source, err)
+			}
+			if !isChunk {
+				if prev, ok := whole[name]; ok {
+					return nil, fmt.Errorf("declarative config file %q is stored in both %s and %s", name, prev, source)
+				}
+				whole[name] = source
+				files[name] = []byte(data[key])
+				continue
+			}
+			chunked[name] = append(chunked[name], fileChunk{index: index, source: source, data: []byte(data[key])})
+		}
+	}
+
+	for name, chunks := range chunked {
+		if prev, ok := whole[name]; ok {
+			return nil, fmt.Errorf("declarative config file %q is stored both whole in %s and as chunks", name, prev)
+		}
+		sort.Slice(chunks, func(i, j int) bool {
+			return chunks[i].index < chunks[j].index
+		})
+		var buf bytes.Buffer
+		for i, c := range chunks {
+			if c.index != i {
+				if c.index < i {
+					return nil, fmt.Errorf("declarative config file %q has chunk %d in both %s and %s", name, c.index, chunks[i-1].source, c.source)
+				}
+				return nil, fmt.Errorf("declarative config file %q is missing chunk %d", name, i)
+			}
+			buf.Write(c.data)
+		}
+		files[name] = buf.Bytes()
+	}
+	return files, nil
+}
+
+// WriteDeclarativeConfigDir writes the declarative config files stored in the
+// data of configMaps to dir, so that they can be loaded like any other
+// declarative config directory.
+func WriteDeclarativeConfigDir(configMaps []corev1.ConfigMap, dir string) error {
+	files, err := DeclarativeConfigFiles(configMaps)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no declarative config files found in %d configmap(s)", len(configMaps))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseDeclarativeConfigKey(key string) (name string, index int, isChunk bool, err error) {
+	i := strings.LastIndex(key, DeclarativeConfigChunkSeparator)
+	if i < 0 {
+		return key, 0, false, nil
+	}
+	index, err = strconv.Atoi(key[i+len(DeclarativeConfigChunkSeparator):])
+	if err != nil || index < 0 {
+		return key[:i], 0, true, fmt.Errorf("invalid chunk index in key %q", key)
+	}
+	return key[:i], index, true, nil
+}
+
+func isDeclarativeConfigFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package configmap
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/lib/encoding"
+)
+
+const (
+	fooPackage = `{"schema":"olm.package","name":"foo","defaultChannel":"stable"}`
+	fooChannel = `{"schema":"olm.channel","package":"foo","name":"stable","entries":[{"name":"foo.v0.1.0"}]}`
+	fooBundle  = `{"schema":"olm.bundle","package":"foo","name":"foo.v0.1.0","image":"foo-bundle:v0.1.0","properties":[{"type":"olm.package","value":{"packageName":"foo","version":"0.1.0"}}]}`
+)
+
+func declcfgConfigMap(name string, data map[string]string) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: configMapNamespace},
+		Data:       data,
+	}
+}
+
+func TestDeclarativeConfigFiles(t *testing.T) {
+	catalog := fooPackage + "\n" + fooChannel + "\n" + fooBundle + "\n"
+
+	gzipped, err := encoding.GzipBase64Encode([]byte(catalog))
+	require.NoError(t, err)
+	gzipConfigMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "catalog",
+			Namespace:   configMapNamespace,
+			Annotations: map[string]string{ConfigMapEncodingAnnotationKey: ConfigMapEncodingAnnotationGzip},
+		},
+		BinaryData: map[string][]byte{"catalog.json": gzipped},
+	}
+
+	type spec struct {
+		name        string
+		configMaps  []corev1.ConfigMap
+		expected    map[string]string
+		expectedErr string
+	}
+	specs := []spec{
+		{
+			name: "Whole",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog", map[string]string{"catalog.json": catalog, "README.md": "not a declarative config"}),
+			},
+			expected: map[string]string{"catalog.json": catalog},
+		},
+		{
+			name: "AcrossConfigMaps",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog-2", map[string]string{"bundles.yaml": fooBundle}),
+				declcfgConfigMap("catalog-1", map[string]string{"package.json": fooPackage, "channels.yml": fooChannel}),
+			},
+			expected: map[string]string{"package.json": fooPackage, "channels.yml": fooChannel, "bundles.yaml": fooBundle},
+		},
+		{
+			name: "Chunks",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog-b", map[string]string{"catalog.json.chunk-10": "10", "catalog.json.chunk-2": "2"}),
+				declcfgConfigMap("catalog-a", map[string]string{"catalog.json.chunk-0": "0", "catalog.json.chunk-1": "1"}),
+				declcfgConfigMap("catalog-c", map[string]string{
+					"catalog.json.chunk-3": "3", "catalog.json.chunk-4": "4", "catalog.json.chunk-5": "5",
+					"catalog.json.chunk-6": "6", "catalog.json.chunk-7": "7", "catalog.json.chunk-8": "8", "catalog.json.chunk-9": "9",
+				}),
+			},
+			expected: map[string]string{"catalog.json": "012345678910"},
+		},
+		{
+			name:       "Gzip",
+			configMaps: []corev1.ConfigMap{gzipConfigMap},
+			expected:   map[string]string{"catalog.json": catalog},
+		},
+		{
+			name: "DuplicateFile",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog-1", map[string]string{"catalog.json": catalog}),
+				declcfgConfigMap("catalog-2", map[string]string{"catalog.json": catalog}),
+			},
+			expectedErr: `declarative config file "catalog.json" is stored in both test-namespace/catalog-1[catalog.json] and test-namespace/catalog-2[catalog.json]`,
+		},
+		{
+			name: "WholeAndChunks",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog", map[string]string{"catalog.json": catalog, "catalog.json.chunk-0": catalog}),
+			},
+			expectedErr: `declarative config file "catalog.json" is stored both whole in test-namespace/catalog[catalog.json] and as chunks`,
+		},
+		{
+			name: "MissingChunk",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog", map[string]string{"catalog.json.chunk-0": "0", "catalog.json.chunk-2": "2"}),
+			},
+			expectedErr: `declarative config file "catalog.json" is missing chunk 1`,
+		},
+		{
+			name: "DuplicateChunk",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog-1", map[string]string{"catalog.json.chunk-0": "0"}),
+				declcfgConfigMap("catalog-2", map[string]string{"catalog.json.chunk-0": "0"}),
+			},
+			expectedErr: `declarative config file "catalog.json" has chunk 0 in both test-namespace/catalog-1[catalog.json.chunk-0] and test-namespace/catalog-2[catalog.json.chunk-0]`,
+		},
+		{
+			name: "InvalidChunkIndex",
+			configMaps: []corev1.ConfigMap{
+				declcfgConfigMap("catalog", map[string]string{"catalog.json.chunk-a": "0", "notes.txt.chunk-a": "ignored"}),
+			},
+			expectedErr: `configmap key test-namespace/catalog[catalog.json.chunk-a]: invalid chunk index in key "catalog.json.chunk-a"`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			files, err := DeclarativeConfigFiles(s.configMaps)
+			if s.expectedErr != "" {
+				require.EqualError(t, err, s.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			actual := map[string]string{}
+			for name, data := range files {
+				actual[name] = string(data)
+			}
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
+
+func TestWriteDeclarativeConfigDir(t *testing.T) {
+	catalog := fooPackage + "\n" + fooChannel + "\n" + fooBundle + "\n"
+	half := len(catalog) / 2
+	configMaps := []corev1.ConfigMap{
+		declcfgConfigMap("catalog-1", map[string]string{"catalog.json.chunk-0": catalog[:half]}),
+		declcfgConfigMap("catalog-2", map[string]string{"catalog.json.chunk-1": catalog[half:]}),
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, WriteDeclarativeConfigDir(configMaps, dir))
+
+	cfg, err := declcfg.LoadFS(context.Background(), os.DirFS(dir))
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	require.Len(t, cfg.Channels, 1)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, "foo.v0.1.0", cfg.Bundles[0].Name)
+
+	require.EqualError(t, WriteDeclarativeConfigDir([]corev1.ConfigMap{declcfgConfigMap("empty", nil)}, t.TempDir()), "no declarative config files found in 1 configmap(s)")
+}
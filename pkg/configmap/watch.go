@@ -0,0 +1,159 @@
+package configmap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Watcher watches the ConfigMaps of a namespace that hold a declarative
+// config, i.e. those that are named explicitly or whose labels match a
+// selector, with an informer, so that a catalog can be aggregated from
+// ConfigMaps that are contributed separately, and be updated when they
+// change.
+type Watcher struct {
+	namespace string
+	names     sets.Set[string]
+	selector  labels.Selector
+
+	factory informers.SharedInformerFactory
+	lister  listersv1.ConfigMapNamespaceLister
+	synced  cache.InformerSynced
+	changed chan struct{}
+	stop    context.CancelFunc
+}
+
+// NewWatcher returns a Watcher of the ConfigMaps of namespace that are named
+// by names or whose labels match selector. At least one of them must be set.
+func NewWatcher(client kubernetes.Interface, namespace string, names []string, selector string) (*Watcher, error) {
+	if len(names) == 0 && selector == "" {
+		return nil, fmt.Errorf("at least one configmap name or a label selector must be set")
+	}
+	w := &Watcher{
+		namespace: namespace,
+		names:     sets.New[string](names...),
+		selector:  labels.Nothing(),
+		changed:   make(chan struct{}, 1),
+		stop:      func() {},
+	}
+	if selector != "" {
+		var err error
+		if w.selector, err = labels.Parse(selector); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %v", selector, err)
+		}
+	}
+
+	opts := []informers.SharedInformerOption{informers.WithNamespace(namespace)}
+	if len(names) == 0 {
+		// Only the ConfigMaps that match the selector need to be listed and
+		// watched; named ConfigMaps are filtered from all of the namespace.
+		opts = append(opts, informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = selector
+		}))
+	}
+	w.factory = informers.NewSharedInformerFactoryWithOptions(client, 0, opts...)
+	informer := w.factory.Core().V1().ConfigMaps()
+	w.lister = informer.Lister().ConfigMaps(namespace)
+	w.synced = informer.Informer().HasSynced
+	if _, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.notify,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// A ConfigMap whose labels no longer match the selector is
+			// removed from the catalog.
+			w.notify(oldObj)
+			w.notify(newObj)
+		},
+		DeleteFunc: w.notify,
+	}); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Start starts the informer of w, which runs until ctx is done or Stop is
+// called, and waits until it has listed the ConfigMaps of the namespace.
+func (w *Watcher) Start(ctx context.Context) error {
+	ctx, w.stop = context.WithCancel(ctx)
+	w.factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), w.synced) {
+		return fmt.Errorf("failed to list configmaps of namespace %q: %v", w.namespace, ctx.Err())
+	}
+	// The ConfigMaps that were listed are not changes.
+	select {
+	case <-w.changed:
+	default:
+	}
+	return nil
+}
+
+// Stop stops the informer of w, and waits for it to exit.
+func (w *Watcher) Stop() {
+	w.stop()
+	w.factory.Shutdown()
+}
+
+// ConfigMaps returns the watched ConfigMaps, sorted by name. It fails if a
+// named ConfigMap does not exist, or if no ConfigMap is watched.
+func (w *Watcher) ConfigMaps() ([]corev1.ConfigMap, error) {
+	all, err := w.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var configMaps []corev1.ConfigMap
+	found := sets.New[string]()
+	for _, cm := range all {
+		if w.matches(cm) {
+			configMaps = append(configMaps, *cm)
+			found.Insert(cm.GetName())
+		}
+	}
+	if missing := w.names.Difference(found); missing.Len() > 0 {
+		return nil, fmt.Errorf("configmaps %v not found in namespace %q", sets.List(missing), w.namespace)
+	}
+	if len(configMaps) == 0 {
+		return nil, fmt.Errorf("no configmaps match selector %q in namespace %q", w.selector, w.namespace)
+	}
+	sort.Slice(configMaps, func(i, j int) bool { return configMaps[i].GetName() < configMaps[j].GetName() })
+	return configMaps, nil
+}
+
+// Watch calls onChange whenever a watched ConfigMap is added, updated, or
+// deleted after Start, until ctx is done. Changes that happen while onChange
+// runs are coalesced into one more call.
+func (w *Watcher) Watch(ctx context.Context, onChange func(ctx context.Context)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.changed:
+		}
+		onChange(ctx)
+	}
+}
+
+func (w *Watcher) notify(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || !w.matches(cm) {
+		return
+	}
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) matches(cm *corev1.ConfigMap) bool {
+	return w.names.Has(cm.GetName()) || w.selector.Matches(labels.Set(cm.GetLabels()))
+}
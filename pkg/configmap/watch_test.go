@@ -0,0 +1,110 @@
+package configmap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestWatcher(t *testing.T) {
+	labeled := func(name, team string) *corev1.ConfigMap {
+		cm := declcfgConfigMap(name, map[string]string{name + ".json": fooPackage})
+		cm.Labels = map[string]string{"catalog": team}
+		return &cm
+	}
+	names := func(configMaps []corev1.ConfigMap) []string {
+		var names []string
+		for _, cm := range configMaps {
+			names = append(names, cm.GetName())
+		}
+		return names
+	}
+
+	client := fake.NewSimpleClientset(labeled("b", "main"), labeled("other", "other"))
+	// The fake clientset does not replay the changes that happen between the
+	// list and the watch of the informer, so wait for the watch to start.
+	watching := make(chan struct{})
+	client.PrependWatchReactor("configmaps", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		w, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		close(watching)
+		return true, w, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := NewWatcher(client, configMapNamespace, nil, "catalog=main")
+	require.NoError(t, err)
+	defer w.Stop()
+	require.NoError(t, w.Start(ctx))
+	<-watching
+
+	configMaps, err := w.ConfigMaps()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, names(configMaps))
+
+	changed := make(chan struct{}, 10)
+	go w.Watch(ctx, func(context.Context) { changed <- struct{}{} })
+	waitForChange := func() {
+		t.Helper()
+		select {
+		case <-changed:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a change")
+		}
+	}
+
+	cms := client.CoreV1().ConfigMaps(configMapNamespace)
+	_, err = cms.Create(ctx, labeled("a", "main"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	waitForChange()
+	require.Eventually(t, func() bool {
+		configMaps, err := w.ConfigMaps()
+		return err == nil && len(configMaps) == 2
+	}, 10*time.Second, 10*time.Millisecond)
+	configMaps, err = w.ConfigMaps()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, names(configMaps))
+
+	_, err = cms.Update(ctx, labeled("a", "other"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	waitForChange()
+
+	require.NoError(t, cms.Delete(ctx, "b", metav1.DeleteOptions{}))
+	waitForChange()
+	require.Eventually(t, func() bool {
+		_, err := w.ConfigMaps()
+		return err != nil
+	}, 10*time.Second, 10*time.Millisecond)
+	_, err = w.ConfigMaps()
+	require.ErrorContains(t, err, "no configmaps match selector")
+}
+
+func TestWatcherNames(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, err := NewWatcher(client, configMapNamespace, nil, "")
+	require.Error(t, err)
+	_, err = NewWatcher(client, configMapNamespace, nil, "a in (")
+	require.ErrorContains(t, err, "invalid label selector")
+
+	named := declcfgConfigMap("named", map[string]string{"catalog.json": fooPackage})
+	client = fake.NewSimpleClientset(&named)
+	w, err := NewWatcher(client, configMapNamespace, []string{"named", "missing"}, "")
+	require.NoError(t, err)
+	defer w.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, w.Start(ctx))
+	_, err = w.ConfigMaps()
+	require.ErrorContains(t, err, "configmaps [missing] not found")
+}
@@ -6,6 +6,11 @@ const (
 	NoneTool ContainerTool = iota
 	PodmanTool
 	DockerTool
+	NerdctlTool
+	// CraneTool only pulls, inspects, and unpacks images, reading them
+	// straight from their registry: it cannot build or push them, so the
+	// commands that build images reject it as their build tool.
+	CraneTool
 )
 
 func (t ContainerTool) String() (s string) {
@@ -16,6 +21,10 @@ func (t ContainerTool) String() (s string) {
 		s = "podman"
 	case DockerTool:
 		s = "docker"
+	case NerdctlTool:
+		s = "nerdctl"
+	case CraneTool:
+		s = "crane"
 	}
 	return
 }
@@ -26,6 +35,10 @@ func (t ContainerTool) CommandFactory() CommandFactory {
 		return &PodmanCommandFactory{}
 	case DockerTool:
 		return &DockerCommandFactory{}
+	case NerdctlTool:
+		return &NerdctlCommandFactory{}
+	case CraneTool:
+		return &CraneCommandFactory{}
 	}
 	return &StubCommandFactory{}
 }
@@ -36,6 +49,10 @@ func NewContainerTool(s string, defaultTool ContainerTool) (t ContainerTool) {
 		t = PodmanTool
 	case "docker":
 		t = DockerTool
+	case "nerdctl":
+		t = NerdctlTool
+	case "crane":
+		t = CraneTool
 	case "none":
 		t = NoneTool
 	default:
@@ -49,6 +66,10 @@ func NewCommandContainerTool(s string) (t ContainerTool) {
 	switch s {
 	case "docker":
 		t = DockerTool
+	case "nerdctl":
+		t = NerdctlTool
+	case "crane":
+		t = CraneTool
 	default:
 		t = PodmanTool
 	}
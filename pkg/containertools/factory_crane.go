@@ -0,0 +1,17 @@
+package containertools
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// CraneCommandFactory is the CommandFactory of crane, the go-containerregistry
+// CLI. crane only reads images from their registry, to pull, inspect, and
+// unpack them: it cannot build images from a Dockerfile.
+type CraneCommandFactory struct{}
+
+// BuildCommand returns an error that matches errors.ErrUnsupported.
+func (c *CraneCommandFactory) BuildCommand(o BuildOptions) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("crane cannot build images from a Dockerfile, build them with docker, podman, or nerdctl instead: %w", errors.ErrUnsupported)
+}
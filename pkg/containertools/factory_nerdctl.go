@@ -0,0 +1,33 @@
+package containertools
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// NerdctlCommandFactory builds images with nerdctl, the docker-compatible CLI
+// of containerd, which builds with BuildKit.
+type NerdctlCommandFactory struct{}
+
+func (n *NerdctlCommandFactory) BuildCommand(o BuildOptions) (*exec.Cmd, error) {
+	args := []string{"build"}
+
+	if o.format != "" && o.format != "docker" {
+		return nil, fmt.Errorf(`format %q invalid for "nerdctl build"`, o.format)
+	}
+
+	if o.dockerfile != "" {
+		args = append(args, "-f", o.dockerfile)
+	}
+
+	for _, tag := range o.tags {
+		args = append(args, "-t", tag)
+	}
+
+	if o.context == "" {
+		return nil, fmt.Errorf("context not provided")
+	}
+	args = append(args, o.context)
+
+	return exec.Command("nerdctl", args...), nil
+}
@@ -134,6 +134,39 @@ func TestBuildCommand(t *testing.T) {
 				"podman", "build", "--format", "docker", "-t", "foo", "-t", "bar", ".",
 			},
 		},
+		{
+			Name:    "nerdctl defaults",
+			Factory: &NerdctlCommandFactory{},
+			Options: DefaultBuildOptions(),
+			Args: []string{
+				"nerdctl", "build", ".",
+			},
+		},
+		{
+			Name:    "nerdctl unsupported format",
+			Factory: &NerdctlCommandFactory{},
+			Options: BuildOptions{
+				context: ".",
+				format:  "oci",
+			},
+		},
+		{
+			Name:    "nerdctl dockerfile and tags",
+			Factory: &NerdctlCommandFactory{},
+			Options: BuildOptions{
+				context:    "foo",
+				dockerfile: "foo/Dockerfile",
+				tags:       []string{"foo", "bar"},
+			},
+			Args: []string{
+				"nerdctl", "build", "-f", "foo/Dockerfile", "-t", "foo", "-t", "bar", "foo",
+			},
+		},
+		{
+			Name:    "crane defaults",
+			Factory: &CraneCommandFactory{},
+			Options: DefaultBuildOptions(),
+		},
 		{
 			Name:    "stub defaults",
 			Factory: &StubCommandFactory{},
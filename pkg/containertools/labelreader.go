@@ -55,7 +55,7 @@ func (r ImageLabelReader) GetLabelsFromImage(image string) (map[string]string, e
 
 	// parse output of inspect to get labels
 	switch containerTool := r.Cmd.GetToolName(); containerTool {
-	case "docker":
+	case "docker", "nerdctl":
 		var data []DockerImageData
 		err := json.Unmarshal(imageData, &data)
 		if err != nil {
@@ -69,6 +69,14 @@ func (r ImageLabelReader) GetLabelsFromImage(image string) (map[string]string, e
 			return nil, err
 		}
 		return data[0].Labels, nil
+	case "crane":
+		// crane prints the config of one image, with its labels in config.Labels.
+		var data DockerImageData
+		err := json.Unmarshal(imageData, &data)
+		if err != nil {
+			return nil, err
+		}
+		return data.Config.Labels, nil
 	}
 
 	return nil, fmt.Errorf("Unable to parse label data from container")
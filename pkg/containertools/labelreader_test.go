@@ -106,6 +106,27 @@ func TestReadPodmanLabelsNoLabels(t *testing.T) {
 	require.Equal(t, len(labels), 0)
 }
 
+func TestReadCraneLabels(t *testing.T) {
+	image := "quay.io/operator-framework/example"
+	imageData := `{"architecture":"amd64","os":"linux","config":{"Labels":{"operators.operatorframework.io.index.database.v1":"./index.db"}}}`
+
+	logger := logrus.NewEntry(logrus.New())
+	mockCmd := containertoolsfakes.FakeCommandRunner{}
+
+	mockCmd.PullReturns(nil)
+	mockCmd.InspectReturns([]byte(imageData), nil)
+	mockCmd.GetToolNameReturns("crane")
+
+	labelReader := containertools.ImageLabelReader{
+		Cmd:    &mockCmd,
+		Logger: logger,
+	}
+
+	labels, err := labelReader.GetLabelsFromImage(image)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"operators.operatorframework.io.index.database.v1": "./index.db"}, labels)
+}
+
 func TestReadDockerLabels_PullError(t *testing.T) {
 	image := "quay.io/operator-framework/example"
 	pullErr := fmt.Errorf("Error pulling image")
@@ -3,13 +3,17 @@ package containertools
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
-// CommandRunner defines methods to shell out to common container tools
+// CommandRunner defines methods to shell out to common container tools. Images
+// are pulled into the local storage of docker, podman, and nerdctl, while
+// crane reads them directly from their registry. crane cannot build images, so
+// Build fails with an error that matches errors.ErrUnsupported for it.
 type CommandRunner interface {
 	GetToolName() string
 	Pull(image string) error
@@ -26,7 +30,17 @@ type ContainerCommandRunner struct {
 }
 
 type RunnerConfig struct {
+	// SkipTLS skips the verification of the TLS certificates of registries,
+	// and allows plain HTTP, for podman, nerdctl, and crane.
 	SkipTLS bool
+
+	// CertDir is a directory of certificates that are trusted to verify
+	// registries, in the layout of podman's --cert-dir.
+	CertDir string
+
+	// RegistriesConf is the path of the containers-registries.conf(5) file
+	// that podman uses.
+	RegistriesConf string
 }
 
 type RunnerOption func(config *RunnerConfig)
@@ -37,6 +51,20 @@ func SkipTLS(skip bool) RunnerOption {
 	}
 }
 
+// WithCertDir makes podman trust the certificates in dir to verify registries.
+func WithCertDir(dir string) RunnerOption {
+	return func(config *RunnerConfig) {
+		config.CertDir = dir
+	}
+}
+
+// WithRegistriesConf makes podman use the registries.conf file at path.
+func WithRegistriesConf(path string) RunnerOption {
+	return func(config *RunnerConfig) {
+		config.RegistriesConf = path
+	}
+}
+
 func (r *RunnerConfig) apply(options []RunnerOption) {
 	for _, option := range options {
 		option(r)
@@ -53,6 +81,21 @@ func (r *ContainerCommandRunner) argsForCmd(cmd string, args ...string) []string
 			if r.config.SkipTLS {
 				cmdArgs = append(cmdArgs, "--tls-verify=false")
 			}
+			if r.config.CertDir != "" {
+				cmdArgs = append(cmdArgs, "--cert-dir="+r.config.CertDir)
+			}
+		}
+	case NerdctlTool:
+		switch cmd {
+		case "pull", "push", "login":
+			if r.config.SkipTLS {
+				cmdArgs = append(cmdArgs, "--insecure-registry")
+			}
+		}
+	case CraneTool:
+		// --insecure is a global flag of crane.
+		if r.config.SkipTLS {
+			cmdArgs = append(cmdArgs, "--insecure")
 		}
 	default:
 	}
@@ -60,6 +103,15 @@ func (r *ContainerCommandRunner) argsForCmd(cmd string, args ...string) []string
 	return cmdArgs
 }
 
+// command returns the command that runs the container tool with args.
+func (r *ContainerCommandRunner) command(args []string) *exec.Cmd {
+	command := exec.Command(r.containerTool.String(), args...)
+	if r.containerTool == PodmanTool && r.config.RegistriesConf != "" {
+		command.Env = append(os.Environ(), "CONTAINERS_REGISTRIES_CONF="+r.config.RegistriesConf)
+	}
+	return command
+}
+
 // NewCommandRunner takes the containerTool as an input string and returns a
 // CommandRunner to run commands with that cli tool
 func NewCommandRunner(containerTool ContainerTool, logger *logrus.Entry, opts ...RunnerOption) *ContainerCommandRunner {
@@ -81,9 +133,12 @@ func (r *ContainerCommandRunner) GetToolName() string {
 // Pull takes a container image path hosted on a container registry and runs the
 // pull command to download it onto the local environment
 func (r *ContainerCommandRunner) Pull(image string) error {
+	if r.containerTool == CraneTool {
+		return r.cranePull(image)
+	}
 	args := r.argsForCmd("pull", image)
 
-	command := exec.Command(r.containerTool.String(), args...)
+	command := r.command(args)
 
 	r.logger.Infof("running %s", command.String())
 
@@ -106,7 +161,7 @@ func (r *ContainerCommandRunner) Build(dockerfile, tag string) error {
 	o.SetContext(".")
 	command, err := r.containerTool.CommandFactory().BuildCommand(o)
 	if err != nil {
-		return fmt.Errorf("unable to perform build: %v", err)
+		return fmt.Errorf("unable to perform build: %w", err)
 	}
 
 	r.logger.Infof("running %s build", r.containerTool)
@@ -123,9 +178,12 @@ func (r *ContainerCommandRunner) Build(dockerfile, tag string) error {
 
 // Unpack copies a directory from a local container image to a directory in the local filesystem.
 func (r *ContainerCommandRunner) Unpack(image, src, dst string) error {
+	if r.containerTool == CraneTool {
+		return r.craneUnpack(image, src, dst)
+	}
 	args := r.argsForCmd("create", image, "")
 
-	command := exec.Command(r.containerTool.String(), args...)
+	command := r.command(args)
 
 	r.logger.Infof("running %s create", r.containerTool)
 	r.logger.Debugf("%s", command.Args)
@@ -141,7 +199,7 @@ func (r *ContainerCommandRunner) Unpack(image, src, dst string) error {
 
 	id := strings.TrimSuffix(string(out), "\n")
 	args = r.argsForCmd("cp", id+":"+src, dst)
-	command = exec.Command(r.containerTool.String(), args...)
+	command = r.command(args)
 
 	r.logger.Infof("running %s cp", r.containerTool)
 	r.logger.Debugf("%s", command.Args)
@@ -153,7 +211,7 @@ func (r *ContainerCommandRunner) Unpack(image, src, dst string) error {
 	}
 
 	args = r.argsForCmd("rm", id)
-	command = exec.Command(r.containerTool.String(), args...)
+	command = r.command(args)
 
 	r.logger.Infof("running %s rm", r.containerTool)
 	r.logger.Debugf("%s", command.Args)
@@ -170,9 +228,12 @@ func (r *ContainerCommandRunner) Unpack(image, src, dst string) error {
 // Inspect runs the 'inspect' command to get image metadata of a local container
 // image and returns a byte array of the command's output
 func (r *ContainerCommandRunner) Inspect(image string) ([]byte, error) {
+	if r.containerTool == CraneTool {
+		return r.craneInspect(image)
+	}
 	args := r.argsForCmd("inspect", image)
 
-	command := exec.Command(r.containerTool.String(), args...)
+	command := r.command(args)
 
 	r.logger.Infof("running %s inspect", r.containerTool)
 	r.logger.Debugf("%s", command.Args)
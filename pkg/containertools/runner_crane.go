@@ -0,0 +1,128 @@
+package containertools
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// cranePull checks that image can be read from its registry. crane has no
+// local image storage, so images are read from their registry whenever they
+// are inspected or unpacked.
+func (r *ContainerCommandRunner) cranePull(image string) error {
+	command := r.command(r.argsForCmd("digest", image))
+
+	r.logger.Infof("running %s", command.String())
+
+	out, err := command.CombinedOutput()
+	if err != nil {
+		r.logger.Error(string(out))
+		return fmt.Errorf("error pulling image: %s. %v", string(out), err)
+	}
+
+	return nil
+}
+
+// craneInspect returns the config of image, which has the labels of the image
+// in config.Labels, like the output of 'docker inspect' has them for each
+// image in Config.Labels.
+func (r *ContainerCommandRunner) craneInspect(image string) ([]byte, error) {
+	command := r.command(r.argsForCmd("config", image))
+
+	r.logger.Infof("running %s config", r.containerTool)
+	r.logger.Debugf("%s", command.Args)
+
+	out, err := command.Output()
+	if err != nil {
+		r.logger.Error(string(out))
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// craneUnpack writes the contents of the directory src of the flattened
+// filesystem of image to dst.
+func (r *ContainerCommandRunner) craneUnpack(image, src, dst string) error {
+	command := r.command(r.argsForCmd("export", image, "-"))
+
+	r.logger.Infof("running %s export", r.containerTool)
+	r.logger.Debugf("%s", command.Args)
+
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := command.Start(); err != nil {
+		return fmt.Errorf("error exporting image: %v", err)
+	}
+	extractErr := extractTarDir(stdout, src, dst)
+	if extractErr != nil {
+		// Drain the rest of the export, so that crane does not block on a full pipe.
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := command.Wait(); err != nil {
+		r.logger.Error(stderr.String())
+		return fmt.Errorf("error exporting image %s: %v", stderr.String(), err)
+	}
+	if extractErr != nil {
+		return fmt.Errorf("error copying image directory: %v", extractErr)
+	}
+
+	return nil
+}
+
+// extractTarDir writes the regular files and directories under the directory
+// src of the tar stream r to dst. Links and special files are skipped.
+func extractTarDir(r io.Reader, src, dst string) error {
+	prefix := strings.TrimPrefix(path.Clean("/"+src), "/")
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if prefix != "" {
+			if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+		}
+		target := filepath.Join(dst, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, hdr.FileInfo().Mode().Perm()|0600)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
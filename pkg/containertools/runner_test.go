@@ -0,0 +1,89 @@
+package containertools
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunnerCommand(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	opts := []RunnerOption{SkipTLS(true), WithCertDir("/certs"), WithRegistriesConf("/registries.conf")}
+
+	podman := NewCommandRunner(PodmanTool, logger, opts...)
+	pull := podman.command(podman.argsForCmd("pull", "quay.io/foo/bar:v1"))
+	require.Equal(t, []string{"podman", "pull", "--tls-verify=false", "--cert-dir=/certs", "quay.io/foo/bar:v1"}, pull.Args)
+	require.Contains(t, pull.Env, "CONTAINERS_REGISTRIES_CONF=/registries.conf")
+	inspect := podman.command(podman.argsForCmd("inspect", "quay.io/foo/bar:v1"))
+	require.Equal(t, []string{"podman", "inspect", "quay.io/foo/bar:v1"}, inspect.Args)
+
+	docker := NewCommandRunner(DockerTool, logger, opts...)
+	pull = docker.command(docker.argsForCmd("pull", "quay.io/foo/bar:v1"))
+	require.Equal(t, []string{"docker", "pull", "quay.io/foo/bar:v1"}, pull.Args)
+	require.Nil(t, pull.Env)
+
+	nerdctl := NewCommandRunner(NerdctlTool, logger, opts...)
+	pull = nerdctl.command(nerdctl.argsForCmd("pull", "quay.io/foo/bar:v1"))
+	require.Equal(t, []string{"nerdctl", "pull", "--insecure-registry", "quay.io/foo/bar:v1"}, pull.Args)
+	inspect = nerdctl.command(nerdctl.argsForCmd("inspect", "quay.io/foo/bar:v1"))
+	require.Equal(t, []string{"nerdctl", "inspect", "quay.io/foo/bar:v1"}, inspect.Args)
+
+	crane := NewCommandRunner(CraneTool, logger, opts...)
+	config := crane.command(crane.argsForCmd("config", "quay.io/foo/bar:v1"))
+	require.Equal(t, []string{"crane", "config", "--insecure", "quay.io/foo/bar:v1"}, config.Args)
+	require.Nil(t, config.Env)
+	// crane cannot build images.
+	require.ErrorIs(t, crane.Build("Dockerfile", "quay.io/foo/bar:v1"), errors.ErrUnsupported)
+}
+
+func TestExtractTarDir(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		data string
+	}{
+		{"manifests/", ""},
+		{"manifests/csv.yaml", "csv"},
+		{"metadata/annotations.yaml", "annotations"},
+		{"manifests-other/foo.yaml", "foo"},
+		{"../escape.yaml", "escape"},
+	} {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(f.name, "/") {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(f.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	all := t.TempDir()
+	require.NoError(t, extractTarDir(bytes.NewReader(buf.Bytes()), "/.", all))
+	for name, data := range map[string]string{
+		"manifests/csv.yaml":        "csv",
+		"metadata/annotations.yaml": "annotations",
+		"manifests-other/foo.yaml":  "foo",
+		"escape.yaml":               "escape",
+	} {
+		actual, err := os.ReadFile(filepath.Join(all, name))
+		require.NoError(t, err)
+		require.Equal(t, data, string(actual))
+	}
+
+	manifests := t.TempDir()
+	require.NoError(t, extractTarDir(bytes.NewReader(buf.Bytes()), "/manifests", manifests))
+	entries, err := os.ReadDir(manifests)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "csv.yaml", entries[0].Name())
+}
@@ -0,0 +1,79 @@
+package image
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+)
+
+// ClientConfig configures how images are pulled from their registries. It is
+// shared by the Registry implementations, so that users who pull through
+// mirrors or behind a proxy configure every image operation the same way.
+//
+// Proxies are taken from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+// variables by every implementation, so they are not part of the config.
+type ClientConfig struct {
+	// CAFile is a PEM file of certificates that are trusted to verify
+	// registries, in addition to the system's.
+	CAFile string
+
+	// SkipTLSVerify disables the verification of registry certificates.
+	SkipTLSVerify bool
+
+	// PlainHTTP reaches registries over HTTP instead of HTTPS.
+	PlainHTTP bool
+
+	// RegistriesConfPath is the path of a containers-registries.conf(5) file
+	// whose mirrors and blocked registries are honored. If empty, the file of
+	// the CONTAINERS_REGISTRIES_CONF environment variable is used, or else
+	// the system's, if there is one.
+	RegistriesConfPath string
+}
+
+// RootCAs returns the certificates that are trusted to verify registries.
+func (c ClientConfig) RootCAs() (*x509.CertPool, error) {
+	return certs.RootCAs(c.CAFile)
+}
+
+// PullSource is a location that an image can be pulled from.
+type PullSource struct {
+	// Ref is the reference of the image at the location.
+	Ref reference.Named
+
+	// Insecure is true if the registry of the location may be reached
+	// without verifying its certificate.
+	Insecure bool
+}
+
+// PullSources returns the locations that the image of ref is pulled from, in
+// the order they are tried: the mirrors that the registries.conf file sets
+// for it, and then ref itself. An error is returned if the registry of ref is
+// blocked.
+func (c ClientConfig) PullSources(ref reference.Named) ([]PullSource, error) {
+	sys := &types.SystemContext{SystemRegistriesConfPath: c.RegistriesConfPath}
+	reg, err := sysregistriesv2.FindRegistry(sys, ref.Name())
+	if err != nil {
+		return nil, fmt.Errorf("error loading registries configuration: %v", err)
+	}
+	if reg == nil {
+		return []PullSource{{Ref: ref}}, nil
+	}
+	if reg.Blocked {
+		return nil, fmt.Errorf("registry %s is blocked in the registries configuration", reg.Prefix)
+	}
+
+	sources, err := reg.PullSourcesFromReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]PullSource, 0, len(sources))
+	for _, s := range sources {
+		out = append(out, PullSource{Ref: s.Reference, Insecure: s.Endpoint.Insecure})
+	}
+	return out, nil
+}
@@ -0,0 +1,104 @@
+package image
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientConfigPullSources(t *testing.T) {
+	registriesConf := filepath.Join(t.TempDir(), "registries.conf")
+	require.NoError(t, os.WriteFile(registriesConf, []byte(`
+[[registry]]
+location = "quay.io/org"
+
+[[registry.mirror]]
+location = "mirror.example.com/quay"
+insecure = true
+
+[[registry]]
+location = "registry.example.com"
+mirror-by-digest-only = true
+
+[[registry.mirror]]
+location = "mirror.example.com/registry"
+
+[[registry]]
+location = "blocked.example.com"
+blocked = true
+`), 0600))
+	cfg := ClientConfig{RegistriesConfPath: registriesConf}
+
+	const dgst = "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	type spec struct {
+		name      string
+		ref       string
+		expected  []string
+		insecure  []bool
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "NoRegistry",
+			ref:       "docker.io/library/foo:v1",
+			expected:  []string{"docker.io/library/foo:v1"},
+			insecure:  []bool{false},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Mirror",
+			ref:       "quay.io/org/foo:v1",
+			expected:  []string{"mirror.example.com/quay/foo:v1", "quay.io/org/foo:v1"},
+			insecure:  []bool{true, false},
+			assertion: require.NoError,
+		},
+		{
+			name:      "NoMatchingPrefix",
+			ref:       "quay.io/other/foo:v1",
+			expected:  []string{"quay.io/other/foo:v1"},
+			insecure:  []bool{false},
+			assertion: require.NoError,
+		},
+		{
+			name:      "MirrorByDigestOnly/Tag",
+			ref:       "registry.example.com/foo:v1",
+			expected:  []string{"registry.example.com/foo:v1"},
+			insecure:  []bool{false},
+			assertion: require.NoError,
+		},
+		{
+			name:      "MirrorByDigestOnly/Digest",
+			ref:       "registry.example.com/foo@" + dgst,
+			expected:  []string{"mirror.example.com/registry/foo@" + dgst, "registry.example.com/foo@" + dgst},
+			insecure:  []bool{false, false},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Blocked",
+			ref:       "blocked.example.com/foo:v1",
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			ref, err := reference.ParseNamed(s.ref)
+			require.NoError(t, err)
+			sources, err := cfg.PullSources(ref)
+			s.assertion(t, err)
+
+			var (
+				actual   []string
+				insecure []bool
+			)
+			for _, src := range sources {
+				actual = append(actual, src.Ref.String())
+				insecure = append(insecure, src.Insecure)
+			}
+			require.Equal(t, s.expected, actual)
+			require.Equal(t, s.insecure, insecure)
+		})
+	}
+}
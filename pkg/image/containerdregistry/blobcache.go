@@ -0,0 +1,359 @@
+package containerdregistry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	blobCacheBlobsDir       = "blobs"
+	blobCacheDescriptorsDir = "descriptors"
+	blobCacheIngestDir      = "ingest"
+
+	// staleIngestAge is the age after which a partially fetched blob is
+	// assumed to have been abandoned, e.g. by an interrupted invocation.
+	staleIngestAge = 24 * time.Hour
+)
+
+// BlobCacheGCPolicy determines the blobs that are removed from a BlobCache
+// when it is garbage collected. A blob is last used when it was added to the
+// cache or last read from it.
+type BlobCacheGCPolicy struct {
+	// MaxAge is the time after its last use that a blob is removed. Zero
+	// means that blobs are not removed because of their age.
+	MaxAge time.Duration
+	// MaxSize is the total size of blobs that the cache is reduced to, by
+	// removing the least recently used blobs first. Zero means no limit.
+	MaxSize int64
+}
+
+// DefaultBlobCacheGCPolicy removes blobs that have not been used for 30 days,
+// and keeps the cache under 10GiB.
+var DefaultBlobCacheGCPolicy = BlobCacheGCPolicy{
+	MaxAge:  30 * 24 * time.Hour,
+	MaxSize: 10 << 30,
+}
+
+// DefaultBlobCacheDir returns the default directory of the persistent blob
+// cache, "opm" in the user's cache directory, e.g. $XDG_CACHE_HOME/opm.
+func DefaultBlobCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "opm"), nil
+}
+
+// BlobCache is a persistent, content-addressed cache of image blobs, keyed by
+// digest, that can be shared by registries across opm invocations, including
+// concurrent ones. Blobs are verified against their digest before they are
+// added to the cache, so a cached blob is always complete.
+//
+// The cache also remembers the descriptors of images that were pulled by
+// digest, so that pulling them again does not need to reach their registry
+// at all if all of their blobs are cached.
+type BlobCache struct {
+	dir string
+}
+
+// NewBlobCache returns a BlobCache that stores blobs in dir, which is created
+// if it does not exist.
+func NewBlobCache(dir string) (*BlobCache, error) {
+	for _, sub := range []string{blobCacheBlobsDir, blobCacheDescriptorsDir, blobCacheIngestDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("create blob cache: %v", err)
+		}
+	}
+	return &BlobCache{dir: dir}, nil
+}
+
+func (c *BlobCache) blobPath(dgst digest.Digest) string {
+	return filepath.Join(c.dir, blobCacheBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+func (c *BlobCache) descriptorPath(dgst digest.Digest) string {
+	return filepath.Join(c.dir, blobCacheDescriptorsDir, dgst.Algorithm().String(), dgst.Encoded()+".json")
+}
+
+// Open returns a reader for the cached blob of desc, and whether it is cached.
+func (c *BlobCache) Open(desc ocispec.Descriptor) (io.ReadCloser, bool) {
+	if desc.Digest.Validate() != nil {
+		return nil, false
+	}
+	path := c.blobPath(desc.Digest)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	if info, err := f.Stat(); err != nil || (desc.Size > 0 && info.Size() != desc.Size) {
+		f.Close()
+		return nil, false
+	}
+	// Mark the blob as used, for garbage collection.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return f, true
+}
+
+// Add returns a reader of r that adds the blob of desc to the cache once r
+// has been read to its end and its content matches desc. If it does not, or
+// the reader is closed first, the blob is not added.
+func (c *BlobCache) Add(desc ocispec.Descriptor, r io.ReadCloser) io.ReadCloser {
+	if desc.Digest.Validate() != nil {
+		return r
+	}
+	tmp, err := os.CreateTemp(filepath.Join(c.dir, blobCacheIngestDir), desc.Digest.Encoded()+"-*")
+	if err != nil {
+		return r
+	}
+	return &cachingReader{
+		cache:    c,
+		desc:     desc,
+		r:        r,
+		tmp:      tmp,
+		verifier: desc.Digest.Verifier(),
+	}
+}
+
+// cachingReader copies what is read from r to a temporary file, which is
+// moved into the cache when r is read to its end and its content is verified.
+type cachingReader struct {
+	cache    *BlobCache
+	desc     ocispec.Descriptor
+	r        io.ReadCloser
+	tmp      *os.File
+	verifier digest.Verifier
+	size     int64
+	failed   bool
+	done     bool
+}
+
+func (cr *cachingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	if n > 0 && !cr.failed {
+		cr.size += int64(n)
+		if _, werr := cr.tmp.Write(p[:n]); werr != nil {
+			cr.failed = true
+		} else {
+			_, _ = cr.verifier.Write(p[:n])
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		cr.commit()
+	}
+	return n, err
+}
+
+func (cr *cachingReader) commit() {
+	if cr.done {
+		return
+	}
+	cr.done = true
+	if err := cr.tmp.Close(); err != nil || cr.failed {
+		os.Remove(cr.tmp.Name())
+		return
+	}
+	if !cr.verifier.Verified() || (cr.desc.Size > 0 && cr.size != cr.desc.Size) {
+		os.Remove(cr.tmp.Name())
+		return
+	}
+	path := cr.cache.blobPath(cr.desc.Digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		os.Remove(cr.tmp.Name())
+		return
+	}
+	// Renaming is atomic, so concurrent readers of the cache never see a
+	// partial blob, and concurrent writers of the same blob write the same
+	// content.
+	if err := os.Rename(cr.tmp.Name(), path); err != nil {
+		os.Remove(cr.tmp.Name())
+	}
+}
+
+func (cr *cachingReader) Close() error {
+	if !cr.done {
+		cr.done = true
+		cr.tmp.Close()
+		os.Remove(cr.tmp.Name())
+	}
+	return cr.r.Close()
+}
+
+// descriptor returns the cached descriptor of the image with digest dgst.
+func (c *BlobCache) descriptor(dgst digest.Digest) (ocispec.Descriptor, bool) {
+	if dgst.Validate() != nil {
+		return ocispec.Descriptor{}, false
+	}
+	data, err := os.ReadFile(c.descriptorPath(dgst))
+	if err != nil {
+		return ocispec.Descriptor{}, false
+	}
+	var desc ocispec.Descriptor
+	if err := json.Unmarshal(data, &desc); err != nil || desc.Digest != dgst {
+		return ocispec.Descriptor{}, false
+	}
+	return desc, true
+}
+
+// addDescriptor caches desc, the descriptor of an image pulled by digest.
+func (c *BlobCache) addDescriptor(desc ocispec.Descriptor) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(ocispec.Descriptor{MediaType: desc.MediaType, Digest: desc.Digest, Size: desc.Size})
+	if err != nil {
+		return err
+	}
+	path := c.descriptorPath(desc.Digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(c.dir, blobCacheIngestDir), desc.Digest.Encoded()+"-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type cachedBlob struct {
+	path     string
+	digest   digest.Digest
+	size     int64
+	lastUsed time.Time
+}
+
+// GC removes the blobs selected by policy, the descriptors of images whose
+// manifest was removed, and abandoned partially fetched blobs.
+func (c *BlobCache) GC(policy BlobCacheGCPolicy) error {
+	var blobs []cachedBlob
+	blobsDir := filepath.Join(c.dir, blobCacheBlobsDir)
+	err := filepath.WalkDir(blobsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// removed by a concurrent GC
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(blobsDir, path)
+		if err != nil {
+			return err
+		}
+		algorithm, encoded := filepath.Split(rel)
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(filepath.Clean(algorithm)), encoded)
+		blobs = append(blobs, cachedBlob{path: path, digest: dgst, size: info.Size(), lastUsed: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("garbage collect blob cache: %v", err)
+	}
+
+	// Least recently used first.
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].lastUsed.Before(blobs[j].lastUsed)
+	})
+	var total int64
+	for _, b := range blobs {
+		total += b.size
+	}
+
+	var errs []error
+	now := time.Now()
+	for _, b := range blobs {
+		expired := policy.MaxAge > 0 && now.Sub(b.lastUsed) > policy.MaxAge
+		oversized := policy.MaxSize > 0 && total > policy.MaxSize
+		if !expired && !oversized {
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		total -= b.size
+		if err := os.Remove(c.descriptorPath(b.digest)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	ingest, err := os.ReadDir(filepath.Join(c.dir, blobCacheIngestDir))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, e := range ingest {
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < staleIngestAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, blobCacheIngestDir, e.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("garbage collect blob cache: %v", errors.Join(errs...))
+	}
+	return nil
+}
+
+// cachingFetcher fetches blobs from a BlobCache, and from a remote fetcher
+// for blobs that are not cached, adding them to the cache. The remote
+// fetcher is only created when it is first needed.
+type cachingFetcher struct {
+	cache *BlobCache
+
+	once      sync.Once
+	newRemote func() (remotes.Fetcher, error)
+	remote    remotes.Fetcher
+	remoteErr error
+}
+
+var _ remotes.Fetcher = &cachingFetcher{}
+
+func newCachingFetcher(cache *BlobCache, newRemote func() (remotes.Fetcher, error)) *cachingFetcher {
+	return &cachingFetcher{cache: cache, newRemote: newRemote}
+}
+
+func (f *cachingFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	if rc, ok := f.cache.Open(desc); ok {
+		return rc, nil
+	}
+	f.once.Do(func() {
+		f.remote, f.remoteErr = f.newRemote()
+	})
+	if f.remoteErr != nil {
+		return nil, f.remoteErr
+	}
+	rc, err := f.remote.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	return f.cache.Add(desc, rc), nil
+}
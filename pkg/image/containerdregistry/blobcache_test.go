@@ -0,0 +1,184 @@
+package containerdregistry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func blob(content string) ([]byte, ocispec.Descriptor) {
+	data := []byte(content)
+	return data, ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayer,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+}
+
+func addBlob(t *testing.T, c *BlobCache, data []byte, desc ocispec.Descriptor) {
+	t.Helper()
+	r := c.Add(desc, io.NopCloser(bytes.NewReader(data)))
+	_, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+}
+
+func TestBlobCacheAdd(t *testing.T) {
+	data, desc := blob("layer")
+
+	t.Run("Verified", func(t *testing.T) {
+		c, err := NewBlobCache(t.TempDir())
+		require.NoError(t, err)
+		_, ok := c.Open(desc)
+		require.False(t, ok)
+
+		addBlob(t, c, data, desc)
+
+		rc, ok := c.Open(desc)
+		require.True(t, ok)
+		defer rc.Close()
+		actual, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.Equal(t, data, actual)
+	})
+
+	t.Run("DigestMismatch", func(t *testing.T) {
+		c, err := NewBlobCache(t.TempDir())
+		require.NoError(t, err)
+		_, other := blob("other")
+		other.Size = desc.Size
+
+		addBlob(t, c, data, other)
+
+		_, ok := c.Open(other)
+		require.False(t, ok)
+	})
+
+	t.Run("ClosedBeforeEnd", func(t *testing.T) {
+		dir := t.TempDir()
+		c, err := NewBlobCache(dir)
+		require.NoError(t, err)
+
+		r := c.Add(desc, io.NopCloser(bytes.NewReader(data)))
+		_, err = r.Read(make([]byte, 1))
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		_, ok := c.Open(desc)
+		require.False(t, ok)
+		ingest, err := os.ReadDir(filepath.Join(dir, blobCacheIngestDir))
+		require.NoError(t, err)
+		require.Empty(t, ingest)
+	})
+}
+
+func TestBlobCacheGC(t *testing.T) {
+	type cached struct {
+		content string
+		age     time.Duration
+	}
+	type spec struct {
+		name     string
+		blobs    []cached
+		policy   BlobCacheGCPolicy
+		expected []string
+	}
+	specs := []spec{
+		{
+			name:     "NoLimits",
+			blobs:    []cached{{"a", 48 * time.Hour}, {"b", time.Hour}},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "MaxAge",
+			blobs:    []cached{{"a", 48 * time.Hour}, {"b", time.Hour}},
+			policy:   BlobCacheGCPolicy{MaxAge: 24 * time.Hour},
+			expected: []string{"b"},
+		},
+		{
+			name:     "MaxSizeRemovesLeastRecentlyUsed",
+			blobs:    []cached{{"aaaa", 3 * time.Hour}, {"bbbb", time.Hour}, {"cccc", 2 * time.Hour}},
+			policy:   BlobCacheGCPolicy{MaxSize: 8},
+			expected: []string{"bbbb", "cccc"},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			c, err := NewBlobCache(t.TempDir())
+			require.NoError(t, err)
+			for _, b := range s.blobs {
+				data, desc := blob(b.content)
+				addBlob(t, c, data, desc)
+				require.NoError(t, c.addDescriptor(desc))
+				lastUsed := time.Now().Add(-b.age)
+				require.NoError(t, os.Chtimes(c.blobPath(desc.Digest), lastUsed, lastUsed))
+			}
+
+			require.NoError(t, c.GC(s.policy))
+
+			var actual []string
+			for _, b := range s.blobs {
+				_, desc := blob(b.content)
+				_, hasBlob := c.Open(desc)
+				_, hasDescriptor := c.descriptor(desc.Digest)
+				require.Equal(t, hasBlob, hasDescriptor)
+				if hasBlob {
+					actual = append(actual, b.content)
+				}
+			}
+			require.ElementsMatch(t, s.expected, actual)
+		})
+	}
+}
+
+type countingFetcher struct {
+	blobs   map[digest.Digest][]byte
+	fetched int
+}
+
+func (f *countingFetcher) Fetch(_ context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	f.fetched++
+	data, ok := f.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestCachingFetcher(t *testing.T) {
+	data, desc := blob("layer")
+	remote := &countingFetcher{blobs: map[digest.Digest][]byte{desc.Digest: data}}
+	c, err := NewBlobCache(t.TempDir())
+	require.NoError(t, err)
+
+	remotesCreated := 0
+	newFetcher := func() *cachingFetcher {
+		return newCachingFetcher(c, func() (remotes.Fetcher, error) {
+			remotesCreated++
+			return remote, nil
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		rc, err := newFetcher().Fetch(context.Background(), desc)
+		require.NoError(t, err)
+		actual, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		require.Equal(t, data, actual)
+	}
+	// The second fetch is served from the cache, without creating a remote
+	// fetcher.
+	require.Equal(t, 1, remote.fetched)
+	require.Equal(t, 1, remotesCreated)
+}
@@ -0,0 +1,153 @@
+package containerdregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/containers/common/pkg/auth"
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/types"
+	dockerconfig "github.com/docker/cli/cli/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// credentialStore looks up the registry credentials of repositories, and
+// caches those found in the auth file until the auth file changes. The auth
+// file is checked for changes on every lookup, following symlinks, so that
+// long-running processes pick up rotated credentials, e.g. a pull secret
+// remounted by the kubelet, without being restarted, and resolvers created
+// after the rotation authenticate with the new credentials.
+//
+// Credentials that are not in the auth file are looked up in the system
+// defaults of containers/image (podman/skopeo) every time, as those files are
+// not watched.
+type credentialStore struct {
+	authFile string
+	lookup   func(sys *types.SystemContext, repo string) (types.DockerAuthConfig, error)
+
+	mu      sync.Mutex
+	version string
+	creds   map[string]types.DockerAuthConfig
+}
+
+// newCredentialStore returns a credentialStore for the auth file in
+// configDir, or in the standard docker config directory if configDir is
+// empty. If the REGISTRY_AUTH_FILE or DOCKER_CONFIG environment variables
+// are set, they are used (in that order) instead to derive the auth file.
+func newCredentialStore(configDir string) *credentialStore {
+	if configDir == "" {
+		configDir = dockerconfig.Dir()
+	}
+	authFile := filepath.Join(configDir, dockerconfig.ConfigFileName)
+	if defaultAuthFile := auth.GetDefaultAuthFile(); defaultAuthFile != "" {
+		authFile = defaultAuthFile
+	}
+	return &credentialStore{authFile: authFile, lookup: config.GetCredentials}
+}
+
+// get returns the username and password, or the identity token, to
+// authenticate to repo with.
+func (s *credentialStore) get(repo string) (string, string, error) {
+	cred, err := s.credentials(repo)
+	if err != nil {
+		return "", "", err
+	}
+	if cred.IdentityToken != "" {
+		return "", cred.IdentityToken, nil
+	}
+	return cred.Username, cred.Password, nil
+}
+
+func (s *credentialStore) credentials(repo string) (types.DockerAuthConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, err := authFileVersion(s.authFile)
+	if err != nil {
+		// The auth file cannot be checked for changes, e.g. because a
+		// directory on its path cannot be read. Forget the credentials read
+		// from it, and use the system defaults, like when it does not exist.
+		recordAuthRefresh(s.authFile, err)
+		s.version, s.creds = "", nil
+		return s.lookup(nil, repo)
+	}
+	if version != s.version {
+		if s.creds != nil {
+			recordAuthRefresh(s.authFile, nil)
+		}
+		s.version = version
+		s.creds = map[string]types.DockerAuthConfig{}
+	}
+	if cred, ok := s.creds[repo]; ok {
+		return cred, nil
+	}
+
+	// In order to maintain backward-compatibility with the original credential getter,
+	// we will first try to get the credentials from the auth file, if it exists.
+	if version != "" {
+		cred, err := s.lookup(&types.SystemContext{AuthFilePath: s.authFile}, repo)
+		if err != nil {
+			recordAuthRefresh(s.authFile, err)
+		} else if cred != (types.DockerAuthConfig{}) {
+			s.creds[repo] = cred
+			return cred, nil
+		}
+	}
+
+	// If the auth file doesn't exist or if we couldn't find credentials in it, we'll use
+	// system defaults from containers/image (podman/skopeo) to lookup the credentials.
+	return s.lookup(nil, repo)
+}
+
+// authFileVersion summarizes the path that authFile resolves to, its size
+// and its modification time, so that changes to it, including the swap of
+// the symlinks of a mounted Secret, can be detected without reading it. The
+// version of an auth file that does not exist, or is not a regular file, is
+// empty.
+func authFileVersion(authFile string) (string, error) {
+	path, err := filepath.EvalSymlinks(authFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", nil
+	}
+	return fmt.Sprintf("%s %d %d", path, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+const meterName = "github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+
+// recordAuthRefresh records that the credentials of authFile were reloaded
+// because it changed, or that reading it failed with err. The meter is
+// resolved from the global MeterProvider on each call, so that the provider
+// that a command sets up is used.
+func recordAuthRefresh(authFile string, err error) {
+	meter := otel.Meter(meterName)
+	attrs := metric.WithAttributes(attribute.String("auth.file", authFile))
+	if err != nil {
+		if failures, mErr := meter.Int64Counter("registry.auth.refresh.failures", metric.WithDescription("Number of failures to read the registry auth file")); mErr == nil {
+			failures.Add(context.Background(), 1, attrs)
+		}
+		return
+	}
+	if refreshes, mErr := meter.Int64Counter("registry.auth.refreshes", metric.WithDescription("Number of times registry credentials were reloaded because the auth file changed")); mErr == nil {
+		refreshes.Add(context.Background(), 1, attrs)
+	}
+}
@@ -0,0 +1,104 @@
+package containerdregistry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// TestCredentialStoreRotation ensures that credentials are reloaded when the
+// auth file changes, including when a mounted Secret swaps its symlinks, so
+// that long-running processes pick up rotated credentials without being
+// restarted.
+func TestCredentialStoreRotation(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(provider)
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	// Lay out the auth file like the kubelet mounts a Secret:
+	// config.json -> ..data/config.json, ..data -> <timestamped directory>.
+	dir := t.TempDir()
+	writeSecret := func(name, password string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "config.json"), []byte(password), 0600))
+		tmp := filepath.Join(dir, "..data_tmp")
+		require.NoError(t, os.Symlink(name, tmp))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+	}
+	writeSecret("..2026_01_01", "first")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.json"), filepath.Join(dir, "config.json")))
+
+	var lookups int
+	s := &credentialStore{
+		authFile: filepath.Join(dir, "config.json"),
+		lookup: func(sys *types.SystemContext, repo string) (types.DockerAuthConfig, error) {
+			lookups++
+			if sys == nil {
+				return types.DockerAuthConfig{}, nil
+			}
+			data, err := os.ReadFile(sys.AuthFilePath)
+			if err != nil {
+				return types.DockerAuthConfig{}, err
+			}
+			if strings.HasPrefix(string(data), "invalid") {
+				return types.DockerAuthConfig{}, errors.New("invalid auth file")
+			}
+			return types.DockerAuthConfig{Username: repo, Password: string(data)}, nil
+		},
+	}
+	get := func(password string) {
+		t.Helper()
+		username, actual, err := s.get("registry.example.com/foo/bar")
+		require.NoError(t, err)
+		require.Equal(t, password, actual)
+		if password != "" {
+			require.Equal(t, "registry.example.com/foo/bar", username)
+		}
+	}
+	counts := func() map[string]int64 {
+		t.Helper()
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		counts := map[string]int64{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					counts[m.Name] += dp.Value
+				}
+			}
+		}
+		return counts
+	}
+
+	get("first")
+	get("first")
+	require.Equal(t, 1, lookups, "credentials should be cached while the auth file is unchanged")
+	require.Empty(t, counts())
+
+	writeSecret("..2026_01_02", "rotated")
+	get("rotated")
+	require.Equal(t, 2, lookups)
+	require.Equal(t, map[string]int64{"registry.auth.refreshes": 1}, counts())
+
+	// Credentials that cannot be read from the auth file are looked up in
+	// the system defaults, and the failure is counted.
+	writeSecret("..2026_01_03", "invalid")
+	get("")
+	require.Equal(t, map[string]int64{"registry.auth.refreshes": 2, "registry.auth.refresh.failures": 1}, counts())
+
+	// Without an auth file, the system defaults are used.
+	require.NoError(t, os.Remove(filepath.Join(dir, "config.json")))
+	get("")
+	require.Equal(t, map[string]int64{"registry.auth.refreshes": 3, "registry.auth.refresh.failures": 1}, counts())
+}
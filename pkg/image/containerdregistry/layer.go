@@ -0,0 +1,58 @@
+package containerdregistry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/images"
+	"github.com/klauspost/compress/zstd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// estargzEntries are the files that eStargz adds to the root of a gzip layer
+// to support lazy pulling. They are not part of the image's content.
+var estargzEntries = map[string]struct{}{
+	"stargz.index.json":     {},
+	".prefetch.landmark":    {},
+	".no.prefetch.landmark": {},
+}
+
+// decompressLayer returns the uncompressed tar stream of a layer, using the
+// compression named by the layer's media type. If the media type does not
+// name a compression, e.g. for Docker layers that may or may not be
+// compressed, the compression is detected from the content.
+func decompressLayer(ctx context.Context, layer ocispec.Descriptor, r io.Reader) (io.ReadCloser, error) {
+	c, err := images.DiffCompression(ctx, layer.MediaType)
+	if err != nil {
+		c = "unknown"
+	}
+	switch c {
+	case "gzip":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("decompress gzip layer %s: %v", layer.Digest, err)
+		}
+		return zr, nil
+	case "zstd":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("decompress zstd layer %s: %v", layer.Digest, err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return compression.DecompressStream(r)
+	}
+}
+
+// dropEstargzEntries filters out the eStargz metadata files, so that eStargz
+// layers unpack to the same content as plain gzip layers.
+func dropEstargzEntries(h *tar.Header) (bool, error) {
+	_, ok := estargzEntries[path.Clean(strings.TrimPrefix(h.Name, "./"))]
+	return !ok, nil
+}
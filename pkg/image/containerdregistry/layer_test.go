@@ -0,0 +1,54 @@
+package containerdregistry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func TestRegistry_UnpackCompressedLayers(t *testing.T) {
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir,
+		testLayoutImage{tag: "gzip", compression: "gzip", files: map[string]string{"manifests/csv.yaml": "gzip"}},
+		testLayoutImage{tag: "zstd", compression: "zstd", files: map[string]string{"manifests/csv.yaml": "zstd"}},
+		testLayoutImage{tag: "none", compression: "none", files: map[string]string{"manifests/csv.yaml": "none"}},
+		testLayoutImage{tag: "estargz", compression: "gzip", files: map[string]string{
+			"manifests/csv.yaml":      "estargz",
+			".prefetch.landmark":      "0",
+			"stargz.index.json":       "{}",
+			"./.no.prefetch.landmark": "0",
+		}},
+	)
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	ctx := context.Background()
+	for _, tag := range []string{"gzip", "zstd", "none", "estargz"} {
+		t.Run(tag, func(t *testing.T) {
+			ref := image.SimpleReference("oci-layout:" + layoutDir + ":" + tag)
+			require.NoError(t, reg.Pull(ctx, ref))
+
+			unpackDir := t.TempDir()
+			require.NoError(t, reg.Unpack(ctx, ref, unpackDir))
+			content, err := os.ReadFile(filepath.Join(unpackDir, "manifests", "csv.yaml"))
+			require.NoError(t, err)
+			require.Equal(t, tag, string(content))
+
+			// Only the image content is unpacked, without eStargz metadata.
+			entries, err := os.ReadDir(unpackDir)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			require.Equal(t, "manifests", entries[0].Name())
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package containerdregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/containerd/containerd/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// resolveOCILayout returns the descriptor of the image referenced by ref
+// within its OCI image layout directory, along with a fetcher that reads
+// content from the layout's blobs.
+func resolveOCILayout(ref *image.OCILayoutReference) (ocispec.Descriptor, remotes.Fetcher, error) {
+	layoutData, err := os.ReadFile(filepath.Join(ref.Dir, ocispec.ImageLayoutFile))
+	if err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("read OCI layout %q: %v", ref.Dir, err)
+	}
+	var layout ocispec.ImageLayout
+	if err := json.Unmarshal(layoutData, &layout); err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("parse OCI layout %q: %v", ref.Dir, err)
+	}
+	if layout.Version != ocispec.ImageLayoutVersion {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("unsupported OCI layout version %q in %q", layout.Version, ref.Dir)
+	}
+
+	indexData, err := os.ReadFile(filepath.Join(ref.Dir, ocispec.ImageIndexFile))
+	if err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("read OCI layout index: %v", err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("parse OCI layout index: %v", err)
+	}
+
+	var matches []ocispec.Descriptor
+	for _, desc := range index.Manifests {
+		switch {
+		case ref.Digest != "":
+			if desc.Digest == ref.Digest {
+				matches = append(matches, desc)
+			}
+		case ref.Tag != "":
+			if desc.Annotations[ocispec.AnnotationRefName] == ref.Tag {
+				matches = append(matches, desc)
+			}
+		default:
+			matches = append(matches, desc)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], ociLayoutFetcher{dir: ref.Dir}, nil
+	case len(matches) == 0:
+		return ocispec.Descriptor{}, nil, fmt.Errorf("image %q not found in OCI layout %q", ref, ref.Dir)
+	case ref.Tag != "":
+		return ocispec.Descriptor{}, nil, fmt.Errorf("tag %q refers to more than one image in OCI layout %q", ref.Tag, ref.Dir)
+	default:
+		var tags []string
+		for _, desc := range matches {
+			if tag, ok := desc.Annotations[ocispec.AnnotationRefName]; ok {
+				tags = append(tags, tag)
+			}
+		}
+		sort.Strings(tags)
+		return ocispec.Descriptor{}, nil, fmt.Errorf("OCI layout %q contains %d images, a tag or digest must be specified (tags: %s)", ref.Dir, len(matches), strings.Join(tags, ", "))
+	}
+}
+
+// ociLayoutFetcher fetches content from the blobs directory of an OCI image
+// layout.
+type ociLayoutFetcher struct {
+	dir string
+}
+
+var _ remotes.Fetcher = ociLayoutFetcher{}
+
+func (f ociLayoutFetcher) Fetch(_ context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(f.dir, ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded()))
+}
@@ -0,0 +1,220 @@
+package containerdregistry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+// testLayoutImage is an image with a single layer, to be written to an OCI
+// image layout.
+type testLayoutImage struct {
+	tag    string
+	labels map[string]string
+	files  map[string]string
+
+	// compression of the layer: "gzip" (the default), "zstd", or "none".
+	compression string
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func writeTestOCILayout(t *testing.T, dir string, images ...testLayoutImage) {
+	t.Helper()
+	writeBlob := func(mediaType string, data []byte) ocispec.Descriptor {
+		dgst := digest.FromBytes(data)
+		blobDir := filepath.Join(dir, ocispec.ImageBlobsDir, dgst.Algorithm().String())
+		require.NoError(t, os.MkdirAll(blobDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(blobDir, dgst.Encoded()), data, 0644))
+		return ocispec.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(data))}
+	}
+	writeJSONBlob := func(mediaType string, v interface{}) ocispec.Descriptor {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return writeBlob(mediaType, data)
+	}
+
+	index := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: ocispec.MediaTypeImageIndex}
+	for _, img := range images {
+		var layer bytes.Buffer
+		var (
+			cw        io.WriteCloser
+			mediaType string
+		)
+		switch img.compression {
+		case "", "gzip":
+			cw, mediaType = gzip.NewWriter(&layer), ocispec.MediaTypeImageLayerGzip
+		case "zstd":
+			zw, err := zstd.NewWriter(&layer)
+			require.NoError(t, err)
+			cw, mediaType = zw, ocispec.MediaTypeImageLayerZstd
+		case "none":
+			cw, mediaType = nopWriteCloser{&layer}, ocispec.MediaTypeImageLayer
+		default:
+			t.Fatalf("unknown layer compression %q", img.compression)
+		}
+		tw := tar.NewWriter(cw)
+		for name, content := range img.files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, cw.Close())
+		layerDesc := writeBlob(mediaType, layer.Bytes())
+
+		configDesc := writeJSONBlob(ocispec.MediaTypeImageConfig, ocispec.Image{
+			Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"},
+			Config:   ocispec.ImageConfig{Labels: img.labels},
+			RootFS:   ocispec.RootFS{Type: "layers"},
+		})
+		manifestDesc := writeJSONBlob(ocispec.MediaTypeImageManifest, ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    configDesc,
+			Layers:    []ocispec.Descriptor{layerDesc},
+		})
+		if img.tag != "" {
+			manifestDesc.Annotations = map[string]string{ocispec.AnnotationRefName: img.tag}
+		}
+		index.Manifests = append(index.Manifests, manifestDesc)
+	}
+
+	indexData, err := json.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ocispec.ImageIndexFile), indexData, 0644))
+	layoutData, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ocispec.ImageLayoutFile), layoutData, 0644))
+}
+
+func TestRegistry_OCILayout(t *testing.T) {
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir,
+		testLayoutImage{
+			tag:    "v1",
+			labels: map[string]string{"version": "v1"},
+			files:  map[string]string{"configs/catalog.yaml": "v1"},
+		},
+		testLayoutImage{
+			tag:    "v2",
+			labels: map[string]string{"version": "v2"},
+			files:  map[string]string{"configs/catalog.yaml": "v2"},
+		},
+	)
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	ctx := context.Background()
+	for _, tag := range []string{"v1", "v2"} {
+		t.Run(tag, func(t *testing.T) {
+			ref := image.SimpleReference("oci-layout:" + layoutDir + ":" + tag)
+			require.NoError(t, reg.Pull(ctx, ref))
+
+			labels, err := reg.Labels(ctx, ref)
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"version": tag}, labels)
+
+			layers, err := reg.Layers(ctx, ref)
+			require.NoError(t, err)
+			require.Len(t, layers, 1)
+			require.NotZero(t, layers[0].Size)
+
+			unpackDir := t.TempDir()
+			require.NoError(t, reg.Unpack(ctx, ref, unpackDir))
+			content, err := os.ReadFile(filepath.Join(unpackDir, "configs", "catalog.yaml"))
+			require.NoError(t, err)
+			require.Equal(t, tag, string(content))
+		})
+	}
+
+	t.Run("Error/AmbiguousReference", func(t *testing.T) {
+		err := reg.Pull(ctx, image.SimpleReference("oci-layout:"+layoutDir))
+		require.ErrorContains(t, err, "a tag or digest must be specified (tags: v1, v2)")
+	})
+	t.Run("Error/UnknownTag", func(t *testing.T) {
+		err := reg.Pull(ctx, image.SimpleReference("oci-layout:"+layoutDir+":v3"))
+		require.ErrorContains(t, err, "not found")
+	})
+	t.Run("Error/NotALayout", func(t *testing.T) {
+		err := reg.Pull(ctx, image.SimpleReference("oci-layout:"+t.TempDir()))
+		require.Error(t, err)
+	})
+}
+
+func TestRegistry_Platforms(t *testing.T) {
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir,
+		testLayoutImage{tag: "amd64", files: map[string]string{"bin": "amd64"}},
+		testLayoutImage{tag: "arm64", files: map[string]string{"bin": "arm64"}},
+	)
+
+	// Add a multi-arch image, whose index lists both images, and an
+	// attestation manifest that is not an image of any platform.
+	indexPath := filepath.Join(layoutDir, ocispec.ImageIndexFile)
+	data, err := os.ReadFile(indexPath)
+	require.NoError(t, err)
+	var layout ocispec.Index
+	require.NoError(t, json.Unmarshal(data, &layout))
+	multi := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: ocispec.MediaTypeImageIndex}
+	for _, desc := range layout.Manifests {
+		desc.Platform = &ocispec.Platform{OS: "linux", Architecture: desc.Annotations[ocispec.AnnotationRefName]}
+		desc.Annotations = nil
+		multi.Manifests = append(multi.Manifests, desc)
+	}
+	attestation := layout.Manifests[0]
+	attestation.Platform = &ocispec.Platform{OS: "unknown", Architecture: "unknown"}
+	attestation.Annotations = nil
+	multi.Manifests = append(multi.Manifests, attestation)
+	data, err = json.Marshal(multi)
+	require.NoError(t, err)
+	dgst := digest.FromBytes(data)
+	require.NoError(t, os.WriteFile(filepath.Join(layoutDir, ocispec.ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded()), data, 0644))
+	layout.Manifests = append(layout.Manifests, ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageIndex,
+		Digest:      dgst,
+		Size:        int64(len(data)),
+		Annotations: map[string]string{ocispec.AnnotationRefName: "multi"},
+	})
+	data, err = json.Marshal(layout)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(indexPath, data, 0644))
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	ctx := context.Background()
+	platforms, err := reg.Platforms(ctx, image.SimpleReference("oci-layout:"+layoutDir+":amd64"))
+	require.NoError(t, err)
+	require.Equal(t, []ocispec.Platform{{OS: "linux", Architecture: "amd64"}}, platforms)
+
+	platforms, err = reg.Platforms(ctx, image.SimpleReference("oci-layout:"+layoutDir+":multi"))
+	require.NoError(t, err)
+	require.Equal(t, []ocispec.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}}, platforms)
+}
@@ -3,6 +3,7 @@ package containerdregistry
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
@@ -17,6 +18,9 @@ import (
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/certs"
 )
 
 type RegistryConfig struct {
@@ -25,9 +29,15 @@ type RegistryConfig struct {
 	DBPath            string
 	CacheDir          string
 	PreserveCache     bool
+	BlobCacheDir      string
+	BlobCacheGC       BlobCacheGCPolicy
 	SkipTLSVerify     bool
 	PlainHTTP         bool
 	Roots             *x509.CertPool
+	CAFile            string
+	RegistriesConf    string
+	Retry             RetryPolicy
+	PullTimeout       time.Duration
 }
 
 func (r *RegistryConfig) apply(options []RegistryOption) {
@@ -45,14 +55,34 @@ func (r *RegistryConfig) complete() error {
 		r.DBPath = filepath.Join(r.CacheDir, "metadata.db")
 	}
 
+	if r.CAFile != "" {
+		roots, err := certs.RootCAs(r.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to get RootCAs: %v", err)
+		}
+		r.Roots = roots
+	}
+
 	return nil
 }
 
+// clientConfig returns the image client configuration that r sets.
+func (r *RegistryConfig) clientConfig() image.ClientConfig {
+	return image.ClientConfig{
+		CAFile:             r.CAFile,
+		SkipTLSVerify:      r.SkipTLSVerify,
+		PlainHTTP:          r.PlainHTTP,
+		RegistriesConfPath: r.RegistriesConf,
+	}
+}
+
 func defaultConfig() *RegistryConfig {
 	config := &RegistryConfig{
 		Log:               logrus.NewEntry(logrus.New()),
 		ResolverConfigDir: "",
 		CacheDir:          "cache",
+		BlobCacheGC:       DefaultBlobCacheGCPolicy,
+		Retry:             DefaultRetryPolicy,
 	}
 
 	return config
@@ -72,6 +102,14 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 		return
 	}
 
+	var blobCache *BlobCache
+	if config.BlobCacheDir != "" {
+		blobCache, err = NewBlobCache(config.BlobCacheDir)
+		if err != nil {
+			return
+		}
+	}
+
 	var bdb *bolt.DB
 	bdb, err = bolt.Open(config.DBPath, 0644, nil)
 	if err != nil {
@@ -84,6 +122,11 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 			if destroyErr = bdb.Close(); destroyErr != nil {
 				return
 			}
+			if blobCache != nil {
+				if err := blobCache.GC(config.BlobCacheGC); err != nil {
+					config.Log.WithError(err).Warn("unable to garbage collect blob cache")
+				}
+			}
 			if config.PreserveCache {
 				return
 			}
@@ -95,17 +138,34 @@ func NewRegistry(options ...RegistryOption) (registry *Registry, err error) {
 	}
 
 	httpClient := newClient(config.SkipTLSVerify, config.Roots)
+	// Mirrors that registries.conf marks as insecure are reached without
+	// verifying their certificates.
+	insecureClient := newClient(true, nil)
+	if config.Retry.MaxRetries > 0 {
+		httpClient.Transport = &retryTransport{base: httpClient.Transport, policy: config.Retry, log: config.Log}
+		insecureClient.Transport = &retryTransport{base: insecureClient.Transport, policy: config.Retry, log: config.Log}
+	}
+	// The resolvers of the registry share the credentials of the auth file,
+	// which are reloaded when it changes.
+	creds := newCredentialStore(config.ResolverConfigDir)
 	registry = &Registry{
 		Store:   newStore(metadata.NewDB(bdb, cs, nil)),
 		destroy: destroy,
 		log:     config.Log,
-		resolverFunc: func(repo string) (remotes.Resolver, error) {
-			return NewResolver(httpClient, config.ResolverConfigDir, config.PlainHTTP, repo)
+		resolverFunc: func(repo string, insecure bool) (remotes.Resolver, error) {
+			if insecure {
+				return newResolver(insecureClient, creds, config.PlainHTTP, repo)
+			}
+			return newResolver(httpClient, creds, config.PlainHTTP, repo)
 		},
+		pullSources: config.clientConfig().PullSources,
 		platform: platforms.Ordered(platforms.DefaultSpec(), specs.Platform{
 			OS:           "linux",
 			Architecture: "amd64",
 		}),
+		blobCache:   blobCache,
+		retry:       config.Retry,
+		pullTimeout: config.PullTimeout,
 	}
 	return
 }
@@ -130,6 +190,53 @@ func WithCacheDir(dir string) RegistryOption {
 	}
 }
 
+// WithBlobCacheDir enables a persistent cache of image blobs in dir, which is
+// kept when the registry is destroyed, so that it can be shared with other
+// registries, including those of other processes. Blobs that are in the cache
+// are not fetched from their registry again.
+func WithBlobCacheDir(dir string) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.BlobCacheDir = dir
+	}
+}
+
+// WithBlobCacheGCPolicy sets the policy used to garbage collect the blob cache
+// when the registry is destroyed. It defaults to DefaultBlobCacheGCPolicy.
+func WithBlobCacheGCPolicy(policy BlobCacheGCPolicy) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.BlobCacheGC = policy
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed registry requests,
+// resume interrupted blob downloads and retry failed pulls. It defaults to
+// DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.Retry = policy
+	}
+}
+
+// WithPullTimeout bounds the time that a pull may take, including all of
+// its retries. Zero, the default, means no bound.
+func WithPullTimeout(timeout time.Duration) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.PullTimeout = timeout
+	}
+}
+
+// WithClientConfig configures the registry with the image client
+// configuration that is shared with the other image.Registry implementations.
+// Its CA file replaces the root CAs of WithRootCAs.
+func WithClientConfig(cfg image.ClientConfig) RegistryOption {
+	return func(config *RegistryConfig) {
+		config.SkipTLSVerify = cfg.SkipTLSVerify
+		config.PlainHTTP = cfg.PlainHTTP
+		config.CAFile = cfg.CAFile
+		config.RegistriesConf = cfg.RegistriesConfPath
+	}
+}
+
 func WithRootCAs(pool *x509.CertPool) RegistryOption {
 	return func(config *RegistryConfig) {
 		config.Roots = pool
@@ -0,0 +1,257 @@
+package containerdregistry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// ociLayerMediaTypes maps the media types of Docker image layers to their
+// OCI equivalents, which have the same content.
+var ociLayerMediaTypes = map[string]string{
+	images.MediaTypeDockerSchema2Layer:     ocispec.MediaTypeImageLayer,
+	images.MediaTypeDockerSchema2LayerGzip: ocispec.MediaTypeImageLayerGzip,
+}
+
+// Pack creates and stores an image named ref, that has the layers and
+// configuration of the image from, plus a layer with the contents of dir and
+// the given labels. The image from must have been pulled. If from is nil, the
+// image is created from scratch.
+//
+// Whiteout files in dir, e.g. an opaque whiteout named ".wh..wh..opq", are
+// added to the layer as they are, so that dir can remove the files of from.
+// If from is a multi-platform image, the packed image only has the platform
+// of the registry.
+func (r *Registry) Pack(ctx context.Context, ref, from image.Reference, dir string, labels map[string]string) (err error) {
+	ctx, span := startSpan(ctx, "Pack", ref)
+	defer func() { endSpan(span, err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	config := ocispec.Image{
+		Platform: ocispec.Platform{OS: "linux", Architecture: runtime.GOARCH},
+		RootFS:   ocispec.RootFS{Type: "layers"},
+	}
+	var layers []ocispec.Descriptor
+	if from != nil {
+		span.SetAttributes(attribute.String("image.from", from.String()))
+		manifest, err := r.getManifest(ctx, from)
+		if err != nil {
+			return fmt.Errorf("get manifest of %s: %v", from, err)
+		}
+		data, err := content.ReadBlob(ctx, r.Content(), manifest.Config)
+		if err != nil {
+			return fmt.Errorf("read config of %s: %v", from, err)
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("parse config of %s: %v", from, err)
+		}
+		for _, l := range manifest.Layers {
+			if mediaType, ok := ociLayerMediaTypes[l.MediaType]; ok {
+				l.MediaType = mediaType
+			}
+			layers = append(layers, l)
+		}
+	}
+
+	layer, diffID, err := r.writeLayer(ctx, ref, dir)
+	if err != nil {
+		return fmt.Errorf("write layer: %v", err)
+	}
+	layers = append(layers, layer)
+
+	created := time.Now().UTC()
+	config.Created = &created
+	config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+	config.History = append(config.History, ocispec.History{Created: &created, CreatedBy: "opm"})
+	if len(labels) > 0 && config.Config.Labels == nil {
+		config.Config.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		config.Config.Labels[k] = v
+	}
+	configDesc, err := r.writeJSON(ctx, ref, ocispec.MediaTypeImageConfig, config)
+	if err != nil {
+		return fmt.Errorf("write config: %v", err)
+	}
+
+	manifestDesc, err := r.writeJSON(ctx, ref, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    layers,
+	})
+	if err != nil {
+		return fmt.Errorf("write manifest: %v", err)
+	}
+	span.SetAttributes(attribute.String("image.digest", manifestDesc.Digest.String()))
+
+	img := images.Image{
+		Name:   ref.String(),
+		Target: manifestDesc,
+	}
+	if _, err = r.Images().Create(ctx, img); err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			_, err = r.Images().Update(ctx, img)
+		}
+	}
+	return err
+}
+
+// Push uploads an image that has been pulled or packed to the remote
+// registry of its reference.
+func (r *Registry) Push(ctx context.Context, ref image.Reference) (err error) {
+	ctx, span := startSpan(ctx, "Push", ref)
+	defer func() { endSpan(span, err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	img, err := r.Images().Get(ctx, ref.String())
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("image.digest", img.Target.Digest.String()))
+
+	namedRef, err := reference.ParseNamed(ref.String())
+	if err != nil {
+		return err
+	}
+	resolver, err := r.resolverFunc(namedRef.Name(), false)
+	if err != nil {
+		return err
+	}
+	pusher, err := resolver.Pusher(ctx, ref.String())
+	if err != nil {
+		return err
+	}
+	return remotes.PushContent(ctx, pusher, img.Target, r.Content(), nil, r.platform, nil)
+}
+
+// writeLayer stores a gzipped tar archive of the contents of dir, and returns
+// its descriptor and the digest of the uncompressed archive. Entries are
+// written in lexical order, owned by root and with the modification time
+// of the Unix epoch, so that the same contents always result in the same
+// layer.
+func (r *Registry) writeLayer(ctx context.Context, ref image.Reference, dir string) (ocispec.Descriptor, digest.Digest, error) {
+	tmp, err := os.CreateTemp("", "opm-layer-")
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	compressed := digest.Canonical.Digester()
+	uncompressed := digest.Canonical.Digester()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, compressed.Hash()))
+	tw := tar.NewWriter(io.MultiWriter(gz, uncompressed.Hash()))
+	if err := writeTar(tw, dir); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if err := tw.Close(); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    compressed.Digest(),
+		Size:      size,
+	}
+	if err := content.WriteBlob(ctx, r.Content(), ref.String()+"-layer", tmp, desc); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	return desc, uncompressed.Digest(), nil
+}
+
+func writeTar(tw *tar.Writer, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		hdr.ModTime, hdr.AccessTime, hdr.ChangeTime = time.Unix(0, 0), time.Time{}, time.Time{}
+		hdr.Format = tar.FormatPAX
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+func (r *Registry) writeJSON(ctx context.Context, ref image.Reference, mediaType string, v interface{}) (ocispec.Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	return desc, content.WriteBlob(ctx, r.Content(), ref.String()+"-"+desc.Digest.String(), bytes.NewReader(data), desc)
+}
@@ -0,0 +1,160 @@
+package containerdregistry
+
+import (
+	"context"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	libimage "github.com/operator-framework/operator-registry/pkg/lib/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func writePackDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestRegistry_Pack(t *testing.T) {
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir, testLayoutImage{
+		tag:    "v1",
+		labels: map[string]string{"version": "v1", "kept": "true"},
+		files:  map[string]string{"configs/a.yaml": "a", "configs/b.yaml": "b", "bin/opm": "opm"},
+	})
+	from := image.SimpleReference("oci-layout:" + layoutDir + ":v1")
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+	ctx := context.Background()
+	require.NoError(t, reg.Pull(ctx, from))
+
+	type spec struct {
+		name       string
+		from       image.Reference
+		files      map[string]string
+		wantFiles  map[string]string
+		wantLabels map[string]string
+	}
+	for _, s := range []spec{
+		{
+			name:       "AddFiles",
+			from:       from,
+			files:      map[string]string{"configs/c.yaml": "c"},
+			wantFiles:  map[string]string{"configs/a.yaml": "a", "configs/b.yaml": "b", "configs/c.yaml": "c", "bin/opm": "opm"},
+			wantLabels: map[string]string{"version": "v2", "kept": "true"},
+		},
+		{
+			name:       "ReplaceDirectory",
+			from:       from,
+			files:      map[string]string{"configs/" + ".wh..wh..opq": "", "configs/c.yaml": "c"},
+			wantFiles:  map[string]string{"configs/c.yaml": "c", "bin/opm": "opm"},
+			wantLabels: map[string]string{"version": "v2", "kept": "true"},
+		},
+		{
+			name:       "Scratch",
+			files:      map[string]string{"configs/c.yaml": "c"},
+			wantFiles:  map[string]string{"configs/c.yaml": "c"},
+			wantLabels: map[string]string{"version": "v2"},
+		},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			ref := image.SimpleReference("packed/" + s.name + ":v2")
+			require.NoError(t, reg.Pack(ctx, ref, s.from, writePackDir(t, s.files), map[string]string{"version": "v2"}))
+
+			labels, err := reg.Labels(ctx, ref)
+			require.NoError(t, err)
+			require.Equal(t, s.wantLabels, labels)
+
+			dir := t.TempDir()
+			require.NoError(t, reg.Unpack(ctx, ref, dir))
+			files := map[string]string{}
+			require.NoError(t, filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				files[filepath.ToSlash(rel)] = string(data)
+				return err
+			}))
+			require.Equal(t, s.wantFiles, files)
+		})
+	}
+
+	t.Run("Reproducible", func(t *testing.T) {
+		dir := writePackDir(t, map[string]string{"configs/c.yaml": "c"})
+		digests := map[string]bool{}
+		for _, name := range []string{"first", "second"} {
+			ref := image.SimpleReference("packed/" + name + ":v2")
+			require.NoError(t, reg.Pack(ctx, ref, from, dir, nil))
+			manifest, err := reg.getManifest(ensureNamespace(ctx), ref)
+			require.NoError(t, err)
+			digests[manifest.Layers[len(manifest.Layers)-1].Digest.String()] = true
+		}
+		require.Len(t, digests, 1)
+	})
+}
+
+func TestRegistry_PackAndPush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	host, cafile, err := libimage.RunDockerRegistry(ctx, "")
+	require.NoError(t, err)
+	// The in-process registry installs its own global TracerProvider, which
+	// would otherwise record the spans of this and every later test.
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	caData, err := os.ReadFile(cafile)
+	require.NoError(t, err)
+	rootCAs := x509.NewCertPool()
+	require.True(t, rootCAs.AppendCertsFromPEM(caData))
+
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir, testLayoutImage{
+		tag:   "v1",
+		files: map[string]string{"configs/a.yaml": "a"},
+	})
+	from := image.SimpleReference("oci-layout:" + layoutDir + ":v1")
+	ref := image.SimpleReference(host + "/test/index:v2")
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()), WithRootCAs(rootCAs))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+	require.NoError(t, reg.Pull(ctx, from))
+	require.NoError(t, reg.Pack(ctx, ref, from, writePackDir(t, map[string]string{"configs/b.yaml": "b"}), map[string]string{"version": "v2"}))
+	require.NoError(t, reg.Push(ctx, ref))
+
+	pulled, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()), WithRootCAs(rootCAs))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, pulled.Destroy())
+	}()
+	require.NoError(t, pulled.Pull(ctx, ref))
+	labels, err := pulled.Labels(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"version": "v2"}, labels)
+	dir := t.TempDir()
+	require.NoError(t, pulled.Unpack(ctx, ref, dir))
+	require.FileExists(t, filepath.Join(dir, "configs", "a.yaml"))
+	require.FileExists(t, filepath.Join(dir, "configs", "b.yaml"))
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,16 +15,20 @@ import (
 
 	"github.com/containerd/containerd/archive"
 	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/containerd/platforms"
 	"github.com/containerd/containerd/remotes"
 	"github.com/containers/image/v5/docker/reference"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/client-go/util/retry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/operator-framework/operator-registry/pkg/image"
 )
@@ -33,76 +38,262 @@ type Registry struct {
 	Store
 	destroy      func() error
 	log          *logrus.Entry
-	resolverFunc func(repo string) (remotes.Resolver, error)
+	resolverFunc func(repo string, insecure bool) (remotes.Resolver, error)
+	pullSources  func(ref reference.Named) ([]image.PullSource, error)
 	platform     platforms.MatchComparer
+	blobCache    *BlobCache
+	retry        RetryPolicy
+	pullTimeout  time.Duration
 }
 
-var _ image.Registry = &Registry{}
+var (
+	_ image.Registry    = &Registry{}
+	_ image.LayerReader = &Registry{}
+)
+
+const tracerName = "github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+
+// startSpan starts a span of an operation on the image that ref refers to.
+// The tracer is resolved from the global TracerProvider on each call, so that
+// spans are recorded by the provider that is current when they start.
+func startSpan(ctx context.Context, name string, ref image.Reference, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(append([]attribute.KeyValue{attribute.String("image.ref", ref.String())}, attrs...)...))
+}
+
+// endSpan records err, if any, as the outcome of span, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
 
 var nonRetriablePullError = regexp.MustCompile("specified image is a docker schema v1 manifest, which is not supported")
 
 // Pull fetches and stores an image by reference.
-func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
+func (r *Registry) Pull(ctx context.Context, ref image.Reference) (err error) {
+	ctx, span := startSpan(ctx, "Pull", ref)
+	defer func() { endSpan(span, err) }()
+
 	// Set the default namespace if unset
 	ctx = ensureNamespace(ctx)
 
-	namedRef, err := reference.ParseNamed(ref.String())
+	if r.pullTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.pullTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	root, fetcher, err := r.resolve(ctx, ref)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attribute.String("image.digest", root.Digest.String()))
+	log := r.log.WithFields(logrus.Fields{"image": ref.String(), "digest": root.Digest.String()})
 
-	resolver, err := r.resolverFunc(namedRef.Name())
+	for attempt := 0; ; attempt++ {
+		pullErr := r.fetch(ctx, ref, fetcher, root)
+		if pullErr == nil {
+			break
+		}
+		if attempt == r.retry.MaxRetries || ctx.Err() != nil || nonRetriablePullError.MatchString(pullErr.Error()) {
+			return pullErr
+		}
+		log.WithError(pullErr).Warn("error pulling image, retrying")
+		if err := r.retry.wait(ctx, attempt); err != nil {
+			return pullErr
+		}
+	}
+
+	img := images.Image{
+		Name:   ref.String(),
+		Target: root,
+	}
+	if _, err = r.Images().Create(ctx, img); err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			_, err = r.Images().Update(ctx, img)
+		}
+	}
+	if err == nil {
+		log.WithField("duration", time.Since(start).String()).Debug("pulled image")
+	}
+
+	return err
+}
+
+// Digest returns the digest of the image that ref currently refers to,
+// without pulling it.
+func (r *Registry) Digest(ctx context.Context, ref image.Reference) (digest.Digest, error) {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	root, _, err := r.resolve(ctx, ref)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return root.Digest, nil
+}
+
+// Platforms returns the platforms that the image that ref refers to provides,
+// without pulling it: those of the manifests of an image index, or the
+// platform in the config of a single image manifest. The manifests of an
+// index that do not have a runnable platform, such as attestations, are
+// skipped.
+func (r *Registry) Platforms(ctx context.Context, ref image.Reference) ([]ocispec.Platform, error) {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
 
-	name, root, err := resolver.Resolve(ctx, ref.String())
+	root, fetcher, err := r.resolve(ctx, ref)
 	if err != nil {
-		return fmt.Errorf("error resolving name for image ref %s: %v", ref.String(), err)
+		return nil, err
 	}
-	r.log.Debugf("resolved name: %s", name)
+	switch root.MediaType {
+	case images.MediaTypeDockerSchema2ManifestList, ocispec.MediaTypeImageIndex:
+		var index ocispec.Index
+		if err := fetchJSON(ctx, fetcher, root, &index); err != nil {
+			return nil, err
+		}
+		var ps []ocispec.Platform
+		for _, m := range index.Manifests {
+			if m.Platform == nil || m.Platform.OS == "unknown" {
+				continue
+			}
+			ps = append(ps, *m.Platform)
+		}
+		return ps, nil
+	case images.MediaTypeDockerSchema2Manifest, ocispec.MediaTypeImageManifest:
+		var manifest ocispec.Manifest
+		if err := fetchJSON(ctx, fetcher, root, &manifest); err != nil {
+			return nil, err
+		}
+		var img ocispec.Image
+		if err := fetchJSON(ctx, fetcher, manifest.Config, &img); err != nil {
+			return nil, err
+		}
+		return []ocispec.Platform{img.Platform}, nil
+	default:
+		return nil, fmt.Errorf("unsupported media type %q of image %q", root.MediaType, ref)
+	}
+}
 
-	fetcher, err := resolver.Fetcher(ctx, name)
+func fetchJSON(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor, v interface{}) error {
+	rc, err := fetcher.Fetch(ctx, desc)
 	if err != nil {
 		return err
 	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
 
-	retryBackoff := wait.Backoff{
-		Duration: 1 * time.Second,
-		Factor:   1.0,
-		Jitter:   0.1,
-		Steps:    5,
+// resolve returns the root descriptor of the referenced image and a fetcher
+// for its content. References to images in OCI image layout directories are
+// read from the local filesystem, and all others from their remote registry.
+func (r *Registry) resolve(ctx context.Context, ref image.Reference) (root ocispec.Descriptor, fetcher remotes.Fetcher, err error) {
+	ctx, span := startSpan(ctx, "resolve", ref)
+	defer func() { endSpan(span, err) }()
+
+	if image.IsOCILayoutReference(ref.String()) {
+		layoutRef, err := image.ParseOCILayoutReference(ref.String())
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		return resolveOCILayout(layoutRef)
 	}
 
-	if err := retry.OnError(retryBackoff,
-		func(pullErr error) bool {
-			if nonRetriablePullError.MatchString(pullErr.Error()) {
-				return false
-			}
-			r.log.Warnf("Error pulling image %q: %v. Retrying", ref.String(), pullErr)
-			return true
-		},
-		func() error { return r.fetch(ctx, fetcher, root) },
-	); err != nil {
-		return err
+	namedRef, err := reference.ParseNamed(ref.String())
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
 	}
 
-	img := images.Image{
-		Name:   ref.String(),
-		Target: root,
+	sources, err := r.pullSources(namedRef)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
 	}
-	if _, err = r.Images().Create(ctx, img); err != nil {
-		if errdefs.IsAlreadyExists(err) {
-			_, err = r.Images().Update(ctx, img)
+
+	// The content of an image referenced by digest cannot change, so its
+	// cached descriptor can be used without resolving the reference again.
+	canonical, isCanonical := namedRef.(reference.Canonical)
+	if r.blobCache != nil && isCanonical {
+		if root, ok := r.blobCache.descriptor(canonical.Digest()); ok {
+			r.log.WithField("digest", root.Digest).Debug("using cached descriptor")
+			return root, newCachingFetcher(r.blobCache, func() (remotes.Fetcher, error) {
+				fetcher, err := r.sourceFetcher(ctx, sources)
+				if err != nil {
+					return nil, err
+				}
+				return newResumingFetcher(fetcher, r.retry, r.log), nil
+			}), nil
 		}
 	}
 
-	return err
+	name, root, fetcher, err := r.resolveSources(ctx, sources)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	r.log.Debugf("resolved name: %s", name)
+
+	fetcher = newResumingFetcher(fetcher, r.retry, r.log)
+	if r.blobCache == nil {
+		return root, fetcher, nil
+	}
+	if isCanonical && root.Digest == canonical.Digest() {
+		if err := r.blobCache.addDescriptor(root); err != nil {
+			r.log.WithError(err).Warn("unable to cache image descriptor")
+		}
+	}
+	return root, newCachingFetcher(r.blobCache, func() (remotes.Fetcher, error) {
+		return fetcher, nil
+	}), nil
+}
+
+// resolveSources resolves the first of sources that can be resolved, trying
+// them in order, and returns its name, root descriptor and a fetcher for its
+// content.
+func (r *Registry) resolveSources(ctx context.Context, sources []image.PullSource) (string, ocispec.Descriptor, remotes.Fetcher, error) {
+	var errs []error
+	for _, src := range sources {
+		resolver, err := r.resolverFunc(src.Ref.Name(), src.Insecure)
+		if err != nil {
+			return "", ocispec.Descriptor{}, nil, err
+		}
+		name, root, err := resolver.Resolve(ctx, src.Ref.String())
+		if err != nil {
+			r.log.WithError(err).Debugf("unable to resolve %s", src.Ref)
+			errs = append(errs, fmt.Errorf("error resolving name for image ref %s: %v", src.Ref, err))
+			continue
+		}
+		fetcher, err := resolver.Fetcher(ctx, name)
+		if err != nil {
+			return "", ocispec.Descriptor{}, nil, err
+		}
+		return name, root, fetcher, nil
+	}
+	return "", ocispec.Descriptor{}, nil, errors.Join(errs...)
+}
+
+// sourceFetcher returns a fetcher for the content of an image whose root
+// descriptor is already known. The image is only resolved again if it has
+// mirrors, to find one that has it.
+func (r *Registry) sourceFetcher(ctx context.Context, sources []image.PullSource) (remotes.Fetcher, error) {
+	if len(sources) > 1 {
+		_, _, fetcher, err := r.resolveSources(ctx, sources)
+		return fetcher, err
+	}
+	resolver, err := r.resolverFunc(sources[0].Ref.Name(), sources[0].Insecure)
+	if err != nil {
+		return nil, err
+	}
+	return resolver.Fetcher(ctx, sources[0].Ref.String())
 }
 
 // Unpack writes the unpackaged content of an image to a directory.
 // If the referenced image does not exist in the registry, an error is returned.
-func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) error {
+func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string) (err error) {
+	ctx, span := startSpan(ctx, "Unpack", ref)
+	defer func() { endSpan(span, err) }()
+
 	// Set the default namespace if unset
 	ctx = ensureNamespace(ctx)
 
@@ -117,7 +308,7 @@ func (r *Registry) Unpack(ctx context.Context, ref image.Reference, dir string)
 
 	for _, layer := range manifest.Layers {
 		r.log.Debugf("unpacking layer: %v", layer)
-		if err := r.unpackLayer(ctx, layer, dir); err != nil {
+		if err := r.unpackLayer(ctx, ref, layer, dir); err != nil {
 			return err
 		}
 	}
@@ -142,7 +333,95 @@ func (r *Registry) Labels(ctx context.Context, ref image.Reference) (map[string]
 	return imageConfig.Config.Labels, nil
 }
 
-// Destroy cleans up the on-disk boltdb file and other cache files, unless preserve cache is true
+// Config gets the configuration of an image that is already stored, e.g.
+// its entrypoint.
+func (r *Registry) Config(ctx context.Context, ref image.Reference) (*ocispec.ImageConfig, error) {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	manifest, err := r.getManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	data, err := content.ReadBlob(ctx, r.Content(), manifest.Config)
+	if err != nil {
+		return nil, err
+	}
+	var img ocispec.Image
+	if err := json.Unmarshal(data, &img); err != nil {
+		return nil, err
+	}
+	return &img.Config, nil
+}
+
+// Layers gets the descriptors of the layers of an image that is already
+// stored, in the order they are applied.
+func (r *Registry) Layers(ctx context.Context, ref image.Reference) ([]ocispec.Descriptor, error) {
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	manifest, err := r.getManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Layers, nil
+}
+
+// ReadLayers calls fn with each entry of the layers of an image that is
+// already stored, in the order they are applied. The eStargz metadata entries
+// of layers are skipped, like they are when the image is unpacked.
+func (r *Registry) ReadLayers(ctx context.Context, ref image.Reference, fn func(layer int, hdr *tar.Header, r io.Reader) error) (err error) {
+	ctx, span := startSpan(ctx, "ReadLayers", ref)
+	defer func() { endSpan(span, err) }()
+
+	// Set the default namespace if unset
+	ctx = ensureNamespace(ctx)
+
+	manifest, err := r.getManifest(ctx, ref)
+	if err != nil {
+		return err
+	}
+	for i, layer := range manifest.Layers {
+		if err := r.readLayer(ctx, layer, func(hdr *tar.Header, r io.Reader) error { return fn(i, hdr, r) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) readLayer(ctx context.Context, layer ocispec.Descriptor, fn func(hdr *tar.Header, r io.Reader) error) error {
+	ra, err := r.Content().ReaderAt(ctx, layer)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+
+	decompressed, err := decompressLayer(ctx, layer, io.NewSectionReader(ra, 0, ra.Size()))
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	tr := tar.NewReader(decompressed)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read layer %s: %v", layer.Digest, err)
+		}
+		if ok, _ := dropEstargzEntries(hdr); !ok {
+			continue
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// Destroy cleans up the on-disk boltdb file and other cache files, unless preserve cache is true.
+// If the registry uses a blob cache, the cache is garbage collected.
 func (r *Registry) Destroy() (err error) {
 	return r.destroy()
 }
@@ -185,7 +464,10 @@ func (r *Registry) getImage(ctx context.Context, manifest ocispec.Manifest) (*oc
 	return &imageConfig, nil
 }
 
-func (r *Registry) fetch(ctx context.Context, fetcher remotes.Fetcher, root ocispec.Descriptor) error {
+func (r *Registry) fetch(ctx context.Context, ref image.Reference, fetcher remotes.Fetcher, root ocispec.Descriptor) (err error) {
+	ctx, span := startSpan(ctx, "fetch", ref)
+	defer func() { endSpan(span, err) }()
+
 	visitor := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
 		r.log.WithField("digest", desc.Digest).Debug("fetched")
 		r.log.Debug(desc)
@@ -198,14 +480,35 @@ func (r *Registry) fetch(ctx context.Context, fetcher remotes.Fetcher, root ocis
 
 	handler := images.Handlers(
 		visitor,
-		remotes.FetchHandler(r.Content(), fetcher),
+		tracedHandler(ref, remotes.FetchHandler(r.Content(), fetcher)),
 		images.ChildrenHandler(r.Content()),
 	)
 
 	return images.Dispatch(ctx, handler, nil, root)
 }
 
-func (r *Registry) unpackLayer(ctx context.Context, layer ocispec.Descriptor, dir string) error {
+// tracedHandler traces each call of handler in a span of the descriptor it
+// handles, e.g. the fetch of a blob.
+func tracedHandler(ref image.Reference, handler images.Handler) images.HandlerFunc {
+	return func(ctx context.Context, desc ocispec.Descriptor) (children []ocispec.Descriptor, err error) {
+		ctx, span := startSpan(ctx, "fetch blob", ref, descriptorAttributes(desc)...)
+		defer func() { endSpan(span, err) }()
+		return handler.Handle(ctx, desc)
+	}
+}
+
+func descriptorAttributes(desc ocispec.Descriptor) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("oci.digest", desc.Digest.String()),
+		attribute.String("oci.media_type", desc.MediaType),
+		attribute.Int64("oci.size", desc.Size),
+	}
+}
+
+func (r *Registry) unpackLayer(ctx context.Context, ref image.Reference, layer ocispec.Descriptor, dir string) (err error) {
+	ctx, span := startSpan(ctx, "unpack layer", ref, descriptorAttributes(layer)...)
+	defer func() { endSpan(span, err) }()
+
 	ra, err := r.Content().ReaderAt(ctx, layer)
 	if err != nil {
 		return err
@@ -213,12 +516,13 @@ func (r *Registry) unpackLayer(ctx context.Context, layer ocispec.Descriptor, di
 	defer ra.Close()
 
 	// TODO(njhale): Chunk layer reading
-	decompressed, err := compression.DecompressStream(io.NewSectionReader(ra, 0, ra.Size()))
+	decompressed, err := decompressLayer(ctx, layer, io.NewSectionReader(ra, 0, ra.Size()))
 	if err != nil {
 		return err
 	}
+	defer decompressed.Close()
 
-	filters := filterList{adjustPerms, dropXattrs}
+	filters := filterList{dropEstargzEntries, adjustPerms, dropXattrs}
 	_, err = archive.Apply(ctx, dir, decompressed, archive.WithFilter(filters.and))
 
 	return err
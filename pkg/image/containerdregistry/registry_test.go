@@ -0,0 +1,121 @@
+package containerdregistry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+func TestRegistry_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir, testLayoutImage{
+		tag:   "v1",
+		files: map[string]string{"configs/catalog.yaml": "v1"},
+	})
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	ctx := context.Background()
+	ref := image.SimpleReference("oci-layout:" + layoutDir + ":v1")
+	require.NoError(t, reg.Pull(ctx, ref))
+	require.NoError(t, reg.Unpack(ctx, ref, t.TempDir()))
+	require.Error(t, reg.Pull(ctx, image.SimpleReference("oci-layout:"+layoutDir+":v2")))
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	require.Len(t, spans["Pull"], 2)
+	require.Len(t, spans["resolve"], 2)
+	require.Len(t, spans["fetch"], 1)
+	// The manifest, config and layer of the image.
+	require.Len(t, spans["fetch blob"], 3)
+	require.Len(t, spans["Unpack"], 1)
+	require.Len(t, spans["unpack layer"], 1)
+
+	pull := spans["Pull"][0]
+	require.Contains(t, pull.Attributes(), attribute.String("image.ref", ref.String()))
+	for _, name := range []string{"resolve", "fetch"} {
+		require.Equal(t, pull.SpanContext().SpanID(), spans[name][0].Parent().SpanID(), "%s must be a child of Pull", name)
+	}
+	require.Equal(t, spans["fetch"][0].SpanContext().SpanID(), spans["fetch blob"][0].Parent().SpanID())
+	require.Equal(t, spans["Unpack"][0].SpanContext().SpanID(), spans["unpack layer"][0].Parent().SpanID())
+
+	failed := spans["Pull"][1]
+	require.Equal(t, codes.Error, failed.Status().Code)
+	require.Equal(t, codes.Error, spans["resolve"][1].Status().Code)
+}
+
+func TestRegistry_PullFromMirrors(t *testing.T) {
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir, testLayoutImage{
+		tag:   "v1",
+		files: map[string]string{"configs/catalog.yaml": "v1"},
+	})
+	mirror := serveTestOCILayout(t, layoutDir, nil)
+	empty := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(empty.Close)
+
+	// The image is only in the second mirror, and the registry itself is
+	// unreachable.
+	registriesConf := filepath.Join(t.TempDir(), "registries.conf")
+	require.NoError(t, os.WriteFile(registriesConf, []byte(fmt.Sprintf(`
+[[registry]]
+location = "registry.invalid"
+
+[[registry.mirror]]
+location = "%s"
+
+[[registry.mirror]]
+location = "%s"
+
+[[registry]]
+location = "blocked.invalid"
+blocked = true
+`, strings.TrimPrefix(empty.URL, "http://"), mirror)), 0600))
+
+	reg, err := NewRegistry(
+		WithCacheDir(t.TempDir()),
+		WithLog(log.Null()),
+		WithClientConfig(image.ClientConfig{PlainHTTP: true, RegistriesConfPath: registriesConf}),
+	)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	ctx := context.Background()
+	ref := image.SimpleReference("registry.invalid/test:v1")
+	require.NoError(t, reg.Pull(ctx, ref))
+	unpackDir := t.TempDir()
+	require.NoError(t, reg.Unpack(ctx, ref, unpackDir))
+	content, err := os.ReadFile(filepath.Join(unpackDir, "configs", "catalog.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(content))
+
+	err = reg.Pull(ctx, image.SimpleReference("blocked.invalid/test:v1"))
+	require.ErrorContains(t, err, "registry blocked.invalid is blocked")
+}
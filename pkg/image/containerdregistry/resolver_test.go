@@ -0,0 +1,62 @@
+package containerdregistry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCredentialFuncRotation ensures that credentials are read again from the
+// auth file when it changes, so that long-running processes pick up rotated
+// credentials without being restarted.
+func TestCredentialFuncRotation(t *testing.T) {
+	t.Setenv("REGISTRY_AUTH_FILE", "")
+	t.Setenv("DOCKER_CONFIG", "")
+
+	configDir := t.TempDir()
+	writeAuth := func(username, password string) {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		data := fmt.Sprintf(`{"auths":{"registry.example.com":{"auth":%q}}}`, auth)
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.json"), []byte(data), 0600))
+	}
+
+	credFunc := credentialFunc(newCredentialStore(configDir), "registry.example.com/foo/bar")
+
+	writeAuth("user", "first")
+	username, password, err := credFunc("registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+	require.Equal(t, "first", password)
+
+	writeAuth("user", "rotated")
+	username, password, err = credFunc("registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+	require.Equal(t, "rotated", password)
+}
+
+// TestCredentialFuncUnreadableAuthFile ensures that credentials are looked up
+// in the system defaults when the auth file cannot be checked for changes.
+func TestCredentialFuncUnreadableAuthFile(t *testing.T) {
+	t.Setenv("REGISTRY_AUTH_FILE", "")
+	t.Setenv("DOCKER_CONFIG", "")
+
+	// A file on the path of the auth file makes it impossible to resolve.
+	configDir := filepath.Join(t.TempDir(), "not-a-directory")
+	require.NoError(t, os.WriteFile(configDir, nil, 0600))
+
+	s := newCredentialStore(configDir)
+	s.lookup = func(sys *types.SystemContext, repo string) (types.DockerAuthConfig, error) {
+		require.Nil(t, sys, "only the system defaults should be looked up")
+		return types.DockerAuthConfig{Username: "user", Password: "default"}, nil
+	}
+	username, password, err := credentialFunc(s, "registry.example.com/foo/bar")("registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+	require.Equal(t, "default", password)
+}
@@ -0,0 +1,226 @@
+package containerdregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/containerd/containerd/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryPolicy configures how failed registry requests, interrupted blob
+// downloads and failed pulls are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of times that a failed operation is retried.
+	// Zero disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry. It doubles with
+	// every retry that follows.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy retries failed operations four times, after one,
+// two, four and eight seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     4,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// backoff returns the delay before the retry that follows the given number
+// of failed attempts, starting at zero.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return wait.Jitter(d, 0.1)
+}
+
+// wait blocks until the retry that follows the given number of failed
+// attempts is due, or ctx is done.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryTransport retries idempotent requests that fail with network errors,
+// or with statuses that report transient server conditions.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	log    *logrus.Entry
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt == t.policy.MaxRetries || req.Context().Err() != nil || !retriableResponse(resp, err) {
+			return resp, err
+		}
+		if err != nil {
+			t.log.WithError(err).Debugf("%s %s failed, retrying", req.Method, req.URL.Redacted())
+		} else {
+			t.log.Debugf("%s %s returned %s, retrying", req.Method, req.URL.Redacted(), resp.Status)
+			// Drain the body, so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		if waitErr := t.policy.wait(req.Context(), attempt); waitErr != nil {
+			if err == nil {
+				err = fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+			}
+			return nil, err
+		}
+	}
+}
+
+func retriableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// resumingFetcher resumes blob downloads that are interrupted, from the
+// offset at which they stopped, rather than failing them.
+type resumingFetcher struct {
+	remotes.Fetcher
+	policy RetryPolicy
+	log    *logrus.Entry
+}
+
+var _ remotes.Fetcher = &resumingFetcher{}
+
+func newResumingFetcher(fetcher remotes.Fetcher, policy RetryPolicy, log *logrus.Entry) remotes.Fetcher {
+	if policy.MaxRetries == 0 {
+		return fetcher
+	}
+	return &resumingFetcher{Fetcher: fetcher, policy: policy, log: log}
+}
+
+func (f *resumingFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := f.Fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	r := &resumingReader{ctx: ctx, fetcher: f, desc: desc, rc: rc}
+	if _, ok := rc.(io.Seeker); ok {
+		// Keep the reader seekable, so that content stores can resume the
+		// ingestion of blobs that earlier pulls left incomplete.
+		return &resumingReadSeeker{r}, nil
+	}
+	return r, nil
+}
+
+// resumingReader reads a blob, fetching it again from the offset of the
+// last byte read when a read fails. Blobs are resumed by seeking the readers
+// of their fetches, which the fetchers of remote registries implement with
+// HTTP range requests. Blobs whose readers cannot seek are only resumed
+// before any of their content is read.
+type resumingReader struct {
+	ctx     context.Context
+	fetcher *resumingFetcher
+	desc    ocispec.Descriptor
+	rc      io.ReadCloser
+	offset  int64
+	// retries counts the consecutive failed reads.
+	retries int
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.rc.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.retries = 0
+		}
+		if err == nil || errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if n > 0 {
+			// Return what was read, and resume on the next read.
+			return n, nil
+		}
+		if resumeErr := r.resume(err); resumeErr != nil {
+			return 0, resumeErr
+		}
+	}
+}
+
+// resume replaces the reader of the blob with one that reads from the
+// current offset, after a backoff, or returns the error of the failed read
+// if the blob cannot be resumed.
+func (r *resumingReader) resume(readErr error) error {
+	if r.retries == r.fetcher.policy.MaxRetries || r.ctx.Err() != nil {
+		return readErr
+	}
+	if _, ok := r.rc.(io.Seeker); !ok && r.offset > 0 {
+		return readErr
+	}
+	r.fetcher.log.WithError(readErr).WithField("digest", r.desc.Digest).Debugf("blob download interrupted at offset %d, resuming", r.offset)
+	if err := r.fetcher.policy.wait(r.ctx, r.retries); err != nil {
+		return readErr
+	}
+	r.retries++
+
+	rc, err := r.fetcher.Fetcher.Fetch(r.ctx, r.desc)
+	if err != nil {
+		return fmt.Errorf("resume blob %s at offset %d: %w", r.desc.Digest, r.offset, err)
+	}
+	if r.offset > 0 {
+		seeker, ok := rc.(io.Seeker)
+		if !ok {
+			rc.Close()
+			return readErr
+		}
+		if _, err := seeker.Seek(r.offset, io.SeekStart); err != nil {
+			rc.Close()
+			return fmt.Errorf("resume blob %s at offset %d: %w", r.desc.Digest, r.offset, err)
+		}
+	}
+	r.rc.Close()
+	r.rc = rc
+	return nil
+}
+
+func (r *resumingReader) Close() error {
+	return r.rc.Close()
+}
+
+type resumingReadSeeker struct {
+	*resumingReader
+}
+
+func (r *resumingReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	abs, err := r.rc.(io.Seeker).Seek(offset, whence)
+	if err == nil {
+		r.offset = abs
+	}
+	return abs, err
+}
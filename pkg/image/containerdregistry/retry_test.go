@@ -0,0 +1,324 @@
+package containerdregistry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+)
+
+var testRetryPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		got := policy.backoff(attempt)
+		require.GreaterOrEqual(t, got, want, "attempt %d", attempt)
+		require.LessOrEqual(t, got, want+want/10, "attempt %d", attempt)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	type spec struct {
+		name     string
+		method   string
+		statuses []int
+		want     int
+		requests int
+	}
+	for _, s := range []spec{
+		{name: "Success", method: http.MethodGet, statuses: []int{http.StatusOK}, want: http.StatusOK, requests: 1},
+		{name: "Transient", method: http.MethodGet, statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, want: http.StatusOK, requests: 3},
+		{name: "Head", method: http.MethodHead, statuses: []int{http.StatusBadGateway, http.StatusOK}, want: http.StatusOK, requests: 2},
+		{name: "Exhausted", method: http.MethodGet, statuses: []int{http.StatusServiceUnavailable}, want: http.StatusServiceUnavailable, requests: 3},
+		{name: "NotTransient", method: http.MethodGet, statuses: []int{http.StatusNotFound}, want: http.StatusNotFound, requests: 1},
+		{name: "NotIdempotent", method: http.MethodPost, statuses: []int{http.StatusServiceUnavailable}, want: http.StatusServiceUnavailable, requests: 1},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&requests, 1))
+				w.WriteHeader(s.statuses[min(n, len(s.statuses))-1])
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, policy: testRetryPolicy, log: log.Null()}}
+			req, err := http.NewRequest(s.method, srv.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, s.want, resp.StatusCode)
+			require.Equal(t, s.requests, int(atomic.LoadInt32(&requests)))
+		})
+	}
+}
+
+// flakyFetcher fetches a blob whose reads fail with errInterrupted after
+// the offsets in failAt, one per fetch.
+type flakyFetcher struct {
+	data     []byte
+	seekable bool
+	failAt   []int64
+	fetches  int
+}
+
+var errInterrupted = errors.New("connection reset by peer")
+
+func (f *flakyFetcher) Fetch(_ context.Context, _ ocispec.Descriptor) (io.ReadCloser, error) {
+	failAt := int64(len(f.data))
+	if f.fetches < len(f.failAt) {
+		failAt = f.failAt[f.fetches]
+	}
+	f.fetches++
+	r := &flakyReader{data: f.data, failAt: failAt}
+	if f.seekable {
+		return &flakyReadSeeker{r}, nil
+	}
+	return r, nil
+}
+
+type flakyReader struct {
+	data   []byte
+	offset int64
+	failAt int64
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if r.offset >= r.failAt && r.failAt < int64(len(r.data)) {
+		return 0, errInterrupted
+	}
+	if r.offset >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	end := int64(len(r.data))
+	if r.failAt > r.offset && r.failAt < end {
+		end = r.failAt
+	}
+	n := copy(p, r.data[r.offset:end])
+	r.offset += int64(n)
+	return n, nil
+}
+
+func (r *flakyReader) Close() error { return nil }
+
+type flakyReadSeeker struct {
+	*flakyReader
+}
+
+func (r *flakyReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, errors.New("unsupported whence")
+	}
+	r.offset = offset
+	if r.failAt < offset {
+		r.failAt = int64(len(r.data))
+	}
+	return offset, nil
+}
+
+func TestResumingFetcher(t *testing.T) {
+	data := make([]byte, 1000)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	desc := ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+
+	type spec struct {
+		name     string
+		seekable bool
+		failAt   []int64
+		fetches  int
+		wantErr  error
+	}
+	for _, s := range []spec{
+		{name: "Uninterrupted", seekable: true, fetches: 1},
+		{name: "Resumed", seekable: true, failAt: []int64{100, 500}, fetches: 3},
+		{name: "ResumedFromStart", failAt: []int64{0}, fetches: 2},
+		{name: "NotSeekable", failAt: []int64{100}, fetches: 1, wantErr: errInterrupted},
+		{name: "Exhausted", seekable: true, failAt: []int64{100, 100, 100}, fetches: 3, wantErr: errInterrupted},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			remote := &flakyFetcher{data: data, seekable: s.seekable, failAt: s.failAt}
+			fetcher := newResumingFetcher(remote, testRetryPolicy, log.Null())
+			rc, err := fetcher.Fetch(context.Background(), desc)
+			require.NoError(t, err)
+			defer rc.Close()
+
+			got, err := io.ReadAll(rc)
+			require.Equal(t, s.fetches, remote.fetches)
+			if s.wantErr != nil {
+				require.ErrorIs(t, err, s.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+		})
+	}
+}
+
+// serveTestOCILayout serves the images of the OCI image layout in dir as the
+// repository "test" of a registry, and returns the host of the registry.
+// Blob requests are passed to intercept, if it is not nil, which reports
+// whether it handled them.
+func serveTestOCILayout(t *testing.T, dir string, intercept func(w http.ResponseWriter, r *http.Request, data []byte) bool) string {
+	t.Helper()
+	var index ocispec.Index
+	indexData, err := os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(indexData, &index))
+
+	readBlob := func(dgst digest.Digest) ([]byte, error) {
+		if err := dgst.Validate(); err != nil {
+			return nil, err
+		}
+		return os.ReadFile(filepath.Join(dir, ocispec.ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded()))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+		case strings.HasPrefix(r.URL.Path, "/v2/test/manifests/"):
+			ref := strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/")
+			for _, m := range index.Manifests {
+				if m.Annotations[ocispec.AnnotationRefName] != ref && m.Digest.String() != ref {
+					continue
+				}
+				data, err := readBlob(m.Digest)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", m.MediaType)
+				w.Header().Set("Docker-Content-Digest", m.Digest.String())
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+				return
+			}
+			http.NotFound(w, r)
+		case strings.HasPrefix(r.URL.Path, "/v2/test/blobs/"):
+			data, err := readBlob(digest.Digest(strings.TrimPrefix(r.URL.Path, "/v2/test/blobs/")))
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			if intercept != nil && intercept(w, r, data) {
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestRegistry_PullResumesInterruptedBlobs(t *testing.T) {
+	content := make([]byte, 1<<20)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir, testLayoutImage{
+		tag:         "v1",
+		files:       map[string]string{"configs/catalog.json": string(content)},
+		compression: "none",
+	})
+
+	var (
+		mu         sync.Mutex
+		ranges     []string
+		interrupts int
+	)
+	host := serveTestOCILayout(t, layoutDir, func(w http.ResponseWriter, r *http.Request, data []byte) bool {
+		if len(data) < len(content) {
+			return false
+		}
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		interrupt := interrupts == 0
+		interrupts++
+		mu.Unlock()
+		if !interrupt {
+			return false
+		}
+
+		// Send half of the layer, then reset the connection.
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		fmt.Fprintf(rw, "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\nContent-Length: %d\r\n\r\n", len(data))
+		_, _ = rw.Write(data[:len(data)/2])
+		require.NoError(t, rw.Flush())
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, conn.(*net.TCPConn).SetLinger(0))
+		conn.Close()
+		return true
+	})
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()), WithPlainHTTP(true), WithRetryPolicy(testRetryPolicy))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	ctx := context.Background()
+	ref := image.SimpleReference(host + "/test:v1")
+	require.NoError(t, reg.Pull(ctx, ref))
+
+	unpackDir := t.TempDir()
+	require.NoError(t, reg.Unpack(ctx, ref, unpackDir))
+	got, err := os.ReadFile(filepath.Join(unpackDir, "configs", "catalog.json"))
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, ranges, 2, "the layer must be fetched once more after the interruption")
+	require.Empty(t, ranges[0])
+	require.Regexp(t, `^bytes=[1-9][0-9]*-$`, ranges[1], "the layer must be resumed where it was interrupted")
+}
+
+func TestRegistry_PullTimeout(t *testing.T) {
+	layoutDir := t.TempDir()
+	writeTestOCILayout(t, layoutDir, testLayoutImage{
+		tag:   "v1",
+		files: map[string]string{"configs/catalog.yaml": "v1"},
+	})
+	host := serveTestOCILayout(t, layoutDir, func(w http.ResponseWriter, r *http.Request, _ []byte) bool {
+		// Stall until the client gives up.
+		<-r.Context().Done()
+		return true
+	})
+
+	reg, err := NewRegistry(WithCacheDir(t.TempDir()), WithLog(log.Null()), WithPlainHTTP(true), WithPullTimeout(200*time.Millisecond))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reg.Destroy())
+	}()
+
+	start := time.Now()
+	err = reg.Pull(context.Background(), image.SimpleReference(host+"/test:v1"))
+	require.ErrorContains(t, err, context.DeadlineExceeded.Error())
+	require.Less(t, time.Since(start), 10*time.Second)
+}
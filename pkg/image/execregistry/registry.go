@@ -2,6 +2,9 @@ package execregistry
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/sirupsen/logrus"
 
@@ -16,16 +19,17 @@ type CommandRunner interface {
 	Unpack(image, src, dst string) error
 }
 
-// Registry enables manipulation of images via exec podman/docker commands.
+// Registry enables manipulation of images via exec podman/docker/nerdctl/crane commands.
 type Registry struct {
-	log *logrus.Entry
-	cmd CommandRunner
+	log     *logrus.Entry
+	cmd     CommandRunner
+	destroy func() error
 }
 
 // Adapt the cmd interface to the registry interface
 var _ image.Registry = &Registry{}
 
-// NewRegistry instantiates and returns a new registry which manipulates images via exec podman/docker commands.
+// NewRegistry instantiates and returns a new registry which manipulates images via exec podman/docker/nerdctl/crane commands.
 func NewRegistry(tool containertools.ContainerTool, logger *logrus.Entry, opts ...containertools.RunnerOption) (registry *Registry, err error) {
 	return &Registry{
 		log: logger,
@@ -33,6 +37,51 @@ func NewRegistry(tool containertools.ContainerTool, logger *logrus.Entry, opts .
 	}, nil
 }
 
+// NewRegistryWithClientConfig instantiates and returns a new registry which
+// pulls images via exec podman/docker/nerdctl/crane commands as cfg configures.
+// The other tools read their configuration from their own files, or from the
+// docker daemon, instead, so only podman supports a CA file
+// and a registries.conf file.
+func NewRegistryWithClientConfig(tool containertools.ContainerTool, logger *logrus.Entry, cfg image.ClientConfig) (*Registry, error) {
+	if tool != containertools.PodmanTool {
+		switch {
+		case cfg.CAFile != "":
+			return nil, fmt.Errorf("a CA file cannot be used with %[1]s; certificates must be configured specifically for %[1]s", tool)
+		case cfg.RegistriesConfPath != "":
+			return nil, fmt.Errorf("a registries.conf file cannot be used with %[1]s; mirrors must be configured specifically for %[1]s", tool)
+		}
+	}
+
+	opts := []containertools.RunnerOption{
+		containertools.SkipTLS(cfg.SkipTLSVerify || cfg.PlainHTTP),
+		containertools.WithRegistriesConf(cfg.RegistriesConfPath),
+	}
+	destroy := func() error { return nil }
+	if cfg.CAFile != "" {
+		// podman reads certificates from a directory of *.crt files.
+		certDir, err := os.MkdirTemp("", "opm-certs-")
+		if err != nil {
+			return nil, err
+		}
+		destroy = func() error { return os.RemoveAll(certDir) }
+		certs, err := os.ReadFile(cfg.CAFile)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(certDir, "ca.crt"), certs, 0600)
+		}
+		if err != nil {
+			_ = destroy()
+			return nil, fmt.Errorf("failed to copy CA file %s: %v", cfg.CAFile, err)
+		}
+		opts = append(opts, containertools.WithCertDir(certDir))
+	}
+
+	return &Registry{
+		log:     logger,
+		cmd:     containertools.NewCommandRunner(tool, logger, opts...),
+		destroy: destroy,
+	}, nil
+}
+
 // Pull fetches and stores an image by reference.
 func (r *Registry) Pull(ctx context.Context, ref image.Reference) error {
 	return r.cmd.Pull(ref.String())
@@ -52,7 +101,11 @@ func (r *Registry) Labels(ctx context.Context, ref image.Reference) (map[string]
 	}.GetLabelsFromImage(ref.String())
 }
 
-// Destroy is no-op for exec tools
+// Destroy removes the files that the registry wrote to configure the exec
+// tool, if any. The images it pulled are kept.
 func (r *Registry) Destroy() error {
-	return nil
+	if r.destroy == nil {
+		return nil
+	}
+	return r.destroy()
 }
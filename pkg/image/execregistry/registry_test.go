@@ -0,0 +1,42 @@
+package execregistry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+func TestNewRegistryWithClientConfig(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("certs"), 0600))
+
+	t.Run("Podman", func(t *testing.T) {
+		reg, err := NewRegistryWithClientConfig(containertools.PodmanTool, logger, image.ClientConfig{CAFile: caFile, RegistriesConfPath: "registries.conf"})
+		require.NoError(t, err)
+		require.NoError(t, reg.Destroy())
+	})
+	t.Run("Docker", func(t *testing.T) {
+		reg, err := NewRegistryWithClientConfig(containertools.DockerTool, logger, image.ClientConfig{SkipTLSVerify: true})
+		require.NoError(t, err)
+		require.NoError(t, reg.Destroy())
+	})
+	t.Run("Error/DockerCAFile", func(t *testing.T) {
+		_, err := NewRegistryWithClientConfig(containertools.DockerTool, logger, image.ClientConfig{CAFile: caFile})
+		require.ErrorContains(t, err, "a CA file cannot be used with docker")
+	})
+	t.Run("Error/DockerRegistriesConf", func(t *testing.T) {
+		_, err := NewRegistryWithClientConfig(containertools.DockerTool, logger, image.ClientConfig{RegistriesConfPath: "registries.conf"})
+		require.ErrorContains(t, err, "a registries.conf file cannot be used with docker")
+	})
+	t.Run("Error/MissingCAFile", func(t *testing.T) {
+		_, err := NewRegistryWithClientConfig(containertools.PodmanTool, logger, image.ClientConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+		require.ErrorContains(t, err, "failed to copy CA file")
+	})
+}
@@ -1,15 +1,21 @@
 package image
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
 )
 
-var _ Registry = &MockRegistry{}
+var (
+	_ Registry    = &MockRegistry{}
+	_ LayerReader = &MockRegistry{}
+)
 
 type MockRegistry struct {
 	RemoteImages map[Reference]*MockImage
@@ -67,6 +73,30 @@ func (m *MockRegistry) Unpack(_ context.Context, ref Reference, dir string) erro
 	return image.unpack(dir)
 }
 
+// ReadLayers calls fn with the files and directories of the image, as the
+// entries of a single layer.
+func (m *MockRegistry) ReadLayers(_ context.Context, ref Reference, fn func(layer int, hdr *tar.Header, r io.Reader) error) error {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	image, ok := m.localImages[ref]
+	if !ok {
+		return errors.New("not found")
+	}
+	return fs.WalkDir(image.FS, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == "." {
+			return err
+		}
+		if entry.IsDir() {
+			return fn(0, &tar.Header{Typeflag: tar.TypeDir, Name: path + "/", Mode: 0755}, bytes.NewReader(nil))
+		}
+		data, err := fs.ReadFile(image.FS, path)
+		if err != nil {
+			return err
+		}
+		return fn(0, &tar.Header{Typeflag: tar.TypeReg, Name: path, Mode: 0644, Size: int64(len(data))}, bytes.NewReader(data))
+	})
+}
+
 func (m *MockRegistry) Labels(_ context.Context, ref Reference) (map[string]string, error) {
 	m.m.RLock()
 	defer m.m.RUnlock()
@@ -0,0 +1,67 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// OCILayoutPrefix is the prefix of references to images stored in a local
+// OCI image layout directory, such as those produced by
+// "skopeo copy ... oci:<dir>:<tag>" or "oras copy --to-oci-layout".
+const OCILayoutPrefix = "oci-layout:"
+
+// OCILayoutReference is a reference to an image in an OCI image layout
+// directory, of the form oci-layout:<dir>[:<tag>|@<digest>].
+//
+// If neither Tag nor Digest is set, the layout must contain exactly one image.
+type OCILayoutReference struct {
+	Dir    string
+	Tag    string
+	Digest digest.Digest
+}
+
+// IsOCILayoutReference returns true if ref refers to an image in an OCI image
+// layout directory.
+func IsOCILayoutReference(ref string) bool {
+	return strings.HasPrefix(ref, OCILayoutPrefix)
+}
+
+// ParseOCILayoutReference parses a reference of the form
+// oci-layout:<dir>[:<tag>|@<digest>].
+func ParseOCILayoutReference(ref string) (*OCILayoutReference, error) {
+	if !IsOCILayoutReference(ref) {
+		return nil, fmt.Errorf("invalid OCI layout reference %q: must start with %q", ref, OCILayoutPrefix)
+	}
+	out := &OCILayoutReference{Dir: strings.TrimPrefix(ref, OCILayoutPrefix)}
+
+	if i := strings.LastIndex(out.Dir, "@"); i >= 0 {
+		dgst, err := digest.Parse(out.Dir[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid OCI layout reference %q: %v", ref, err)
+		}
+		out.Dir, out.Digest = out.Dir[:i], dgst
+	} else if i := strings.LastIndex(out.Dir, ":"); i >= 0 && !strings.Contains(out.Dir[i:], "/") {
+		// A colon after the last path separator separates the tag.
+		out.Dir, out.Tag = out.Dir[:i], out.Dir[i+1:]
+		if out.Tag == "" {
+			return nil, fmt.Errorf("invalid OCI layout reference %q: empty tag", ref)
+		}
+	}
+	if out.Dir == "" {
+		return nil, fmt.Errorf("invalid OCI layout reference %q: empty directory", ref)
+	}
+	return out, nil
+}
+
+func (r OCILayoutReference) String() string {
+	switch {
+	case r.Digest != "":
+		return OCILayoutPrefix + r.Dir + "@" + r.Digest.String()
+	case r.Tag != "":
+		return OCILayoutPrefix + r.Dir + ":" + r.Tag
+	default:
+		return OCILayoutPrefix + r.Dir
+	}
+}
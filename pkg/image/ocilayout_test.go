@@ -0,0 +1,73 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOCILayoutReference(t *testing.T) {
+	type spec struct {
+		name      string
+		ref       string
+		expected  *OCILayoutReference
+		assertion require.ErrorAssertionFunc
+	}
+	const dgst = "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	specs := []spec{
+		{
+			name:      "Dir",
+			ref:       "oci-layout:./catalog",
+			expected:  &OCILayoutReference{Dir: "./catalog"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Tag",
+			ref:       "oci-layout:/tmp/catalog:v1.0.0",
+			expected:  &OCILayoutReference{Dir: "/tmp/catalog", Tag: "v1.0.0"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Digest",
+			ref:       "oci-layout:/tmp/catalog@" + dgst,
+			expected:  &OCILayoutReference{Dir: "/tmp/catalog", Digest: dgst},
+			assertion: require.NoError,
+		},
+		{
+			name:      "ColonInDir",
+			ref:       "oci-layout:/tmp/a:b/catalog",
+			expected:  &OCILayoutReference{Dir: "/tmp/a:b/catalog"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/NoPrefix",
+			ref:       "quay.io/foo/bar:latest",
+			assertion: require.Error,
+		},
+		{
+			name:      "Error/EmptyDir",
+			ref:       "oci-layout::latest",
+			assertion: require.Error,
+		},
+		{
+			name:      "Error/EmptyTag",
+			ref:       "oci-layout:./catalog:",
+			assertion: require.Error,
+		},
+		{
+			name:      "Error/InvalidDigest",
+			ref:       "oci-layout:./catalog@sha256:1234",
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := ParseOCILayoutReference(s.ref)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+			if actual != nil {
+				require.Equal(t, s.ref, actual.String())
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package image
 
 import (
+	"archive/tar"
 	"context"
+	"io"
 )
 
 // Registry knows how to Pull and Unpack Operator Bundle images to the filesystem.
@@ -29,3 +31,15 @@ type Registry interface {
 	// If it exists, it's used as the base image.
 	// Pack(ctx context.Context, ref Reference, from io.Reader) (next string, err error)
 }
+
+// LayerReader is implemented by registries that can read the layers of the
+// images they store, so that the content of an image can be read without
+// unpacking it to a directory.
+type LayerReader interface {
+	// ReadLayers calls fn with each entry of the layers of an image that is
+	// already stored, in the order the layers are applied, along with the
+	// index of the layer of the entry. r reads the content of the entry.
+	// If the referenced image does not exist in the registry, an error is
+	// returned.
+	ReadLayers(ctx context.Context, ref Reference, fn func(layer int, hdr *tar.Header, r io.Reader) error) error
+}
@@ -13,7 +13,7 @@ func BuildBundleImage(imageTag, imageBuilder string) (*exec.Cmd, error) {
 	var args []string
 
 	switch imageBuilder {
-	case "docker", "podman":
+	case "docker", "podman", "nerdctl":
 		args = append(args, "build", "-f", DockerFile, "-t", imageTag, ".")
 	case "buildah":
 		args = append(args, "bud", "--format=docker", "-f", DockerFile, "-t", imageTag, ".")
@@ -45,7 +45,7 @@ func ExecuteCommand(cmd *exec.Cmd) error {
 // @directory: The local directory where bundle manifests and metadata are located
 // @imageTag: The image tag that is applied to the bundle image
 // @imageBuilder: The image builder tool that is used to build container image
-// (docker, buildah or podman)
+// (docker, buildah, podman or nerdctl)
 // @packageName: The name of the package that bundle image belongs to
 // @channels: The list of channels that bundle image belongs to
 // @channelDefault: The default channel for the bundle image
@@ -1,9 +1,15 @@
 package bundle
 
 import (
+	"archive/tar"
 	"context"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/otiai10/copy"
 	"github.com/sirupsen/logrus"
@@ -30,7 +36,45 @@ func NewExporterForBundle(image, directory string, containerTool containertools.
 }
 
 func (i *BundleExporter) Export(skipTLSVerify, plainHTTP bool) error {
+	return i.export(skipTLSVerify, plainHTTP, func(reg image.Registry, tmpDir string) error {
+		dir := filepath.Join(tmpDir, "bundle")
+		if err := pullAndUnpack(context.TODO(), reg, image.SimpleReference(i.image), dir); err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(i.directory, 0777); err != nil {
+			return err
+		}
 
+		return copy.Copy(filepath.Join(dir, "manifests"), i.directory)
+	})
+}
+
+// ExportFS returns the content of the bundle image, i.e. its manifests and
+// metadata directories, as an in-memory filesystem. The directory of the
+// exporter is not used.
+func (i *BundleExporter) ExportFS(skipTLSVerify, plainHTTP bool) (fs.FS, error) {
+	var fsys fs.FS
+	err := i.export(skipTLSVerify, plainHTTP, func(reg image.Registry, _ string) error {
+		var err error
+		fsys, err = UnpackFS(context.TODO(), reg, image.SimpleReference(i.image))
+		return err
+	})
+	return fsys, err
+}
+
+// ExportTar writes a tar archive of the content of the bundle image, i.e.
+// its manifests and metadata directories, to w. The directory of the
+// exporter is not used.
+func (i *BundleExporter) ExportTar(w io.Writer, skipTLSVerify, plainHTTP bool) error {
+	return i.export(skipTLSVerify, plainHTTP, func(reg image.Registry, _ string) error {
+		return UnpackTar(context.TODO(), reg, image.SimpleReference(i.image), w)
+	})
+}
+
+// export calls fn with a registry for the container tool of the exporter,
+// and a temporary directory that is removed when fn returns.
+func (i *BundleExporter) export(skipTLSVerify, plainHTTP bool, fn func(reg image.Registry, tmpDir string) error) error {
 	log := logrus.WithField("img", i.image)
 
 	tmpDir, err := os.MkdirTemp("./", "bundle_tmp")
@@ -41,18 +85,16 @@ func (i *BundleExporter) Export(skipTLSVerify, plainHTTP bool) error {
 
 	var reg image.Registry
 	var rerr error
+	clientConfig := image.ClientConfig{SkipTLSVerify: skipTLSVerify, PlainHTTP: plainHTTP}
 	switch i.containerTool {
 	case containertools.NoneTool:
 		reg, rerr = containerdregistry.NewRegistry(
-			containerdregistry.SkipTLSVerify(skipTLSVerify),
-			containerdregistry.WithPlainHTTP(plainHTTP),
+			containerdregistry.WithClientConfig(clientConfig),
 			containerdregistry.WithLog(log),
 			containerdregistry.WithCacheDir(filepath.Join(tmpDir, "cacheDir")),
 		)
-	case containertools.PodmanTool:
-		fallthrough
-	case containertools.DockerTool:
-		reg, rerr = execregistry.NewRegistry(i.containerTool, log, containertools.SkipTLS(plainHTTP))
+	case containertools.PodmanTool, containertools.DockerTool, containertools.NerdctlTool, containertools.CraneTool:
+		reg, rerr = execregistry.NewRegistryWithClientConfig(i.containerTool, log, clientConfig)
 	}
 	if rerr != nil {
 		return rerr
@@ -63,17 +105,289 @@ func (i *BundleExporter) Export(skipTLSVerify, plainHTTP bool) error {
 		}
 	}()
 
-	if err := reg.Pull(context.TODO(), image.SimpleReference(i.image)); err != nil {
+	return fn(reg, tmpDir)
+}
+
+// maxContentSize bounds the size of the content of a bundle image that
+// UnpackFS reads into memory, so that an image that is not a bundle, e.g. a
+// catalog or an operator image, cannot exhaust the memory of the process.
+const maxContentSize = 64 << 20
+
+// UnpackFS pulls the bundle image ref with reg, and returns its content, i.e.
+// its manifests and metadata directories, as an in-memory filesystem. If reg
+// is an image.LayerReader, the layers of the image are read into memory
+// directly; otherwise the image is unpacked to a temporary directory first.
+// Entries other than regular files and directories, e.g. symlinks, and
+// entries outside of the manifests and metadata directories are skipped.
+// Bundles whose content exceeds 64MiB are rejected.
+func UnpackFS(ctx context.Context, reg image.Registry, ref image.Reference) (fs.FS, error) {
+	if err := reg.Pull(ctx, ref); err != nil {
+		return nil, err
+	}
+	if lr, ok := reg.(image.LayerReader); ok {
+		return readLayers(ctx, lr, ref, true)
+	}
+
+	dir, err := os.MkdirTemp("", "bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := reg.Unpack(ctx, ref, dir); err != nil {
+		return nil, err
+	}
+	return readDirFS(dir)
+}
+
+// UnpackTar pulls the bundle image ref with reg, and writes a tar archive of
+// its content, as returned by UnpackFS, to w. The content is streamed: if reg
+// is an image.LayerReader, the layers of the image are read twice, once to
+// apply their whiteouts, and once to write the files that remain; otherwise
+// the image is unpacked to a temporary directory, and written with WriteTar.
+func UnpackTar(ctx context.Context, reg image.Registry, ref image.Reference, w io.Writer) error {
+	if err := reg.Pull(ctx, ref); err != nil {
 		return err
 	}
+	if lr, ok := reg.(image.LayerReader); ok {
+		return writeLayersTar(ctx, lr, ref, w)
+	}
 
-	if err := reg.Unpack(context.TODO(), image.SimpleReference(i.image), tmpDir); err != nil {
+	dir, err := os.MkdirTemp("", "bundle-")
+	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(dir)
+	if err := reg.Unpack(ctx, ref, dir); err != nil {
+		return err
+	}
+	return WriteTar(w, dir)
+}
 
-	if err := os.MkdirAll(i.directory, 0777); err != nil {
+// WriteTar writes a tar archive of the content of the bundle image unpacked
+// in dir, i.e. its manifests and metadata directories, to w. Files are
+// streamed from dir, rather than read into memory. Entries other than
+// regular files and directories, e.g. symlinks, are skipped.
+func WriteTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := walkContentDir(dir, func(name string, info fs.FileInfo) error {
+		if info.IsDir() {
+			return tw.WriteHeader(dirHeader(name, info))
+		}
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := tw.WriteHeader(fileHeader(name, info)); err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
 		return err
 	}
+	return tw.Close()
+}
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// isContent reports whether name, a slash-separated path relative to the
+// root of a bundle image, is in its manifests or metadata directory.
+func isContent(name string) bool {
+	top, _, _ := strings.Cut(name, "/")
+	return top+"/" == ManifestsDir || top+"/" == MetadataDir
+}
 
-	return copy.Copy(filepath.Join(tmpDir, "manifests"), i.directory)
+// readLayers applies the layers of the image ref, read with lr, to an
+// in-memory filesystem of its content, including their whiteouts. The data
+// of regular files is only read if withData is set; otherwise, the src of
+// their entries tells which tar entry of the layers holds it.
+func readLayers(ctx context.Context, lr image.LayerReader, ref image.Reference, withData bool) (*memFS, error) {
+	fsys := newMemFS()
+	var index int
+	var size int64
+	err := lr.ReadLayers(ctx, ref, func(layer int, hdr *tar.Header, r io.Reader) error {
+		index++
+		name := path.Clean("/" + hdr.Name)[1:]
+		if name == "" {
+			return nil
+		}
+		dir, base := path.Split(name)
+		dir = path.Clean("/" + dir)[1:]
+		if dir == "" {
+			dir = "."
+		}
+		switch {
+		case base == whiteoutOpaque:
+			fsys.removeOlder(dir, layer)
+			return nil
+		case strings.HasPrefix(base, whiteoutPrefix):
+			fsys.remove(path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			return nil
+		case !isContent(name):
+			return nil
+		}
+
+		e := &memEntry{mode: fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime, layer: layer, src: index}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			e.mode |= fs.ModeDir
+		case tar.TypeReg:
+			e.size = hdr.Size
+			if withData {
+				if size += hdr.Size; size > maxContentSize {
+					return fmt.Errorf("bundle content exceeds the maximum size of %d bytes", maxContentSize)
+				}
+				data, err := io.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				e.data = data
+			}
+		case tar.TypeLink:
+			target := fsys.lookup(path.Clean("/" + hdr.Linkname)[1:])
+			if target == nil || !target.mode.IsRegular() {
+				return nil
+			}
+			e.mode, e.size, e.data, e.src = target.mode, target.size, target.data, target.src
+		default:
+			return nil
+		}
+		fsys.put(name, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+// writeLayersTar writes a tar archive of the content of the image ref, read
+// with lr, to w, without holding the data of its files in memory.
+func writeLayersTar(ctx context.Context, lr image.LayerReader, ref image.Reference, w io.Writer) error {
+	fsys, err := readLayers(ctx, lr, ref, false)
+	if err != nil {
+		return err
+	}
+
+	// Directories are written first, so that they precede their files, and
+	// files as their data is read again from the layers. The files that
+	// share the data of a tar entry, as hard links do, are written once,
+	// and then as hard links.
+	tw := tar.NewWriter(w)
+	filesBySrc := map[int][]string{}
+	err = fsys.root.walk("", func(name string, e *memEntry) error {
+		if e.mode.IsDir() {
+			return tw.WriteHeader(dirHeader(name, e))
+		}
+		filesBySrc[e.src] = append(filesBySrc[e.src], name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var index int
+	err = lr.ReadLayers(ctx, ref, func(_ int, _ *tar.Header, r io.Reader) error {
+		index++
+		names := filesBySrc[index]
+		if len(names) == 0 {
+			return nil
+		}
+		e := fsys.lookup(names[0])
+		if err := tw.WriteHeader(fileHeader(names[0], e)); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, r); err != nil {
+			return err
+		}
+		for _, name := range names[1:] {
+			hdr := fileHeader(name, fsys.lookup(name))
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeLink, names[0], 0
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+		}
+		delete(filesBySrc, index)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(filesBySrc) > 0 {
+		return fmt.Errorf("layers of image %s changed while they were read", ref)
+	}
+	return tw.Close()
+}
+
+func dirHeader(name string, info fs.FileInfo) *tar.Header {
+	return &tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: int64(info.Mode().Perm()), ModTime: info.ModTime()}
+}
+
+func fileHeader(name string, info fs.FileInfo) *tar.Header {
+	return &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}
+}
+
+// walkContentDir calls fn with the slash-separated name, relative to root,
+// and the file info of each regular file and directory of the content of the
+// bundle image unpacked in root, parents first.
+func walkContentDir(root string, fn func(name string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		switch {
+		case !isContent(name) && d.IsDir():
+			return fs.SkipDir
+		case !isContent(name), !d.IsDir() && !d.Type().IsRegular():
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(name, info)
+	})
+}
+
+// readDirFS reads the content of the bundle image unpacked in root into an
+// in-memory filesystem.
+func readDirFS(root string) (*memFS, error) {
+	fsys := newMemFS()
+	var size int64
+	err := walkContentDir(root, func(name string, info fs.FileInfo) error {
+		e := &memEntry{mode: info.Mode(), modTime: info.ModTime()}
+		if !info.IsDir() {
+			if size += info.Size(); size > maxContentSize {
+				return fmt.Errorf("bundle content exceeds the maximum size of %d bytes", maxContentSize)
+			}
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+			if err != nil {
+				return err
+			}
+			e.data, e.size = data, int64(len(data))
+		}
+		fsys.put(name, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+func pullAndUnpack(ctx context.Context, reg image.Registry, ref image.Reference, dir string) error {
+	if err := reg.Pull(ctx, ref); err != nil {
+		return err
+	}
+	return reg.Unpack(ctx, ref, dir)
 }
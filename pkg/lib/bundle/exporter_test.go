@@ -1,10 +1,20 @@
 package bundle
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExportForBundleWithBadImage(t *testing.T) {
@@ -19,3 +29,188 @@ func TestExportForBundleWithBadImage(t *testing.T) {
 	err = exporter.Export(true, false)
 	assert.Error(t, err)
 }
+
+func mockBundleRegistry() (*image.MockRegistry, image.Reference) {
+	ref := image.SimpleReference("quay.io/example/bundle:v1")
+	return &image.MockRegistry{RemoteImages: map[image.Reference]*image.MockImage{
+		ref: {FS: fstest.MapFS{
+			"manifests/example.csv.yaml":  &fstest.MapFile{Data: []byte("kind: ClusterServiceVersion\n")},
+			"manifests/example.crd.yaml":  &fstest.MapFile{Data: []byte("kind: CustomResourceDefinition\n")},
+			"metadata/annotations.yaml":   &fstest.MapFile{Data: []byte("annotations: {}\n")},
+			"metadata/nested/extra.yaml":  &fstest.MapFile{Data: []byte("extra: true\n")},
+			"metadata/nested/extra2.yaml": &fstest.MapFile{Data: []byte("extra: 2\n")},
+		}},
+	}}, ref
+}
+
+// unpackOnlyRegistry hides the image.LayerReader implementation of a
+// registry, so that images are unpacked to a directory.
+type unpackOnlyRegistry struct {
+	image.Registry
+}
+
+// layeredRegistry is a registry whose images are made of layers, given as
+// tar entries.
+type layeredRegistry struct {
+	image.Registry
+	layers [][]tarEntry
+}
+
+type tarEntry struct {
+	hdr  tar.Header
+	data string
+}
+
+func (r *layeredRegistry) ReadLayers(_ context.Context, _ image.Reference, fn func(layer int, hdr *tar.Header, r io.Reader) error) error {
+	for i, layer := range r.layers {
+		for _, e := range layer {
+			hdr := e.hdr
+			if err := fn(i, &hdr, strings.NewReader(e.data)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestUnpackFS(t *testing.T) {
+	reg, ref := mockBundleRegistry()
+	for name, reg := range map[string]image.Registry{
+		"LayerReader": reg,
+		"Unpack":      unpackOnlyRegistry{reg},
+	} {
+		t.Run(name, func(t *testing.T) {
+			testUnpackFS(t, reg, ref)
+		})
+	}
+}
+
+func testUnpackFS(t *testing.T, reg image.Registry, ref image.Reference) {
+	fsys, err := UnpackFS(context.Background(), reg, ref)
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys,
+		"manifests/example.csv.yaml",
+		"manifests/example.crd.yaml",
+		"metadata/annotations.yaml",
+		"metadata/nested/extra.yaml",
+		"metadata/nested/extra2.yaml",
+	))
+	data, err := fs.ReadFile(fsys, "manifests/example.csv.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "kind: ClusterServiceVersion\n", string(data))
+
+	_, err = UnpackFS(context.Background(), reg, image.SimpleReference("quay.io/example/missing:v1"))
+	require.Error(t, err)
+}
+
+func layeredBundleRegistry() (*layeredRegistry, image.Reference) {
+	file := func(name, data string) tarEntry {
+		return tarEntry{hdr: tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(data))}, data: data}
+	}
+	dir := func(name string) tarEntry {
+		return tarEntry{hdr: tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: 0755}}
+	}
+	reg, ref := mockBundleRegistry()
+	return &layeredRegistry{Registry: reg, layers: [][]tarEntry{
+		{
+			dir("./"),
+			dir("./manifests/"),
+			file("./manifests/example.csv.yaml", "kind: ClusterServiceVersion\n"),
+			file("./manifests/removed.yaml", "removed: true\n"),
+			file("metadata/annotations.yaml", "annotations: {}\n"),
+			file("metadata/nested/old.yaml", "old: true\n"),
+			file("tests/scorecard/config.yaml", "kind: Configuration\n"),
+			{hdr: tar.Header{Typeflag: tar.TypeSymlink, Name: "manifests/link.yaml", Linkname: "example.csv.yaml"}},
+		},
+		{
+			file("manifests/.wh.removed.yaml", ""),
+			file("metadata/nested/new.yaml", "new: true\n"),
+			file("metadata/nested/.wh..wh..opq", ""),
+			file("metadata/annotations.yaml", "annotations: {updated: true}\n"),
+			{hdr: tar.Header{Typeflag: tar.TypeLink, Name: "manifests/hardlink.yaml", Linkname: "manifests/example.csv.yaml"}},
+		},
+	}}, ref
+}
+
+// The content of the image of layeredBundleRegistry, after its whiteouts are
+// applied.
+var layeredBundleFiles = map[string]string{
+	"manifests/example.csv.yaml": "kind: ClusterServiceVersion\n",
+	"manifests/hardlink.yaml":    "kind: ClusterServiceVersion\n",
+	"metadata/annotations.yaml":  "annotations: {updated: true}\n",
+	"metadata/nested/new.yaml":   "new: true\n",
+}
+
+func TestUnpackFSLayers(t *testing.T) {
+	reg, ref := layeredBundleRegistry()
+	fsys, err := UnpackFS(context.Background(), reg, ref)
+	require.NoError(t, err)
+	files := map[string]string{}
+	require.NoError(t, fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, path)
+		files[path] = string(data)
+		return err
+	}))
+	require.Equal(t, layeredBundleFiles, files)
+
+	t.Run("MaxSize", func(t *testing.T) {
+		reg, ref := mockBundleRegistry()
+		huge := &layeredRegistry{Registry: reg, layers: [][]tarEntry{{
+			{hdr: tar.Header{Typeflag: tar.TypeReg, Name: "manifests/huge.yaml", Size: maxContentSize + 1}},
+		}}}
+		_, err := UnpackFS(context.Background(), huge, ref)
+		require.ErrorContains(t, err, "bundle content exceeds the maximum size")
+	})
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(t, err)
+		switch hdr.Typeflag {
+		case tar.TypeLink:
+			files[hdr.Name] = files[hdr.Linkname]
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			files[hdr.Name] = string(data)
+		}
+	}
+}
+
+func TestUnpackTarLayers(t *testing.T) {
+	reg, ref := layeredBundleRegistry()
+	buf := &bytes.Buffer{}
+	require.NoError(t, UnpackTar(context.Background(), reg, ref, buf))
+	require.Equal(t, layeredBundleFiles, readTar(t, buf))
+}
+
+func TestUnpackTar(t *testing.T) {
+	reg, ref := mockBundleRegistry()
+	expected := map[string]string{
+		"manifests/example.csv.yaml":  "kind: ClusterServiceVersion\n",
+		"manifests/example.crd.yaml":  "kind: CustomResourceDefinition\n",
+		"metadata/annotations.yaml":   "annotations: {}\n",
+		"metadata/nested/extra.yaml":  "extra: true\n",
+		"metadata/nested/extra2.yaml": "extra: 2\n",
+	}
+	for name, reg := range map[string]image.Registry{
+		"LayerReader": reg,
+		"Unpack":      unpackOnlyRegistry{reg},
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, UnpackTar(context.Background(), reg, ref, buf))
+			require.Equal(t, expected, readTar(t, buf))
+		})
+	}
+}
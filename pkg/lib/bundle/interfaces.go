@@ -20,10 +20,20 @@ type BundleImageValidator interface {
 	ValidateBundleContent(directory string) error
 }
 
-// NewImageValidator is a constructor that returns an ImageValidator
+// NewImageValidator is a constructor that returns an ImageValidator. If the
+// registry is an ImageProber, it is used to probe images.
 func NewImageValidator(registry image.Registry, logger *logrus.Entry, options ...string) BundleImageValidator {
+	prober, _ := registry.(ImageProber)
+	return NewImageProbingValidator(registry, prober, logger, options...)
+}
+
+// NewImageProbingValidator is a constructor that returns an ImageValidator
+// that probes images with prober, for registries that cannot probe images
+// themselves.
+func NewImageProbingValidator(registry image.Registry, prober ImageProber, logger *logrus.Entry, options ...string) BundleImageValidator {
 	return imageValidator{
 		registry: registry,
+		prober:   prober,
 		logger:   logger,
 		optional: options,
 	}
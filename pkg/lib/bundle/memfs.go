@@ -0,0 +1,199 @@
+package bundle
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// memFS is a read-only, in-memory fs.FS. It is a tree of entries, so that
+// the layers of an image can replace and delete whole directories without
+// scanning the other entries.
+type memFS struct {
+	root *memEntry
+}
+
+// memEntry is a file or a directory of a memFS.
+type memEntry struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	size    int64
+	data    []byte
+	// children are the entries of a directory, by name.
+	children map[string]*memEntry
+
+	// layer is the index of the image layer that added the entry, and src
+	// the index, across all layers, of the tar entry that holds the data of
+	// a regular file.
+	layer int
+	src   int
+}
+
+func newMemFS() *memFS {
+	return &memFS{root: &memEntry{name: ".", mode: fs.ModeDir | 0755, children: map[string]*memEntry{}}}
+}
+
+// lookup returns the entry of name, or nil if there is none.
+func (fsys *memFS) lookup(name string) *memEntry {
+	e := fsys.root
+	if name == "." {
+		return e
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if e = e.children[elem]; e == nil {
+			return nil
+		}
+	}
+	return e
+}
+
+// mkdirAll returns the directory name, creating it and its parents, or
+// replacing the entries in its path that are not directories, as needed.
+func (fsys *memFS) mkdirAll(name string, layer int) *memEntry {
+	e := fsys.root
+	if name == "." {
+		return e
+	}
+	for _, elem := range strings.Split(name, "/") {
+		child := e.children[elem]
+		if child == nil || !child.mode.IsDir() {
+			child = &memEntry{name: elem, mode: fs.ModeDir | 0755, layer: layer, children: map[string]*memEntry{}}
+			e.children[elem] = child
+		}
+		e = child
+	}
+	return e
+}
+
+// put adds e as name, along with its missing parents. It replaces the
+// existing entry of name, and everything below it, unless both are
+// directories, in which case the existing directory takes the mode of e and
+// keeps its entries, as when a layer of an image adds a directory again.
+func (fsys *memFS) put(name string, e *memEntry) {
+	dir := fsys.mkdirAll(path.Dir(name), e.layer)
+	e.name = path.Base(name)
+	if existing := dir.children[e.name]; existing != nil && existing.mode.IsDir() && e.mode.IsDir() {
+		existing.mode, existing.modTime, existing.layer = e.mode, e.modTime, e.layer
+		return
+	}
+	if e.mode.IsDir() {
+		e.children = map[string]*memEntry{}
+	}
+	dir.children[e.name] = e
+}
+
+// remove removes name and everything below it, if it exists.
+func (fsys *memFS) remove(name string) {
+	if dir := fsys.lookup(path.Dir(name)); dir != nil {
+		delete(dir.children, path.Base(name))
+	}
+}
+
+// removeOlder removes the entries below the directory name that were added
+// by layers before layer, as an opaque whiteout in layer does. Directories
+// that contain entries of layer are kept.
+func (fsys *memFS) removeOlder(name string, layer int) {
+	if dir := fsys.lookup(name); dir != nil {
+		dir.removeOlder(layer)
+	}
+}
+
+func (e *memEntry) removeOlder(layer int) {
+	for name, child := range e.children {
+		child.removeOlder(layer)
+		if child.layer < layer && len(child.children) == 0 {
+			delete(e.children, name)
+		}
+	}
+}
+
+// walk calls fn with each entry below the directory e, parents first, in
+// lexical order.
+func (e *memEntry) walk(dir string, fn func(name string, e *memEntry) error) error {
+	for _, childName := range e.childNames() {
+		child := e.children[childName]
+		name := path.Join(dir, childName)
+		if err := fn(name, child); err != nil {
+			return err
+		}
+		if err := child.walk(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *memEntry) childNames() []string {
+	names := make([]string, 0, len(e.children))
+	for name := range e.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (fsys *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e := fsys.lookup(name)
+	if e == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if e.mode.IsDir() {
+		return &memDir{memEntry: e, path: name, names: e.childNames()}, nil
+	}
+	return &memFile{memEntry: e, Reader: bytes.NewReader(e.data)}, nil
+}
+
+func (e *memEntry) Name() string               { return e.name }
+func (e *memEntry) Size() int64                { return e.size }
+func (e *memEntry) Mode() fs.FileMode          { return e.mode }
+func (e *memEntry) ModTime() time.Time         { return e.modTime }
+func (e *memEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *memEntry) Sys() interface{}           { return nil }
+func (e *memEntry) Stat() (fs.FileInfo, error) { return e, nil }
+func (e *memEntry) Close() error               { return nil }
+
+type memFile struct {
+	*memEntry
+	*bytes.Reader
+}
+
+// Size disambiguates between the size of the file info and that of the
+// unread portion of the reader.
+func (f *memFile) Size() int64 { return f.memEntry.Size() }
+
+type memDir struct {
+	*memEntry
+	path   string
+	names  []string
+	offset int
+}
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.names[d.offset:]
+	if n > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(remaining) {
+		remaining = remaining[:n]
+	}
+	entries := make([]fs.DirEntry, 0, len(remaining))
+	for _, name := range remaining {
+		if child := d.children[name]; child != nil {
+			entries = append(entries, fs.FileInfoToDirEntry(child))
+		}
+	}
+	d.offset += len(remaining)
+	return entries, nil
+}
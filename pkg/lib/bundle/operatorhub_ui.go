@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	v1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+const (
+	validateOperatorHubUIKey = "operatorhub-ui"
+
+	// minOperatorHubDescriptionLength is the minimum length of the long
+	// description of a CSV, which is the body of its operatorhub.io page.
+	minOperatorHubDescriptionLength = 100
+)
+
+// operatorHubIconMediaTypes are the icon media types that operatorhub.io
+// displays.
+var operatorHubIconMediaTypes = sets.New[string]("image/gif", "image/jpeg", "image/png", "image/svg+xml")
+
+// ImageProber resolves image references in their registries, with the
+// credentials of the registry client. *containerdregistry.Registry is an
+// ImageProber.
+type ImageProber interface {
+	Digest(ctx context.Context, ref image.Reference) (digest.Digest, error)
+}
+
+// validateOperatorHubUI checks that a CSV sets the fields that operatorhub.io
+// displays: an icon, categories, a short and a long description, and the
+// operator container image. All images of the CSV must be referenced by
+// digest and, if prober is set, must be resolvable in their registries.
+func validateOperatorHubUI(ctx context.Context, csv *v1.ClusterServiceVersion, prober ImageProber) []error {
+	var errs []error
+
+	if len(csv.Spec.Icon) == 0 {
+		errs = append(errs, fmt.Errorf("spec.icon: an icon is required"))
+	}
+	for i, icon := range csv.Spec.Icon {
+		if icon.Data == "" {
+			errs = append(errs, fmt.Errorf("spec.icon[%d].base64data: icon data is required", i))
+		}
+		if !operatorHubIconMediaTypes.Has(icon.MediaType) {
+			errs = append(errs, fmt.Errorf("spec.icon[%d].mediatype: %q is not one of %s", i, icon.MediaType, strings.Join(sets.List(operatorHubIconMediaTypes), ", ")))
+		}
+	}
+
+	annotations := csv.GetAnnotations()
+	if strings.TrimSpace(annotations["categories"]) == "" {
+		errs = append(errs, fmt.Errorf("metadata.annotations.categories: at least one category is required"))
+	}
+	if strings.TrimSpace(annotations["description"]) == "" {
+		errs = append(errs, fmt.Errorf("metadata.annotations.description: a short description is required"))
+	}
+	if n := len(strings.TrimSpace(csv.Spec.Description)); n < minOperatorHubDescriptionLength {
+		errs = append(errs, fmt.Errorf("spec.description: the description must be at least %d characters long, found %d", minOperatorHubDescriptionLength, n))
+	}
+	if annotations["containerImage"] == "" {
+		errs = append(errs, fmt.Errorf("metadata.annotations.containerImage: the operator container image is required"))
+	}
+
+	for _, img := range csvImages(csv) {
+		errs = append(errs, validateOperatorHubImage(ctx, img, prober)...)
+	}
+	return errs
+}
+
+func validateOperatorHubImage(ctx context.Context, img string, prober ImageProber) []error {
+	named, err := reference.ParseNormalizedNamed(img)
+	if err != nil {
+		return []error{fmt.Errorf("image %q is not a valid image reference: %v", img, err)}
+	}
+	var errs []error
+	if _, ok := named.(reference.Canonical); !ok {
+		errs = append(errs, fmt.Errorf("image %q is not referenced by digest", img))
+	}
+	if prober != nil {
+		if _, err := prober.Digest(ctx, image.SimpleReference(img)); err != nil {
+			errs = append(errs, fmt.Errorf("image %q is unreachable: %v", img, err))
+		}
+	}
+	return errs
+}
+
+// csvImages returns the sorted, deduplicated images of a CSV: its container
+// image annotation, related images, and the images of the containers of its
+// deployments.
+func csvImages(csv *v1.ClusterServiceVersion) []string {
+	images := sets.New[string]()
+	if img := csv.GetAnnotations()["containerImage"]; img != "" {
+		images.Insert(img)
+	}
+	for _, ri := range csv.Spec.RelatedImages {
+		images.Insert(ri.Image)
+	}
+	for _, d := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range d.Spec.Template.Spec.InitContainers {
+			images.Insert(c.Image)
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			images.Insert(c.Image)
+		}
+	}
+	images.Delete("")
+	return sets.List(images)
+}
@@ -0,0 +1,144 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+const (
+	operatorImage = "quay.io/example/operator@sha256:2b6e5ba4a34ec0f5dfbc9e0dc6dc9a53b3b2f24c6fa0b1d5c93ddbd9f5e8ac84"
+	operandImage  = "quay.io/example/operand@sha256:0d1a1e8b1c2f2bf5a46bf9f6c4dc1ec0d4f6d2b0c1e0b1a8e5c3d1b3e9f7a5c2"
+)
+
+type fakeProber map[string]error
+
+func (p fakeProber) Digest(_ context.Context, ref image.Reference) (digest.Digest, error) {
+	if err, ok := p[ref.String()]; ok {
+		return "", err
+	}
+	return digest.FromString(ref.String()), nil
+}
+
+func operatorHubCSV() *v1.ClusterServiceVersion {
+	return &v1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "example.v1.0.0",
+			Annotations: map[string]string{
+				"categories":     "Database",
+				"description":    "Runs example databases",
+				"containerImage": operatorImage,
+			},
+		},
+		Spec: v1.ClusterServiceVersionSpec{
+			Description: strings.Repeat("The example operator runs example databases. ", 3),
+			Icon:        []v1.Icon{{Data: "PHN2Zy8+", MediaType: "image/svg+xml"}},
+			RelatedImages: []v1.RelatedImage{
+				{Name: "operand", Image: operandImage},
+			},
+			InstallStrategy: v1.NamedInstallStrategy{
+				StrategySpec: v1.StrategyDetailsDeployment{
+					DeploymentSpecs: []v1.StrategyDeploymentSpec{{
+						Name: "example-operator",
+						Spec: appsv1.DeploymentSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									Containers: []corev1.Container{{Name: "operator", Image: operatorImage}},
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateOperatorHubUI(t *testing.T) {
+	type spec struct {
+		name     string
+		mutate   func(*v1.ClusterServiceVersion)
+		prober   ImageProber
+		expected []string
+	}
+	specs := []spec{
+		{
+			name:   "Valid",
+			prober: fakeProber{},
+		},
+		{
+			name: "MissingMetadata",
+			mutate: func(csv *v1.ClusterServiceVersion) {
+				csv.Spec.Icon = nil
+				csv.Spec.Description = "Too short"
+				delete(csv.Annotations, "categories")
+				delete(csv.Annotations, "description")
+				delete(csv.Annotations, "containerImage")
+			},
+			expected: []string{
+				"spec.icon: an icon is required",
+				"metadata.annotations.categories: at least one category is required",
+				"metadata.annotations.description: a short description is required",
+				"spec.description: the description must be at least 100 characters long, found 9",
+				"metadata.annotations.containerImage: the operator container image is required",
+			},
+		},
+		{
+			name: "InvalidIcon",
+			mutate: func(csv *v1.ClusterServiceVersion) {
+				csv.Spec.Icon = []v1.Icon{{MediaType: "image/bmp"}}
+			},
+			expected: []string{
+				"spec.icon[0].base64data: icon data is required",
+				`spec.icon[0].mediatype: "image/bmp" is not one of image/gif, image/jpeg, image/png, image/svg+xml`,
+			},
+		},
+		{
+			name: "ImagesNotByDigest",
+			mutate: func(csv *v1.ClusterServiceVersion) {
+				csv.Spec.RelatedImages = append(csv.Spec.RelatedImages,
+					v1.RelatedImage{Name: "tagged", Image: "quay.io/example/operand:v1"},
+					v1.RelatedImage{Name: "invalid", Image: "quay.io/example/Operand:v1"},
+				)
+				csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.InitContainers = []corev1.Container{
+					{Name: "init", Image: "busybox"},
+				}
+			},
+			expected: []string{
+				`image "busybox" is not referenced by digest`,
+				`image "quay.io/example/Operand:v1" is not a valid image reference: invalid reference format: repository name (example/Operand) must be lowercase`,
+				`image "quay.io/example/operand:v1" is not referenced by digest`,
+			},
+		},
+		{
+			name:   "UnreachableImages",
+			prober: fakeProber{operandImage: errors.New("not found")},
+			expected: []string{
+				`image "` + operandImage + `" is unreachable: not found`,
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			csv := operatorHubCSV()
+			if s.mutate != nil {
+				s.mutate(csv)
+			}
+			var actual []string
+			for _, err := range validateOperatorHubUI(context.Background(), csv, s.prober) {
+				actual = append(actual, err.Error())
+			}
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
@@ -28,10 +28,16 @@ import (
 )
 
 const (
-	v1CRDapiVersion          = "apiextensions.k8s.io/v1"
-	v1beta1CRDapiVersion     = "apiextensions.k8s.io/v1beta1"
-	validateOperatorHubKey   = "operatorhub"
-	validateBundleObjectsKey = "bundle-objects"
+	v1CRDapiVersion           = "apiextensions.k8s.io/v1"
+	v1beta1CRDapiVersion      = "apiextensions.k8s.io/v1beta1"
+	validateOperatorHubKey    = "operatorhub"
+	validateBundleObjectsKey  = "bundle-objects"
+	validateDeprecatedAPIsKey = "deprecated-apis"
+
+	// K8sVersionOption is the optional value that sets the Kubernetes
+	// version that the deprecated-apis validator checks bundles against,
+	// e.g. "k8s-version=1.25".
+	K8sVersionOption = "k8s-version"
 )
 
 type Meta struct {
@@ -42,6 +48,7 @@ type Meta struct {
 // imageValidator is a struct implementation of the Indexer interface
 type imageValidator struct {
 	registry image.Registry
+	prober   ImageProber
 	logger   *log.Entry
 	optional []string
 }
@@ -398,6 +405,15 @@ func (i imageValidator) ValidateBundleContent(manifestDir string) error {
 		}
 	}
 
+	// Run the operatorhub.io UI metadata validation if specified
+	if _, ok := optionalValidators[validateOperatorHubUIKey]; ok {
+		if i.prober == nil {
+			i.logger.Warn("Image probing is not supported by the registry, only checking that images are referenced by digest")
+		}
+		i.logger.Debug("Performing operatorhub.io UI metadata validation")
+		validationErrors = append(validationErrors, validateOperatorHubUI(context.TODO(), csv, i.prober)...)
+	}
+
 	// Run the bundle object validation if specified
 	if _, ok := optionalValidators[validateBundleObjectsKey]; ok {
 		i.logger.Debug("Performing bundle objects validation")
@@ -409,6 +425,26 @@ func (i imageValidator) ValidateBundleContent(manifestDir string) error {
 		}
 	}
 
+	// Run the deprecated API validation if specified
+	if _, ok := optionalValidators[validateDeprecatedAPIsKey]; ok {
+		i.logger.Debug("Performing deprecated APIs validation")
+		deprecatedAPIValidator, err := validation.NewDeprecatedAPIValidator(optionalValidators[K8sVersionOption])
+		if err != nil {
+			validationErrors = append(validationErrors, err)
+		} else {
+			bundle := registry.NewBundle(csvName, &registry.Annotations{}, unstObjs...)
+			results := deprecatedAPIValidator.Validate(bundle)
+			if len(results) > 0 {
+				for _, err := range results[0].Errors {
+					validationErrors = append(validationErrors, err)
+				}
+				for _, warning := range results[0].Warnings {
+					i.logger.Warn(warning.Error())
+				}
+			}
+		}
+	}
+
 	if len(validationErrors) > 0 {
 		return NewValidationError(validationErrors)
 	}
@@ -446,15 +482,16 @@ func validateKubectlable(fileBytes []byte) error {
 }
 
 // parseOptions looks at the provided optional validators provided via a command line flag and returns an map
-// example input: ["operatorhub,bundle-objects"]
-// example output: {"operatorhub": {}, "bundle-objects": {}}
-func parseOptions(args []string) map[string]struct{} {
-	validators := make(map[string]struct{})
+// of them to their values, which are only set for options of the form key=value
+// example input: ["operatorhub,bundle-objects,k8s-version=1.25"]
+// example output: {"operatorhub": "", "bundle-objects": "", "k8s-version": "1.25"}
+func parseOptions(args []string) map[string]string {
+	validators := make(map[string]string)
 	for _, arg := range args {
 		arr := strings.Split(arg, ",")
-		for _, key := range arr {
-			key = strings.TrimSpace(key)
-			validators[key] = struct{}{}
+		for _, option := range arr {
+			key, value, _ := strings.Cut(option, "=")
+			validators[strings.TrimSpace(key)] = strings.TrimSpace(value)
 		}
 	}
 	return validators
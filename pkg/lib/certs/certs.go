@@ -15,7 +15,7 @@ func RootCAs(CaFile string) (*x509.CertPool, error) {
 	if len(CaFile) > 0 {
 		certs, err := os.ReadFile(CaFile)
 		if err != nil {
-			return nil, fmt.Errorf("failed to append %q to RootCAs: %v", certs, err)
+			return nil, fmt.Errorf("failed to append %q to RootCAs: %v", CaFile, err)
 		}
 		if ok := rootCAs.AppendCertsFromPEM(certs); !ok {
 			return nil, fmt.Errorf("unable to add certs specified in %s", CaFile)
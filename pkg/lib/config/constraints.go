@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// CheckConstraints returns a message for every olm.constraint property of the
+// bundles of cfg that is invalid, e.g. because its CEL rule does not compile
+// in the environment that OLM evaluates it in.
+//
+// Constraints are only checked when catalogs are rendered and validated, not
+// when they are served, so that catalogs that were valid before constraints
+// were checked can still be served.
+func CheckConstraints(cfg declcfg.DeclarativeConfig) []string {
+	var msgs []string
+	for _, b := range cfg.Bundles {
+		for i, p := range b.Properties {
+			if p.Type != property.TypeConstraint {
+				continue
+			}
+			var c property.Constraint
+			if err := json.Unmarshal(p.Value, &c); err != nil {
+				msgs = append(msgs, fmt.Sprintf("bundle %q: %v", b.Name, property.ParseError{Idx: i, Typ: p.Type, Err: err}))
+				continue
+			}
+			if err := c.Validate(); err != nil {
+				msgs = append(msgs, fmt.Sprintf("bundle %q: invalid property[%d] of type %q: %v", b.Name, i, p.Type, err))
+			}
+		}
+	}
+	return msgs
+}
+
+func constraintsRule() Rule {
+	return NewRule(RuleConstraints, `"olm.constraint" properties of bundles are valid`, SeverityError, func(cfg declcfg.DeclarativeConfig) []string {
+		return CheckConstraints(cfg)
+	})
+}
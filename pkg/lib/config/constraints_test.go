@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestCheckConstraints(t *testing.T) {
+	cfg := declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{
+			{Package: "foo", Name: "foo.v1", Properties: []property.Property{
+				property.MustBuildPackage("foo", "1.0.0"),
+				property.MustBuildConstraintCEL(`properties.exists(p, p.type == "certified")`, ""),
+			}},
+			{Package: "foo", Name: "foo.v2", Properties: []property.Property{
+				property.MustBuildPackage("foo", "2.0.0"),
+				property.MustBuildConstraintCEL(`"certified"`, ""),
+				{Type: property.TypeConstraint, Value: []byte(`"certified"`)},
+			}},
+		},
+	}
+	require.Equal(t, []string{
+		`bundle "foo.v2": invalid property[1] of type "olm.constraint": invalid cel rule "\"certified\"": cel expressions must have type Bool`,
+		`bundle "foo.v2": parse property[2] of type "olm.constraint": json: cannot unmarshal string into Go value of type property.Constraint`,
+	}, CheckConstraints(cfg))
+
+	// The constraints rule is enabled by default.
+	require.Equal(t, []Finding{
+		{RuleID: RuleConstraints, Severity: SeverityError, Message: CheckConstraints(cfg)[0]},
+		{RuleID: RuleConstraints, Severity: SeverityError, Message: CheckConstraints(cfg)[1]},
+	}, constraintsOnly(DefaultRuleSet(SizeLimits{}).Check(cfg)))
+}
+
+func constraintsOnly(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.RuleID == RuleConstraints {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// BundleRef identifies a bundle within a catalog.
+type BundleRef struct {
+	Package string
+	Name    string
+}
+
+func (r BundleRef) String() string {
+	return fmt.Sprintf("%s/%s", r.Package, r.Name)
+}
+
+// DuplicateBundles describes a set of bundles, registered under different
+// names or packages, that share the same bundle image or the same content.
+type DuplicateBundles struct {
+	// Reason describes what the bundles have in common, e.g.
+	// `image "quay.io/foo/bar@sha256:..."` or `objects sha256:...`.
+	Reason  string
+	Bundles []BundleRef
+}
+
+func (d DuplicateBundles) String() string {
+	refs := make([]string, 0, len(d.Bundles))
+	for _, b := range d.Bundles {
+		refs = append(refs, b.String())
+	}
+	return fmt.Sprintf("bundles %s share the same %s", strings.Join(refs, ", "), d.Reason)
+}
+
+// FindDuplicateBundles reports groups of bundles in cfg that reference the
+// same bundle image, or whose objects hash to the same digest. Bundles are
+// compared across all packages. A group of bundles is reported only once,
+// even if the bundles share both their image and their content.
+func FindDuplicateBundles(cfg declcfg.DeclarativeConfig) []DuplicateBundles {
+	var (
+		keys    []string
+		reasons = map[string]string{}
+		groups  = map[string][]BundleRef{}
+	)
+	add := func(key, reason string, ref BundleRef) {
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			reasons[key] = reason
+		}
+		groups[key] = append(groups[key], ref)
+	}
+	for _, b := range cfg.Bundles {
+		ref := BundleRef{Package: b.Package, Name: b.Name}
+		if b.Image != "" {
+			add("image:"+b.Image, fmt.Sprintf("image %q", b.Image), ref)
+		}
+		if len(b.Objects) > 0 {
+			digest := objectsDigest(b.Objects)
+			add("objects:"+digest, "objects "+digest, ref)
+		}
+	}
+
+	var (
+		duplicates []DuplicateBundles
+		reported   = map[string]struct{}{}
+	)
+	for _, key := range keys {
+		refs := groups[key]
+		if len(refs) < 2 {
+			continue
+		}
+		sort.Slice(refs, func(i, j int) bool { return refs[i].String() < refs[j].String() })
+		members := make([]string, 0, len(refs))
+		for _, r := range refs {
+			members = append(members, r.String())
+		}
+		membersKey := strings.Join(members, "\n")
+		if _, ok := reported[membersKey]; ok {
+			continue
+		}
+		reported[membersKey] = struct{}{}
+		duplicates = append(duplicates, DuplicateBundles{Reason: reasons[key], Bundles: refs})
+	}
+	return duplicates
+}
+
+// objectsDigest hashes a bundle's objects independently of their order.
+func objectsDigest(objects []string) string {
+	sorted := append([]string{}, objects...)
+	sort.Strings(sorted)
+	h := sha256.New()
+	for _, obj := range sorted {
+		fmt.Fprintf(h, "%d:%s", len(obj), obj)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestFindDuplicateBundles(t *testing.T) {
+	csv := `{"kind":"ClusterServiceVersion","metadata":{"name":"foo.v1.0.0"}}`
+	crd := `{"kind":"CustomResourceDefinition","metadata":{"name":"foos.test.io"}}`
+
+	type spec struct {
+		name     string
+		bundles  []declcfg.Bundle
+		expected []DuplicateBundles
+	}
+	specs := []spec{
+		{
+			name: "NoDuplicates",
+			bundles: []declcfg.Bundle{
+				{Package: "foo", Name: "foo.v1.0.0", Image: "foo:v1.0.0"},
+				{Package: "foo", Name: "foo.v1.1.0", Image: "foo:v1.1.0"},
+			},
+		},
+		{
+			name: "SameImageAcrossPackages",
+			bundles: []declcfg.Bundle{
+				{Package: "foo", Name: "foo.v1.0.0", Image: "foo:v1.0.0"},
+				{Package: "bar", Name: "bar.v1.0.0", Image: "foo:v1.0.0"},
+			},
+			expected: []DuplicateBundles{{
+				Reason:  `image "foo:v1.0.0"`,
+				Bundles: []BundleRef{{Package: "bar", Name: "bar.v1.0.0"}, {Package: "foo", Name: "foo.v1.0.0"}},
+			}},
+		},
+		{
+			name: "SameContentDifferentImages",
+			bundles: []declcfg.Bundle{
+				{Package: "foo", Name: "foo.v1.0.0", Image: "foo:v1.0.0", Objects: []string{csv, crd}},
+				{Package: "foo", Name: "foo.v1.0.0-copy", Image: "mirror/foo:v1.0.0", Objects: []string{crd, csv}},
+			},
+			expected: []DuplicateBundles{{
+				Reason:  "objects " + objectsDigest([]string{csv, crd}),
+				Bundles: []BundleRef{{Package: "foo", Name: "foo.v1.0.0"}, {Package: "foo", Name: "foo.v1.0.0-copy"}},
+			}},
+		},
+		{
+			name: "SameImageAndContentReportedOnce",
+			bundles: []declcfg.Bundle{
+				{Package: "foo", Name: "foo.v1.0.0", Image: "foo:v1.0.0", Objects: []string{csv}},
+				{Package: "bar", Name: "bar.v1.0.0", Image: "foo:v1.0.0", Objects: []string{csv}},
+			},
+			expected: []DuplicateBundles{{
+				Reason:  `image "foo:v1.0.0"`,
+				Bundles: []BundleRef{{Package: "bar", Name: "bar.v1.0.0"}, {Package: "foo", Name: "foo.v1.0.0"}},
+			}},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual := FindDuplicateBundles(declcfg.DeclarativeConfig{Bundles: s.bundles})
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
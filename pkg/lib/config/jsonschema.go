@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// RuleJSONSchemas is the ID of the rule returned by NewJSONSchemaRule.
+const RuleJSONSchemas = "json-schemas"
+
+// NewJSONSchemaRule returns a rule that validates the objects of schemas that
+// are not defined by declarative configs, such as olm.package or olm.bundle,
+// against JSON Schemas. schemas maps the schema names of the objects to the
+// JSON Schema documents to validate them against. Objects of schemas that
+// are not in schemas are not validated.
+//
+// The JSON Schemas are draft-07 JSON Schemas, like those printed by
+// declcfg.JSONSchemas, but they must be self-contained, as references with $ref
+// are not supported. The contains, propertyNames, if, then and else keywords
+// are not supported either, and JSON Schemas that use them are rejected.
+func NewJSONSchemaRule(schemas map[string][]byte) (Rule, error) {
+	validators := map[string]*validate.SchemaValidator{}
+	for name, data := range schemas {
+		if _, ok := declcfg.JSONSchemas()[name]; ok {
+			return nil, fmt.Errorf("JSON Schema for schema %q: objects of schemas defined by declarative configs cannot be validated against JSON Schemas", name)
+		}
+		var raw interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parse JSON Schema for schema %q: %v", name, err)
+		}
+		translated, err := toDraft04(raw, "#")
+		if err != nil {
+			return nil, fmt.Errorf("JSON Schema for schema %q: %v", name, err)
+		}
+		draft04, err := json.Marshal(translated)
+		if err != nil {
+			return nil, fmt.Errorf("parse JSON Schema for schema %q: %v", name, err)
+		}
+		var s spec.Schema
+		if err := json.Unmarshal(draft04, &s); err != nil {
+			return nil, fmt.Errorf("parse JSON Schema for schema %q: %v", name, err)
+		}
+		validators[name] = validate.NewSchemaValidator(&s, nil, "", strfmt.Default)
+	}
+
+	return NewRule(RuleJSONSchemas, "objects of other schemas are valid against the JSON Schemas supplied for them", SeverityError, func(cfg declcfg.DeclarativeConfig) []string {
+		var msgs []string
+		for i, m := range cfg.Others {
+			v, ok := validators[m.Schema]
+			if !ok {
+				continue
+			}
+			obj := fmt.Sprintf("object %d", i)
+			if m.Name != "" {
+				obj = fmt.Sprintf("object %q", m.Name)
+			}
+			if m.Package != "" {
+				obj = fmt.Sprintf("package %q, %s", m.Package, obj)
+			}
+
+			var data interface{}
+			if err := json.Unmarshal(m.Blob, &data); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s of schema %q: %v", obj, m.Schema, err))
+				continue
+			}
+			var errs []string
+			for _, err := range v.Validate(data).Errors {
+				errs = append(errs, err.Error())
+			}
+			sort.Strings(errs)
+			for _, err := range errs {
+				msgs = append(msgs, fmt.Sprintf("%s of schema %q: %s", obj, m.Schema, err))
+			}
+		}
+		return msgs
+	}), nil
+}
+
+// unsupportedKeywords are the draft-07 keywords that cannot be validated with
+// the draft-04 validator, mapped to the reason.
+var unsupportedKeywords = map[string]string{
+	"$ref":          "references with $ref are not supported",
+	"contains":      "the contains keyword is not supported",
+	"propertyNames": "the propertyNames keyword is not supported",
+	"if":            "conditional subschemas with if, then and else are not supported",
+	"then":          "conditional subschemas with if, then and else are not supported",
+	"else":          "conditional subschemas with if, then and else are not supported",
+}
+
+// toDraft04 translates the decoded draft-07 JSON Schema v to the draft-04
+// dialect that the validator implements. const is translated to a single value
+// enum, numeric exclusiveMinimum and exclusiveMaximum to minimum and maximum
+// with the boolean draft-04 keywords, and boolean subschemas to the empty
+// schema or its negation. Keywords that have no draft-04 equivalent are
+// rejected, rather than being silently ignored.
+func toDraft04(v interface{}, path string) (interface{}, error) {
+	if b, ok := v.(bool); ok {
+		if b {
+			return map[string]interface{}{}, nil
+		}
+		return map[string]interface{}{"not": map[string]interface{}{}}, nil
+	}
+	s, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: JSON Schema must be an object or a boolean", path)
+	}
+
+	out := make(map[string]interface{}, len(s))
+	for k, e := range s {
+		if reason, ok := unsupportedKeywords[k]; ok {
+			return nil, fmt.Errorf("%s: %s", path, reason)
+		}
+		var err error
+		switch k {
+		case "not":
+			out[k], err = toDraft04(e, path+"/"+k)
+		case "additionalProperties", "additionalItems":
+			if _, ok := e.(bool); ok {
+				out[k] = e
+				continue
+			}
+			out[k], err = toDraft04(e, path+"/"+k)
+		case "items":
+			if _, ok := e.([]interface{}); ok {
+				out[k], err = toDraft04Slice(e, path+"/"+k)
+			} else {
+				out[k], err = toDraft04(e, path+"/"+k)
+			}
+		case "allOf", "anyOf", "oneOf":
+			out[k], err = toDraft04Slice(e, path+"/"+k)
+		case "properties", "patternProperties", "definitions", "dependencies":
+			m, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s/%s: must be an object", path, k)
+			}
+			subs := make(map[string]interface{}, len(m))
+			for name, sub := range m {
+				if _, ok := sub.([]interface{}); ok && k == "dependencies" {
+					// Property dependencies are the same in both dialects.
+					subs[name] = sub
+					continue
+				}
+				if subs[name], err = toDraft04(sub, path+"/"+k+"/"+name); err != nil {
+					return nil, err
+				}
+			}
+			out[k] = subs
+		default:
+			out[k] = e
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c, ok := out["const"]; ok {
+		delete(out, "const")
+		if _, ok := out["enum"]; ok {
+			allOf, _ := out["allOf"].([]interface{})
+			out["allOf"] = append(allOf, map[string]interface{}{"enum": []interface{}{c}})
+		} else {
+			out["enum"] = []interface{}{c}
+		}
+	}
+	for _, b := range []struct {
+		exclusive, inclusive string
+		stricter             func(a, b float64) bool
+	}{
+		{"exclusiveMinimum", "minimum", func(a, b float64) bool { return a >= b }},
+		{"exclusiveMaximum", "maximum", func(a, b float64) bool { return a <= b }},
+	} {
+		n, ok := out[b.exclusive].(float64)
+		if !ok {
+			continue
+		}
+		delete(out, b.exclusive)
+		if m, ok := out[b.inclusive].(float64); ok && !b.stricter(n, m) {
+			// The inclusive bound is the stricter one.
+			continue
+		}
+		out[b.inclusive] = n
+		out[b.exclusive] = true
+	}
+	return out, nil
+}
+
+func toDraft04Slice(v interface{}, path string) ([]interface{}, error) {
+	s, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an array", path)
+	}
+	out := make([]interface{}, 0, len(s))
+	for i, e := range s {
+		sub, err := toDraft04(e, fmt.Sprintf("%s/%d", path, i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestJSONSchemaRule(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"required": ["schema", "name", "replicas"],
+		"properties": {
+			"schema": {"type": "string"},
+			"name": {"type": "string"},
+			"replicas": {"type": "integer", "minimum": 1}
+		}
+	}`)
+	meta := func(schema, pkg, name, blob string) declcfg.Meta {
+		return declcfg.Meta{Schema: schema, Package: pkg, Name: name, Blob: json.RawMessage(blob)}
+	}
+
+	rule, err := NewJSONSchemaRule(map[string][]byte{"example.deployment": schema})
+	require.NoError(t, err)
+	require.Equal(t, RuleJSONSchemas, rule.ID())
+	require.Equal(t, SeverityError, rule.Severity())
+
+	msgs := rule.Check(declcfg.DeclarativeConfig{Others: []declcfg.Meta{
+		meta("example.deployment", "foo", "valid", `{"schema":"example.deployment","name":"valid","replicas":2}`),
+		meta("example.deployment", "foo", "invalid", `{"schema":"example.deployment","name":"invalid","replicas":0}`),
+		meta("example.deployment", "", "", `{"schema":"example.deployment","replicas":"two"}`),
+		// Objects of other schemas are not validated.
+		meta("example.other", "", "", `{"schema":"example.other"}`),
+	}})
+	require.Len(t, msgs, 3)
+	require.Contains(t, msgs[0], `package "foo", object "invalid" of schema "example.deployment": replicas in body should be greater than or equal to 1`)
+	require.Contains(t, msgs[1], `object 2 of schema "example.deployment": `)
+	require.Contains(t, msgs[1]+msgs[2], "name in body is required")
+	require.Contains(t, msgs[1]+msgs[2], "replicas in body must be of type integer")
+
+	t.Run("Error/InvalidSchema", func(t *testing.T) {
+		_, err := NewJSONSchemaRule(map[string][]byte{"example.deployment": []byte(`{`)})
+		require.ErrorContains(t, err, `parse JSON Schema for schema "example.deployment"`)
+	})
+
+	t.Run("Error/Ref", func(t *testing.T) {
+		_, err := NewJSONSchemaRule(map[string][]byte{"example.deployment": []byte(`{"properties":{"name":{"$ref":"#/definitions/name"}}}`)})
+		require.ErrorContains(t, err, "references with $ref are not supported")
+	})
+
+	t.Run("Draft07", func(t *testing.T) {
+		rule, err := NewJSONSchemaRule(map[string][]byte{"example.deployment": []byte(`{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"type": "object",
+			"properties": {
+				"schema": {"const": "example.deployment"},
+				"replicas": {"type": "integer", "exclusiveMinimum": 0, "maximum": 10, "exclusiveMaximum": 5},
+				"mode": {"enum": ["a", "b"], "const": "a"},
+				"removed": false
+			}
+		}`)})
+		require.NoError(t, err)
+
+		require.Empty(t, rule.Check(declcfg.DeclarativeConfig{Others: []declcfg.Meta{
+			meta("example.deployment", "", "valid", `{"schema":"example.deployment","replicas":4,"mode":"a"}`),
+		}}))
+		msgs := rule.Check(declcfg.DeclarativeConfig{Others: []declcfg.Meta{
+			meta("example.deployment", "", "const", `{"schema":"example.deployment","mode":"b"}`),
+			meta("example.deployment", "", "exclusiveMinimum", `{"schema":"example.deployment","replicas":0}`),
+			meta("example.deployment", "", "exclusiveMaximum", `{"schema":"example.deployment","replicas":5}`),
+			meta("example.deployment", "", "false", `{"schema":"example.deployment","removed":true}`),
+		}})
+		// const alongside enum is validated as an allOf, which adds a message.
+		require.Len(t, msgs, 5)
+		require.Contains(t, msgs, `object "const" of schema "example.deployment": mode in body should be one of [a]`)
+		require.Contains(t, msgs, `object "exclusiveMinimum" of schema "example.deployment": replicas in body should be greater than 0`)
+		require.Contains(t, msgs, `object "exclusiveMaximum" of schema "example.deployment": replicas in body should be less than 5`)
+		require.Contains(t, msgs, `object "false" of schema "example.deployment": "removed" must not validate the schema (not)`)
+	})
+
+	t.Run("Error/Unsupported", func(t *testing.T) {
+		for _, keyword := range []string{"contains", "propertyNames", "if"} {
+			_, err := NewJSONSchemaRule(map[string][]byte{"example.deployment": []byte(`{"properties":{"ports":{"` + keyword + `":{}}}}`)})
+			require.ErrorContains(t, err, `JSON Schema for schema "example.deployment": #/properties/ports: `)
+			require.ErrorContains(t, err, "not supported")
+		}
+	})
+
+	t.Run("Error/DeclarativeConfigSchema", func(t *testing.T) {
+		_, err := NewJSONSchemaRule(map[string][]byte{declcfg.SchemaBundle: schema})
+		require.ErrorContains(t, err, "objects of schemas defined by declarative configs cannot be validated")
+	})
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// Severity is the severity of the problems reported by a validation rule.
+type Severity string
+
+const (
+	// SeverityError problems fail validation.
+	SeverityError Severity = "error"
+	// SeverityWarning problems are logged as warnings, unless warnings are
+	// treated as errors.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo problems are logged for information only.
+	SeverityInfo Severity = "info"
+)
+
+// IDs of the built-in validation rules.
+const (
+	RuleModel                  = "model"
+	RuleBundleSize             = "bundle-size"
+	RuleDuplicateBundles       = "duplicate-bundles"
+	RuleBundleObjectProperties = "bundle-object-properties"
+	RuleConstraints            = "constraints"
+)
+
+// Rule is a validation check that can be run against a declarative config.
+type Rule interface {
+	// ID returns a short, unique identifier for the rule, e.g. "bundle-size".
+	ID() string
+	// Description returns a one-line description of what the rule checks.
+	Description() string
+	// Severity returns the severity of the problems reported by the rule.
+	Severity() Severity
+	// Check returns a message for every problem the rule finds in cfg.
+	Check(cfg declcfg.DeclarativeConfig) []string
+}
+
+// NewRule returns a Rule that reports the messages returned by check.
+func NewRule(id, description string, severity Severity, check func(declcfg.DeclarativeConfig) []string) Rule {
+	return funcRule{id: id, description: description, severity: severity, check: check}
+}
+
+type funcRule struct {
+	id          string
+	description string
+	severity    Severity
+	check       func(declcfg.DeclarativeConfig) []string
+}
+
+func (r funcRule) ID() string                                   { return r.id }
+func (r funcRule) Description() string                          { return r.description }
+func (r funcRule) Severity() Severity                           { return r.severity }
+func (r funcRule) Check(cfg declcfg.DeclarativeConfig) []string { return r.check(cfg) }
+
+// Finding is a problem reported by a validation rule.
+type Finding struct {
+	RuleID   string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.RuleID, f.Message)
+}
+
+// Error makes a Finding an error, so that the findings of a ValidationError
+// can be matched with errors.As.
+func (f Finding) Error() string {
+	return f.String()
+}
+
+// ValidationError is returned when validation finds problems that fail
+// validation.
+type ValidationError struct {
+	Findings []Finding
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Findings))
+	for _, f := range e.Findings {
+		msgs = append(msgs, f.String())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the findings of the error.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Findings))
+	for _, f := range e.Findings {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// RuleSet is a set of validation rules, each of which is either enabled or
+// disabled. Rules are run in the order they were registered.
+type RuleSet struct {
+	rules   []Rule
+	enabled map[string]bool
+}
+
+func NewRuleSet() *RuleSet {
+	return &RuleSet{enabled: map[string]bool{}}
+}
+
+// DefaultRuleSet returns the built-in rules. The model and constraints rules
+// are enabled, and so is the bundle-size rule if any of limits is set. Other rules must be
+// enabled explicitly.
+func DefaultRuleSet(limits SizeLimits) *RuleSet {
+	s := NewRuleSet()
+	for _, r := range []struct {
+		rule    Rule
+		enabled bool
+	}{
+		{modelRule(), true},
+		{constraintsRule(), true},
+		{sizeRule(limits), limits.enabled()},
+		{duplicateBundlesRule(), false},
+		{bundleObjectPropertiesRule(), false},
+	} {
+		// The built-in rules have unique IDs.
+		_ = s.Register(r.rule, r.enabled)
+	}
+	return s
+}
+
+// Register adds rule to the set. It returns an error if a rule with the
+// same ID is already registered.
+func (s *RuleSet) Register(rule Rule, enabled bool) error {
+	if _, ok := s.enabled[rule.ID()]; ok {
+		return fmt.Errorf("rule %q is already registered", rule.ID())
+	}
+	s.rules = append(s.rules, rule)
+	s.enabled[rule.ID()] = enabled
+	return nil
+}
+
+// Enable enables the rules with the given IDs.
+func (s *RuleSet) Enable(ids ...string) error {
+	return s.setEnabled(ids, true)
+}
+
+// Disable disables the rules with the given IDs.
+func (s *RuleSet) Disable(ids ...string) error {
+	return s.setEnabled(ids, false)
+}
+
+func (s *RuleSet) setEnabled(ids []string, enabled bool) error {
+	for _, id := range ids {
+		if _, ok := s.enabled[id]; !ok {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+		s.enabled[id] = enabled
+	}
+	return nil
+}
+
+// Rules returns all rules in the set, in registration order.
+func (s *RuleSet) Rules() []Rule {
+	return append([]Rule(nil), s.rules...)
+}
+
+// Enabled returns true if the rule with the given ID is enabled.
+func (s *RuleSet) Enabled(id string) bool {
+	return s.enabled[id]
+}
+
+// Check runs the enabled rules against cfg and returns their findings.
+func (s *RuleSet) Check(cfg declcfg.DeclarativeConfig) []Finding {
+	var findings []Finding
+	for _, r := range s.rules {
+		if !s.enabled[r.ID()] {
+			continue
+		}
+		for _, msg := range r.Check(cfg) {
+			findings = append(findings, Finding{RuleID: r.ID(), Severity: r.Severity(), Message: msg})
+		}
+	}
+	return findings
+}
+
+// modelRule converts the config to the model that is used for serving
+// catalogs, which validates the schemas, references, and upgrade graphs of
+// all packages.
+func modelRule() Rule {
+	return NewRule(RuleModel, "catalog objects are valid and form valid upgrade graphs", SeverityError, func(cfg declcfg.DeclarativeConfig) []string {
+		if _, err := declcfg.ConvertToModel(cfg); err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	})
+}
+
+func sizeRule(limits SizeLimits) Rule {
+	severity := SeverityWarning
+	if limits.Fail {
+		severity = SeverityError
+	}
+	return NewRule(RuleBundleSize, "catalog and bundle content do not exceed the configured size limits", severity, func(cfg declcfg.DeclarativeConfig) []string {
+		violations, err := CheckSizes(cfg, limits)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		var msgs []string
+		for _, v := range violations {
+			msgs = append(msgs, v.String())
+		}
+		return msgs
+	})
+}
+
+func duplicateBundlesRule() Rule {
+	return NewRule(RuleDuplicateBundles, "bundles do not share an image or content under different names or packages", SeverityWarning, func(cfg declcfg.DeclarativeConfig) []string {
+		var msgs []string
+		for _, d := range FindDuplicateBundles(cfg) {
+			msgs = append(msgs, d.String())
+		}
+		return msgs
+	})
+}
+
+func bundleObjectPropertiesRule() Rule {
+	return NewRule(RuleBundleObjectProperties, `bundles do not use "olm.bundle.object" properties`, SeverityInfo, func(cfg declcfg.DeclarativeConfig) []string {
+		var msgs []string
+		for _, b := range cfg.Bundles {
+			if hasBundleObjectProperties(b) {
+				msgs = append(msgs, fmt.Sprintf("package %q, bundle %q: uses olm.bundle.object properties (suggestion: %s)", b.Package, b.Name, csvMetadataSuggestion))
+			}
+		}
+		return msgs
+	})
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestRuleSet(t *testing.T) {
+	s := NewRuleSet()
+	require.NoError(t, s.Register(NewRule("a", "rule a", SeverityError, func(declcfg.DeclarativeConfig) []string { return []string{"a1", "a2"} }), true))
+	require.NoError(t, s.Register(NewRule("b", "rule b", SeverityInfo, func(declcfg.DeclarativeConfig) []string { return []string{"b1"} }), false))
+	require.EqualError(t, s.Register(NewRule("a", "", SeverityInfo, nil), true), `rule "a" is already registered`)
+
+	require.Equal(t, []Finding{
+		{RuleID: "a", Severity: SeverityError, Message: "a1"},
+		{RuleID: "a", Severity: SeverityError, Message: "a2"},
+	}, s.Check(declcfg.DeclarativeConfig{}))
+
+	require.NoError(t, s.Enable("b"))
+	require.NoError(t, s.Disable("a"))
+	require.Equal(t, []Finding{{RuleID: "b", Severity: SeverityInfo, Message: "b1"}}, s.Check(declcfg.DeclarativeConfig{}))
+	require.EqualError(t, s.Enable("c"), `unknown rule "c"`)
+}
+
+func TestValidateConfigRules(t *testing.T) {
+	cfg := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "foo.v1"}, {Name: "foo.v2", Replaces: "foo.v1"}}}},
+		Bundles: []declcfg.Bundle{
+			{Schema: declcfg.SchemaBundle, Package: "foo", Name: "foo.v1", Image: "foo:v1", Properties: []property.Property{property.MustBuildPackage("foo", "1.0.0")}},
+			{Schema: declcfg.SchemaBundle, Package: "foo", Name: "foo.v2", Image: "foo:v1", Properties: []property.Property{property.MustBuildPackage("foo", "2.0.0")}},
+		},
+	}
+	withRules := func(enable ...string) ValidateOption {
+		rules := DefaultRuleSet(SizeLimits{})
+		require.NoError(t, rules.Enable(enable...))
+		return WithRuleSet(rules)
+	}
+
+	type spec struct {
+		name      string
+		opts      []ValidateOption
+		expectErr []Finding
+	}
+	specs := []spec{
+		{
+			name: "Default",
+		},
+		{
+			name: "Warning",
+			opts: []ValidateOption{withRules(RuleDuplicateBundles)},
+		},
+		{
+			name: "WarningsAsErrors",
+			opts: []ValidateOption{withRules(RuleDuplicateBundles), WithWarningsAsErrors()},
+			expectErr: []Finding{{
+				RuleID:   RuleDuplicateBundles,
+				Severity: SeverityWarning,
+				Message:  `bundles foo/foo.v1, foo/foo.v2 share the same image "foo:v1"`,
+			}},
+		},
+		{
+			name: "ReportDuplicatesWithWarningsAsErrors",
+			opts: []ValidateOption{WithDuplicateBundleReport(), WithWarningsAsErrors()},
+			expectErr: []Finding{{
+				RuleID:   RuleDuplicateBundles,
+				Severity: SeverityWarning,
+				Message:  `bundles foo/foo.v1, foo/foo.v2 share the same image "foo:v1"`,
+			}},
+		},
+		{
+			name: "CustomRule",
+			opts: []ValidateOption{func() ValidateOption {
+				rules := NewRuleSet()
+				require.NoError(t, rules.Register(NewRule("no-foo", "no package is named foo", SeverityError, func(cfg declcfg.DeclarativeConfig) []string {
+					var msgs []string
+					for _, p := range cfg.Packages {
+						if p.Name == "foo" {
+							msgs = append(msgs, "package foo is not allowed")
+						}
+					}
+					return msgs
+				}), true))
+				return WithRuleSet(rules)
+			}()},
+			expectErr: []Finding{{RuleID: "no-foo", Severity: SeverityError, Message: "package foo is not allowed"}},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			err := ValidateConfig(cfg, s.opts...)
+			if s.expectErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			var verr *ValidationError
+			require.True(t, errors.As(err, &verr), "expected a *ValidationError, got %v", err)
+			require.Equal(t, s.expectErr, verr.Findings)
+
+			// The findings are wrapped by the error.
+			var finding Finding
+			require.True(t, errors.As(err, &finding))
+			require.Equal(t, s.expectErr[0], finding)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+const csvMetadataSuggestion = `migrate "olm.bundle.object" properties to "olm.csv.metadata" (e.g. opm render --migrate-level=bundle-object-to-csv-metadata)`
+
+// SizeLimits configures the thresholds used to flag oversized bundle content.
+// A limit of zero disables the corresponding check.
+type SizeLimits struct {
+	// MaxBundleSize is the maximum combined size in bytes of all objects in a bundle.
+	MaxBundleSize int64
+	// MaxObjectSize is the maximum size in bytes of a single bundle object.
+	MaxObjectSize int64
+	// MaxPropertySize is the maximum size in bytes of a single encoded bundle property value.
+	MaxPropertySize int64
+	// MaxCatalogSize is the maximum size in bytes of the whole catalog, as
+	// encoded by declcfg.WriteJSON.
+	MaxCatalogSize int64
+
+	// Fail causes limit violations to be reported as validation errors
+	// instead of warnings.
+	Fail bool
+}
+
+func (l SizeLimits) enabled() bool {
+	return l.MaxBundleSize > 0 || l.MaxObjectSize > 0 || l.MaxPropertySize > 0 || l.MaxCatalogSize > 0
+}
+
+// SizeViolation describes a catalog, bundle, bundle object, or bundle
+// property that exceeds a configured size limit.
+type SizeViolation struct {
+	// Package and Bundle are empty for the violations of the whole catalog.
+	Package string
+	Bundle  string
+	// Subject identifies what exceeded the limit, e.g. "catalog", "bundle objects",
+	// "object ClusterServiceVersion/foo.v1.0.0", or "property[3] (olm.bundle.object)".
+	Subject    string
+	Size       int64
+	Limit      int64
+	Suggestion string
+}
+
+func (v SizeViolation) String() string {
+	msg := fmt.Sprintf("%s size %d bytes exceeds limit of %d bytes", v.Subject, v.Size, v.Limit)
+	if v.Bundle != "" {
+		msg = fmt.Sprintf("package %q, bundle %q: %s", v.Package, v.Bundle, msg)
+	}
+	if v.Suggestion != "" {
+		msg = fmt.Sprintf("%s (suggestion: %s)", msg, v.Suggestion)
+	}
+	return msg
+}
+
+// CheckSizes returns a violation for the catalog, and for every bundle,
+// bundle object, and bundle property in cfg, that exceeds the provided
+// limits. It returns an error if the catalog size is limited and cfg cannot
+// be encoded.
+func CheckSizes(cfg declcfg.DeclarativeConfig, limits SizeLimits) ([]SizeViolation, error) {
+	var violations []SizeViolation
+	if limits.MaxCatalogSize > 0 {
+		var w countingWriter
+		if err := declcfg.WriteJSON(cfg, &w); err != nil {
+			return nil, fmt.Errorf("encode catalog to check its size: %v", err)
+		}
+		if w.n > limits.MaxCatalogSize {
+			violations = append(violations, SizeViolation{Subject: "catalog", Size: w.n, Limit: limits.MaxCatalogSize})
+		}
+	}
+	for _, b := range cfg.Bundles {
+		suggestion := ""
+		if hasBundleObjectProperties(b) {
+			suggestion = csvMetadataSuggestion
+		}
+		newViolation := func(subject string, size, limit int64) SizeViolation {
+			return SizeViolation{
+				Package:    b.Package,
+				Bundle:     b.Name,
+				Subject:    subject,
+				Size:       size,
+				Limit:      limit,
+				Suggestion: suggestion,
+			}
+		}
+
+		var total int64
+		for _, obj := range b.Objects {
+			size := int64(len(obj))
+			total += size
+			if limits.MaxObjectSize > 0 && size > limits.MaxObjectSize {
+				violations = append(violations, newViolation("object "+objectName(obj), size, limits.MaxObjectSize))
+			}
+		}
+		if limits.MaxBundleSize > 0 && total > limits.MaxBundleSize {
+			violations = append(violations, newViolation("bundle objects", total, limits.MaxBundleSize))
+		}
+
+		for i, p := range b.Properties {
+			size := int64(len(p.Value))
+			if limits.MaxPropertySize > 0 && size > limits.MaxPropertySize {
+				violations = append(violations, newViolation(fmt.Sprintf("property[%d] (%s)", i, p.Type), size, limits.MaxPropertySize))
+			}
+		}
+	}
+	return violations, nil
+}
+
+func hasBundleObjectProperties(b declcfg.Bundle) bool {
+	for _, p := range b.Properties {
+		if p.Type == property.TypeBundleObject {
+			return true
+		}
+	}
+	return false
+}
+
+func objectName(obj string) string {
+	var u struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(obj), &u); err != nil || u.Kind == "" {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%s/%s", u.Kind, u.Metadata.Name)
+}
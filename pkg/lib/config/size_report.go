@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// Size is the size in bytes of catalog content, as encoded by
+// declcfg.WriteJSON, and once that is compressed with gzip.
+type Size struct {
+	Raw  int64 `json:"raw"`
+	Gzip int64 `json:"gzip"`
+}
+
+// SizeReport is the size of a catalog, and of each of its packages and
+// bundles, for dashboards that track catalog size budgets.
+type SizeReport struct {
+	Total    Size          `json:"total"`
+	Packages []PackageSize `json:"packages"`
+}
+
+// PackageSize is the size of the objects of a package, as they are written
+// to the catalog file of the package.
+type PackageSize struct {
+	Name string `json:"name"`
+	Size
+	Bundles []BundleSize `json:"bundles"`
+}
+
+// BundleSize is the size of an olm.bundle object.
+type BundleSize struct {
+	Name string `json:"name"`
+	Size
+}
+
+// ComputeSizeReport returns the size of cfg, and of each of its packages and
+// bundles, sorted by name. Objects that do not belong to a package only count
+// toward the total.
+func ComputeSizeReport(cfg declcfg.DeclarativeConfig) (*SizeReport, error) {
+	total, err := encodedSize(cfg)
+	if err != nil {
+		return nil, err
+	}
+	report := &SizeReport{Total: total, Packages: []PackageSize{}}
+
+	for name, pcfg := range declcfg.SplitByPackage(cfg) {
+		if name == "" {
+			continue
+		}
+		size, err := encodedSize(*pcfg)
+		if err != nil {
+			return nil, err
+		}
+		ps := PackageSize{Name: name, Size: size, Bundles: []BundleSize{}}
+		for _, b := range pcfg.Bundles {
+			size, err := encodedSize(declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{b}})
+			if err != nil {
+				return nil, err
+			}
+			ps.Bundles = append(ps.Bundles, BundleSize{Name: b.Name, Size: size})
+		}
+		sort.Slice(ps.Bundles, func(i, j int) bool { return ps.Bundles[i].Name < ps.Bundles[j].Name })
+		report.Packages = append(report.Packages, ps)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool { return report.Packages[i].Name < report.Packages[j].Name })
+	return report, nil
+}
+
+// WriteJSON writes r to w as indented JSON.
+func (r SizeReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
+
+// WriteSizeReport writes the size report of cfg to the file named filename,
+// creating or truncating it.
+func WriteSizeReport(cfg declcfg.DeclarativeConfig, filename string) error {
+	report, err := ComputeSizeReport(cfg)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encodedSize returns the size of cfg as encoded by declcfg.WriteJSON, raw
+// and compressed with gzip.
+func encodedSize(cfg declcfg.DeclarativeConfig) (Size, error) {
+	var buf bytes.Buffer
+	if err := declcfg.WriteJSON(cfg, &buf); err != nil {
+		return Size{}, err
+	}
+	raw := int64(buf.Len())
+
+	var compressed countingWriter
+	zw := gzip.NewWriter(&compressed)
+	if _, err := buf.WriteTo(zw); err != nil {
+		return Size{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return Size{}, err
+	}
+	return Size{Raw: raw, Gzip: compressed.n}, nil
+}
+
+// countingWriter counts the bytes written to it, and discards them.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
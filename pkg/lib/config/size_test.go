@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestCheckSizes(t *testing.T) {
+	csv := `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"foo.v1.0.0"}}`
+	crd := `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"foos.test.io"}}`
+
+	objectBundle := declcfg.Bundle{
+		Schema:  declcfg.SchemaBundle,
+		Name:    "foo.v1.0.0",
+		Package: "foo",
+		Properties: []property.Property{
+			property.MustBuildPackage("foo", "1.0.0"),
+			property.MustBuildBundleObject([]byte(csv)),
+			property.MustBuildBundleObject([]byte(crd)),
+		},
+		Objects: []string{csv, crd},
+	}
+	metadataBundle := declcfg.Bundle{
+		Schema:  declcfg.SchemaBundle,
+		Name:    "bar.v1.0.0",
+		Package: "bar",
+		Properties: []property.Property{
+			property.MustBuildPackage("bar", "1.0.0"),
+		},
+	}
+	cfg := declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{objectBundle, metadataBundle}}
+
+	type spec struct {
+		name     string
+		limits   SizeLimits
+		expected []string
+	}
+	specs := []spec{
+		{
+			name:   "NoLimits",
+			limits: SizeLimits{},
+		},
+		{
+			name:   "WithinLimits",
+			limits: SizeLimits{MaxBundleSize: 1024, MaxObjectSize: 1024, MaxPropertySize: 1024},
+		},
+		{
+			name:     "BundleSizeExceeded",
+			limits:   SizeLimits{MaxBundleSize: int64(len(csv) + len(crd) - 1)},
+			expected: []string{"bundle objects"},
+		},
+		{
+			name:     "ObjectSizeExceeded",
+			limits:   SizeLimits{MaxObjectSize: int64(len(crd))},
+			expected: []string{"object ClusterServiceVersion/foo.v1.0.0"},
+		},
+		{
+			name:     "PropertySizeExceeded",
+			limits:   SizeLimits{MaxPropertySize: 100},
+			expected: []string{"property[1] (olm.bundle.object)", "property[2] (olm.bundle.object)"},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			violations, err := CheckSizes(cfg, s.limits)
+			require.NoError(t, err)
+			subjects := make([]string, 0, len(violations))
+			for _, v := range violations {
+				require.Equal(t, "foo", v.Package)
+				require.Equal(t, csvMetadataSuggestion, v.Suggestion)
+				subjects = append(subjects, v.Subject)
+			}
+			require.ElementsMatch(t, s.expected, subjects)
+		})
+	}
+}
+
+func TestCheckCatalogSize(t *testing.T) {
+	cfg := declcfg.DeclarativeConfig{Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo"}}}
+	var buf bytes.Buffer
+	require.NoError(t, declcfg.WriteJSON(cfg, &buf))
+	size := int64(buf.Len())
+
+	violations, err := CheckSizes(cfg, SizeLimits{MaxCatalogSize: size})
+	require.NoError(t, err)
+	require.Empty(t, violations)
+
+	violations, err = CheckSizes(cfg, SizeLimits{MaxCatalogSize: size - 1})
+	require.NoError(t, err)
+	require.Equal(t, []SizeViolation{{Subject: "catalog", Size: size, Limit: size - 1}}, violations)
+	require.Equal(t, fmt.Sprintf("catalog size %d bytes exceeds limit of %d bytes", size, size-1), violations[0].String())
+
+	// A catalog that cannot be encoded is an error, not a catalog within the
+	// limit.
+	cfg.Others = []declcfg.Meta{{Schema: "custom", Blob: []byte(`{"schema":`)}}
+	_, err = CheckSizes(cfg, SizeLimits{MaxCatalogSize: size})
+	require.ErrorContains(t, err, "encode catalog to check its size")
+}
+
+func TestComputeSizeReport(t *testing.T) {
+	bundle := func(pkg, name string) declcfg.Bundle {
+		return declcfg.Bundle{
+			Schema:     declcfg.SchemaBundle,
+			Name:       name,
+			Package:    pkg,
+			Properties: []property.Property{property.MustBuildPackage(pkg, "1.0.0")},
+		}
+	}
+	cfg := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{
+			{Schema: declcfg.SchemaPackage, Name: "foo"},
+			{Schema: declcfg.SchemaPackage, Name: "bar"},
+		},
+		Bundles: []declcfg.Bundle{bundle("foo", "foo.v1.0.0"), bundle("bar", "bar.v1.0.0"), bundle("foo", "foo.v0.9.0")},
+		Others:  []declcfg.Meta{{Schema: "custom", Blob: []byte(`{"schema":"custom"}`)}},
+	}
+
+	report, err := ComputeSizeReport(cfg)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, declcfg.WriteJSON(cfg, &buf))
+	require.Equal(t, int64(buf.Len()), report.Total.Raw)
+	require.Positive(t, report.Total.Gzip)
+
+	require.Len(t, report.Packages, 2)
+	require.Equal(t, "bar", report.Packages[0].Name)
+	require.Equal(t, "foo", report.Packages[1].Name)
+	foo := report.Packages[1]
+	require.Len(t, foo.Bundles, 2)
+	require.Equal(t, "foo.v0.9.0", foo.Bundles[0].Name)
+	require.Equal(t, "foo.v1.0.0", foo.Bundles[1].Name)
+	require.Greater(t, foo.Raw, foo.Bundles[0].Raw+foo.Bundles[1].Raw)
+	require.Greater(t, report.Total.Raw, foo.Raw+report.Packages[0].Raw)
+
+	buf.Reset()
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded SizeReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, *report, decoded)
+
+	filename := filepath.Join(t.TempDir(), "sizes.json")
+	require.NoError(t, WriteSizeReport(cfg, filename))
+	written, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, buf.String(), string(written))
+}
+
+func TestValidateSizeLimits(t *testing.T) {
+	fsys := fstest.MapFS{
+		"catalog.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v1.0.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v1.0.0
+image: test.registry/foo-operator/foo-bundle:v1.0.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 1.0.0
+- type: olm.bundle.object
+  value:
+    data: eyJraW5kIjoiQ2x1c3RlclNlcnZpY2VWZXJzaW9uIiwibWV0YWRhdGEiOnsibmFtZSI6ImZvby52MS4wLjAifX0=
+`)},
+	}
+
+	require.NoError(t, Validate(context.Background(), fsys, WithSizeLimits(SizeLimits{MaxObjectSize: 10})))
+
+	err := Validate(context.Background(), fsys, WithSizeLimits(SizeLimits{MaxObjectSize: 10, Fail: true}))
+	require.ErrorContains(t, err, `bundle "foo.v1.0.0": object ClusterServiceVersion/foo.v1.0.0 size`)
+}
@@ -4,29 +4,119 @@ import (
 	"context"
 	"io/fs"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
 )
 
+type ValidateOptions struct {
+	Log              *logrus.Entry
+	SizeLimits       SizeLimits
+	ReportDuplicates bool
+
+	// RuleSet is the set of rules to run. If unset, DefaultRuleSet is used,
+	// configured by SizeLimits and ReportDuplicates.
+	RuleSet *RuleSet
+	// WarningsAsErrors causes warnings to fail validation.
+	WarningsAsErrors bool
+}
+
+type ValidateOption func(*ValidateOptions)
+
+func WithLog(log *logrus.Entry) ValidateOption {
+	return func(o *ValidateOptions) {
+		o.Log = log
+	}
+}
+
+// WithSizeLimits enables checking bundle content against the provided
+// size limits. Violations are logged as warnings, unless limits.Fail
+// is set, in which case they are returned as a validation error.
+func WithSizeLimits(limits SizeLimits) ValidateOption {
+	return func(o *ValidateOptions) {
+		o.SizeLimits = limits
+	}
+}
+
+// WithDuplicateBundleReport enables reporting bundles that share the same
+// image or content under different names or packages. Duplicates are logged
+// as warnings and do not fail validation.
+func WithDuplicateBundleReport() ValidateOption {
+	return func(o *ValidateOptions) {
+		o.ReportDuplicates = true
+	}
+}
+
+// WithRuleSet sets the rules to run, in place of the default rules. Size
+// limits and duplicate reporting options are ignored when a rule set is
+// provided; use DefaultRuleSet to build on the default rules.
+func WithRuleSet(rules *RuleSet) ValidateOption {
+	return func(o *ValidateOptions) {
+		o.RuleSet = rules
+	}
+}
+
+// WithWarningsAsErrors causes problems reported at warning severity to fail
+// validation.
+func WithWarningsAsErrors() ValidateOption {
+	return func(o *ValidateOptions) {
+		o.WarningsAsErrors = true
+	}
+}
+
 // Validate takes a filesystem containing the declarative config file(s)
 // 1. Validate if declarative config file(s) are valid based on specified schema
 // 2. Validate the `replaces` chains of the upgrade graph
+// 3. Optionally, validate bundle content against configured size limits
+// 4. Optionally, report bundles duplicated under different names
+// 5. Optionally, run other validation rules (see RuleSet)
 // Inputs:
 // directory: a filesystem where declarative config file(s) exist
 // Outputs:
 // error: a wrapped error that contains a tree of error strings
-func Validate(ctx context.Context, root fs.FS) error {
+func Validate(ctx context.Context, root fs.FS, validateOpts ...ValidateOption) error {
 	// Load config files and convert them to declcfg objects
 	cfg, err := declcfg.LoadFS(ctx, root)
 	if err != nil {
 		return err
 	}
-	// Validate the config using model validation:
-	// This will convert declcfg objects to intermediate model objects that are
-	// also used for serve and add commands. The conversion process will run
-	// validation for the model objects and ensure they are valid.
-	_, err = declcfg.ConvertToModel(*cfg)
-	if err != nil {
-		return err
+	return ValidateConfig(*cfg, validateOpts...)
+}
+
+// ValidateConfig performs the same validation as Validate on a declarative
+// config that has already been loaded, e.g. with declcfg.LoadRef.
+func ValidateConfig(cfg declcfg.DeclarativeConfig, validateOpts ...ValidateOption) error {
+	opts := &ValidateOptions{
+		Log: log.Null(),
+	}
+	for _, opt := range validateOpts {
+		opt(opts)
+	}
+
+	rules := opts.RuleSet
+	if rules == nil {
+		rules = DefaultRuleSet(opts.SizeLimits)
+		if opts.ReportDuplicates {
+			_ = rules.Enable(RuleDuplicateBundles)
+		}
+	}
+
+	// Findings at error severity, and optionally at warning severity, fail
+	// validation. Others are logged.
+	var failed []Finding
+	for _, f := range rules.Check(cfg) {
+		switch {
+		case f.Severity == SeverityError, f.Severity == SeverityWarning && opts.WarningsAsErrors:
+			failed = append(failed, f)
+		case f.Severity == SeverityWarning:
+			opts.Log.WithField("rule", f.RuleID).Warn(f.Message)
+		default:
+			opts.Log.WithField("rule", f.RuleID).Info(f.Message)
+		}
+	}
+	if len(failed) > 0 {
+		return &ValidationError{Findings: failed}
 	}
 	return nil
 }
@@ -0,0 +1,113 @@
+// Package errors provides errors that carry a machine-readable code, so
+// that callers of opm can tell failures apart without matching messages.
+package errors
+
+import (
+	"errors"
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Code identifies the kind of failure of an error. Codes are stable, so
+// they can be matched by scripts that run opm.
+type Code string
+
+const (
+	// CodeUnknown is the code of errors that do not have one.
+	CodeUnknown Code = "Unknown"
+	// CodeInvalidArgument is the code of errors caused by invalid flags,
+	// arguments or references, e.g. a reference of a type that a command
+	// does not render.
+	CodeInvalidArgument Code = "InvalidArgument"
+	// CodeImagePullFailed is the code of errors caused by failing to pull
+	// an image.
+	CodeImagePullFailed Code = "ImagePullFailed"
+	// CodeImagePushFailed is the code of errors caused by failing to push
+	// an image.
+	CodeImagePushFailed Code = "ImagePushFailed"
+	// CodeInvalidCatalog is the code of errors caused by catalog content
+	// that cannot be loaded or is not valid.
+	CodeInvalidCatalog Code = "InvalidCatalog"
+	// CodeBundleAlreadyExists is the code of errors caused by adding a
+	// bundle to a catalog that already has it.
+	CodeBundleAlreadyExists Code = "BundleAlreadyExists"
+	// CodePackageVersionAlreadyExists is the code of errors caused by
+	// adding a bundle to a catalog that already has a bundle of the same
+	// package and version.
+	CodePackageVersionAlreadyExists Code = "PackageVersionAlreadyExists"
+	// CodeOutputConflict is the code of errors caused by an output
+	// location that cannot be written, e.g. an output directory that is
+	// not empty.
+	CodeOutputConflict Code = "OutputConflict"
+)
+
+// Error is an error with a code. Its message is the message of Err, so
+// adding a code to an error does not change how it is printed.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// New returns err with code. If err is nil, New returns nil.
+func New(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf formats an error like fmt.Errorf, with code.
+func Errorf(code Code, format string, a ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// Wrap returns err with code, unless err already has a code, in which case
+// it is returned as is, so that the code of the cause of an error wins.
+func Wrap(code Code, err error) error {
+	if err == nil || CodeOf(err) != CodeUnknown {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the code of e.
+func (e *Error) ErrorCode() Code {
+	return e.Code
+}
+
+// Coder is implemented by errors that have a code. Error implements it;
+// other error types can implement it to have a code without being wrapped.
+type Coder interface {
+	ErrorCode() Code
+}
+
+// CodeOf returns the code of the first error in the chain of err that has
+// one, or of the first error of an aggregate that has one. It returns
+// CodeUnknown for errors without a code, and "" for nil.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var c Coder
+	if errors.As(err, &c) {
+		return c.ErrorCode()
+	}
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		for _, err := range agg.Errors() {
+			if code := CodeOf(err); code != CodeUnknown {
+				return code
+			}
+		}
+	}
+	return CodeUnknown
+}
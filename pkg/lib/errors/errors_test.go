@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+type coder struct{}
+
+func (coder) Error() string   { return "bundle already exists" }
+func (coder) ErrorCode() Code { return CodeBundleAlreadyExists }
+
+func TestCodeOf(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	for _, tt := range []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{name: "Nil", err: nil, want: ""},
+		{name: "NoCode", err: errors.New("failed"), want: CodeUnknown},
+		{name: "Code", err: Errorf(CodeImagePullFailed, "pull: %w", sentinel), want: CodeImagePullFailed},
+		{name: "Wrapped", err: fmt.Errorf("render: %w", New(CodeInvalidCatalog, sentinel)), want: CodeInvalidCatalog},
+		{name: "WrapKeepsCause", err: Wrap(CodeInvalidCatalog, New(CodeImagePullFailed, sentinel)), want: CodeImagePullFailed},
+		{name: "WrapAddsCode", err: Wrap(CodeInvalidCatalog, sentinel), want: CodeInvalidCatalog},
+		{name: "Coder", err: fmt.Errorf("add: %w", coder{}), want: CodeBundleAlreadyExists},
+		{name: "Aggregate", err: utilerrors.NewAggregate([]error{sentinel, New(CodeBundleAlreadyExists, sentinel)}), want: CodeBundleAlreadyExists},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, CodeOf(tt.err))
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := Errorf(CodeInvalidArgument, "cannot render: %w", sentinel)
+	require.EqualError(t, err, "cannot render: sentinel")
+	require.ErrorIs(t, err, sentinel)
+
+	require.NoError(t, New(CodeInvalidArgument, nil))
+	require.NoError(t, Wrap(CodeInvalidArgument, nil))
+}
@@ -0,0 +1,268 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/cache"
+	"github.com/operator-framework/operator-registry/pkg/containertools"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
+	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
+)
+
+// opaqueWhiteout is the name of a file that hides the contents that lower
+// layers of an image have in the directory it is in.
+const opaqueWhiteout = ".wh..wh..opq"
+
+// addToFileBasedIndex adds the bundles of request to the file-based catalog
+// of the index image request.FromIndex, and pushes the result to
+// request.Tag. The image is built by adding a layer with the updated catalog
+// to the index image, so no container tool is needed. If the index image
+// serves a pre-built cache, the cache is rebuilt in the new layer.
+func (i ImageIndexer) addToFileBasedIndex(ctx context.Context, request AddToIndexRequest) error {
+	switch {
+	case request.FromIndex == "":
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "pushing an index requires --from-index to be a file-based catalog image")
+	case request.Tag == "" && !request.DryRun:
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "pushing an index requires --tag")
+	case request.Generate:
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "pushing an index cannot be combined with generating a Dockerfile")
+	}
+
+	reg, err := containerdregistry.NewRegistry(
+		containerdregistry.WithClientConfig(image.ClientConfig{
+			CAFile:        request.CaFile,
+			SkipTLSVerify: request.SkipTLSVerify,
+			PlainHTTP:     request.PlainHTTP,
+		}),
+		containerdregistry.WithLog(i.Logger),
+		containerdregistry.WithBlobCacheDir(request.BlobCacheDir))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := reg.Destroy(); err != nil {
+			i.Logger.WithError(err).Warn("error destroying local cache")
+		}
+	}()
+
+	i.Logger.WithField("image", request.FromIndex).Info("pulling previous image to get metadata")
+	fromRef := image.SimpleReference(request.FromIndex)
+	if err := reg.Pull(ctx, fromRef); err != nil {
+		return liberrors.New(liberrors.CodeImagePullFailed, err)
+	}
+	labels, err := reg.Labels(ctx, fromRef)
+	if err != nil {
+		return err
+	}
+	configsLocation, ok := labels[containertools.ConfigsLocationLabel]
+	if !ok {
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "index image %s is not a file-based catalog: missing label %s", request.FromIndex, containertools.ConfigsLocationLabel)
+	}
+
+	workDir, err := os.MkdirTemp("", tmpDirPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+	baseDir, layerDir := filepath.Join(workDir, "base"), filepath.Join(workDir, "layer")
+	if err := reg.Unpack(ctx, fromRef, baseDir); err != nil {
+		return err
+	}
+	cfg, err := declcfg.LoadFS(ctx, os.DirFS(filepath.Join(baseDir, configsLocation)))
+	if err != nil {
+		return liberrors.Errorf(liberrors.CodeInvalidCatalog, "load file-based catalog of %s: %v", request.FromIndex, err)
+	}
+
+	bundles, err := i.renderFBCBundles(ctx, reg, request)
+	if err != nil {
+		return err
+	}
+	before := declcfg.SnapshotGraphs(*cfg)
+	if err := (action.Populate{Catalog: cfg, Bundles: bundles, Mode: request.Mode, Overwrite: request.Overwrite}).Run(); err != nil {
+		return err
+	}
+	for _, b := range bundles {
+		i.Logger.WithFields(logrus.Fields{"package": b.Package, "bundle": b.Name, "image": b.Image}).Info("added bundle")
+	}
+	if _, err := declcfg.ConvertToModel(*cfg); err != nil {
+		if !request.Permissive {
+			return liberrors.Errorf(liberrors.CodeInvalidCatalog, "invalid index: %v", err)
+		}
+		i.Logger.WithError(err).Warn("permissive mode enabled, ignoring invalid index")
+	}
+	if request.DryRun {
+		return writeDryRunReport(request, before, declcfg.SnapshotGraphs(*cfg))
+	}
+
+	configsDir, err := layerPath(layerDir, baseDir, configsLocation)
+	if err != nil {
+		return err
+	}
+	if err := declcfg.WriteFS(*cfg, configsDir, declcfg.WriteJSON, ".json"); err != nil {
+		return err
+	}
+
+	config, err := reg.Config(ctx, fromRef)
+	if err != nil {
+		return err
+	}
+	var whiteoutDirs []string
+	if cacheLocation := cacheDirArg(append(config.Entrypoint, config.Cmd...)); cacheLocation != "" {
+		if _, err := os.Stat(filepath.Join(baseDir, cacheLocation)); err == nil {
+			cacheDir, err := i.rebuildCache(ctx, baseDir, layerDir, cacheLocation, configsDir)
+			if err != nil {
+				return err
+			}
+			whiteoutDirs = append(whiteoutDirs, cacheDir)
+		}
+	}
+	// The whiteouts are added after the cache is built, as they are not in
+	// the catalog and cache directories of containers of the image.
+	for _, dir := range append(whiteoutDirs, configsDir) {
+		if err := os.WriteFile(filepath.Join(dir, opaqueWhiteout), nil, 0644); err != nil {
+			return err
+		}
+	}
+
+	tagRef := image.SimpleReference(request.Tag)
+	if err := reg.Pack(ctx, tagRef, fromRef, layerDir, nil); err != nil {
+		return fmt.Errorf("build index image: %v", err)
+	}
+	i.Logger.WithField("image", request.Tag).Info("pushing index image")
+	if err := reg.Push(ctx, tagRef); err != nil {
+		return liberrors.Errorf(liberrors.CodeImagePushFailed, "push index image: %v", err)
+	}
+	return nil
+}
+
+// renderFBCBundles renders the bundle images of request.
+func (i ImageIndexer) renderFBCBundles(ctx context.Context, reg image.Registry, request AddToIndexRequest) ([]action.PopulateBundle, error) {
+	render := action.Render{
+		Refs:                 request.Bundles,
+		Registry:             reg,
+		AllowedRefMask:       action.RefBundleImage,
+		SkipReferencedImages: request.SkipReferencedImages,
+		MaxParallel:          request.MaxParallel,
+	}
+	rendered, err := render.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each bundle image renders to one bundle, in the order of the images.
+	bundles := make([]action.PopulateBundle, 0, len(rendered.Bundles))
+	for j, b := range rendered.Bundles {
+		labels, err := reg.Labels(ctx, image.SimpleReference(request.Bundles[j]))
+		if err != nil {
+			return nil, err
+		}
+		pb := action.PopulateBundle{Bundle: b, DefaultChannel: labels[bundle.ChannelDefaultLabel]}
+		for _, ch := range strings.Split(labels[bundle.ChannelsLabel], ",") {
+			if ch = strings.TrimSpace(ch); ch != "" {
+				pb.Channels = append(pb.Channels, ch)
+			}
+		}
+		if len(pb.Channels) == 0 {
+			return nil, liberrors.Errorf(liberrors.CodeInvalidArgument, "bundle image %s has no channels: missing label %s", request.Bundles[j], bundle.ChannelsLabel)
+		}
+		bundles = append(bundles, pb)
+	}
+	return bundles, nil
+}
+
+// cacheDirArg returns the value of the --cache-dir flag in args, the
+// command of an index image, or "" if it is not set.
+func cacheDirArg(args []string) string {
+	for j, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "--cache-dir="); ok {
+			return v
+		}
+		if arg == "--cache-dir" && j+1 < len(args) {
+			return args[j+1]
+		}
+	}
+	return ""
+}
+
+// rebuildCache builds the cache of the catalog in configsDir, in the format
+// of the cache at cacheLocation in the index image unpacked in baseDir, and
+// moves it to the same location in layerDir.
+func (i ImageIndexer) rebuildCache(ctx context.Context, baseDir, layerDir, cacheLocation, configsDir string) (string, error) {
+	i.Logger.WithField("cache", cacheLocation).Info("rebuilding the cache")
+	baseCacheDir := filepath.Join(baseDir, cacheLocation)
+	c, err := cache.New(baseCacheDir, cache.WithLog(i.Logger))
+	if err != nil {
+		return "", fmt.Errorf("open cache of index image: %v", err)
+	}
+	if err := c.Build(ctx, os.DirFS(configsDir)); err != nil {
+		c.Close()
+		return "", fmt.Errorf("build cache: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		return "", err
+	}
+	cacheDir, err := layerPath(layerDir, baseDir, cacheLocation)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(cacheDir); err != nil {
+		return "", err
+	}
+	return cacheDir, os.Rename(baseCacheDir, cacheDir)
+}
+
+// layerPath creates the directory at location in layerDir, with the modes
+// that its parent directories have in the image unpacked in baseDir, so
+// that adding the layer to the image does not change them, e.g. to make
+// /tmp unwritable.
+func layerPath(layerDir, baseDir, location string) (string, error) {
+	rel := filepath.Clean(strings.TrimPrefix(location, "/"))
+	dir := filepath.Join(layerDir, rel)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	for parent := filepath.Dir(rel); parent != "."; parent = filepath.Dir(parent) {
+		info, err := os.Stat(filepath.Join(baseDir, parent))
+		if err != nil {
+			continue
+		}
+		mode := info.Mode() & (os.ModePerm | os.ModeSticky | os.ModeSetuid | os.ModeSetgid)
+		if err := os.Chmod(filepath.Join(layerDir, parent), mode); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// exportFBC writes the file-based catalog of the named packages of the sqlite
+// database at databaseFile, or of all of its packages if none are named, to a
+// directory per package in downloadPath. Bundles that were deprecated in the
+// database are kept, along with olm.deprecations entries for them.
+func exportFBC(ctx context.Context, databaseFile string, packages []string, downloadPath string) error {
+	cfg, err := action.Render{
+		Refs:                     []string{databaseFile},
+		AllowedRefMask:           action.RefSqliteFile,
+		FilterPackages:           packages,
+		IncludeDeprecatedBundles: true,
+	}.Run(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range packages {
+		if !slices.ContainsFunc(cfg.Packages, func(p declcfg.Package) bool { return p.Name == name }) {
+			return fmt.Errorf("package %q not found in index", name)
+		}
+	}
+	return declcfg.WriteFS(*cfg, downloadPath, declcfg.WriteYAML, ".yaml")
+}
@@ -0,0 +1,92 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+func TestCacheDirArg(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"serve", "/configs", "--cache-dir=/tmp/cache"}, want: "/tmp/cache"},
+		{args: []string{"serve", "/configs", "--cache-dir", "/tmp/cache"}, want: "/tmp/cache"},
+		{args: []string{"serve", "/configs", "--cache-dir"}, want: ""},
+		{args: []string{"serve", "/configs"}, want: ""},
+	} {
+		require.Equal(t, tt.want, cacheDirArg(tt.args), "%v", tt.args)
+	}
+}
+
+func TestLayerPath(t *testing.T) {
+	baseDir, layerDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(baseDir, "tmp"), 0755))
+	require.NoError(t, os.Chmod(filepath.Join(baseDir, "tmp"), 0777|os.ModeSticky))
+
+	dir, err := layerPath(layerDir, baseDir, "/tmp/cache")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(layerDir, "tmp", "cache"), dir)
+	require.DirExists(t, dir)
+	info, err := os.Stat(filepath.Join(layerDir, "tmp"))
+	require.NoError(t, err)
+	require.Equal(t, os.ModeDir|0777|os.ModeSticky, info.Mode())
+}
+
+func TestExportFBC(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "index.db")
+	db, err := sqlite.Open(dbFile)
+	require.NoError(t, err)
+	loader, err := sqlite.NewDeprecationAwareLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, loader.Migrate(context.Background()))
+	require.NoError(t, sqlite.NewSQLLoaderForDirectory(loader, "../../../manifests").Populate())
+	_, err = db.Exec(`UPDATE operatorbundle SET bundlepath = 'quay.io/test/etcd:v0.9.0' WHERE name = 'etcdoperator.v0.9.0'`)
+	require.NoError(t, err)
+	require.NoError(t, loader.DeprecateBundle("quay.io/test/etcd:v0.9.0"))
+	require.NoError(t, db.Close())
+
+	t.Run("Packages", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, exportFBC(context.Background(), dbFile, []string{"etcd"}, dir))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.FileExists(t, filepath.Join(dir, "etcd", "catalog.yaml"))
+
+		cfg, err := declcfg.LoadFS(context.Background(), os.DirFS(dir))
+		require.NoError(t, err)
+		require.Len(t, cfg.Packages, 1)
+		require.Len(t, cfg.Bundles, 2)
+		require.Equal(t, []declcfg.Deprecation{{
+			Schema:  declcfg.SchemaDeprecation,
+			Package: "etcd",
+			Entries: []declcfg.DeprecationEntry{{
+				Reference: declcfg.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "etcdoperator.v0.9.0"},
+				Message:   sqlite.DeprecatedBundleMessage,
+			}},
+		}}, cfg.Deprecations)
+		_, err = declcfg.ConvertToModel(*cfg)
+		require.NoError(t, err)
+	})
+	t.Run("AllPackages", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, exportFBC(context.Background(), dbFile, nil, dir))
+
+		for _, pkg := range []string{"etcd", "prometheus", "strimzi-kafka-operator"} {
+			require.FileExists(t, filepath.Join(dir, pkg, "catalog.yaml"))
+		}
+	})
+	t.Run("UnknownPackage", func(t *testing.T) {
+		err := exportFBC(context.Background(), dbFile, []string{"etcd", "missing"}, t.TempDir())
+		require.ErrorContains(t, err, `package "missing" not found in index`)
+	})
+}
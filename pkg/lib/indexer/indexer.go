@@ -16,12 +16,14 @@ import (
 	"gopkg.in/yaml.v2"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
 	"github.com/operator-framework/operator-registry/pkg/lib/bundle"
-	"github.com/operator-framework/operator-registry/pkg/lib/certs"
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 	pregistry "github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
@@ -49,6 +51,7 @@ type ImageIndexer struct {
 	RegistryPruner         registry.RegistryPruner
 	RegistryStrandedPruner registry.RegistryStrandedPruner
 	RegistryDeprecator     registry.RegistryDeprecator
+	RegistryBundleRemover  registry.RegistryBundleRemover
 	BuildTool              containertools.ContainerTool
 	PullTool               containertools.ContainerTool
 	Logger                 *logrus.Entry
@@ -69,10 +72,42 @@ type AddToIndexRequest struct {
 	PlainHTTP         bool
 	Overwrite         bool
 	EnableAlpha       bool
+
+	// SkipReferencedImages excludes the images that bundle CSVs only
+	// reference, through RELATED_IMAGE_* environment variables and image
+	// annotations, from the related images of added bundles.
+	SkipReferencedImages bool
+
+	// MaxParallel is the maximum number of bundle images that are pulled
+	// and unpacked at the same time. Zero means one at a time.
+	MaxParallel int
+
+	// BlobCacheDir is the directory of a persistent cache of image blobs
+	// that is used to pull bundle images. Empty means no cache.
+	BlobCacheDir string
+
+	// Push adds the bundles to the file-based catalog of FromIndex and
+	// pushes the resulting image to Tag, without a container tool, instead
+	// of building a sqlite-based index image.
+	Push bool
+
+	// DryRun validates the bundles by adding them to a copy of the index,
+	// and writes a report of the changes to the upgrade graphs of the index
+	// to DryRunOutput, or stdout if it is nil, without generating, building,
+	// or pushing an index image.
+	DryRun       bool
+	DryRunOutput io.Writer
 }
 
 // AddToIndex is an aggregate API used to generate a registry index image with additional bundles
 func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
+	if request.DryRun && request.Generate {
+		return liberrors.Errorf(liberrors.CodeInvalidArgument, "a dry run cannot be combined with generating a Dockerfile")
+	}
+	if request.Push {
+		return i.addToFileBasedIndex(context.TODO(), request)
+	}
+
 	buildDir, outDockerfile, cleanup, err := buildContext(request.Generate, request.OutDockerfile)
 	defer cleanup()
 	if err != nil {
@@ -84,17 +119,28 @@ func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
 		return err
 	}
 
+	var before declcfg.GraphSnapshot
+	if request.DryRun && request.FromIndex != "" {
+		if before, err = databaseGraphs(databasePath); err != nil {
+			return err
+		}
+	}
+
 	// Run opm registry add on the database
 	addToRegistryReq := registry.AddToRegistryRequest{
-		Bundles:       request.Bundles,
-		InputDatabase: databasePath,
-		Permissive:    request.Permissive,
-		Mode:          request.Mode,
-		SkipTLSVerify: request.SkipTLSVerify,
-		PlainHTTP:     request.PlainHTTP,
-		ContainerTool: i.PullTool,
-		Overwrite:     request.Overwrite,
-		EnableAlpha:   request.EnableAlpha,
+		Bundles:              request.Bundles,
+		InputDatabase:        databasePath,
+		Permissive:           request.Permissive,
+		Mode:                 request.Mode,
+		SkipTLSVerify:        request.SkipTLSVerify,
+		PlainHTTP:            request.PlainHTTP,
+		CaFile:               request.CaFile,
+		ContainerTool:        i.PullTool,
+		Overwrite:            request.Overwrite,
+		EnableAlpha:          request.EnableAlpha,
+		MaxParallel:          request.MaxParallel,
+		SkipReferencedImages: request.SkipReferencedImages,
+		BlobCacheDir:         request.BlobCacheDir,
 	}
 
 	// Add the bundles to the registry
@@ -104,6 +150,14 @@ func (i ImageIndexer) AddToIndex(request AddToIndexRequest) error {
 		return err
 	}
 
+	if request.DryRun {
+		after, err := databaseGraphs(databasePath)
+		if err != nil {
+			return err
+		}
+		return writeDryRunReport(request, before, after)
+	}
+
 	// generate the dockerfile
 	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
 	err = write(dockerfile, outDockerfile, i.Logger)
@@ -250,6 +304,8 @@ type PruneFromIndexRequest struct {
 	OutDockerfile     string
 	Tag               string
 	Packages          []string
+	KeepChannels      []string
+	KeepVersions      []string
 	CaFile            string
 	SkipTLSVerify     bool
 	PlainHTTP         bool
@@ -270,6 +326,8 @@ func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
 	// Run opm registry prune on the database
 	pruneFromRegistryReq := registry.PruneFromRegistryRequest{
 		Packages:      request.Packages,
+		KeepChannels:  request.KeepChannels,
+		KeepVersions:  request.KeepVersions,
 		InputDatabase: databasePath,
 		Permissive:    request.Permissive,
 	}
@@ -300,6 +358,69 @@ func (i ImageIndexer) PruneFromIndex(request PruneFromIndexRequest) error {
 	return nil
 }
 
+// RemoveBundlesFromIndexRequest defines the parameters to send to the RemoveBundlesFromIndex API
+type RemoveBundlesFromIndexRequest struct {
+	Generate          bool
+	Permissive        bool
+	BinarySourceImage string
+	FromIndex         string
+	OutDockerfile     string
+	Tag               string
+	Bundles           []string
+	ForceTruncate     bool
+	CaFile            string
+	SkipTLSVerify     bool
+	PlainHTTP         bool
+}
+
+// RemoveBundlesFromIndex is an aggregate API used to generate a registry index image
+// without specific bundles
+func (i ImageIndexer) RemoveBundlesFromIndex(request RemoveBundlesFromIndexRequest) error {
+	buildDir, outDockerfile, cleanup, err := buildContext(request.Generate, request.OutDockerfile)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	databasePath, err := i.ExtractDatabase(buildDir, request.FromIndex, request.CaFile, request.SkipTLSVerify, request.PlainHTTP)
+	if err != nil {
+		return err
+	}
+
+	// Run opm registry rm-bundle on the database
+	removeBundlesFromRegistryReq := registry.RemoveBundlesFromRegistryRequest{
+		Bundles:       request.Bundles,
+		ForceTruncate: request.ForceTruncate,
+		InputDatabase: databasePath,
+		Permissive:    request.Permissive,
+	}
+
+	// Remove the bundles from the registry
+	err = i.RegistryBundleRemover.RemoveBundlesFromRegistry(removeBundlesFromRegistryReq)
+	if err != nil {
+		return err
+	}
+
+	// generate the dockerfile
+	dockerfile := i.DockerfileGenerator.GenerateIndexDockerfile(request.BinarySourceImage, databasePath)
+	err = write(dockerfile, outDockerfile, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	if request.Generate {
+		return nil
+	}
+
+	// build the dockerfile
+	err = build(outDockerfile, request.Tag, i.CommandRunner, i.Logger)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ExtractDatabase sets a temp directory for unpacking an image
 func (i ImageIndexer) ExtractDatabase(buildDir, fromIndex, caFile string, skipTLSVerify, plainHTTP bool) (string, error) {
 	tmpDir, err := os.MkdirTemp("./", tmpDirPrefix)
@@ -326,21 +447,14 @@ func (i ImageIndexer) getDatabaseFile(workingDir, fromIndex, caFile string, skip
 
 	var reg image.Registry
 	var rerr error
+	clientConfig := image.ClientConfig{CAFile: caFile, SkipTLSVerify: skipTLSVerify, PlainHTTP: plainHTTP}
 	switch i.PullTool {
 	case containertools.NoneTool:
-		rootCAs, err := certs.RootCAs(caFile)
-		if err != nil {
-			return "", fmt.Errorf("failed to get RootCAs: %v", err)
-		}
 		reg, rerr = containerdregistry.NewRegistry(
-			containerdregistry.SkipTLSVerify(skipTLSVerify),
-			containerdregistry.WithPlainHTTP(plainHTTP),
-			containerdregistry.WithLog(i.Logger),
-			containerdregistry.WithRootCAs(rootCAs))
-	case containertools.PodmanTool:
-		fallthrough
-	case containertools.DockerTool:
-		reg, rerr = execregistry.NewRegistry(i.PullTool, i.Logger, containertools.SkipTLS(plainHTTP))
+			containerdregistry.WithClientConfig(clientConfig),
+			containerdregistry.WithLog(i.Logger))
+	case containertools.PodmanTool, containertools.DockerTool, containertools.NerdctlTool, containertools.CraneTool:
+		reg, rerr = execregistry.NewRegistryWithClientConfig(i.PullTool, i.Logger, clientConfig)
 	}
 	if rerr != nil {
 		return "", rerr
@@ -488,6 +602,19 @@ func write(dockerfileText, outDockerfile string, logger *logrus.Entry) error {
 	return nil
 }
 
+// ExportFormat is the format that ExportFromIndex exports operators in.
+type ExportFormat string
+
+const (
+	// ExportFormatAppregistry exports the manifests of every bundle of an
+	// operator, along with a package.yaml file. It is the default format.
+	ExportFormatAppregistry ExportFormat = "appregistry"
+
+	// ExportFormatFBC exports the file-based catalog of every operator to
+	// <DownloadPath>/<package>/catalog.yaml.
+	ExportFormatFBC ExportFormat = "fbc"
+)
+
 // ExportFromIndexRequest defines the parameters to send to the ExportFromIndex API
 type ExportFromIndexRequest struct {
 	Index         string
@@ -497,11 +624,18 @@ type ExportFromIndexRequest struct {
 	CaFile        string
 	SkipTLSVerify bool
 	PlainHTTP     bool
+	Format        ExportFormat
 }
 
 // ExportFromIndex is an aggregate API used to specify operators from
 // an index image
 func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) error {
+	switch request.Format {
+	case "", ExportFormatAppregistry, ExportFormatFBC:
+	default:
+		return fmt.Errorf("unknown export format %q, expected one of %q or %q", request.Format, ExportFormatAppregistry, ExportFormatFBC)
+	}
+
 	// set a temp directory
 	workingDir, err := os.MkdirTemp("./", tmpDirPrefix)
 	if err != nil {
@@ -515,6 +649,10 @@ func (i ImageIndexer) ExportFromIndex(request ExportFromIndexRequest) error {
 		return err
 	}
 
+	if request.Format == ExportFormatFBC {
+		return exportFBC(context.TODO(), databaseFile, request.Packages, request.DownloadPath)
+	}
+
 	db, err := sqlite.Open(databaseFile)
 	if err != nil {
 		return err
@@ -717,3 +855,28 @@ func (i ImageIndexer) DeprecateFromIndex(request DeprecateFromIndexRequest) erro
 
 	return nil
 }
+
+// databaseGraphs returns a snapshot of the upgrade graphs of the sqlite
+// database at databaseFile.
+func databaseGraphs(databaseFile string) (declcfg.GraphSnapshot, error) {
+	cfg, err := action.Render{
+		Refs:           []string{databaseFile},
+		AllowedRefMask: action.RefSqliteFile,
+	}.Run(context.TODO())
+	if err != nil {
+		return declcfg.GraphSnapshot{}, fmt.Errorf("render index database: %v", err)
+	}
+	return declcfg.SnapshotGraphs(*cfg), nil
+}
+
+// writeDryRunReport writes the report of a dry run of request, the changes
+// from the upgrade graphs of the index before to those after adding the
+// bundles.
+func writeDryRunReport(request AddToIndexRequest, before, after declcfg.GraphSnapshot) error {
+	w := request.DryRunOutput
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err := io.WriteString(w, declcfg.CompareGraphs(before, after).String())
+	return err
+}
@@ -1,13 +1,18 @@
 package indexer
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"reflect"
 	"sort"
 	"testing"
 
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/yaml"
 
+	"github.com/operator-framework/operator-registry/pkg/lib/registry"
 	pregistry "github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -141,3 +146,46 @@ func TestBuildContext(t *testing.T) {
 		}
 	}
 }
+
+// registryAdderFunc adds bundles to a registry by calling itself.
+type registryAdderFunc func(registry.AddToRegistryRequest) error
+
+func (f registryAdderFunc) AddToRegistry(request registry.AddToRegistryRequest) error {
+	return f(request)
+}
+
+func TestAddToIndexDryRun(t *testing.T) {
+	// The adder populates the database with the manifests of the
+	// repository, rather than pulling bundle images.
+	adder := registryAdderFunc(func(request registry.AddToRegistryRequest) error {
+		db, err := sqlite.Open(request.InputDatabase)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		loader, err := sqlite.NewDeprecationAwareLoader(db)
+		if err != nil {
+			return err
+		}
+		if err := loader.Migrate(context.Background()); err != nil {
+			return err
+		}
+		return sqlite.NewSQLLoaderForDirectory(loader, "../../../manifests").Populate()
+	})
+	// The indexer has no Dockerfile generator or command runner, as a dry
+	// run neither generates nor builds an index image.
+	indexer := ImageIndexer{RegistryAdder: adder, Logger: logrus.NewEntry(logrus.New())}
+
+	var out bytes.Buffer
+	require.NoError(t, indexer.AddToIndex(AddToIndexRequest{
+		Bundles:      []string{"quay.io/test/etcd:v0.9.2"},
+		DryRun:       true,
+		DryRunOutput: &out,
+	}))
+	require.Contains(t, out.String(), "package \"etcd\", channel \"alpha\" (new channel):\n")
+	require.Contains(t, out.String(), "  + entry etcdoperator.v0.9.2 (replaces etcdoperator.v0.9.0; skips etcdoperator.v0.9.1; skipRange \"< 0.6.0\")\n")
+	require.Contains(t, out.String(), "  + edge etcdoperator.v0.9.0 -> etcdoperator.v0.9.2 (replaces)\n")
+
+	err := indexer.AddToIndex(AddToIndexRequest{Generate: true, DryRun: true})
+	require.ErrorContains(t, err, "a dry run cannot be combined with generating a Dockerfile")
+}
@@ -100,6 +100,23 @@ func NewIndexPruner(containerTool containertools.ContainerTool, logger *logrus.E
 	}
 }
 
+// IndexBundleRemover removes bundles from an index
+type IndexBundleRemover interface {
+	RemoveBundlesFromIndex(RemoveBundlesFromIndexRequest) error
+}
+
+func NewIndexBundleRemover(buildTool, pullTool containertools.ContainerTool, logger *logrus.Entry) IndexBundleRemover {
+	return ImageIndexer{
+		DockerfileGenerator:   containertools.NewDockerfileGenerator(logger),
+		CommandRunner:         containertools.NewCommandRunner(buildTool, logger),
+		LabelReader:           containertools.NewLabelReader(pullTool, logger),
+		RegistryBundleRemover: registry.NewRegistryBundleRemover(logger),
+		BuildTool:             buildTool,
+		PullTool:              pullTool,
+		Logger:                logger,
+	}
+}
+
 // IndexDeprecator prunes operators out of an index
 type IndexDeprecator interface {
 	DeprecateFromIndex(DeprecateFromIndexRequest) error
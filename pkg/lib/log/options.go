@@ -0,0 +1,119 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+)
+
+// The formats of the --log-format flag.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options are the format and level of logs, as set by the --log-format and
+// --log-level flags. Empty options leave the format or level of loggers
+// unchanged.
+type Options struct {
+	// Format is FormatText or FormatJSON. JSON logs are written as one
+	// object per line, with the fields of each entry, such as the package,
+	// bundle, or image that it is about, as keys of the object.
+	Format string
+	// Level is the minimum level of the entries that are logged, e.g.
+	// "info" or "debug".
+	Level string
+}
+
+// AddFlags adds the --log-format and --log-level flags, which set o, to fs.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.Var(&formatValue{&o.Format}, "log-format", "Format of logs (text|json). In json format, each log entry is written as a JSON object with its fields as keys")
+	fs.Var(&levelValue{&o.Level}, "log-level", "Minimum level of logs (trace|debug|info|warn|error|fatal|panic) (default info)")
+}
+
+// Apply sets the format and level of logger to those of o.
+func (o Options) Apply(logger *logrus.Logger) {
+	switch o.Format {
+	case FormatText:
+		logger.SetFormatter(&logrus.TextFormatter{})
+	case FormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+	if level, err := logrus.ParseLevel(o.Level); err == nil {
+		logger.SetLevel(level)
+	}
+}
+
+var (
+	mu      sync.Mutex
+	options Options
+	loggers []*logrus.Logger
+)
+
+// Configure applies o to the standard logger, and to the loggers that are
+// created with New, including those created later.
+func Configure(o Options) {
+	mu.Lock()
+	defer mu.Unlock()
+	options = o
+	o.Apply(logrus.StandardLogger())
+	for _, l := range loggers {
+		o.Apply(l)
+	}
+}
+
+// New returns a new logger, with the options set with Configure. Commands
+// create their loggers with it, before their flags are parsed, so that the
+// options are applied to them once they are.
+func New() *logrus.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	l := logrus.New()
+	options.Apply(l)
+	loggers = append(loggers, l)
+	return l
+}
+
+type formatValue struct {
+	format *string
+}
+
+func (v *formatValue) String() string {
+	if v.format == nil {
+		return ""
+	}
+	return *v.format
+}
+
+func (v *formatValue) Set(s string) error {
+	if s != FormatText && s != FormatJSON {
+		return fmt.Errorf("invalid log format %q, expected (text|json)", s)
+	}
+	*v.format = s
+	return nil
+}
+
+func (v *formatValue) Type() string { return "string" }
+
+type levelValue struct {
+	level *string
+}
+
+func (v *levelValue) String() string {
+	if v.level == nil {
+		return ""
+	}
+	return *v.level
+}
+
+func (v *levelValue) Set(s string) error {
+	if _, err := logrus.ParseLevel(s); err != nil {
+		return err
+	}
+	*v.level = s
+	return nil
+}
+
+func (v *levelValue) Type() string { return "string" }
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsFlags(t *testing.T) {
+	var o Options
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	o.AddFlags(fs)
+	require.NoError(t, fs.Parse([]string{"--log-format=json", "--log-level", "debug"}))
+	require.Equal(t, Options{Format: FormatJSON, Level: "debug"}, o)
+
+	require.ErrorContains(t, fs.Parse([]string{"--log-format=xml"}), `invalid log format "xml", expected (text|json)`)
+	require.ErrorContains(t, fs.Parse([]string{"--log-level=verbose"}), `not a valid logrus Level: "verbose"`)
+}
+
+func TestOptionsApply(t *testing.T) {
+	logger := logrus.New()
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	// Empty options leave the logger unchanged.
+	Options{}.Apply(logger)
+	require.IsType(t, &logrus.TextFormatter{}, logger.Formatter)
+	require.Equal(t, logrus.InfoLevel, logger.Level)
+
+	Options{Format: FormatJSON, Level: "warn"}.Apply(logger)
+	logger.WithFields(logrus.Fields{"package": "foo", "bundle": "foo.v0.1.0"}).Info("ignored")
+	logger.WithField("image", "quay.io/example/foo:v0.1.0").Warn("pulled image")
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "pulled image", entry["msg"])
+	require.Equal(t, "warning", entry["level"])
+	require.Equal(t, "quay.io/example/foo:v0.1.0", entry["image"])
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(Options{Format: FormatText, Level: "info"})
+
+	before := New()
+	Configure(Options{Format: FormatJSON, Level: "debug"})
+	after := New()
+	for _, l := range []*logrus.Logger{before, after, logrus.StandardLogger()} {
+		require.IsType(t, &logrus.JSONFormatter{}, l.Formatter)
+		require.Equal(t, logrus.DebugLevel, l.Level)
+	}
+}
@@ -47,6 +47,16 @@ func NewRegistryPruner(logger *logrus.Entry) RegistryPruner {
 	}
 }
 
+type RegistryBundleRemover interface {
+	RemoveBundlesFromRegistry(RemoveBundlesFromRegistryRequest) error
+}
+
+func NewRegistryBundleRemover(logger *logrus.Entry) RegistryBundleRemover {
+	return RegistryUpdater{
+		Logger: logger,
+	}
+}
+
 type RegistryDeprecator interface {
 	DeprecateFromRegistry(DeprecateFromRegistryRequest) error
 }
@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/blang/semver/v4"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/operator-framework/operator-registry/pkg/containertools"
 	"github.com/operator-framework/operator-registry/pkg/image"
 	"github.com/operator-framework/operator-registry/pkg/image/containerdregistry"
 	"github.com/operator-framework/operator-registry/pkg/image/execregistry"
-	"github.com/operator-framework/operator-registry/pkg/lib/certs"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"github.com/operator-framework/operator-registry/pkg/sqlite"
 )
@@ -32,6 +35,20 @@ type AddToRegistryRequest struct {
 	ContainerTool containertools.ContainerTool
 	Overwrite     bool
 	EnableAlpha   bool
+
+	// SkipReferencedImages excludes the images that bundle CSVs only
+	// reference, through RELATED_IMAGE_* environment variables and image
+	// annotations, from the related images of added bundles.
+	SkipReferencedImages bool
+
+	// MaxParallel is the maximum number of bundle images that are pulled
+	// and unpacked at the same time. Zero means one at a time.
+	MaxParallel int
+
+	// BlobCacheDir is the directory of a persistent cache of image blobs
+	// that is used to pull bundle images. Empty means no cache. It is only
+	// used if ContainerTool is none.
+	BlobCacheDir string
 }
 
 func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
@@ -41,7 +58,7 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 	}
 	defer db.Close()
 
-	dbLoader, err := sqlite.NewSQLLiteLoader(db, sqlite.WithEnableAlpha(request.EnableAlpha))
+	dbLoader, err := sqlite.NewSQLLiteLoader(db, sqlite.WithEnableAlpha(request.EnableAlpha), sqlite.WithSkipReferencedImages(request.SkipReferencedImages))
 	if err != nil {
 		return err
 	}
@@ -56,25 +73,21 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 	}
 	dbQuerier := sqlite.NewSQLLiteQuerierFromDb(db)
 
-	// add custom ca certs to resolver
-
 	var reg image.Registry
 	var rerr error
+	clientConfig := image.ClientConfig{
+		CAFile:        request.CaFile,
+		SkipTLSVerify: request.SkipTLSVerify,
+		PlainHTTP:     request.PlainHTTP,
+	}
 	switch request.ContainerTool {
 	case containertools.NoneTool:
-		rootCAs, err := certs.RootCAs(request.CaFile)
-		if err != nil {
-			return fmt.Errorf("failed to get RootCAs: %v", err)
-		}
 		reg, rerr = containerdregistry.NewRegistry(
-			containerdregistry.SkipTLSVerify(request.SkipTLSVerify),
-			containerdregistry.WithPlainHTTP(request.PlainHTTP),
-			containerdregistry.WithRootCAs(rootCAs),
+			containerdregistry.WithClientConfig(clientConfig),
+			containerdregistry.WithBlobCacheDir(request.BlobCacheDir),
 		)
-	case containertools.PodmanTool:
-		fallthrough
-	case containertools.DockerTool:
-		reg, rerr = execregistry.NewRegistry(request.ContainerTool, r.Logger, containertools.SkipTLS(request.PlainHTTP))
+	case containertools.PodmanTool, containertools.DockerTool, containertools.NerdctlTool, containertools.CraneTool:
+		reg, rerr = execregistry.NewRegistryWithClientConfig(request.ContainerTool, r.Logger, clientConfig)
 	}
 	if rerr != nil {
 		return rerr
@@ -90,7 +103,7 @@ func (r RegistryUpdater) AddToRegistry(request AddToRegistryRequest) error {
 		simpleRefs = append(simpleRefs, image.SimpleReference(ref))
 	}
 
-	if err := populate(context.TODO(), dbLoader, graphLoader, dbQuerier, reg, simpleRefs, request.Mode, request.Overwrite); err != nil {
+	if err := populate(context.TODO(), dbLoader, graphLoader, dbQuerier, reg, simpleRefs, request.Mode, request.Overwrite, request.MaxParallel); err != nil {
 		r.Logger.Debugf("unable to populate database: %s", err)
 
 		if !request.Permissive {
@@ -130,19 +143,49 @@ func unpackImage(ctx context.Context, reg image.Registry, ref image.Reference) (
 	return ref, workingDir, cleanup, nil
 }
 
-func populate(ctx context.Context, loader registry.Load, graphLoader registry.GraphLoader, querier registry.Query, reg image.Registry, refs []image.Reference, mode registry.Mode, overwrite bool) error {
+// unpackImages pulls and unpacks refs, at most maxParallel at a time. The
+// returned directories and cleanup functions are in the order of refs.
+func unpackImages(ctx context.Context, reg image.Registry, refs []image.Reference, maxParallel int) ([]string, func(), error) {
+	dirs := make([]string, len(refs))
+	cleanups := make([]func(), len(refs))
+	cleanup := func() {
+		for _, c := range cleanups {
+			if c != nil {
+				c()
+			}
+		}
+	}
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(max(maxParallel, 1))
+	for i, ref := range refs {
+		eg.Go(func() error {
+			_, dir, c, err := unpackImage(egCtx, reg, ref)
+			cleanups[i] = c
+			dirs[i] = dir
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, cleanup, err
+	}
+	return dirs, cleanup, nil
+}
+
+func populate(ctx context.Context, loader registry.Load, graphLoader registry.GraphLoader, querier registry.Query, reg image.Registry, refs []image.Reference, mode registry.Mode, overwrite bool, maxParallel int) error {
+	dirs, cleanup, err := unpackImages(ctx, reg, refs, maxParallel)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
 	unpackedImageMap := make(map[image.Reference]string, 0)
 	overwrittenBundles := map[string][]string{}
 	var imagesToAdd []*registry.Bundle
-	for _, ref := range refs {
-		to, from, cleanup, err := unpackImage(ctx, reg, ref)
-		if err != nil {
-			return err
-		}
-		unpackedImageMap[to] = from
-		defer cleanup()
+	for i, ref := range refs {
+		unpackedImageMap[ref] = dirs[i]
 
-		img, err := registry.NewImageInput(to, from)
+		img, err := registry.NewImageInput(ref, dirs[i])
 		if err != nil {
 			return err
 		}
@@ -253,6 +296,83 @@ type PruneFromRegistryRequest struct {
 	Permissive    bool
 	InputDatabase string
 	Packages      []string
+
+	// KeepChannels limits the channels that are kept in the kept packages.
+	// Each entry is a channel name, which applies to every kept package, or
+	// a package name and a channel name separated by a colon, which applies
+	// to that package only. Packages without entries keep all channels.
+	KeepChannels []string
+
+	// KeepVersions limits the bundle versions that are kept in the kept
+	// packages. Each entry is a semver range, e.g. ">=1.2.0 <2.0.0", which
+	// applies to every kept package, or a package name and a semver range
+	// separated by a colon, which applies to that package only. A bundle is
+	// kept if its version is in any of the ranges that apply to its package.
+	KeepVersions []string
+}
+
+// packagePruneFilter selects the channels and bundle versions to keep in a
+// package.
+type packagePruneFilter struct {
+	channels []string
+	versions semver.Range
+}
+
+// pruneFilters parses the keepChannels and keepVersions of a prune request
+// into a filter for each of the kept packages that they apply to.
+func pruneFilters(packages, keepChannels, keepVersions []string) (map[string]*packagePruneFilter, error) {
+	kept := sets.New[string](packages...)
+	filters := map[string]*packagePruneFilter{}
+	// forEachPackage calls fn with the filter of each package that a
+	// "[<package>:]<value>" entry applies to, and the value.
+	forEachPackage := func(flag, entry string, fn func(f *packagePruneFilter, value string) error) error {
+		pkgs := packages
+		value := entry
+		if pkg, v, ok := strings.Cut(entry, ":"); ok {
+			if !kept.Has(pkg) {
+				return fmt.Errorf("invalid %s entry %q: package %q is not kept", flag, entry, pkg)
+			}
+			pkgs, value = []string{pkg}, v
+		}
+		if value == "" {
+			return fmt.Errorf("invalid %s entry %q: value must not be empty", flag, entry)
+		}
+		for _, pkg := range pkgs {
+			if filters[pkg] == nil {
+				filters[pkg] = &packagePruneFilter{}
+			}
+			if err := fn(filters[pkg], value); err != nil {
+				return fmt.Errorf("invalid %s entry %q: %v", flag, entry, err)
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range keepChannels {
+		if err := forEachPackage("keep channels", entry, func(f *packagePruneFilter, channel string) error {
+			f.channels = append(f.channels, channel)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range keepVersions {
+		if err := forEachPackage("keep versions", entry, func(f *packagePruneFilter, versions string) error {
+			r, err := semver.ParseRange(versions)
+			if err != nil {
+				return err
+			}
+			if f.versions == nil {
+				f.versions = r
+			} else {
+				f.versions = f.versions.OR(r)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return filters, nil
 }
 
 func (r RegistryUpdater) PruneFromRegistry(request PruneFromRegistryRequest) error {
@@ -270,6 +390,11 @@ func (r RegistryUpdater) PruneFromRegistry(request PruneFromRegistryRequest) err
 		return err
 	}
 
+	filters, err := pruneFilters(request.Packages, request.KeepChannels, request.KeepVersions)
+	if err != nil {
+		return err
+	}
+
 	// get all the packages
 	lister := sqlite.NewSQLLiteQuerierFromDb(db)
 	packages, err := lister.ListPackages(context.TODO())
@@ -298,6 +423,61 @@ func (r RegistryUpdater) PruneFromRegistry(request PruneFromRegistryRequest) err
 		}
 	}
 
+	// prune channels and versions from the kept packages
+	for _, pkg := range packages {
+		f, ok := filters[pkg]
+		if !ok || !pkgMap[pkg] {
+			continue
+		}
+		if err := dbLoader.PrunePackage(pkg, f.channels, f.versions); err != nil {
+			err = fmt.Errorf("error pruning package %q: %s", pkg, err)
+			if !request.Permissive {
+				return err
+			}
+			logrus.WithError(err).Warn("permissive mode enabled")
+		}
+	}
+
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return err
+	}
+	return nil
+}
+
+type RemoveBundlesFromRegistryRequest struct {
+	Permissive    bool
+	InputDatabase string
+	// Bundles are the names of the bundles to remove.
+	Bundles []string
+	// ForceTruncate also removes the bundles that the removed bundles
+	// replace, directly or transitively, instead of rewiring the upgrade
+	// graph around the removed bundles.
+	ForceTruncate bool
+}
+
+func (r RegistryUpdater) RemoveBundlesFromRegistry(request RemoveBundlesFromRegistryRequest) error {
+	db, err := sqlite.Open(request.InputDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	dbLoader, err := sqlite.NewDeprecationAwareLoader(db)
+	if err != nil {
+		return err
+	}
+	if err := dbLoader.Migrate(context.TODO()); err != nil {
+		return err
+	}
+
+	if err := dbLoader.RemoveBundles(request.Bundles, request.ForceTruncate); err != nil {
+		err = fmt.Errorf("error removing bundles from database: %s", err)
+		if !request.Permissive {
+			return err
+		}
+		r.Logger.WithError(err).Warn("permissive mode enabled")
+	}
+
 	if _, err := db.Exec("VACUUM"); err != nil {
 		return err
 	}
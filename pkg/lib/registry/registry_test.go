@@ -712,3 +712,84 @@ func TestCheckForBundles(t *testing.T) {
 		})
 	}
 }
+
+func TestPruneFilters(t *testing.T) {
+	type spec struct {
+		name         string
+		keepChannels []string
+		keepVersions []string
+		expected     map[string][]string
+		versions     map[string]map[string]bool
+		err          string
+	}
+	specs := []spec{
+		{
+			name:     "None",
+			expected: map[string][]string{},
+		},
+		{
+			name:         "UnscopedChannels",
+			keepChannels: []string{"stable"},
+			expected:     map[string][]string{"etcd": {"stable"}, "prometheus": {"stable"}},
+		},
+		{
+			name:         "ScopedChannels",
+			keepChannels: []string{"etcd:stable", "etcd:fast", "prometheus:beta"},
+			expected:     map[string][]string{"etcd": {"stable", "fast"}, "prometheus": {"beta"}},
+		},
+		{
+			name:         "ScopedVersions",
+			keepVersions: []string{"etcd:>=1.2.0 <2.0.0", "etcd:>=3.0.0"},
+			expected:     map[string][]string{"etcd": nil},
+			versions: map[string]map[string]bool{
+				"etcd": {"1.1.0": false, "1.2.0": true, "2.0.0": false, "3.1.0": true},
+			},
+		},
+		{
+			name:         "UnscopedVersions",
+			keepVersions: []string{"<1.0.0"},
+			expected:     map[string][]string{"etcd": nil, "prometheus": nil},
+			versions: map[string]map[string]bool{
+				"etcd":       {"0.9.0": true, "1.0.0": false},
+				"prometheus": {"0.9.0": true, "1.0.0": false},
+			},
+		},
+		{
+			name:         "PackageNotKept",
+			keepChannels: []string{"other:stable"},
+			err:          `invalid keep channels entry "other:stable": package "other" is not kept`,
+		},
+		{
+			name:         "EmptyChannel",
+			keepChannels: []string{"etcd:"},
+			err:          `invalid keep channels entry "etcd:": value must not be empty`,
+		},
+		{
+			name:         "InvalidRange",
+			keepVersions: []string{"etcd:>=one"},
+			err:          `invalid keep versions entry "etcd:>=one"`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			filters, err := pruneFilters([]string{"etcd", "prometheus"}, s.keepChannels, s.keepVersions)
+			if s.err != "" {
+				require.ErrorContains(t, err, s.err)
+				return
+			}
+			require.NoError(t, err)
+
+			channels := map[string][]string{}
+			for pkg, f := range filters {
+				channels[pkg] = f.channels
+			}
+			require.Equal(t, s.expected, channels)
+
+			for pkg, versions := range s.versions {
+				for v, keep := range versions {
+					require.Equal(t, keep, filters[pkg].versions(semver.MustParse(v)), "package %s version %s", pkg, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package tracing configures the OpenTelemetry tracing of opm.
+//
+// Image pulls and unpacks, and the RPCs of the registry server, are traced
+// with spans from the global tracer provider. By default, the global provider
+// discards them. Setup installs a provider that exports them with the
+// exporter selected by the OTEL_TRACES_EXPORTER environment variable:
+//
+//   - otlp exports spans to an OpenTelemetry collector, configured with the
+//     standard OTEL_EXPORTER_OTLP_* environment variables, e.g.
+//     OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_EXPORTER_OTLP_PROTOCOL.
+//   - console writes spans to stderr as JSON, so that they do not mix with
+//     catalogs written to stdout.
+//   - none, or an unset variable, disables tracing.
+//
+// Metrics, such as the refreshes of registry credentials, are recorded with
+// instruments from the global meter provider. Setup installs a provider that
+// exports them with the exporter selected by the OTEL_METRICS_EXPORTER
+// environment variable, which takes the same values, and also prometheus.
+//
+// The traced service is named by OTEL_SERVICE_NAME, and defaults to "opm".
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/contrib/exporters/autoexport"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	exporterEnv        = "OTEL_TRACES_EXPORTER"
+	metricsExporterEnv = "OTEL_METRICS_EXPORTER"
+	serviceNameEnv     = "OTEL_SERVICE_NAME"
+	disabledEnv        = "OTEL_SDK_DISABLED"
+
+	defaultServiceName = "opm"
+)
+
+// Setup installs a global tracer provider that exports spans with the
+// exporter selected by the environment, and a propagator of W3C trace
+// context, so that the spans of the registry server join the traces of its
+// clients. It also installs a global meter provider that exports metrics
+// with the exporter selected by the environment.
+//
+// The returned function flushes the pending spans and metrics and shuts the
+// providers down, and must be called before the process exits. If neither
+// tracing nor metrics are enabled, Setup installs nothing and the returned
+// function does nothing.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if os.Getenv(disabledEnv) == "true" {
+		return noop, nil
+	}
+	exporterName := os.Getenv(exporterEnv)
+	tracesEnabled := exporterName != "" && exporterName != "none"
+	metricsExporterName := os.Getenv(metricsExporterEnv)
+	metricsEnabled := metricsExporterName != "" && metricsExporterName != "none"
+	if !tracesEnabled && !metricsEnabled {
+		return noop, nil
+	}
+
+	res := resource.Default()
+	if os.Getenv(serviceNameEnv) == "" {
+		var err error
+		res, err = resource.Merge(res, resource.NewSchemaless(attribute.String("service.name", defaultServiceName)))
+		if err != nil {
+			return noop, err
+		}
+	}
+
+	var shutdowns []func(context.Context) error
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		for _, s := range shutdowns {
+			errs = append(errs, s(ctx))
+		}
+		return errors.Join(errs...)
+	}
+
+	if tracesEnabled {
+		var (
+			exporter sdktrace.SpanExporter
+			err      error
+		)
+		if exporterName == "console" {
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		} else {
+			exporter, err = autoexport.NewSpanExporter(ctx)
+		}
+		if err != nil {
+			return noop, fmt.Errorf("create %s trace exporter: %v", exporterName, err)
+		}
+
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+
+	if metricsEnabled {
+		var (
+			reader sdkmetric.Reader
+			err    error
+		)
+		if metricsExporterName == "console" {
+			var exporter sdkmetric.Exporter
+			exporter, err = stdoutmetric.New(stdoutmetric.WithWriter(os.Stderr))
+			if err == nil {
+				reader = sdkmetric.NewPeriodicReader(exporter)
+			}
+		} else {
+			reader, err = autoexport.NewMetricReader(ctx)
+		}
+		if err != nil {
+			return shutdown, fmt.Errorf("create %s metric exporter: %v", metricsExporterName, err)
+		}
+
+		provider := sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithResource(res),
+		)
+		otel.SetMeterProvider(provider)
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+	return shutdown, nil
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetup(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	ctx := context.Background()
+
+	for _, env := range []map[string]string{
+		{exporterEnv: ""},
+		{exporterEnv: "none"},
+		{exporterEnv: "console", disabledEnv: "true"},
+	} {
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+		shutdown, err := Setup(ctx)
+		require.NoError(t, err)
+		require.NoError(t, shutdown(ctx))
+		require.Equal(t, prev, otel.GetTracerProvider(), "tracing must not be set up with %v", env)
+	}
+
+	t.Setenv(disabledEnv, "")
+	t.Setenv(exporterEnv, "unknown")
+	_, err := Setup(ctx)
+	require.ErrorContains(t, err, "create unknown trace exporter")
+
+	t.Setenv(exporterEnv, "console")
+	shutdown, err := Setup(ctx)
+	require.NoError(t, err)
+	require.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+	require.NoError(t, shutdown(ctx))
+}
+
+func TestSetupMetrics(t *testing.T) {
+	prevTracer, prevMeter := otel.GetTracerProvider(), otel.GetMeterProvider()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTracer)
+		otel.SetMeterProvider(prevMeter)
+	})
+	ctx := context.Background()
+	t.Setenv(exporterEnv, "")
+
+	t.Setenv(metricsExporterEnv, "none")
+	shutdown, err := Setup(ctx)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+	require.Equal(t, prevMeter, otel.GetMeterProvider())
+
+	t.Setenv(metricsExporterEnv, "unknown")
+	_, err = Setup(ctx)
+	require.ErrorContains(t, err, "create unknown metric exporter")
+
+	// Metrics are set up without tracing.
+	t.Setenv(metricsExporterEnv, "console")
+	shutdown, err = Setup(ctx)
+	require.NoError(t, err)
+	require.IsType(t, &sdkmetric.MeterProvider{}, otel.GetMeterProvider())
+	require.Equal(t, prevTracer, otel.GetTracerProvider())
+	require.NoError(t, shutdown(ctx))
+}
@@ -0,0 +1,136 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/operator-framework/api/pkg/validation/errors"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// DefaultKubernetesVersion is the Kubernetes version that bundles are checked
+// against for deprecated APIs when no version is set.
+const DefaultKubernetesVersion = "1.32"
+
+// RemovedAPI is an API version of a kind that is deprecated in a Kubernetes
+// version, and removed from a later one.
+type RemovedAPI struct {
+	GroupVersion string
+	Kind         string
+	// DeprecatedIn and RemovedIn are Kubernetes minor versions, e.g. "1.16".
+	DeprecatedIn string
+	RemovedIn    string
+	// Replacement is the API version that the kind should be migrated to.
+	Replacement string
+}
+
+// RemovedAPIs is the built-in schedule of the removals of Kubernetes APIs.
+var RemovedAPIs = []RemovedAPI{
+	{GroupVersion: "extensions/v1beta1", Kind: "DaemonSet", DeprecatedIn: "1.8", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{GroupVersion: "extensions/v1beta1", Kind: "Deployment", DeprecatedIn: "1.8", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{GroupVersion: "extensions/v1beta1", Kind: "ReplicaSet", DeprecatedIn: "1.8", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{GroupVersion: "extensions/v1beta1", Kind: "NetworkPolicy", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "networking.k8s.io/v1"},
+	{GroupVersion: "extensions/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: "1.10", RemovedIn: "1.16", Replacement: "policy/v1beta1"},
+	{GroupVersion: "extensions/v1beta1", Kind: "Ingress", DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{GroupVersion: "apps/v1beta1", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{GroupVersion: "apps/v1beta2", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+
+	{GroupVersion: "admissionregistration.k8s.io/v1beta1", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+	{GroupVersion: "apiextensions.k8s.io/v1beta1", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "apiextensions.k8s.io/v1"},
+	{GroupVersion: "apiregistration.k8s.io/v1beta1", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "apiregistration.k8s.io/v1"},
+	{GroupVersion: "authentication.k8s.io/v1beta1", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "authentication.k8s.io/v1"},
+	{GroupVersion: "authorization.k8s.io/v1beta1", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{GroupVersion: "certificates.k8s.io/v1beta1", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "certificates.k8s.io/v1"},
+	{GroupVersion: "coordination.k8s.io/v1beta1", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "coordination.k8s.io/v1"},
+	{GroupVersion: "networking.k8s.io/v1beta1", Kind: "Ingress", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{GroupVersion: "networking.k8s.io/v1beta1", Kind: "IngressClass", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{GroupVersion: "rbac.authorization.k8s.io/v1beta1", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{GroupVersion: "scheduling.k8s.io/v1beta1", DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "scheduling.k8s.io/v1"},
+	{GroupVersion: "storage.k8s.io/v1beta1", Kind: "CSIDriver", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{GroupVersion: "storage.k8s.io/v1beta1", Kind: "CSINode", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{GroupVersion: "storage.k8s.io/v1beta1", Kind: "StorageClass", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{GroupVersion: "storage.k8s.io/v1beta1", Kind: "VolumeAttachment", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+
+	{GroupVersion: "batch/v1beta1", Kind: "CronJob", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "batch/v1"},
+	{GroupVersion: "discovery.k8s.io/v1beta1", Kind: "EndpointSlice", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "discovery.k8s.io/v1"},
+	{GroupVersion: "events.k8s.io/v1beta1", Kind: "Event", DeprecatedIn: "1.19", RemovedIn: "1.25", Replacement: "events.k8s.io/v1"},
+	{GroupVersion: "autoscaling/v2beta1", Kind: "HorizontalPodAutoscaler", DeprecatedIn: "1.22", RemovedIn: "1.25", Replacement: "autoscaling/v2"},
+	{GroupVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "policy/v1"},
+	{GroupVersion: "policy/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "Pod Security Admission"},
+	{GroupVersion: "node.k8s.io/v1beta1", Kind: "RuntimeClass", DeprecatedIn: "1.20", RemovedIn: "1.25", Replacement: "node.k8s.io/v1"},
+
+	{GroupVersion: "flowcontrol.apiserver.k8s.io/v1beta1", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{GroupVersion: "autoscaling/v2beta2", Kind: "HorizontalPodAutoscaler", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "autoscaling/v2"},
+	{GroupVersion: "storage.k8s.io/v1beta1", Kind: "CSIStorageCapacity", DeprecatedIn: "1.24", RemovedIn: "1.27", Replacement: "storage.k8s.io/v1"},
+	{GroupVersion: "flowcontrol.apiserver.k8s.io/v1beta2", DeprecatedIn: "1.26", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{GroupVersion: "flowcontrol.apiserver.k8s.io/v1beta3", DeprecatedIn: "1.29", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// NewDeprecatedAPIValidator returns a Validator of Bundles that checks the
+// API versions of their objects against the RemovedAPIs schedule for
+// Kubernetes k8sVersion, e.g. "1.25" or "v1.25.3", or for
+// DefaultKubernetesVersion if it is empty. Objects of API versions that are
+// removed in that version are errors, and those that are deprecated in it,
+// and so will be removed in a later one, are warnings.
+func NewDeprecatedAPIValidator(k8sVersion string) (interfaces.Validator, error) {
+	if k8sVersion == "" {
+		k8sVersion = DefaultKubernetesVersion
+	}
+	target, err := parseKubernetesVersion(k8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	return interfaces.ValidatorFunc(func(objs ...interface{}) (results []errors.ManifestResult) {
+		for _, obj := range objs {
+			switch v := obj.(type) {
+			case *registry.Bundle:
+				results = append(results, validateDeprecatedAPIs(v, target))
+			}
+		}
+		return results
+	}), nil
+}
+
+func validateDeprecatedAPIs(bundle *registry.Bundle, target semver.Version) (result errors.ManifestResult) {
+	result.Name = bundle.Name
+	for _, obj := range bundle.Objects {
+		gvk := obj.GroupVersionKind()
+		removed, ok := findRemovedAPI(gvk)
+		if !ok {
+			continue
+		}
+		subject := fmt.Sprintf("%s %q (%s)", obj.GetKind(), obj.GetName(), obj.GetAPIVersion())
+		deprecatedIn, _ := parseKubernetesVersion(removed.DeprecatedIn)
+		removedIn, _ := parseKubernetesVersion(removed.RemovedIn)
+		switch {
+		case target.GTE(removedIn):
+			result.Add(errors.ErrInvalidBundle(fmt.Sprintf("%s uses an API that was removed in Kubernetes %s; migrate to %s", subject, removed.RemovedIn, removed.Replacement), gvk))
+		case target.GTE(deprecatedIn):
+			result.Add(errors.WarnInvalidBundle(fmt.Sprintf("%s uses an API that is deprecated and will be removed in Kubernetes %s; migrate to %s", subject, removed.RemovedIn, removed.Replacement), gvk))
+		}
+	}
+	return result
+}
+
+func findRemovedAPI(gvk schema.GroupVersionKind) (RemovedAPI, bool) {
+	gv := gvk.GroupVersion().String()
+	for _, r := range RemovedAPIs {
+		if r.GroupVersion == gv && (r.Kind == "" || r.Kind == gvk.Kind) {
+			return r, true
+		}
+	}
+	return RemovedAPI{}, false
+}
+
+// parseKubernetesVersion parses the minor version of a Kubernetes version,
+// e.g. "1.25", "v1.25", or "1.25.3".
+func parseKubernetesVersion(version string) (semver.Version, error) {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("invalid Kubernetes version %q: %v", version, err)
+	}
+	return semver.Version{Major: v.Major, Minor: v.Minor}, nil
+}
@@ -0,0 +1,86 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestDeprecatedAPIValidator(t *testing.T) {
+	object := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName(name)
+		return u
+	}
+	bundle := registry.NewBundle("test", &registry.Annotations{},
+		object("apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "foos.test.io"),
+		object("policy/v1beta1", "PodDisruptionBudget", "foo"),
+		object("policy/v1", "PodDisruptionBudget", "bar"),
+		object("apps/v1", "Deployment", "foo"),
+	)
+
+	var table = []struct {
+		description string
+		k8sVersion  string
+		errors      []string
+		warnings    []string
+	}{
+		{
+			description: "before any deprecation",
+			k8sVersion:  "1.15",
+		},
+		{
+			description: "deprecated",
+			k8sVersion:  "v1.21.3",
+			warnings: []string{
+				`CustomResourceDefinition "foos.test.io" (apiextensions.k8s.io/v1beta1) uses an API that is deprecated and will be removed in Kubernetes 1.22; migrate to apiextensions.k8s.io/v1`,
+				`PodDisruptionBudget "foo" (policy/v1beta1) uses an API that is deprecated and will be removed in Kubernetes 1.25; migrate to policy/v1`,
+			},
+		},
+		{
+			description: "removed and deprecated",
+			k8sVersion:  "1.22",
+			errors: []string{
+				`CustomResourceDefinition "foos.test.io" (apiextensions.k8s.io/v1beta1) uses an API that was removed in Kubernetes 1.22; migrate to apiextensions.k8s.io/v1`,
+			},
+			warnings: []string{
+				`PodDisruptionBudget "foo" (policy/v1beta1) uses an API that is deprecated and will be removed in Kubernetes 1.25; migrate to policy/v1`,
+			},
+		},
+		{
+			description: "default version",
+			errors: []string{
+				`CustomResourceDefinition "foos.test.io" (apiextensions.k8s.io/v1beta1) uses an API that was removed in Kubernetes 1.22; migrate to apiextensions.k8s.io/v1`,
+				`PodDisruptionBudget "foo" (policy/v1beta1) uses an API that was removed in Kubernetes 1.25; migrate to policy/v1`,
+			},
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.description, func(t *testing.T) {
+			validator, err := NewDeprecatedAPIValidator(tt.k8sVersion)
+			require.NoError(t, err)
+			results := validator.Validate(bundle)
+			require.Len(t, results, 1)
+			require.Equal(t, "test", results[0].Name)
+
+			var errs, warnings []string
+			for _, e := range results[0].Errors {
+				errs = append(errs, e.Detail)
+			}
+			for _, w := range results[0].Warnings {
+				warnings = append(warnings, w.Detail)
+			}
+			require.Equal(t, tt.errors, errs)
+			require.Equal(t, tt.warnings, warnings)
+		})
+	}
+
+	_, err := NewDeprecatedAPIValidator("latest")
+	require.ErrorContains(t, err, `invalid Kubernetes version "latest"`)
+}
@@ -342,6 +342,20 @@ func (b *Bundle) Images() (map[string]struct{}, error) {
 	return result, nil
 }
 
+// ReferencedImages returns the images that the CSV of the bundle references
+// through RELATED_IMAGE_* environment variables and image annotations. See
+// ClusterServiceVersion.GetReferencedImages.
+func (b *Bundle) ReferencedImages() (map[string]struct{}, error) {
+	csv, err := b.ClusterServiceVersion()
+	if err != nil {
+		return nil, err
+	}
+	if csv == nil {
+		return map[string]struct{}{}, nil
+	}
+	return csv.GetReferencedImages()
+}
+
 func (b *Bundle) cache() error {
 	if !b.cacheStale {
 		return nil
@@ -358,7 +372,7 @@ func (b *Bundle) cache() error {
 	}
 
 	for _, o := range b.Objects {
-		if o.GroupVersionKind().Kind == CRDKind {
+		if gvk := o.GroupVersionKind(); gvk.Group == apiextensionsv1.GroupName && gvk.Kind == CRDKind {
 			// Marshal Unstructured and Decode as CustomResourceDefinition. FromUnstructured has issues
 			// converting JSON numbers to float64 for CRD minimum/maximum validation.
 			cb, err := o.MarshalJSON()
@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsinstall "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/install"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// crdConversionScheme registers the internal apiextensions types along with
+// their defaulting and conversion functions, which Scheme does not.
+var crdConversionScheme = func() *runtime.Scheme {
+	s := runtime.NewScheme()
+	apiextensionsinstall.Install(s)
+	return s
+}()
+
+// ConvertV1beta1CRD converts a v1beta1 CRD to v1, as the API server would,
+// and adjusts the result to what v1 requires: pruning of unknown fields,
+// and a schema for every version. It returns warnings that describe those
+// adjustments, since they change the behavior of the CRD on a cluster.
+func ConvertV1beta1CRD(in *apiextensionsv1beta1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, []string, error) {
+	defaulted := in.DeepCopy()
+	crdConversionScheme.Default(defaulted)
+
+	internal := &apiextensions.CustomResourceDefinition{}
+	if err := crdConversionScheme.Convert(defaulted, internal, nil); err != nil {
+		return nil, nil, err
+	}
+	out := &apiextensionsv1.CustomResourceDefinition{}
+	if err := crdConversionScheme.Convert(internal, out, nil); err != nil {
+		return nil, nil, err
+	}
+	out.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind(CRDKind))
+
+	var warnings []string
+	if out.Spec.PreserveUnknownFields {
+		out.Spec.PreserveUnknownFields = false
+		warnings = append(warnings, "spec.preserveUnknownFields is not supported in v1, unknown fields are preserved by the schemas of the versions instead")
+	}
+	for i := range out.Spec.Versions {
+		v := &out.Spec.Versions[i]
+		if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+			preserve := true
+			v.Schema = &apiextensionsv1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserve},
+			}
+			warnings = append(warnings, fmt.Sprintf("version %q has no schema, which v1 requires, so a schema that accepts any object is used", v.Name))
+		} else if in.Spec.PreserveUnknownFields == nil || *in.Spec.PreserveUnknownFields {
+			if v.Schema.OpenAPIV3Schema.XPreserveUnknownFields == nil {
+				preserve := true
+				v.Schema.OpenAPIV3Schema.XPreserveUnknownFields = &preserve
+			}
+		}
+	}
+	return out, warnings, nil
+}
+
+// convertV1beta1CRDObject converts obj, a v1beta1 CRD, to a v1 CRD object.
+func convertV1beta1CRDObject(obj *unstructured.Unstructured) (*unstructured.Unstructured, []string, error) {
+	// Marshal Unstructured and Decode as CustomResourceDefinition. FromUnstructured has issues
+	// converting JSON numbers to float64 for CRD minimum/maximum validation.
+	cb, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+	in := &apiextensionsv1beta1.CustomResourceDefinition{}
+	if _, _, err := serializer.NewCodecFactory(Scheme).UniversalDeserializer().Decode(cb, nil, in); err != nil {
+		return nil, nil, fmt.Errorf("error decoding v1beta1 CRD: %v", err)
+	}
+	crd, warnings, err := ConvertV1beta1CRD(in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := json.Marshal(crd)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &unstructured.Unstructured{}
+	if err := out.UnmarshalJSON(b); err != nil {
+		return nil, nil, err
+	}
+	// Drop the zero values that the typed CRD adds, so that only the
+	// converted content of obj is left.
+	unstructured.RemoveNestedField(out.Object, "metadata", "creationTimestamp")
+	if _, ok := obj.Object["status"]; !ok {
+		unstructured.RemoveNestedField(out.Object, "status")
+	}
+	return out, warnings, nil
+}
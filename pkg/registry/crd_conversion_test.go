@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvertV1beta1CRD(t *testing.T) {
+	yes, no := true, false
+	minimum := float64(1)
+	in := &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "foos.test.io"},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   "test.io",
+			Version: "v1alpha1",
+			Names:   apiextensionsv1beta1.CustomResourceDefinitionNames{Plural: "foos", Kind: "Foo"},
+			Scope:   apiextensionsv1beta1.NamespaceScoped,
+			Validation: &apiextensionsv1beta1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+						"size": {Type: "integer", Minimum: &minimum},
+					},
+				},
+			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{}},
+		},
+	}
+
+	out, warnings, err := ConvertV1beta1CRD(in)
+	require.NoError(t, err)
+	require.Equal(t, "apiextensions.k8s.io/v1", out.APIVersion)
+	require.Equal(t, CRDKind, out.Kind)
+	require.False(t, out.Spec.PreserveUnknownFields)
+	require.Len(t, warnings, 1)
+
+	// The top-level version, schema and subresources move to the versions.
+	require.Len(t, out.Spec.Versions, 1)
+	v := out.Spec.Versions[0]
+	require.Equal(t, "v1alpha1", v.Name)
+	require.True(t, v.Served)
+	require.True(t, v.Storage)
+	require.NotNil(t, v.Subresources)
+	require.NotNil(t, v.Schema)
+	require.Equal(t, &minimum, v.Schema.OpenAPIV3Schema.Properties["size"].Minimum)
+	// Unknown fields were preserved by default in v1beta1, and still are.
+	require.Equal(t, &yes, v.Schema.OpenAPIV3Schema.XPreserveUnknownFields)
+
+	t.Run("NoSchema", func(t *testing.T) {
+		in := in.DeepCopy()
+		in.Spec.Validation = nil
+		in.Spec.PreserveUnknownFields = &no
+
+		out, warnings, err := ConvertV1beta1CRD(in)
+		require.NoError(t, err)
+		require.Equal(t, []string{`version "v1alpha1" has no schema, which v1 requires, so a schema that accepts any object is used`}, warnings)
+		require.Equal(t, &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &yes},
+		}, out.Spec.Versions[0].Schema)
+	})
+}
+
+func TestBundleParserConvertsV1beta1CRDs(t *testing.T) {
+	bundleFS := fstest.MapFS{
+		"manifests/csv.yaml": &fstest.MapFile{Data: []byte(`
+apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: foo.v1.0.0
+spec:
+  version: 1.0.0
+  customresourcedefinitions:
+    owned:
+    - name: foos.test.io
+      version: v1
+      kind: Foo
+    - name: bars.test.io
+      version: v1beta1
+      kind: Bar
+`)},
+		"manifests/foos.crd.yaml": &fstest.MapFile{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: foos.test.io
+spec:
+  group: test.io
+  scope: Namespaced
+  names:
+    plural: foos
+    kind: Foo
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+`)},
+		"manifests/bars.crd.yaml": &fstest.MapFile{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: bars.test.io
+spec:
+  group: test.io
+  scope: Namespaced
+  names:
+    plural: bars
+    kind: Bar
+  versions:
+  - name: v1beta1
+    served: true
+    storage: false
+  - name: v1
+    served: true
+    storage: true
+  validation:
+    openAPIV3Schema:
+      type: object
+`)},
+		"metadata/annotations.yaml": &fstest.MapFile{Data: []byte(`
+annotations:
+  operators.operatorframework.io.bundle.package.v1: "foo"
+  operators.operatorframework.io.bundle.channels.v1: "stable"
+`)},
+	}
+
+	logger, hook := test.NewNullLogger()
+	bundle, err := newBundleParser(logrus.NewEntry(logger)).Parse(bundleFS)
+	require.NoError(t, err)
+
+	for _, obj := range bundle.Objects {
+		if obj.GetKind() == CRDKind {
+			require.Equal(t, "apiextensions.k8s.io/v1", obj.GetAPIVersion(), obj.GetName())
+		}
+	}
+	require.NotEmpty(t, hook.AllEntries())
+	require.Equal(t, `converted v1beta1 CRD "bars.test.io" in bars.crd.yaml to v1`, hook.AllEntries()[0].Message)
+
+	provided, err := bundle.ProvidedAPIs()
+	require.NoError(t, err)
+	require.Equal(t, map[APIKey]struct{}{
+		{Group: "test.io", Version: "v1", Kind: "Foo", Plural: "foos"}:      {},
+		{Group: "test.io", Version: "v1beta1", Kind: "Bar", Plural: "bars"}: {},
+		{Group: "test.io", Version: "v1", Kind: "Bar", Plural: "bars"}:      {},
+	}, provided)
+}
+
+func TestBundleParserKeepsV1beta1CRDKindsOfOtherGroups(t *testing.T) {
+	bundleFS := fstest.MapFS{
+		"manifests/csv.yaml": &fstest.MapFile{Data: []byte(`
+apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: foo.v1.0.0
+spec:
+  version: 1.0.0
+`)},
+		"manifests/other.yaml": &fstest.MapFile{Data: []byte(`
+apiVersion: example.com/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: other
+spec:
+  foo: bar
+`)},
+		"metadata/annotations.yaml": &fstest.MapFile{Data: []byte(`
+annotations:
+  operators.operatorframework.io.bundle.package.v1: "foo"
+  operators.operatorframework.io.bundle.channels.v1: "stable"
+`)},
+	}
+
+	logger, hook := test.NewNullLogger()
+	bundle, err := newBundleParser(logrus.NewEntry(logger)).Parse(bundleFS)
+	require.NoError(t, err)
+
+	var found bool
+	for _, obj := range bundle.Objects {
+		if obj.GetName() == "other" {
+			found = true
+			require.Equal(t, "example.com/v1beta1", obj.GetAPIVersion())
+			require.Equal(t, map[string]interface{}{"foo": "bar"}, obj.Object["spec"])
+		}
+	}
+	require.True(t, found)
+	for _, e := range hook.AllEntries() {
+		require.NotContains(t, e.Message, "converted")
+	}
+}
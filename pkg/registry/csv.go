@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	prettyunmarshaler "github.com/operator-framework/operator-registry/pkg/prettyunmarshaler"
 
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -63,8 +65,16 @@ const (
 
 	// The yaml attribute that specifies the optional substitutesfor of the ClusterServiceVersion
 	substitutesForAnnotationKey = "olm.substitutesFor"
+
+	// The prefix of the names of the environment variables through which
+	// operators are told the images of their operands
+	relatedImageEnvPrefix = "RELATED_IMAGE_"
 )
 
+// imageAnnotationKeys are the well-known ClusterServiceVersion annotations
+// whose values are image references.
+var imageAnnotationKeys = []string{"containerImage"}
+
 // ClusterServiceVersion is a structured representation of cluster service
 // version object(s) specified inside the 'clusterServiceVersions' section of
 // an operator manifest.
@@ -320,6 +330,58 @@ func (csv *ClusterServiceVersion) GetRelatedImages() (imageSet map[string]struct
 // GetOperatorImages returns a list of any images used to run the operator.
 // Currently this pulls any images in the pod specs of operator deployments.
 func (csv *ClusterServiceVersion) GetOperatorImages() (map[string]struct{}, error) {
+	podSpecs, err := csv.getDeploymentPodSpecs()
+	if err != nil || podSpecs == nil {
+		return nil, err
+	}
+
+	images := map[string]struct{}{}
+	for _, spec := range podSpecs {
+		for _, c := range spec.Containers {
+			images[c.Image] = struct{}{}
+		}
+		for _, c := range spec.InitContainers {
+			images[c.Image] = struct{}{}
+		}
+	}
+
+	return images, nil
+}
+
+// GetReferencedImages returns the images that the operator references without
+// running them: the values of the RELATED_IMAGE_* environment variables of the
+// containers and init containers of its deployments, and the images of the
+// well-known image annotations of the ClusterServiceVersion.
+func (csv *ClusterServiceVersion) GetReferencedImages() (map[string]struct{}, error) {
+	podSpecs, err := csv.getDeploymentPodSpecs()
+	if err != nil {
+		return nil, err
+	}
+
+	images := map[string]struct{}{}
+	for _, spec := range podSpecs {
+		for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for _, c := range containers {
+				for _, env := range c.Env {
+					if strings.HasPrefix(env.Name, relatedImageEnvPrefix) && env.Value != "" {
+						images[env.Value] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	for _, key := range imageAnnotationKeys {
+		if img := strings.TrimSpace(csv.GetAnnotations()[key]); img != "" {
+			images[img] = struct{}{}
+		}
+	}
+
+	return images, nil
+}
+
+// getDeploymentPodSpecs returns the pod specs of the operator deployments, or
+// nil if the install strategy is not the deployment strategy.
+func (csv *ClusterServiceVersion) getDeploymentPodSpecs() ([]corev1.PodSpec, error) {
 	type dep struct {
 		Name string
 		Spec v1.DeploymentSpec
@@ -345,17 +407,11 @@ func (csv *ClusterServiceVersion) GetOperatorImages() (map[string]struct{}, erro
 		return nil, nil
 	}
 
-	images := map[string]struct{}{}
+	podSpecs := make([]corev1.PodSpec, 0, len(spec.Install.Spec.Deployments))
 	for _, d := range spec.Install.Spec.Deployments {
-		for _, c := range d.Spec.Template.Spec.Containers {
-			images[c.Image] = struct{}{}
-		}
-		for _, c := range d.Spec.Template.Spec.InitContainers {
-			images[c.Image] = struct{}{}
-		}
+		podSpecs = append(podSpecs, d.Spec.Template.Spec)
 	}
-
-	return images, nil
+	return podSpecs, nil
 }
 
 type Icon struct {
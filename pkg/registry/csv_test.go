@@ -760,6 +760,77 @@ func TestClusterServiceVersion_GetOperatorImages(t *testing.T) {
 	}
 }
 
+func TestClusterServiceVersion_GetReferencedImages(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		spec        json.RawMessage
+		want        map[string]struct{}
+		wantErr     bool
+	}{
+		{
+			name: "bad strategy",
+			spec: json.RawMessage(`{"install": {"strategy": "nope"}}`),
+			want: map[string]struct{}{},
+		},
+		{
+			name:        "annotation only",
+			annotations: map[string]string{"containerImage": "quay.io/example/operator:v1"},
+			spec:        json.RawMessage(`{}`),
+			want:        map[string]struct{}{"quay.io/example/operator:v1": {}},
+		},
+		{
+			name:        "env vars and annotation",
+			annotations: map[string]string{"containerImage": "quay.io/example/operator:v1"},
+			spec: json.RawMessage(`
+				{"install": {"strategy": "deployment", "spec": {"deployments":[{
+					"name":"example-operator",
+					"spec":{"template":{"spec":{
+						"initContainers":[{
+							"name":"init",
+							"image":"quay.io/example/init:v1",
+							"env":[{"name":"RELATED_IMAGE_MIGRATION","value":"quay.io/example/migration:v1"}]
+						}],
+						"containers":[{
+							"name":"operator",
+							"image":"quay.io/example/operator:v1",
+							"env":[
+								{"name":"RELATED_IMAGE_OPERAND","value":"quay.io/example/operand:v1"},
+								{"name":"RELATED_IMAGE_EMPTY","value":""},
+								{"name":"WATCH_NAMESPACE","value":"quay.io/example/not-an-image:v1"}
+							]
+						}]
+					}}}
+				}]}}}`),
+			want: map[string]struct{}{
+				"quay.io/example/operator:v1":  {},
+				"quay.io/example/migration:v1": {},
+				"quay.io/example/operand:v1":   {},
+			},
+		},
+		{
+			name:    "invalid spec",
+			spec:    json.RawMessage(`{"install": {"strategy": "deployment", "spec": {"deployments": "nope"}}}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv := &ClusterServiceVersion{
+				ObjectMeta: v1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       tt.spec,
+			}
+			got, err := csv.GetReferencedImages()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestLoadingCsvFromBundleDirectory(t *testing.T) {
 	tests := []struct {
 		dir       string
@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// BundleFilter selects bundles by their package, channel, and properties. A
+// bundle matches if it matches every field that is set.
+type BundleFilter struct {
+	// Packages, if set, are the names of the only packages whose bundles
+	// match.
+	Packages []string
+	// Channels, if set, are the names of the only channels whose bundles
+	// match.
+	Channels []string
+	// Properties, if set, match the bundles that have a property that
+	// matches each of them.
+	Properties []PropertySelector
+}
+
+// PropertySelector matches the properties of a type. If Value is set, it
+// only matches the properties whose value is equal to it, or, if it is an
+// object, whose value is an object that has all of its fields.
+type PropertySelector struct {
+	Type  string
+	Value json.RawMessage
+}
+
+// BundleFilterFromRequest returns the filter of the filter fields of req, or
+// nil if none are set.
+func BundleFilterFromRequest(req *api.ListBundlesRequest) (*BundleFilter, error) {
+	if len(req.GetPackages()) == 0 && len(req.GetChannels()) == 0 && len(req.GetProperties()) == 0 {
+		return nil, nil
+	}
+	filter := &BundleFilter{Packages: req.GetPackages(), Channels: req.GetChannels()}
+	for i, s := range req.GetProperties() {
+		if s.GetType() == "" {
+			return nil, fmt.Errorf("property selector %d: type must be set", i)
+		}
+		selector := PropertySelector{Type: s.GetType()}
+		if s.GetValue() != "" {
+			if !json.Valid([]byte(s.GetValue())) {
+				return nil, fmt.Errorf("property selector %d: value %q is not valid JSON", i, s.GetValue())
+			}
+			selector.Value = json.RawMessage(s.GetValue())
+		}
+		filter.Properties = append(filter.Properties, selector)
+	}
+	return filter, nil
+}
+
+// MatchesChannel reports whether the bundles in the channel of the package
+// may match f, without regard to their properties.
+func (f BundleFilter) MatchesChannel(pkgName, channelName string) bool {
+	return (len(f.Packages) == 0 || slices.Contains(f.Packages, pkgName)) &&
+		(len(f.Channels) == 0 || slices.Contains(f.Channels, channelName))
+}
+
+// MatchesProperties reports whether properties has a property that matches
+// each of the property selectors of f.
+func (f BundleFilter) MatchesProperties(properties []*api.Property) bool {
+	for _, s := range f.Properties {
+		if !slices.ContainsFunc(properties, s.Matches) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether b matches f.
+func (f BundleFilter) Matches(b *api.Bundle) bool {
+	return f.MatchesChannel(b.GetPackageName(), b.GetChannelName()) && f.MatchesProperties(b.GetProperties())
+}
+
+// Matches reports whether p matches s.
+func (s PropertySelector) Matches(p *api.Property) bool {
+	if p.GetType() != s.Type {
+		return false
+	}
+	if len(s.Value) == 0 {
+		return true
+	}
+	var want, got any
+	if err := json.Unmarshal(s.Value, &want); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(p.GetValue()), &got); err != nil {
+		return false
+	}
+	return containsJSON(got, want)
+}
+
+// containsJSON reports whether got is equal to want or, if want is an
+// object, whether got is an object that contains each of its fields.
+func containsJSON(got, want any) bool {
+	wantObj, ok := want.(map[string]any)
+	if !ok {
+		return reflect.DeepEqual(got, want)
+	}
+	gotObj, ok := got.(map[string]any)
+	if !ok {
+		return false
+	}
+	for k, v := range wantObj {
+		gv, ok := gotObj[k]
+		if !ok || !containsJSON(gv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilteringBundleSender sends only the bundles that match Filter to
+// BundleSender.
+type FilteringBundleSender struct {
+	BundleSender
+	Filter BundleFilter
+}
+
+func (s FilteringBundleSender) Send(b *api.Bundle) error {
+	if !s.Filter.Matches(b) {
+		return nil
+	}
+	return s.BundleSender.Send(b)
+}
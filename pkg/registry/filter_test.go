@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+func TestPropertySelectorMatches(t *testing.T) {
+	gvk := &api.Property{Type: "olm.gvk", Value: `{"group":"etcd.database.coreos.com","kind":"EtcdCluster","version":"v1beta2"}`}
+	for _, tt := range []struct {
+		name     string
+		selector PropertySelector
+		property *api.Property
+		expected bool
+	}{
+		{name: "Type", selector: PropertySelector{Type: "olm.gvk"}, property: gvk, expected: true},
+		{name: "OtherType", selector: PropertySelector{Type: "olm.package"}, property: gvk, expected: false},
+		{name: "Fields", selector: PropertySelector{Type: "olm.gvk", Value: json.RawMessage(`{"kind":"EtcdCluster"}`)}, property: gvk, expected: true},
+		{name: "OtherField", selector: PropertySelector{Type: "olm.gvk", Value: json.RawMessage(`{"kind":"EtcdBackup"}`)}, property: gvk, expected: false},
+		{name: "MissingField", selector: PropertySelector{Type: "olm.gvk", Value: json.RawMessage(`{"plural":"etcdclusters"}`)}, property: gvk, expected: false},
+		{name: "Scalar", selector: PropertySelector{Type: "olm.label", Value: json.RawMessage(`"stable"`)}, property: &api.Property{Type: "olm.label", Value: `"stable"`}, expected: true},
+		{name: "OtherScalar", selector: PropertySelector{Type: "olm.label", Value: json.RawMessage(`"stable"`)}, property: &api.Property{Type: "olm.label", Value: `"beta"`}, expected: false},
+		{name: "Array", selector: PropertySelector{Type: "olm.list", Value: json.RawMessage(`["a"]`)}, property: &api.Property{Type: "olm.list", Value: `["a","b"]`}, expected: false},
+		{name: "InvalidValue", selector: PropertySelector{Type: "olm.gvk", Value: json.RawMessage(`{}`)}, property: &api.Property{Type: "olm.gvk", Value: `{`}, expected: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.selector.Matches(tt.property))
+		})
+	}
+}
+
+func TestBundleFilterFromRequest(t *testing.T) {
+	filter, err := BundleFilterFromRequest(&api.ListBundlesRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Nil(t, filter)
+
+	filter, err = BundleFilterFromRequest(&api.ListBundlesRequest{
+		Packages:   []string{"etcd"},
+		Properties: []*api.PropertySelector{{Type: "olm.gvk", Value: `{"kind":"EtcdCluster"}`}, {Type: "olm.package"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &BundleFilter{
+		Packages: []string{"etcd"},
+		Properties: []PropertySelector{
+			{Type: "olm.gvk", Value: json.RawMessage(`{"kind":"EtcdCluster"}`)},
+			{Type: "olm.package"},
+		},
+	}, filter)
+
+	_, err = BundleFilterFromRequest(&api.ListBundlesRequest{Properties: []*api.PropertySelector{{Value: `{}`}}})
+	require.ErrorContains(t, err, "type must be set")
+	_, err = BundleFilterFromRequest(&api.ListBundlesRequest{Properties: []*api.PropertySelector{{Type: "olm.gvk", Value: `{`}}})
+	require.ErrorContains(t, err, "is not valid JSON")
+}
+
+type sliceBundleSender []*api.Bundle
+
+func (s *sliceBundleSender) Send(b *api.Bundle) error {
+	*s = append(*s, b)
+	return nil
+}
+
+func TestFilteringBundleSender(t *testing.T) {
+	bundles := []*api.Bundle{
+		{CsvName: "a.v1", PackageName: "a", ChannelName: "stable", Properties: []*api.Property{{Type: "olm.gvk", Value: `{"kind":"A"}`}}},
+		{CsvName: "a.v1", PackageName: "a", ChannelName: "beta", Properties: []*api.Property{{Type: "olm.gvk", Value: `{"kind":"A"}`}}},
+		{CsvName: "a.v0", PackageName: "a", ChannelName: "stable"},
+		{CsvName: "b.v1", PackageName: "b", ChannelName: "stable", Properties: []*api.Property{{Type: "olm.gvk", Value: `{"kind":"A"}`}}},
+	}
+	var sent sliceBundleSender
+	sender := FilteringBundleSender{BundleSender: &sent, Filter: BundleFilter{
+		Packages:   []string{"a"},
+		Channels:   []string{"stable"},
+		Properties: []PropertySelector{{Type: "olm.gvk"}},
+	}}
+	for _, b := range bundles {
+		require.NoError(t, sender.Send(b))
+	}
+	require.Equal(t, sliceBundleSender{bundles[0]}, sent)
+}
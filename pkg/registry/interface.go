@@ -59,6 +59,88 @@ type GRPCQuery interface {
 	GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error)
 }
 
+// ChecksumQuery is implemented by stores that can report a content digest
+// for each package they serve. See declcfg.ComputeChecksums.
+type ChecksumQuery interface {
+	// Get the content checksum of each package in the index, keyed by package name
+	GetPackageChecksums(ctx context.Context) (map[string]string, error)
+}
+
+// DocumentationQuery is implemented by stores that can serve the
+// documentation attached to a package.
+type DocumentationQuery interface {
+	// Get the documentation of a package, or a codes.NotFound error if the package does not exist or has none
+	GetPackageDocumentation(ctx context.Context, pkgName string) (*api.PackageDocumentation, error)
+}
+
+// PackageMetadataQuery is implemented by stores that can serve the metadata
+// of a package and of its channels without the bundles of the package.
+type PackageMetadataQuery interface {
+	// Get the metadata of a package, its channel heads, and its deprecations,
+	// or a codes.NotFound error if the package does not exist
+	GetPackageMetadata(ctx context.Context, pkgName string) (*api.PackageMetadata, error)
+}
+
+type BundleMetadataSender interface {
+	Send(*api.BundleMetadata) error
+}
+
+// BundleMetadataQuery is implemented by stores that can serve bundles
+// without their manifests.
+type BundleMetadataQuery interface {
+	// Get the metadata of a bundle by its package name, channel name and csv name from the index
+	GetBundleMetadata(ctx context.Context, pkgName, channelName, csvName string) (*api.BundleMetadata, error)
+
+	// Sends the metadata of all available bundles in the index
+	SendBundleMetadata(ctx context.Context, stream BundleMetadataSender) error
+}
+
+// DeprecationQuery is implemented by stores that can serve the deprecations
+// of packages, and of their channels and bundles.
+type DeprecationQuery interface {
+	// List the deprecations of a package, or of all packages if pkgName is empty
+	ListDeprecations(ctx context.Context, pkgName string) ([]*api.DeprecationEntry, error)
+}
+
+// BundleCursor identifies a bundle in a channel of a package. Pages of
+// bundles are ordered by package name, then channel name, then bundle name.
+type BundleCursor struct {
+	PackageName string
+	ChannelName string
+	CsvName     string
+}
+
+// Less reports whether c orders before other.
+func (c BundleCursor) Less(other BundleCursor) bool {
+	if c.PackageName != other.PackageName {
+		return c.PackageName < other.PackageName
+	}
+	if c.ChannelName != other.ChannelName {
+		return c.ChannelName < other.ChannelName
+	}
+	return c.CsvName < other.CsvName
+}
+
+// PagedBundleQuery is implemented by stores that can send the bundles of the
+// index one page at a time, without reading the bundles of other pages.
+type PagedBundleQuery interface {
+	// Sends at most limit bundles, in cursor order, that follow after, or
+	// that start the index if after is nil. Returns the cursor of the last
+	// bundle sent if more bundles follow it, or nil.
+	SendBundlesPage(ctx context.Context, stream BundleSender, after *BundleCursor, limit int) (*BundleCursor, error)
+}
+
+// FilteredBundleQuery is implemented by stores that can send only the bundles
+// that match a filter, without reading the bundles of the packages and
+// channels that it excludes.
+type FilteredBundleQuery interface {
+	// Sends the bundles that match filter, like SendBundlesPage does if limit
+	// is positive, in which case bundles that do not match the properties of
+	// filter count towards limit. If limit is zero, all of the bundles that
+	// match are sent, and nil is returned.
+	SendFilteredBundles(ctx context.Context, stream BundleSender, filter BundleFilter, after *BundleCursor, limit int) (*BundleCursor, error)
+}
+
 type Query interface {
 	GRPCQuery
 
@@ -95,7 +177,8 @@ type Query interface {
 
 // GraphLoader generates a graph
 // GraphLoader supports multiple different loading schemes
-// GraphLoader from SQL, GraphLoader from old format (filesystem), GraphLoader from SQL + input bundles
+// GraphLoader from SQL, GraphLoader from old format (filesystem), GraphLoader from SQL + input bundles,
+// GraphLoader from file-based catalogs (model)
 type GraphLoader interface {
 	Generate(packageName string) (*Package, error)
 }
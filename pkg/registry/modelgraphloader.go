@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/model"
+)
+
+// ModelGraphLoader generates graphs from a model, such as one loaded from a
+// file-based catalog, so that graphs can be generated without converting the
+// catalog to a sqlite database.
+type ModelGraphLoader struct {
+	Model model.Model
+}
+
+var _ GraphLoader = &ModelGraphLoader{}
+
+// NewModelGraphLoader returns a GraphLoader that generates graphs from m.
+func NewModelGraphLoader(m model.Model) *ModelGraphLoader {
+	return &ModelGraphLoader{Model: m}
+}
+
+// NewDeclarativeConfigGraphLoader returns a GraphLoader that generates graphs
+// from the file-based catalog cfg.
+func NewDeclarativeConfigGraphLoader(cfg declcfg.DeclarativeConfig) (*ModelGraphLoader, error) {
+	m, err := declcfg.ConvertToModel(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewModelGraphLoader(m), nil
+}
+
+// Generate returns the graph of the package named packageName. As with the
+// graphs of sqlite databases, the nodes of a channel are its bundles, and
+// each bundle has an edge to the bundles that it replaces or skips. Bundles
+// that are replaced or skipped but that are not in the channel are
+// identified by name only.
+func (g *ModelGraphLoader) Generate(packageName string) (*Package, error) {
+	graph := &Package{
+		Name:     packageName,
+		Channels: make(map[string]Channel, 0),
+	}
+
+	pkg, ok := g.Model[packageName]
+	if !ok {
+		return graph, ErrPackageNotInDatabase
+	}
+	if pkg.DefaultChannel != nil {
+		graph.DefaultChannel = pkg.DefaultChannel.Name
+	}
+
+	for _, ch := range pkg.Channels {
+		head, err := ch.Head()
+		if err != nil {
+			return graph, fmt.Errorf("no channel head found for %s: %v", ch.Name, err)
+		}
+
+		nodes := make(map[BundleKey]map[BundleKey]struct{}, len(ch.Bundles))
+		for _, b := range ch.Bundles {
+			edges := map[BundleKey]struct{}{}
+			if b.Replaces != "" {
+				edges[bundleKeyInChannel(ch, b.Replaces)] = struct{}{}
+			}
+			for _, skip := range b.Skips {
+				edges[bundleKeyInChannel(ch, skip)] = struct{}{}
+			}
+			nodes[modelBundleKey(b)] = edges
+		}
+
+		graph.Channels[ch.Name] = Channel{
+			Head:  modelBundleKey(head),
+			Nodes: nodes,
+		}
+	}
+
+	return graph, nil
+}
+
+func modelBundleKey(b *model.Bundle) BundleKey {
+	return BundleKey{
+		BundlePath: b.Image,
+		Version:    b.Version.String(),
+		CsvName:    b.Name,
+	}
+}
+
+// bundleKeyInChannel returns the key of the bundle named name in ch, or a key
+// with only its name if it is not in ch.
+func bundleKeyInChannel(ch *model.Channel, name string) BundleKey {
+	if b, ok := ch.Bundles[name]; ok {
+		return modelBundleKey(b)
+	}
+	return BundleKey{CsvName: name}
+}
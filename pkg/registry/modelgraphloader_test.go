@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestModelGraphLoader(t *testing.T) {
+	bundle := func(name, version string) declcfg.Bundle {
+		return declcfg.Bundle{
+			Schema:     declcfg.SchemaBundle,
+			Name:       name,
+			Package:    "etcd",
+			Image:      "quay.io/etcd/" + name,
+			Properties: []property.Property{property.MustBuildPackage("etcd", version)},
+		}
+	}
+	cfg := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "etcd", DefaultChannel: "alpha"}},
+		Channels: []declcfg.Channel{
+			{Schema: declcfg.SchemaChannel, Package: "etcd", Name: "alpha", Entries: []declcfg.ChannelEntry{
+				{Name: "etcdoperator.v0.6.1"},
+				{Name: "etcdoperator.v0.9.0", Replaces: "etcdoperator.v0.6.1"},
+				{Name: "etcdoperator.v0.9.2", Replaces: "etcdoperator.v0.9.0", Skips: []string{"etcdoperator.v0.9.1"}},
+			}},
+			{Schema: declcfg.SchemaChannel, Package: "etcd", Name: "beta", Entries: []declcfg.ChannelEntry{
+				{Name: "etcdoperator.v0.9.0"},
+			}},
+		},
+		Bundles: []declcfg.Bundle{
+			bundle("etcdoperator.v0.6.1", "0.6.1"),
+			bundle("etcdoperator.v0.9.0", "0.9.0"),
+			bundle("etcdoperator.v0.9.2", "0.9.2"),
+		},
+	}
+	v061 := BundleKey{BundlePath: "quay.io/etcd/etcdoperator.v0.6.1", Version: "0.6.1", CsvName: "etcdoperator.v0.6.1"}
+	v090 := BundleKey{BundlePath: "quay.io/etcd/etcdoperator.v0.9.0", Version: "0.9.0", CsvName: "etcdoperator.v0.9.0"}
+	v092 := BundleKey{BundlePath: "quay.io/etcd/etcdoperator.v0.9.2", Version: "0.9.2", CsvName: "etcdoperator.v0.9.2"}
+	expected := &Package{
+		Name:           "etcd",
+		DefaultChannel: "alpha",
+		Channels: map[string]Channel{
+			"alpha": {
+				Head: v092,
+				Nodes: map[BundleKey]map[BundleKey]struct{}{
+					v061: {},
+					v090: {v061: {}},
+					v092: {v090: {}, {CsvName: "etcdoperator.v0.9.1"}: {}},
+				},
+			},
+			"beta": {
+				Head:  v090,
+				Nodes: map[BundleKey]map[BundleKey]struct{}{v090: {}},
+			},
+		},
+	}
+
+	loader, err := NewDeclarativeConfigGraphLoader(cfg)
+	require.NoError(t, err)
+
+	actual, err := loader.Generate("etcd")
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	_, err = loader.Generate("not-a-real-package")
+	require.Equal(t, ErrPackageNotInDatabase, err)
+}
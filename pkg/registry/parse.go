@@ -8,6 +8,7 @@ import (
 
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/sirupsen/logrus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -86,6 +87,20 @@ func (b *bundleParser) addManifests(manifests fs.FS, bundle *Bundle) error {
 			csvFound = true
 		}
 
+		// v1beta1 CRDs are no longer served, so convert them to v1 for the
+		// bundle to be installable on current clusters.
+		if gvk := obj.GroupVersionKind(); gvk.Group == apiextensionsv1.GroupName && gvk.Kind == CRDKind && gvk.Version == v1beta1CRDVersion {
+			converted, warnings, err := convertV1beta1CRDObject(obj)
+			if err != nil {
+				return fmt.Errorf("error converting v1beta1 CRD %q in %s to v1: %v", obj.GetName(), name, err)
+			}
+			b.log.Warnf("converted v1beta1 CRD %q in %s to v1", obj.GetName(), name)
+			for _, w := range warnings {
+				b.log.Warnf("CRD %q: %s", obj.GetName(), w)
+			}
+			obj = converted
+		}
+
 		if obj.Object != nil {
 			bundle.Add(obj)
 		}
@@ -230,6 +245,14 @@ func (b *bundleParser) derivedProperties(bundle *Bundle) ([]Property, error) {
 		derived = append(derived, Property{Type: GVKType, Value: value})
 	}
 
+	if archs := ArchitecturesFromLabels(csv.GetLabels()); archs != nil {
+		value, err := json.Marshal(archs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal architectures property: %s", err)
+		}
+		derived = append(derived, Property{Type: ArchitecturesType, Value: value})
+	}
+
 	return propertySet(derived), nil
 }
 
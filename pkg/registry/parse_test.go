@@ -551,6 +551,33 @@ func TestDerivedProperties(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ArchitecturesFromCSVLabels",
+			args: args{
+				csv: &ClusterServiceVersion{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							ArchLabelPrefix + "s390x": "supported",
+							ArchLabelPrefix + "arm64": "supported",
+							ArchLabelPrefix + "ppc64": "unsupported",
+							"app":                     "foo",
+						},
+					},
+					Spec: json.RawMessage(`{}`),
+				},
+			},
+			expected: expected{
+				properties: []Property{
+					{
+						Type: ArchitecturesType,
+						Value: mustMarshal(t, ArchitecturesProperty{
+							Architectures:    []string{"arm64", "s390x"},
+							OperatingSystems: []string{"linux"},
+						}),
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			parser := newBundleParser(logrus.NewEntry(logrus.StandardLogger()))
@@ -0,0 +1,420 @@
+// Package querytest is a conformance test suite for implementations of
+// registry.GRPCQuery, the queries that back the registry gRPC API.
+//
+// Storage backends, including those outside of this repository, can run
+// the suite to verify that they answer each query like the backends that
+// the registry server ships with:
+//
+//	func TestConformance(t *testing.T) {
+//		querytest.Run(t, func(t *testing.T, fbc fs.FS) registry.GRPCQuery {
+//			// Load the file-based catalog in fbc into the backend.
+//			return newBackend(t, fbc)
+//		})
+//	}
+//
+// The suite only asserts the behaviors that clients depend on. It does not
+// assert the order of results that the interface leaves unspecified.
+package querytest
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// NewQuerierFunc returns a querier that serves the file-based catalog in
+// fbc. It fails t if the catalog cannot be loaded.
+type NewQuerierFunc func(t *testing.T, fbc fs.FS) registry.GRPCQuery
+
+// catalog is the file-based catalog that the queriers under test serve.
+//
+// Package foo has two channels. Its default channel, stable, upgrades from
+// foo.v1.0.0 straight to foo.v2.0.0, which skips foo.v1.1.0 of the beta
+// channel. Package bar has a single bundle, which provides the API that
+// foo.v2.0.0 requires.
+const catalog = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v1.0.0
+- name: foo.v2.0.0
+  replaces: foo.v1.0.0
+  skips:
+  - foo.v1.1.0
+---
+schema: olm.channel
+package: foo
+name: beta
+entries:
+- name: foo.v1.0.0
+- name: foo.v1.1.0
+  replaces: foo.v1.0.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v1.0.0
+image: example.com/foo-bundle:v1.0.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 1.0.0
+- type: olm.gvk
+  value:
+    group: example.com
+    version: v1
+    kind: Foo
+---
+schema: olm.bundle
+package: foo
+name: foo.v1.1.0
+image: example.com/foo-bundle:v1.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 1.1.0
+- type: olm.gvk
+  value:
+    group: example.com
+    version: v1
+    kind: Foo
+---
+schema: olm.bundle
+package: foo
+name: foo.v2.0.0
+image: example.com/foo-bundle:v2.0.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 2.0.0
+- type: olm.gvk
+  value:
+    group: example.com
+    version: v1
+    kind: Foo
+- type: olm.gvk
+  value:
+    group: example.com
+    version: v2
+    kind: Foo
+- type: olm.gvk.required
+  value:
+    group: example.com
+    version: v1
+    kind: Bar
+---
+schema: olm.package
+name: bar
+defaultChannel: alpha
+---
+schema: olm.channel
+package: bar
+name: alpha
+entries:
+- name: bar.v0.1.0
+---
+schema: olm.bundle
+package: bar
+name: bar.v0.1.0
+image: example.com/bar-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: bar
+    version: 0.1.0
+- type: olm.gvk
+  value:
+    group: example.com
+    version: v1
+    kind: Bar
+`
+
+// Catalog returns the file-based catalog that Run loads into the queriers
+// under test.
+func Catalog() fs.FS {
+	return fstest.MapFS{
+		"catalog.yaml": &fstest.MapFile{Data: []byte(catalog)},
+	}
+}
+
+// Run runs the conformance suite against the querier that newQuerier
+// returns for Catalog(). Each query is tested in a subtest of t.
+func Run(t *testing.T, newQuerier NewQuerierFunc) {
+	q := newQuerier(t, Catalog())
+	for _, tt := range []struct {
+		name string
+		test func(*testing.T, registry.GRPCQuery)
+	}{
+		{"ListPackages", testListPackages},
+		{"GetPackage", testGetPackage},
+		{"GetBundle", testGetBundle},
+		{"GetBundleForChannel", testGetBundleForChannel},
+		{"ListBundles", testListBundles},
+		{"SendBundles", testSendBundles},
+		{"SendFilteredBundles", testSendFilteredBundles},
+		{"GetChannelEntriesThatReplace", testGetChannelEntriesThatReplace},
+		{"GetBundleThatReplaces", testGetBundleThatReplaces},
+		{"GetChannelEntriesThatProvide", testGetChannelEntriesThatProvide},
+		{"GetLatestChannelEntriesThatProvide", testGetLatestChannelEntriesThatProvide},
+		{"GetBundleThatProvides", testGetBundleThatProvides},
+	} {
+		t.Run(tt.name, func(t *testing.T) { tt.test(t, q) })
+	}
+}
+
+// entryKey identifies a bundle in a channel of a package.
+type entryKey struct {
+	Package, Channel, Bundle string
+}
+
+func entryKeys(entries []*registry.ChannelEntry) []entryKey {
+	seen := map[entryKey]struct{}{}
+	var keys []entryKey
+	for _, e := range entries {
+		k := entryKey{e.PackageName, e.ChannelName, e.BundleName}
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func bundleKeys(bundles []*api.Bundle) []entryKey {
+	var keys []entryKey
+	for _, b := range bundles {
+		keys = append(keys, entryKey{b.PackageName, b.ChannelName, b.CsvName})
+	}
+	return keys
+}
+
+func gvk(group, version, kind string) *api.GroupVersionKind {
+	return &api.GroupVersionKind{Group: group, Version: version, Kind: kind}
+}
+
+// gvks returns apis without their plurals, which file-based catalogs do
+// not declare.
+func gvks(apis []*api.GroupVersionKind) []*api.GroupVersionKind {
+	var out []*api.GroupVersionKind
+	for _, a := range apis {
+		out = append(out, gvk(a.Group, a.Version, a.Kind))
+	}
+	return out
+}
+
+var allEntries = []entryKey{
+	{"foo", "stable", "foo.v1.0.0"},
+	{"foo", "stable", "foo.v2.0.0"},
+	{"foo", "beta", "foo.v1.0.0"},
+	{"foo", "beta", "foo.v1.1.0"},
+	{"bar", "alpha", "bar.v0.1.0"},
+}
+
+func testListPackages(t *testing.T, q registry.GRPCQuery) {
+	packages, err := q.ListPackages(context.Background())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"foo", "bar"}, packages)
+}
+
+func testGetPackage(t *testing.T, q registry.GRPCQuery) {
+	pkg, err := q.GetPackage(context.Background(), "foo")
+	require.NoError(t, err)
+	require.Equal(t, "foo", pkg.PackageName)
+	require.Equal(t, "stable", pkg.DefaultChannelName)
+	require.ElementsMatch(t, []registry.PackageChannel{
+		{Name: "stable", CurrentCSVName: "foo.v2.0.0"},
+		{Name: "beta", CurrentCSVName: "foo.v1.1.0"},
+	}, pkg.Channels)
+
+	_, err = q.GetPackage(context.Background(), "missing")
+	require.Error(t, err, "a package that is not in the catalog must not be found")
+}
+
+func testGetBundle(t *testing.T, q registry.GRPCQuery) {
+	b, err := q.GetBundle(context.Background(), "foo", "stable", "foo.v2.0.0")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v2.0.0", b.CsvName)
+	require.Equal(t, "foo", b.PackageName)
+	require.Equal(t, "stable", b.ChannelName)
+	require.Equal(t, "example.com/foo-bundle:v2.0.0", b.BundlePath)
+	require.Equal(t, "2.0.0", b.Version)
+	require.ElementsMatch(t, []*api.GroupVersionKind{gvk("example.com", "v1", "Foo"), gvk("example.com", "v2", "Foo")}, gvks(b.ProvidedApis))
+	require.ElementsMatch(t, []*api.GroupVersionKind{gvk("example.com", "v1", "Bar")}, gvks(b.RequiredApis))
+
+	_, err = q.GetBundle(context.Background(), "foo", "stable", "foo.v1.1.0")
+	require.Error(t, err, "a bundle that is not in the channel must not be found")
+	_, err = q.GetBundle(context.Background(), "missing", "stable", "foo.v2.0.0")
+	require.Error(t, err, "a bundle of a package that is not in the catalog must not be found")
+}
+
+func testGetBundleForChannel(t *testing.T, q registry.GRPCQuery) {
+	b, err := q.GetBundleForChannel(context.Background(), "foo", "beta")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v1.1.0", b.CsvName)
+
+	_, err = q.GetBundleForChannel(context.Background(), "foo", "missing")
+	require.Error(t, err, "the head of a channel that is not in the package must not be found")
+}
+
+func testListBundles(t *testing.T, q registry.GRPCQuery) {
+	bundles, err := q.ListBundles(context.Background())
+	require.NoError(t, err)
+	require.ElementsMatch(t, allEntries, bundleKeys(bundles), "a bundle must be listed once for each channel it is in")
+}
+
+type bundleSender []*api.Bundle
+
+func (s *bundleSender) Send(b *api.Bundle) error {
+	*s = append(*s, b)
+	return nil
+}
+
+func testSendBundles(t *testing.T, q registry.GRPCQuery) {
+	var sent bundleSender
+	require.NoError(t, q.SendBundles(context.Background(), &sent))
+	require.ElementsMatch(t, allEntries, bundleKeys(sent), "a bundle must be sent once for each channel it is in")
+}
+
+func testSendFilteredBundles(t *testing.T, q registry.GRPCQuery) {
+	fq, ok := q.(registry.FilteredBundleQuery)
+	if !ok {
+		t.Skip("the querier does not implement registry.FilteredBundleQuery")
+	}
+
+	for _, tt := range []struct {
+		name     string
+		filter   registry.BundleFilter
+		expected []entryKey
+	}{
+		{
+			name:     "Packages",
+			filter:   registry.BundleFilter{Packages: []string{"bar"}},
+			expected: []entryKey{{"bar", "alpha", "bar.v0.1.0"}},
+		},
+		{
+			name:     "Channels",
+			filter:   registry.BundleFilter{Packages: []string{"foo", "bar"}, Channels: []string{"beta"}},
+			expected: []entryKey{{"foo", "beta", "foo.v1.0.0"}, {"foo", "beta", "foo.v1.1.0"}},
+		},
+		{
+			name:     "Properties",
+			filter:   registry.BundleFilter{Properties: []registry.PropertySelector{{Type: "olm.package", Value: []byte(`{"version":"1.0.0"}`)}}},
+			expected: []entryKey{{"foo", "stable", "foo.v1.0.0"}, {"foo", "beta", "foo.v1.0.0"}},
+		},
+		{
+			name:   "NoMatch",
+			filter: registry.BundleFilter{Channels: []string{"missing"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent bundleSender
+			next, err := fq.SendFilteredBundles(context.Background(), &sent, tt.filter, nil, 0)
+			require.NoError(t, err)
+			require.Nil(t, next, "no cursor must be returned without a limit")
+			require.ElementsMatch(t, tt.expected, bundleKeys(sent))
+		})
+	}
+
+	t.Run("Paged", func(t *testing.T) {
+		var (
+			sent  bundleSender
+			after *registry.BundleCursor
+		)
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, len(allEntries), "paging must end")
+			var page bundleSender
+			next, err := fq.SendFilteredBundles(context.Background(), &page, registry.BundleFilter{Packages: []string{"foo"}}, after, 1)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page), 1)
+			sent = append(sent, page...)
+			if next == nil {
+				break
+			}
+			after = next
+		}
+		require.ElementsMatch(t, allEntries[:4], bundleKeys(sent))
+	})
+}
+
+func testGetChannelEntriesThatReplace(t *testing.T, q registry.GRPCQuery) {
+	entries, err := q.GetChannelEntriesThatReplace(context.Background(), "foo.v1.0.0")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []entryKey{
+		{"foo", "stable", "foo.v2.0.0"},
+		{"foo", "beta", "foo.v1.1.0"},
+	}, entryKeys(entries))
+
+	entries, err = q.GetChannelEntriesThatReplace(context.Background(), "foo.v1.1.0")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []entryKey{{"foo", "stable", "foo.v2.0.0"}}, entryKeys(entries), "the entries that skip a bundle replace it")
+
+	_, err = q.GetChannelEntriesThatReplace(context.Background(), "foo.v2.0.0")
+	require.Error(t, err, "there are no entries that replace a channel head")
+}
+
+func testGetBundleThatReplaces(t *testing.T, q registry.GRPCQuery) {
+	b, err := q.GetBundleThatReplaces(context.Background(), "foo.v1.0.0", "foo", "beta")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v1.1.0", b.CsvName)
+	require.Equal(t, "beta", b.ChannelName)
+
+	_, err = q.GetBundleThatReplaces(context.Background(), "foo.v2.0.0", "foo", "stable")
+	require.Error(t, err, "there is no bundle that replaces a channel head")
+}
+
+func testGetChannelEntriesThatProvide(t *testing.T, q registry.GRPCQuery) {
+	entries, err := q.GetChannelEntriesThatProvide(context.Background(), "example.com", "v1", "Foo")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []entryKey{
+		{"foo", "stable", "foo.v1.0.0"},
+		{"foo", "stable", "foo.v2.0.0"},
+		{"foo", "beta", "foo.v1.0.0"},
+		{"foo", "beta", "foo.v1.1.0"},
+	}, entryKeys(entries))
+
+	_, err = q.GetChannelEntriesThatProvide(context.Background(), "example.com", "v3", "Foo")
+	require.Error(t, err, "there are no entries that provide an API that no bundle provides")
+}
+
+func testGetLatestChannelEntriesThatProvide(t *testing.T, q registry.GRPCQuery) {
+	entries, err := q.GetLatestChannelEntriesThatProvide(context.Background(), "example.com", "v1", "Foo")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []entryKey{
+		{"foo", "stable", "foo.v2.0.0"},
+		{"foo", "beta", "foo.v1.1.0"},
+	}, entryKeys(entries), "only channel heads are the latest entries")
+
+	entries, err = q.GetLatestChannelEntriesThatProvide(context.Background(), "example.com", "v2", "Foo")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []entryKey{{"foo", "stable", "foo.v2.0.0"}}, entryKeys(entries))
+
+	_, err = q.GetLatestChannelEntriesThatProvide(context.Background(), "example.com", "v3", "Foo")
+	require.Error(t, err, "there are no entries that provide an API that no bundle provides")
+}
+
+func testGetBundleThatProvides(t *testing.T, q registry.GRPCQuery) {
+	b, err := q.GetBundleThatProvides(context.Background(), "example.com", "v1", "Foo")
+	require.NoError(t, err)
+	require.Equal(t, "foo.v2.0.0", b.CsvName, "the head of the default channel provides the API")
+	require.Equal(t, "stable", b.ChannelName)
+
+	b, err = q.GetBundleThatProvides(context.Background(), "example.com", "v1", "Bar")
+	require.NoError(t, err)
+	require.Equal(t, "bar.v0.1.0", b.CsvName)
+
+	_, err = q.GetBundleThatProvides(context.Background(), "example.com", "v3", "Foo")
+	require.Error(t, err, "no bundle provides an API that no bundle provides")
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/blang/semver/v4"
 	"github.com/operator-framework/api/pkg/constraints"
+
+	liberrors "github.com/operator-framework/operator-registry/pkg/lib/errors"
 )
 
 var (
@@ -32,6 +34,10 @@ func (e BundleImageAlreadyAddedErr) Error() string {
 	return e.ErrorString
 }
 
+func (e BundleImageAlreadyAddedErr) ErrorCode() liberrors.Code {
+	return liberrors.CodeBundleAlreadyExists
+}
+
 // PackageVersionAlreadyAddedErr is an error that describes that a bundle that is already in the databse that provides this package and version
 type PackageVersionAlreadyAddedErr struct {
 	ErrorString string
@@ -41,6 +47,10 @@ func (e PackageVersionAlreadyAddedErr) Error() string {
 	return e.ErrorString
 }
 
+func (e PackageVersionAlreadyAddedErr) ErrorCode() liberrors.Code {
+	return liberrors.CodePackageVersionAlreadyExists
+}
+
 // OverwritesErr is an error that describes that an error with the add request with --force enabled.
 type OverwriteErr struct {
 	ErrorString string
@@ -57,6 +67,15 @@ const (
 	LabelType      = "olm.label"
 	PropertyKey    = "olm.properties"
 	ConstraintType = "olm.constraint"
+
+	ArchitecturesType = "olm.architectures"
+)
+
+// The prefixes of the CSV labels that declare the platforms a bundle
+// supports, e.g. operatorframework.io/arch.arm64: supported.
+const (
+	ArchLabelPrefix = "operatorframework.io/arch."
+	OSLabelPrefix   = "operatorframework.io/os."
 )
 
 // APIKey stores GroupVersionKind for use as map keys
@@ -257,6 +276,46 @@ type PackageProperty struct {
 	Version string `json:"version" yaml:"version"`
 }
 
+type ArchitecturesProperty struct {
+	// The architectures that the bundle supports, such as 'amd64'
+	Architectures []string `json:"architectures" yaml:"architectures"`
+
+	// The operating systems that the bundle supports, such as 'linux'
+	OperatingSystems []string `json:"operatingSystems" yaml:"operatingSystems"`
+}
+
+// ArchitecturesFromLabels returns the architectures and operating systems
+// that the operatorframework.io/arch.<arch> and operatorframework.io/os.<os>
+// labels of a CSV declare as supported, or nil if it has none of them. As for
+// OLM, a CSV that only declares one of them supports amd64 or linux for the
+// other.
+func ArchitecturesFromLabels(labels map[string]string) *ArchitecturesProperty {
+	var archs, oses []string
+	for k, v := range labels {
+		if v != "supported" {
+			continue
+		}
+		if arch, ok := strings.CutPrefix(k, ArchLabelPrefix); ok && arch != "" {
+			archs = append(archs, arch)
+		}
+		if os, ok := strings.CutPrefix(k, OSLabelPrefix); ok && os != "" {
+			oses = append(oses, os)
+		}
+	}
+	if len(archs) == 0 && len(oses) == 0 {
+		return nil
+	}
+	if len(archs) == 0 {
+		archs = []string{"amd64"}
+	}
+	if len(oses) == 0 {
+		oses = []string{"linux"}
+	}
+	sort.Strings(archs)
+	sort.Strings(oses)
+	return &ArchitecturesProperty{Architectures: archs, OperatingSystems: oses}
+}
+
 type DeprecatedProperty struct {
 	// Whether the bundle is deprecated
 }
@@ -0,0 +1,21 @@
+// Package sdk is the supported Go API for embedding opm functionality in other
+// programs, such as operator-sdk, catalogd, and CI tooling.
+//
+// The packages under alpha/ change without notice. This package exposes the
+// stable subset of their functionality (rendering, validation, migration,
+// template expansion, and loading and serving catalogs) behind the Interface
+// type, and follows these compatibility rules:
+//
+//   - Exported identifiers in this package are not removed or changed in an
+//     incompatible way within a major version of operator-registry.
+//   - New methods are only added to Interface in a minor release, alongside
+//     a note in the release notes. Consumers that implement Interface
+//     themselves (e.g. for testing) should embed it to stay source compatible.
+//   - Identifiers slated for removal are marked with a "Deprecated:" comment
+//     for at least two minor releases before they are removed in the next
+//     major version.
+//
+// Catalogs are represented by the DeclarativeConfig type of this package,
+// which follows the stable file-based catalog format, so that consumers do not
+// depend on the types of alpha packages.
+package sdk
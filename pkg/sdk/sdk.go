@@ -0,0 +1,211 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/action/migrations"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/template/basic"
+	"github.com/operator-framework/operator-registry/alpha/template/semver"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/cache"
+	"github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/lib/config"
+	"github.com/operator-framework/operator-registry/pkg/lib/log"
+	"github.com/operator-framework/operator-registry/pkg/server"
+)
+
+const (
+	templateSchemaBasic  = "olm.template.basic"
+	templateSchemaSemver = "olm.semver"
+)
+
+// catalogRefMask restricts LoadCatalog to references that contain whole catalogs.
+const catalogRefMask = action.RefSqliteImage | action.RefSqliteFile | action.RefDCImage | action.RefDCDir |
+	action.RefDCArchive | action.RefDCGit | action.RefDCGRPC
+
+// Interface is the stable set of opm operations provided by this package.
+type Interface interface {
+	// LoadCatalog loads the catalog found at ref. The reference may be a
+	// file-based catalog directory, file, archive, git repository, gRPC
+	// address, catalog image, or sqlite database.
+	LoadCatalog(ctx context.Context, ref string) (*DeclarativeConfig, error)
+
+	// Render renders each of refs, which may be any reference supported by
+	// LoadCatalog as well as bundle images and bundle directories, and
+	// returns the combined result.
+	Render(ctx context.Context, refs ...string) (*DeclarativeConfig, error)
+
+	// Validate checks that cfg is a valid catalog.
+	Validate(ctx context.Context, cfg DeclarativeConfig) error
+
+	// Migrate runs all schema migrations up to and including the named
+	// migration against cfg, in place.
+	Migrate(ctx context.Context, cfg *DeclarativeConfig, level string) error
+
+	// ExpandTemplate renders the catalog template read from r. Both basic
+	// (olm.template.basic) and semver (olm.semver) templates are supported.
+	ExpandTemplate(ctx context.Context, r io.Reader) (*DeclarativeConfig, error)
+
+	// Serve serves the file-based catalog in fbc over gRPC on lis until ctx is
+	// done. The serving cache is stored in cacheDir, and is reused if it is
+	// up to date with fbc.
+	Serve(ctx context.Context, fbc fs.FS, cacheDir string, lis net.Listener) error
+}
+
+type Options struct {
+	Registry image.Registry
+	Log      *logrus.Entry
+}
+
+type Option func(*Options)
+
+// WithRegistry sets the registry used to pull catalog and bundle images.
+// If unset, a temporary registry is created for each operation that needs one.
+func WithRegistry(reg image.Registry) Option {
+	return func(o *Options) {
+		o.Registry = reg
+	}
+}
+
+func WithLog(log *logrus.Entry) Option {
+	return func(o *Options) {
+		o.Log = log
+	}
+}
+
+type SDK struct {
+	opts Options
+}
+
+var _ Interface = &SDK{}
+
+func New(opts ...Option) *SDK {
+	o := Options{
+		Log: log.Null(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &SDK{opts: o}
+}
+
+func (s *SDK) LoadCatalog(ctx context.Context, ref string) (*DeclarativeConfig, error) {
+	r := s.render([]string{ref})
+	r.AllowedRefMask = catalogRefMask
+	return convertResult(r.Run(ctx))
+}
+
+func (s *SDK) Render(ctx context.Context, refs ...string) (*DeclarativeConfig, error) {
+	return convertResult(s.render(refs).Run(ctx))
+}
+
+func (s *SDK) Validate(_ context.Context, cfg DeclarativeConfig) error {
+	dc, err := cfg.toDeclcfg()
+	if err != nil {
+		return err
+	}
+	return config.ValidateConfig(*dc, config.WithLog(s.opts.Log))
+}
+
+func (s *SDK) Migrate(_ context.Context, cfg *DeclarativeConfig, level string) error {
+	m, err := migrations.NewMigrations(level)
+	if err != nil {
+		return err
+	}
+	dc, err := cfg.toDeclcfg()
+	if err != nil {
+		return err
+	}
+	if err := m.Migrate(dc); err != nil {
+		return err
+	}
+	migrated, err := fromDeclcfg(dc)
+	if err != nil {
+		return err
+	}
+	*cfg = *migrated
+	return nil
+}
+
+func (s *SDK) ExpandTemplate(ctx context.Context, r io.Reader) (*DeclarativeConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Schema string `json:"schema"`
+	}
+	raw := json.RawMessage{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decoding template schema: %v", err)
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decoding template schema: %v", err)
+	}
+
+	renderBundle := func(ctx context.Context, ref string) (*declcfg.DeclarativeConfig, error) {
+		r := s.render([]string{ref})
+		r.AllowedRefMask = action.RefBundleImage
+		return r.Run(ctx)
+	}
+	switch doc.Schema {
+	case templateSchemaBasic:
+		return convertResult(basic.Template{RenderBundle: renderBundle}.Render(ctx, bytes.NewReader(data)))
+	case templateSchemaSemver:
+		return convertResult(semver.Template{Data: bytes.NewReader(data), RenderBundle: renderBundle}.Render(ctx))
+	}
+	return nil, fmt.Errorf("template has unknown schema (%q), expected one of (%s|%s)", doc.Schema, templateSchemaBasic, templateSchemaSemver)
+}
+
+func (s *SDK) Serve(ctx context.Context, fbc fs.FS, cacheDir string, lis net.Listener) error {
+	store, err := cache.New(cacheDir, cache.WithLog(s.opts.Log))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := cache.LoadOrRebuild(ctx, store, fbc); err != nil {
+		return fmt.Errorf("failed to load or rebuild cache: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	api.RegisterRegistryServer(grpcServer, server.NewRegistryServer(store))
+	health.RegisterHealthServer(grpcServer, server.NewHealthServer())
+
+	// done stops the goroutine when Serve fails before ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			grpcServer.GracefulStop()
+		case <-done:
+		}
+	}()
+	return grpcServer.Serve(lis)
+}
+
+func (s *SDK) render(refs []string) action.Render {
+	return action.Render{Refs: refs, Registry: s.opts.Registry}
+}
+
+// convertResult converts the result of an operation of the alpha packages
+// to the types of this package.
+func convertResult(cfg *declcfg.DeclarativeConfig, err error) (*DeclarativeConfig, error) {
+	if err != nil {
+		return nil, err
+	}
+	return fromDeclcfg(cfg)
+}
@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+const testCatalog = `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v1.0.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v1.0.0
+image: test.registry/foo-operator/foo-bundle:v1.0.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 1.0.0
+`
+
+func TestSDK(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catalog.yaml"), []byte(testCatalog), 0600))
+
+	s := New()
+	ctx := context.Background()
+
+	cfg, err := s.LoadCatalog(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	require.Len(t, cfg.Bundles, 1)
+
+	require.NoError(t, s.Validate(ctx, *cfg))
+
+	invalid := *cfg
+	invalid.Channels = nil
+	require.Error(t, s.Validate(ctx, invalid))
+
+	require.Error(t, s.Migrate(ctx, cfg, "not-a-migration"))
+
+	_, err = s.ExpandTemplate(ctx, strings.NewReader("schema: olm.unknown\n"))
+	require.ErrorContains(t, err, `template has unknown schema ("olm.unknown")`)
+}
+
+func TestSDKLoadCatalogRejectsBundles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "manifests"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "metadata"), 0755))
+
+	_, err := New().LoadCatalog(context.Background(), dir)
+	require.Error(t, err)
+}
+
+func TestDeclarativeConfigConversion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catalog.yaml"), []byte(testCatalog+`---
+schema: example.com/other
+package: foo
+name: bar
+value: 1
+`), 0600))
+	expected, err := declcfg.LoadFS(context.Background(), os.DirFS(dir))
+	require.NoError(t, err)
+
+	cfg, err := fromDeclcfg(expected)
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, []Property{{Type: "olm.package", Value: json.RawMessage(`{"packageName":"foo","version":"1.0.0"}`)}}, cfg.Bundles[0].Properties)
+	require.Len(t, cfg.Others, 1)
+	require.Equal(t, "example.com/other", cfg.Others[0].Schema)
+
+	actual, err := cfg.toDeclcfg()
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestSDKServeListenerError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catalog.yaml"), []byte(testCatalog), 0600))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, lis.Close())
+
+	// Serve returns the error of the listener without waiting for ctx.
+	err = New().Serve(context.Background(), os.DirFS(dir), t.TempDir(), lis)
+	require.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// DeclarativeConfig is a file-based catalog, or a subset of one. Its types
+// follow the file-based catalog format, which is stable, and are converted to
+// and from the types of the alpha packages, which are not.
+type DeclarativeConfig struct {
+	Packages       []Package
+	Channels       []Channel
+	Bundles        []Bundle
+	Deprecations   []Deprecation
+	Documentations []PackageDocumentation
+	// Others are the objects of schemas that are not defined by the
+	// file-based catalog format.
+	Others []Meta
+}
+
+// Package is an olm.package object.
+type Package struct {
+	Schema         string     `json:"schema"`
+	Name           string     `json:"name"`
+	DefaultChannel string     `json:"defaultChannel"`
+	Icon           *Icon      `json:"icon,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	Properties     []Property `json:"properties,omitempty"`
+}
+
+type Icon struct {
+	Data      []byte `json:"base64data"`
+	MediaType string `json:"mediatype"`
+}
+
+// Channel is an olm.channel object.
+type Channel struct {
+	Schema     string         `json:"schema"`
+	Name       string         `json:"name"`
+	Package    string         `json:"package"`
+	Entries    []ChannelEntry `json:"entries"`
+	Properties []Property     `json:"properties,omitempty"`
+}
+
+type ChannelEntry struct {
+	Name      string   `json:"name"`
+	Replaces  string   `json:"replaces,omitempty"`
+	Skips     []string `json:"skips,omitempty"`
+	SkipRange string   `json:"skipRange,omitempty"`
+}
+
+// Bundle is an olm.bundle object.
+type Bundle struct {
+	Schema        string         `json:"schema"`
+	Name          string         `json:"name,omitempty"`
+	Package       string         `json:"package,omitempty"`
+	Image         string         `json:"image"`
+	Properties    []Property     `json:"properties,omitempty"`
+	RelatedImages []RelatedImage `json:"relatedImages,omitempty"`
+}
+
+type RelatedImage struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// Property is a property of a package, channel, or bundle. Value is the JSON
+// value of the property, whose format depends on Type.
+type Property struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Deprecation is an olm.deprecations object.
+type Deprecation struct {
+	Schema  string             `json:"schema"`
+	Package string             `json:"package"`
+	Entries []DeprecationEntry `json:"entries"`
+}
+
+type DeprecationEntry struct {
+	Reference PackageScopedReference `json:"reference"`
+	Message   string                 `json:"message"`
+}
+
+type PackageScopedReference struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name,omitempty"`
+}
+
+// PackageDocumentation is an olm.package.documentation object.
+type PackageDocumentation struct {
+	Schema  string `json:"schema"`
+	Package string `json:"package"`
+	Content string `json:"content,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// Meta is an object of any schema. Blob is the JSON encoding of the whole
+// object, and Schema, Package, and Name are the values of its fields of the
+// same names.
+type Meta struct {
+	Schema  string
+	Package string
+	Name    string
+
+	Blob json.RawMessage
+}
+
+// fromDeclcfg converts cfg to the types of this package, which have the same
+// JSON encoding.
+func fromDeclcfg(cfg *declcfg.DeclarativeConfig) (*DeclarativeConfig, error) {
+	out := &DeclarativeConfig{}
+	for _, c := range []struct {
+		from, to interface{}
+	}{
+		{cfg.Packages, &out.Packages},
+		{cfg.Channels, &out.Channels},
+		{cfg.Bundles, &out.Bundles},
+		{cfg.Deprecations, &out.Deprecations},
+		{cfg.Documentations, &out.Documentations},
+	} {
+		data, err := json.Marshal(c.from)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, c.to); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range cfg.Others {
+		out.Others = append(out.Others, Meta{Schema: m.Schema, Package: m.Package, Name: m.Name, Blob: m.Blob})
+	}
+	return out, nil
+}
+
+// toDeclcfg loads cfg as a declarative config of the alpha packages, in the
+// same way that file-based catalogs are loaded, so that the fields of
+// bundles that are derived from their properties are populated.
+func (cfg DeclarativeConfig) toDeclcfg() (*declcfg.DeclarativeConfig, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, objs := range [][]interface{}{
+		objects(cfg.Packages),
+		objects(cfg.Channels),
+		objects(cfg.Bundles),
+		objects(cfg.Deprecations),
+		objects(cfg.Documentations),
+	} {
+		for _, obj := range objs {
+			if err := enc.Encode(obj); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, m := range cfg.Others {
+		if _, err := buf.Write(append(bytes.TrimSpace(m.Blob), '\n')); err != nil {
+			return nil, err
+		}
+	}
+	out, err := declcfg.LoadReader(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("load declarative config: %v", err)
+	}
+	return out, nil
+}
+
+func objects[T any](in []T) []interface{} {
+	out := make([]interface{}, 0, len(in))
+	for _, v := range in {
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// Reloader reloads the catalog that a registry serves.
+type Reloader interface {
+	// Reload rebuilds the served catalog from its source, swaps it in, and
+	// returns the digest of its new content, as computed by CatalogDigest.
+	// Errors should be gRPC status errors, e.g. with
+	// codes.FailedPrecondition if the catalog cannot be reloaded yet.
+	Reload(ctx context.Context) (string, error)
+}
+
+// ReloaderFunc is a function that implements Reloader.
+type ReloaderFunc func(ctx context.Context) (string, error)
+
+func (f ReloaderFunc) Reload(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// AdminServer implements the Admin API. Calls must carry the token of the
+// server as a bearer token in their authorization metadata, or they fail with
+// codes.Unauthenticated.
+type AdminServer struct {
+	api.UnimplementedAdminServer
+	reloader Reloader
+	token    string
+}
+
+var _ api.AdminServer = &AdminServer{}
+
+// NewAdminServer returns an AdminServer that reloads the catalog with
+// reloader, and authenticates calls with token, which must not be empty.
+func NewAdminServer(reloader Reloader, token string) *AdminServer {
+	return &AdminServer{reloader: reloader, token: token}
+}
+
+func (s *AdminServer) Reload(ctx context.Context, _ *api.ReloadRequest) (*api.ReloadResponse, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	digest, err := s.reloader.Reload(ctx)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reload catalog: %v", err)
+	}
+	return &api.ReloadResponse{Digest: digest}, nil
+}
+
+// authenticate checks the bearer token in the authorization metadata of ctx.
+func (s *AdminServer) authenticate(ctx context.Context) error {
+	if s.token == "" {
+		return status.Error(codes.Unauthenticated, "admin API has no token configured")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// NewAdminHTTPHandler returns an http.Handler that serves the Admin API of
+// admin as JSON over HTTP, like NewHTTPHandler does the Registry API. The
+// Authorization header of requests is passed to admin as their authorization
+// metadata.
+//
+// The following endpoints are served:
+//
+//	POST /api/v1/admin/reload
+func NewAdminHTTPHandler(admin api.AdminServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/admin/reload", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if auth := r.Header.Values("Authorization"); len(auth) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.MD{"authorization": auth})
+		}
+		resp, err := admin.Reload(ctx, &api.ReloadRequest{})
+		writeHTTPResponse(w, resp, err)
+	})
+	return mux
+}
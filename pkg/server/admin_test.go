@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+func TestAdminServerReload(t *testing.T) {
+	var reloads int
+	admin := NewAdminServer(ReloaderFunc(func(context.Context) (string, error) {
+		reloads++
+		return "sha256:abc", nil
+	}), "secret")
+
+	withAuth := func(auth string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", auth))
+	}
+
+	_, err := admin.Reload(context.Background(), &api.ReloadRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = admin.Reload(withAuth("Bearer wrong"), &api.ReloadRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = admin.Reload(withAuth("secret"), &api.ReloadRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Zero(t, reloads)
+
+	resp, err := admin.Reload(withAuth("Bearer secret"), &api.ReloadRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "sha256:abc", resp.GetDigest())
+	require.Equal(t, 1, reloads)
+
+	_, err = NewAdminServer(ReloaderFunc(func(context.Context) (string, error) {
+		return "", errors.New("broken config")
+	}), "secret").Reload(withAuth("Bearer secret"), &api.ReloadRequest{})
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Contains(t, err.Error(), "broken config")
+
+	_, err = NewAdminServer(ReloaderFunc(func(context.Context) (string, error) {
+		return "", status.Error(codes.FailedPrecondition, "catalog is loading")
+	}), "secret").Reload(withAuth("Bearer secret"), &api.ReloadRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = NewAdminServer(ReloaderFunc(func(context.Context) (string, error) {
+		return "sha256:abc", nil
+	}), "").Reload(withAuth("Bearer "), &api.ReloadRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAdminHTTPHandler(t *testing.T) {
+	srv := httptest.NewServer(NewAdminHTTPHandler(NewAdminServer(ReloaderFunc(func(context.Context) (string, error) {
+		return "sha256:abc", nil
+	}), "secret")))
+	defer srv.Close()
+
+	reload := func(auth string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/admin/reload", nil)
+		require.NoError(t, err)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := reload("Bearer secret")
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"digest":"sha256:abc"}`, body)
+
+	code, _ = reload("")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	resp, err := http.Get(srv.URL + "/api/v1/admin/reload")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// PackageAuthorizer decides which packages are visible to the caller of a
+// request, so that a registry can be shared by tenants that may only see
+// some of its packages. Authorizers typically identify the caller by its
+// mTLS client certificate (see google.golang.org/grpc/peer) or by the claims
+// of a token in the request metadata (see google.golang.org/grpc/metadata).
+//
+// Packages that are not visible to the caller are omitted from the results
+// of listing and query requests, and requests that name them fail with
+// codes.NotFound, as if the packages did not exist.
+type PackageAuthorizer interface {
+	// AuthorizePackage reports whether the caller of the request with
+	// context ctx may see the package named pkgName. A non-nil error fails
+	// the request, and should be a gRPC status error, e.g. with
+	// codes.Unauthenticated if the caller could not be identified.
+	AuthorizePackage(ctx context.Context, pkgName string) (bool, error)
+}
+
+// PackageAuthorizerFunc is a function that implements PackageAuthorizer.
+type PackageAuthorizerFunc func(ctx context.Context, pkgName string) (bool, error)
+
+func (f PackageAuthorizerFunc) AuthorizePackage(ctx context.Context, pkgName string) (bool, error) {
+	return f(ctx, pkgName)
+}
+
+// packageFilter applies a PackageAuthorizer to the results of one request.
+// Decisions are memoized, so that the authorizer is called at most once per
+// package and request. A packageFilter without an authorizer allows every
+// package.
+type packageFilter struct {
+	ctx        context.Context
+	authorizer PackageAuthorizer
+	decisions  map[string]bool
+}
+
+func (s *RegistryServer) packageFilter(ctx context.Context) *packageFilter {
+	return &packageFilter{ctx: ctx, authorizer: s.authorizer, decisions: map[string]bool{}}
+}
+
+func (f *packageFilter) enabled() bool {
+	return f.authorizer != nil
+}
+
+func (f *packageFilter) allowed(pkgName string) (bool, error) {
+	if f.authorizer == nil {
+		return true, nil
+	}
+	if allowed, ok := f.decisions[pkgName]; ok {
+		return allowed, nil
+	}
+	allowed, err := f.authorizer.AuthorizePackage(f.ctx, pkgName)
+	if err != nil {
+		return false, err
+	}
+	f.decisions[pkgName] = allowed
+	return allowed, nil
+}
+
+// check returns a codes.NotFound error if pkgName is not visible.
+func (f *packageFilter) check(pkgName string) error {
+	allowed, err := f.allowed(pkgName)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return status.Errorf(codes.NotFound, "package %q not found", pkgName)
+	}
+	return nil
+}
+
+func (f *packageFilter) packageNames(names []string) ([]string, error) {
+	if !f.enabled() {
+		return names, nil
+	}
+	filtered := make([]string, 0, len(names))
+	for _, name := range names {
+		allowed, err := f.allowed(name)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered, nil
+}
+
+func (f *packageFilter) channelEntries(entries []*registry.ChannelEntry) ([]*registry.ChannelEntry, error) {
+	if !f.enabled() {
+		return entries, nil
+	}
+	filtered := make([]*registry.ChannelEntry, 0, len(entries))
+	for _, e := range entries {
+		allowed, err := f.allowed(e.PackageName)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered, nil
+}
+
+func (f *packageFilter) checksums(checksums map[string]string) (map[string]string, error) {
+	if !f.enabled() {
+		return checksums, nil
+	}
+	filtered := make(map[string]string, len(checksums))
+	for name, checksum := range checksums {
+		allowed, err := f.allowed(name)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			filtered[name] = checksum
+		}
+	}
+	return filtered, nil
+}
+
+func (f *packageFilter) deprecations(entries []*api.DeprecationEntry) ([]*api.DeprecationEntry, error) {
+	if !f.enabled() {
+		return entries, nil
+	}
+	filtered := make([]*api.DeprecationEntry, 0, len(entries))
+	for _, e := range entries {
+		allowed, err := f.allowed(e.GetPackageName())
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered, nil
+}
+
+// bundle returns b, or a codes.NotFound error if the package of b is not
+// visible.
+func (f *packageFilter) bundle(b *api.Bundle, err error) (*api.Bundle, error) {
+	if err != nil || !f.enabled() {
+		return b, err
+	}
+	if err := f.check(b.GetPackageName()); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (f *packageFilter) bundleSender(stream registry.BundleSender) registry.BundleSender {
+	if !f.enabled() {
+		return stream
+	}
+	return &filteredBundleSender{stream: stream, filter: f}
+}
+
+func (f *packageFilter) bundleMetadataSender(stream registry.BundleMetadataSender) registry.BundleMetadataSender {
+	if !f.enabled() {
+		return stream
+	}
+	return &filteredBundleMetadataSender{stream: stream, filter: f}
+}
+
+type filteredBundleSender struct {
+	stream registry.BundleSender
+	filter *packageFilter
+}
+
+func (s *filteredBundleSender) Send(b *api.Bundle) error {
+	allowed, err := s.filter.allowed(b.GetPackageName())
+	if err != nil || !allowed {
+		return err
+	}
+	return s.stream.Send(b)
+}
+
+type filteredBundleMetadataSender struct {
+	stream registry.BundleMetadataSender
+	filter *packageFilter
+}
+
+func (s *filteredBundleMetadataSender) Send(b *api.BundleMetadata) error {
+	allowed, err := s.filter.allowed(b.GetPackageName())
+	if err != nil || !allowed {
+		return err
+	}
+	return s.stream.Send(b)
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// authzStore serves one bundle for each of its packages.
+type authzStore struct {
+	registry.GRPCQuery
+	packages []string
+}
+
+func (s authzStore) ListPackages(context.Context) ([]string, error) {
+	return s.packages, nil
+}
+
+func (s authzStore) SendBundles(_ context.Context, stream registry.BundleSender) error {
+	for _, p := range s.packages {
+		if err := stream.Send(&api.Bundle{PackageName: p, CsvName: p + ".v1"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s authzStore) GetBundle(_ context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	return &api.Bundle{PackageName: pkgName, ChannelName: channelName, CsvName: csvName}, nil
+}
+
+func (s authzStore) GetChannelEntriesThatProvide(context.Context, string, string, string) ([]*registry.ChannelEntry, error) {
+	var entries []*registry.ChannelEntry
+	for _, p := range s.packages {
+		entries = append(entries, &registry.ChannelEntry{PackageName: p, ChannelName: "stable", BundleName: p + ".v1"})
+	}
+	return entries, nil
+}
+
+func (s authzStore) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	return s.GetChannelEntriesThatProvide(ctx, group, version, kind)
+}
+
+func (s authzStore) GetBundleThatProvides(context.Context, string, string, string) (*api.Bundle, error) {
+	return &api.Bundle{PackageName: s.packages[0], CsvName: s.packages[0] + ".v1"}, nil
+}
+
+// GetPackage makes the "stable" channel the default channel of every package
+// but "beta".
+func (s authzStore) GetPackage(_ context.Context, name string) (*registry.PackageManifest, error) {
+	m := &registry.PackageManifest{PackageName: name, DefaultChannelName: "stable"}
+	if name == "beta" {
+		m.DefaultChannelName = "fast"
+	}
+	return m, nil
+}
+
+// tenantAuthorizer allows each tenant, identified by the "tenant" request
+// metadata, to see the packages listed for it.
+func tenantAuthorizer(packagesByTenant map[string][]string) PackageAuthorizer {
+	return PackageAuthorizerFunc(func(ctx context.Context, pkgName string) (bool, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		tenants := md.Get("tenant")
+		if len(tenants) != 1 {
+			return false, status.Error(codes.Unauthenticated, "missing tenant")
+		}
+		for _, p := range packagesByTenant[tenants[0]] {
+			if p == pkgName {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func TestPackageAuthorizer(t *testing.T) {
+	store := authzStore{packages: []string{"alpha", "beta", "gamma"}}
+	authorizer := tenantAuthorizer(map[string][]string{
+		"a": {"alpha"},
+		"b": {"beta", "gamma"},
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	api.RegisterRegistryServer(s, NewRegistryServer(store, WithPackageAuthorizer(authorizer)))
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	c := api.NewRegistryClient(conn)
+
+	tenantCtx := func(tenant string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "tenant", tenant)
+	}
+
+	t.Run("ListPackages", func(t *testing.T) {
+		stream, err := c.ListPackages(tenantCtx("b"), &api.ListPackageRequest{})
+		require.NoError(t, err)
+		var names []string
+		for {
+			p, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, p.GetName())
+		}
+		require.Equal(t, []string{"beta", "gamma"}, names)
+	})
+
+	t.Run("ListBundles", func(t *testing.T) {
+		stream, err := c.ListBundles(tenantCtx("a"), &api.ListBundlesRequest{})
+		require.NoError(t, err)
+		var names []string
+		for {
+			b, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, b.GetCsvName())
+		}
+		require.Equal(t, []string{"alpha.v1"}, names)
+	})
+
+	t.Run("GetBundle", func(t *testing.T) {
+		b, err := c.GetBundle(tenantCtx("a"), &api.GetBundleRequest{PkgName: "alpha", ChannelName: "stable", CsvName: "alpha.v1"})
+		require.NoError(t, err)
+		require.Equal(t, "alpha.v1", b.GetCsvName())
+
+		_, err = c.GetBundle(tenantCtx("a"), &api.GetBundleRequest{PkgName: "beta", ChannelName: "stable", CsvName: "beta.v1"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("GetChannelEntriesThatProvide", func(t *testing.T) {
+		stream, err := c.GetChannelEntriesThatProvide(tenantCtx("b"), &api.GetAllProvidersRequest{Group: "example.com", Version: "v1", Kind: "Example"})
+		require.NoError(t, err)
+		var pkgs []string
+		for {
+			e, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			pkgs = append(pkgs, e.GetPackageName())
+		}
+		require.Equal(t, []string{"beta", "gamma"}, pkgs)
+	})
+
+	t.Run("GetDefaultBundleThatProvides", func(t *testing.T) {
+		_, err := c.GetDefaultBundleThatProvides(tenantCtx("a"), &api.GetDefaultProviderRequest{Group: "example.com", Version: "v1", Kind: "Example"})
+		require.NoError(t, err)
+
+		// The default provider is not visible to tenant "b", so the provider in
+		// the default channel of another visible package is returned.
+		b, err := c.GetDefaultBundleThatProvides(tenantCtx("b"), &api.GetDefaultProviderRequest{Group: "example.com", Version: "v1", Kind: "Example"})
+		require.NoError(t, err)
+		require.Equal(t, "gamma", b.GetPackageName())
+		require.Equal(t, "gamma.v1", b.GetCsvName())
+
+		_, err = c.GetDefaultBundleThatProvides(tenantCtx("c"), &api.GetDefaultProviderRequest{Group: "example.com", Version: "v1", Kind: "Example"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := c.GetBundle(context.Background(), &api.GetBundleRequest{PkgName: "alpha", ChannelName: "stable", CsvName: "alpha.v1"})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestPackageFilterMemoizesDecisions(t *testing.T) {
+	calls := map[string]int{}
+	s := NewRegistryServer(nil, WithPackageAuthorizer(PackageAuthorizerFunc(func(_ context.Context, pkgName string) (bool, error) {
+		calls[pkgName]++
+		return pkgName == "alpha", nil
+	})))
+
+	f := s.packageFilter(context.Background())
+	names, err := f.packageNames([]string{"alpha", "beta", "alpha", "beta"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alpha", "alpha"}, names)
+	require.Equal(t, map[string]int{"alpha": 1, "beta": 1}, calls)
+}
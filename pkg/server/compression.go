@@ -0,0 +1,93 @@
+package server
+
+import (
+	"compress/flate"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	// Register the gzip compressor, so that the server accepts gzip
+	// compressed requests and can compress its responses with gzip.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// Deflate is the name of the deflate compressor that is registered with gRPC
+// by this package.
+const Deflate = "deflate"
+
+func init() {
+	encoding.RegisterCompressor(&deflateCompressor{})
+}
+
+// deflateCompressor implements encoding.Compressor with compress/flate.
+type deflateCompressor struct {
+	writers sync.Pool
+}
+
+type deflateWriter struct {
+	*flate.Writer
+	pool *sync.Pool
+}
+
+func (w *deflateWriter) Close() error {
+	defer w.pool.Put(w)
+	return w.Writer.Close()
+}
+
+func (c *deflateCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if dw, ok := c.writers.Get().(*deflateWriter); ok {
+		dw.Reset(w)
+		return dw, nil
+	}
+	fw, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &deflateWriter{Writer: fw, pool: &c.writers}, nil
+}
+
+func (c *deflateCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return flate.NewReader(r), nil
+}
+
+func (c *deflateCompressor) Name() string {
+	return Deflate
+}
+
+// CompressionInterceptors returns interceptors that compress responses with
+// the registered compressor named compressor, e.g. "gzip" or Deflate, when
+// the client supports it, even if its requests are not compressed. Without
+// them, responses are only compressed if the request was.
+func CompressionInterceptors(compressor string) (grpc.StreamServerInterceptor, grpc.UnaryServerInterceptor, error) {
+	if encoding.GetCompressor(compressor) == nil {
+		return nil, nil, fmt.Errorf("unknown compressor %q", compressor)
+	}
+	setCompressor := func(ctx context.Context) error {
+		supported, err := grpc.ClientSupportedCompressors(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range supported {
+			if s == compressor {
+				return grpc.SetSendCompressor(ctx, compressor)
+			}
+		}
+		return nil
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := setCompressor(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := setCompressor(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	return stream, unary, nil
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// csvStore serves bundles with a large CSV, which dominates the size of
+// real responses.
+type csvStore struct {
+	registry.GRPCQuery
+	csvJSON string
+}
+
+func newCSVStore() csvStore {
+	var sb strings.Builder
+	sb.WriteString(`{"kind":"ClusterServiceVersion","spec":{"customresourcedefinitions":{"owned":[`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"name":"resource%d.example.com","version":"v1","kind":"Resource%d","displayName":"Resource %d","description":"Resource %d is managed by the example operator."}`, i, i, i, i)
+	}
+	sb.WriteString(`]}}}`)
+	return csvStore{csvJSON: sb.String()}
+}
+
+func (s csvStore) GetBundle(_ context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	return &api.Bundle{PackageName: pkgName, ChannelName: channelName, CsvName: csvName, CsvJson: s.csvJSON}, nil
+}
+
+// countingListener counts the bytes written by the server to its
+// connections.
+type countingListener struct {
+	net.Listener
+	written atomic.Int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, written: &l.written}, nil
+}
+
+type countingConn struct {
+	net.Conn
+	written *atomic.Int64
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+func serveCompressed(t testing.TB, store registry.GRPCQuery, compressor string) (api.RegistryClient, *countingListener) {
+	t.Helper()
+	var opts []grpc.ServerOption
+	if compressor != "" {
+		stream, unary, err := CompressionInterceptors(compressor)
+		require.NoError(t, err)
+		opts = append(opts, grpc.StreamInterceptor(stream), grpc.UnaryInterceptor(unary))
+	}
+	bl := bufconn.Listen(1 << 20)
+	lis := &countingListener{Listener: bl}
+	s := grpc.NewServer(opts...)
+	api.RegisterRegistryServer(s, NewRegistryServer(store))
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return bl.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return api.NewRegistryClient(conn), lis
+}
+
+func TestCompressionInterceptors(t *testing.T) {
+	store := newCSVStore()
+	req := &api.GetBundleRequest{PkgName: "foo", ChannelName: "stable", CsvName: "foo.v1"}
+
+	c, lis := serveCompressed(t, store, "")
+	b, err := c.GetBundle(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, store.csvJSON, b.GetCsvJson())
+	uncompressed := lis.written.Load()
+
+	for _, compressor := range []string{gzip.Name, Deflate} {
+		t.Run(compressor, func(t *testing.T) {
+			c, lis := serveCompressed(t, store, compressor)
+			b, err := c.GetBundle(context.Background(), req)
+			require.NoError(t, err)
+			require.Equal(t, store.csvJSON, b.GetCsvJson())
+			require.Less(t, lis.written.Load(), uncompressed/2)
+		})
+	}
+
+	t.Run("CompressedRequest", func(t *testing.T) {
+		c, _ := serveCompressed(t, store, "")
+		b, err := c.GetBundle(context.Background(), req, grpc.UseCompressor(Deflate))
+		require.NoError(t, err)
+		require.Equal(t, store.csvJSON, b.GetCsvJson())
+	})
+
+	t.Run("UnknownCompressor", func(t *testing.T) {
+		_, _, err := CompressionInterceptors("zstd")
+		require.EqualError(t, err, `unknown compressor "zstd"`)
+	})
+}
+
+func BenchmarkCompression(b *testing.B) {
+	store := newCSVStore()
+	req := &api.GetBundleRequest{PkgName: "foo", ChannelName: "stable", CsvName: "foo.v1"}
+	for _, compressor := range []string{"", gzip.Name, Deflate} {
+		name := compressor
+		if name == "" {
+			name = "none"
+		}
+		b.Run(name, func(b *testing.B) {
+			c, lis := serveCompressed(b, store, compressor)
+			// Establish the connection before measuring.
+			_, err := c.GetBundle(context.Background(), req)
+			require.NoError(b, err)
+			lis.written.Store(0)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.GetBundle(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(lis.written.Load())/float64(b.N), "wire-bytes/op")
+		})
+	}
+}
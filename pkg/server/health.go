@@ -4,18 +4,40 @@ import (
 	"context"
 
 	health "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
 )
 
 type HealthServer struct {
 	health.UnimplementedHealthServer
+	readiness *Readiness
 }
 
 var _ health.HealthServer = &HealthServer{}
 
-func NewHealthServer() *HealthServer {
-	return &HealthServer{health.UnimplementedHealthServer{}}
+type HealthServerOption func(*HealthServer)
+
+// WithHealthReadiness reports NOT_SERVING until readiness leaves the LOADING
+// state. A degraded catalog is still served, so it is reported as SERVING.
+func WithHealthReadiness(readiness *Readiness) HealthServerOption {
+	return func(s *HealthServer) {
+		s.readiness = readiness
+	}
+}
+
+func NewHealthServer(opts ...HealthServerOption) *HealthServer {
+	s := &HealthServer{UnimplementedHealthServer: health.UnimplementedHealthServer{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *HealthServer) Check(ctx context.Context, req *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
+	if s.readiness != nil {
+		if state, _ := s.readiness.State(); state == api.CatalogStatus_LOADING {
+			return &health.HealthCheckResponse{Status: health.HealthCheckResponse_NOT_SERVING}, nil
+		}
+	}
 	return &health.HealthCheckResponse{Status: health.HealthCheckResponse_SERVING}, nil
 }
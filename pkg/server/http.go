@@ -0,0 +1,485 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// NewHTTPHandler returns an http.Handler that serves the Registry API of
+// registry, and the health service of healthServer, as JSON over HTTP, for
+// clients that cannot use gRPC. Requests are served by the same server
+// implementations as the gRPC API, so they return the same data and errors.
+//
+// The following endpoints are served:
+//
+//	GET /api/v1/packages[?pageSize={size}[&pageToken={token}]]
+//	GET /api/v1/packages/{package}
+//	GET /api/v1/packages/{package}/documentation
+//	GET /api/v1/packages/{package}/metadata
+//	GET /api/v1/packages/{package}/channels/{channel}/head
+//	GET /api/v1/packages/{package}/channels/{channel}/bundles/{csv}
+//	GET /api/v1/packages/{package}/channels/{channel}/bundles/{csv}/replacement
+//	GET /api/v1/bundles[?package={package}...][&channel={channel}...][&pageSize={size}[&pageToken={token}]]
+//	GET /api/v1/channelentries?replaces={csv}
+//	GET /api/v1/channelentries?group={group}&version={version}&kind={kind}[&latest=true]
+//	GET /api/v1/providers/default?group={group}&version={version}&kind={kind}
+//	GET /api/v1/catalog
+//	GET /api/v1/catalog/status
+//	GET /healthz
+//
+// Messages are encoded with the canonical protobuf JSON mapping. Streaming
+// RPCs return a JSON array. Paged list requests return the token of the next
+// page, if there is one, in the Next-Page-Token header. Errors return a
+// google.rpc.Status JSON object with an HTTP status code that corresponds to
+// the gRPC status code.
+//
+// Requests are dispatched as calls of the gRPC methods, whose peer is the HTTP
+// client and whose incoming metadata are the HTTP request headers, so that
+// PackageAuthorizers, and the interceptors set with WithHTTPInterceptors,
+// see HTTP clients as they see gRPC clients.
+func NewHTTPHandler(registry api.RegistryServer, healthServer health.HealthServer, opts ...HTTPOption) http.Handler {
+	h := &httpHandler{methods: map[string]httpMethod{}}
+	for _, svc := range []struct {
+		srv  interface{}
+		desc *grpc.ServiceDesc
+	}{
+		{registry, &api.Registry_ServiceDesc},
+		{healthServer, &health.Health_ServiceDesc},
+	} {
+		for _, m := range svc.desc.Methods {
+			h.methods["/"+svc.desc.ServiceName+"/"+m.MethodName] = httpMethod{srv: svc.srv, unary: m.Handler}
+		}
+		for _, st := range svc.desc.Streams {
+			h.methods["/"+svc.desc.ServiceName+"/"+st.StreamName] = httpMethod{srv: svc.srv, stream: st.Handler}
+		}
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/packages", h.listPackages)
+	mux.HandleFunc("GET /api/v1/packages/{package}", h.getPackage)
+	mux.HandleFunc("GET /api/v1/packages/{package}/documentation", h.getPackageDocumentation)
+	mux.HandleFunc("GET /api/v1/packages/{package}/metadata", h.getPackageMetadata)
+	mux.HandleFunc("GET /api/v1/packages/{package}/channels/{channel}/head", h.getBundleForChannel)
+	mux.HandleFunc("GET /api/v1/packages/{package}/channels/{channel}/bundles/{csv}", h.getBundle)
+	mux.HandleFunc("GET /api/v1/packages/{package}/channels/{channel}/bundles/{csv}/replacement", h.getBundleThatReplaces)
+	mux.HandleFunc("GET /api/v1/bundles", h.listBundles)
+	mux.HandleFunc("GET /api/v1/channelentries", h.getChannelEntries)
+	mux.HandleFunc("GET /api/v1/providers/default", h.getDefaultBundleThatProvides)
+	mux.HandleFunc("GET /api/v1/catalog", h.getCatalogInfo)
+	mux.HandleFunc("GET "+catalogStatusPath, h.getCatalogStatus)
+	mux.HandleFunc("GET /healthz", h.healthz)
+	return mux
+}
+
+const (
+	catalogStatusPath = "/api/v1/catalog/status"
+
+	// nextPageTokenHeader is the header of the responses of paged list
+	// requests that holds the token of the next page.
+	nextPageTokenHeader = "Next-Page-Token"
+)
+
+// HTTPOption configures the handler returned by NewHTTPHandler.
+type HTTPOption func(*httpHandler)
+
+// WithHTTPInterceptors serves HTTP requests through the interceptors of gRPC
+// calls, in the order of the slices, as grpc.ChainStreamInterceptor and
+// grpc.ChainUnaryInterceptor do, so that HTTP requests are e.g. logged,
+// traced, and rate limited like gRPC calls. Interceptors must not depend on
+// the transport of gRPC, e.g. to set the compression of responses.
+func WithHTTPInterceptors(stream []grpc.StreamServerInterceptor, unary []grpc.UnaryServerInterceptor) HTTPOption {
+	return func(h *httpHandler) {
+		h.streamInterceptors = append(h.streamInterceptors, stream...)
+		h.unaryInterceptors = append(h.unaryInterceptors, unary...)
+	}
+}
+
+type httpHandler struct {
+	methods            map[string]httpMethod
+	streamInterceptors []grpc.StreamServerInterceptor
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+}
+
+// httpMethod is a gRPC method, as dispatched by a grpc.Server.
+type httpMethod struct {
+	srv    interface{}
+	unary  grpc.MethodHandler
+	stream grpc.StreamHandler
+}
+
+func (h *httpHandler) listPackages(w http.ResponseWriter, r *http.Request) {
+	req := &api.ListPackageRequest{}
+	var err error
+	req.PageSize, req.PageToken, err = pageQuery(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	h.serveStream(w, r, api.Registry_ListPackages_FullMethodName, req)
+}
+
+func (h *httpHandler) getPackage(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetPackage_FullMethodName, &api.GetPackageRequest{Name: r.PathValue("package")})
+}
+
+func (h *httpHandler) getPackageDocumentation(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetPackageDocumentation_FullMethodName, &api.GetPackageDocumentationRequest{PkgName: r.PathValue("package")})
+}
+
+func (h *httpHandler) getPackageMetadata(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetPackageMetadata_FullMethodName, &api.GetPackageMetadataRequest{PkgName: r.PathValue("package")})
+}
+
+func (h *httpHandler) getBundleForChannel(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetBundleForChannel_FullMethodName, &api.GetBundleInChannelRequest{
+		PkgName:     r.PathValue("package"),
+		ChannelName: r.PathValue("channel"),
+	})
+}
+
+func (h *httpHandler) getBundle(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetBundle_FullMethodName, &api.GetBundleRequest{
+		PkgName:     r.PathValue("package"),
+		ChannelName: r.PathValue("channel"),
+		CsvName:     r.PathValue("csv"),
+	})
+}
+
+func (h *httpHandler) getBundleThatReplaces(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetBundleThatReplaces_FullMethodName, &api.GetReplacementRequest{
+		PkgName:     r.PathValue("package"),
+		ChannelName: r.PathValue("channel"),
+		CsvName:     r.PathValue("csv"),
+	})
+}
+
+func (h *httpHandler) listBundles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := &api.ListBundlesRequest{Packages: q["package"], Channels: q["channel"]}
+	var err error
+	req.PageSize, req.PageToken, err = pageQuery(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	h.serveStream(w, r, api.Registry_ListBundles_FullMethodName, req)
+}
+
+func (h *httpHandler) getChannelEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if replaces := q.Get("replaces"); replaces != "" {
+		h.serveStream(w, r, api.Registry_GetChannelEntriesThatReplace_FullMethodName, &api.GetAllReplacementsRequest{CsvName: replaces})
+		return
+	}
+
+	group, version, kind := q.Get("group"), q.Get("version"), q.Get("kind")
+	if group == "" || version == "" || kind == "" {
+		writeHTTPError(w, status.Error(codes.InvalidArgument, `either the "replaces" query parameter, or all of the "group", "version", and "kind" query parameters must be set`))
+		return
+	}
+	latest, err := strconv.ParseBool(q.Get("latest"))
+	if err != nil && q.Get("latest") != "" {
+		writeHTTPError(w, status.Errorf(codes.InvalidArgument, `invalid "latest" query parameter: %v`, err))
+		return
+	}
+	if latest {
+		h.serveStream(w, r, api.Registry_GetLatestChannelEntriesThatProvide_FullMethodName, &api.GetLatestProvidersRequest{Group: group, Version: version, Kind: kind})
+		return
+	}
+	h.serveStream(w, r, api.Registry_GetChannelEntriesThatProvide_FullMethodName, &api.GetAllProvidersRequest{Group: group, Version: version, Kind: kind})
+}
+
+func (h *httpHandler) getDefaultBundleThatProvides(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	h.serveUnary(w, r, api.Registry_GetDefaultBundleThatProvides_FullMethodName, &api.GetDefaultProviderRequest{
+		Group:   q.Get("group"),
+		Version: q.Get("version"),
+		Kind:    q.Get("kind"),
+	})
+}
+
+func (h *httpHandler) getCatalogInfo(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetCatalogInfo_FullMethodName, &api.GetCatalogInfoRequest{})
+}
+
+func (h *httpHandler) getCatalogStatus(w http.ResponseWriter, r *http.Request) {
+	h.serveUnary(w, r, api.Registry_GetCatalogStatus_FullMethodName, &api.GetCatalogStatusRequest{})
+}
+
+func (h *httpHandler) healthz(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.callUnary(r, health.Health_Check_FullMethodName, &health.HealthCheckRequest{Service: r.URL.Query().Get("service")})
+	if err == nil && resp.(*health.HealthCheckResponse).GetStatus() != health.HealthCheckResponse_SERVING {
+		err = status.Errorf(codes.Unavailable, "service status is %s", resp.(*health.HealthCheckResponse).GetStatus())
+	}
+	writeHTTPResponse(w, resp, err)
+}
+
+// pageQuery returns the paging fields of a list request from the pageSize
+// and pageToken query parameters of r.
+func pageQuery(r *http.Request) (int32, string, error) {
+	q := r.URL.Query()
+	var size int64
+	if v := q.Get("pageSize"); v != "" {
+		var err error
+		size, err = strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return 0, "", status.Errorf(codes.InvalidArgument, `invalid "pageSize" query parameter: %v`, err)
+		}
+	}
+	return int32(size), q.Get("pageToken"), nil
+}
+
+// serveUnary writes the response of the unary method to req.
+func (h *httpHandler) serveUnary(w http.ResponseWriter, r *http.Request, fullMethod string, req proto.Message) {
+	resp, err := h.callUnary(r, fullMethod, req)
+	writeHTTPResponse(w, resp, err)
+}
+
+// callUnary calls the unary method with req, through the unary interceptors
+// of h, as a grpc.Server does.
+func (h *httpHandler) callUnary(r *http.Request, fullMethod string, req proto.Message) (proto.Message, error) {
+	m := h.methods[fullMethod]
+	dec := func(in interface{}) error {
+		proto.Merge(in.(proto.Message), req)
+		return nil
+	}
+	resp, err := m.unary(m.srv, rpcContext(r), dec, chainUnaryInterceptors(h.unaryInterceptors))
+	if err != nil {
+		return nil, err
+	}
+	return resp.(proto.Message), nil
+}
+
+// serveStream writes the messages that the server-streaming method sends in
+// response to req, through the stream interceptors of h, as a grpc.Server
+// does.
+func (h *httpHandler) serveStream(w http.ResponseWriter, r *http.Request, fullMethod string, req proto.Message) {
+	m := h.methods[fullMethod]
+	s := &httpStream{w: w, ctx: rpcContext(r), req: req}
+	if pr, ok := req.(pageRequest); ok && isPaged(pr) {
+		// The token of the next page may only be known once the page has
+		// been sent, so the page, which is bounded by its size, is
+		// buffered to write the token in a header.
+		s.buffered = true
+	}
+	info := &grpc.StreamServerInfo{FullMethod: fullMethod, IsServerStream: true}
+	s.finish(chainStreamInterceptors(h.streamInterceptors)(m.srv, s, info, m.stream))
+}
+
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if len(interceptors) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func chainStreamInterceptors(interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// rpcContext returns the context of the gRPC call that r is served as. Its
+// peer is the HTTP client, with the TLS state of the connection, if any, and
+// its incoming metadata are the headers of r, so that clients are identified
+// as they are over gRPC, e.g. by their certificate or by a bearer token.
+func rpcContext(r *http.Request) context.Context {
+	p := &peer.Peer{Addr: httpRemoteAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{
+			State:          *r.TLS,
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		}
+	}
+	md := metadata.MD{}
+	for k, v := range r.Header {
+		md.Append(k, v...)
+	}
+	return metadata.NewIncomingContext(peer.NewContext(r.Context(), p), md)
+}
+
+func httpRemoteAddr(addr string) net.Addr {
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return net.TCPAddrFromAddrPort(ap)
+	}
+	return remoteAddr(addr)
+}
+
+// remoteAddr is the address of an HTTP client that is not an IP address and
+// port.
+type remoteAddr string
+
+func (a remoteAddr) Network() string { return "tcp" }
+func (a remoteAddr) String() string  { return string(a) }
+
+// httpStream adapts a server-streaming RPC to an HTTP response. Messages are
+// written as elements of a JSON array as they are sent, so that large
+// responses are not held in memory, unless the stream is buffered.
+type httpStream struct {
+	w        http.ResponseWriter
+	ctx      context.Context
+	req      proto.Message
+	buffered bool
+
+	started bool
+	buf     bytes.Buffer
+	trailer metadata.MD
+}
+
+var _ grpc.ServerStream = &httpStream{}
+
+func (s *httpStream) SetHeader(metadata.MD) error  { return nil }
+func (s *httpStream) SendHeader(metadata.MD) error { return nil }
+
+func (s *httpStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func (s *httpStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg receives the request of the RPC.
+func (s *httpStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.req)
+	return nil
+}
+
+func (s *httpStream) SendMsg(m interface{}) error {
+	data, err := protojson.Marshal(m.(proto.Message))
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if !s.started {
+		s.started = true
+		sep = "["
+		if !s.buffered {
+			s.w.Header().Set("Content-Type", "application/json")
+			s.w.WriteHeader(http.StatusOK)
+		}
+	}
+	var w io.Writer = s.w
+	if s.buffered {
+		w = &s.buf
+	}
+	if _, err := io.WriteString(w, sep); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// finish completes the response with the result of the streaming RPC.
+func (s *httpStream) finish(err error) {
+	switch {
+	case err != nil && (!s.started || s.buffered):
+		writeHTTPError(s.w, err)
+		return
+	case err != nil:
+		// The status has already been sent, so the only way to signal the
+		// error is to abort the response.
+		panic(http.ErrAbortHandler)
+	case !s.started:
+		s.buf.WriteString("[")
+	}
+	s.buf.WriteString("]")
+	if !s.started || s.buffered {
+		s.w.Header().Set("Content-Type", "application/json")
+		if token := s.trailer.Get(api.NextPageTokenTrailer); len(token) > 0 {
+			s.w.Header().Set(nextPageTokenHeader, token[0])
+		}
+	}
+	_, _ = s.w.Write(s.buf.Bytes())
+}
+
+func writeHTTPResponse(w http.ResponseWriter, m proto.Message, err error) {
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func writeHTTPError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	data, mErr := protojson.Marshal(st.Proto())
+	if mErr != nil {
+		http.Error(w, st.Message(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromCode(st.Code()))
+	_, _ = w.Write(data)
+}
+
+// httpStatusFromCode maps gRPC status codes to HTTP status codes, following
+// https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
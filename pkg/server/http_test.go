@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+type stubRegistryServer struct {
+	api.UnimplementedRegistryServer
+}
+
+func (stubRegistryServer) ListPackages(_ *api.ListPackageRequest, stream api.Registry_ListPackagesServer) error {
+	for _, name := range []string{"bar", "foo"} {
+		if err := stream.Send(&api.PackageName{Name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stubRegistryServer) GetPackage(_ context.Context, req *api.GetPackageRequest) (*api.Package, error) {
+	if req.GetName() != "foo" {
+		return nil, status.Errorf(codes.NotFound, "package %q not found", req.GetName())
+	}
+	return &api.Package{Name: "foo", DefaultChannelName: "stable"}, nil
+}
+
+func (stubRegistryServer) GetBundle(_ context.Context, req *api.GetBundleRequest) (*api.Bundle, error) {
+	return &api.Bundle{PackageName: req.GetPkgName(), ChannelName: req.GetChannelName(), CsvName: req.GetCsvName()}, nil
+}
+
+func (stubRegistryServer) GetChannelEntriesThatProvide(req *api.GetAllProvidersRequest, stream api.Registry_GetChannelEntriesThatProvideServer) error {
+	return nil
+}
+
+func (stubRegistryServer) GetLatestChannelEntriesThatProvide(req *api.GetLatestProvidersRequest, stream api.Registry_GetLatestChannelEntriesThatProvideServer) error {
+	return stream.Send(&api.ChannelEntry{PackageName: "foo", ChannelName: "stable", BundleName: "foo.v1.0.0"})
+}
+
+func TestHTTPHandler(t *testing.T) {
+	type spec struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}
+	specs := []spec{
+		{
+			name:         "ListPackages",
+			path:         "/api/v1/packages",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"name":"bar"},{"name":"foo"}]`,
+		},
+		{
+			name:         "GetPackage",
+			path:         "/api/v1/packages/foo",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"name":"foo","defaultChannelName":"stable"}`,
+		},
+		{
+			name:         "GetPackage/NotFound",
+			path:         "/api/v1/packages/baz",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"code":5,"message":"package \"baz\" not found"}`,
+		},
+		{
+			name:         "GetBundle",
+			path:         "/api/v1/packages/foo/channels/stable/bundles/foo.v1.0.0",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"csvName":"foo.v1.0.0","packageName":"foo","channelName":"stable"}`,
+		},
+		{
+			name:         "GetChannelEntries/Empty",
+			path:         "/api/v1/channelentries?group=g&version=v&kind=k",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+		},
+		{
+			name:         "GetChannelEntries/Latest",
+			path:         "/api/v1/channelentries?group=g&version=v&kind=k&latest=true",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"packageName":"foo","channelName":"stable","bundleName":"foo.v1.0.0"}]`,
+		},
+		{
+			name:         "GetChannelEntries/MissingParameters",
+			path:         "/api/v1/channelentries?group=g",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GetCatalogInfo/Unimplemented",
+			path:         "/api/v1/catalog",
+			expectedCode: http.StatusNotImplemented,
+		},
+		{
+			name:         "Healthz",
+			path:         "/healthz",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"SERVING"}`,
+		},
+	}
+
+	srv := httptest.NewServer(NewHTTPHandler(stubRegistryServer{}, NewHealthServer()))
+	defer srv.Close()
+
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + s.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, s.expectedCode, resp.StatusCode, string(body))
+			require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			if s.expectedBody != "" {
+				require.JSONEq(t, s.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerServesRPCs(t *testing.T) {
+	store := authzStore{packages: []string{"alpha", "beta", "gamma"}}
+	authorizer := tenantAuthorizer(map[string][]string{"b": {"beta", "gamma"}})
+
+	var calls []string
+	record := func(ctx context.Context, fullMethod string) {
+		p, ok := peer.FromContext(ctx)
+		require.True(t, ok)
+		require.NotNil(t, p.Addr)
+		calls = append(calls, fullMethod)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		record(ss.Context(), info.FullMethod)
+		return handler(srv, ss)
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		record(ctx, info.FullMethod)
+		return handler(ctx, req)
+	}
+	srv := httptest.NewServer(NewHTTPHandler(NewRegistryServer(store, WithPackageAuthorizer(authorizer)), NewHealthServer(),
+		WithHTTPInterceptors([]grpc.StreamServerInterceptor{stream}, []grpc.UnaryServerInterceptor{unary})))
+	defer srv.Close()
+
+	get := func(path, tenant string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		if tenant != "" {
+			req.Header.Set("Tenant", tenant)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := get("/api/v1/packages?pageSize=1", "b")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.JSONEq(t, `[{"name":"beta"}]`, body)
+	token := resp.Header.Get("Next-Page-Token")
+	require.NotEmpty(t, token)
+
+	resp, body = get("/api/v1/packages?pageSize=1&pageToken="+token, "b")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.JSONEq(t, `[{"name":"gamma"}]`, body)
+	require.Empty(t, resp.Header.Get("Next-Page-Token"))
+
+	resp, body = get("/api/v1/packages/alpha/channels/stable/bundles/alpha.v1", "b")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, body)
+
+	resp, body = get("/api/v1/packages", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode, body)
+
+	resp, body = get("/api/v1/packages?pageSize=x", "b")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+
+	require.Equal(t, []string{
+		api.Registry_ListPackages_FullMethodName,
+		api.Registry_ListPackages_FullMethodName,
+		api.Registry_GetBundle_FullMethodName,
+		api.Registry_ListPackages_FullMethodName,
+	}, calls)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The schemes of the addresses that Listen accepts, other than TCP.
+const (
+	UnixScheme    = "unix://"
+	SystemdScheme = "fd://"
+)
+
+// listenFDsStart is the first file descriptor that systemd passes to
+// socket-activated services.
+const listenFDsStart = 3
+
+// Listen returns a listener for a registry server on addr, which is one of:
+//   - a TCP address, e.g. ":50051" or "localhost:50051", optionally prefixed
+//     with tcp://
+//   - unix:///path/to/registry.sock, a unix domain socket. A socket file
+//     that is left at the path, e.g. by a server that was killed, is
+//     replaced, and the file is removed when the listener is closed.
+//   - fd:// for systemd socket activation, which serves on the first socket
+//     that systemd passes, or fd://<name> for the socket named <name> with
+//     FileDescriptorName= in its socket unit.
+func Listen(addr string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, UnixScheme):
+		return listenUnix(strings.TrimPrefix(addr, UnixScheme))
+	case strings.HasPrefix(addr, SystemdScheme):
+		return listenSystemd(strings.TrimPrefix(addr, SystemdScheme))
+	default:
+		return net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+	}
+}
+
+// ListenAddress returns the address to pass to Listen for a server that is
+// configured with both a --listen address and a --port, which listen takes
+// precedence over.
+func ListenAddress(listen, port string) string {
+	if listen != "" {
+		return listen
+	}
+	return ":" + port
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path must be set")
+	}
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("listen on unix socket %q: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale unix socket %q: %v", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// listenSystemd returns a listener for a socket that systemd passed to the
+// process, as described in sd_listen_fds(3): the sockets are the file
+// descriptors from 3 up, LISTEN_FDS is their number, LISTEN_FDNAMES their
+// colon-separated names, and LISTEN_PID the process they are passed to.
+func listenSystemd(name string) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets passed by systemd socket activation: LISTEN_PID is not set to the pid of this process")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("no sockets passed by systemd socket activation: LISTEN_FDS is not set")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	i := 0
+	if name != "" {
+		i = -1
+		for j := 0; j < n && j < len(names); j++ {
+			if names[j] == name {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("no socket named %q passed by systemd socket activation (LISTEN_FDNAMES=%q)", name, os.Getenv("LISTEN_FDNAMES"))
+		}
+	}
+
+	f := os.NewFile(uintptr(listenFDsStart+i), "LISTEN_FD_"+strconv.Itoa(listenFDsStart+i))
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("listen on socket passed by systemd socket activation: %v", err)
+	}
+	return l, nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen(t *testing.T) {
+	t.Run("TCP", func(t *testing.T) {
+		for _, addr := range []string{"127.0.0.1:0", "tcp://127.0.0.1:0"} {
+			l, err := Listen(addr)
+			require.NoError(t, err)
+			require.Equal(t, "tcp", l.Addr().Network())
+			require.NoError(t, l.Close())
+		}
+	})
+
+	t.Run("Unix", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "registry.sock")
+		l, err := Listen("unix://" + path)
+		require.NoError(t, err)
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		require.NoError(t, l.Close())
+		require.NoFileExists(t, path)
+	})
+
+	t.Run("UnixStaleSocket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "registry.sock")
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+		require.FileExists(t, path)
+
+		l, err := Listen("unix://" + path)
+		require.NoError(t, err)
+		require.NoError(t, l.Close())
+	})
+
+	t.Run("UnixNotASocket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "registry.sock")
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+		_, err := Listen("unix://" + path)
+		require.ErrorContains(t, err, "file exists and is not a socket")
+	})
+
+	t.Run("SystemdNotActivated", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", "")
+		_, err := Listen("fd://")
+		require.ErrorContains(t, err, "LISTEN_PID is not set to the pid of this process")
+
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		t.Setenv("LISTEN_FDS", "")
+		_, err = Listen("fd://")
+		require.ErrorContains(t, err, "LISTEN_FDS is not set")
+	})
+
+	t.Run("SystemdUnknownName", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		t.Setenv("LISTEN_FDS", "1")
+		t.Setenv("LISTEN_FDNAMES", "http")
+		_, err := Listen("fd://grpc")
+		require.ErrorContains(t, err, `no socket named "grpc"`)
+	})
+}
+
+func TestListenAddress(t *testing.T) {
+	require.Equal(t, ":50051", ListenAddress("", "50051"))
+	require.Equal(t, "unix:///run/registry.sock", ListenAddress("unix:///run/registry.sock", "50051"))
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// packagePageToken is the cursor of a page of packages.
+type packagePageToken struct {
+	After string `json:"after"`
+}
+
+// pageRequest is implemented by the requests of paged list RPCs.
+type pageRequest interface {
+	GetPageSize() int32
+	GetPageToken() string
+}
+
+// isPaged reports whether req asks for a page. Requests without paging
+// fields list everything, as they did before paging was supported.
+func isPaged(req pageRequest) bool {
+	return req.GetPageSize() != 0 || req.GetPageToken() != ""
+}
+
+// decodePageToken validates the paging fields of req, and decodes its page
+// token, if it has one, into cursor. It reports whether there was a token.
+func decodePageToken(req pageRequest, cursor any) (bool, error) {
+	switch {
+	case req.GetPageSize() < 0:
+		return false, status.Errorf(codes.InvalidArgument, "invalid page size %d: must not be negative", req.GetPageSize())
+	case req.GetPageSize() == 0:
+		return false, status.Error(codes.InvalidArgument, "a page token requires a page size")
+	case req.GetPageToken() == "":
+		return false, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(req.GetPageToken())
+	if err == nil {
+		err = json.Unmarshal(data, cursor)
+	}
+	if err != nil {
+		return false, status.Errorf(codes.InvalidArgument, "invalid page token %q", req.GetPageToken())
+	}
+	return true, nil
+}
+
+// setNextPageToken sets the trailer that holds the token of the page that
+// follows cursor.
+func setNextPageToken(stream grpc.ServerStream, cursor any) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	stream.SetTrailer(metadata.Pairs(api.NextPageTokenTrailer, base64.RawURLEncoding.EncodeToString(data)))
+	return nil
+}
+
+func (s *RegistryServer) listPackagesPage(req *api.ListPackageRequest, stream api.Registry_ListPackagesServer, packageNames []string) error {
+	var token packagePageToken
+	if _, err := decodePageToken(req, &token); err != nil {
+		return err
+	}
+	sort.Strings(packageNames)
+	start := sort.SearchStrings(packageNames, token.After)
+	if start < len(packageNames) && packageNames[start] == token.After {
+		start++
+	}
+	page := packageNames[start:]
+	if len(page) > int(req.GetPageSize()) {
+		page = page[:req.GetPageSize()]
+		if err := setNextPageToken(stream, packagePageToken{After: page[len(page)-1]}); err != nil {
+			return err
+		}
+	}
+	for _, p := range page {
+		if err := stream.Send(&api.PackageName{Name: p}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RegistryServer) listBundlesPage(req *api.ListBundlesRequest, stream api.Registry_ListBundlesServer, filter *registry.BundleFilter) error {
+	var (
+		cursor registry.BundleCursor
+		after  *registry.BundleCursor
+	)
+	hasToken, err := decodePageToken(req, &cursor)
+	if err != nil {
+		return err
+	}
+	if hasToken {
+		after = &cursor
+	}
+	sender := s.packageFilter(stream.Context()).bundleSender(stream)
+	limit := int(req.GetPageSize())
+
+	var next *registry.BundleCursor
+	if fq, ok := s.store.(registry.FilteredBundleQuery); ok && filter != nil {
+		next, err = fq.SendFilteredBundles(stream.Context(), sender, *filter, after, limit)
+	} else {
+		if filter != nil {
+			sender = registry.FilteringBundleSender{BundleSender: sender, Filter: *filter}
+		}
+		if pq, ok := s.store.(registry.PagedBundleQuery); ok {
+			next, err = pq.SendBundlesPage(stream.Context(), sender, after, limit)
+		} else {
+			next, err = sendBundlesPage(stream.Context(), s.store, sender, after, limit)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		return setNextPageToken(stream, next)
+	}
+	return nil
+}
+
+// sendBundlesPage pages the bundles of stores that do not implement
+// registry.PagedBundleQuery, by reading all of their bundles.
+func sendBundlesPage(ctx context.Context, store registry.GRPCQuery, stream registry.BundleSender, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	bundles, err := store.ListBundles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cursor := func(b *api.Bundle) registry.BundleCursor {
+		return registry.BundleCursor{PackageName: b.GetPackageName(), ChannelName: b.GetChannelName(), CsvName: b.GetCsvName()}
+	}
+	sort.Slice(bundles, func(i, j int) bool { return cursor(bundles[i]).Less(cursor(bundles[j])) })
+	start := sort.Search(len(bundles), func(i int) bool { return after == nil || after.Less(cursor(bundles[i])) })
+	page := bundles[start:]
+	var next *registry.BundleCursor
+	if len(page) > limit {
+		page = page[:limit]
+		last := cursor(page[limit-1])
+		next = &last
+	}
+	for _, b := range page {
+		if err := stream.Send(b); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
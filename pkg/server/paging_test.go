@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	registryclient "github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestListPackagesPaged(t *testing.T) {
+	for name, addr := range map[string]string{"Sqlite": dbAddress, "FBCCache": cacheAddress} {
+		t.Run(name, func(t *testing.T) {
+			_, conn := client(t, addr)
+			defer conn.Close()
+			c := registryclient.NewClientFromConn(conn)
+
+			names, token, err := c.ListPackagesPage(context.TODO(), 2, "")
+			require.NoError(t, err)
+			require.Equal(t, []string{"etcd", "prometheus"}, names)
+			require.NotEmpty(t, token)
+
+			names, token, err = c.ListPackagesPage(context.TODO(), 2, token)
+			require.NoError(t, err)
+			require.Equal(t, []string{"strimzi-kafka-operator"}, names)
+			require.Empty(t, token)
+		})
+	}
+}
+
+func TestListBundlesPaged(t *testing.T) {
+	for name, addr := range map[string]string{"Sqlite": dbAddress, "FBCCache": cacheAddress} {
+		t.Run(name, func(t *testing.T) {
+			_, conn := client(t, addr)
+			defer conn.Close()
+			testListBundlesPaged(t, registryclient.NewClientFromConn(conn))
+		})
+	}
+
+	t.Run("UnpagedStore", func(t *testing.T) {
+		_, conn := client(t, dbAddress)
+		defer conn.Close()
+		bundles, err := registryclient.NewClientFromConn(conn).Registry.ListBundles(context.TODO(), &api.ListBundlesRequest{})
+		require.NoError(t, err)
+		var all []*api.Bundle
+		for {
+			b, err := bundles.Recv()
+			if err != nil {
+				break
+			}
+			all = append(all, b)
+		}
+
+		// The store only implements ListBundles, so the server pages the
+		// bundles itself.
+		lis := bufconn.Listen(1 << 20)
+		s := grpc.NewServer()
+		api.RegisterRegistryServer(s, NewRegistryServer(unpagedStore{bundles: all}))
+		go func() { _ = s.Serve(lis) }()
+		defer s.Stop()
+		bufConn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		require.NoError(t, err)
+		defer bufConn.Close()
+		testListBundlesPaged(t, registryclient.NewClientFromConn(bufConn))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, conn := client(t, dbAddress)
+		defer conn.Close()
+		c := registryclient.NewClientFromConn(conn)
+
+		_, _, err := c.ListBundlesPage(context.TODO(), -1, "")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, _, err = c.ListBundlesPage(context.TODO(), 0, "token")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, _, err = c.ListBundlesPage(context.TODO(), 2, "not a token")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, _, err = c.ListPackagesPage(context.TODO(), 2, "not a token")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// testListBundlesPaged lists the bundles served to c in pages, and checks
+// that they are those of an unpaged listing, in cursor order.
+func testListBundlesPaged(t *testing.T, c *registryclient.Client) {
+	t.Helper()
+	it, err := c.ListBundles(context.TODO())
+	require.NoError(t, err)
+	var expected []registry.BundleCursor
+	for b := it.Next(); b != nil; b = it.Next() {
+		expected = append(expected, bundleCursor(b))
+	}
+	require.NoError(t, it.Error())
+	sort.Slice(expected, func(i, j int) bool { return expected[i].Less(expected[j]) })
+
+	var (
+		actual []registry.BundleCursor
+		token  string
+		pages  int
+	)
+	for {
+		bundles, next, err := c.ListBundlesPage(context.TODO(), 3, token)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(bundles), 3)
+		for _, b := range bundles {
+			actual = append(actual, bundleCursor(b))
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	require.Equal(t, expected, actual)
+	require.Equal(t, (len(expected)+2)/3, pages)
+}
+
+func bundleCursor(b *api.Bundle) registry.BundleCursor {
+	return registry.BundleCursor{PackageName: b.GetPackageName(), ChannelName: b.GetChannelName(), CsvName: b.GetCsvName()}
+}
+
+// unpagedStore is a store that does not implement registry.PagedBundleQuery.
+type unpagedStore struct {
+	registry.GRPCQuery
+	bundles []*api.Bundle
+}
+
+func (s unpagedStore) ListBundles(context.Context) ([]*api.Bundle, error) {
+	return s.bundles, nil
+}
+
+func (s unpagedStore) SendBundles(_ context.Context, stream registry.BundleSender) error {
+	for _, b := range s.bundles {
+		if err := stream.Send(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// RateLimits bounds the calls of the Registry API that a server handles, so
+// that a single client cannot exhaust the CPU of a registry, e.g. with a
+// storm of ListBundles calls. A stream counts as one call, which is active
+// until its handler returns. A zero value disables a limit.
+type RateLimits struct {
+	// Rate is the number of calls per second that are allowed across all
+	// clients, and Burst the number of calls above Rate that are allowed at
+	// once. A Burst of zero defaults to Rate, rounded up.
+	Rate  float64
+	Burst int
+	// ClientRate and ClientBurst are like Rate and Burst, for the calls of
+	// each client, as identified by its IP address.
+	ClientRate  float64
+	ClientBurst int
+	// MaxConcurrent is the number of calls that may be active at once across
+	// all clients, and MaxConcurrentPerClient the number for each client.
+	MaxConcurrent          int
+	MaxConcurrentPerClient int
+}
+
+// RecommendedRateLimits are limits for a registry that serves a catalog pod
+// with the modest CPU and memory of a typical catalog source. They are far
+// above the load of the clients of a cluster, e.g. the catalog operator of
+// OLM, and packageserver, but bound the load of a runaway client. Limits are
+// opt-in: the zero RateLimits, which servers use unless told otherwise,
+// disables all of them.
+var RecommendedRateLimits = RateLimits{
+	Rate:                   200,
+	Burst:                  400,
+	ClientRate:             50,
+	ClientBurst:            100,
+	MaxConcurrent:          64,
+	MaxConcurrentPerClient: 16,
+}
+
+// clientIdleTimeout is how long the limiter of a client that has no active
+// calls is kept after its last call.
+const clientIdleTimeout = 5 * time.Minute
+
+var (
+	errRateLimited        = status.Error(codes.ResourceExhausted, "rate limit exceeded, retry later")
+	errClientRateLimited  = status.Error(codes.ResourceExhausted, "client rate limit exceeded, retry later")
+	errTooManyCalls       = status.Error(codes.ResourceExhausted, "too many concurrent requests, retry later")
+	errTooManyClientCalls = status.Error(codes.ResourceExhausted, "too many concurrent requests from client, retry later")
+)
+
+type rateLimiter struct {
+	limits RateLimits
+	now    func() time.Time
+
+	mu        sync.Mutex
+	global    *rate.Limiter
+	active    int
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	active   int
+	lastSeen time.Time
+}
+
+func newRateLimiter(limits RateLimits, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		limits:    limits,
+		now:       now,
+		global:    newLimiter(limits.Rate, limits.Burst),
+		clients:   map[string]*clientLimiter{},
+		lastSweep: now(),
+	}
+}
+
+func newLimiter(r float64, burst int) *rate.Limiter {
+	if r == 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	if burst == 0 {
+		burst = int(math.Ceil(r))
+	}
+	return rate.NewLimiter(rate.Limit(r), burst)
+}
+
+// acquire admits a call of client, and returns a function that must be
+// called when the call is done, or a codes.ResourceExhausted error if a
+// limit is exceeded. Calls are rejected rather than queued, so that a
+// registry under load does not accumulate waiting calls.
+func (l *rateLimiter) acquire(client string) (func(), error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientLimiter{limiter: newLimiter(l.limits.ClientRate, l.limits.ClientBurst)}
+		l.clients[client] = c
+	}
+	c.lastSeen = now
+
+	switch {
+	case l.limits.MaxConcurrentPerClient > 0 && c.active >= l.limits.MaxConcurrentPerClient:
+		return nil, errTooManyClientCalls
+	case l.limits.MaxConcurrent > 0 && l.active >= l.limits.MaxConcurrent:
+		return nil, errTooManyCalls
+	case !c.limiter.AllowN(now, 1):
+		return nil, errClientRateLimited
+	case !l.global.AllowN(now, 1):
+		return nil, errRateLimited
+	}
+	l.active++
+	c.active++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.active--
+			c.active--
+		})
+	}, nil
+}
+
+// sweep forgets the clients that have been idle for clientIdleTimeout, so
+// that the limiters of clients that come and go do not accumulate.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < clientIdleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for id, c := range l.clients {
+		if c.active == 0 && now.Sub(c.lastSeen) >= clientIdleTimeout {
+			delete(l.clients, id)
+		}
+	}
+}
+
+// clientID identifies the client of a call by its IP address.
+func clientID(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+// limited reports whether a call of the full gRPC method is subject to rate
+// limits. Only the Registry API is, so that health checks and the Admin API
+// are not rejected when a registry is under load.
+func limited(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+api.Registry_ServiceDesc.ServiceName+"/")
+}
+
+// RateLimitInterceptors returns interceptors that reject the calls of the
+// Registry API that exceed limits with codes.ResourceExhausted. Calls of
+// other services, e.g. health checks, are let through, as are all calls if
+// limits is the zero RateLimits.
+func RateLimitInterceptors(limits RateLimits) (grpc.StreamServerInterceptor, grpc.UnaryServerInterceptor, error) {
+	if limits.Rate < 0 || limits.Burst < 0 || limits.ClientRate < 0 || limits.ClientBurst < 0 ||
+		limits.MaxConcurrent < 0 || limits.MaxConcurrentPerClient < 0 {
+		return nil, nil, errors.New("rate limits must not be negative")
+	}
+	// With no limits, calls are not tracked at all, so that they do not
+	// contend on the lock of the limiter.
+	disabled := limits == RateLimits{}
+	l := newRateLimiter(limits, time.Now)
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if disabled || !limited(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		release, err := l.acquire(clientID(ss.Context()))
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if disabled || !limited(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		release, err := l.acquire(clientID(ctx))
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+	return stream, unary, nil
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := func() time.Time { return now }
+	requireCode := func(t *testing.T, err error, code codes.Code) {
+		t.Helper()
+		require.Equal(t, code, status.Code(err), "%v", err)
+	}
+
+	t.Run("ClientRate", func(t *testing.T) {
+		l := newRateLimiter(RateLimits{ClientRate: 1, ClientBurst: 2}, clock)
+		for i := 0; i < 2; i++ {
+			release, err := l.acquire("a")
+			require.NoError(t, err)
+			release()
+		}
+		_, err := l.acquire("a")
+		requireCode(t, err, codes.ResourceExhausted)
+		require.ErrorIs(t, err, errClientRateLimited)
+
+		// Other clients have their own limit.
+		_, err = l.acquire("b")
+		require.NoError(t, err)
+
+		now = now.Add(time.Second)
+		_, err = l.acquire("a")
+		require.NoError(t, err)
+	})
+
+	t.Run("GlobalRate", func(t *testing.T) {
+		l := newRateLimiter(RateLimits{Rate: 2}, clock)
+		_, err := l.acquire("a")
+		require.NoError(t, err)
+		_, err = l.acquire("b")
+		require.NoError(t, err)
+		_, err = l.acquire("c")
+		require.ErrorIs(t, err, errRateLimited)
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		l := newRateLimiter(RateLimits{MaxConcurrent: 3, MaxConcurrentPerClient: 2}, clock)
+		releaseA1, err := l.acquire("a")
+		require.NoError(t, err)
+		_, err = l.acquire("a")
+		require.NoError(t, err)
+		_, err = l.acquire("a")
+		require.ErrorIs(t, err, errTooManyClientCalls)
+
+		_, err = l.acquire("b")
+		require.NoError(t, err)
+		_, err = l.acquire("c")
+		require.ErrorIs(t, err, errTooManyCalls)
+
+		// Releasing a call is idempotent, and frees one slot.
+		releaseA1()
+		releaseA1()
+		_, err = l.acquire("c")
+		require.NoError(t, err)
+		_, err = l.acquire("c")
+		require.ErrorIs(t, err, errTooManyCalls)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		l := newRateLimiter(RateLimits{}, clock)
+		for i := 0; i < 1000; i++ {
+			_, err := l.acquire("a")
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("SweepIdleClients", func(t *testing.T) {
+		l := newRateLimiter(RecommendedRateLimits, clock)
+		releaseA, err := l.acquire("a")
+		require.NoError(t, err)
+		releaseA()
+		_, err = l.acquire("b")
+		require.NoError(t, err)
+
+		now = now.Add(clientIdleTimeout)
+		_, err = l.acquire("c")
+		require.NoError(t, err)
+		// a is idle, but b still has an active call.
+		require.NotContains(t, l.clients, "a")
+		require.Contains(t, l.clients, "b")
+		require.Contains(t, l.clients, "c")
+	})
+}
+
+func TestRateLimitInterceptors(t *testing.T) {
+	_, _, err := RateLimitInterceptors(RateLimits{ClientRate: -1})
+	require.Error(t, err)
+
+	_, unary, err := RateLimitInterceptors(RateLimits{ClientRate: 1, ClientBurst: 1})
+	require.NoError(t, err)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	call := func(ip, method string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 12345}})
+		_, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	require.NoError(t, call("10.0.0.1", api.Registry_ListPackages_FullMethodName))
+	err = call("10.0.0.1", api.Registry_GetPackage_FullMethodName)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Clients are identified by IP address, not by connection.
+	require.NoError(t, call("10.0.0.2", api.Registry_ListPackages_FullMethodName))
+
+	// Other services are not limited.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, call("10.0.0.1", "/grpc.health.v1.Health/Check"))
+	}
+
+	// The zero RateLimits disables rate limiting.
+	_, unary, err = RateLimitInterceptors(RateLimits{})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, call("10.0.0.1", api.Registry_ListPackages_FullMethodName))
+	}
+}
+
+func TestRateLimitInterceptorsHTTP(t *testing.T) {
+	stream, unary, err := RateLimitInterceptors(RateLimits{ClientRate: 1, ClientBurst: 1})
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewHTTPHandler(stubRegistryServer{}, NewHealthServer(),
+		WithHTTPInterceptors([]grpc.StreamServerInterceptor{stream}, []grpc.UnaryServerInterceptor{unary})))
+	defer srv.Close()
+
+	statusCode := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, statusCode("/api/v1/packages"))
+	require.Equal(t, http.StatusTooManyRequests, statusCode("/api/v1/packages/foo"))
+	require.Equal(t, http.StatusOK, statusCode("/healthz"))
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+// Readiness tracks the state of the catalog that a registry serves, so that
+// the registry can accept connections while its catalog is still loading:
+// its health check does not report SERVING, and the queries of the Registry
+// API are rejected as unavailable, until SetServing is called. A Readiness
+// starts in the LOADING state.
+//
+// The states are:
+//
+//	LOADING  -> SERVING   the catalog has been loaded
+//	SERVING  -> DEGRADED  a reload of the catalog failed, and the previously
+//	                      loaded catalog continues to be served
+//	DEGRADED -> SERVING   a reload of the catalog succeeded
+//
+// A Readiness never returns to LOADING.
+type Readiness struct {
+	mu      sync.RWMutex
+	state   api.CatalogStatus_State
+	message string
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{state: api.CatalogStatus_LOADING}
+}
+
+// SetServing records that the catalog has been loaded, or reloaded.
+func (r *Readiness) SetServing() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state, r.message = api.CatalogStatus_SERVING, ""
+}
+
+// SetDegraded records that a reload of the catalog failed, and why. It has
+// no effect while the catalog is loading, as there is no catalog to fall
+// back to.
+func (r *Readiness) SetDegraded(message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == api.CatalogStatus_LOADING {
+		return
+	}
+	r.state, r.message = api.CatalogStatus_DEGRADED, message
+}
+
+// State returns the state of the catalog, and why it is degraded, if it is.
+func (r *Readiness) State() (api.CatalogStatus_State, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.state, r.message
+}
+
+func (r *Readiness) loading() bool {
+	state, _ := r.State()
+	return state == api.CatalogStatus_LOADING
+}
+
+// errCatalogLoading is returned by the queries of the Registry API while
+// the catalog is loading.
+var errCatalogLoading = status.Error(codes.Unavailable, "catalog is loading")
+
+// gated reports whether a call of the full gRPC method is rejected while the
+// catalog is loading. GetCatalogStatus is not, so that clients can follow
+// the progress of the registry.
+func gated(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+api.Registry_ServiceDesc.ServiceName+"/") &&
+		fullMethod != api.Registry_GetCatalogStatus_FullMethodName
+}
+
+// Interceptors returns interceptors that reject the calls of the Registry
+// API, other than GetCatalogStatus, with codes.Unavailable while the catalog
+// is loading. Calls of other services, e.g. health checks, are let through.
+func (r *Readiness) Interceptors() (grpc.StreamServerInterceptor, grpc.UnaryServerInterceptor) {
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if gated(info.FullMethod) && r.loading() {
+			return errCatalogLoading
+		}
+		return handler(srv, ss)
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if gated(info.FullMethod) && r.loading() {
+			return nil, errCatalogLoading
+		}
+		return handler(ctx, req)
+	}
+	return stream, unary
+}
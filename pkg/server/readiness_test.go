@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// checksumStore serves the packages of its checksums.
+type checksumStore struct {
+	registry.GRPCQuery
+	checksums map[string]string
+}
+
+func (s checksumStore) ListPackages(context.Context) ([]string, error) {
+	var names []string
+	for name := range s.checksums {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (s checksumStore) GetPackageChecksums(context.Context) (map[string]string, error) {
+	return s.checksums, nil
+}
+
+func TestReadiness(t *testing.T) {
+	store := checksumStore{checksums: map[string]string{"alpha": "sha256:a", "beta": "sha256:b"}}
+	readiness := NewReadiness()
+
+	lis := bufconn.Listen(1 << 20)
+	stream, unary := readiness.Interceptors()
+	s := grpc.NewServer(grpc.ChainStreamInterceptor(stream), grpc.ChainUnaryInterceptor(unary))
+	api.RegisterRegistryServer(s, NewRegistryServer(store, WithReadiness(readiness)))
+	health.RegisterHealthServer(s, NewHealthServer(WithHealthReadiness(readiness)))
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	c := api.NewRegistryClient(conn)
+	h := health.NewHealthClient(conn)
+	ctx := context.Background()
+
+	requireState := func(t *testing.T, state api.CatalogStatus_State, healthStatus health.HealthCheckResponse_ServingStatus) *api.CatalogStatus {
+		t.Helper()
+		resp, err := h.Check(ctx, &health.HealthCheckRequest{})
+		require.NoError(t, err)
+		require.Equal(t, healthStatus, resp.GetStatus())
+		catalogStatus, err := c.GetCatalogStatus(ctx, &api.GetCatalogStatusRequest{})
+		require.NoError(t, err)
+		require.Equal(t, state, catalogStatus.GetState())
+		return catalogStatus
+	}
+
+	t.Run("Loading", func(t *testing.T) {
+		catalogStatus := requireState(t, api.CatalogStatus_LOADING, health.HealthCheckResponse_NOT_SERVING)
+		require.Empty(t, catalogStatus.GetDigest())
+
+		_, err := c.GetPackage(ctx, &api.GetPackageRequest{Name: "alpha"})
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		packages, err := c.ListPackages(ctx, &api.ListPackageRequest{})
+		require.NoError(t, err)
+		_, err = packages.Recv()
+		require.Equal(t, codes.Unavailable, status.Code(err))
+
+		// There is no catalog to fall back to while loading.
+		readiness.SetDegraded("reload failed")
+		requireState(t, api.CatalogStatus_LOADING, health.HealthCheckResponse_NOT_SERVING)
+	})
+
+	var digest string
+	t.Run("Serving", func(t *testing.T) {
+		readiness.SetServing()
+		catalogStatus := requireState(t, api.CatalogStatus_SERVING, health.HealthCheckResponse_SERVING)
+		require.Equal(t, CatalogDigest(store.checksums), catalogStatus.GetDigest())
+		require.Regexp(t, "^sha256:[0-9a-f]{64}$", catalogStatus.GetDigest())
+		digest = catalogStatus.GetDigest()
+
+		packages, err := c.ListPackages(ctx, &api.ListPackageRequest{})
+		require.NoError(t, err)
+		_, err = packages.Recv()
+		require.NoError(t, err)
+	})
+
+	t.Run("Degraded", func(t *testing.T) {
+		readiness.SetDegraded("reload failed")
+		catalogStatus := requireState(t, api.CatalogStatus_DEGRADED, health.HealthCheckResponse_SERVING)
+		require.Equal(t, "reload failed", catalogStatus.GetMessage())
+		require.Equal(t, digest, catalogStatus.GetDigest())
+
+		readiness.SetServing()
+		catalogStatus = requireState(t, api.CatalogStatus_SERVING, health.HealthCheckResponse_SERVING)
+		require.Empty(t, catalogStatus.GetMessage())
+	})
+}
+
+func TestReadinessHTTP(t *testing.T) {
+	readiness := NewReadiness()
+	registryServer := NewRegistryServer(checksumStore{checksums: map[string]string{"foo": "sha256:f"}}, WithReadiness(readiness))
+	stream, unary := readiness.Interceptors()
+	srv := httptest.NewServer(NewHTTPHandler(registryServer, NewHealthServer(WithHealthReadiness(readiness)),
+		WithHTTPInterceptors([]grpc.StreamServerInterceptor{stream}, []grpc.UnaryServerInterceptor{unary})))
+	defer srv.Close()
+
+	statusCode := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, statusCode("/api/v1/packages"))
+	require.Equal(t, http.StatusServiceUnavailable, statusCode("/healthz"))
+	require.Equal(t, http.StatusOK, statusCode("/api/v1/catalog/status"))
+
+	readiness.SetServing()
+	require.Equal(t, http.StatusOK, statusCode("/api/v1/packages"))
+	require.Equal(t, http.StatusOK, statusCode("/healthz"))
+}
+
+func TestCatalogDigest(t *testing.T) {
+	a := CatalogDigest(map[string]string{"alpha": "1", "beta": "2"})
+	require.Equal(t, a, CatalogDigest(map[string]string{"beta": "2", "alpha": "1"}))
+	require.NotEqual(t, a, CatalogDigest(map[string]string{"alpha": "1", "beta": "3"}))
+	require.NotEqual(t, a, CatalogDigest(map[string]string{"alpha": "1"}))
+}
@@ -2,20 +2,47 @@ package server
 
 import (
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
 type RegistryServer struct {
 	api.UnimplementedRegistryServer
-	store registry.GRPCQuery
+	store      registry.GRPCQuery
+	authorizer PackageAuthorizer
+	readiness  *Readiness
 }
 
 var _ api.RegistryServer = &RegistryServer{}
 
-func NewRegistryServer(store registry.GRPCQuery) *RegistryServer {
-	return &RegistryServer{UnimplementedRegistryServer: api.UnimplementedRegistryServer{}, store: store}
+type RegistryServerOption func(*RegistryServer)
+
+// WithPackageAuthorizer limits the packages that the server returns to each
+// caller to those that authorizer allows.
+func WithPackageAuthorizer(authorizer PackageAuthorizer) RegistryServerOption {
+	return func(s *RegistryServer) {
+		s.authorizer = authorizer
+	}
+}
+
+// WithReadiness reports the state of readiness in GetCatalogStatus. Without
+// it, the catalog is reported as SERVING.
+func WithReadiness(readiness *Readiness) RegistryServerOption {
+	return func(s *RegistryServer) {
+		s.readiness = readiness
+	}
+}
+
+func NewRegistryServer(store registry.GRPCQuery, opts ...RegistryServerOption) *RegistryServer {
+	s := &RegistryServer{UnimplementedRegistryServer: api.UnimplementedRegistryServer{}, store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Registry_ListPackagesServer) error {
@@ -23,6 +50,13 @@ func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Re
 	if err != nil {
 		return err
 	}
+	packageNames, err = s.packageFilter(stream.Context()).packageNames(packageNames)
+	if err != nil {
+		return err
+	}
+	if isPaged(req) {
+		return s.listPackagesPage(req, stream, packageNames)
+	}
 	for _, p := range packageNames {
 		if err := stream.Send(&api.PackageName{Name: p}); err != nil {
 			return err
@@ -33,10 +67,28 @@ func (s *RegistryServer) ListPackages(req *api.ListPackageRequest, stream api.Re
 }
 
 func (s *RegistryServer) ListBundles(req *api.ListBundlesRequest, stream api.Registry_ListBundlesServer) error {
-	return s.store.SendBundles(stream.Context(), stream)
+	filter, err := registry.BundleFilterFromRequest(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if isPaged(req) {
+		return s.listBundlesPage(req, stream, filter)
+	}
+	sender := s.packageFilter(stream.Context()).bundleSender(stream)
+	if filter == nil {
+		return s.store.SendBundles(stream.Context(), sender)
+	}
+	if fq, ok := s.store.(registry.FilteredBundleQuery); ok {
+		_, err := fq.SendFilteredBundles(stream.Context(), sender, *filter, nil, 0)
+		return err
+	}
+	return s.store.SendBundles(stream.Context(), registry.FilteringBundleSender{BundleSender: sender, Filter: *filter})
 }
 
 func (s *RegistryServer) GetPackage(ctx context.Context, req *api.GetPackageRequest) (*api.Package, error) {
+	if err := s.packageFilter(ctx).check(req.GetName()); err != nil {
+		return nil, err
+	}
 	packageManifest, err := s.store.GetPackage(ctx, req.GetName())
 	if err != nil {
 		return nil, err
@@ -45,10 +97,16 @@ func (s *RegistryServer) GetPackage(ctx context.Context, req *api.GetPackageRequ
 }
 
 func (s *RegistryServer) GetBundle(ctx context.Context, req *api.GetBundleRequest) (*api.Bundle, error) {
+	if err := s.packageFilter(ctx).check(req.GetPkgName()); err != nil {
+		return nil, err
+	}
 	return s.store.GetBundle(ctx, req.GetPkgName(), req.GetChannelName(), req.GetCsvName())
 }
 
 func (s *RegistryServer) GetBundleForChannel(ctx context.Context, req *api.GetBundleInChannelRequest) (*api.Bundle, error) {
+	if err := s.packageFilter(ctx).check(req.GetPkgName()); err != nil {
+		return nil, err
+	}
 	return s.store.GetBundleForChannel(ctx, req.GetPkgName(), req.GetChannelName())
 }
 
@@ -57,6 +115,10 @@ func (s *RegistryServer) GetChannelEntriesThatReplace(req *api.GetAllReplacement
 	if err != nil {
 		return err
 	}
+	channelEntries, err = s.packageFilter(stream.Context()).channelEntries(channelEntries)
+	if err != nil {
+		return err
+	}
 	for _, e := range channelEntries {
 		if err := stream.Send(registry.ChannelEntryToAPIChannelEntry(e)); err != nil {
 			return err
@@ -66,6 +128,9 @@ func (s *RegistryServer) GetChannelEntriesThatReplace(req *api.GetAllReplacement
 }
 
 func (s *RegistryServer) GetBundleThatReplaces(ctx context.Context, req *api.GetReplacementRequest) (*api.Bundle, error) {
+	if err := s.packageFilter(ctx).check(req.GetPkgName()); err != nil {
+		return nil, err
+	}
 	return s.store.GetBundleThatReplaces(ctx, req.GetCsvName(), req.GetPkgName(), req.GetChannelName())
 }
 
@@ -74,6 +139,10 @@ func (s *RegistryServer) GetChannelEntriesThatProvide(req *api.GetAllProvidersRe
 	if err != nil {
 		return err
 	}
+	channelEntries, err = s.packageFilter(stream.Context()).channelEntries(channelEntries)
+	if err != nil {
+		return err
+	}
 	for _, e := range channelEntries {
 		if err := stream.Send(registry.ChannelEntryToAPIChannelEntry(e)); err != nil {
 			return err
@@ -87,6 +156,10 @@ func (s *RegistryServer) GetLatestChannelEntriesThatProvide(req *api.GetLatestPr
 	if err != nil {
 		return err
 	}
+	channelEntries, err = s.packageFilter(stream.Context()).channelEntries(channelEntries)
+	if err != nil {
+		return err
+	}
 	for _, e := range channelEntries {
 		if err := stream.Send(registry.ChannelEntryToAPIChannelEntry(e)); err != nil {
 			return err
@@ -96,5 +169,168 @@ func (s *RegistryServer) GetLatestChannelEntriesThatProvide(req *api.GetLatestPr
 }
 
 func (s *RegistryServer) GetDefaultBundleThatProvides(ctx context.Context, req *api.GetDefaultProviderRequest) (*api.Bundle, error) {
-	return s.store.GetBundleThatProvides(ctx, req.GetGroup(), req.GetVersion(), req.GetKind())
+	filter := s.packageFilter(ctx)
+	b, err := s.store.GetBundleThatProvides(ctx, req.GetGroup(), req.GetVersion(), req.GetKind())
+	if err != nil || !filter.enabled() {
+		return b, err
+	}
+	allowed, err := filter.allowed(b.GetPackageName())
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
+		return b, nil
+	}
+	return s.visibleBundleThatProvides(ctx, filter, req.GetGroup(), req.GetVersion(), req.GetKind())
+}
+
+// visibleBundleThatProvides returns the latest bundle that provides an api in
+// the default channel of a package visible to the caller, for when the default
+// provider of the store is not visible. Providers are considered in the order
+// of the store, which lists the channels of higher priority first.
+func (s *RegistryServer) visibleBundleThatProvides(ctx context.Context, filter *packageFilter, group, version, kind string) (*api.Bundle, error) {
+	entries, err := s.store.GetLatestChannelEntriesThatProvide(ctx, group, version, kind)
+	if err != nil {
+		return nil, err
+	}
+	entries, err = filter.channelEntries(entries)
+	if err != nil {
+		return nil, err
+	}
+	defaultChannels := map[string]string{}
+	for _, e := range entries {
+		defaultChannel, ok := defaultChannels[e.PackageName]
+		if !ok {
+			pkg, err := s.store.GetPackage(ctx, e.PackageName)
+			if err != nil {
+				return nil, err
+			}
+			defaultChannel = pkg.GetDefaultChannel()
+			defaultChannels[e.PackageName] = defaultChannel
+		}
+		if e.ChannelName == defaultChannel {
+			return s.store.GetBundle(ctx, e.PackageName, e.ChannelName, e.BundleName)
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "no entry found that provides group:%q version:%q kind:%q", group, version, kind)
+}
+
+func (s *RegistryServer) GetPackageDocumentation(ctx context.Context, req *api.GetPackageDocumentationRequest) (*api.PackageDocumentation, error) {
+	store, ok := s.store.(registry.DocumentationQuery)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "package documentation is not supported by this registry")
+	}
+	if err := s.packageFilter(ctx).check(req.GetPkgName()); err != nil {
+		return nil, err
+	}
+	return store.GetPackageDocumentation(ctx, req.GetPkgName())
+}
+
+func (s *RegistryServer) GetPackageMetadata(ctx context.Context, req *api.GetPackageMetadataRequest) (*api.PackageMetadata, error) {
+	store, ok := s.store.(registry.PackageMetadataQuery)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "package metadata is not supported by this registry")
+	}
+	if err := s.packageFilter(ctx).check(req.GetPkgName()); err != nil {
+		return nil, err
+	}
+	return store.GetPackageMetadata(ctx, req.GetPkgName())
+}
+
+func (s *RegistryServer) GetCatalogInfo(ctx context.Context, req *api.GetCatalogInfoRequest) (*api.CatalogInfo, error) {
+	store, ok := s.store.(registry.ChecksumQuery)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "catalog info is not supported by this registry")
+	}
+	checksums, err := store.GetPackageChecksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err = s.packageFilter(ctx).checksums(checksums)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CatalogInfo{PackageChecksums: checksums}, nil
+}
+
+// GetCatalogStatus returns the state of the catalog, and the digest of its
+// content once it is loaded. The digest is computed from the package
+// checksums that GetCatalogInfo returns, so that it identifies the content of
+// the catalog regardless of how it is stored, and only covers the packages
+// that the caller is allowed to see.
+func (s *RegistryServer) GetCatalogStatus(ctx context.Context, req *api.GetCatalogStatusRequest) (*api.CatalogStatus, error) {
+	state, message := api.CatalogStatus_SERVING, ""
+	if s.readiness != nil {
+		state, message = s.readiness.State()
+	}
+	resp := &api.CatalogStatus{State: state, Message: message}
+	if state == api.CatalogStatus_LOADING {
+		return resp, nil
+	}
+	if store, ok := s.store.(registry.ChecksumQuery); ok {
+		checksums, err := store.GetPackageChecksums(ctx)
+		if err != nil {
+			return nil, err
+		}
+		checksums, err = s.packageFilter(ctx).checksums(checksums)
+		if err != nil {
+			return nil, err
+		}
+		resp.Digest = CatalogDigest(checksums)
+	}
+	return resp, nil
+}
+
+// CatalogDigest returns the digest of a catalog from the checksums of its
+// packages, as reported by GetCatalogStatus. It is the canonical digest of
+// the catalog, as computed by declcfg.Hash, for catalogs whose objects all
+// belong to packages.
+func CatalogDigest(checksums map[string]string) string {
+	return declcfg.DigestChecksums(checksums)
+}
+
+func (s *RegistryServer) GetBundleMetadata(ctx context.Context, req *api.GetBundleMetadataRequest) (*api.BundleMetadata, error) {
+	store, ok := s.store.(registry.BundleMetadataQuery)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "bundle metadata is not supported by this registry")
+	}
+	if err := s.packageFilter(ctx).check(req.GetPkgName()); err != nil {
+		return nil, err
+	}
+	return store.GetBundleMetadata(ctx, req.GetPkgName(), req.GetChannelName(), req.GetCsvName())
+}
+
+func (s *RegistryServer) ListBundleMetadata(req *api.ListBundleMetadataRequest, stream api.Registry_ListBundleMetadataServer) error {
+	store, ok := s.store.(registry.BundleMetadataQuery)
+	if !ok {
+		return status.Error(codes.Unimplemented, "bundle metadata is not supported by this registry")
+	}
+	return store.SendBundleMetadata(stream.Context(), s.packageFilter(stream.Context()).bundleMetadataSender(stream))
+}
+
+func (s *RegistryServer) ListDeprecations(req *api.ListDeprecationsRequest, stream api.Registry_ListDeprecationsServer) error {
+	store, ok := s.store.(registry.DeprecationQuery)
+	if !ok {
+		return status.Error(codes.Unimplemented, "deprecations are not supported by this registry")
+	}
+	filter := s.packageFilter(stream.Context())
+	if req.GetPkgName() != "" {
+		if err := filter.check(req.GetPkgName()); err != nil {
+			return err
+		}
+	}
+	entries, err := store.ListDeprecations(stream.Context(), req.GetPkgName())
+	if err != nil {
+		return err
+	}
+	entries, err = filter.deprecations(entries)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+	return nil
 }
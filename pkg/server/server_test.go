@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"testing"
 	"testing/fstest"
@@ -18,7 +19,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/operator-framework/operator-registry/alpha/action"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
@@ -230,6 +235,60 @@ func testListPackages(addr string, expected []string) func(*testing.T) {
 	}
 }
 
+func TestListDeprecations(t *testing.T) {
+	expectedDep := []*api.DeprecationEntry{
+		{
+			PackageName: "cockroachdb",
+			Schema:      "olm.package",
+			Deprecation: &api.Deprecation{Message: `package cockroachdb is end of life.  Please use 'nouveau-cockroachdb' package for support.
+`},
+		},
+		{
+			PackageName: "cockroachdb",
+			Schema:      "olm.channel",
+			Name:        "stable-5.x",
+			Deprecation: &api.Deprecation{Message: `channel stable-5.x is no longer supported.  Please switch to channel 'stable-6.x'.
+`},
+		},
+		{
+			PackageName: "cockroachdb",
+			Schema:      "olm.bundle",
+			Name:        "cockroachdb.v5.0.3",
+			Deprecation: &api.Deprecation{Message: `cockroachdb.v5.0.3 is deprecated. Uninstall and install cockroachdb.v5.0.4 for support.
+`},
+		},
+	}
+
+	t.Run("Sqlite", testListDeprecations(dbAddress, "etcd", nil))
+	t.Run("FBCCache", testListDeprecations(cacheAddress, "", nil))
+	t.Run("FBCCacheWithDeprecations", testListDeprecations(deprecationCacheAddress, "cockroachdb", expectedDep))
+	t.Run("FBCCacheWithDeprecationsAllPackages", testListDeprecations(deprecationCacheAddress, "", expectedDep))
+}
+
+func testListDeprecations(addr, pkgName string, expected []*api.DeprecationEntry) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		stream, err := c.ListDeprecations(context.TODO(), &api.ListDeprecationsRequest{PkgName: pkgName})
+		require.NoError(t, err)
+
+		var actual []*api.DeprecationEntry
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, e)
+		}
+		require.Len(t, actual, len(expected))
+		for i := range expected {
+			require.True(t, proto.Equal(expected[i], actual[i]), "expected %v, got %v", expected[i], actual[i])
+		}
+	}
+}
+
 func TestGetPackage(t *testing.T) {
 	var (
 		getPackageExpected = &api.Package{
@@ -787,6 +846,148 @@ func testListBundles(addr string, etcdAlpha *api.Bundle, etcdStable *api.Bundle)
 	}
 }
 
+func TestListBundlesFiltered(t *testing.T) {
+	for name, addr := range map[string]string{"Sqlite": dbAddress, "FBCCache": cacheAddress} {
+		t.Run(name, func(t *testing.T) {
+			c, conn := client(t, addr)
+			defer conn.Close()
+
+			actual := listBundleCursors(t, c, &api.ListBundlesRequest{Packages: []string{"etcd"}, Channels: []string{"alpha"}})
+			require.Equal(t, []registry.BundleCursor{
+				{PackageName: "etcd", ChannelName: "alpha", CsvName: "etcdoperator.v0.6.1"},
+				{PackageName: "etcd", ChannelName: "alpha", CsvName: "etcdoperator.v0.9.0"},
+				{PackageName: "etcd", ChannelName: "alpha", CsvName: "etcdoperator.v0.9.2"},
+			}, actual)
+
+			actual = listBundleCursors(t, c, &api.ListBundlesRequest{Properties: []*api.PropertySelector{
+				{Type: "olm.package", Value: `{"version":"0.9.2"}`},
+				{Type: "olm.gvk"},
+			}})
+			require.Equal(t, []registry.BundleCursor{
+				{PackageName: "etcd", ChannelName: "alpha", CsvName: "etcdoperator.v0.9.2"},
+				{PackageName: "etcd", ChannelName: "stable", CsvName: "etcdoperator.v0.9.2"},
+			}, actual)
+
+			actual = listBundleCursors(t, c, &api.ListBundlesRequest{Channels: []string{"missing"}})
+			require.Empty(t, actual)
+
+			// Pages of filtered bundles hold the bundles of an unpaged listing.
+			req := &api.ListBundlesRequest{Packages: []string{"etcd"}}
+			expected := listBundleCursors(t, c, req)
+			require.Len(t, expected, 8)
+			var paged []registry.BundleCursor
+			for req.PageSize = 3; ; {
+				var trailer metadata.MD
+				stream, err := c.ListBundles(context.TODO(), req, grpc.Trailer(&trailer))
+				require.NoError(t, err)
+				for b, err := stream.Recv(); err != io.EOF; b, err = stream.Recv() {
+					require.NoError(t, err)
+					paged = append(paged, registry.BundleCursor{PackageName: b.PackageName, ChannelName: b.ChannelName, CsvName: b.CsvName})
+				}
+				if len(trailer.Get(api.NextPageTokenTrailer)) == 0 {
+					break
+				}
+				req.PageToken = trailer.Get(api.NextPageTokenTrailer)[0]
+			}
+			require.Equal(t, expected, paged)
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		c, conn := client(t, dbAddress)
+		defer conn.Close()
+
+		for _, selector := range []*api.PropertySelector{{Value: `{}`}, {Type: "olm.package", Value: "{"}} {
+			stream, err := c.ListBundles(context.TODO(), &api.ListBundlesRequest{Properties: []*api.PropertySelector{selector}})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	})
+}
+
+// listBundleCursors lists the bundles of req, and returns their cursors in
+// cursor order.
+func listBundleCursors(t *testing.T, c api.RegistryClient, req *api.ListBundlesRequest) []registry.BundleCursor {
+	t.Helper()
+	stream, err := c.ListBundles(context.TODO(), req)
+	require.NoError(t, err)
+	var cursors []registry.BundleCursor
+	for b, err := stream.Recv(); err != io.EOF; b, err = stream.Recv() {
+		require.NoError(t, err)
+		cursors = append(cursors, registry.BundleCursor{PackageName: b.PackageName, ChannelName: b.ChannelName, CsvName: b.CsvName})
+	}
+	sort.Slice(cursors, func(i, j int) bool { return cursors[i].Less(cursors[j]) })
+	return cursors
+}
+
+func TestGetBundleMetadata(t *testing.T) {
+	t.Run("Sqlite", testGetBundleMetadata(dbAddress, etcdoperator_v0_9_2("alpha", false, false, includeManifestsNone)))
+	t.Run("FBCCache", testGetBundleMetadata(cacheAddress, etcdoperator_v0_9_2("alpha", false, true, includeManifestsNone)))
+}
+
+func testGetBundleMetadata(addr string, expected *api.Bundle) func(*testing.T) {
+	return func(t *testing.T) {
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		bundle, err := c.GetBundle(context.TODO(), &api.GetBundleRequest{PkgName: expected.PackageName, ChannelName: expected.ChannelName, CsvName: expected.CsvName})
+		require.NoError(t, err)
+		metadata, err := c.GetBundleMetadata(context.TODO(), &api.GetBundleMetadataRequest{PkgName: expected.PackageName, ChannelName: expected.ChannelName, CsvName: expected.CsvName})
+		require.NoError(t, err)
+
+		want := api.ConvertBundleToBundleMetadata(bundle)
+		require.True(t, proto.Equal(want, metadata), "expected %v, got %v", want, metadata)
+	}
+}
+
+func TestListBundleMetadata(t *testing.T) {
+	t.Run("Sqlite", testListBundleMetadata(dbAddress))
+	t.Run("FBCCache", testListBundleMetadata(cacheAddress))
+}
+
+func testListBundleMetadata(addr string) func(*testing.T) {
+	return func(t *testing.T) {
+		require := require.New(t)
+
+		c, conn := client(t, addr)
+		defer conn.Close()
+
+		key := func(pkgName, channelName, csvName string) string {
+			return pkgName + "/" + channelName + "/" + csvName
+		}
+
+		bundleStream, err := c.ListBundles(context.TODO(), &api.ListBundlesRequest{})
+		require.NoError(err)
+		bundles := map[string]*api.Bundle{}
+		for {
+			b, err := bundleStream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(err)
+			bundles[key(b.PackageName, b.ChannelName, b.CsvName)] = b
+		}
+
+		metadataStream, err := c.ListBundleMetadata(context.TODO(), &api.ListBundleMetadataRequest{})
+		require.NoError(err)
+		count := 0
+		for {
+			m, err := metadataStream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(err)
+			b, ok := bundles[key(m.PackageName, m.ChannelName, m.CsvName)]
+			require.True(ok, "unexpected bundle metadata %v", m)
+			want := api.ConvertBundleToBundleMetadata(b)
+			require.True(proto.Equal(want, m), "expected %v, got %v", want, m)
+			count++
+		}
+		require.Equal(len(bundles), count)
+	}
+}
+
 func EqualBundles(t *testing.T, expected, actual api.Bundle) {
 	t.Helper()
 	stripPlural(actual.ProvidedApis)
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig returns the TLS configuration of a registry server that presents
+// the certificate and key in certFile and keyFile, which must be PEM encoded.
+//
+// If clientCAFile is set, clients must present a certificate signed by one
+// of the PEM encoded CAs in it, i.e. connections are mutually authenticated.
+// The verified certificate chains of the client are available to handlers
+// through google.golang.org/grpc/peer, e.g. to authorize packages.
+//
+// The certificate and key are reloaded when their files change, so that
+// rotated certificates are served without a restart.
+func TLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both a certificate and a key are required")
+	}
+	certs := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := certs.getCertificate(nil); err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.getCertificate,
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in client CA file %q", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// certificateReloader loads a certificate and its key, and reloads them when
+// the modification time of either file changes.
+type certificateReloader struct {
+	certFile, keyFile string
+
+	mu              sync.Mutex
+	cert            *tls.Certificate
+	certMod, keyMod time.Time
+}
+
+func (r *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %v", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		// A certificate and key that are rotated one after the other do
+		// not match for a moment, so keep serving the previous pair.
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("load certificate and key: %v", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return r.cert, nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert creates a certificate signed by parent, or a self-signed CA
+// certificate if parent is nil.
+func newTestCert(t *testing.T, serial int64, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		tmpl.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+	if keyFile == "" {
+		return
+	}
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func serveTLS(t *testing.T, cfg *tls.Config) *bufconn.Listener {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(cfg)))
+	health.RegisterHealthServer(s, NewHealthServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return lis
+}
+
+func checkHealth(lis *bufconn.Listener, clientCfg *tls.Config) error {
+	conn, err := grpc.NewClient("passthrough:///localhost",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(credentials.NewTLS(clientCfg)),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = health.NewHealthClient(conn).Check(ctx, &health.HealthCheckRequest{})
+	return err
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		certFile     = filepath.Join(dir, "tls.crt")
+		keyFile      = filepath.Join(dir, "tls.key")
+		clientCAFile = filepath.Join(dir, "client-ca.crt")
+	)
+	ca := newTestCert(t, 1, nil, 0)
+	serverCert := newTestCert(t, 2, ca, x509.ExtKeyUsageServerAuth)
+	serverCert.write(t, certFile, keyFile)
+	ca.write(t, clientCAFile, "")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	t.Run("TLS", func(t *testing.T) {
+		cfg, err := TLSConfig(certFile, keyFile, "")
+		require.NoError(t, err)
+		lis := serveTLS(t, cfg)
+		require.NoError(t, checkHealth(lis, &tls.Config{RootCAs: roots, ServerName: "localhost"}))
+		require.Error(t, checkHealth(lis, &tls.Config{ServerName: "localhost"}), "server certificate must be verified")
+	})
+
+	t.Run("MutualTLS", func(t *testing.T) {
+		cfg, err := TLSConfig(certFile, keyFile, clientCAFile)
+		require.NoError(t, err)
+		lis := serveTLS(t, cfg)
+
+		clientCert := newTestCert(t, 3, ca, x509.ExtKeyUsageClientAuth)
+		require.NoError(t, checkHealth(lis, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{clientCert.tlsCertificate()}}))
+		require.Error(t, checkHealth(lis, &tls.Config{RootCAs: roots, ServerName: "localhost"}), "client certificate is required")
+
+		untrusted := newTestCert(t, 4, newTestCert(t, 5, nil, 0), x509.ExtKeyUsageClientAuth)
+		require.Error(t, checkHealth(lis, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{untrusted.tlsCertificate()}}), "client certificate must be signed by the client CA")
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+		serverCert.write(t, certFile, keyFile)
+		r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+		cert, err := r.getCertificate(nil)
+		require.NoError(t, err)
+		require.Equal(t, serverCert.der, cert.Certificate[0])
+
+		// A certificate that does not match the key yet keeps the previous
+		// pair in use.
+		rotated := newTestCert(t, 6, ca, x509.ExtKeyUsageServerAuth)
+		rotated.write(t, certFile, "")
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, later, later))
+		cert, err = r.getCertificate(nil)
+		require.NoError(t, err)
+		require.Equal(t, serverCert.der, cert.Certificate[0])
+
+		rotated.write(t, certFile, keyFile)
+		require.NoError(t, os.Chtimes(certFile, later.Add(time.Minute), later.Add(time.Minute)))
+		require.NoError(t, os.Chtimes(keyFile, later.Add(time.Minute), later.Add(time.Minute)))
+		cert, err = r.getCertificate(nil)
+		require.NoError(t, err)
+		require.Equal(t, rotated.der, cert.Certificate[0])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := TLSConfig(certFile, "", "")
+		require.EqualError(t, err, "both a certificate and a key are required")
+
+		_, err = TLSConfig(certFile, filepath.Join(dir, "missing.key"), "")
+		require.ErrorContains(t, err, "load key")
+
+		_, err = TLSConfig(certFile, keyFile, keyFile)
+		require.EqualError(t, err, `no PEM encoded certificates found in client CA file "`+keyFile+`"`)
+	})
+}
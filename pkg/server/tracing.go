@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tracerName = "github.com/operator-framework/operator-registry/pkg/server"
+
+// TracingInterceptors returns interceptors that trace each RPC in a span of
+// the global tracer provider, named after the full method, e.g.
+// "api.Registry/GetBundle". The trace context propagated by the client in
+// the request metadata, if any, is the parent of the span, and the context
+// of the handler carries the span, so that spans of the handler's work are
+// its children.
+func TracingInterceptors() (grpc.StreamServerInterceptor, grpc.UnaryServerInterceptor) {
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startRPCSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+		err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx})
+		endRPCSpan(span, err)
+		return err
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startRPCSpan(ctx, info.FullMethod)
+		defer span.End()
+		resp, err := handler(ctx, req)
+		endRPCSpan(span, err)
+		return resp, err
+	}
+	return stream, unary
+}
+
+func startRPCSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, method, _ := strings.Cut(name, "/")
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		),
+	)
+}
+
+func endRPCSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int64("rpc.grpc.status_code", int64(code)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// tracedServerStream is a grpc.ServerStream whose context carries the span
+// of its RPC.
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+var _ propagation.TextMapCarrier = metadataCarrier{}
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
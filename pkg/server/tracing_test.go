@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestTracingInterceptors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	stream, unary := TracingInterceptors()
+	s := grpc.NewServer(grpc.StreamInterceptor(stream), grpc.UnaryInterceptor(unary))
+	healthServer := NewHealthServer()
+	health.RegisterHealthServer(s, healthServer)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := health.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The span of the RPC is a child of the span propagated by the client.
+	ctx, parent := provider.Tracer("test").Start(ctx, "client")
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	_, err = client.Check(metadata.AppendToOutgoingContext(ctx, "traceparent", carrier.Get("traceparent")), &health.HealthCheckRequest{})
+	require.NoError(t, err)
+	parent.End()
+
+	_, err = client.Check(context.Background(), &health.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	// Watch is not implemented, so the stream fails.
+	watch, err := client.Watch(context.Background(), &health.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool { return len(recorder.Ended()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+
+	checks := spans["grpc.health.v1.Health/Check"]
+	require.Len(t, checks, 2)
+	require.Equal(t, trace.SpanKindServer, checks[0].SpanKind())
+	require.Equal(t, parent.SpanContext().TraceID(), checks[0].Parent().TraceID())
+	require.Equal(t, parent.SpanContext().SpanID(), checks[0].Parent().SpanID())
+	require.Contains(t, checks[0].Attributes(), attribute.String("rpc.service", "grpc.health.v1.Health"))
+	require.Contains(t, checks[0].Attributes(), attribute.String("rpc.method", "Check"))
+	require.Equal(t, otelcodes.Unset, checks[0].Status().Code)
+
+	require.False(t, checks[1].Parent().IsValid())
+
+	watches := spans["grpc.health.v1.Health/Watch"]
+	require.Len(t, watches, 1)
+	require.Equal(t, otelcodes.Error, watches[0].Status().Code)
+	require.Contains(t, watches[0].Attributes(), attribute.Int64("rpc.grpc.status_code", int64(codes.Unimplemented)))
+}
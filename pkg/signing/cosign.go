@@ -0,0 +1,154 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultCosignPath is the cosign binary that is run if Cosign.Path is
+	// not set.
+	DefaultCosignPath = "cosign"
+
+	// AttestationPredicateType is the predicate type of the attestations of
+	// catalog content created by Cosign.
+	AttestationPredicateType = "https://operatorframework.io/attestations/catalog-content/v1"
+)
+
+// AttestationPredicate is the predicate of the attestations of catalog
+// content created by Cosign. ContentDigest is the digest of the content, as
+// computed by ContentDigest.
+type AttestationPredicate struct {
+	ContentDigest string `json:"contentDigest"`
+}
+
+// Cosign signs and verifies catalog content with the cosign CLI of the
+// sigstore project, which must be installed. The blob that is signed is the
+// digest of the content, as text.
+//
+// Signatures are cosign bundles, which contain the signature and, for keyless
+// signing, the signing certificate and transparency log entry, so that they
+// can be verified from a single file.
+type Cosign struct {
+	// Path is the cosign binary to run. It defaults to DefaultCosignPath,
+	// looked up in PATH.
+	Path string
+	// Key is the private key to sign with, or the public key to verify
+	// with, as a file path or a KMS URI. If it is empty, keyless signing is
+	// used, and signatures are verified with CertificateIdentity and
+	// CertificateOIDCIssuer.
+	Key string
+	// CertificateIdentity is the identity that keyless signatures must
+	// have been issued for, e.g. an email address.
+	CertificateIdentity string
+	// CertificateOIDCIssuer is the OIDC issuer that must have issued the
+	// identity of keyless signatures.
+	CertificateOIDCIssuer string
+	// Attest creates and verifies in-toto attestations of the content,
+	// with an AttestationPredicate, instead of plain signatures.
+	Attest bool
+}
+
+var (
+	_ Signer   = Cosign{}
+	_ Verifier = Cosign{}
+)
+
+func (c Cosign) Sign(ctx context.Context, digest string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "opm-cosign-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	blob, err := writeBlob(dir, digest)
+	if err != nil {
+		return nil, err
+	}
+	bundle := filepath.Join(dir, "signature.bundle")
+
+	args := []string{"sign-blob"}
+	if c.Attest {
+		predicate, err := writePredicate(dir, digest)
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"attest-blob", "--predicate", predicate, "--type", AttestationPredicateType}
+	}
+	if c.Key != "" {
+		args = append(args, "--key", c.Key)
+	}
+	args = append(args, "--bundle", bundle, "--yes", blob)
+	if err := c.run(ctx, args...); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(bundle)
+}
+
+func (c Cosign) Verify(ctx context.Context, digest string, signature []byte) error {
+	dir, err := os.MkdirTemp("", "opm-cosign-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	blob, err := writeBlob(dir, digest)
+	if err != nil {
+		return err
+	}
+	bundle := filepath.Join(dir, "signature.bundle")
+	if err := os.WriteFile(bundle, signature, 0600); err != nil {
+		return err
+	}
+
+	args := []string{"verify-blob"}
+	if c.Attest {
+		args = []string{"verify-blob-attestation", "--type", AttestationPredicateType}
+	}
+	switch {
+	case c.Key != "":
+		args = append(args, "--key", c.Key)
+	case c.CertificateIdentity != "" && c.CertificateOIDCIssuer != "":
+		args = append(args, "--certificate-identity", c.CertificateIdentity, "--certificate-oidc-issuer", c.CertificateOIDCIssuer)
+	default:
+		return fmt.Errorf("a key, or a certificate identity and OIDC issuer, are required to verify signatures")
+	}
+	args = append(args, "--bundle", bundle, blob)
+	return c.run(ctx, args...)
+}
+
+func (c Cosign) run(ctx context.Context, args ...string) error {
+	path := c.Path
+	if path == "" {
+		path = DefaultCosignPath
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %v: %s", path, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// writeBlob writes the digest of the content to sign or verify to a file in
+// dir, and returns its path.
+func writeBlob(dir, digest string) (string, error) {
+	path := filepath.Join(dir, "catalog.digest")
+	return path, os.WriteFile(path, []byte(digest), 0600)
+}
+
+func writePredicate(dir, digest string) (string, error) {
+	data, err := json.Marshal(AttestationPredicate{ContentDigest: digest})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "predicate.json")
+	return path, os.WriteFile(path, data, 0600)
+}
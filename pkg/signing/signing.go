@@ -0,0 +1,62 @@
+// Package signing signs the content of file-based catalogs, and verifies
+// their signatures, so that catalog pipelines can check the provenance of a
+// catalog before it is served.
+//
+// The signed content of a catalog is its canonical digest, as computed by
+// declcfg.Hash, so a signature does not depend on how the catalog is laid out
+// on disk, on the order or formatting of its objects, or on whether it is
+// stored as JSON or YAML.
+package signing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// Signer signs the content of a catalog, as identified by its digest, and
+// returns its signature.
+type Signer interface {
+	Sign(ctx context.Context, digest string) ([]byte, error)
+}
+
+// Verifier verifies that signature is a valid signature of the content of a
+// catalog, as identified by its digest.
+type Verifier interface {
+	Verify(ctx context.Context, digest string, signature []byte) error
+}
+
+// ContentDigest returns the digest of the content of cfg that is signed.
+func ContentDigest(cfg declcfg.DeclarativeConfig) (string, error) {
+	digest, err := declcfg.Hash(cfg)
+	if err != nil {
+		return "", fmt.Errorf("compute catalog content digest: %v", err)
+	}
+	return digest, nil
+}
+
+// SignConfig signs the content of cfg with signer.
+func SignConfig(ctx context.Context, signer Signer, cfg declcfg.DeclarativeConfig) ([]byte, error) {
+	digest, err := ContentDigest(cfg)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.Sign(ctx, digest)
+	if err != nil {
+		return nil, fmt.Errorf("sign catalog: %v", err)
+	}
+	return signature, nil
+}
+
+// VerifyConfig verifies signature over the content of cfg with verifier.
+func VerifyConfig(ctx context.Context, verifier Verifier, cfg declcfg.DeclarativeConfig, signature []byte) error {
+	digest, err := ContentDigest(cfg)
+	if err != nil {
+		return err
+	}
+	if err := verifier.Verify(ctx, digest, signature); err != nil {
+		return fmt.Errorf("verify catalog signature: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package signing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func testConfig() declcfg.DeclarativeConfig {
+	return declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{
+			{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"},
+			{Schema: declcfg.SchemaPackage, Name: "bar", DefaultChannel: "stable"},
+		},
+		Channels: []declcfg.Channel{
+			{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "foo.v0.1.0"}}},
+			{Schema: declcfg.SchemaChannel, Package: "bar", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "bar.v0.1.0"}}},
+		},
+	}
+}
+
+func TestContentDigest(t *testing.T) {
+	cfg := testConfig()
+	expected, err := ContentDigest(cfg)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(expected, "sha256:"))
+
+	// The order of the objects of a catalog does not change its content.
+	reordered := testConfig()
+	reordered.Packages[0], reordered.Packages[1] = reordered.Packages[1], reordered.Packages[0]
+	reordered.Channels[0], reordered.Channels[1] = reordered.Channels[1], reordered.Channels[0]
+	actual, err := ContentDigest(reordered)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	changed := testConfig()
+	changed.Packages[0].DefaultChannel = "candidate"
+	actual, err = ContentDigest(changed)
+	require.NoError(t, err)
+	require.NotEqual(t, expected, actual)
+
+	// The signed content is the canonical digest of the catalog.
+	hash, err := declcfg.Hash(cfg)
+	require.NoError(t, err)
+	require.Equal(t, hash, expected)
+}
+
+// fakeCosign writes a script that records its arguments and the content of
+// the blob it is passed to dir, and writes "signature" to its --bundle, or
+// fails verification of any other bundle.
+func fakeCosign(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "cosign")
+	script := `#!/bin/sh
+echo "$@" > "` + dir + `/args"
+bundle=""
+while [ $# -gt 1 ]; do
+  if [ "$1" = "--bundle" ]; then bundle="$2"; fi
+  shift
+done
+cp "$1" "` + dir + `/blob"
+case "$(cat "` + dir + `/args")" in
+  sign-blob*|attest-blob*) printf signature > "$bundle" ;;
+  *) [ "$(cat "$bundle")" = "signature" ] || { echo "invalid signature" >&2; exit 1; } ;;
+esac
+`
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestCosign(t *testing.T) {
+	cfg := testConfig()
+	digest, err := ContentDigest(cfg)
+	require.NoError(t, err)
+
+	type spec struct {
+		name         string
+		cosign       Cosign
+		expectSign   string
+		expectVerify string
+	}
+	specs := []spec{
+		{
+			name:         "Key",
+			cosign:       Cosign{Key: "cosign.key"},
+			expectSign:   "sign-blob --key cosign.key --bundle <dir>/signature.bundle --yes <dir>/catalog.digest",
+			expectVerify: "verify-blob --key cosign.key --bundle <dir>/signature.bundle <dir>/catalog.digest",
+		},
+		{
+			name:         "Keyless",
+			cosign:       Cosign{CertificateIdentity: "me@example.com", CertificateOIDCIssuer: "https://issuer.example.com"},
+			expectSign:   "sign-blob --bundle <dir>/signature.bundle --yes <dir>/catalog.digest",
+			expectVerify: "verify-blob --certificate-identity me@example.com --certificate-oidc-issuer https://issuer.example.com --bundle <dir>/signature.bundle <dir>/catalog.digest",
+		},
+		{
+			name:         "Attestation",
+			cosign:       Cosign{Key: "cosign.key", Attest: true},
+			expectSign:   "attest-blob --predicate <dir>/predicate.json --type " + AttestationPredicateType + " --key cosign.key --bundle <dir>/signature.bundle --yes <dir>/catalog.digest",
+			expectVerify: "verify-blob-attestation --type " + AttestationPredicateType + " --key cosign.key --bundle <dir>/signature.bundle <dir>/catalog.digest",
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s.cosign.Path = fakeCosign(t, dir)
+			args := func() string {
+				data, err := os.ReadFile(filepath.Join(dir, "args"))
+				require.NoError(t, err)
+				// The last argument is the blob, in the temporary directory
+				// of the invocation.
+				fields := strings.Fields(string(data))
+				tmpDir := filepath.Dir(fields[len(fields)-1])
+				return strings.ReplaceAll(strings.Join(fields, " "), tmpDir, "<dir>")
+			}
+			blob := func() string {
+				data, err := os.ReadFile(filepath.Join(dir, "blob"))
+				require.NoError(t, err)
+				return string(data)
+			}
+
+			signature, err := SignConfig(context.Background(), s.cosign, cfg)
+			require.NoError(t, err)
+			require.Equal(t, "signature", string(signature))
+			require.Equal(t, s.expectSign, args())
+			require.Equal(t, digest, blob())
+
+			require.NoError(t, VerifyConfig(context.Background(), s.cosign, cfg, signature))
+			require.Equal(t, s.expectVerify, args())
+			require.Equal(t, digest, blob())
+
+			err = VerifyConfig(context.Background(), s.cosign, cfg, []byte("forged"))
+			require.ErrorContains(t, err, "invalid signature")
+		})
+	}
+
+	t.Run("AttestationPredicate", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cosign"), []byte(`#!/bin/sh
+while [ $# -gt 1 ]; do
+  case "$1" in
+    --predicate) cp "$2" "`+dir+`/predicate" ;;
+    --bundle) printf signature > "$2" ;;
+  esac
+  shift
+done
+`), 0755))
+		_, err := SignConfig(context.Background(), Cosign{Path: filepath.Join(dir, "cosign"), Attest: true}, cfg)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "predicate"))
+		require.NoError(t, err)
+		var predicate AttestationPredicate
+		require.NoError(t, json.Unmarshal(data, &predicate))
+		require.Equal(t, digest, predicate.ContentDigest)
+	})
+
+	t.Run("VerifyRequiresIdentity", func(t *testing.T) {
+		err := VerifyConfig(context.Background(), Cosign{Path: "cosign"}, cfg, []byte("signature"))
+		require.EqualError(t, err, "verify catalog signature: a key, or a certificate identity and OIDC issuer, are required to verify signatures")
+	})
+}
@@ -15,12 +15,38 @@ import (
 	"github.com/operator-framework/operator-registry/pkg/registry"
 )
 
-func ToModel(ctx context.Context, q *SQLQuerier) (model.Model, error) {
+// DeprecatedBundleMessage is the deprecation message of the bundles that
+// ToModel keeps when IncludeDeprecatedBundles is set, since deprecations in
+// sqlite databases have no message of their own.
+const DeprecatedBundleMessage = "This bundle has been deprecated in the index."
+
+type toModelConfig struct {
+	includeDeprecated bool
+}
+
+// ToModelOption configures ToModel.
+type ToModelOption func(*toModelConfig)
+
+// IncludeDeprecatedBundles keeps the bundles that were deprecated in the
+// database in the model, with a deprecation, instead of omitting them.
+// Deprecated bundles that are no longer in any channel are omitted either way.
+func IncludeDeprecatedBundles(include bool) ToModelOption {
+	return func(c *toModelConfig) {
+		c.includeDeprecated = include
+	}
+}
+
+func ToModel(ctx context.Context, q *SQLQuerier, opts ...ToModelOption) (model.Model, error) {
+	var cfg toModelConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	pkgs, err := initializeModelPackages(ctx, q)
 	if err != nil {
 		return nil, err
 	}
-	if err := populateModelChannels(ctx, pkgs, q); err != nil {
+	if err := populateModelChannels(ctx, pkgs, q, cfg.includeDeprecated); err != nil {
 		return nil, fmt.Errorf("populate channels: %v", err)
 	}
 	if err := populatePackageIcons(ctx, pkgs, q); err != nil {
@@ -71,21 +97,25 @@ func initializeModelPackages(ctx context.Context, q *SQLQuerier) (model.Model, e
 	return pkgs, nil
 }
 
-func populateModelChannels(ctx context.Context, pkgs model.Model, q *SQLQuerier) error {
+func populateModelChannels(ctx context.Context, pkgs model.Model, q *SQLQuerier, includeDeprecated bool) error {
 	bundles, err := q.ListBundles(ctx)
 	if err != nil {
 		return err
 	}
 
-ConvertBundles:
 	for _, bundle := range bundles {
+		deprecated := false
 		for _, prop := range bundle.Properties {
 			if prop.Type == registry.DeprecatedType {
-				// bundle contains `olm.Deprecated` property
-				// exclude this bundle from being rendered
-				continue ConvertBundles
+				deprecated = true
+				break
 			}
 		}
+		if deprecated && !includeDeprecated {
+			// bundle contains `olm.Deprecated` property
+			// exclude this bundle from being rendered
+			continue
+		}
 		pkg, ok := pkgs[bundle.PackageName]
 		if !ok {
 			return fmt.Errorf("unknown package %q for bundle %q", bundle.PackageName, bundle.CsvName)
@@ -93,6 +123,11 @@ ConvertBundles:
 
 		pkgChannel, ok := pkg.Channels[bundle.ChannelName]
 		if !ok {
+			if deprecated {
+				// channels that start with a deprecated bundle are
+				// elided from their package
+				continue
+			}
 			return fmt.Errorf("unknown channel %q for bundle %q", bundle.ChannelName, bundle.CsvName)
 		}
 
@@ -102,6 +137,9 @@ ConvertBundles:
 		}
 		mbundle.Package = pkg
 		mbundle.Channel = pkgChannel
+		if deprecated {
+			mbundle.Deprecation = &model.Deprecation{Message: DeprecatedBundleMessage}
+		}
 		pkgChannel.Bundles[bundle.CsvName] = mbundle
 	}
 	return nil
@@ -7,6 +7,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/model"
 )
 
 func TestToModel(t *testing.T) {
@@ -65,3 +67,35 @@ func TestToModel(t *testing.T) {
 	require.Equal(t, 3, len(m["strimzi-kafka-operator"].Channels["beta"].Bundles))
 	require.Equal(t, 2, len(m["strimzi-kafka-operator"].Channels["stable"].Bundles))
 }
+
+func TestToModelIncludeDeprecatedBundles(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open(dbPath)
+	require.NoError(t, err)
+	load, err := NewDeprecationAwareLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+	require.NoError(t, NewSQLLoaderForDirectory(load, "../../manifests").Populate())
+
+	// Bundles are deprecated by image, which bundles loaded from directories do not have.
+	_, err = db.Exec(`UPDATE operatorbundle SET bundlepath = 'quay.io/test/etcd:v0.9.0' WHERE name = 'etcdoperator.v0.9.0'`)
+	require.NoError(t, err)
+	require.NoError(t, load.DeprecateBundle("quay.io/test/etcd:v0.9.0"))
+	require.NoError(t, db.Close())
+
+	store, err := NewSQLLiteQuerier(dbPath)
+	require.NoError(t, err)
+
+	m, err := ToModel(context.TODO(), store)
+	require.NoError(t, err)
+	require.NotContains(t, m["etcd"].Channels["alpha"].Bundles, "etcdoperator.v0.9.0")
+
+	m, err = ToModel(context.TODO(), store, IncludeDeprecatedBundles(true))
+	require.NoError(t, err)
+	require.NoError(t, m.Validate())
+	alpha := m["etcd"].Channels["alpha"]
+	require.Len(t, alpha.Bundles, 2)
+	require.Nil(t, alpha.Bundles["etcdoperator.v0.9.2"].Deprecation)
+	require.Equal(t, &model.Deprecation{Message: DeprecatedBundleMessage}, alpha.Bundles["etcdoperator.v0.9.0"].Deprecation)
+}
@@ -1,9 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // Open opens a connection to a sqlite db. It should be used everywhere instead of sql.Open so that foreign keys are
@@ -12,9 +15,84 @@ func Open(fileName string) (*sql.DB, error) {
 	return sql.Open("sqlite3", EnableForeignKeys(fileName))
 }
 
-// Open opens a connection to a sqlite db. It is
-func OpenReadOnly(fileName string) (*sql.DB, error) {
-	return sql.Open("sqlite3", EnableImmutable(fileName))
+// OpenReadOnly opens a read-only connection to a sqlite db. By default, the db
+// is opened as immutable, see WithImmutable.
+func OpenReadOnly(fileName string, opts ...ReadOnlyOption) (*sql.DB, error) {
+	o := readOnlyOptions{immutable: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dsn := EnableImmutable(fileName)
+	if !o.immutable {
+		dsn = "file:" + fileName + "?mode=ro"
+	}
+	if o.cacheSize != 0 {
+		dsn += fmt.Sprintf("&_cache_size=%d", o.cacheSize)
+	}
+	if o.mmapSize == 0 {
+		return sql.Open("sqlite3", dsn)
+	}
+	// The mmap size can only be set with a pragma, which must be run on
+	// every connection of the pool.
+	return sql.OpenDB(connector{dsn: dsn, driver: &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			_, err := conn.Exec(fmt.Sprintf("PRAGMA mmap_size=%d", o.mmapSize), nil)
+			return err
+		},
+	}}), nil
+}
+
+type readOnlyOptions struct {
+	immutable bool
+	mmapSize  int64
+	cacheSize int64
+}
+
+// ReadOnlyOption configures the connections that OpenReadOnly opens.
+type ReadOnlyOption func(*readOnlyOptions)
+
+// WithImmutable opens the db with immutable=1 if true, the default, and
+// with mode=ro otherwise. Immutable dbs are read without locks or checks for
+// changes, which is faster but returns wrong results, or fails, if the file
+// changes while it is open.
+func WithImmutable(immutable bool) ReadOnlyOption {
+	return func(o *readOnlyOptions) {
+		o.immutable = immutable
+	}
+}
+
+// WithMmapSize sets the maximum number of bytes of the db that are read
+// through memory-mapped I/O, see PRAGMA mmap_size. Zero leaves the default
+// of sqlite.
+func WithMmapSize(bytes int64) ReadOnlyOption {
+	return func(o *readOnlyOptions) {
+		o.mmapSize = bytes
+	}
+}
+
+// WithCacheSize sets the size of the page cache of each connection, as PRAGMA
+// cache_size does: in pages if positive, or in KiB if negative. Zero leaves
+// the default of sqlite.
+func WithCacheSize(size int64) ReadOnlyOption {
+	return func(o *readOnlyOptions) {
+		o.cacheSize = size
+	}
+}
+
+// connector opens connections with driver, which is not registered with
+// database/sql, so that its hooks only apply to the dbs opened with it.
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.driver
 }
 
 // EnableForeignKeys appends the option to enable foreign keys on connections
@@ -8,6 +8,10 @@ type DbOptions struct {
 	// MigratorBuilder is a function that returns a migrator instance
 	MigratorBuilder func(*sql.DB) (Migrator, error)
 	EnableAlpha     bool
+	// SkipReferencedImages excludes the images that bundle CSVs only
+	// reference, through RELATED_IMAGE_* environment variables and image
+	// annotations, from the related images of added bundles
+	SkipReferencedImages bool
 }
 
 type DbOption func(*DbOptions)
@@ -30,3 +34,9 @@ func WithEnableAlpha(enableAlpha bool) DbOption {
 		o.EnableAlpha = enableAlpha
 	}
 }
+
+func WithSkipReferencedImages(skip bool) DbOption {
+	return func(o *DbOptions) {
+		o.SkipReferencedImages = skip
+	}
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeedsMigration(t *testing.T) {
+	ctx := context.Background()
+	dbName := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(dbName)
+	require.NoError(t, err)
+	defer db.Close()
+
+	needsMigration, err := NeedsMigration(ctx, db)
+	require.NoError(t, err)
+	require.True(t, needsMigration)
+
+	migrator, err := NewSQLLiteMigrator(db)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(ctx))
+
+	needsMigration, err = NeedsMigration(ctx, db)
+	require.NoError(t, err)
+	require.False(t, needsMigration)
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dbName := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(dbName)
+	require.NoError(t, err)
+	migrator, err := NewSQLLiteMigrator(db)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Migrate(ctx))
+	require.NoError(t, db.Close())
+
+	tests := []struct {
+		name          string
+		opts          []ReadOnlyOption
+		wantMmapSize  int64
+		wantCacheSize int64
+	}{
+		{
+			name:          "Defaults",
+			wantCacheSize: -2000,
+		},
+		{
+			name:          "MutableWithTuning",
+			opts:          []ReadOnlyOption{WithImmutable(false), WithMmapSize(1 << 20), WithCacheSize(-4096)},
+			wantMmapSize:  1 << 20,
+			wantCacheSize: -4096,
+		},
+		{
+			name:          "ImmutableWithTuning",
+			opts:          []ReadOnlyOption{WithMmapSize(1 << 20), WithCacheSize(500)},
+			wantMmapSize:  1 << 20,
+			wantCacheSize: 500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := OpenReadOnly(dbName, tt.opts...)
+			require.NoError(t, err)
+			defer db.Close()
+
+			var mmapSize, cacheSize int64
+			require.NoError(t, db.QueryRowContext(ctx, "PRAGMA mmap_size").Scan(&mmapSize))
+			require.NoError(t, db.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&cacheSize))
+			require.Equal(t, tt.wantMmapSize, mmapSize)
+			require.Equal(t, tt.wantCacheSize, cacheSize)
+
+			needsMigration, err := NeedsMigration(ctx, db)
+			require.NoError(t, err)
+			require.False(t, needsMigration)
+
+			_, err = db.ExecContext(ctx, "DELETE FROM "+DefaultMigrationsTable)
+			require.ErrorContains(t, err, "readonly database")
+		})
+	}
+}
@@ -263,12 +263,33 @@ func TestQuerierForDirectory(t *testing.T) {
 		"strimzi/cluster-operator:0.11.1",
 		"strimzi/operator:0.12.1",
 		"strimzi/operator:0.12.2",
+		// referenced by the containerImage annotations of the strimzi CSVs
+		"docker.io/strimzi/cluster-operator:0.11.0",
+		"docker.io/strimzi/cluster-operator:0.11.1",
+		"docker.io/strimzi/operator:0.12.1",
+		"docker.io/strimzi/operator:0.12.2",
 	}
 	dbImages, err := store.ListImages(context.TODO())
 	require.NoError(t, err)
 	require.ElementsMatch(t, expectedDatabaseImages, dbImages)
 }
 
+func TestQuerierForDirectorySkipReferencedImages(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	load, err := NewSQLLiteLoader(db, WithSkipReferencedImages(true))
+	require.NoError(t, err)
+	require.NoError(t, load.Migrate(context.TODO()))
+
+	loader := NewSQLLoaderForDirectory(load, "../../manifests")
+	require.NoError(t, loader.Populate())
+
+	store := NewSQLLiteQuerierFromDb(db)
+	images, err := store.GetImagesForBundle(context.TODO(), "strimzi-cluster-operator.v0.11.0")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"strimzi/cluster-operator:0.11.0"}, images)
+}
+
 func EqualBundles(t *testing.T, expected, actual api.Bundle) {
 	require.ElementsMatch(t, expected.ProvidedApis, actual.ProvidedApis)
 	require.ElementsMatch(t, expected.RequiredApis, actual.RequiredApis)
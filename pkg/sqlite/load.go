@@ -16,9 +16,10 @@ import (
 )
 
 type sqlLoader struct {
-	db          *sql.DB
-	migrator    Migrator
-	enableAlpha bool
+	db                   *sql.DB
+	migrator             Migrator
+	enableAlpha          bool
+	skipReferencedImages bool
 }
 
 type MigratableLoader interface {
@@ -49,7 +50,7 @@ func newSQLLoader(db *sql.DB, opts ...DbOption) (*sqlLoader, error) {
 		return nil, err
 	}
 
-	return &sqlLoader{db: db, migrator: migrator, enableAlpha: options.EnableAlpha}, nil
+	return &sqlLoader{db: db, migrator: migrator, enableAlpha: options.EnableAlpha, skipReferencedImages: options.SkipReferencedImages}, nil
 }
 
 func NewSQLLiteLoader(db *sql.DB, opts ...DbOption) (MigratableLoader, error) {
@@ -134,6 +135,15 @@ func (s *sqlLoader) addOperatorBundle(tx *sql.Tx, bundle *registry.Bundle) error
 	if err != nil {
 		return fmt.Errorf("unable to obtain images : %s", err)
 	}
+	if !s.skipReferencedImages {
+		refImgs, err := bundle.ReferencedImages()
+		if err != nil {
+			return fmt.Errorf("unable to obtain referenced images : %s", err)
+		}
+		for img := range refImgs {
+			imgs[img] = struct{}{}
+		}
+	}
 	for img := range imgs {
 		if _, err := addImage.Exec(img, csvName); err != nil {
 			return fmt.Errorf("failed to add related images %q for bundle %q: %s", img, csvName, err.Error())
@@ -1277,3 +1277,46 @@ func TestRemoveOverwrittenChannelHead(t *testing.T) {
 		})
 	}
 }
+
+func TestConstraintPropertiesAreDependencies(t *testing.T) {
+	db, cleanup := CreateTestDb(t)
+	defer cleanup()
+	store, err := NewSQLLiteLoader(db)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.Background()))
+
+	constraint := `{"cel":{"rule":"properties.exists(p, p.type == \"certified\")"},"failureMessage":"must be certified"}`
+	gvk := `{"group":"example.com","kind":"Example","version":"v1"}`
+	bundle := newBundle(t, "csv-1.0.0", "pkg-0", []string{"stable"}, newUnstructuredCSVWithReplacesAndVersion(t, "csv-1.0.0", "", "1.0.0"))
+	bundle.Properties = []registry.Property{{Type: registry.ConstraintType, Value: json.RawMessage(constraint)}}
+	bundle.Dependencies = []*registry.Dependency{{Type: registry.GVKType, Value: json.RawMessage(gvk)}}
+	require.NoError(t, store.AddOperatorBundle(bundle))
+	require.NoError(t, store.AddPackageChannels(registry.PackageManifest{
+		PackageName:        "pkg-0",
+		Channels:           []registry.PackageChannel{{Name: "stable", CurrentCSVName: "csv-1.0.0"}},
+		DefaultChannelName: "stable",
+	}))
+
+	expected := []string{
+		registry.ConstraintType + " " + constraint,
+		registry.GVKType + " " + gvk,
+	}
+
+	querier := NewSQLLiteQuerierFromDb(db)
+	deps, err := querier.GetDependenciesForBundle(context.Background(), "csv-1.0.0", "1.0.0", "")
+	require.NoError(t, err)
+	var actual []string
+	for _, d := range deps {
+		actual = append(actual, d.Type+" "+d.Value)
+	}
+	require.ElementsMatch(t, expected, actual)
+
+	bundles, err := querier.ListBundles(context.Background())
+	require.NoError(t, err)
+	require.Len(t, bundles, 1)
+	actual = nil
+	for _, d := range bundles[0].Dependencies {
+		actual = append(actual, d.Type+" "+d.Value)
+	}
+	require.ElementsMatch(t, expected, actual)
+}
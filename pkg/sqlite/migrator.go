@@ -64,6 +64,28 @@ func (m *SQLLiteMigrator) Migrate(ctx context.Context) error {
 	return m.Up(ctx, m.migrations.From(version+1))
 }
 
+// NeedsMigration reports whether db is older than the latest migration, and
+// so would be changed by Migrate. It only reads db, so that it can be called
+// on dbs opened with OpenReadOnly.
+func NeedsMigration(ctx context.Context, db *sql.DB) (bool, error) {
+	m := &SQLLiteMigrator{
+		db:              db,
+		migrationsTable: DefaultMigrationsTable,
+		migrations:      migrations.All(),
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	version, err := m.version(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	return len(m.migrations.From(version+1)) > 0, nil
+}
+
 // Up runs a specific set of migrations.
 func (m *SQLLiteMigrator) Up(ctx context.Context, migrations migrations.Migrations) error {
 	tx, err := m.db.Begin()
@@ -0,0 +1,342 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// PrunePackage removes the channels of a package that are not in
+// keepChannels, and the bundles of the package that are not in a kept channel
+// or whose version is not in keepVersions. An empty keepChannels keeps all
+// channels, and a nil keepVersions keeps all versions.
+//
+// Upgrade edges are rewired around the removed bundles: a kept bundle that
+// replaces a removed bundle instead replaces the closest kept bundle in its
+// replaces chain, and the head of a channel that is removed is replaced by
+// the closest kept bundle in its replaces chain. Channels without any kept
+// bundle are removed. If a single channel is kept, it becomes the default
+// channel, with a warning if the default channel is removed; otherwise it is
+// an error to remove the default channel.
+func (s *sqlLoader) PrunePackage(pkg string, keepChannels []string, keepVersions semver.Range) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	g, err := s.getPackageGraph(tx, pkg)
+	if err != nil {
+		return err
+	}
+	channels := make([]string, 0, len(g.heads))
+	if len(keepChannels) == 0 {
+		for name := range g.heads {
+			channels = append(channels, name)
+		}
+	} else {
+		for _, name := range keepChannels {
+			if _, ok := g.heads[name]; !ok {
+				return fmt.Errorf("channel %q not found in package %q", name, pkg)
+			}
+			channels = append(channels, name)
+		}
+	}
+
+	keep := map[string]bool{}
+	for _, c := range channels {
+		bundles, err := getChannelBundleVersions(tx, pkg, c)
+		if err != nil {
+			return err
+		}
+		for name, version := range bundles {
+			if keepVersions != nil {
+				v, err := semver.Parse(version)
+				if err != nil {
+					return fmt.Errorf("cannot prune bundle %q of package %q by version: invalid version %q: %v", name, pkg, version, err)
+				}
+				if !keepVersions(v) {
+					continue
+				}
+			}
+			keep[name] = true
+		}
+	}
+
+	if err := s.rewirePackage(tx, g, channels, keep, "prune"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// separate transaction so that we remove stranded bundles after the channels have been recalculated
+	return s.RemoveStrandedBundles()
+}
+
+// RemoveBundles removes the bundles named bundles from the database.
+//
+// Without truncate, upgrade edges are rewired around the removed bundles, as
+// they are by PrunePackage, so that the rest of the upgrade graph is
+// unchanged. With truncate, the bundles that a removed bundle replaces,
+// directly or transitively, are removed as well, and the bundles that
+// replaced them no longer replace anything. It is an error to remove every
+// bundle of the default channel of a package.
+func (s *sqlLoader) RemoveBundles(bundles []string, truncate bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	byPackage := map[string][]string{}
+	var packages []string
+	for _, name := range bundles {
+		pkg, err := getBundlePackage(tx, name)
+		if err != nil {
+			return err
+		}
+		if _, ok := byPackage[pkg]; !ok {
+			packages = append(packages, pkg)
+		}
+		byPackage[pkg] = append(byPackage[pkg], name)
+	}
+
+	for _, pkg := range packages {
+		g, err := s.getPackageGraph(tx, pkg)
+		if err != nil {
+			return err
+		}
+		channels := make([]string, 0, len(g.heads))
+		for name := range g.heads {
+			channels = append(channels, name)
+		}
+
+		remove := map[string]bool{}
+		for _, name := range byPackage[pkg] {
+			remove[name] = true
+			if !truncate {
+				continue
+			}
+			seen := map[string]bool{}
+			for r := g.replaces[name]; r != "" && !seen[r]; r = g.replaces[r] {
+				seen[r] = true
+				remove[r] = true
+			}
+		}
+		keep := map[string]bool{}
+		for _, name := range g.csvNames {
+			if !remove[name] {
+				keep[name] = true
+			}
+		}
+
+		if err := s.rewirePackage(tx, g, channels, keep, "remove"); err != nil {
+			return err
+		}
+		for name := range remove {
+			if _, err := tx.Exec(`DELETE FROM deprecated WHERE operatorbundle_name = ?`, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// separate transaction so that we remove stranded bundles after the channels have been recalculated
+	return s.RemoveStrandedBundles()
+}
+
+// packageGraph is the upgrade graph of a package, as stored in the database.
+type packageGraph struct {
+	pkg            string
+	defaultChannel string
+	// heads maps channel names to the names of their heads.
+	heads map[string]string
+	// csvNames are the names of the bundles in the package.
+	csvNames []string
+	// replaces maps bundle names to the name of the bundle they replace.
+	replaces map[string]string
+}
+
+func (s *sqlLoader) getPackageGraph(tx *sql.Tx, pkg string) (*packageGraph, error) {
+	var defaultChannel sql.NullString
+	if err := tx.QueryRow(`SELECT default_channel FROM package WHERE name = ?`, pkg).Scan(&defaultChannel); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no package found for packagename %s", pkg)
+		}
+		return nil, err
+	}
+
+	heads, err := getChannelHeads(tx, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	csvNames, err := s.getCSVNames(tx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	replaces := make(map[string]string, len(csvNames))
+	for _, name := range csvNames {
+		r, _, _, err := s.getBundleSkipsReplacesVersion(tx, name)
+		if err != nil {
+			// channel entries for skipped bundles may not have a bundle
+			continue
+		}
+		replaces[name] = r
+	}
+
+	return &packageGraph{
+		pkg:            pkg,
+		defaultChannel: defaultChannel.String,
+		heads:          heads,
+		csvNames:       csvNames,
+		replaces:       replaces,
+	}, nil
+}
+
+// rewirePackage removes the bundles of a package that are not in keep, and
+// its channels that are not in channels, and rewires the upgrade edges of
+// the kept bundles around the removed ones: a kept bundle that replaces a
+// removed bundle instead replaces the closest kept bundle in its replaces
+// chain, and the head of a channel that is removed is replaced by the
+// closest kept bundle in its replaces chain. Channels without any kept
+// bundle are removed. If a single channel is kept, it becomes the default
+// channel, with a warning if the default channel is removed; otherwise it is
+// an error to remove the default channel, and op names the operation in that
+// error.
+func (s *sqlLoader) rewirePackage(tx *sql.Tx, g *packageGraph, channels []string, keep map[string]bool, op string) error {
+	// closestKept follows the replaces chain from name to the first kept
+	// bundle, or returns "" if there is none.
+	closestKept := func(name string) string {
+		seen := map[string]bool{}
+		for name != "" && !keep[name] && !seen[name] {
+			seen[name] = true
+			name = g.replaces[name]
+		}
+		if keep[name] {
+			return name
+		}
+		return ""
+	}
+
+	manifest := registry.PackageManifest{PackageName: g.pkg, DefaultChannelName: g.defaultChannel}
+	for _, c := range channels {
+		head := closestKept(g.heads[c])
+		if head == "" {
+			continue
+		}
+		manifest.Channels = append(manifest.Channels, registry.PackageChannel{Name: c, CurrentCSVName: head})
+	}
+	hasDefault := false
+	for _, c := range manifest.Channels {
+		if c.IsDefaultChannel(manifest) {
+			hasDefault = true
+		}
+	}
+	if !hasDefault {
+		return fmt.Errorf("cannot %s default channel %q of package %q", op, g.defaultChannel, g.pkg)
+	}
+	if len(manifest.Channels) == 1 && manifest.Channels[0].Name != g.defaultChannel {
+		logrus.WithField("package", g.pkg).Warnf("default channel %q removed, making %q, the only remaining channel, the default channel", g.defaultChannel, manifest.Channels[0].Name)
+		manifest.DefaultChannelName = manifest.Channels[0].Name
+	}
+
+	updateReplaces, err := tx.Prepare(`UPDATE operatorbundle SET replaces = ? WHERE name = ?`)
+	if err != nil {
+		return err
+	}
+	defer updateReplaces.Close()
+	for name := range keep {
+		r, ok := g.replaces[name]
+		if !ok || r == "" || keep[r] {
+			continue
+		}
+		var newReplaces sql.NullString
+		if closest := closestKept(r); closest != "" {
+			newReplaces = sql.NullString{String: closest, Valid: true}
+		}
+		if _, err := updateReplaces.Exec(newReplaces, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range g.csvNames {
+		if keep[name] {
+			continue
+		}
+		if err := s.rmBundle(tx, name); err != nil {
+			return err
+		}
+	}
+
+	if err := s.rmPackage(tx, g.pkg); err != nil {
+		return err
+	}
+	return s.addPackageChannels(tx, manifest)
+}
+
+// getBundlePackage returns the name of the package of the bundle named name.
+func getBundlePackage(tx *sql.Tx, name string) (string, error) {
+	var pkg sql.NullString
+	if err := tx.QueryRow(`SELECT DISTINCT package_name FROM channel_entry WHERE operatorbundle_name = ? LIMIT 1`, name).Scan(&pkg); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("bundle %q not found", name)
+		}
+		return "", err
+	}
+	return pkg.String, nil
+}
+
+func getChannelHeads(tx *sql.Tx, pkg string) (map[string]string, error) {
+	rows, err := tx.Query(`SELECT name, head_operatorbundle_name FROM channel WHERE package_name = ?`, pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heads := map[string]string{}
+	for rows.Next() {
+		var name, head sql.NullString
+		if err := rows.Scan(&name, &head); err != nil {
+			return nil, err
+		}
+		heads[name.String] = head.String
+	}
+	return heads, rows.Err()
+}
+
+// getChannelBundleVersions returns the versions of the bundles in a channel,
+// keyed by bundle name.
+func getChannelBundleVersions(tx *sql.Tx, pkg, channel string) (map[string]string, error) {
+	rows, err := tx.Query(`
+	  SELECT DISTINCT operatorbundle.name, operatorbundle.version
+	  FROM channel_entry
+	  INNER JOIN operatorbundle ON channel_entry.operatorbundle_name = operatorbundle.name
+	  WHERE channel_entry.package_name = ? AND channel_entry.channel_name = ?`, pkg, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[string]string{}
+	for rows.Next() {
+		var name, version sql.NullString
+		if err := rows.Scan(&name, &version); err != nil {
+			return nil, err
+		}
+		versions[name.String] = version.String
+	}
+	return versions, rows.Err()
+}
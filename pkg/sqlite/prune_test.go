@@ -0,0 +1,313 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func newUnstructuredCSVWithReplacesAndVersion(t *testing.T, name, replaces, version string) *unstructured.Unstructured {
+	csv := &registry.ClusterServiceVersion{}
+	csv.TypeMeta.Kind = "ClusterServiceVersion"
+	csv.SetName(name)
+	csv.Spec = json.RawMessage(fmt.Sprintf(`{"replaces": %q, "version": %q}`, replaces, version))
+
+	out, err := runtime.DefaultUnstructuredConverter.ToUnstructured(csv)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: out}
+}
+
+// loadPruneTestPackages adds two packages to store: pkg-0, whose stable and
+// fast channels share a replaces chain that fast extends, and pkg-1, with a
+// single bundle.
+func loadPruneTestPackages(t *testing.T, store MigratableLoader) {
+	t.Helper()
+	bundles := []*registry.Bundle{
+		newBundle(t, "csv-1.0.0", "pkg-0", []string{"stable", "fast"}, newUnstructuredCSVWithReplacesAndVersion(t, "csv-1.0.0", "", "1.0.0")),
+		newBundle(t, "csv-1.1.0", "pkg-0", []string{"stable", "fast"}, newUnstructuredCSVWithReplacesAndVersion(t, "csv-1.1.0", "csv-1.0.0", "1.1.0")),
+		newBundle(t, "csv-1.2.0", "pkg-0", []string{"stable", "fast"}, newUnstructuredCSVWithReplacesAndVersion(t, "csv-1.2.0", "csv-1.1.0", "1.2.0")),
+		newBundle(t, "csv-2.0.0", "pkg-0", []string{"stable", "fast"}, newUnstructuredCSVWithReplacesAndVersion(t, "csv-2.0.0", "csv-1.2.0", "2.0.0")),
+		newBundle(t, "csv-2.1.0", "pkg-0", []string{"fast"}, newUnstructuredCSVWithReplacesAndVersion(t, "csv-2.1.0", "csv-2.0.0", "2.1.0")),
+		newBundle(t, "other-1.0.0", "pkg-1", []string{"stable"}, newUnstructuredCSVWithReplacesAndVersion(t, "other-1.0.0", "", "1.0.0")),
+	}
+	for _, bundle := range bundles {
+		require.NoError(t, store.AddOperatorBundle(bundle))
+	}
+	pkgs := []registry.PackageManifest{
+		{
+			PackageName: "pkg-0",
+			Channels: []registry.PackageChannel{
+				{Name: "stable", CurrentCSVName: "csv-2.0.0"},
+				{Name: "fast", CurrentCSVName: "csv-2.1.0"},
+			},
+			DefaultChannelName: "stable",
+		},
+		{
+			PackageName:        "pkg-1",
+			Channels:           []registry.PackageChannel{{Name: "stable", CurrentCSVName: "other-1.0.0"}},
+			DefaultChannelName: "stable",
+		},
+	}
+	for _, pkg := range pkgs {
+		require.NoError(t, store.AddPackageChannels(pkg))
+	}
+}
+
+func TestPrunePackage(t *testing.T) {
+	type args struct {
+		keepChannels []string
+		keepVersions semver.Range
+	}
+	type expected struct {
+		err            string
+		bundles        []string
+		defaultChannel string
+		warning        string
+	}
+	tests := []struct {
+		description string
+		args        args
+		expected    expected
+	}{
+		{
+			description: "KeepAll",
+			expected: expected{
+				bundles: []string{
+					"pkg-0/stable/csv-1.0.0/",
+					"pkg-0/stable/csv-1.1.0/csv-1.0.0",
+					"pkg-0/stable/csv-1.2.0/csv-1.1.0",
+					"pkg-0/stable/csv-2.0.0/csv-1.2.0",
+					"pkg-0/fast/csv-1.0.0/",
+					"pkg-0/fast/csv-1.1.0/csv-1.0.0",
+					"pkg-0/fast/csv-1.2.0/csv-1.1.0",
+					"pkg-0/fast/csv-2.0.0/csv-1.2.0",
+					"pkg-0/fast/csv-2.1.0/csv-2.0.0",
+					"pkg-1/stable/other-1.0.0/",
+				},
+			},
+		},
+		{
+			description: "KeepChannels",
+			args: args{
+				keepChannels: []string{"stable"},
+			},
+			expected: expected{
+				bundles: []string{
+					"pkg-0/stable/csv-1.0.0/",
+					"pkg-0/stable/csv-1.1.0/csv-1.0.0",
+					"pkg-0/stable/csv-1.2.0/csv-1.1.0",
+					"pkg-0/stable/csv-2.0.0/csv-1.2.0",
+					"pkg-1/stable/other-1.0.0/",
+				},
+			},
+		},
+		{
+			description: "KeepVersionRange",
+			args: args{
+				keepVersions: semver.MustParseRange(">=1.1.0 <2.0.0"),
+			},
+			expected: expected{
+				bundles: []string{
+					"pkg-0/stable/csv-1.1.0/",
+					"pkg-0/stable/csv-1.2.0/csv-1.1.0",
+					"pkg-0/fast/csv-1.1.0/",
+					"pkg-0/fast/csv-1.2.0/csv-1.1.0",
+					"pkg-1/stable/other-1.0.0/",
+				},
+			},
+		},
+		{
+			description: "RewireAroundPrunedVersion",
+			args: args{
+				keepChannels: []string{"stable"},
+				keepVersions: semver.MustParseRange("!1.1.0"),
+			},
+			expected: expected{
+				bundles: []string{
+					"pkg-0/stable/csv-1.0.0/",
+					"pkg-0/stable/csv-1.2.0/csv-1.0.0",
+					"pkg-0/stable/csv-2.0.0/csv-1.2.0",
+					"pkg-1/stable/other-1.0.0/",
+				},
+			},
+		},
+		{
+			description: "KeepNonDefaultChannel",
+			args: args{
+				keepChannels: []string{"fast"},
+			},
+			expected: expected{
+				bundles: []string{
+					"pkg-0/fast/csv-1.0.0/",
+					"pkg-0/fast/csv-1.1.0/csv-1.0.0",
+					"pkg-0/fast/csv-1.2.0/csv-1.1.0",
+					"pkg-0/fast/csv-2.0.0/csv-1.2.0",
+					"pkg-0/fast/csv-2.1.0/csv-2.0.0",
+					"pkg-1/stable/other-1.0.0/",
+				},
+				defaultChannel: "fast",
+				warning:        `default channel "stable" removed, making "fast", the only remaining channel, the default channel`,
+			},
+		},
+		{
+			description: "PruneAllVersions",
+			args: args{
+				keepVersions: semver.MustParseRange(">=3.0.0"),
+			},
+			expected: expected{
+				err: `cannot prune default channel "stable" of package "pkg-0"`,
+			},
+		},
+		{
+			description: "UnknownChannel",
+			args: args{
+				keepChannels: []string{"beta"},
+			},
+			expected: expected{
+				err: `channel "beta" not found in package "pkg-0"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			db, cleanup := CreateTestDb(t)
+			defer cleanup()
+			store, err := NewSQLLiteLoader(db)
+			require.NoError(t, err)
+			require.NoError(t, store.Migrate(context.Background()))
+
+			loadPruneTestPackages(t, store)
+
+			logHook := test.NewGlobal()
+			defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+			err = store.(*sqlLoader).PrunePackage("pkg-0", tt.args.keepChannels, tt.args.keepVersions)
+			if tt.expected.err != "" {
+				require.EqualError(t, err, tt.expected.err)
+				return
+			}
+			require.NoError(t, err)
+
+			querier := NewSQLLiteQuerierFromDb(db)
+			listed, err := querier.ListBundles(context.Background())
+			require.NoError(t, err)
+			var actual []string
+			for _, b := range listed {
+				actual = append(actual, fmt.Sprintf("%s/%s/%s/%s", b.PackageName, b.ChannelName, b.CsvName, b.Replaces))
+			}
+			require.ElementsMatch(t, tt.expected.bundles, actual)
+
+			if tt.expected.defaultChannel != "" {
+				pkg, err := querier.GetPackage(context.Background(), "pkg-0")
+				require.NoError(t, err)
+				require.Equal(t, tt.expected.defaultChannel, pkg.DefaultChannelName)
+			}
+			var warnings []string
+			for _, e := range logHook.AllEntries() {
+				if e.Level == logrus.WarnLevel {
+					warnings = append(warnings, e.Message)
+				}
+			}
+			if tt.expected.warning != "" {
+				require.Equal(t, []string{tt.expected.warning}, warnings)
+			} else {
+				require.Empty(t, warnings)
+			}
+		})
+	}
+}
+
+func TestRemoveBundles(t *testing.T) {
+	tests := []struct {
+		description string
+		bundles     []string
+		truncate    bool
+		expected    []string
+		err         string
+	}{
+		{
+			description: "RewireAroundRemovedBundle",
+			bundles:     []string{"csv-1.1.0"},
+			expected: []string{
+				"pkg-0/stable/csv-1.0.0/",
+				"pkg-0/stable/csv-1.2.0/csv-1.0.0",
+				"pkg-0/stable/csv-2.0.0/csv-1.2.0",
+				"pkg-0/fast/csv-1.0.0/",
+				"pkg-0/fast/csv-1.2.0/csv-1.0.0",
+				"pkg-0/fast/csv-2.0.0/csv-1.2.0",
+				"pkg-0/fast/csv-2.1.0/csv-2.0.0",
+				"pkg-1/stable/other-1.0.0/",
+			},
+		},
+		{
+			description: "RemoveChannelHead",
+			bundles:     []string{"csv-2.1.0"},
+			expected: []string{
+				"pkg-0/stable/csv-1.0.0/",
+				"pkg-0/stable/csv-1.1.0/csv-1.0.0",
+				"pkg-0/stable/csv-1.2.0/csv-1.1.0",
+				"pkg-0/stable/csv-2.0.0/csv-1.2.0",
+				"pkg-0/fast/csv-1.0.0/",
+				"pkg-0/fast/csv-1.1.0/csv-1.0.0",
+				"pkg-0/fast/csv-1.2.0/csv-1.1.0",
+				"pkg-0/fast/csv-2.0.0/csv-1.2.0",
+				"pkg-1/stable/other-1.0.0/",
+			},
+		},
+		{
+			description: "Truncate",
+			bundles:     []string{"csv-1.2.0"},
+			truncate:    true,
+			expected: []string{
+				"pkg-0/stable/csv-2.0.0/",
+				"pkg-0/fast/csv-2.0.0/",
+				"pkg-0/fast/csv-2.1.0/csv-2.0.0",
+				"pkg-1/stable/other-1.0.0/",
+			},
+		},
+		{
+			description: "RemoveDefaultChannel",
+			bundles:     []string{"csv-1.1.0", "other-1.0.0"},
+			err:         `cannot remove default channel "stable" of package "pkg-1"`,
+		},
+		{
+			description: "UnknownBundle",
+			bundles:     []string{"csv-3.0.0"},
+			err:         `bundle "csv-3.0.0" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			db, cleanup := CreateTestDb(t)
+			defer cleanup()
+			store, err := NewSQLLiteLoader(db)
+			require.NoError(t, err)
+			require.NoError(t, store.Migrate(context.Background()))
+			loadPruneTestPackages(t, store)
+
+			err = store.(*sqlLoader).RemoveBundles(tt.bundles, tt.truncate)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			listed, err := NewSQLLiteQuerierFromDb(db).ListBundles(context.Background())
+			require.NoError(t, err)
+			var actual []string
+			for _, b := range listed {
+				actual = append(actual, fmt.Sprintf("%s/%s/%s/%s", b.PackageName, b.ChannelName, b.CsvName, b.Replaces))
+			}
+			require.ElementsMatch(t, tt.expected, actual)
+		})
+	}
+}
@@ -42,6 +42,9 @@ var _ registry.Query = &SQLQuerier{}
 
 type querierConfig struct {
 	omitManifests bool
+	// packages, if set, is a JSON array of the names of the only packages
+	// that are listed.
+	packages *string
 }
 
 type SQLiteQuerierOption func(*querierConfig)
@@ -55,6 +58,28 @@ func OmitManifests(b bool) SQLiteQuerierOption {
 	}
 }
 
+// OnlyPackages limits the packages that ListPackages lists, and the bundles
+// that ListBundles and SendBundles send, to those of the named packages, so
+// that the bundles of other packages are not read from the database. Without
+// names, all packages are listed.
+func OnlyPackages(names ...string) SQLiteQuerierOption {
+	return func(c *querierConfig) {
+		c.packages = jsonArray(names)
+	}
+}
+
+// jsonArray returns the JSON array of names, for queries to select from with
+// json_each, or nil if there are no names.
+func jsonArray(names []string) *string {
+	if len(names) == 0 {
+		return nil
+	}
+	// Errors are impossible when marshaling a slice of strings.
+	data, _ := json.Marshal(names)
+	array := string(data)
+	return &array
+}
+
 func NewSQLLiteQuerier(dbFilename string, opts ...SQLiteQuerierOption) (*SQLQuerier, error) {
 	db, err := OpenReadOnly(dbFilename)
 	if err != nil {
@@ -98,8 +123,8 @@ func (s *SQLQuerier) ListTables(ctx context.Context) ([]string, error) {
 
 // ListPackages returns a list of package names as strings
 func (s *SQLQuerier) ListPackages(ctx context.Context) ([]string, error) {
-	query := "SELECT DISTINCT name FROM package"
-	rows, err := s.db.QueryContext(ctx, query)
+	query := "SELECT DISTINCT name FROM package WHERE :packages IS NULL OR name IN (SELECT value FROM json_each(:packages))"
+	rows, err := s.db.QueryContext(ctx, query, sql.Named("packages", s.packages))
 	if err != nil {
 		return nil, err
 	}
@@ -229,10 +254,24 @@ func (s *SQLQuerier) GetChannelEntriesFromPackage(ctx context.Context, packageNa
 }
 
 func (s *SQLQuerier) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
-	query := `SELECT DISTINCT channel_entry.entry_id, operatorbundle.name, operatorbundle.bundle, operatorbundle.bundlepath, operatorbundle.version, operatorbundle.skiprange
+	return s.getBundle(ctx, pkgName, channelName, csvName, true)
+}
+
+// GetBundleMetadata returns the bundle like GetBundle does, without reading
+// its manifests from the database.
+func (s *SQLQuerier) GetBundleMetadata(ctx context.Context, pkgName, channelName, csvName string) (*api.BundleMetadata, error) {
+	b, err := s.getBundle(ctx, pkgName, channelName, csvName, false)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertBundleToBundleMetadata(b), nil
+}
+
+func (s *SQLQuerier) getBundle(ctx context.Context, pkgName, channelName, csvName string, withManifests bool) (*api.Bundle, error) {
+	query := `SELECT DISTINCT channel_entry.entry_id, operatorbundle.name, CASE WHEN ? THEN operatorbundle.bundle END, operatorbundle.bundlepath, operatorbundle.version, operatorbundle.skiprange
 			  FROM operatorbundle INNER JOIN channel_entry ON operatorbundle.name=channel_entry.operatorbundle_name
               WHERE channel_entry.package_name=? AND channel_entry.channel_name=? AND operatorbundle_name=? LIMIT 1`
-	rows, err := s.db.QueryContext(ctx, query, pkgName, channelName, csvName)
+	rows, err := s.db.QueryContext(ctx, query, withManifests, pkgName, channelName, csvName)
 	if err != nil {
 		return nil, err
 	}
@@ -970,7 +1009,7 @@ merged_dependencies (bundle_name, merged) AS (
 )
 SELECT
     replaces_bundle.entry_id,
-    CASE WHEN :omit_manifests AND length(coalesce(operatorbundle.bundlepath, "")) > 0 THEN NULL ELSE operatorbundle.bundle END,
+    CASE WHEN :omit_all_manifests OR (:omit_manifests AND length(coalesce(operatorbundle.bundlepath, "")) > 0) THEN NULL ELSE operatorbundle.bundle END,
     operatorbundle.bundlepath,
     operatorbundle.name,
     replaces_bundle.package_name,
@@ -991,15 +1030,108 @@ SELECT
     LEFT OUTER JOIN merged_dependencies
       ON operatorbundle.name = merged_dependencies.bundle_name
     LEFT OUTER JOIN merged_properties
-      ON operatorbundle.name = merged_properties.bundle_name`
+      ON operatorbundle.name = merged_properties.bundle_name
+  WHERE (:packages IS NULL OR replaces_bundle.package_name IN (SELECT value FROM json_each(:packages)))
+    AND (:filter_packages IS NULL OR replaces_bundle.package_name IN (SELECT value FROM json_each(:filter_packages)))
+    AND (:filter_channels IS NULL OR replaces_bundle.channel_name IN (SELECT value FROM json_each(:filter_channels)))
+    AND (:after_package IS NULL OR (replaces_bundle.package_name, replaces_bundle.channel_name, operatorbundle.name) > (:after_package, :after_channel, :after_bundle))`
+
+// listBundlesPageQuery orders the rows of listBundlesQuery by their cursor, so
+// that pages can be resumed, and limits them to :limit rows. Listing all
+// bundles at once does not need an order, and sorting them is not free on
+// large databases.
+const listBundlesPageQuery = listBundlesQuery + `
+  ORDER BY replaces_bundle.package_name, replaces_bundle.channel_name, operatorbundle.name
+  LIMIT :limit`
 
 func (s *SQLQuerier) SendBundles(ctx context.Context, stream registry.BundleSender) error {
-	rows, err := s.db.QueryContext(ctx, listBundlesQuery, sql.Named("omit_manifests", s.omitManifests))
+	return s.sendBundles(ctx, false, stream.Send)
+}
+
+// SendBundleMetadata sends the bundles like SendBundles does, without reading
+// their manifests from the database.
+func (s *SQLQuerier) SendBundleMetadata(ctx context.Context, stream registry.BundleMetadataSender) error {
+	return s.sendBundles(ctx, true, func(b *api.Bundle) error {
+		return stream.Send(api.ConvertBundleToBundleMetadata(b))
+	})
+}
+
+// ListDeprecations returns no deprecations: sqlite databases do not store the
+// olm.deprecations blobs of file-based catalogs. Bundles deprecated with
+// olm.deprecated are not listed, since they are removed from their channels.
+func (s *SQLQuerier) ListDeprecations(ctx context.Context, pkgName string) ([]*api.DeprecationEntry, error) {
+	return nil, nil
+}
+
+// SendBundlesPage sends the bundles like SendBundles does, one page at a time.
+func (s *SQLQuerier) SendBundlesPage(ctx context.Context, stream registry.BundleSender, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("invalid page size %d: must be positive", limit)
+	}
+	return s.sendBundlesPage(ctx, false, nil, after, limit, stream.Send)
+}
+
+// SendFilteredBundles sends the bundles that match filter like SendBundles
+// does, or like SendBundlesPage does if limit is positive. Only the rows of
+// the packages and channels of filter are read from the database.
+func (s *SQLQuerier) SendFilteredBundles(ctx context.Context, stream registry.BundleSender, filter registry.BundleFilter, after *registry.BundleCursor, limit int) (*registry.BundleCursor, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("invalid page size %d: must not be negative", limit)
+	}
+	return s.sendBundlesPage(ctx, false, &filter, after, limit, stream.Send)
+}
+
+func (s *SQLQuerier) sendBundles(ctx context.Context, omitAllManifests bool, send func(*api.Bundle) error) error {
+	_, err := s.sendBundlesPage(ctx, omitAllManifests, nil, nil, 0, send)
+	return err
+}
+
+// sendBundlesPage sends the bundles that match filter, if it is set, and that
+// follow after, at most limit of them if limit is positive, and returns the
+// cursor of the last one if more follow. Rows that are not sent, e.g. those
+// without a bundle path or without the properties of filter, still count
+// towards the limit, so a page may hold fewer bundles than limit.
+func (s *SQLQuerier) sendBundlesPage(ctx context.Context, omitAllManifests bool, filter *registry.BundleFilter, after *registry.BundleCursor, limit int, send func(*api.Bundle) error) (*registry.BundleCursor, error) {
+	args := []any{
+		sql.Named("omit_manifests", s.omitManifests),
+		sql.Named("omit_all_manifests", omitAllManifests),
+		sql.Named("packages", s.packages),
+		sql.Named("after_package", nil),
+		sql.Named("after_channel", nil),
+		sql.Named("after_bundle", nil),
+		sql.Named("filter_packages", nil),
+		sql.Named("filter_channels", nil),
+	}
+	if after != nil {
+		args[3] = sql.Named("after_package", after.PackageName)
+		args[4] = sql.Named("after_channel", after.ChannelName)
+		args[5] = sql.Named("after_bundle", after.CsvName)
+	}
+	if filter != nil {
+		args[6] = sql.Named("filter_packages", jsonArray(filter.Packages))
+		args[7] = sql.Named("filter_channels", jsonArray(filter.Channels))
+	}
+	query := listBundlesQuery
+	if after != nil || limit > 0 {
+		query = listBundlesPageQuery
+		// A negative limit is no limit. One more row than the limit is
+		// queried to find whether more rows follow the page.
+		pageLimit := -1
+		if limit > 0 {
+			pageLimit = limit + 1
+		}
+		args = append(args, sql.Named("limit", pageLimit))
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var (
+		cursor *registry.BundleCursor
+		count  int
+	)
 	for rows.Next() {
 		var (
 			entryID     sql.NullInt64
@@ -1016,8 +1148,14 @@ func (s *SQLQuerier) SendBundles(ctx context.Context, stream registry.BundleSend
 			props       sql.NullString
 		)
 		if err := rows.Scan(&entryID, &bundle, &bundlePath, &bundleName, &pkgName, &channelName, &replaces, &skips, &version, &skipRange, &deps, &props); err != nil {
-			return err
+			return nil, err
+		}
+
+		count++
+		if limit > 0 && count > limit {
+			return cursor, nil
 		}
+		cursor = &registry.BundleCursor{PackageName: pkgName.String, ChannelName: channelName.String, CsvName: bundleName.String}
 
 		if !bundleName.Valid || !version.Valid || !bundlePath.Valid || !channelName.Valid {
 			continue
@@ -1027,7 +1165,7 @@ func (s *SQLQuerier) SendBundles(ctx context.Context, stream registry.BundleSend
 		if bundle.Valid && bundle.String != "" {
 			out, err = registry.BundleStringToAPIBundle(bundle.String)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		out.CsvName = bundleName.String
@@ -1044,25 +1182,34 @@ func (s *SQLQuerier) SendBundles(ctx context.Context, stream registry.BundleSend
 
 		if deps.Valid {
 			if err := json.Unmarshal([]byte(deps.String), &out.Dependencies); err != nil {
-				return err
+				return nil, err
 			}
 		}
-		buildLegacyRequiredAPIs(out.Dependencies, &out.RequiredApis)
-		out.Dependencies = uniqueDeps(out.Dependencies)
-
 		if props.Valid {
 			if err := json.Unmarshal([]byte(props.String), &out.Properties); err != nil {
-				return err
+				return nil, err
+			}
+		}
+
+		for _, p := range out.Properties {
+			if p.Type == registry.ConstraintType {
+				out.Dependencies = append(out.Dependencies, &api.Dependency{Type: p.Type, Value: p.Value})
 			}
 		}
+		buildLegacyRequiredAPIs(out.Dependencies, &out.RequiredApis)
+		out.Dependencies = uniqueDeps(out.Dependencies)
+
 		buildLegacyProvidedAPIs(out.Properties, &out.ProvidedApis)
 		out.Properties = uniqueProps(out.Properties)
-		if err := stream.Send(out); err != nil {
-			return err
+		if filter != nil && !filter.MatchesProperties(out.Properties) {
+			continue
+		}
+		if err := send(out); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
 type sliceBundleSender []*api.Bundle
@@ -1151,12 +1298,20 @@ func uniqueProps(props []*api.Property) []*api.Property {
 }
 
 func (s *SQLQuerier) GetDependenciesForBundle(ctx context.Context, name, version, path string) (dependencies []*api.Dependency, err error) {
+	// olm.constraint properties are dependencies too, whether they were
+	// declared in the bundle's dependencies or its properties.
 	depQuery := `SELECT DISTINCT type, value FROM dependencies
 	WHERE operatorbundle_name=?
 	AND (operatorbundle_version=? OR operatorbundle_version is NULL)
+	AND (operatorbundle_path=? OR operatorbundle_path is NULL)
+	UNION
+	SELECT type, value FROM properties
+	WHERE type=?
+	AND operatorbundle_name=?
+	AND (operatorbundle_version=? OR operatorbundle_version is NULL)
 	AND (operatorbundle_path=? OR operatorbundle_path is NULL)`
 
-	rows, err := s.db.QueryContext(ctx, depQuery, name, version, path)
+	rows, err := s.db.QueryContext(ctx, depQuery, name, version, path, registry.ConstraintType, name, version, path)
 	if err != nil {
 		return nil, err
 	}
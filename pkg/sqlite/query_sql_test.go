@@ -12,10 +12,11 @@ import (
 
 func TestListBundlesQuery(t *testing.T) {
 	for _, tt := range []struct {
-		Name         string
-		Setup        func(t *testing.T, db *sql.DB)
-		Expect       func(t *testing.T, rows *sql.Rows)
-		OmitManfests bool
+		Name             string
+		Setup            func(t *testing.T, db *sql.DB)
+		Expect           func(t *testing.T, rows *sql.Rows)
+		OmitManfests     bool
+		OmitAllManifests bool
 	}{
 		{
 			Name: "replacement comes from channel entry",
@@ -155,6 +156,34 @@ func TestListBundlesQuery(t *testing.T) {
 				require.False(rows.Next())
 			},
 		},
+		{
+			Name:             "all manifests omitted without bundlepath",
+			OmitAllManifests: true,
+			Setup: func(t *testing.T, db *sql.DB) {
+				for _, stmt := range []string{
+					`insert into package (name, default_channel) values ("package", "channel")`,
+					`insert into channel (name, package_name, head_operatorbundle_name) values ("channel", "package", "bundle")`,
+					`insert into operatorbundle (name, bundle) values ("bundle-a", "{}")`,
+					`insert into channel_entry (package_name, channel_name, operatorbundle_name, entry_id, depth) values ("package", "channel", "bundle-a", 1, 0)`,
+				} {
+					if _, err := db.Exec(stmt); err != nil {
+						t.Fatalf("unexpected error executing setup statements: %v", err)
+					}
+				}
+
+			},
+			Expect: func(t *testing.T, rows *sql.Rows) {
+				require := require.New(t)
+				require.True(rows.Next())
+				var (
+					c      interface{}
+					bundle sql.NullString
+				)
+				require.NoError(rows.Scan(&c, &bundle, &c, &c, &c, &c, &c, &c, &c, &c, &c, &c))
+				require.Equal(sql.NullString{Valid: false, String: ""}, bundle)
+				require.False(rows.Next())
+			},
+		},
 		{
 			Name:         "properties and depdendencies columns may be stored as sqlite type blob",
 			OmitManfests: true,
@@ -231,7 +260,7 @@ func TestListBundlesQuery(t *testing.T) {
 			_, err = db.Exec("PRAGMA foreign_keys = ON")
 			require.NoError(t, err)
 
-			rows, err := db.QueryContext(ctx, listBundlesQuery, sql.Named("omit_manifests", tt.OmitManfests))
+			rows, err := db.QueryContext(ctx, listBundlesQuery, sql.Named("omit_manifests", tt.OmitManfests), sql.Named("omit_all_manifests", tt.OmitAllManifests), sql.Named("packages", nil), sql.Named("after_package", nil), sql.Named("after_channel", nil), sql.Named("after_bundle", nil), sql.Named("filter_packages", nil), sql.Named("filter_channels", nil))
 			if err != nil {
 				t.Fatalf("unexpected error executing list bundles query: %v", err)
 			}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/operator-framework/operator-registry/pkg/lib/tmp"
+)
+
+// ServeOptions are the options of the commands that serve a db, as set by
+// the --skip-migrate, --read-only, --immutable, --sqlite-mmap-size and
+// --sqlite-cache-size flags.
+type ServeOptions struct {
+	// SkipMigrate serves the db as is, instead of migrated to the latest
+	// version.
+	SkipMigrate bool
+	// ReadOnly serves the db in place, without copying it, if it does not
+	// need to be migrated.
+	ReadOnly bool
+	// Immutable, MmapSize and CacheSize configure the connections to a db
+	// that is served in place, see WithImmutable, WithMmapSize and
+	// WithCacheSize.
+	Immutable bool
+	MmapSize  int64
+	CacheSize int64
+}
+
+// AddFlags adds the flags that set o to fs.
+func (o *ServeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.SkipMigrate, "skip-migrate", false, "do  not attempt to migrate to the latest db revision when starting")
+	fs.BoolVar(&o.ReadOnly, "read-only", false, "serve the db in place, without copying it, if it does not need to be migrated")
+	fs.BoolVar(&o.Immutable, "immutable", true, "with --read-only, open the db as immutable, which is faster but requires that the db file is never changed while it is served")
+	fs.Int64Var(&o.MmapSize, "sqlite-mmap-size", 0, "with --read-only, the maximum number of bytes of the db to read with memory-mapped I/O (PRAGMA mmap_size), 0 for the sqlite default")
+	fs.Int64Var(&o.CacheSize, "sqlite-cache-size", 0, "with --read-only, the page cache size of each db connection (PRAGMA cache_size), in pages if positive or in KiB if negative, 0 for the sqlite default")
+}
+
+// OpenForServing opens the db at fileName to serve, migrated to the latest
+// version unless o.SkipMigrate is set. With o.ReadOnly, a db that does not
+// need to be migrated is opened in place, without the time and disk space of
+// a copy. Otherwise, the db is copied to a temporary file, so that the
+// original is never changed, and the returned function removes the copy.
+func (o ServeOptions) OpenForServing(ctx context.Context, fileName string, logger *logrus.Entry) (*sql.DB, func(), error) {
+	if o.ReadOnly {
+		db, err := OpenReadOnly(fileName, WithImmutable(o.Immutable), WithMmapSize(o.MmapSize), WithCacheSize(o.CacheSize))
+		if err != nil {
+			return nil, nil, err
+		}
+		needsMigration := false
+		if !o.SkipMigrate {
+			needsMigration, err = NeedsMigration(ctx, db)
+			if err != nil {
+				db.Close()
+				return nil, nil, err
+			}
+		}
+		if !needsMigration {
+			return db, func() {}, nil
+		}
+		logger.Info("db needs to be migrated, serving a migrated copy")
+		if err := db.Close(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// make a writable copy of the db for migrations
+	tmpdb, err := tmp.CopyTmpDB(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.Remove(tmpdb) }
+
+	db, err := Open(tmpdb)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	// migrate to the latest version
+	if !o.SkipMigrate {
+		if err := migrateForServing(ctx, db); err != nil {
+			logger.WithError(err).Warnf("couldn't migrate db")
+		}
+	}
+	return db, cleanup, nil
+}
+
+func migrateForServing(ctx context.Context, db *sql.DB) error {
+	migrator, err := NewSQLLiteMigrator(db)
+	if err != nil {
+		return err
+	}
+	if migrator == nil {
+		return fmt.Errorf("failed to load migrator")
+	}
+	return migrator.Migrate(ctx)
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeOptionsFlags(t *testing.T) {
+	var o ServeOptions
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	o.AddFlags(fs)
+	require.Equal(t, ServeOptions{Immutable: true}, o)
+
+	require.NoError(t, fs.Parse([]string{"--read-only", "--immutable=false", "--sqlite-mmap-size", "1048576", "--sqlite-cache-size=-4096"}))
+	require.Equal(t, ServeOptions{ReadOnly: true, MmapSize: 1 << 20, CacheSize: -4096}, o)
+}
+
+func TestOpenForServing(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.NewEntry(logrus.New())
+
+	newDB := func(t *testing.T, migrated bool) string {
+		dbName := filepath.Join(t.TempDir(), "test.db")
+		db, err := Open(dbName)
+		require.NoError(t, err)
+		defer db.Close()
+		if migrated {
+			migrator, err := NewSQLLiteMigrator(db)
+			require.NoError(t, err)
+			require.NoError(t, migrator.Migrate(ctx))
+		}
+		return dbName
+	}
+
+	tests := []struct {
+		name     string
+		opts     ServeOptions
+		migrated bool
+		// wantInPlace is whether the db is served in place, which is
+		// read-only, rather than a writable copy of it.
+		wantInPlace bool
+	}{
+		{
+			name:     "Copy",
+			migrated: true,
+		},
+		{
+			name:        "ReadOnly",
+			opts:        ServeOptions{ReadOnly: true, Immutable: true},
+			migrated:    true,
+			wantInPlace: true,
+		},
+		{
+			name: "ReadOnlyNeedsMigration",
+			opts: ServeOptions{ReadOnly: true, Immutable: true},
+		},
+		{
+			name:        "ReadOnlySkipMigrate",
+			opts:        ServeOptions{ReadOnly: true, Immutable: true, SkipMigrate: true},
+			wantInPlace: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbName := newDB(t, tt.migrated)
+			db, cleanup, err := tt.opts.OpenForServing(ctx, dbName, logger)
+			require.NoError(t, err)
+			defer cleanup()
+			defer db.Close()
+
+			needsMigration, err := NeedsMigration(ctx, db)
+			require.NoError(t, err)
+			require.Equal(t, tt.opts.SkipMigrate && !tt.migrated, needsMigration)
+
+			_, err = db.ExecContext(ctx, "CREATE TABLE served (id INTEGER)")
+			if tt.wantInPlace {
+				require.ErrorContains(t, err, "readonly database")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}